@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/mackee/tanukirpc"
@@ -103,7 +104,9 @@ func main() {
 	// You can use chi middleware or `func (http.Handler) http.Handler`
 	r.Use(middleware.RequestID)
 
-	r.Post("/accounts", tanukirpc.NewHandler(createAccount))
+	// SQLite allows only one writer at a time, so the writes wait for their turn instead of failing with SQLITE_BUSY
+	w := r.LimitConcurrency(1, tanukirpc.WithConcurrencyQueue(100, 5*time.Second))
+	w.Post("/accounts", tanukirpc.NewHandler(createAccount))
 	r.Get("/account/{id}", tanukirpc.NewHandler(account))
 
 	if err := r.ListenAndServe(ctx, ":8080"); err != nil {
@@ -1,111 +1,143 @@
 // This file was automatically @generated by gentypescript
 
+export interface PingResponse {
+  message: string;
+}
+
+export interface Task {
+  id: string;
+  name: string;
+  description: string;
+  status: "todo" | "doing" | "done";
+  created_at: string;
+  /** Version is sent as the ETag header, and the clients send it back as the If-Match header to update the task. */
+  version: number;
+}
+
+export interface TasksResponse {
+  tasks: Task[];
+}
+
+export interface TaskNewInput {
+  name: string;
+  description?: string;
+}
+
+export interface AddTaskRequest {
+  task: TaskNewInput;
+}
+
+export interface AddTaskResponse {
+  task: Task;
+}
+
+export interface TaskResponse {
+  task: Task;
+}
+
+export interface ChangeTaskRequest {
+  task?: {
+    name?: string;
+    description?: string;
+    status?: "todo" | "doing" | "done";
+  };
+}
+
+export interface RemoveTaskResponse {
+  status: string;
+}
+
 type apiSchemaCollection = {
   "GET /api/ping": {
-    Query: undefined
-    Request: undefined
-    Response: {
-      message: string;
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: undefined;
+    Response: PingResponse;
   };
   "GET /api/tasks": {
-    Query: undefined
-    Request: undefined
-    Response: {
-      tasks: {
-        id: string;
-        name: string;
-        description: string;
-        status: string;
-        created_at: string;
-      }[];
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: undefined;
+    Response: TasksResponse;
   };
   "POST /api/tasks": {
-    Query: undefined
-    Request: {
-      task: {
-        name: string;
-        description?: string;
-      };
-    }
-    Response: {
-      task: {
-        id: string;
-        name: string;
-        description: string;
-        status: string;
-        created_at: string;
-      };
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: AddTaskRequest;
+    Response: AddTaskResponse;
   };
   "GET /api/tasks/{id}": {
-    Query: undefined
-    Request: undefined
-    Response: {
-      task: {
-        id: string;
-        name: string;
-        description: string;
-        status: string;
-        created_at: string;
-      };
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: undefined;
+    Response: TaskResponse;
   };
   "PUT /api/tasks/{id}": {
-    Query: undefined
-    Request: {
-      task?: {
-        name?: string;
-        description?: string;
-        status?: string;
-      };
-    }
-    Response: {
-      task: {
-        id: string;
-        name: string;
-        description: string;
-        status: string;
-        created_at: string;
-      };
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: ChangeTaskRequest;
+    Response: TaskResponse;
   };
   "DELETE /api/tasks/{id}": {
-    Query: undefined
-    Request: undefined
-    Response: {
-      status: string;
-    } | { error: { message: string } }
+    Query: undefined;
+    Request: undefined;
+    Response: RemoveTaskResponse;
   };
-}
+};
+
+export type errorResponse = { error: { message: string } };
 
-export const isErrorResponse = (response: unknown): response is { error: { message: string } } => {
+export const isErrorResponse = (response: unknown): response is errorResponse => {
   return !!((response as { error: unknown })?.error)
+};
+
+export type apiResult<T> =
+  | { ok: true; status: number; data: T }
+  | { ok: false; status: number; error: errorResponse["error"] };
+
+export class ApiError extends Error {
+  readonly status: number;
+  readonly error: errorResponse["error"];
+
+  constructor(status: number, error: errorResponse["error"]) {
+    super(error.message);
+    this.name = "ApiError";
+    this.status = status;
+    this.error = error;
+  }
 }
 
 type method = keyof apiSchemaCollection extends `${infer M} ${string}` ? M : never;
-type methodPathsByMethod<M extends method> = Extract<keyof apiSchemaCollection, `${M} ${string}`>
-type pathByMethod<MP extends string> = MP extends `${method} ${infer P}` ? P : never
-type pathsByMethod<M extends method> = pathByMethod<methodPathsByMethod<M>>
+type methodPathsByMethod<M extends method> = Extract<keyof apiSchemaCollection, `${M} ${string}`>;
+type pathByMethod<MP extends string> = MP extends `${method} ${infer P}` ? P : never;
+type pathsByMethod<M extends method> = pathByMethod<methodPathsByMethod<M>>;
 
 const hasApiRequest = <PM extends keyof apiSchemaCollection>(args: unknown): args is { data: apiSchemaCollection[PM]["Request"] } => {
   return !!(args as { data: unknown })?.data
-}
+};
 
 const hasApiQuery = <PM extends keyof apiSchemaCollection>(args: unknown): args is { query: apiSchemaCollection[PM]["Query"] } => {
   return !!(args as { query: unknown })?.query
-}
+};
 const apiPathBuilder = {
     "/api/tasks/{id}": (args: {id: string}) => `/api/tasks/${args.id}`,
-} as const
+} as const;
 
-const hasApiPathBuilder = (path: string): path is keyof typeof apiPathBuilder => path in apiPathBuilder
-type apiPathBuilderArgs<K extends keyof apiSchemaCollection> = K extends `${method} ${infer P}` ? (P extends keyof typeof apiPathBuilder ? Parameters<typeof apiPathBuilder[P]>[0] : never) : never
+const hasApiPathBuilder = (path: string): path is keyof typeof apiPathBuilder => path in apiPathBuilder;
+type apiPathBuilderArgs<PM extends keyof apiSchemaCollection> =
+	PM extends `${method} ${infer P}`
+		? P extends keyof typeof apiPathBuilder
+			? apiPathBuilderArgsByPath<P>
+			: never
+		: never;
+type apiPathBuilderArgsByPath<K extends keyof typeof apiPathBuilder> =
+	Parameters<(typeof apiPathBuilder)[K]>[0];
+
+const pathBuilderByPath = <P extends keyof typeof apiPathBuilder>(
+	path: P,
+): ((args: apiPathBuilderArgsByPath<P>) => string) => {
+	const builder: unknown = apiPathBuilder[path];
+	return builder as (args: apiPathBuilderArgsByPath<P>) => string;
+};
 
 const hasApiPathArgs = <PM extends keyof apiSchemaCollection>(args: unknown): args is { pathArgs: apiPathBuilderArgs<PM> } => {
   return !!(args as { pathArgs: unknown })?.pathArgs
-}
+};
 
 type pathCallArgs<PM extends keyof apiSchemaCollection> =
   apiSchemaCollection[PM]["Request"] extends undefined
@@ -144,39 +176,126 @@ type pathCallArgs<PM extends keyof apiSchemaCollection> =
           };
 
 type client = {
+  get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiSchemaCollection[`GET ${P}`]["Response"] | errorResponse>
+  post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiSchemaCollection[`POST ${P}`]["Response"] | errorResponse>
+  put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiSchemaCollection[`PUT ${P}`]["Response"] | errorResponse>
+  delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiSchemaCollection[`DELETE ${P}`]["Response"] | errorResponse>
+};
+
+type resultClient = {
+  get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiResult<apiSchemaCollection[`GET ${P}`]["Response"]>>
+  post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiResult<apiSchemaCollection[`POST ${P}`]["Response"]>>
+  put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiResult<apiSchemaCollection[`PUT ${P}`]["Response"]>>
+  delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiResult<apiSchemaCollection[`DELETE ${P}`]["Response"]>>
+};
+
+type throwingClient = {
   get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiSchemaCollection[`GET ${P}`]["Response"]>
   post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiSchemaCollection[`POST ${P}`]["Response"]>
   put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiSchemaCollection[`PUT ${P}`]["Response"]>
   delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiSchemaCollection[`DELETE ${P}`]["Response"]>
-}
+};
 
-export const newClient = (baseURL = ""): client => {
+type myFetcher = (input: string, init: { method: string; headers: Record<string, string>; body: string | undefined; }) => Promise<Response>;
+
+const newRequester = (baseURL: string, myFetch: myFetcher) => async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>): Promise<Response> => {
+  const builtPath = hasApiPathBuilder(path) && hasApiPathArgs(args) ? pathBuilderByPath(path)(args.pathArgs) : path;
+  const query = hasApiQuery(args) ? `?${new URLSearchParams(args.query).toString()}` : "";
+  const body = hasApiRequest(args) ? JSON.stringify(args.data) : undefined;
+  return await myFetch(baseURL + builtPath + query, {
+    method,
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body,
+  });
+};
+
+const newResultFetcher = (baseURL: string, myFetch: myFetcher) => {
+  const request = newRequester(baseURL, myFetch);
+  return async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>): Promise<apiResult<apiSchemaCollection[PM]["Response"]>> => {
+    const response = await request(method, path, args);
+    if (!response.ok) {
+      let error: unknown = undefined;
+      try {
+        error = await response.json();
+      } catch (e) {
+        // the error response is not JSON
+      }
+      return {
+        ok: false,
+        status: response.status,
+        error: isErrorResponse(error) ? error.error : { message: response.statusText },
+      };
+    }
+    const data = (await response.json()) as apiSchemaCollection[PM]["Response"];
+    return { ok: true, status: response.status, data };
+  };
+};
+
+export const newClient = (baseURL = "", myFetch: myFetcher = fetch): client => {
+  const request = newRequester(baseURL, myFetch);
   const fetchByPath = async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>) => {
-    const builtPath = hasApiPathBuilder(path) && hasApiPathArgs(args) ? apiPathBuilder[path](args.pathArgs) : path
-    const query = hasApiQuery(args) ? `?${new URLSearchParams(args.query).toString()}` : ""
-    const body = hasApiRequest(args) ? JSON.stringify(args.data) : undefined
-    const response = await fetch(baseURL + builtPath + query, {
-      method,
-      headers: {
-        "Content-Type": "application/json",
-      },
-      body,
-    })
+    const response = await request(method, path, args);
 
     if (!response.ok) {
-      throw new Error(response.statusText)
+      try {
+        const error = await response.json();
+        return error;
+      } catch (e) {
+        throw new Error(response.statusText);
+      }
     }
-    return response.json() as Promise<apiSchemaCollection[PM]["Response"]>
+    return response.json() as Promise<apiSchemaCollection[PM]["Response"]>;
   }
-  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath("GET", path, args)
-  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath("POST", path, args)
-  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await fetchByPath("PUT", path, args)
-  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await fetchByPath("DELETE", path, args)
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath("GET", path, args);
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath("POST", path, args);
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await fetchByPath("PUT", path, args);
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await fetchByPath("DELETE", path, args);
 
   return {
     get,
     post,
     put,
     delete: _delete,
-  }
-}
+  };
+};
+
+// newResultClient returns the client that resolves to the result union instead of throwing on the non-2xx responses.
+export const newResultClient = (baseURL = "", myFetch: myFetcher = fetch): resultClient => {
+  const fetchByPath = newResultFetcher(baseURL, myFetch);
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath<`GET ${P}`>("GET", path, args);
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath<`POST ${P}`>("POST", path, args);
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await fetchByPath<`PUT ${P}`>("PUT", path, args);
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await fetchByPath<`DELETE ${P}`>("DELETE", path, args);
+
+  return {
+    get,
+    post,
+    put,
+    delete: _delete,
+  };
+};
+
+// newThrowingClient returns the client that resolves to the response data and throws ApiError on the non-2xx responses.
+export const newThrowingClient = (baseURL = "", myFetch: myFetcher = fetch): throwingClient => {
+  const fetchByPath = newResultFetcher(baseURL, myFetch);
+  const unwrap = async <T,>(result: Promise<apiResult<T>>): Promise<T> => {
+    const r = await result;
+    if (!r.ok) {
+      throw new ApiError(r.status, r.error);
+    }
+    return r.data;
+  };
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await unwrap(fetchByPath<`GET ${P}`>("GET", path, args));
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await unwrap(fetchByPath<`POST ${P}`>("POST", path, args));
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await unwrap(fetchByPath<`PUT ${P}`>("PUT", path, args));
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await unwrap(fetchByPath<`DELETE ${P}`>("DELETE", path, args));
+
+  return {
+    get,
+    post,
+    put,
+    delete: _delete,
+  };
+};
@@ -34,6 +34,8 @@ type Task struct {
 	Description string    `json:"description"`
 	Status      Status    `json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
+	// Version is sent as the ETag header, and the clients send it back as the If-Match header to update the task.
+	Version int `json:"version" precondition:"etag"`
 }
 
 type Registry struct {
@@ -122,6 +124,7 @@ func addTaskHandler(ctx tanukirpc.Context[*Registry], req AddTaskRequest) (*AddT
 		Description: req.Task.Description,
 		Status:      StatusTodo,
 		CreatedAt:   time.Now(),
+		Version:     1,
 	}
 	ctx.Registry().db[task.ID] = task
 
@@ -155,7 +158,8 @@ func getTaskHandler(ctx tanukirpc.Context[*RegistryWithTask], _ struct{}) (*Task
 }
 
 type ChangeTaskRequest struct {
-	Task struct {
+	IfMatch *int `json:"-" precondition:"if-match"`
+	Task    struct {
 		Name        *string `json:"name"`
 		Description *string `json:"description"`
 		Status      Status  `json:"status"`
@@ -164,6 +168,9 @@ type ChangeTaskRequest struct {
 
 func changeTaskHandler(ctx tanukirpc.Context[*RegistryWithTask], req ChangeTaskRequest) (*TaskResponse, error) {
 	task := ctx.Registry().task
+	if req.IfMatch != nil && *req.IfMatch != task.Version {
+		return nil, tanukirpc.ErrPreconditionFailed
+	}
 
 	if req.Task.Name != nil {
 		task.Name = *req.Task.Name
@@ -172,6 +179,7 @@ func changeTaskHandler(ctx tanukirpc.Context[*RegistryWithTask], req ChangeTaskR
 		task.Description = *req.Task.Description
 	}
 	task.Status = req.Task.Status
+	task.Version++
 	ctx.Registry().db[task.ID] = task
 
 	return &TaskResponse{Task: task}, nil
@@ -2,8 +2,13 @@ package tanukirpc
 
 import (
 	gocontext "context"
+	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -17,28 +22,199 @@ type WrapResponseWriter interface {
 	BytesWritten() int
 }
 
-type accessLogger struct{}
+type accessLogger struct {
+	keys         map[string]string
+	attrs        []slog.Attr
+	durationUnit time.Duration
+	group        string
+}
+
+// AccessLoggerOption is an option for NewAccessLogger.
+type AccessLoggerOption func(*accessLogger)
+
+// NewAccessLogger returns the built-in AccessLogger that writes the access log as the structured log.
+// The schema of the access log can be customized by the options for the compatibility with existing log pipelines.
+func NewAccessLogger(opts ...AccessLoggerOption) AccessLogger {
+	a := &accessLogger{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithAccessLogKeys renames the keys of the access log. The map is from the default key to the new key.
+// For example, map[string]string{"path": "uri"} writes the path of the request as "uri".
+func WithAccessLogKeys(keys map[string]string) AccessLoggerOption {
+	return func(a *accessLogger) {
+		a.keys = keys
+	}
+}
+
+// WithAccessLogAttrs adds the static attributes to every access log such as the service name.
+func WithAccessLogAttrs(attrs ...slog.Attr) AccessLoggerOption {
+	return func(a *accessLogger) {
+		a.attrs = append(a.attrs, attrs...)
+	}
+}
+
+// WithAccessLogDurationUnit writes the process time as the number in the unit such as time.Millisecond.
+// By default, the process time is written as the string like "1.5ms".
+func WithAccessLogDurationUnit(unit time.Duration) AccessLoggerOption {
+	return func(a *accessLogger) {
+		a.durationUnit = unit
+	}
+}
+
+// WithAccessLogGroup places the attributes of the access log under the slog.Group of the name.
+func WithAccessLogGroup(name string) AccessLoggerOption {
+	return func(a *accessLogger) {
+		a.group = name
+	}
+}
+
+func (a *accessLogger) key(k string) string {
+	if nk, ok := a.keys[k]; ok {
+		return nk
+	}
+	return k
+}
 
 func (a *accessLogger) Log(ctx gocontext.Context, logger *slog.Logger, ww WrapResponseWriter, req *http.Request, err error, t1 time.Time, t2 time.Time) error {
 	reqHostHeader := req.Header.Get("Host")
 	reqContentType := req.Header.Get("Content-Type")
 	respContentType := ww.Header().Get("Content-Type")
 
-	logger.InfoContext(ctx, "accesslog",
-		slog.String("host", reqHostHeader),
-		slog.String("method", req.Method),
-		slog.String("path", req.URL.String()),
-		slog.String("proto", req.Proto),
-		slog.String("remote", req.RemoteAddr),
-		slog.String("request_content_type", reqContentType),
-		slog.String("response_content_type", respContentType),
-		slog.Int("status", ww.Status()),
-		slog.Int("size", ww.BytesWritten()),
-		slog.String("process_time", t2.Sub(t1).String()),
-		slog.Time("start", t1),
-		slog.Time("end", t2),
-		slog.Bool("error", err != nil),
+	processTime := slog.String(a.key("process_time"), t2.Sub(t1).String())
+	if a.durationUnit > 0 {
+		processTime = slog.Float64(a.key("process_time"), float64(t2.Sub(t1))/float64(a.durationUnit))
+	}
+
+	attrs := make([]any, 0, 14+len(a.attrs))
+	attrs = append(attrs,
+		slog.String(a.key("host"), reqHostHeader),
+		slog.String(a.key("method"), req.Method),
+		slog.String(a.key("path"), redactedRequestURI(ctx, req.URL.String())),
+		slog.String(a.key("proto"), req.Proto),
+		slog.String(a.key("remote"), req.RemoteAddr),
+		slog.String(a.key("client_ip"), ClientIP(ctx)),
+		slog.String(a.key("request_content_type"), reqContentType),
+		slog.String(a.key("response_content_type"), respContentType),
+		slog.Int(a.key("status"), ww.Status()),
+		slog.Int(a.key("size"), ww.BytesWritten()),
+		processTime,
+		slog.Time(a.key("start"), t1),
+		slog.Time(a.key("end"), t2),
+		slog.Bool(a.key("error"), err != nil),
+	)
+	if ab, ok := AuditBodyFromContext(ctx); ok {
+		attrs = append(attrs,
+			slog.String(a.key("request_body"), string(ab.Request)),
+			slog.String(a.key("response_body"), string(ab.Response)),
+		)
+	}
+	if p, ok := AccessLogPayloadFromContext(ctx); ok {
+		attrs = append(attrs,
+			slog.String(a.key("request_payload"), string(p.RequestJSON)),
+			slog.String(a.key("response_payload"), string(p.ResponseJSON)),
+		)
+	}
+	for _, attr := range a.attrs {
+		attrs = append(attrs, attr)
+	}
+	if a.group != "" {
+		attrs = []any{slog.Group(a.group, attrs...)}
+	}
+
+	logger.InfoContext(ctx, "accesslog", attrs...)
+
+	return nil
+}
+
+type combinedAccessLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewCombinedAccessLogger returns the AccessLogger that writes the access log in the Apache combined log format to w.
+// The logger of the Router is not used by this AccessLogger.
+func NewCombinedAccessLogger(w io.Writer) AccessLogger {
+	return &combinedAccessLogger{w: w}
+}
+
+func (c *combinedAccessLogger) Log(ctx gocontext.Context, logger *slog.Logger, ww WrapResponseWriter, req *http.Request, err error, t1 time.Time, t2 time.Time) error {
+	host := ClientIP(ctx)
+	if host == "" {
+		host = remoteHost(req.RemoteAddr)
+	}
+	user := "-"
+	if req.URL.User != nil {
+		if name := req.URL.User.Username(); name != "" {
+			user = name
+		}
+	}
+	size := "-"
+	if n := ww.BytesWritten(); n > 0 {
+		size = strconv.Itoa(n)
+	}
+	line := fmt.Sprintf("%s - %s [%s] %q %d %s %q %q\n",
+		host,
+		user,
+		t1.Format("02/Jan/2006:15:04:05 -0700"),
+		req.Method+" "+redactedRequestURI(req.Context(), req.RequestURI)+" "+req.Proto,
+		ww.Status(),
+		size,
+		combinedLogValue(req.Referer()),
+		combinedLogValue(req.UserAgent()),
 	)
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := io.WriteString(c.w, line); err != nil {
+		return fmt.Errorf("failed to write access log: %w", err)
+	}
 	return nil
 }
+
+func combinedLogValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// AccessLogFilter reports whether the access log of the request should be written.
+// status is the status code of the response.
+type AccessLogFilter func(req *http.Request, status int) bool
+
+func (r *Router[Reg]) shouldAccessLog(req *http.Request, status int) bool {
+	for _, filter := range r.accessLogFilters {
+		if !filter(req, status) {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLogSampling returns an AccessLogFilter that writes the access logs of the successful responses at the rate.
+// The rate is a value between 0 and 1. The responses with status code 400 or above are always written.
+func AccessLogSampling(rate float64) AccessLogFilter {
+	return func(req *http.Request, status int) bool {
+		if status >= http.StatusBadRequest {
+			return true
+		}
+		return rate >= 1 || rand.Float64() < rate
+	}
+}
+
+// AccessLogExcludePaths returns an AccessLogFilter that skips the access logs of the paths such as health checks.
+// The paths are compared with the path of the request URL exactly.
+func AccessLogExcludePaths(paths ...string) AccessLogFilter {
+	excludes := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		excludes[p] = struct{}{}
+	}
+	return func(req *http.Request, status int) bool {
+		_, ok := excludes[req.URL.Path]
+		return !ok
+	}
+}
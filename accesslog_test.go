@@ -0,0 +1,272 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordAccessLogger struct {
+	paths []string
+}
+
+func (r *recordAccessLogger) Log(ctx context.Context, logger *slog.Logger, ww tanukirpc.WrapResponseWriter, req *http.Request, err error, t1 time.Time, t2 time.Time) error {
+	r.paths = append(r.paths, req.URL.Path)
+	return nil
+}
+
+func TestAccessLogFilter(t *testing.T) {
+	al := &recordAccessLogger{}
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](al),
+		tanukirpc.WithAccessLogFilter[struct{}](
+			tanukirpc.AccessLogExcludePaths("/healthz"),
+			tanukirpc.AccessLogSampling(0),
+		),
+	)
+	ok := func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}
+	ng := func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, errors.New("failed")
+	}
+	router.Get("/healthz", tanukirpc.NewHandler(ok))
+	router.Get("/ok", tanukirpc.NewHandler(ok))
+	router.Get("/ng", tanukirpc.NewHandler(ng))
+
+	for _, path := range []string{"/healthz", "/ok", "/ng"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []string{"/ng"}, al.paths)
+}
+
+func TestAccessLoggerSchema(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithLogger[struct{}](logger),
+		tanukirpc.WithAccessLogger[struct{}](tanukirpc.NewAccessLogger(
+			tanukirpc.WithAccessLogKeys(map[string]string{"path": "uri"}),
+			tanukirpc.WithAccessLogAttrs(slog.String("service", "tanuki")),
+			tanukirpc.WithAccessLogDurationUnit(time.Millisecond),
+			tanukirpc.WithAccessLogGroup("http"),
+		)),
+	)
+	router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	var record struct {
+		HTTP map[string]any `json:"http"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "/ping", record.HTTP["uri"])
+	assert.Equal(t, "tanuki", record.HTTP["service"])
+	assert.IsType(t, float64(0), record.HTTP["process_time"])
+	assert.NotContains(t, record.HTTP, "path")
+}
+
+func TestCombinedAccessLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](tanukirpc.NewCombinedAccessLogger(buf)),
+	)
+	router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "tanuki-agent")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[[^\]]+\] "GET /ping HTTP/1\.1" 200 \d+ "-" "tanuki-agent"\n$`, buf.String())
+}
+
+func TestAddLogAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := tanukirpc.NewLogger(slog.New(slog.NewJSONHandler(buf, nil)), nil)
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](logger))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tanukirpc.AddLogAttr(req.Context(), slog.String("user_id", "tanuki"))
+			next.ServeHTTP(w, req)
+		})
+	})
+	router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		tanukirpc.AddLogAttr(ctx, slog.Int("items", 3))
+		return struct{}{}, nil
+	}))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "accesslog", record["msg"])
+	assert.Equal(t, "tanuki", record["user_id"])
+	assert.Equal(t, float64(3), record["items"])
+}
+
+type auditLoginRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password" log:"-"`
+}
+
+type auditLoginResponse struct {
+	Name  string `json:"name"`
+	Token string `json:"token" log:"-"`
+}
+
+func TestAuditBody(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	var hooked tanukirpc.AuditBody
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithLogger[struct{}](logger),
+		tanukirpc.WithAuditBody[struct{}](64),
+	)
+	router.Post("/login", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *auditLoginRequest) (*auditLoginResponse, error) {
+		ctx.Defer(func() error {
+			hooked, _ = tanukirpc.AuditBodyFromContext(ctx)
+			return nil
+		}, tanukirpc.DeferDoTimingAfterResponse)
+		return &auditLoginResponse{Name: req.Name, Token: "secret-token"}, nil
+	}))
+
+	post := func(body string) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	post(`{"name":"tanuki","password":"p@ssw0rd"}`)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.JSONEq(t, `{"name":"tanuki","password":"[REDACTED]"}`, record["request_body"].(string))
+	assert.JSONEq(t, `{"name":"tanuki","token":"[REDACTED]"}`, record["response_body"].(string))
+	assert.NotContains(t, buf.String(), "p@ssw0rd")
+	assert.NotContains(t, buf.String(), "secret-token")
+	assert.Equal(t, `{"name":"tanuki","token":"[REDACTED]"}`, string(hooked.Response))
+
+	// the truncated body cannot be redacted, so it is dropped
+	post(`{"name":"` + strings.Repeat("x", 64) + `","password":"p@ssw0rd"}`)
+	assert.Nil(t, hooked.Request)
+	assert.True(t, hooked.RequestTruncated)
+	assert.NotContains(t, buf.String(), "p@ssw0rd")
+}
+
+type payloadSearchRequest struct {
+	Query  string `query:"q"`
+	APIKey string `query:"api_key" sensitive:"true"`
+}
+
+type payloadSearchResponse struct {
+	Results []string `json:"results"`
+}
+
+func TestAccessLogPayloads(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	var hooked tanukirpc.AccessLogPayload
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithLogger[struct{}](logger),
+		tanukirpc.WithAccessLogPayloads[struct{}](64, nil),
+	)
+	router.Get("/search", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req payloadSearchRequest) (*payloadSearchResponse, error) {
+		ctx.Defer(func() error {
+			hooked, _ = tanukirpc.AccessLogPayloadFromContext(ctx)
+			return nil
+		}, tanukirpc.DeferDoTimingAfterResponse)
+		if req.Query == "" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusBadRequest, errors.New("q is required"))
+		}
+		res := &payloadSearchResponse{}
+		for i := 0; i < 10; i++ {
+			res.Results = append(res.Results, req.Query)
+		}
+		return res, nil
+	}))
+
+	get := func(target string) map[string]any {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		return record
+	}
+
+	record := get("/search?q=tanuki&api_key=k3y")
+	assert.JSONEq(t, `{"Query":"tanuki","APIKey":"[REDACTED]"}`, record["request_payload"].(string))
+	assert.True(t, hooked.ResponseTruncated)
+	assert.Len(t, hooked.ResponseJSON, 64)
+	assert.Equal(t, string(hooked.ResponseJSON), record["response_payload"])
+	assert.NotContains(t, buf.String(), "k3y")
+
+	record = get("/search?api_key=k3y")
+	assert.JSONEq(t, `{"Query":"","APIKey":"[REDACTED]"}`, record["request_payload"].(string))
+	assert.Equal(t, "", record["response_payload"])
+
+	t.Run("redactor", func(t *testing.T) {
+		buf.Reset()
+		router := tanukirpc.NewRouter(struct{}{},
+			tanukirpc.WithLogger[struct{}](logger),
+			tanukirpc.WithAccessLogPayloads[struct{}](1024, func(v any) any {
+				if res, ok := v.(*auditLoginResponse); ok {
+					return map[string]string{"name": res.Name}
+				}
+				return tanukirpc.Redact(v)
+			}),
+		)
+		router.Post("/login", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *auditLoginRequest) (*auditLoginResponse, error) {
+			return &auditLoginResponse{Name: req.Name, Token: "secret-token"}, nil
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"name":"tanuki","password":"p@ssw0rd"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.JSONEq(t, `{"name":"tanuki","password":"[REDACTED]"}`, record["request_payload"].(string))
+		assert.JSONEq(t, `{"name":"tanuki"}`, record["response_payload"].(string))
+		assert.NotContains(t, buf.String(), "p@ssw0rd")
+		assert.NotContains(t, buf.String(), "secret-token")
+	})
+	t.Run("no limit", func(t *testing.T) {
+		for _, maxBytes := range []int{0, -1} {
+			buf.Reset()
+			router := tanukirpc.NewRouter(struct{}{},
+				tanukirpc.WithLogger[struct{}](logger),
+				tanukirpc.WithAccessLogPayloads[struct{}](maxBytes, nil),
+			)
+			router.Get("/search", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req payloadSearchRequest) (*payloadSearchResponse, error) {
+				return &payloadSearchResponse{Results: []string{req.Query}}, nil
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/search?q=tanuki", nil)
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, maxBytes)
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.JSONEq(t, `{"results":["tanuki"]}`, record["response_payload"].(string), maxBytes)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// PayloadRedactor returns the value that is logged in place of v, the decoded request or the typed response of the handler.
+// Redact is the PayloadRedactor that honors the struct tags of the redacted fields.
+type PayloadRedactor func(v any) any
+
+// AccessLogPayload is the decoded request and the typed response of the handler for the access logs.
+// The values are passed through the PayloadRedactor of WithAccessLogPayloads.
+type AccessLogPayload struct {
+	// Request is the decoded request that is passed through the redactor.
+	Request any
+	// RequestJSON is Request encoded as JSON and truncated to the limit.
+	RequestJSON []byte
+	// RequestTruncated reports whether RequestJSON is truncated.
+	RequestTruncated bool
+	// Response is the response of the handler that is passed through the redactor. It is nil if the handler fails.
+	Response any
+	// ResponseJSON is Response encoded as JSON and truncated to the limit.
+	ResponseJSON []byte
+	// ResponseTruncated reports whether ResponseJSON is truncated.
+	ResponseTruncated bool
+}
+
+// WithAccessLogPayloads keeps the decoded requests and the typed responses of the handlers for the access logs of the debugging environments.
+// They are available by AccessLogPayloadFromContext in the AccessLogger and the ErrorHooker, and the built-in AccessLogger writes them
+// as "request_payload" and "response_payload" encoded as JSON up to maxBytes each. The payloads are not truncated if maxBytes is zero or negative.
+// redactor replaces the values before they are kept, and Redact is used if it is nil.
+// The streaming responses like io.Reader and iter.Seq are not kept.
+//
+// Unlike WithAuditBody, the payloads are taken from the handler pipeline rather than the bodies on the wire,
+// so the requests of the URL parameters, the queries and the forms are logged as well.
+func WithAccessLogPayloads[Reg any](maxBytes int, redactor PayloadRedactor) RouterOption[Reg] {
+	if redactor == nil {
+		redactor = Redact
+	}
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.accessLogPayloads = &accessLogPayloads{limit: maxBytes, redactor: redactor}
+		return r
+	}
+}
+
+type accessLogPayloadKey struct{}
+
+type accessLogPayloads struct {
+	limit    int
+	redactor PayloadRedactor
+}
+
+type accessLogPayloadRecord struct {
+	mu      sync.Mutex
+	cfg     *accessLogPayloads
+	payload AccessLogPayload
+}
+
+// AccessLogPayloadFromContext returns the AccessLogPayload of the request. It returns false if WithAccessLogPayloads is not set.
+func AccessLogPayloadFromContext(ctx gocontext.Context) (AccessLogPayload, bool) {
+	rec, ok := ctx.Value(accessLogPayloadKey{}).(*accessLogPayloadRecord)
+	if !ok {
+		return AccessLogPayload{}, false
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.payload, true
+}
+
+// withRecord attaches the record of the payloads to the request. It returns the nil record if the payloads are not kept.
+func (a *accessLogPayloads) withRecord(req *http.Request) (*http.Request, *accessLogPayloadRecord) {
+	if a == nil {
+		return req, nil
+	}
+	rec := &accessLogPayloadRecord{cfg: a}
+	return req.WithContext(gocontext.WithValue(req.Context(), accessLogPayloadKey{}, rec)), rec
+}
+
+func (rec *accessLogPayloadRecord) recordRequest(v any) {
+	if rec == nil || isRawResponse(v) {
+		return
+	}
+	rv, body, truncated := rec.render(v)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.payload.Request = rv
+	rec.payload.RequestJSON = body
+	rec.payload.RequestTruncated = truncated
+}
+
+func (rec *accessLogPayloadRecord) recordResponse(v any) {
+	if rec == nil || isRawResponse(v) || isSeqResponse(v) {
+		return
+	}
+	rv, body, truncated := rec.render(v)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.payload.Response = rv
+	rec.payload.ResponseJSON = body
+	rec.payload.ResponseTruncated = truncated
+}
+
+// render redacts v and encodes it as JSON up to the limit. The body is nil if it can not be encoded.
+func (rec *accessLogPayloadRecord) render(v any) (any, []byte, bool) {
+	rv := rec.cfg.redactor(v)
+	body, err := json.Marshal(rv)
+	if err != nil {
+		return rv, nil, false
+	}
+	if rec.cfg.limit > 0 && len(body) > rec.cfg.limit {
+		return rv, body[:rec.cfg.limit], true
+	}
+	return rv, body, false
+}
@@ -0,0 +1,117 @@
+package tanukirpc
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RouteAnnotations is the annotations of the route for the tooling like the generated clients and the documents.
+type RouteAnnotations struct {
+	// Name is the name of the operation of the route like "listTasks".
+	Name string
+	// Tags are the tags to group the routes like "tasks".
+	Tags []string
+	// Scopes are the scopes that the route requires like "tasks:read".
+	// They are documented only, so guard the route by Authorize to enforce them.
+	Scopes []string
+}
+
+type routeAnnotationConfig struct {
+	annotations RouteAnnotations
+	deprecation *Deprecation
+}
+
+// RouteAnnotationOption is an option of Annotate.
+type RouteAnnotationOption func(*routeAnnotationConfig)
+
+// WithRouteName sets the name of the operation of the route.
+// The generated clients and the OpenAPI document use it instead of the name derived from the method and the path.
+func WithRouteName(name string) RouteAnnotationOption {
+	return func(c *routeAnnotationConfig) {
+		c.annotations.Name = name
+	}
+}
+
+// WithRouteTags adds the tags of the route. The routes are grouped by the tags in the generated clients and the OpenAPI document.
+func WithRouteTags(tags ...string) RouteAnnotationOption {
+	return func(c *routeAnnotationConfig) {
+		for _, tag := range tags {
+			if !slices.Contains(c.annotations.Tags, tag) {
+				c.annotations.Tags = append(c.annotations.Tags, tag)
+			}
+		}
+	}
+}
+
+// WithRouteScopes adds the scopes that the route requires.
+func WithRouteScopes(scopes ...string) RouteAnnotationOption {
+	return func(c *routeAnnotationConfig) {
+		for _, scope := range scopes {
+			if !slices.Contains(c.annotations.Scopes, scope) {
+				c.annotations.Scopes = append(c.annotations.Scopes, scope)
+			}
+		}
+	}
+}
+
+// WithRouteDeprecation marks the route as deprecated like Deprecated.
+func WithRouteDeprecation(sunset time.Time, link string) RouteAnnotationOption {
+	return func(c *routeAnnotationConfig) {
+		c.deprecation = &Deprecation{Sunset: sunset, Link: link}
+	}
+}
+
+// Annotate annotates the handler with the name, the tags, the required scopes and the deprecation of the route.
+// The annotations are in HandlerMetadata of Router.Routes, and genclient uses them to name and group the operations.
+// The annotations of the nested Annotate are merged, and the name of the outer one is used.
+//
+//	r.Get("/tasks", tanukirpc.Annotate(tanukirpc.NewHandler(listTasks),
+//		tanukirpc.WithRouteName("listTasks"),
+//		tanukirpc.WithRouteTags("tasks"),
+//		tanukirpc.WithRouteScopes("tasks:read"),
+//	))
+func Annotate[Reg any](h Handler[Reg], opts ...RouteAnnotationOption) Handler[Reg] {
+	if h == nil {
+		return nil
+	}
+	cfg := &routeAnnotationConfig{}
+	m := h.Metadata()
+	if m != nil && m.Annotations != nil {
+		cfg.annotations = RouteAnnotations{
+			Name:   m.Annotations.Name,
+			Tags:   slices.Clone(m.Annotations.Tags),
+			Scopes: slices.Clone(m.Annotations.Scopes),
+		}
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.deprecation != nil {
+		h = Deprecated(h, cfg.deprecation.Sunset, cfg.deprecation.Link)
+	}
+	var meta *HandlerMetadata
+	if m := h.Metadata(); m != nil {
+		mc := *m
+		mc.Annotations = &cfg.annotations
+		meta = &mc
+	}
+	return &annotatedHandler[Reg]{handler: h, meta: meta}
+}
+
+type annotatedHandler[Reg any] struct {
+	handler Handler[Reg]
+	meta    *HandlerMetadata
+}
+
+func (h *annotatedHandler[Reg]) Metadata() *HandlerMetadata {
+	return h.meta
+}
+
+func (h *annotatedHandler[Reg]) isNil() bool {
+	return isNilHandler(h.handler)
+}
+
+func (h *annotatedHandler[Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	return h.handler.build(r)
+}
@@ -0,0 +1,166 @@
+// Package audit records the structured events of the mutations, like "who changed what", for the audit trails.
+//
+// Middleware attaches the recorder to the requests, and the handlers and the transformers call Record with the Context:
+//
+//	r := tanukirpc.NewRouter(reg)
+//	r.Use(audit.Middleware(audit.NewSlogSink(logger), audit.WithActor(actorOf)))
+//
+//	func createTask(ctx tanukirpc.Context[*registry], req *createTaskRequest) (*createTaskResponse, error) {
+//		...
+//		audit.Record(ctx, audit.Event{Action: "task.create", Resource: "task", ResourceID: id})
+//		return &createTaskResponse{ID: id}, nil
+//	}
+//
+// The events are written to the Sink after the successful response by DeferDoTimingAfterResponse of the Context,
+// and they are discarded when the request fails, so the events of the rolled back mutations are not written.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/internal/requestid"
+)
+
+// Event is the event of the mutation.
+type Event struct {
+	// Action is the name of the mutation like "task.create".
+	Action string `json:"action"`
+	// Resource is the kind of the mutated resource like "task".
+	Resource string `json:"resource,omitempty"`
+	// ResourceID is the identifier of the mutated resource.
+	ResourceID string `json:"resource_id,omitempty"`
+	// Actor is the identifier of the caller. It is filled by the function of WithActor if it is empty.
+	Actor string `json:"actor,omitempty"`
+	// RequestID is the ID of the request. It is filled automatically if it is empty.
+	RequestID string `json:"request_id,omitempty"`
+	// Time is the time when the event is recorded. It is filled automatically if it is zero.
+	Time time.Time `json:"time"`
+	// Attrs is the additional attributes of the event like the changed fields.
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// Sink writes the events of the request.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// SinkFunc is an adapter to allow the use of ordinary functions as Sink, for example, to insert the events to the database or to publish them to the queue.
+type SinkFunc func(ctx context.Context, events []Event) error
+
+func (f SinkFunc) Write(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// Option is the option of Middleware.
+type Option func(*options)
+
+type options struct {
+	actor func(ctx context.Context) string
+}
+
+// WithActor sets the function that returns the actor of the events, like the ID of the authenticated user.
+// ctx is the context that is passed to Record, so it can be asserted to the Context[Reg] to use the registry.
+func WithActor(fn func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.actor = fn
+	}
+}
+
+type recorderKey struct{}
+
+type recorder struct {
+	mu         sync.Mutex
+	sink       Sink
+	opts       *options
+	events     []Event
+	registered map[context.Context]struct{}
+}
+
+type deferrer interface {
+	Defer(fn tanukirpc.DeferFunc, timings ...tanukirpc.DeferDoTiming)
+}
+
+// Middleware attaches the recorder of the events to the requests, and writes the events to the sink.
+// The events that are recorded in the transformers and are not written by the handlers are written after the successful response.
+func Middleware(sink Sink, opts ...Option) func(http.Handler) http.Handler {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rec := &recorder{sink: sink, opts: o, registered: map[context.Context]struct{}{}}
+			req = req.WithContext(context.WithValue(req.Context(), recorderKey{}, rec))
+			ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+			next.ServeHTTP(ww, req)
+			if status := ww.Status(); status != 0 && status >= http.StatusBadRequest {
+				rec.discard()
+				return
+			}
+			if err := rec.flush(req.Context()); err != nil {
+				// the response has been written, so the error cannot be reported to the caller
+				slog.ErrorContext(req.Context(), "audit events are lost", slog.Any("error", err))
+			}
+		})
+	}
+}
+
+// Record records the event of the request. ctx is the Context of the handler or the transformer.
+// The event is written to the Sink after the successful response. It does nothing if Middleware is not used.
+func Record(ctx context.Context, event Event) {
+	rec, ok := ctx.Value(recorderKey{}).(*recorder)
+	if !ok {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.RequestID == "" {
+		event.RequestID, _ = ctx.Value(requestid.RequestIDKey).(string)
+	}
+	if event.Actor == "" && rec.opts.actor != nil {
+		event.Actor = rec.opts.actor(ctx)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.events = append(rec.events, event)
+	d, ok := ctx.(deferrer)
+	if !ok {
+		return
+	}
+	if _, ok := rec.registered[ctx]; ok {
+		return
+	}
+	rec.registered[ctx] = struct{}{}
+	d.Defer(func() error {
+		return rec.flush(ctx)
+	}, tanukirpc.DeferDoTimingAfterResponse)
+}
+
+func (r *recorder) flush(ctx context.Context) error {
+	r.mu.Lock()
+	events := r.events
+	r.events = nil
+	r.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+	if err := r.sink.Write(ctx, events); err != nil {
+		return fmt.Errorf("failed to write audit events: %w", err)
+	}
+	return nil
+}
+
+func (r *recorder) discard() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
@@ -0,0 +1,132 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	name string
+}
+
+type createTaskRequest struct {
+	Title string `json:"title"`
+}
+
+type recordSink struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (s *recordSink) Write(ctx context.Context, events []audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *recordSink) take() []audit.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func TestRecord(t *testing.T) {
+	sink := &recordSink{}
+	actor := func(ctx context.Context) string {
+		if ctx, ok := ctx.(tanukirpc.Context[*user]); ok {
+			return ctx.Registry().name
+		}
+		return ctx.(tanukirpc.Context[struct{}]).Request().Header.Get("X-User")
+	}
+	auth := tanukirpc.NewTransformer(func(ctx tanukirpc.Context[struct{}]) (*user, error) {
+		name := ctx.Request().Header.Get("X-User")
+		if name == "" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusUnauthorized, errors.New("unauthorized"))
+		}
+		audit.Record(ctx, audit.Event{Action: "session.use"})
+		return &user{name: name}, nil
+	})
+
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Use(audit.Middleware(sink, audit.WithActor(actor)))
+	tanukirpc.RouteWithTransformer(r, auth, "/", func(r *tanukirpc.Router[*user]) {
+		r.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[*user], req *createTaskRequest) (struct{}, error) {
+			if req.Title == "" {
+				audit.Record(ctx, audit.Event{Action: "task.create"})
+				return struct{}{}, tanukirpc.WrapErrorWithStatus(http.StatusBadRequest, errors.New("title is required"))
+			}
+			audit.Record(ctx, audit.Event{Action: "task.create", Resource: "task", ResourceID: "1", Attrs: map[string]any{"title": req.Title}})
+			return struct{}{}, nil
+		}))
+		r.Get("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[*user], req struct{}) (struct{}, error) {
+			return struct{}{}, nil
+		}))
+	})
+
+	do := func(method, body string) int {
+		req := httptest.NewRequest(method, "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-User", "tanuki")
+		req.Header.Set("X-Request-ID", "req-1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(http.MethodPost, `{"title":"buy milk"}`))
+		events := sink.take()
+		require.Len(t, events, 2)
+		assert.Equal(t, "session.use", events[0].Action)
+		assert.Equal(t, "task.create", events[1].Action)
+		assert.Equal(t, "1", events[1].ResourceID)
+		assert.Equal(t, map[string]any{"title": "buy milk"}, events[1].Attrs)
+		for _, e := range events {
+			assert.Equal(t, "tanuki", e.Actor)
+			assert.Equal(t, "req-1", e.RequestID)
+			assert.False(t, e.Time.IsZero())
+		}
+	})
+
+	t.Run("transformer only", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(http.MethodGet, ""))
+		events := sink.take()
+		require.Len(t, events, 1)
+		assert.Equal(t, "session.use", events[0].Action)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, do(http.MethodPost, `{}`))
+		assert.Empty(t, sink.take())
+	})
+}
+
+func TestSlogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := audit.NewSlogSink(slog.New(slog.NewJSONHandler(buf, nil)))
+	err := sink.Write(context.Background(), []audit.Event{{Action: "task.delete", Resource: "task", ResourceID: "1", Actor: "tanuki", Attrs: map[string]any{"reason": "done"}}})
+	require.NoError(t, err)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "audit", record["msg"])
+	assert.Equal(t, "task.delete", record["action"])
+	assert.Equal(t, "tanuki", record["actor"])
+	assert.Equal(t, map[string]any{"reason": "done"}, record["attrs"])
+}
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogSink returns the Sink that writes the events as the structured logs with the message "audit".
+func NewSlogSink(logger *slog.Logger) Sink {
+	return &slogSink{logger: logger}
+}
+
+func (s *slogSink) Write(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		attrs := []slog.Attr{
+			slog.String("action", e.Action),
+			slog.String("resource", e.Resource),
+			slog.String("resource_id", e.ResourceID),
+			slog.String("actor", e.Actor),
+			slog.String("request_id", e.RequestID),
+			slog.Time("time", e.Time),
+		}
+		if len(e.Attrs) > 0 {
+			group := make([]any, 0, len(e.Attrs))
+			for k, v := range e.Attrs {
+				group = append(group, slog.Any(k, v))
+			}
+			attrs = append(attrs, slog.Group("attrs", group...))
+		}
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "audit", attrs...)
+	}
+	return nil
+}
+
+// MultiSink returns the Sink that writes the events to all sinks. It stops at the first error.
+func MultiSink(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, events []Event) error {
+		for _, s := range sinks {
+			if err := s.Write(ctx, events); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,110 @@
+package tanukirpc
+
+import (
+	"bytes"
+	gocontext "context"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// AuditBody is the request body and the response body of the handler that are kept for the audit trails.
+// The redacted fields of the request and the response are replaced with RedactedValue. See Redact for the struct tags.
+// If the body cannot be redacted, for example it is not JSON or it is truncated, and the type has the redacted fields, the body is dropped.
+type AuditBody struct {
+	// Request is the raw request body that is decoded by the codec.
+	Request []byte
+	// RequestTruncated reports whether the request body is longer than the limit and Request is truncated.
+	RequestTruncated bool
+	// Response is the response body that is encoded by the codec.
+	Response []byte
+	// ResponseTruncated reports whether the response body is longer than the limit and Response is truncated.
+	ResponseTruncated bool
+}
+
+// WithAuditBody keeps the request body and the response body of the handlers up to maxBytes each for the audit trails.
+// They are available by AuditBodyFromContext in the AccessLogger, the ErrorHooker and the hooks of the Context,
+// and the built-in AccessLogger writes them as "request_body" and "response_body".
+// The response that is io.Reader is not kept.
+func WithAuditBody[Reg any](maxBytes int) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.auditBodyLimit = maxBytes
+		return r
+	}
+}
+
+type auditBodyKey struct{}
+
+type auditBody struct {
+	mu    sync.Mutex
+	limit int
+	body  AuditBody
+}
+
+// AuditBodyFromContext returns the AuditBody of the request. It returns false if WithAuditBody is not set.
+func AuditBodyFromContext(ctx gocontext.Context) (AuditBody, bool) {
+	ab, ok := ctx.Value(auditBodyKey{}).(*auditBody)
+	if !ok {
+		return AuditBody{}, false
+	}
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	return ab.body, true
+}
+
+// withAuditBody attaches the auditBody to the request, and tees the request body to it.
+// The returned function records the teed request body as the request of type t.
+func withAuditBody(req *http.Request, limit int) (*http.Request, func(t reflect.Type)) {
+	ab := &auditBody{limit: limit}
+	req = req.WithContext(gocontext.WithValue(req.Context(), auditBodyKey{}, ab))
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, func(reflect.Type) {}
+	}
+	buf := &limitedBuffer{limit: limit}
+	req.Body = &teeReadCloser{Reader: io.TeeReader(req.Body, buf), Closer: req.Body}
+	return req, func(t reflect.Type) {
+		ab.mu.Lock()
+		defer ab.mu.Unlock()
+		ab.body.Request = redactBody(buf.buf.Bytes(), t, buf.truncated)
+		ab.body.RequestTruncated = buf.truncated
+	}
+}
+
+// recordAuditResponse records the encoded response body of res if the request has the auditBody.
+func recordAuditResponse(req *http.Request, res any, body []byte) {
+	ab, ok := req.Context().Value(auditBodyKey{}).(*auditBody)
+	if !ok {
+		return
+	}
+	truncated := len(body) > ab.limit
+	if truncated {
+		body = body[:ab.limit]
+	}
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	ab.body.Response = redactBody(bytes.Clone(body), reflect.TypeOf(res), truncated)
+	ab.body.ResponseTruncated = truncated
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedBuffer keeps the written bytes up to the limit, and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if rest := l.limit - l.buf.Len(); len(p) > rest {
+		p = p[:max(rest, 0)]
+		l.truncated = true
+	}
+	l.buf.Write(p)
+	return n, nil
+}
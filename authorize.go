@@ -0,0 +1,134 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ErrForbidden is the error of the requests that are denied by the authorization rules of Authorize.
+// The error of the response is ForbiddenError, which wraps this error.
+var ErrForbidden = errors.New("forbidden")
+
+// PolicyEngine evaluates the named policies of the authorization rules created by Policy.
+// ctx is the Context[Reg] of the route, so the engine can see the principal that the transformers have authenticated.
+// See the casbinauthz package for the adapter of Casbin.
+type PolicyEngine interface {
+	Allow(ctx gocontext.Context, policy string) (bool, error)
+}
+
+// PolicyEngineFunc is an adapter to allow the use of ordinary functions as PolicyEngine.
+type PolicyEngineFunc func(ctx gocontext.Context, policy string) (bool, error)
+
+func (f PolicyEngineFunc) Allow(ctx gocontext.Context, policy string) (bool, error) {
+	return f(ctx, policy)
+}
+
+// ForbiddenError is the error of the requests that are denied by the authorization rules. It is reported with 403 Forbidden.
+type ForbiddenError struct {
+	// Policy is the name of the policy that denies the request. It is empty if the request is denied by the rule of Rule.
+	Policy string
+	err    error
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return ErrForbidden.Error()
+}
+
+func (e *ForbiddenError) Status() int {
+	return http.StatusForbidden
+}
+
+func (e *ForbiddenError) Unwrap() []error {
+	if e.err != nil {
+		return []error{ErrForbidden, e.err}
+	}
+	return []error{ErrForbidden}
+}
+
+// WithPolicyEngine sets the PolicyEngine that evaluates the policies of the authorization rules created by Policy.
+func WithPolicyEngine[Reg any](engine PolicyEngine) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.policyEngine = engine
+		return r
+	}
+}
+
+// AuthorizationRule is the rule of Authorize. Create it by Rule or Policy.
+type AuthorizationRule[Reg any] struct {
+	fn     func(ctx Context[Reg]) error
+	policy string
+}
+
+// Rule returns the AuthorizationRule that denies the request when fn returns the error.
+// The error is reported with 403 Forbidden, unless it is ErrorWithStatus like 401 Unauthorized.
+func Rule[Reg any](fn func(ctx Context[Reg]) error) AuthorizationRule[Reg] {
+	return AuthorizationRule[Reg]{fn: fn}
+}
+
+// Policy returns the AuthorizationRule that denies the request unless the PolicyEngine set by WithPolicyEngine allows the policy of the name.
+// If no PolicyEngine is set, the request is denied.
+func Policy[Reg any](name string) AuthorizationRule[Reg] {
+	return AuthorizationRule[Reg]{policy: name}
+}
+
+// Authorize guards the handler by the authorization rules. The rules are evaluated in order after the Context is built,
+// so they can see the principal that the transformers of RouteWithTransformer have authenticated,
+// and the request is denied with 403 Forbidden by the first rule that fails.
+// The rules are evaluated before the request is decoded and validated, so the denied requests are not told which fields are invalid.
+//
+//	r.Delete("/tasks/{id}", tanukirpc.Authorize(tanukirpc.NewHandler(deleteTask), tanukirpc.Policy[*registry]("tasks:delete")))
+func Authorize[Reg any](h Handler[Reg], rules ...AuthorizationRule[Reg]) Handler[Reg] {
+	return &authorizedHandler[Reg]{handler: h, rules: rules}
+}
+
+type authorizedHandler[Reg any] struct {
+	handler Handler[Reg]
+	rules   []AuthorizationRule[Reg]
+}
+
+func (h *authorizedHandler[Reg]) Metadata() *HandlerMetadata {
+	return h.handler.Metadata()
+}
+
+func (h *authorizedHandler[Reg]) isNil() bool {
+	return isNilHandler(h.handler)
+}
+
+func (h *authorizedHandler[Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	r2 := r.clone()
+	r2.authorizationRules = append(slices.Clip(r.authorizationRules), h.rules...)
+	return h.handler.build(r2)
+}
+
+// authorize returns the error if any of the authorization rules of the route denies the request.
+func (r *Router[Reg]) authorize(ctx Context[Reg]) error {
+	for _, rule := range r.authorizationRules {
+		if rule.fn != nil {
+			if err := rule.fn(ctx); err != nil {
+				var ews ErrorWithStatus
+				if errors.As(err, &ews) {
+					return err
+				}
+				return &ForbiddenError{err: err}
+			}
+			continue
+		}
+		if r.policyEngine == nil {
+			return &ForbiddenError{Policy: rule.policy}
+		}
+		allowed, err := r.policyEngine.Allow(ctx, rule.policy)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate policy %s: %w", rule.policy, err)
+		}
+		if !allowed {
+			return &ForbiddenError{Policy: rule.policy}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	type principal struct {
+		name string
+	}
+	tr := tanukirpc.NewTransformer(func(ctx tanukirpc.Context[struct{}]) (*principal, error) {
+		name := ctx.Request().Header.Get("X-User")
+		if name == "" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusUnauthorized, errors.New("unauthorized"))
+		}
+		return &principal{name: name}, nil
+	})
+	engine := tanukirpc.PolicyEngineFunc(func(ctx context.Context, policy string) (bool, error) {
+		p := ctx.(tanukirpc.Context[*principal]).Registry()
+		if p.name == "broken" {
+			return false, errors.New("policy store is down")
+		}
+		return p.name == "admin" && policy == "tasks:delete", nil
+	})
+	handler := tanukirpc.NewHandler(func(ctx tanukirpc.Context[*principal], req struct{}) (string, error) {
+		return "ok", nil
+	})
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithPolicyEngine[struct{}](engine))
+	tanukirpc.RouteWithTransformer(router, tr, "/tasks", func(r *tanukirpc.Router[*principal]) {
+		r.Delete("/", tanukirpc.Authorize(handler, tanukirpc.Policy[*principal]("tasks:delete")))
+		r.Get("/", tanukirpc.Authorize(handler, tanukirpc.Rule(func(ctx tanukirpc.Context[*principal]) error {
+			if ctx.Registry().name == "guest" {
+				return errors.New("guests cannot list tasks")
+			}
+			return nil
+		})))
+		r.Put("/", handler)
+	})
+
+	testCases := []struct {
+		name         string
+		method       string
+		user         string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "policy allowed", method: http.MethodDelete, user: "admin", expectedCode: http.StatusOK},
+		{name: "policy denied", method: http.MethodDelete, user: "alice", expectedCode: http.StatusForbidden, expectedBody: `{"error":{"message":"forbidden"}}`},
+		{name: "policy error", method: http.MethodDelete, user: "broken", expectedCode: http.StatusInternalServerError},
+		{name: "unauthenticated", method: http.MethodDelete, expectedCode: http.StatusUnauthorized},
+		{name: "rule allowed", method: http.MethodGet, user: "alice", expectedCode: http.StatusOK},
+		{name: "rule denied", method: http.MethodGet, user: "guest", expectedCode: http.StatusForbidden, expectedBody: `{"error":{"message":"guests cannot list tasks"}}`},
+		{name: "no rules", method: http.MethodPut, user: "guest", expectedCode: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/tasks/", nil)
+			req.Header.Set("Accept", "application/json")
+			if tc.user != "" {
+				req.Header.Set("X-User", tc.user)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizeWithoutPolicyEngine(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/", tanukirpc.Authorize(tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		return "ok", nil
+	}), tanukirpc.Policy[struct{}]("tasks:read")))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuthorizeBeforeDecode(t *testing.T) {
+	type createTaskRequest struct {
+		Title string `json:"title" validate:"required"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Post("/tasks", tanukirpc.Authorize(tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req createTaskRequest) (string, error) {
+		return "ok", nil
+	}), tanukirpc.Rule(func(ctx tanukirpc.Context[struct{}]) error {
+		if ctx.Request().Header.Get("X-User") != "admin" {
+			return errors.New("only admins can create tasks")
+		}
+		return nil
+	})))
+
+	testCases := []struct {
+		name         string
+		user         string
+		body         string
+		expectedCode int
+	}{
+		{name: "denied with invalid body", body: `{}`, expectedCode: http.StatusForbidden},
+		{name: "denied with malformed body", body: `{"title":`, expectedCode: http.StatusForbidden},
+		{name: "allowed with invalid body", user: "admin", body: `{}`, expectedCode: http.StatusBadRequest},
+		{name: "allowed", user: "admin", body: `{"title":"write tests"}`, expectedCode: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Content-Type", "application/json")
+			if tc.user != "" {
+				req.Header.Set("X-User", tc.user)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusForbidden {
+				assert.JSONEq(t, `{"error":{"message":"only admins can create tasks"}}`, rec.Body.String())
+			}
+		})
+	}
+}
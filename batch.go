@@ -0,0 +1,187 @@
+package tanukirpc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+const (
+	defaultBatchMaxItems    = 100
+	defaultBatchConcurrency = 8
+)
+
+// ErrBatchTooLarge is the error of the batch requests that have more items than the limit. It is reported with 400 Bad Request.
+var ErrBatchTooLarge = errors.New("too many items in the batch")
+
+// BatchRequest is the request of the handler created by NewBatchHandler.
+type BatchRequest[Req any] struct {
+	Requests []Req `json:"requests" required:"true"`
+}
+
+// BatchResponse is the response of the handler created by NewBatchHandler. The results are in the order of the requests.
+type BatchResponse[Res any] struct {
+	Results []BatchResult[Res] `json:"results"`
+}
+
+// BatchResult is the result of the item of the batch. Either Response or Error is set.
+type BatchResult[Res any] struct {
+	Response Res         `json:"response,omitempty"`
+	Error    *BatchError `json:"error,omitempty"`
+}
+
+// BatchError is the error of the item of the batch.
+type BatchError struct {
+	// Status is the status code that the error would be responded with by the handler, like 404 for the ErrorWithStatus.
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type batchConfig struct {
+	maxItems    int
+	concurrency int
+}
+
+// BatchOption is the option of NewBatchHandler.
+type BatchOption func(*batchConfig)
+
+// WithBatchMaxItems sets the limit of the number of the items of the batch. The default is 100.
+func WithBatchMaxItems(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.maxItems = n
+	}
+}
+
+// WithBatchConcurrency sets the number of the items that are handled concurrently. The default is 8.
+func WithBatchConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+// NewBatchHandler returns the Handler that handles the array of the requests of h in one request, like the bulk creation.
+// The items are validated and handled concurrently with the shared Context, and the results and the errors of the items
+// are returned in the order of the requests. The request fails only when the batch itself is invalid.
+// The functions deferred by the items run after the response like the other handlers.
+//
+//	r.Post("/tasks/batch", tanukirpc.NewBatchHandler(createTask))
+//
+// The generated clients have the method of the route with BatchRequest and BatchResponse of the request and the response of h.
+func NewBatchHandler[Req any, Res any, Reg any](h HandlerFunc[Req, Res, Reg], opts ...BatchOption) Handler[Reg] {
+	cfg := &batchConfig{
+		maxItems:    defaultBatchMaxItems,
+		concurrency: defaultBatchConcurrency,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	item := reflect.TypeFor[Req]()
+	return &batchHandler[Req, Res, Reg]{
+		h:   h,
+		cfg: cfg,
+		// the items are validated one by one, so the batch itself is not validated by the pipeline
+		meta: &HandlerMetadata{
+			Request:  reflect.TypeFor[BatchRequest[Req]](),
+			Response: reflect.TypeFor[*BatchResponse[Res]](),
+		},
+		itemMeta: newHandlerMetadata(item, reflect.TypeFor[Res]()),
+	}
+}
+
+type batchHandler[Req any, Res any, Reg any] struct {
+	h        HandlerFunc[Req, Res, Reg]
+	cfg      *batchConfig
+	meta     *HandlerMetadata
+	itemMeta *HandlerMetadata
+}
+
+func (h *batchHandler[Req, Res, Reg]) Metadata() *HandlerMetadata {
+	return h.meta
+}
+
+func (h *batchHandler[Req, Res, Reg]) isNil() bool {
+	return h.h == nil
+}
+
+func (h *batchHandler[Req, Res, Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	return buildHandlerFunc(r, h.meta, handlerCall[Reg]{
+		newRequest: func() any { return new(BatchRequest[Req]) },
+		request:    func(p any) any { return *p.(*BatchRequest[Req]) },
+		call: func(ctx Context[Reg], p any) (any, error) {
+			return h.handle(r, ctx, p.(*BatchRequest[Req]).Requests)
+		},
+	})
+}
+
+func (h *batchHandler[Req, Res, Reg]) handle(r *Router[Reg], ctx Context[Reg], reqs []Req) (*BatchResponse[Res], error) {
+	if h.cfg.maxItems > 0 && len(reqs) > h.cfg.maxItems {
+		return nil, WrapErrorWithStatus(http.StatusBadRequest, fmt.Errorf("%w: limit=%d", ErrBatchTooLarge, h.cfg.maxItems))
+	}
+
+	results := make([]BatchResult[Res], len(reqs))
+	ictx := &batchItemContext[Reg]{Context: ctx}
+	sem := make(chan struct{}, max(h.cfg.concurrency, 1))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = h.handleItem(r, ictx, req)
+		}()
+	}
+	wg.Wait()
+	return &BatchResponse[Res]{Results: results}, nil
+}
+
+func (h *batchHandler[Req, Res, Reg]) handleItem(r *Router[Reg], ctx Context[Reg], req Req) (result BatchResult[Res]) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.ErrorContext(ctx, "panic in batch item", slog.Any("panic", rec))
+			result = BatchResult[Res]{Error: &BatchError{Status: http.StatusInternalServerError, Message: http.StatusText(http.StatusInternalServerError)}}
+		}
+	}()
+	if err := validateRequest(h.itemMeta, req); err != nil {
+		return BatchResult[Res]{Error: &BatchError{Status: http.StatusBadRequest, Message: r.batchErrorMessage(ctx, err)}}
+	}
+	res, err := h.h(ctx, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var ews ErrorWithStatus
+		if errors.As(err, &ews) {
+			status = ews.Status()
+		} else {
+			r.logger.ErrorContext(ctx, "ocurred internal server error in batch item", slog.Any("error", err))
+		}
+		return BatchResult[Res]{Error: &BatchError{Status: status, Message: r.batchErrorMessage(ctx, err)}}
+	}
+	return BatchResult[Res]{Response: res}
+}
+
+// batchItemContext is the Context shared by the items of the batch. The deferred functions are registered exclusively.
+type batchItemContext[Reg any] struct {
+	Context[Reg]
+	mu sync.Mutex
+}
+
+func (c *batchItemContext[Reg]) Defer(fn DeferFunc, timings ...DeferDoTiming) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Context.Defer(fn, timings...)
+}
+
+// batchErrorMessage returns the message of the error of the item that is localized by the ErrorLocalizer of the Router.
+func (r *Router[Reg]) batchErrorMessage(ctx Context[Reg], err error) string {
+	if r.errorLocalizer != nil {
+		if message := r.errorLocalizer(ctx, err); message != "" {
+			return message
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,75 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchTaskRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type batchTaskResponse struct {
+	Title string `json:"title"`
+}
+
+func TestNewBatchHandler(t *testing.T) {
+	var deferred atomic.Int32
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Post("/tasks/batch", tanukirpc.NewBatchHandler(func(ctx tanukirpc.Context[struct{}], req batchTaskRequest) (*batchTaskResponse, error) {
+		if req.Title == "conflict" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusConflict, errors.New("task already exists"))
+		}
+		ctx.Defer(func() error {
+			deferred.Add(1)
+			return nil
+		})
+		return &batchTaskResponse{Title: req.Title}, nil
+	}, tanukirpc.WithBatchMaxItems(3), tanukirpc.WithBatchConcurrency(2)))
+
+	testCases := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+		deferred int32
+	}{
+		{
+			name:   "results in order",
+			body:   `{"requests":[{"title":"buy milk"},{"title":""},{"title":"conflict"}]}`,
+			status: http.StatusOK,
+			expected: `{"results":[
+				{"response":{"title":"buy milk"}},
+				{"error":{"status":400,"message":"Key: 'batchTaskRequest.Title' Error:Field validation for 'Title' failed on the 'required' tag"}},
+				{"error":{"status":409,"message":"task already exists"}}
+			]}`,
+			deferred: 1,
+		},
+		{
+			name:     "too many items",
+			body:     `{"requests":[{"title":"a"},{"title":"b"},{"title":"c"},{"title":"d"}]}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":{"message":"too many items in the batch: limit=3"}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deferred.Store(0)
+			req := httptest.NewRequest(http.MethodPost, "/tasks/batch", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+			assert.Equal(t, tc.deferred, deferred.Load())
+		})
+	}
+}
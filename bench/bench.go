@@ -0,0 +1,177 @@
+// Package bench is the benchmark suite of the handler pipeline of tanukirpc with the representative shapes of the requests:
+// the URL parameters, the query strings, the JSON bodies and the validation. The requests are served through the Router
+// with the default middlewares by httptest, so the benchmarks measure the binding by the reflection of the codecs as it is used.
+//
+// The allocations per request of each case are enforced by the tests against the baselines in MaxAllocs,
+// so the regressions are found before the benchmarks are compared. Run the benchmarks and take the profiles like:
+//
+//	go test ./bench -run '^$' -bench . -benchmem
+//	go test ./bench -run '^$' -bench 'Pipeline/full' -cpuprofile cpu.out -memprofile mem.out
+//	go tool pprof -top cpu.out
+package bench
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/mackee/tanukirpc"
+)
+
+// Case is the request shape of the benchmark.
+type Case struct {
+	// Name is the name of the case that is used as the name of the sub benchmark.
+	Name string
+	// Handler is the Router that serves the requests of the case.
+	Handler http.Handler
+	// NewRequest returns the request of the case. The request is created each time because the body is consumed.
+	NewRequest func() *http.Request
+	// MaxAllocs is the baseline of the allocations per request. Update it when the pipeline changes intentionally.
+	MaxAllocs float64
+}
+
+// Serve serves a request of the case and returns the error if the response is not 200 OK.
+func (c *Case) Serve() error {
+	w := httptest.NewRecorder()
+	c.Handler.ServeHTTP(w, c.NewRequest())
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status: %d body=%s", c.Name, w.Code, w.Body.String())
+	}
+	return nil
+}
+
+type getItemRequest struct {
+	ID int `urlparam:"id"`
+}
+
+type listItemsRequest struct {
+	Query  string  `query:"q"`
+	Limit  int     `query:"limit"`
+	Offset int     `query:"offset"`
+	Sort   *string `query:"sort"`
+}
+
+type itemBody struct {
+	Name     string            `json:"name" validate:"required,max=64"`
+	Price    int               `json:"price" validate:"gte=0"`
+	Tags     []string          `json:"tags" validate:"max=8,dive,max=16"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type createItemRequest struct {
+	ProjectID string `urlparam:"projectID" validate:"required"`
+	DryRun    bool   `query:"dry_run"`
+	itemBody
+}
+
+type item struct {
+	ID        int               `json:"id"`
+	ProjectID string            `json:"project_id"`
+	Name      string            `json:"name"`
+	Price     int               `json:"price"`
+	Tags      []string          `json:"tags"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+type itemsResponse struct {
+	Items []*item `json:"items"`
+	Next  string  `json:"next,omitempty"`
+}
+
+const itemJSON = `{"name":"tanuki","price":1200,"tags":["animal","forest"],"metadata":{"color":"brown","size":"small"}}`
+
+func newRouter() *tanukirpc.Router[struct{}] {
+	return tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithLogger[struct{}](slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+}
+
+func newRequest(method, target, body string) *http.Request {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	req.Header.Set("Accept", "application/json")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// Cases returns the cases of the benchmark suite.
+func Cases() []*Case {
+	return []*Case{
+		urlParamCase(),
+		queryCase(),
+		jsonCase(),
+		fullCase(),
+	}
+}
+
+func urlParamCase() *Case {
+	r := newRouter()
+	r.Get("/items/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req getItemRequest) (*item, error) {
+		return &item{ID: req.ID, Name: "tanuki"}, nil
+	}))
+	return &Case{
+		Name:    "urlparam",
+		Handler: r,
+		NewRequest: func() *http.Request {
+			return newRequest(http.MethodGet, "/items/42", "")
+		},
+		MaxAllocs: 95,
+	}
+}
+
+func queryCase() *Case {
+	r := newRouter()
+	r.Get("/items", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req listItemsRequest) (*itemsResponse, error) {
+		res := &itemsResponse{Items: make([]*item, 0, req.Limit)}
+		for i := 0; i < req.Limit; i++ {
+			res.Items = append(res.Items, &item{ID: req.Offset + i, Name: req.Query})
+		}
+		return res, nil
+	}))
+	return &Case{
+		Name:    "query",
+		Handler: r,
+		NewRequest: func() *http.Request {
+			return newRequest(http.MethodGet, "/items?q=tanuki&limit=10&offset=20&sort=name", "")
+		},
+		MaxAllocs: 145,
+	}
+}
+
+func jsonCase() *Case {
+	r := newRouter()
+	r.Post("/items", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req itemBody) (*item, error) {
+		return &item{ID: 1, Name: req.Name, Price: req.Price, Tags: req.Tags, Metadata: req.Metadata}, nil
+	}))
+	return &Case{
+		Name:    "json",
+		Handler: r,
+		NewRequest: func() *http.Request {
+			return newRequest(http.MethodPost, "/items", itemJSON)
+		},
+		MaxAllocs: 130,
+	}
+}
+
+func fullCase() *Case {
+	r := newRouter()
+	r.Post("/projects/{projectID}/items", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req createItemRequest) (*item, error) {
+		return &item{ID: 1, ProjectID: req.ProjectID, Name: req.Name, Price: req.Price, Tags: req.Tags, Metadata: req.Metadata}, nil
+	}))
+	return &Case{
+		Name:    "full",
+		Handler: r,
+		NewRequest: func() *http.Request {
+			return newRequest(http.MethodPost, "/projects/p1/items?dry_run=true", itemJSON)
+		},
+		MaxAllocs: 145,
+	}
+}
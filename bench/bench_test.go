@@ -0,0 +1,42 @@
+package bench_test
+
+import (
+	"testing"
+
+	"github.com/mackee/tanukirpc/bench"
+)
+
+func BenchmarkPipeline(b *testing.B) {
+	for _, c := range bench.Cases() {
+		b.Run(c.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := c.Serve(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocationBaselines(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the allocations are not comparable with the race detector")
+	}
+	for _, c := range bench.Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			if err := c.Serve(); err != nil {
+				t.Fatal(err)
+			}
+			allocs := testing.AllocsPerRun(100, func() {
+				if err := c.Serve(); err != nil {
+					t.Fatal(err)
+				}
+			})
+			t.Logf("allocs per request: %.0f (baseline %.0f)", allocs, c.MaxAllocs)
+			if allocs > c.MaxAllocs {
+				t.Errorf("allocs per request %.0f exceed the baseline %.0f. update MaxAllocs of the case if it is intended", allocs, c.MaxAllocs)
+			}
+		})
+	}
+}
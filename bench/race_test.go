@@ -0,0 +1,6 @@
+//go:build race
+
+package bench_test
+
+// raceEnabled is true when the race detector is enabled, because it allocates for the instrumentation.
+const raceEnabled = true
@@ -0,0 +1,46 @@
+package tanukirpc
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// BindFunc is a function that decodes the request into v without reflection.
+// It is usually generated by the genbind command.
+type BindFunc[T any] func(r *http.Request, v *T) error
+
+type binderKey struct {
+	codec string
+	typ   reflect.Type
+}
+
+var binders sync.Map
+
+// RegisterBinder registers the BindFunc for the type T that is used by the codec specified by name.
+// The urlparam, query and form codecs consult the registered BindFunc instead of the reflection-based binding.
+// The form codec consults it only for application/x-www-form-urlencoded, and binds multipart/form-data by the reflection.
+// This function is called from the init function of the code generated by the genbind command.
+func RegisterBinder[T any](codec string, fn BindFunc[T]) {
+	key := binderKey{codec: codec, typ: reflect.TypeOf((*T)(nil))}
+	binders.Store(key, func(r *http.Request, v any) error {
+		return fn(r, v.(*T))
+	})
+	// for the handler that receives the request as a pointer
+	pkey := binderKey{codec: codec, typ: reflect.TypeOf((**T)(nil))}
+	binders.Store(pkey, func(r *http.Request, v any) error {
+		pv := v.(**T)
+		if *pv == nil {
+			*pv = new(T)
+		}
+		return fn(r, *pv)
+	})
+}
+
+func lookupBinder(codec string, v any) (func(r *http.Request, v any) error, bool) {
+	b, ok := binders.Load(binderKey{codec: codec, typ: reflect.TypeOf(v)})
+	if !ok {
+		return nil, false
+	}
+	return b.(func(r *http.Request, v any) error), true
+}
@@ -0,0 +1,95 @@
+package tanukirpc_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type reflectBindRequest struct {
+	ID   int    `urlparam:"id"`
+	Name string `urlparam:"name"`
+}
+
+type generatedBindRequest struct {
+	ID   int    `urlparam:"id"`
+	Name string `urlparam:"name"`
+}
+
+// bindURLParamGeneratedBindRequest is the same as the code generated by genbind.
+func bindURLParamGeneratedBindRequest(r *http.Request, v *generatedBindRequest) error {
+	if s := chi.URLParam(r, "id"); s == "" {
+		return fmt.Errorf("url param id is required at field ID")
+	} else {
+		p, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse id: %w", err)
+		}
+		v.ID = int(p)
+	}
+	if s := chi.URLParam(r, "name"); s == "" {
+		return fmt.Errorf("url param name is required at field Name")
+	} else {
+		v.Name = string(s)
+	}
+	return nil
+}
+
+func init() {
+	tanukirpc.RegisterBinder("urlparam", bindURLParamGeneratedBindRequest)
+}
+
+func newBindRouter[Req any](get func(Req) (int, string)) http.Handler {
+	h := func(ctx tanukirpc.Context[struct{}], req Req) ([]byte, error) {
+		id, name := get(req)
+		return []byte(strconv.Itoa(id) + ":" + name), nil
+	}
+	router := tanukirpc.NewRouter(
+		struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](nil),
+		tanukirpc.WithDefaultMiddleware[struct{}](),
+	)
+	router.Get("/users/{id}/{name}", tanukirpc.NewHandler(h))
+	return router
+}
+
+func TestRegisterBinder(t *testing.T) {
+	routers := map[string]http.Handler{
+		"reflect":           newBindRouter(func(req reflectBindRequest) (int, string) { return req.ID, req.Name }),
+		"generated":         newBindRouter(func(req generatedBindRequest) (int, string) { return req.ID, req.Name }),
+		"generated pointer": newBindRouter(func(req *generatedBindRequest) (int, string) { return req.ID, req.Name }),
+	}
+	for name, router := range routers {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42/tanuki", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "42:tanuki", w.Body.String())
+		})
+	}
+}
+
+func benchmarkBind(b *testing.B, router http.Handler) {
+	req := httptest.NewRequest(http.MethodGet, "/users/42/tanuki", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkBindReflect(b *testing.B) {
+	benchmarkBind(b, newBindRouter(func(req reflectBindRequest) (int, string) { return req.ID, req.Name }))
+}
+
+func BenchmarkBindGenerated(b *testing.B) {
+	benchmarkBind(b, newBindRouter(func(req generatedBindRequest) (int, string) { return req.ID, req.Name }))
+}
@@ -0,0 +1,276 @@
+// Package breaker provides the circuit breaker for the dependencies of the registry, like the database and the other services.
+//
+// Wrap the client that the registry holds, and call it through the breaker in the handlers:
+//
+//	type registry struct {
+//		search *breaker.Client[*search.Client]
+//	}
+//
+//	reg := &registry{search: breaker.Wrap("search", search.New())}
+//
+//	func searchTasks(ctx tanukirpc.Context[*registry], req *searchRequest) (*searchResponse, error) {
+//		return breaker.Call(ctx, ctx.Registry().search, func(ctx context.Context, c *search.Client) (*searchResponse, error) {
+//			return c.Search(ctx, req.Query)
+//		})
+//	}
+//
+// While the breaker is open, the calls fail fast with OpenError, which is responded with 503 Service Unavailable.
+// The states of the breakers are published as the expvar "tanukirpc.breakers", so they are served by the debug endpoint of WithDebugEndpoints.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// ErrOpen is the error of the calls that are rejected by the open breaker.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// breakers is the expvar of the states of the breakers by the names.
+var breakers = expvar.NewMap("tanukirpc.breakers")
+
+// State is the state of the breaker.
+type State int
+
+const (
+	// StateClosed passes the calls and counts the failures.
+	StateClosed State = iota
+	// StateOpen rejects the calls until the open timeout has passed.
+	StateOpen
+	// StateHalfOpen passes the limited calls to probe the dependency. The breaker is closed when they succeed.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// OpenError is the error of the calls that are rejected by the open breaker. It is reported with 503 Service Unavailable.
+type OpenError struct {
+	// Name is the name of the breaker.
+	Name string
+	// RetryAfter is the duration until the breaker probes the dependency.
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open", e.Name)
+}
+
+func (e *OpenError) Status() int {
+	return http.StatusServiceUnavailable
+}
+
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+type options struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+	isFailure        func(err error) bool
+}
+
+// Option is the option of New and Wrap.
+type Option func(*options)
+
+// WithFailureThreshold sets the number of the consecutive failures to open the breaker. The default is 5.
+func WithFailureThreshold(n int) Option {
+	return func(o *options) {
+		o.failureThreshold = n
+	}
+}
+
+// WithOpenTimeout sets the duration to keep the breaker open before probing the dependency. The default is 30 seconds.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests sets the number of the concurrent calls to probe the dependency in the half-open state. The default is 1.
+func WithHalfOpenRequests(n int) Option {
+	return func(o *options) {
+		o.halfOpenRequests = n
+	}
+}
+
+// WithIsFailure sets the function that reports whether the error of the call is the failure of the dependency.
+// By default, all errors except the cancellation of the context are the failures.
+// Use it to ignore the errors of the caller, like the not found errors.
+func WithIsFailure(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.isFailure = fn
+	}
+}
+
+func defaultIsFailure(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
+
+// Breaker is the circuit breaker of the dependency. It is safe for the concurrent use.
+type Breaker struct {
+	name string
+	opts *options
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// New returns the Breaker of the name, and publishes the state of it to the expvar "tanukirpc.breakers".
+func New(name string, opts ...Option) *Breaker {
+	o := &options{
+		failureThreshold: defaultFailureThreshold,
+		openTimeout:      defaultOpenTimeout,
+		halfOpenRequests: defaultHalfOpenRequests,
+		isFailure:        defaultIsFailure,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	b := &Breaker{name: name, opts: o}
+	breakers.Set(name, expvar.Func(func() any {
+		return b.Stats()
+	}))
+	return b
+}
+
+// Name returns the name of the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// Stats is the state of the breaker that is published to the expvar.
+type Stats struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// Stats returns the state of the breaker.
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := Stats{State: b.currentState(time.Now()).String(), Failures: b.failures}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
+
+// currentState returns the state that the open timeout is applied to.
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.openTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Do calls fn if the breaker allows it, and records the result. It returns OpenError without calling fn while the breaker is open.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	probe, err := b.allow(time.Now())
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	b.record(probe, err == nil || !b.opts.isFailure(err), time.Now())
+	return err
+}
+
+// allow reports whether the call is allowed, and whether it is the probe of the half-open state.
+func (b *Breaker) allow(now time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.currentState(now) {
+	case StateOpen:
+		return false, &OpenError{Name: b.name, RetryAfter: b.opts.openTimeout - now.Sub(b.openedAt)}
+	case StateHalfOpen:
+		b.state = StateHalfOpen
+		if b.probes >= b.opts.halfOpenRequests {
+			return false, &OpenError{Name: b.name}
+		}
+		b.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (b *Breaker) record(probe, success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probes--
+	}
+	if success {
+		if probe || b.state == StateClosed {
+			b.state = StateClosed
+			b.failures = 0
+		}
+		return
+	}
+	b.failures++
+	if probe || (b.state == StateClosed && b.failures >= b.opts.failureThreshold) {
+		b.state = StateOpen
+		b.openedAt = now
+	}
+}
+
+// Client is the client of the dependency that is called through the Breaker. Put it in the registry instead of the client.
+type Client[C any] struct {
+	*Breaker
+	client C
+}
+
+// Wrap returns the Client that calls client through the new Breaker of the name.
+func Wrap[C any](name string, client C, opts ...Option) *Client[C] {
+	return &Client[C]{Breaker: New(name, opts...), client: client}
+}
+
+// Do calls fn with the client through the breaker.
+func (c *Client[C]) Do(ctx context.Context, fn func(ctx context.Context, client C) error) error {
+	return c.Breaker.Do(ctx, func(ctx context.Context) error {
+		return fn(ctx, c.client)
+	})
+}
+
+// Call calls fn with the client through the breaker, and returns the result of fn.
+func Call[C any, T any](ctx context.Context, c *Client[C], fn func(ctx context.Context, client C) (T, error)) (T, error) {
+	var result T
+	err := c.Do(ctx, func(ctx context.Context, client C) error {
+		var err error
+		result, err = fn(ctx, client)
+		return err
+	})
+	return result, err
+}
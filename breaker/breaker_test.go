@@ -0,0 +1,113 @@
+package breaker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+type searchClient struct {
+	err error
+}
+
+func (c *searchClient) Search(ctx context.Context, query string) ([]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return []string{query}, nil
+}
+
+func TestBreaker(t *testing.T) {
+	b := breaker.New("test", breaker.WithFailureThreshold(2), breaker.WithOpenTimeout(50*time.Millisecond))
+	fail := func(ctx context.Context) error { return errUnavailable }
+	ok := func(ctx context.Context) error { return nil }
+
+	assert.ErrorIs(t, b.Do(context.Background(), fail), errUnavailable)
+	assert.Equal(t, breaker.StateClosed, b.State())
+	assert.ErrorIs(t, b.Do(context.Background(), fail), errUnavailable)
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	called := false
+	err := b.Do(context.Background(), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	assert.False(t, called)
+	var oe *breaker.OpenError
+	require.ErrorAs(t, err, &oe)
+	assert.Equal(t, "test", oe.Name)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+
+	var stats breaker.Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("tanukirpc.breakers").(*expvar.Map).Get("test").String()), &stats))
+	assert.Equal(t, "open", stats.State)
+	assert.Equal(t, 2, stats.Failures)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, breaker.StateHalfOpen, b.State())
+	// the failed probe opens the breaker again
+	assert.ErrorIs(t, b.Do(context.Background(), fail), errUnavailable)
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, b.Do(context.Background(), ok))
+	assert.Equal(t, breaker.StateClosed, b.State())
+}
+
+func TestWithIsFailure(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b := breaker.New("is_failure", breaker.WithFailureThreshold(1), breaker.WithIsFailure(func(err error) bool {
+		return !errors.Is(err, errNotFound)
+	}))
+	assert.ErrorIs(t, b.Do(context.Background(), func(ctx context.Context) error { return errNotFound }), errNotFound)
+	assert.Equal(t, breaker.StateClosed, b.State())
+}
+
+type registry struct {
+	search *breaker.Client[*searchClient]
+}
+
+type searchResponse struct {
+	Results []string `json:"results"`
+}
+
+func TestCall(t *testing.T) {
+	client := &searchClient{err: errUnavailable}
+	reg := &registry{search: breaker.Wrap("search", client, breaker.WithFailureThreshold(1), breaker.WithOpenTimeout(time.Hour))}
+	router := tanukirpc.NewRouter(reg)
+	router.Get("/search", tanukirpc.NewHandler(func(ctx tanukirpc.Context[*registry], req struct{}) (*searchResponse, error) {
+		results, err := breaker.Call(ctx, ctx.Registry().search, func(ctx context.Context, c *searchClient) ([]string, error) {
+			return c.Search(ctx, "milk")
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &searchResponse{Results: results}, nil
+	}))
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Equal(t, http.StatusInternalServerError, do().Code)
+
+	client.err = nil
+	rec := do()
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"error":{"message":"circuit breaker search is open"}}`, rec.Body.String())
+}
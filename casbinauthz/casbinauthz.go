@@ -0,0 +1,83 @@
+// Package casbinauthz provides the adapter of Casbin as the PolicyEngine of tanukirpc.
+//
+// The adapter does not import Casbin, and accepts the *casbin.Enforcer or the other types that have the Enforce method:
+//
+//	e, err := casbin.NewEnforcer("model.conf", "policy.csv")
+//	engine := casbinauthz.New(e, func(ctx context.Context) (string, error) {
+//		return ctx.(tanukirpc.Context[*registry]).Registry().user.ID, nil
+//	})
+//	r := tanukirpc.NewRouter(reg, tanukirpc.WithPolicyEngine[*registry](engine))
+//	r.Delete("/tasks/{id}", tanukirpc.Authorize(tanukirpc.NewHandler(deleteTask), tanukirpc.Policy[*registry]("tasks:delete")))
+//
+// By default, the request of the Enforce method is the subject, the policy and the method of the request,
+// like the model of "r = sub, obj, act".
+package casbinauthz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mackee/tanukirpc"
+)
+
+// Enforcer is the interface of *casbin.Enforcer to decide whether the request is allowed.
+type Enforcer interface {
+	Enforce(rvals ...any) (bool, error)
+}
+
+// SubjectFunc returns the subject of the request like the user ID. ctx is the Context[Reg] of the route.
+// Return ErrorWithStatus like 401 Unauthorized to tell that the request is not authenticated.
+type SubjectFunc func(ctx context.Context) (string, error)
+
+// RequestFunc returns the request of the Enforce method from the subject and the policy.
+type RequestFunc func(ctx context.Context, subject, policy string) []any
+
+type options struct {
+	request RequestFunc
+}
+
+// Option is the option of New.
+type Option func(*options)
+
+// WithRequest sets the function that returns the request of the Enforce method, for the models other than "r = sub, obj, act".
+func WithRequest(fn RequestFunc) Option {
+	return func(o *options) {
+		o.request = fn
+	}
+}
+
+func defaultRequest(ctx context.Context, subject, policy string) []any {
+	method := ""
+	if rc, ok := ctx.(interface{ Request() *http.Request }); ok {
+		method = rc.Request().Method
+	}
+	return []any{subject, policy, method}
+}
+
+type engine struct {
+	enforcer Enforcer
+	subject  SubjectFunc
+	opts     *options
+}
+
+// New returns the PolicyEngine that evaluates the policies by the Enforcer with the subject of the request.
+func New(enforcer Enforcer, subject SubjectFunc, opts ...Option) tanukirpc.PolicyEngine {
+	o := &options{request: defaultRequest}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &engine{enforcer: enforcer, subject: subject, opts: o}
+}
+
+func (e *engine) Allow(ctx context.Context, policy string) (bool, error) {
+	subject, err := e.subject(ctx)
+	if err != nil {
+		return false, err
+	}
+	allowed, err := e.enforcer.Enforce(e.opts.request(ctx, subject, policy)...)
+	if err != nil {
+		return false, fmt.Errorf("failed to enforce policy: %w", err)
+	}
+	return allowed, nil
+}
@@ -0,0 +1,56 @@
+package casbinauthz_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/casbinauthz"
+	"github.com/stretchr/testify/assert"
+)
+
+// enforcer is the Enforcer of the policies like "p, alice, tasks, GET".
+type enforcer [][3]string
+
+func (e enforcer) Enforce(rvals ...any) (bool, error) {
+	if len(rvals) != 3 {
+		return false, errors.New("invalid request")
+	}
+	for _, p := range e {
+		if p[0] == rvals[0] && p[1] == rvals[1] && p[2] == rvals[2] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestNew(t *testing.T) {
+	engine := casbinauthz.New(enforcer{{"alice", "tasks", http.MethodGet}}, func(ctx context.Context) (string, error) {
+		return ctx.(tanukirpc.Context[struct{}]).Request().Header.Get("X-User"), nil
+	})
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithPolicyEngine[struct{}](engine))
+	handler := tanukirpc.Authorize(tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		return "ok", nil
+	}), tanukirpc.Policy[struct{}]("tasks"))
+	router.Get("/tasks", handler)
+	router.Delete("/tasks", handler)
+
+	testCases := []struct {
+		method, user string
+		expected     int
+	}{
+		{method: http.MethodGet, user: "alice", expected: http.StatusOK},
+		{method: http.MethodDelete, user: "alice", expected: http.StatusForbidden},
+		{method: http.MethodGet, user: "bob", expected: http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/tasks", nil)
+		req.Header.Set("X-User", tc.user)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, tc.expected, rec.Code, "%s %s", tc.method, tc.user)
+	}
+}
@@ -0,0 +1,277 @@
+// Package client calls the APIs with the typed request and response, like the handlers of tanukirpc.
+// It complements the generated clients for the ad-hoc calls, like the calls to the other services.
+//
+//	c := client.New(client.WithRetry(client.RetryPolicy{MaxRetries: 3}))
+//	res, err := client.Call[*createTaskRequest, *taskResponse](ctx, c, http.MethodPost, "https://example.com/tasks", req)
+//
+// The error responses are returned as tanukirpc.ErrorWithStatus that has the message of tanukirpc.ErrorMessage.
+// The TraceContext of the ctx is propagated by the traceparent and tracestate headers, and the deadline of the ctx by the Request-Timeout header.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+)
+
+const (
+	defaultAccept      = "application/json"
+	defaultBackoffBase = 100 * time.Millisecond
+	defaultBackoffMax  = 5 * time.Second
+)
+
+// Client calls the APIs with the Codec.
+type Client struct {
+	httpClient *http.Client
+	codec      tanukirpc.Codec
+	accept     string
+	header     http.Header
+	retry      *RetryPolicy
+}
+
+// Option is the option of New.
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client to send the requests. The default is http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithCodec sets the Codec to encode the requests and decode the responses, and the Accept header of the requests
+// that the Codec encodes for. The default is the JSONCodec and "application/json".
+func WithCodec(codec tanukirpc.Codec, accept string) Option {
+	return func(c *Client) {
+		c.codec = codec
+		c.accept = accept
+	}
+}
+
+// WithHeader adds the header to all requests, like the Authorization header.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithRetry retries the requests by the policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// New returns the Client.
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		codec:      tanukirpc.NewJSONCodec(),
+		accept:     defaultAccept,
+		header:     http.Header{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RetryPolicy is the policy to retry the requests.
+type RetryPolicy struct {
+	// MaxRetries is the number of the retries after the first request.
+	MaxRetries int
+	// Backoff returns the duration to wait before the retry of the attempt, which starts from 1.
+	// The default is ExponentialBackoff(100*time.Millisecond, 5*time.Second).
+	// The Retry-After header of the response is used instead if it is longer.
+	Backoff func(attempt int) time.Duration
+	// Retryable reports whether the request is retried. The default is DefaultRetryable.
+	Retryable func(res *http.Response, err error) bool
+}
+
+// ExponentialBackoff returns the backoff that doubles the duration from base up to maxDelay with the full jitter.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := maxDelay
+		if attempt < 32 {
+			d = min(base<<(attempt-1), maxDelay)
+		}
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d) + 1
+	}
+}
+
+// DefaultRetryable retries the network errors, 429 Too Many Requests, 502 Bad Gateway, 503 Service Unavailable and 504 Gateway Timeout.
+// Note that the requests that are not idempotent like POST are also retried. Set Retryable to change it.
+func DefaultRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Call sends req to url with the method, and returns the decoded response.
+// The request body is not sent for GET and HEAD. The response is the zero value when the response has no body.
+func Call[Req any, Res any](ctx context.Context, c *Client, method, url string, req Req) (Res, error) {
+	var res Res
+	var body []byte
+	var contentType string
+	if method != http.MethodGet && method != http.MethodHead {
+		b, ct, err := c.encode(req)
+		if err != nil {
+			return res, err
+		}
+		body, contentType = b, ct
+	}
+
+	hres, err := c.do(ctx, method, url, body, contentType)
+	if err != nil {
+		return res, err
+	}
+	defer hres.Body.Close()
+	resBody, err := io.ReadAll(hres.Body)
+	if err != nil {
+		return res, fmt.Errorf("failed to read response: %w", err)
+	}
+	if hres.StatusCode >= http.StatusBadRequest {
+		return res, responseError(hres.StatusCode, resBody)
+	}
+	if len(resBody) == 0 {
+		return res, nil
+	}
+	if err := c.decode(hres.Header.Get("Content-Type"), resBody, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (c *Client) do(ctx context.Context, method, url string, body []byte, contentType string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		hreq, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		hreq.Header = c.header.Clone()
+		hreq.Header.Set("Accept", c.accept)
+		if contentType != "" {
+			hreq.Header.Set("Content-Type", contentType)
+		}
+		if tc, ok := tanukirpc.TraceContextFromContext(ctx); ok {
+			tc.Inject(hreq.Header)
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			hreq.Header.Set(tanukirpc.RequestTimeoutHeader, tanukirpc.FormatRequestTimeout(deadline, time.Now()))
+		}
+		hres, err := c.httpClient.Do(hreq)
+		wait, retry := c.shouldRetry(attempt+1, hres, err)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("failed to send request: %w", err)
+			}
+			return hres, nil
+		}
+		if hres != nil {
+			io.Copy(io.Discard, hres.Body)
+			hres.Body.Close()
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, fmt.Errorf("failed to send request: %w", ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// shouldRetry returns the duration to wait before the retry of the attempt, and whether it is retried.
+func (c *Client) shouldRetry(attempt int, res *http.Response, err error) (time.Duration, bool) {
+	if c.retry == nil || attempt > c.retry.MaxRetries {
+		return 0, false
+	}
+	retryable := c.retry.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	if !retryable(res, err) {
+		return 0, false
+	}
+	backoff := c.retry.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(defaultBackoffBase, defaultBackoffMax)
+	}
+	wait := backoff(attempt)
+	if res != nil {
+		if sec, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			wait = max(wait, time.Duration(sec)*time.Second)
+		}
+	}
+	return wait, true
+}
+
+// encode encodes v by the Codec, which encodes the responses of the server.
+func (c *Client) encode(v any) ([]byte, string, error) {
+	w := &bodyWriter{header: http.Header{}}
+	r := &http.Request{Header: http.Header{"Accept": []string{c.accept}}}
+	if err := c.codec.Encode(w, r, v); err != nil {
+		return nil, "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	return w.body.Bytes(), w.header.Get("Content-Type"), nil
+}
+
+// decode decodes the body by the Codec, which decodes the requests of the server.
+func (c *Client) decode(contentType string, body []byte, v any) error {
+	// the codecs match the media type without the parameters like charset
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mt
+	}
+	r := &http.Request{
+		Header: http.Header{"Content-Type": []string{contentType}},
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+	if err := c.codec.Decode(r, v); err != nil && !errors.Is(err, tanukirpc.ErrRequestContinueDecode) {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// responseError returns the error with the status and the message of tanukirpc.ErrorMessage in the body.
+func responseError(status int, body []byte) error {
+	message := http.StatusText(status)
+	var em tanukirpc.ErrorMessage
+	if err := json.Unmarshal(body, &em); err == nil && em.Error.Message != "" {
+		message = em.Error.Message
+	}
+	return tanukirpc.WrapErrorWithStatus(status, errors.New(message))
+}
+
+// bodyWriter is the http.ResponseWriter that keeps the body encoded by the Codec.
+type bodyWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *bodyWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
+
+func (w *bodyWriter) WriteHeader(int) {}
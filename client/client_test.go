@@ -0,0 +1,137 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createTaskRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type taskResponse struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func newServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var flaky atomic.Int32
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *createTaskRequest) (*taskResponse, error) {
+		if ctx.Request().Header.Get("Authorization") != "Bearer token" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusUnauthorized, errors.New("unauthorized"))
+		}
+		return &taskResponse{ID: 1, Title: req.Title}, nil
+	}))
+	r.Get("/flaky", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*taskResponse, error) {
+		if flaky.Add(1) < 3 {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusServiceUnavailable, errors.New("try again"))
+		}
+		return &taskResponse{ID: 2}, nil
+	}))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server, &flaky
+}
+
+func TestCall(t *testing.T) {
+	server, _ := newServer(t)
+	ctx := context.Background()
+	c := client.New(client.WithHTTPClient(server.Client()), client.WithHeader("Authorization", "Bearer token"))
+
+	res, err := client.Call[*createTaskRequest, *taskResponse](ctx, c, http.MethodPost, server.URL+"/tasks", &createTaskRequest{Title: "buy milk"})
+	require.NoError(t, err)
+	assert.Equal(t, &taskResponse{ID: 1, Title: "buy milk"}, res)
+
+	_, err = client.Call[*createTaskRequest, *taskResponse](ctx, c, http.MethodPost, server.URL+"/tasks", &createTaskRequest{})
+	var ews tanukirpc.ErrorWithStatus
+	require.ErrorAs(t, err, &ews)
+	assert.Equal(t, http.StatusBadRequest, ews.Status())
+
+	_, err = client.Call[*createTaskRequest, *taskResponse](ctx, client.New(), http.MethodPost, server.URL+"/tasks", &createTaskRequest{Title: "buy milk"})
+	require.ErrorAs(t, err, &ews)
+	assert.Equal(t, http.StatusUnauthorized, ews.Status())
+	assert.Equal(t, "unauthorized", ews.Error())
+}
+
+func TestCallRetry(t *testing.T) {
+	ctx := context.Background()
+	backoff := func(int) time.Duration { return time.Millisecond }
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		server, flaky := newServer(t)
+		c := client.New(client.WithRetry(client.RetryPolicy{MaxRetries: 2, Backoff: backoff}))
+		res, err := client.Call[struct{}, *taskResponse](ctx, c, http.MethodGet, server.URL+"/flaky", struct{}{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.ID)
+		assert.Equal(t, int32(3), flaky.Load())
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		server, flaky := newServer(t)
+		c := client.New(client.WithRetry(client.RetryPolicy{MaxRetries: 1, Backoff: backoff}))
+		_, err := client.Call[struct{}, *taskResponse](ctx, c, http.MethodGet, server.URL+"/flaky", struct{}{})
+		var ews tanukirpc.ErrorWithStatus
+		require.ErrorAs(t, err, &ews)
+		assert.Equal(t, http.StatusServiceUnavailable, ews.Status())
+		assert.Equal(t, int32(2), flaky.Load())
+	})
+}
+
+func TestCallTraceContext(t *testing.T) {
+	backend := tanukirpc.NewRouter(struct{}{})
+	backend.Get("/trace", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*taskResponse, error) {
+		tc, _ := tanukirpc.TraceContextFromContext(ctx)
+		return &taskResponse{Title: tc.TraceID + "/" + tc.ParentID}, nil
+	}))
+	backendServer := httptest.NewServer(backend)
+	t.Cleanup(backendServer.Close)
+
+	c := client.New(client.WithHTTPClient(backendServer.Client()))
+	frontend := tanukirpc.NewRouter(struct{}{})
+	frontend.Get("/trace", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*taskResponse, error) {
+		res, err := client.Call[struct{}, *taskResponse](ctx, c, http.MethodGet, backendServer.URL+"/trace", struct{}{})
+		if err != nil {
+			return nil, err
+		}
+		tc, _ := tanukirpc.TraceContextFromContext(ctx)
+		assert.Equal(t, tc.TraceID+"/"+tc.SpanID, res.Title)
+		return res, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	frontend.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "4bf92f3577b34da6a3ce929d0e0e4736/")
+}
+
+func TestCallRequestTimeout(t *testing.T) {
+	r := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithRequestDeadline[struct{}](time.Minute))
+	r.Get("/budget", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*taskResponse, error) {
+		deadline, _ := ctx.Deadline()
+		return &taskResponse{ID: int(time.Until(deadline).Round(time.Second) / time.Second)}, nil
+	}))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := client.New(client.WithHTTPClient(server.Client()))
+	res, err := client.Call[struct{}, *taskResponse](ctx, c, http.MethodGet, server.URL+"/budget", struct{}{})
+	require.NoError(t, err)
+	assert.Equal(t, 5, res.ID)
+}
@@ -0,0 +1,54 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the status of the access logs of the requests whose clients disconnected before the response,
+// like 499 of nginx. It is never sent to the clients.
+const StatusClientClosedRequest = 499
+
+// ClientClosedHook is called when the request fails because the client disconnected, instead of the ErrorHooker.
+// It is for the cleanup policies of the abandoned requests, like rolling back the transactions or counting the cancellations.
+// info.Context is the Context of the route if it has been built.
+type ClientClosedHook func(req *http.Request, info *ErrorInfo, err error)
+
+// WithClientClosedHook sets the ClientClosedHook of the Router.
+func WithClientClosedHook[Reg any](hook ClientClosedHook) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.clientClosedHook = hook
+		return r
+	}
+}
+
+// isClientClosed reports whether err is caused by the cancellation of the request context, that is, the client disconnected.
+// The cancellations of the other contexts like the timeouts of the calls to the databases are the errors of the server.
+func isClientClosed(req *http.Request, err error) bool {
+	return err != nil && errors.Is(err, gocontext.Canceled) && errors.Is(req.Context().Err(), gocontext.Canceled)
+}
+
+// onClientClosed calls the ClientClosedHook. No response is written because nobody reads it.
+func (r *Router[Reg]) onClientClosed(req *http.Request, ctx Context[Reg], stage ErrorStage, err error) {
+	if r.clientClosedHook == nil {
+		return
+	}
+	info := &ErrorInfo{
+		RoutePattern: routePattern(req),
+		Stage:        stage,
+	}
+	if ctx != nil {
+		info.Context = ctx
+	}
+	r.clientClosedHook(req, info, err)
+}
+
+// clientClosedResponseWriter reports StatusClientClosedRequest as the status to the access logger.
+type clientClosedResponseWriter struct {
+	WrapResponseWriter
+}
+
+func (w *clientClosedResponseWriter) Status() int {
+	return StatusClientClosedRequest
+}
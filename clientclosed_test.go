@@ -0,0 +1,69 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusAccessLogger struct {
+	statuses []int
+}
+
+func (s *statusAccessLogger) Log(ctx context.Context, logger *slog.Logger, ww tanukirpc.WrapResponseWriter, req *http.Request, err error, t1 time.Time, t2 time.Time) error {
+	s.statuses = append(s.statuses, ww.Status())
+	return nil
+}
+
+func TestClientClosedRequest(t *testing.T) {
+	al := &statusAccessLogger{}
+	var infos []*tanukirpc.ErrorInfo
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](al),
+		tanukirpc.WithClientClosedHook[struct{}](func(req *http.Request, info *tanukirpc.ErrorInfo, err error) {
+			assert.ErrorIs(t, err, context.Canceled)
+			infos = append(infos, info)
+		}),
+	)
+	var cancel context.CancelFunc
+	router.Get("/disconnected", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		// the client disconnects while the handler is running
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	router.Get("/canceled", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		// the context of the call to the other service is canceled by the server
+		cctx, ccancel := context.WithCancel(ctx)
+		ccancel()
+		return "", cctx.Err()
+	}))
+
+	ctx, c := context.WithCancel(context.Background())
+	cancel = c
+	req := httptest.NewRequest(http.MethodGet, "/disconnected", nil).WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Body.String(), "no response is written to the disconnected client")
+	require.Len(t, infos, 1)
+	assert.Equal(t, tanukirpc.ErrorStageHandler, infos[0].Stage)
+	assert.Equal(t, "/disconnected", infos[0].RoutePattern)
+	assert.NotNil(t, infos[0].Context)
+
+	req = httptest.NewRequest(http.MethodGet, "/canceled", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, infos, 1)
+
+	assert.Equal(t, []int{tanukirpc.StatusClientClosedRequest, http.StatusInternalServerError}, al.statuses)
+}
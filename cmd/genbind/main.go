@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/mackee/tanukirpc/genclient"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(genclient.BindGenerator)
+}
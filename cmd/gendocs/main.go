@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mackee/tanukirpc/genclient"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "gendocs",
+		Usage: "gendocs generates the API reference in Markdown or HTML from your server",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "directory of the package that calls genclient.AnalyzeTarget",
+				Value: ".",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "format of the reference: markdown or html",
+				Value: "markdown",
+			},
+			&cli.StringFlag{
+				Name:    "out",
+				Aliases: []string{"o"},
+				Usage:   "output file path. the reference is written to stdout if it is not specified",
+			},
+			&cli.StringFlag{
+				Name:  "title",
+				Usage: "title of the reference",
+			},
+			&cli.StringFlag{
+				Name:  "marshaler-whitelist",
+				Usage: "path to the JSON file that maps the types implementing json.Marshaler to their TypeScript types",
+			},
+			&cli.StringFlag{
+				Name:  "duration",
+				Usage: "type of time.Duration: number or string",
+				Value: "number",
+			},
+		},
+		Action: run,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cctx *cli.Context) error {
+	generate := genclient.GenerateMarkdown
+	switch format := cctx.String("format"); format {
+	case "markdown":
+	case "html":
+		generate = genclient.GenerateHTML
+	default:
+		return fmt.Errorf("unknown format: %q", format)
+	}
+	opts := &genclient.DocsOptions{
+		Title:        cctx.String("title"),
+		DurationType: cctx.String("duration"),
+	}
+	if path := cctx.String("marshaler-whitelist"); path != "" {
+		m, err := genclient.LoadMarshalerWhitelist(path)
+		if err != nil {
+			return err
+		}
+		opts.Marshalers = m
+	}
+
+	ctx, cancel := signal.NotifyContext(cctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	result, err := genclient.Load(ctx, cctx.String("dir"))
+	if err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", cctx.String("dir"), err)
+	}
+	for _, d := range result.Diagnostics {
+		slog.WarnContext(ctx, d.String())
+	}
+	b, err := generate(result, opts)
+	if err != nil {
+		return err
+	}
+
+	out := cctx.String("out")
+	if out == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	if err := os.WriteFile(out, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
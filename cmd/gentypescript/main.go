@@ -1,10 +1,43 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
 	"github.com/mackee/tanukirpc/genclient"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
 func main() {
-	singlechecker.Main(genclient.TypeScriptClientGenerator)
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	watch := fs.Bool("watch", false, "regenerate the client every time the Go files are changed")
+	cache := fs.Bool("cache", false, "reuse the generated client when the module is not changed")
+	genclient.TypeScriptClientGenerator.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	if err := fs.Parse(os.Args[1:]); err != nil || (!*watch && !*cache) {
+		singlechecker.Main(genclient.TypeScriptClientGenerator)
+		return
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./"}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	run := genclient.GenerateTypeScriptClientCached
+	if *watch {
+		run = genclient.WatchTypeScriptClient
+	}
+	if err := run(ctx, ".", patterns); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mackee/tanukirpc/tanukigen"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "tanukigen",
+		Usage: "tanukigen analyzes your server once and generates the clients and the documents configured by tanukigen.yaml",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "path to the configuration file",
+				Value:   tanukigen.DefaultConfigPath,
+			},
+		},
+		Action: run,
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cctx *cli.Context) error {
+	cfg, err := tanukigen.LoadConfig(cctx.String("config"))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(cctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return tanukigen.Run(ctx, cfg)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintOutput(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "tanukilint")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("failed to build tanukilint: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command(bin, "./bodytagtest")
+	cmd.Dir = filepath.Join("..", "..", "genclient", "testdata")
+	out, err := cmd.CombinedOutput()
+	var ee *exec.ExitError
+	// singlechecker exits with 3 when the diagnostics are reported
+	if !errors.As(err, &ee) || ee.ExitCode() != 3 {
+		t.Fatalf("unexpected exit status: %v\n%s", err, out)
+	}
+	for _, expect := range []string{
+		"bodytag.go:29:12: json tags of the request are declared on GET /echo",
+		"bodytag.go:33:13: json tags of the request are declared on HEAD /echo",
+		"bodytag.go:35:15: form tags of the request are declared on DELETE /upload",
+	} {
+		if !strings.Contains(string(out), expect) {
+			t.Errorf("the output does not contain %q:\n%s", expect, out)
+		}
+	}
+}
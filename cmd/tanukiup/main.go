@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io/fs"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -18,6 +20,11 @@ func main() {
 		Name:  "tanukiup",
 		Usage: "tanukiup is a tool to run your server and watch your files",
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "path to the configuration file. if not specified, tanukiup.yaml in the base directory is used if it exists",
+			},
 			&cli.StringSliceFlag{
 				Name:  "ext",
 				Usage: "file extensions to watch",
@@ -60,10 +67,101 @@ func main() {
 				Name:  "catchall-target",
 				Usage: "target to catch all requests. if not specified, the server returns 404",
 			},
+			&cli.BoolFlag{
+				Name:  "error-overlay",
+				Usage: "inject the overlay of the build error into the HTML of the catchall target",
+			},
+			&cli.BoolFlag{
+				Name:  "tls",
+				Usage: "serve the proxy over HTTPS with the certificate issued by the local CA of tanukiup",
+			},
+			&cli.StringFlag{
+				Name:  "tls-cert",
+				Usage: "certificate file of the proxy instead of the issued one. this implies --tls",
+			},
+			&cli.StringFlag{
+				Name:  "tls-key",
+				Usage: "key file of --tls-cert",
+			},
+			&cli.BoolFlag{
+				Name:  "log-requests",
+				Usage: "log the requests through the proxy",
+			},
+			&cli.StringFlag{
+				Name:  "har",
+				Usage: "path to the HAR file that records the requests and the responses through the proxy",
+			},
+			&cli.DurationFlag{
+				Name:  "stream-drain-timeout",
+				Usage: "time to wait for the in-flight websocket and server-sent events streams before restarting the server. if not specified, the streams are closed immediately",
+			},
+			&cli.BoolFlag{
+				Name:  "test",
+				Usage: "run the tests before restarting the server. the server is not restarted if the tests fail",
+			},
+			&cli.StringFlag{
+				Name:        "test-command",
+				Usage:       "test command. the packages are appended to the command. this implies --test",
+				DefaultText: "go test",
+			},
+			&cli.BoolFlag{
+				Name:  "test-changed-only",
+				Usage: "run the tests of only the packages that contain the modified files",
+			},
 			&cli.StringFlag{
 				Name:  "log-level",
 				Usage: "log level (debug, info, warn, error)",
 			},
+			&cli.DurationFlag{
+				Name:        "debounce",
+				Usage:       "period to wait for the following file events before restarting",
+				DefaultText: "200ms",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow-generator",
+				Usage: "go:generate command to run before each build in addition to gentypescript like sqlc or github.com/a-h/templ/cmd/templ",
+			},
+			&cli.StringFlag{
+				Name:  "watcher",
+				Usage: "file watcher: auto, fsnotify or poll. poll works on the network file systems and the bind mounts of the containers",
+			},
+			&cli.DurationFlag{
+				Name:        "poll-interval",
+				Usage:       "interval of the poll watcher",
+				DefaultText: "500ms",
+			},
+			&cli.StringFlag{
+				Name:  "handoff",
+				Usage: "how the proxy connects to the server: unix or tcp. the default is tcp on Windows and unix on the others",
+			},
+			&cli.StringFlag{
+				Name:  "remote-wrapper",
+				Usage: "command to run the build, test and exec commands in a container or a remote host like \"docker compose exec -T app\"",
+			},
+			&cli.BoolFlag{
+				Name:  "remote-quote",
+				Usage: "pass the command to --remote-wrapper as a quoted argument. it is needed for ssh",
+			},
+			&cli.StringFlag{
+				Name:  "remote-sync",
+				Usage: "command to sync the files to the remote before each build like \"rsync -a --delete ./ dev:/app/\"",
+			},
+			&cli.StringFlag{
+				Name:  "remote-dir",
+				Usage: "directory of the source in the remote",
+			},
+			&cli.StringFlag{
+				Name:  "remote-listen",
+				Usage: "address that the server listens on in the remote like 0.0.0.0:8081",
+			},
+			&cli.StringFlag{
+				Name:  "remote-addr",
+				Usage: "address that the proxy connects to the server in the remote. the default is --remote-listen",
+			},
+			&cli.StringSliceFlag{
+				Name:  "env",
+				Usage: "environment variables of the exec command like KEY=VALUE",
+			},
 		},
 		Action: run,
 	}
@@ -74,7 +172,10 @@ func main() {
 }
 
 func run(cctx *cli.Context) error {
-	opts := tanukiup.Options{}
+	opts, err := configOptions(cctx)
+	if err != nil {
+		return err
+	}
 	if exts := cctx.StringSlice("ext"); len(exts) > 0 {
 		opts = append(opts, tanukiup.WithFileExts(exts))
 	}
@@ -88,17 +189,43 @@ func run(cctx *cli.Context) error {
 		opts = append(opts, tanukiup.WithAddr(port))
 	}
 	if logLevel := cctx.String("log-level"); logLevel != "" {
-		levelMap := map[string]slog.Level{
-			"debug": slog.LevelDebug,
-			"info":  slog.LevelInfo,
-			"warn":  slog.LevelWarn,
-			"error": slog.LevelError,
+		lv, err := tanukiup.ParseLogLevel(logLevel)
+		if err != nil {
+			return err
 		}
-		if lv, ok := levelMap[logLevel]; ok {
-			opts = append(opts, tanukiup.WithLogLevel(lv))
-		} else {
-			return fmt.Errorf("unknown log level: %s", logLevel)
+		opts = append(opts, tanukiup.WithLogLevel(lv))
+	}
+	if cctx.IsSet("debounce") {
+		opts = append(opts, tanukiup.WithDebounce(cctx.Duration("debounce")))
+	}
+	if watcher := cctx.String("watcher"); watcher != "" {
+		opts = append(opts, tanukiup.WithWatcher(tanukiup.WatcherKind(watcher)))
+	}
+	if cctx.IsSet("poll-interval") {
+		opts = append(opts, tanukiup.WithPollInterval(cctx.Duration("poll-interval")))
+	}
+	if handoff := cctx.String("handoff"); handoff != "" {
+		opts = append(opts, tanukiup.WithHandoff(tanukiup.Handoff(handoff)))
+	}
+	if wrapper := cctx.String("remote-wrapper"); wrapper != "" {
+		opts = append(opts, tanukiup.WithRemote(tanukiup.Remote{
+			Wrapper: strings.Fields(wrapper),
+			Quote:   cctx.Bool("remote-quote"),
+			Sync:    strings.Fields(cctx.String("remote-sync")),
+			Dir:     cctx.String("remote-dir"),
+			Listen:  cctx.String("remote-listen"),
+			Addr:    cctx.String("remote-addr"),
+		}))
+	}
+	if env := cctx.StringSlice("env"); len(env) > 0 {
+		opts = append(opts, tanukiup.WithEnv(env))
+	}
+	if commands := cctx.StringSlice("allow-generator"); len(commands) > 0 {
+		allowed := make([]tanukiup.AllowedGenerator, 0, len(commands))
+		for _, command := range commands {
+			allowed = append(allowed, tanukiup.AllowedGenerator{Command: command})
 		}
+		opts = append(opts, tanukiup.WithAllowedGenerators(allowed))
 	}
 	if build := cctx.String("build"); build != "" {
 		bc := strings.Fields(build)
@@ -117,6 +244,35 @@ func run(cctx *cli.Context) error {
 	if catchallTarget := cctx.String("catchall-target"); catchallTarget != "" {
 		opts = append(opts, tanukiup.WithCatchAllTarget(catchallTarget))
 	}
+	if cctx.IsSet("error-overlay") {
+		opts = append(opts, tanukiup.WithErrorOverlay(cctx.Bool("error-overlay")))
+	}
+	switch {
+	case cctx.String("tls-cert") != "" || cctx.String("tls-key") != "":
+		opts = append(opts, tanukiup.WithTLSCertificate(cctx.String("tls-cert"), cctx.String("tls-key")))
+	case cctx.IsSet("tls"):
+		opts = append(opts, tanukiup.WithTLS(cctx.Bool("tls")))
+	}
+	if cctx.IsSet("log-requests") {
+		opts = append(opts, tanukiup.WithRequestLog(cctx.Bool("log-requests")))
+	}
+	if har := cctx.String("har"); har != "" {
+		opts = append(opts, tanukiup.WithHARFile(har))
+	}
+	if timeout := cctx.Duration("stream-drain-timeout"); timeout > 0 {
+		opts = append(opts, tanukiup.WithStreamDrainTimeout(timeout))
+	}
+	switch {
+	case cctx.String("test-command") != "":
+		opts = append(opts, tanukiup.WithTestCommand(strings.Fields(cctx.String("test-command"))))
+	case cctx.IsSet("test") && cctx.Bool("test"):
+		opts = append(opts, tanukiup.WithTestCommand([]string{"go", "test"}))
+	case cctx.IsSet("test"):
+		opts = append(opts, tanukiup.WithTestCommand(nil))
+	}
+	if cctx.IsSet("test-changed-only") {
+		opts = append(opts, tanukiup.WithTestChangedOnly(cctx.Bool("test-changed-only")))
+	}
 	if handlerDir := cctx.String("handler-dir"); handlerDir != "" {
 		opts = append(opts, tanukiup.WithHandlerDir(handlerDir))
 	}
@@ -135,3 +291,19 @@ func run(cctx *cli.Context) error {
 	}
 	return nil
 }
+
+// configOptions returns the options of the configuration file. The flags are applied after them, so they override the file.
+func configOptions(cctx *cli.Context) (tanukiup.Options, error) {
+	path := cctx.String("config")
+	if path == "" {
+		path = filepath.Join(cctx.String("base-dir"), tanukiup.ConfigFileName)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return tanukiup.Options{}, nil
+		}
+	}
+	cfg, err := tanukiup.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Options()
+}
@@ -1,7 +1,6 @@
 package tanukirpc
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,9 +8,9 @@ import (
 	"reflect"
 	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
 	"github.com/hetiansu5/urlquery"
 )
 
@@ -21,6 +20,8 @@ var (
 	ErrResponseNotSupportedAtThisCodec = errors.New("response not supported at this codec")
 	DefaultCodecList                   = CodecList{
 		NewURLParamCodec(),
+		NewPreconditionCodec(),
+		NewNegotiateCodec(),
 		NewQueryCodec(),
 		NewFormCodec(),
 		NewJSONCodec(),
@@ -55,34 +56,18 @@ const (
 
 // NewJSONCodec returns a new JSONCodec. This codec supports request and response encoding and decoding.
 // The content type header of the request is application/json and */*, and the content type of the response is application/json.
-func NewJSONCodec() *codec {
+func NewJSONCodec(opts ...JSONCodecOption) *codec {
+	cfg := &jsonCodecConfig{escapeHTML: true, engine: StdJSONEngine}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return &codec{
 		contentTypes:        []string{defaultJSONCodecContentType},
 		acceptTypes:         []string{"*/*", defaultJSONCodecContentType},
 		responseContentType: defaultJSONCodecContentType,
-		decoderFunc: func(r io.Reader) Decoder {
-			return json.NewDecoder(r)
-		},
-		encoderFunc: func(w io.Writer) Encoder {
-			return json.NewEncoder(w)
-		},
-		name: "json",
-	}
-}
-
-// NewFormCodec returns a new FormCodec. This codec supports request decoding only.
-// The content type header of the request is application/x-www-form-urlencoded.
-// If you want to use this codec, you need to set the struct field tag like a `form:"name"`.
-func NewFormCodec() *codec {
-	return &codec{
-		contentTypes:        []string{defaultFormCodecContentType},
-		acceptTypes:         []string{},
-		responseContentType: "",
-		decoderFunc: func(r io.Reader) Decoder {
-			return &renderDecoder{r: r, rd: render.DecodeForm}
-		},
-		encoderFunc: nil,
-		name:        "form",
+		decoderFunc:         cfg.decoder,
+		encoderFunc:         cfg.encoder,
+		name:                "json",
 	}
 }
 
@@ -103,6 +88,10 @@ func (c *codec) isMyContentType(contentType string) bool {
 	return slices.Contains(c.contentTypes, contentType)
 }
 
+func (c *codec) MatchRequestType(t reflect.Type) bool {
+	return !isRawBodyStreamType(t)
+}
+
 func (c *codec) Decode(r *http.Request, v any) error {
 	if c.decoderFunc == nil {
 		return ErrRequestNotSupportedAtThisCodec
@@ -112,6 +101,13 @@ func (c *codec) Decode(r *http.Request, v any) error {
 		return ErrRequestNotSupportedAtThisCodec
 	}
 
+	if bind, ok := lookupBinder(c.name, v); ok {
+		if err := bind(r, v); err != nil {
+			return &ErrCodecDecode{err: err}
+		}
+		return nil
+	}
+
 	if err := c.decoderFunc(r.Body).Decode(v); err != nil {
 		if errors.Is(err, io.EOF) {
 			return ErrRequestContinueDecode
@@ -126,12 +122,18 @@ func (c *codec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
 	if c.encoderFunc == nil {
 		return ErrResponseNotSupportedAtThisCodec
 	}
+	if isRawResponse(v) {
+		return ErrResponseNotSupportedAtThisCodec
+	}
 
 	accept := r.Header.Get("accept")
 	if !slices.Contains(c.acceptTypes, accept) {
 		return ErrResponseNotSupportedAtThisCodec
 	}
 
+	if isSeqResponse(v) {
+		return c.encodeSeq(w, v)
+	}
 	w.Header().Set("content-type", c.responseContentType)
 	if err := c.encoderFunc(w).Encode(v); err != nil {
 		return &ErrCodecEncode{err: err}
@@ -214,10 +216,82 @@ func (c CodecList) Encode(w http.ResponseWriter, r *http.Request, v any) error {
 	return nil
 }
 
+// RequestTypeMatcher is implemented by the Codec that can determine by the request type whether it decodes the request.
+// CodecList skips the codecs that do not match the request type. It is determined once at the route registration.
+type RequestTypeMatcher interface {
+	MatchRequestType(t reflect.Type) bool
+}
+
+// codecForRequestType returns the codec that excludes the codecs that are irrelevant to the request type.
+func codecForRequestType(c Codec, t reflect.Type) Codec {
+	cl, ok := c.(CodecList)
+	if !ok {
+		return c
+	}
+	matched := make(CodecList, 0, len(cl))
+	for _, codec := range cl {
+		if m, ok := codec.(RequestTypeMatcher); ok && !m.MatchRequestType(t) {
+			continue
+		}
+		matched = append(matched, codecForRequestType(codec, t))
+	}
+	return matched
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// hasStructTag reports whether the struct type or the nested struct types have the field with the tag.
+func hasStructTag(t reflect.Type, tag string) bool {
+	return hasStructTagVisited(t, tag, map[reflect.Type]struct{}{})
+}
+
+func hasStructTagVisited(t reflect.Type, tag string, visited map[reflect.Type]struct{}) bool {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if _, ok := ft.Tag.Lookup(tag); ok {
+			return true
+		}
+		if hasStructTagVisited(ft.Type, tag, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceCodec replaces the codec that has the same name as the given codec.
+func replaceCodec(c Codec, replacement Codec) Codec {
+	if c.Name() == replacement.Name() {
+		return replacement
+	}
+	cl, ok := c.(CodecList)
+	if !ok {
+		return c
+	}
+	replaced := make(CodecList, 0, len(cl))
+	for _, codec := range cl {
+		replaced = append(replaced, replaceCodec(codec, replacement))
+	}
+	return replaced
+}
+
 type urlParamCodec struct{}
 
 // NewURLParamCodec returns a new URLParamCodec. This codec supports request decoding only.
 // If you want to url parameter that like a /hello/{name}, you can set the struct field tag like a `urlparam:"name"`.
+// The remaining path of the wildcard route like /files/* is bound by the tag `urlparam:"*"`.
 func NewURLParamCodec() *urlParamCodec {
 	return &urlParamCodec{}
 }
@@ -227,70 +301,134 @@ func (c *urlParamCodec) Name() string {
 }
 
 func (c *urlParamCodec) Decode(r *http.Request, v any) error {
+	if bind, ok := lookupBinder(c.Name(), v); ok {
+		if err := bind(r, v); err != nil {
+			return err
+		}
+		return ErrRequestContinueDecode
+	}
+
 	vr := reflect.ValueOf(v)
+	if vr.Kind() != reflect.Pointer || vr.IsNil() {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+	vr = vr.Elem()
+	// for the handler that receives the request as a pointer
 	if vr.Kind() == reflect.Pointer {
+		if vr.IsNil() {
+			vr.Set(reflect.New(vr.Type().Elem()))
+		}
 		vr = vr.Elem()
 	}
 	if vr.Kind() != reflect.Struct {
 		return ErrRequestNotSupportedAtThisCodec
 	}
+	if err := decodeURLParams(r, vr); err != nil {
+		return err
+	}
+	return ErrRequestContinueDecode
+}
+
+// decodeURLParams binds the URL parameters to the fields of the addressable struct vr and the nested structs.
+// The pointer fields are optional, and they are left nil when the parameters are empty.
+func decodeURLParams(r *http.Request, vr reflect.Value) error {
 	str := vr.Type()
 	for i := 0; i < vr.NumField(); i++ {
 		ft := str.Field(i)
 		field := vr.Field(i)
-		if ft.Type.Kind() == reflect.Struct {
-			if err := c.Decode(r, field.Interface()); err != nil {
+		param, _, _ := strings.Cut(ft.Tag.Get("urlparam"), ",")
+		if param == "" {
+			if !ft.IsExported() && !ft.Anonymous {
+				continue
+			}
+			if indirectType(ft.Type).Kind() != reflect.Struct || !hasStructTag(ft.Type, "urlparam") {
+				continue
+			}
+			if field.Kind() == reflect.Pointer {
+				if field.IsNil() {
+					if !field.CanSet() {
+						continue
+					}
+					field.Set(reflect.New(ft.Type.Elem()))
+				}
+				field = field.Elem()
+			}
+			if err := decodeURLParams(r, field); err != nil {
 				return fmt.Errorf("failed to decode field %s: %w", ft.Name, err)
 			}
 			continue
 		}
-		param := ft.Tag.Get("urlparam")
-		if param == "" {
+		if param == "-" || !field.CanSet() {
 			continue
 		}
 		paramValue := chi.URLParam(r, param)
-		if paramValue == "" {
-			return fmt.Errorf("url param %s is required at field %s", param, ft.Name)
-		}
-		switch field.Kind() {
-		case reflect.String:
-			field.SetString(paramValue)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			pi, err := strconv.ParseInt(paramValue, 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse int at field %s: %w", ft.Name, err)
-			}
-			field.SetInt(pi)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			pu, err := strconv.ParseUint(paramValue, 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse uint at field %s: %w", ft.Name, err)
-			}
-			field.SetUint(pu)
-		case reflect.Float32, reflect.Float64:
-			pf, err := strconv.ParseFloat(paramValue, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse float at field %s: %w", ft.Name, err)
+		if field.Kind() == reflect.Pointer {
+			if paramValue == "" {
+				continue
 			}
-			field.SetFloat(pf)
-		case reflect.Complex64, reflect.Complex128:
-			pc, err := strconv.ParseComplex(paramValue, 128)
-			if err != nil {
-				return fmt.Errorf("failed to parse complex at field %s: %w", ft.Name, err)
+			pv := reflect.New(ft.Type.Elem())
+			if err := setURLParam(pv.Elem(), ft.Name, paramValue); err != nil {
+				return err
 			}
-			field.SetComplex(pc)
-		case reflect.Bool:
-			pb, err := strconv.ParseBool(paramValue)
-			if err != nil {
-				return fmt.Errorf("failed to parse bool at field %s: %w", ft.Name, err)
+			field.Set(pv)
+			continue
+		}
+		// the catch-all segment of the wildcard route like /files/* can be empty
+		if paramValue == "" {
+			if param == "*" {
+				continue
 			}
-			field.SetBool(pb)
-		default:
-			return fmt.Errorf("unsupported type at field %s: %s", ft.Name, field.Kind())
+			return fmt.Errorf("url param %s is required at field %s", param, ft.Name)
+		}
+		if err := setURLParam(field, ft.Name, paramValue); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return ErrRequestContinueDecode
+func setURLParam(field reflect.Value, name, paramValue string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(paramValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pi, err := strconv.ParseInt(paramValue, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse int at field %s: %w", name, err)
+		}
+		field.SetInt(pi)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pu, err := strconv.ParseUint(paramValue, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse uint at field %s: %w", name, err)
+		}
+		field.SetUint(pu)
+	case reflect.Float32, reflect.Float64:
+		pf, err := strconv.ParseFloat(paramValue, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse float at field %s: %w", name, err)
+		}
+		field.SetFloat(pf)
+	case reflect.Complex64, reflect.Complex128:
+		pc, err := strconv.ParseComplex(paramValue, 128)
+		if err != nil {
+			return fmt.Errorf("failed to parse complex at field %s: %w", name, err)
+		}
+		field.SetComplex(pc)
+	case reflect.Bool:
+		pb, err := strconv.ParseBool(paramValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse bool at field %s: %w", name, err)
+		}
+		field.SetBool(pb)
+	default:
+		return fmt.Errorf("unsupported type at field %s: %s", name, field.Kind())
+	}
+	return nil
+}
+
+func (c *urlParamCodec) MatchRequestType(t reflect.Type) bool {
+	return hasStructTag(t, "urlparam")
 }
 
 func (c *urlParamCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
@@ -310,6 +448,12 @@ func (c *queryCodec) Name() string {
 }
 
 func (c *queryCodec) Decode(r *http.Request, v any) error {
+	if bind, ok := lookupBinder(c.Name(), v); ok {
+		if err := bind(r, v); err != nil {
+			return fmt.Errorf("failed to decode query: %w", err)
+		}
+		return ErrRequestContinueDecode
+	}
 	qs := r.URL.Query().Encode()
 	if err := urlquery.Unmarshal([]byte(qs), v); err != nil {
 		return fmt.Errorf("failed to decode query: %w", err)
@@ -317,24 +461,41 @@ func (c *queryCodec) Decode(r *http.Request, v any) error {
 	return ErrRequestContinueDecode
 }
 
-func (c *queryCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
-	return ErrResponseNotSupportedAtThisCodec
-}
-
-type renderDecoder struct {
-	r  io.Reader
-	rd func(r io.Reader, req any) error
+// MatchRequestType reports whether the type may be decoded by the query codec.
+// The query codec binds the fields without the query tag by the field name, so any struct that has exported fields is matched.
+func (c *queryCodec) MatchRequestType(t reflect.Type) bool {
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				return true
+			}
+		}
+		return false
+	case reflect.Map:
+		return true
+	}
+	return false
 }
 
-func (r *renderDecoder) Decode(v any) error {
-	return r.rd(r.r, v)
+func (c *queryCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return ErrResponseNotSupportedAtThisCodec
 }
 
 // RawBodyCodec is a codec that reads the request body as is.
-type RawBodyCodec struct{}
+// When the request type is io.ReadCloser, the body is passed to the handler as an unread stream without buffering.
+type RawBodyCodec struct {
+	maxBytes     int64
+	contentTypes []string
+}
 
-func NewRawBodyCodec() *RawBodyCodec {
-	return &RawBodyCodec{}
+func NewRawBodyCodec(opts ...RawBodyCodecOption) *RawBodyCodec {
+	r := &RawBodyCodec{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *RawBodyCodec) Name() string { return "rawbody" }
@@ -343,6 +504,24 @@ func (r *RawBodyCodec) assignableToReadCloser(t reflect.Type) bool {
 	return t.AssignableTo(reflect.TypeOf((*io.ReadCloser)(nil)).Elem())
 }
 
+func (r *RawBodyCodec) MatchRequestType(t reflect.Type) bool {
+	if r.assignableToReadCloser(t) || r.assignableToReadCloser(reflect.PointerTo(t)) {
+		return true
+	}
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := t.Field(i).Tag.Lookup("rawbody"); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (r *RawBodyCodec) Decode(req *http.Request, v any) error {
 	vr := reflect.ValueOf(v)
 	var target reflect.Value
@@ -375,18 +554,26 @@ func (r *RawBodyCodec) Decode(req *http.Request, v any) error {
 		return ErrRequestNotSupportedAtThisCodec
 	}
 
+	cfg := r.config(req)
+	if err := cfg.checkContentType(req); err != nil {
+		return err
+	}
+	if cfg.maxBytes > 0 && req.ContentLength > cfg.maxBytes {
+		return WrapErrorWithStatus(http.StatusRequestEntityTooLarge, ErrRawBodyTooLarge)
+	}
+
 	tt := target.Type()
 	if tt.Kind() == reflect.Slice && tt.Elem().Kind() == reflect.Uint8 {
-		bs, err := io.ReadAll(req.Body)
+		bs, err := cfg.readAll(req.Body)
 		if err != nil {
-			return fmt.Errorf("failed to read body: %w", err)
+			return err
 		}
 		target.Set(reflect.ValueOf(bs))
 		if err := req.Body.Close(); err != nil {
 			return nil
 		}
 	} else if r.assignableToReadCloser(tt) {
-		target.Set(reflect.ValueOf(req.Body))
+		target.Set(reflect.ValueOf(cfg.stream(req.Body)))
 	} else {
 		return fmt.Errorf("unsupported type %s for field %s", target.Type().Name(), fieldName)
 	}
@@ -395,22 +582,29 @@ func (r *RawBodyCodec) Decode(req *http.Request, v any) error {
 }
 
 func (r *RawBodyCodec) Encode(w http.ResponseWriter, req *http.Request, v any) error {
-	vr := reflect.ValueOf(v)
-	if vr.Kind() == reflect.Slice && vr.Type().Elem().Kind() == reflect.Uint8 {
-		if _, err := w.Write(vr.Bytes()); err != nil {
+	switch body := v.(type) {
+	case *RawResponse:
+		if body == nil {
+			w.Header().Set("content-length", "0")
+			return nil
+		}
+		return body.write(w, req)
+	case *FileResponse:
+		return body.write(w, req)
+	case []byte:
+		if w.Header().Get("content-type") == "" {
+			w.Header().Set("content-type", http.DetectContentType(body))
+		}
+		if _, err := w.Write(body); err != nil {
 			return fmt.Errorf("failed to write body: %w", err)
 		}
 		return nil
+	case io.Reader:
+		return (&RawResponse{Body: body, ContentLength: -1}).write(w, req)
 	}
-	if r, ok := vr.Interface().(io.Reader); ok {
-		if _, err := io.Copy(w, r); err != nil {
-			return fmt.Errorf("failed to write body: %w", err)
-		}
-		if closer, ok := vr.Interface().(io.Closer); ok {
-			if err := closer.Close(); err != nil {
-				return fmt.Errorf("failed to close body: %w", err)
-			}
-		}
+	// the named byte slice types (e.g. type Blob []byte) are not matched by the []byte case
+	if vr := reflect.ValueOf(v); vr.Kind() == reflect.Slice && vr.Type().Elem().Kind() == reflect.Uint8 {
+		return r.Encode(w, req, vr.Bytes())
 	}
 
 	return ErrResponseNotSupportedAtThisCodec
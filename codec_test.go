@@ -0,0 +1,154 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type matchedRequest struct {
+	Name string `json:"name"`
+}
+
+type unmatchedRequest struct {
+	Name string `json:"name"`
+}
+
+type nameResponse struct {
+	Name string `json:"name"`
+}
+
+// typeMatchCodec decodes only matchedRequest. It fails on any other type, so it must be skipped by the request type.
+type typeMatchCodec struct {
+	decodes atomic.Int64
+}
+
+func (c *typeMatchCodec) Name() string {
+	return "typematch"
+}
+
+func (c *typeMatchCodec) MatchRequestType(t reflect.Type) bool {
+	return t == reflect.TypeFor[matchedRequest]()
+}
+
+func (c *typeMatchCodec) Decode(r *http.Request, v any) error {
+	c.decodes.Add(1)
+	req, ok := v.(*matchedRequest)
+	if !ok {
+		return errors.New("typematch codec cannot decode this type")
+	}
+	req.Name = "matched"
+	return nil
+}
+
+func (c *typeMatchCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return tanukirpc.ErrResponseNotSupportedAtThisCodec
+}
+
+func postJSON(router http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCodecRequestTypeMatcher(t *testing.T) {
+	matcher := &typeMatchCodec{}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithCodec[struct{}](tanukirpc.CodecList{
+		matcher,
+		tanukirpc.NewJSONCodec(),
+	}))
+	router.Post("/matched", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req matchedRequest) (*nameResponse, error) {
+		return &nameResponse{Name: req.Name}, nil
+	}))
+	router.Post("/unmatched", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req unmatchedRequest) (*nameResponse, error) {
+		return &nameResponse{Name: req.Name}, nil
+	}))
+
+	t.Run("matcher codec is chosen over the default", func(t *testing.T) {
+		rec := postJSON(router, "/matched", `{"name":"json"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.JSONEq(t, `{"name":"matched"}`, rec.Body.String())
+		assert.Equal(t, int64(1), matcher.decodes.Load())
+	})
+	t.Run("falls back when the request type does not match", func(t *testing.T) {
+		rec := postJSON(router, "/unmatched", `{"name":"json"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.JSONEq(t, `{"name":"json"}`, rec.Body.String())
+		assert.Equal(t, int64(1), matcher.decodes.Load(), "the matcher codec is skipped")
+	})
+}
+
+type validateInner struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validateNestedRequest struct {
+	Inner validateInner `json:"inner"`
+}
+
+type validatePointerRequest struct {
+	Inner *validateInner `json:"inner"`
+}
+
+type noValidateRequest struct {
+	Inner *struct {
+		Name string `json:"name"`
+	} `json:"inner"`
+}
+
+func TestCodecValidateTagLookup(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	nested := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req validateNestedRequest) (*nameResponse, error) {
+		return &nameResponse{Name: req.Inner.Name}, nil
+	})
+	pointer := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req validatePointerRequest) (*nameResponse, error) {
+		if req.Inner == nil {
+			return &nameResponse{}, nil
+		}
+		return &nameResponse{Name: req.Inner.Name}, nil
+	})
+	noValidate := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req noValidateRequest) (*nameResponse, error) {
+		return &nameResponse{}, nil
+	})
+	router.Post("/nested", nested)
+	router.Post("/pointer", pointer)
+	router.Post("/novalidate", noValidate)
+
+	assert.True(t, nested.Metadata().HasValidateTag)
+	assert.True(t, pointer.Metadata().HasValidateTag)
+	assert.False(t, noValidate.Metadata().HasValidateTag)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "nested struct invalid", path: "/nested", body: `{"inner":{}}`, status: http.StatusBadRequest},
+		{name: "nested struct valid", path: "/nested", body: `{"inner":{"name":"a"}}`, status: http.StatusOK},
+		{name: "pointer struct invalid", path: "/pointer", body: `{"inner":{}}`, status: http.StatusBadRequest},
+		{name: "pointer struct valid", path: "/pointer", body: `{"inner":{"name":"a"}}`, status: http.StatusOK},
+		{name: "pointer struct nil", path: "/pointer", body: `{}`, status: http.StatusOK},
+		{name: "no validate tag", path: "/novalidate", body: `{"inner":{}}`, status: http.StatusOK},
+	}
+	// Run twice so that the second round uses the cached lookups.
+	for round := 0; round < 2; round++ {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := postJSON(router, tt.path, tt.body)
+				assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			})
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package tanukirpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// DecodeFunc is the function that decodes the request into v like Codec.Decode.
+type DecodeFunc func(r *http.Request, v any) error
+
+// EncodeFunc is the function that encodes v as the response like Codec.Encode.
+type EncodeFunc func(w http.ResponseWriter, r *http.Request, v any) error
+
+// CodecMiddleware wraps the Decode and the Encode of the Codec of the Router, for the cross-cutting concerns of the bodies
+// like the encryption of the fields, the unwrapping of the envelopes and the conversion of the keys, without writing the whole Codec.
+// next is the Decode or the Encode of the Codec, or the next CodecMiddleware.
+type CodecMiddleware interface {
+	Decode(r *http.Request, v any, next DecodeFunc) error
+	Encode(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error
+}
+
+// DecodeMiddlewareFunc is an adapter to allow the use of ordinary functions as CodecMiddleware that wraps only Decode.
+type DecodeMiddlewareFunc func(r *http.Request, v any, next DecodeFunc) error
+
+func (f DecodeMiddlewareFunc) Decode(r *http.Request, v any, next DecodeFunc) error {
+	return f(r, v, next)
+}
+
+func (f DecodeMiddlewareFunc) Encode(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error {
+	return next(w, r, v)
+}
+
+// EncodeMiddlewareFunc is an adapter to allow the use of ordinary functions as CodecMiddleware that wraps only Encode.
+type EncodeMiddlewareFunc func(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error
+
+func (f EncodeMiddlewareFunc) Decode(r *http.Request, v any, next DecodeFunc) error {
+	return next(r, v)
+}
+
+func (f EncodeMiddlewareFunc) Encode(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error {
+	return f(w, r, v, next)
+}
+
+// WithCodecMiddleware adds the CodecMiddlewares that wrap the Codec of the Router. The first one is the outermost.
+//
+//	// decrypt the fields of the requests and encrypt the fields of the responses
+//	tanukirpc.WithCodecMiddleware[*registry](tanukirpc.DecodeMiddlewareFunc(func(r *http.Request, v any, next tanukirpc.DecodeFunc) error {
+//		if err := next(r, v); err != nil {
+//			return err
+//		}
+//		return decryptFields(v)
+//	}))
+func WithCodecMiddleware[Reg any](mws ...CodecMiddleware) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.codecMiddlewares = append(r.codecMiddlewares[:len(r.codecMiddlewares):len(r.codecMiddlewares)], mws...)
+		return r
+	}
+}
+
+// withCodecMiddlewares returns the codec that is wrapped by the CodecMiddlewares of the Router.
+func (r *Router[Reg]) withCodecMiddlewares(c Codec) Codec {
+	if len(r.codecMiddlewares) == 0 {
+		return c
+	}
+	return &middlewareCodec{Codec: c, mws: r.codecMiddlewares}
+}
+
+type middlewareCodec struct {
+	Codec
+	mws []CodecMiddleware
+}
+
+func (c *middlewareCodec) Decode(r *http.Request, v any) error {
+	decode := c.Codec.Decode
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		mw, next := c.mws[i], decode
+		decode = func(r *http.Request, v any) error {
+			return mw.Decode(r, v, next)
+		}
+	}
+	return decode(r, v)
+}
+
+func (c *middlewareCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	encode := c.Codec.Encode
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		mw, next := c.mws[i], encode
+		encode = func(w http.ResponseWriter, r *http.Request, v any) error {
+			return mw.Encode(w, r, v, next)
+		}
+	}
+	return encode(w, r, v)
+}
+
+// BodyTransformFunc transforms the encoded body of the request or the response.
+type BodyTransformFunc func(r *http.Request, body []byte) ([]byte, error)
+
+// TransformRequestBody returns the CodecMiddleware that transforms the request body before it is decoded, like unwrapping the envelope of {"data": ...}.
+// The empty bodies and the bodies of the requests that receive io.ReadCloser are not transformed.
+func TransformRequestBody(fn BodyTransformFunc) CodecMiddleware {
+	return DecodeMiddlewareFunc(func(r *http.Request, v any, next DecodeFunc) error {
+		if r.Body == nil || r.Body == http.NoBody || isRawBodyStreamType(reflect.TypeOf(v)) {
+			return next(r, v)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return &ErrCodecDecode{err: fmt.Errorf("failed to read request body: %w", err)}
+		}
+		if err := r.Body.Close(); err != nil {
+			return &ErrCodecDecode{err: fmt.Errorf("failed to close request body: %w", err)}
+		}
+		if len(body) > 0 {
+			if body, err = fn(r, body); err != nil {
+				return &ErrCodecDecode{err: err}
+			}
+		}
+		r2 := r.WithContext(r.Context())
+		r2.Body = io.NopCloser(bytes.NewReader(body))
+		r2.ContentLength = int64(len(body))
+		return next(r2, v)
+	})
+}
+
+// TransformResponseBody returns the CodecMiddleware that transforms the response body after it is encoded, like converting the keys of the JSON.
+// The raw responses and the streaming responses are not transformed.
+func TransformResponseBody(fn BodyTransformFunc) CodecMiddleware {
+	return EncodeMiddlewareFunc(func(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error {
+		if isRawResponse(v) || isSeqResponse(v) {
+			return next(w, r, v)
+		}
+		cw := &captureResponseWriter{ResponseWriter: w}
+		if err := next(cw, r, v); err != nil {
+			return err
+		}
+		body := cw.buf.Bytes()
+		if len(body) > 0 {
+			var err error
+			if body, err = fn(r, body); err != nil {
+				return &ErrCodecEncode{err: err}
+			}
+		}
+		if _, err := w.Write(body); err != nil {
+			return fmt.Errorf("failed to write body: %w", err)
+		}
+		return nil
+	})
+}
+
+// captureResponseWriter captures the body that is written by the Codec.
+type captureResponseWriter struct {
+	http.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (c *captureResponseWriter) Write(b []byte) (int, error) {
+	return c.buf.Write(b)
+}
@@ -0,0 +1,109 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretTask struct {
+	Title  string `json:"title"`
+	Secret string `json:"secret"`
+}
+
+func TestCodecMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) tanukirpc.CodecMiddleware {
+		return tanukirpc.DecodeMiddlewareFunc(func(r *http.Request, v any, next tanukirpc.DecodeFunc) error {
+			order = append(order, name)
+			return next(r, v)
+		})
+	}
+	// unwrap the envelope of {"data": ...}
+	unwrap := tanukirpc.TransformRequestBody(func(r *http.Request, body []byte) ([]byte, error) {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, err
+		}
+		return envelope.Data, nil
+	})
+	// the field encryption that reverses the secrets
+	reverse := func(s string) string {
+		rs := []rune(s)
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+		return string(rs)
+	}
+	decrypt := tanukirpc.DecodeMiddlewareFunc(func(r *http.Request, v any, next tanukirpc.DecodeFunc) error {
+		if err := next(r, v); err != nil {
+			return err
+		}
+		if task, ok := v.(*secretTask); ok {
+			task.Secret = reverse(task.Secret)
+		}
+		return nil
+	})
+	encrypt := tanukirpc.EncodeMiddlewareFunc(func(w http.ResponseWriter, r *http.Request, v any, next tanukirpc.EncodeFunc) error {
+		if task, ok := v.(*secretTask); ok {
+			v = &secretTask{Title: task.Title, Secret: reverse(task.Secret)}
+		}
+		return next(w, r, v)
+	})
+	upper := tanukirpc.TransformResponseBody(func(r *http.Request, body []byte) ([]byte, error) {
+		return bytes.ToUpper(body), nil
+	})
+
+	var received *secretTask
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithCodecMiddleware[struct{}](trace("outer"), unwrap, decrypt, encrypt, trace("inner")))
+	router.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req secretTask) (*secretTask, error) {
+		received = &req
+		return &req, nil
+	}))
+	router.Route("/upper", func(r *tanukirpc.Router[struct{}]) {
+		r = r.With()
+		r.Post("/", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req secretTask) (*secretTask, error) {
+			return &req, nil
+		}))
+	})
+	upperRouter := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithCodecMiddleware[struct{}](unwrap), tanukirpc.WithCodecMiddleware[struct{}](upper))
+	upperRouter.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req secretTask) (*secretTask, error) {
+		return &req, nil
+	}))
+
+	post := func(h http.Handler, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(router, "/tasks", `{"data":{"title":"buy milk","secret":"terces"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, &secretTask{Title: "buy milk", Secret: "secret"}, received)
+	assert.JSONEq(t, `{"title":"buy milk","secret":"terces"}`, rec.Body.String())
+	assert.Equal(t, []string{"outer", "inner"}, order)
+
+	rec = post(router, "/upper/", `{"data":{"title":"buy milk","secret":"terces"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"title":"buy milk","secret":"terces"}`, rec.Body.String(), "the sub routers share the middlewares")
+
+	rec = post(upperRouter, "/tasks", `{"data":{"title":"buy milk","secret":"s"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"TITLE":"BUY MILK","SECRET":"S"}`, rec.Body.String())
+	assert.Equal(t, "34", rec.Header().Get("Content-Length"))
+
+	rec = post(upperRouter, "/tasks", `{"title":"no envelope"`)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,126 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ErrConcurrencyLimit is the error of the requests that are rejected by the concurrency limit. It is reported with 503 Service Unavailable.
+var ErrConcurrencyLimit = errors.New("too many concurrent requests")
+
+type concurrencyLimiter struct {
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+// ConcurrencyLimitOption is the option of WithConcurrencyLimit and Router.LimitConcurrency.
+type ConcurrencyLimitOption func(*concurrencyLimiter)
+
+// WithConcurrencyQueue lets the requests over the limit wait for the slot up to timeout, instead of rejecting them immediately.
+// size is the number of the waiting requests, and the requests over it are rejected. The zero timeout waits until the request is canceled.
+func WithConcurrencyQueue(size int, timeout time.Duration) ConcurrencyLimitOption {
+	return func(l *concurrencyLimiter) {
+		l.queue = make(chan struct{}, size)
+		l.timeout = timeout
+	}
+}
+
+func newConcurrencyLimiter(limit int, opts ...ConcurrencyLimitOption) *concurrencyLimiter {
+	if limit <= 0 {
+		panic(fmt.Sprintf("tanukirpc: concurrency limit must be positive: %d", limit))
+	}
+	l := &concurrencyLimiter{slots: make(chan struct{}, limit)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithConcurrencyLimit caps the number of the requests that are handled concurrently by the Router. It panics if limit is not positive.
+// The requests over the limit are rejected with 503 Service Unavailable by the ErrorHooker, or wait in the queue of WithConcurrencyQueue.
+// Use Router.LimitConcurrency to cap the routes that share the constrained resource like SQLite.
+func WithConcurrencyLimit[Reg any](limit int, opts ...ConcurrencyLimitOption) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.concurrencyLimiter = newConcurrencyLimiter(limit, opts...)
+		return r
+	}
+}
+
+// LimitConcurrency returns the Router whose routes share the limit of the requests that are handled concurrently.
+// The limit is shared by the routes registered to the returned Router, and not by the others.
+//
+//	w := r.LimitConcurrency(1, tanukirpc.WithConcurrencyQueue(100, 5*time.Second))
+//	w.Post("/tasks", tanukirpc.NewHandler(createTask))
+//	w.Put("/tasks/{id}", tanukirpc.NewHandler(updateTask))
+func (r *Router[Reg]) LimitConcurrency(limit int, opts ...ConcurrencyLimitOption) *Router[Reg] {
+	l := newConcurrencyLimiter(limit, opts...)
+	return r.With(func(next http.Handler) http.Handler {
+		return r.concurrencyLimitMiddleware(l, next)
+	})
+}
+
+// acquire takes the slot. It returns false if the request is rejected.
+func (l *concurrencyLimiter) acquire(ctx gocontext.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if cap(l.queue) == 0 {
+		return false
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-l.queue }()
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		t := time.NewTimer(l.timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+func (r *Router[Reg]) concurrencyLimitMiddleware(l *concurrencyLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if l == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		t1 := time.Now()
+		if l.acquire(req.Context()) {
+			defer l.release()
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		err := WrapErrorWithStatus(http.StatusServiceUnavailable, ErrConcurrencyLimit)
+		r.onError(ww, req, nil, ErrorStageConcurrencyLimit, err)
+		if err := r.accessLoggerLog(req.Context(), ww, req, err, t1, time.Now()); err != nil {
+			r.logger.ErrorContext(req.Context(), "access log error", slog.Any("error", err))
+		}
+	})
+}
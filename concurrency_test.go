@@ -0,0 +1,128 @@
+package tanukirpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	serve := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	newRouter := func(release <-chan struct{}, started chan<- struct{}, opts ...tanukirpc.ConcurrencyLimitOption) *tanukirpc.Router[struct{}] {
+		router := tanukirpc.NewRouter(struct{}{})
+		router.LimitConcurrency(1, opts...).Get("/slow", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			started <- struct{}{}
+			<-release
+			return "done", nil
+		}))
+		router.Get("/fast", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			return "done", nil
+		}))
+		return router
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		release, started := make(chan struct{}), make(chan struct{}, 1)
+		router := newRouter(release, started)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, serve(router, "/slow").Code)
+		}()
+		<-started
+
+		rec := serve(router, "/slow")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"error":{"message":"too many concurrent requests"}}`, rec.Body.String())
+		assert.Equal(t, http.StatusOK, serve(router, "/fast").Code, "other routes are not limited")
+
+		close(release)
+		wg.Wait()
+		assert.Equal(t, http.StatusOK, serve(router, "/slow").Code, "the slot is released")
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		release, started := make(chan struct{}), make(chan struct{}, 2)
+		router := newRouter(release, started, tanukirpc.WithConcurrencyQueue(1, time.Minute))
+		var wg sync.WaitGroup
+		wg.Add(2)
+		for range 2 {
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, http.StatusOK, serve(router, "/slow").Code, "the queued request is handled")
+			}()
+		}
+		<-started
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(router, "/slow").Code, "the queue is full")
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		release, started := make(chan struct{}), make(chan struct{}, 1)
+		router := newRouter(release, started, tanukirpc.WithConcurrencyQueue(1, 50*time.Millisecond))
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(router, "/slow")
+		}()
+		<-started
+
+		t1 := time.Now()
+		assert.Equal(t, http.StatusServiceUnavailable, serve(router, "/slow").Code)
+		assert.GreaterOrEqual(t, time.Since(t1), 50*time.Millisecond)
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("router", func(t *testing.T) {
+		release, started := make(chan struct{}), make(chan struct{}, 1)
+		router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithConcurrencyLimit[struct{}](1))
+		router.Get("/slow", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			started <- struct{}{}
+			<-release
+			return "done", nil
+		}))
+		router.Get("/fast", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			return "done", nil
+		}))
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(router, "/slow")
+		}()
+		<-started
+		assert.Equal(t, http.StatusServiceUnavailable, serve(router, "/fast").Code)
+
+		close(release)
+		wg.Wait()
+		assert.Equal(t, http.StatusOK, serve(router, "/fast").Code)
+	})
+	t.Run("invalid limit", func(t *testing.T) {
+		for _, limit := range []int{0, -1} {
+			assert.Panics(t, func() {
+				tanukirpc.NewRouter(struct{}{}, tanukirpc.WithConcurrencyLimit[struct{}](limit))
+			})
+			assert.Panics(t, func() {
+				tanukirpc.NewRouter(struct{}{}).LimitConcurrency(limit)
+			})
+		}
+	})
+}
@@ -0,0 +1,264 @@
+// Package config builds the options of the Router and ListenAndServe from the YAML file and the environment variables,
+// so the services do not rewrite the same plumbing of the timeouts, the log level, CORS, the body limit and TLS.
+//
+//	cfg, err := config.Load("server.yaml", "APP")
+//	if err != nil {
+//		return err
+//	}
+//	r := tanukirpc.NewRouter(reg, config.RouterOptions[*registry](cfg)...)
+//	// ...
+//	return r.ListenAndServe(ctx, cfg.Addr, cfg.ListenAndServeOptions()...)
+//
+// The YAML file is like the following, and the keys that are omitted have the defaults of Default:
+//
+//	addr: :8443
+//	log_level: debug
+//	log_format: json
+//	max_body_bytes: 1048576
+//	timeouts:
+//	  read_header: 5s
+//	  write: 30s
+//	  request: 10s
+//	cors:
+//	  allowed_origins: ["https://app.example.com"]
+//	  allow_credentials: true
+//	  max_age: 10m
+//	tls:
+//	  cert_file: /etc/tls/tls.crt
+//	  key_file: /etc/tls/tls.key
+//
+// The environment variables override the file. Their names are the prefix and the keys joined by "_" in upper case,
+// like APP_TIMEOUTS_WRITE=30s and APP_CORS_ALLOWED_ORIGINS=https://a.example.com,https://b.example.com.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/internal/requestid"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the Router and ListenAndServe.
+type Config struct {
+	// Addr is the address that the server listens on. The default is ":8080".
+	Addr string `yaml:"addr"`
+	// LogLevel is the level of the logger of the Router: debug, info, warn or error. The default is "info".
+	LogLevel string `yaml:"log_level"`
+	// LogFormat is the format of the logger of the Router: text or json. The default is "text".
+	LogFormat string `yaml:"log_format"`
+	// MaxHeaderBytes is http.Server.MaxHeaderBytes. Zero is the default of net/http.
+	MaxHeaderBytes int `yaml:"max_header_bytes"`
+	// MaxBodyBytes limits the size of the request bodies. The default is 10 MiB, and zero disables the limit.
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// Timeouts are the timeouts of the server and the requests.
+	Timeouts Timeouts `yaml:"timeouts"`
+	// CORS is the configuration of CORS. CORS is disabled without the allowed origins.
+	CORS CORS `yaml:"cors"`
+	// TLS is the certificate to serve HTTPS. The server serves HTTP without them.
+	TLS TLS `yaml:"tls"`
+}
+
+// Timeouts are the timeouts of the server and the requests. Zero means no timeout.
+type Timeouts struct {
+	// Read is http.Server.ReadTimeout.
+	Read time.Duration `yaml:"read"`
+	// ReadHeader is http.Server.ReadHeaderTimeout. The default is 10 seconds.
+	ReadHeader time.Duration `yaml:"read_header"`
+	// Write is http.Server.WriteTimeout. It is not set by default for the streaming responses.
+	Write time.Duration `yaml:"write"`
+	// Idle is http.Server.IdleTimeout. The default is 2 minutes.
+	Idle time.Duration `yaml:"idle"`
+	// Request is the limit of the deadline of the requests. See tanukirpc.WithRequestDeadline.
+	Request time.Duration `yaml:"request"`
+}
+
+// CORS is the configuration of the Cross-Origin Resource Sharing.
+type CORS struct {
+	// AllowedOrigins are the origins like "https://app.example.com", or "*" for all the origins.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// AllowedMethods are the methods of the preflight requests. The default is GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string `yaml:"allowed_methods"`
+	// AllowedHeaders are the request headers of the preflight requests. The default is Accept, Authorization, Content-Type and X-Request-ID.
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	// ExposedHeaders are the response headers that the browsers expose to the scripts.
+	ExposedHeaders []string `yaml:"exposed_headers"`
+	// AllowCredentials allows the cookies and the authorization headers. It can not be used with the origin "*".
+	AllowCredentials bool `yaml:"allow_credentials"`
+	// MaxAge is the duration that the browsers cache the result of the preflight requests.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// TLS is the certificate and the key files to serve HTTPS.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Default returns the configuration of the defaults.
+func Default() *Config {
+	return &Config{
+		Addr:         ":8080",
+		LogLevel:     "info",
+		LogFormat:    "text",
+		MaxBodyBytes: 10 << 20,
+		Timeouts: Timeouts{
+			ReadHeader: 10 * time.Second,
+			Idle:       2 * time.Minute,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{
+				http.MethodGet, http.MethodHead, http.MethodPost,
+				http.MethodPut, http.MethodPatch, http.MethodDelete,
+			},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
+		},
+	}
+}
+
+// Load reads the YAML file of path over the defaults, overrides it by the environment variables of the prefix, and validates it.
+// The file is skipped if path is empty, and the environment variables are skipped if prefix is empty.
+func Load(path string, prefix string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w filepath=%s", err, path)
+		}
+	}
+	if prefix != "" {
+		if err := cfg.loadEnv(prefix, os.LookupEnv); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration and returns all the errors.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr is required"))
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("unknown log format: %s", c.LogFormat))
+	}
+	if c.MaxHeaderBytes < 0 {
+		errs = append(errs, fmt.Errorf("max_header_bytes must not be negative: %d", c.MaxHeaderBytes))
+	}
+	if c.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("max_body_bytes must not be negative: %d", c.MaxBodyBytes))
+	}
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"timeouts.read", c.Timeouts.Read},
+		{"timeouts.read_header", c.Timeouts.ReadHeader},
+		{"timeouts.write", c.Timeouts.Write},
+		{"timeouts.idle", c.Timeouts.Idle},
+		{"timeouts.request", c.Timeouts.Request},
+		{"cors.max_age", c.CORS.MaxAge},
+	}
+	for _, v := range durations {
+		if v.d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative: %s", v.name, v.d))
+		}
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				errs = append(errs, errors.New("cors.allow_credentials can not be used with the origin *"))
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("invalid cors origin: %s", origin))
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("both tls.cert_file and tls.key_file are required"))
+	}
+	return errors.Join(errs...)
+}
+
+// RouterOptions returns the options of the Router: the logger of the level, the deadline of the requests, CORS and the body limit.
+// The middlewares of CORS and the body limit are added before the default middlewares, so the preflight requests are responded first.
+func RouterOptions[Reg any](c *Config) []tanukirpc.RouterOption[Reg] {
+	level, _ := parseLogLevel(c.LogLevel)
+	opts := []tanukirpc.RouterOption[Reg]{
+		tanukirpc.WithLogger[Reg](newLogger(c.LogFormat, level)),
+	}
+	if c.Timeouts.Request > 0 {
+		opts = append(opts, tanukirpc.WithRequestDeadline[Reg](c.Timeouts.Request))
+	}
+	var middlewares []func(http.Handler) http.Handler
+	if len(c.CORS.AllowedOrigins) > 0 {
+		middlewares = append(middlewares, c.CORS.middleware)
+	}
+	if c.MaxBodyBytes > 0 {
+		middlewares = append(middlewares, maxBodyBytes(c.MaxBodyBytes))
+	}
+	if len(middlewares) > 0 {
+		opts = append(opts, func(r *tanukirpc.Router[Reg]) *tanukirpc.Router[Reg] {
+			r.Use(middlewares...)
+			return r
+		})
+	}
+	return opts
+}
+
+// ListenAndServeOptions returns the options of ListenAndServe: the timeouts, the max header bytes and TLS.
+func (c *Config) ListenAndServeOptions() []tanukirpc.ListenAndServeOption {
+	opts := []tanukirpc.ListenAndServeOption{
+		tanukirpc.WithReadTimeout(c.Timeouts.Read),
+		tanukirpc.WithReadHeaderTimeout(c.Timeouts.ReadHeader),
+		tanukirpc.WithWriteTimeout(c.Timeouts.Write),
+		tanukirpc.WithIdleTimeout(c.Timeouts.Idle),
+	}
+	if c.MaxHeaderBytes > 0 {
+		opts = append(opts, tanukirpc.WithMaxHeaderBytes(c.MaxHeaderBytes))
+	}
+	if c.TLS.CertFile != "" {
+		opts = append(opts, tanukirpc.WithTLS(c.TLS.CertFile, c.TLS.KeyFile))
+	}
+	return opts
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	levelMap := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	lv, ok := levelMap[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level: %s", s)
+	}
+	return lv, nil
+}
+
+func newLogger(format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	return tanukirpc.NewLogger(slog.New(handler), []fmt.Stringer{requestid.RequestIDKey})
+}
@@ -0,0 +1,166 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.Load("", "")
+		require.NoError(t, err)
+		assert.Equal(t, config.Default(), cfg)
+		assert.Equal(t, ":8080", cfg.Addr)
+		assert.Equal(t, 10*time.Second, cfg.Timeouts.ReadHeader)
+	})
+	t.Run("file and env", func(t *testing.T) {
+		path := writeConfig(t, `
+addr: :8443
+log_level: debug
+max_body_bytes: 1024
+timeouts:
+  write: 30s
+  request: 10s
+cors:
+  allowed_origins: ["https://app.example.com"]
+  allow_credentials: true
+  max_age: 10m
+tls:
+  cert_file: /etc/tls/tls.crt
+  key_file: /etc/tls/tls.key
+`)
+		t.Setenv("APP_TIMEOUTS_WRITE", "1m")
+		t.Setenv("APP_LOG_FORMAT", "json")
+		t.Setenv("APP_CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+		t.Setenv("APP_CORS_EXPOSED_HEADERS", "X-Request-ID")
+
+		cfg, err := config.Load(path, "app")
+		require.NoError(t, err)
+		assert.Equal(t, ":8443", cfg.Addr)
+		assert.Equal(t, "debug", cfg.LogLevel)
+		assert.Equal(t, "json", cfg.LogFormat)
+		assert.Equal(t, int64(1024), cfg.MaxBodyBytes)
+		assert.Equal(t, time.Minute, cfg.Timeouts.Write)
+		assert.Equal(t, 10*time.Second, cfg.Timeouts.Request)
+		assert.Equal(t, 2*time.Minute, cfg.Timeouts.Idle)
+		assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowedOrigins)
+		assert.Equal(t, []string{"X-Request-ID"}, cfg.CORS.ExposedHeaders)
+		assert.Equal(t, config.Default().CORS.AllowedMethods, cfg.CORS.AllowedMethods)
+		assert.True(t, cfg.CORS.AllowCredentials)
+		assert.Equal(t, 10*time.Minute, cfg.CORS.MaxAge)
+		assert.Equal(t, config.TLS{CertFile: "/etc/tls/tls.crt", KeyFile: "/etc/tls/tls.key"}, cfg.TLS)
+		assert.Len(t, cfg.ListenAndServeOptions(), 5)
+	})
+	t.Run("invalid env", func(t *testing.T) {
+		t.Setenv("APP_TIMEOUTS_READ", "soon")
+		_, err := config.Load("", "APP")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse APP_TIMEOUTS_READ")
+	})
+	t.Run("file not found", func(t *testing.T) {
+		_, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"), "")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("validation", func(t *testing.T) {
+		path := writeConfig(t, `
+addr: ""
+log_level: verbose
+max_body_bytes: -1
+timeouts:
+  idle: -1s
+cors:
+  allowed_origins: ["*", "app.example.com"]
+  allow_credentials: true
+tls:
+  cert_file: /etc/tls/tls.crt
+`)
+		_, err := config.Load(path, "")
+		require.Error(t, err)
+		for _, msg := range []string{
+			"addr is required",
+			"unknown log level: verbose",
+			"max_body_bytes must not be negative: -1",
+			"timeouts.idle must not be negative: -1s",
+			"cors.allow_credentials can not be used with the origin *",
+			"invalid cors origin: app.example.com",
+			"both tls.cert_file and tls.key_file are required",
+		} {
+			assert.Contains(t, err.Error(), msg)
+		}
+	})
+}
+
+func TestRouterOptions(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxBodyBytes = 16
+	cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.CORS.ExposedHeaders = []string{"X-Request-ID"}
+	cfg.CORS.MaxAge = 10 * time.Minute
+	require.NoError(t, cfg.Validate())
+
+	r := tanukirpc.NewRouter(struct{}{}, config.RouterOptions[struct{}](cfg)...)
+	r.Post("/echo", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		Message string `json:"message"`
+	}) (*struct {
+		Message string `json:"message"`
+	}, error) {
+		return &struct {
+			Message string `json:"message"`
+		}{Message: req.Message}, nil
+	}))
+
+	serve := func(method, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/echo", strings.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		rec := serve(http.MethodOptions, "", map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": http.MethodPost,
+		})
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	})
+	t.Run("allowed origin", func(t *testing.T) {
+		rec := serve(http.MethodPost, `{"message":"hi"}`, map[string]string{"Origin": "https://app.example.com"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+	})
+	t.Run("other origin", func(t *testing.T) {
+		rec := serve(http.MethodPost, `{"message":"hi"}`, map[string]string{"Origin": "https://evil.example.com"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("body limit", func(t *testing.T) {
+		rec := serve(http.MethodPost, `{"message":"too long message"}`, nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
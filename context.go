@@ -2,6 +2,7 @@ package tanukirpc
 
 import (
 	gocontext "context"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
@@ -23,6 +24,8 @@ type context[Reg any] struct {
 	res           http.ResponseWriter
 	registry      Reg
 	deferStackMap deferStackMap
+	resultDefers  deferStack
+	result        deferResult
 }
 
 type ContextFactory[Reg any] interface {
@@ -76,7 +79,11 @@ func (c *context[Reg]) Defer(fn DeferFunc, timings ...DeferDoTiming) {
 }
 
 func (c *context[Reg]) DeferDo(timing DeferDoTiming) error {
-	return c.deferStackMap.do(timing)
+	return c.deferStackMap.do(timing, false, nil)
+}
+
+func (c *context[Reg]) deferDo(timing DeferDoTiming, joinErrors bool, onError func(*DeferFuncError)) error {
+	return c.deferStackMap.do(timing, joinErrors, onError)
 }
 
 type contextHookFactory[Reg any] struct {
@@ -201,14 +208,14 @@ func (d *deferStackMap) push(t DeferDoTiming, da *deferAction) {
 	(*d)[t].push(da)
 }
 
-func (d *deferStackMap) do(t DeferDoTiming) error {
+func (d *deferStackMap) do(t DeferDoTiming, joinErrors bool, onError func(*DeferFuncError)) error {
 	if *d == nil {
 		return nil
 	}
 	if _, ok := (*d)[t]; !ok {
 		return nil
 	}
-	return (*d)[t].do()
+	return (*d)[t].do(joinErrors, onError)
 }
 
 type deferStack []*deferAction
@@ -229,18 +236,31 @@ func (d *deferStack) pop() *deferAction {
 	return da
 }
 
-func (d *deferStack) do() error {
+// do runs the deferred functions in the reverse order of the registration. It stops at the first error and returns it as it is,
+// unless joinErrors is true, which runs all functions and returns the errors as DeferFuncError joined by errors.Join.
+// onError is called with each error if it is not nil.
+func (d *deferStack) do(joinErrors bool, onError func(*DeferFuncError)) error {
 	if len(*d) == 0 {
 		return nil
 	}
+	var errs []error
 	for {
 		da := d.pop()
 		if da == nil {
 			break
 		}
-		if err := da.fn(); err != nil {
+		err := da.fn()
+		if err == nil {
+			continue
+		}
+		dfe := &DeferFuncError{Err: err, Caller: da.caller}
+		if onError != nil {
+			onError(dfe)
+		}
+		if !joinErrors {
 			return err
 		}
+		errs = append(errs, dfe)
 	}
-	return nil
+	return errors.Join(errs...)
 }
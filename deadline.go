@@ -0,0 +1,83 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// RequestTimeoutHeader is the header of the time budget of the request like "1.5" seconds or "500ms".
+	RequestTimeoutHeader = "Request-Timeout"
+	// DeadlineHeader is the header of the absolute deadline of the request in RFC 3339 like "2024-08-01T12:00:00.5Z".
+	DeadlineHeader = "X-Deadline"
+)
+
+// WithRequestDeadline sets the deadline of the context of the requests from the Request-Timeout or X-Deadline header,
+// so the calls of the registry like the database and the other services that take the context respect the budget of the client.
+// The deadline is bounded by limit, and the requests without the valid headers have the deadline of limit.
+// The client package sends the remaining budget of the context as the Request-Timeout header.
+//
+//	tanukirpc.NewRouter(reg, tanukirpc.WithRequestDeadline[*registry](30*time.Second))
+func WithRequestDeadline[Reg any](limit time.Duration) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.maxRequestTimeout = limit
+		return r
+	}
+}
+
+func (r *Router[Reg]) deadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.maxRequestTimeout <= 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+		now := time.Now()
+		deadline := now.Add(r.maxRequestTimeout)
+		if d, ok := parseRequestDeadline(req.Header, now); ok && d.Before(deadline) {
+			deadline = d
+		}
+		ctx, cancel := gocontext.WithDeadline(req.Context(), deadline)
+		defer cancel()
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// parseRequestDeadline returns the deadline of the Request-Timeout or X-Deadline header. Request-Timeout has priority.
+func parseRequestDeadline(h http.Header, now time.Time) (time.Time, bool) {
+	if v := strings.TrimSpace(h.Get(RequestTimeoutHeader)); v != "" {
+		if timeout, ok := parseRequestTimeout(v); ok {
+			return now.Add(timeout), true
+		}
+	}
+	if v := strings.TrimSpace(h.Get(DeadlineHeader)); v != "" {
+		if deadline, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return deadline, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRequestTimeout parses the seconds like "1.5" or the duration like "500ms". The negative values are invalid.
+func parseRequestTimeout(v string) (time.Duration, bool) {
+	if sec, err := strconv.ParseFloat(v, 64); err == nil {
+		if !(sec >= 0) || sec > math.MaxInt64/float64(time.Second) {
+			return 0, false
+		}
+		return time.Duration(sec * float64(time.Second)), true
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// FormatRequestTimeout formats the remaining budget of the deadline as the value of the Request-Timeout header in milliseconds like "1500ms".
+func FormatRequestTimeout(deadline time.Time, now time.Time) string {
+	remaining := max(deadline.Sub(now).Truncate(time.Millisecond), 0)
+	return strconv.FormatInt(remaining.Milliseconds(), 10) + "ms"
+}
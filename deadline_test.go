@@ -0,0 +1,60 @@
+package tanukirpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestDeadline(t *testing.T) {
+	newRouter := func(opts ...tanukirpc.RouterOption[struct{}]) *tanukirpc.Router[struct{}] {
+		router := tanukirpc.NewRouter(struct{}{}, opts...)
+		router.Get("/budget", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return "none", nil
+			}
+			return strconv.FormatInt(time.Until(deadline).Round(time.Second).Milliseconds(), 10), nil
+		}))
+		return router
+	}
+	testCases := []struct {
+		name     string
+		opts     []tanukirpc.RouterOption[struct{}]
+		header   map[string]string
+		expected string
+	}{
+		{name: "disabled", header: map[string]string{"Request-Timeout": "5"}, expected: `"none"`},
+		{name: "seconds", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, header: map[string]string{"Request-Timeout": "5"}, expected: `"5000"`},
+		{name: "duration", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, header: map[string]string{"Request-Timeout": "3000ms"}, expected: `"3000"`},
+		{name: "absolute deadline", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, header: map[string]string{"X-Deadline": time.Now().Add(10 * time.Second).Format(time.RFC3339Nano)}, expected: `"10000"`},
+		{name: "bounded by the limit", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, header: map[string]string{"Request-Timeout": "3600"}, expected: `"60000"`},
+		{name: "invalid", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, header: map[string]string{"Request-Timeout": "-1"}, expected: `"60000"`},
+		{name: "no header", opts: []tanukirpc.RouterOption[struct{}]{tanukirpc.WithRequestDeadline[struct{}](time.Minute)}, expected: `"60000"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/budget", nil)
+			req.Header.Set("Accept", "application/json")
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(tc.opts...).ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestFormatRequestTimeout(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, "1500ms", tanukirpc.FormatRequestTimeout(now.Add(1500*time.Millisecond), now))
+	assert.Equal(t, "0ms", tanukirpc.FormatRequestTimeout(now.Add(-time.Second), now))
+}
@@ -0,0 +1,133 @@
+// Package debug provides the handler that exposes the information of the Router for the development and the incident triage.
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/mackee/tanukirpc"
+)
+
+// Target is the Router that is inspected by the debug handler.
+type Target interface {
+	Routes() ([]*tanukirpc.RouteInfo, error)
+	Codec() tanukirpc.Codec
+}
+
+// Info is the response of the debug handler.
+type Info struct {
+	Routes []*Route   `json:"routes"`
+	Codecs []string   `json:"codecs"`
+	Build  *BuildInfo `json:"build,omitempty"`
+}
+
+// Route is the information of the route.
+type Route struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Request     string   `json:"request,omitempty"`
+	Response    string   `json:"response,omitempty"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// BuildInfo is the build information of the running binary.
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+}
+
+// NewHandler returns the handler that writes the routes, the codec chain and the build information as JSON.
+// If enabled is false, the handler responds 404 Not Found, so it can be mounted unconditionally and gated by a flag.
+func NewHandler(target Target, enabled bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !enabled {
+			http.NotFound(w, req)
+			return
+		}
+		info, err := Inspect(target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+// Inspect returns the information of the target.
+func Inspect(target Target) (*Info, error) {
+	routes, err := target.Routes()
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{
+		Routes: make([]*Route, 0, len(routes)),
+		Codecs: codecNames(target.Codec()),
+	}
+	for _, r := range routes {
+		route := &Route{
+			Method:      r.Method,
+			Pattern:     r.Pattern,
+			Middlewares: make([]string, 0, len(r.Middlewares)),
+		}
+		if r.Metadata != nil {
+			route.Request = typeName(r.Metadata.Request)
+			route.Response = typeName(r.Metadata.Response)
+		}
+		for _, mw := range r.Middlewares {
+			route.Middlewares = append(route.Middlewares, funcName(mw))
+		}
+		info.Routes = append(info.Routes, route)
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Build = &BuildInfo{
+			GoVersion: bi.GoVersion,
+			Path:      bi.Path,
+			Version:   bi.Main.Version,
+			Settings:  make(map[string]string, len(bi.Settings)),
+		}
+		for _, s := range bi.Settings {
+			info.Build.Settings[s.Key] = s.Value
+		}
+	}
+	return info, nil
+}
+
+func codecNames(c tanukirpc.Codec) []string {
+	if c == nil {
+		return []string{}
+	}
+	if cl, ok := c.(tanukirpc.CodecList); ok {
+		names := make([]string, 0, len(cl))
+		for _, c := range cl {
+			names = append(names, codecNames(c)...)
+		}
+		return names
+	}
+	return []string{c.Name()}
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	return f.Name()
+}
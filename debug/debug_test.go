@@ -0,0 +1,51 @@
+package debug_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/debug"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingRequest struct {
+	ID int `urlparam:"id"`
+}
+
+type pingResponse struct {
+	Message string `json:"message"`
+}
+
+func TestNewHandler(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Route("/api", func(r *tanukirpc.Router[struct{}]) {
+		r.Get("/ping/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *pingRequest) (*pingResponse, error) {
+			return &pingResponse{Message: "pong"}, nil
+		}))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		debug.NewHandler(router, true).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var info debug.Info
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+		require.Len(t, info.Routes, 1)
+		assert.Equal(t, http.MethodGet, info.Routes[0].Method)
+		assert.Equal(t, "/api/ping/{id}", info.Routes[0].Pattern)
+		assert.Equal(t, "*debug_test.pingRequest", info.Routes[0].Request)
+		assert.Equal(t, "*debug_test.pingResponse", info.Routes[0].Response)
+		assert.NotEmpty(t, info.Routes[0].Middlewares)
+		assert.Equal(t, []string{"urlparam", "precondition", "negotiate", "query", "form", "json", "rawbody", "nop"}, info.Codecs)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		debug.NewHandler(router, false).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
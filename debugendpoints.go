@@ -0,0 +1,56 @@
+package tanukirpc
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+type debugEndpoints struct {
+	prefix  string
+	handler http.Handler
+}
+
+// WithDebugEndpoints mounts the net/http/pprof and the expvar handlers under the prefix.
+// The profiles are served at prefix + "/pprof/" and the variables are served at prefix + "/vars".
+// The middlewares are applied to the debug endpoints, so use them to authenticate the requests.
+// When the prefix is a string literal, the tanukiup proxy also forwards the debug endpoints to the server.
+func WithDebugEndpoints(prefix string, middlewares ...func(http.Handler) http.Handler) ListenAndServeOption {
+	return func(o *listenAndServeConfig) {
+		o.debugEndpoints = newDebugEndpoints(prefix, middlewares...)
+	}
+}
+
+func newDebugEndpoints(prefix string, middlewares ...func(http.Handler) http.Handler) *debugEndpoints {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	// net/http/pprof handlers expect the path that starts with /debug/pprof/
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	var h http.Handler = http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.URL.Path = "/debug" + req.URL.Path
+		mux.ServeHTTP(w, req)
+	}))
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return &debugEndpoints{prefix: prefix, handler: h}
+}
+
+func (d *debugEndpoints) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, d.prefix+"/") {
+			d.handler.ServeHTTP(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,108 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mackee/tanukirpc/internal/requestid"
+)
+
+// The names of the default middlewares of the Router, in the order of the default.
+// They are used by WithoutDefaultMiddleware, WithMiddlewareBefore and WithMiddlewareAfter.
+const (
+	// MiddlewareRequestID sets the request ID from the X-Request-ID header or generates it.
+	MiddlewareRequestID = "requestid"
+	// MiddlewareLogAttrs prepares the attributes of AddLogAttr. Without it, AddLogAttr does nothing.
+	MiddlewareLogAttrs = "logattrs"
+	// MiddlewareTraceContext parses and starts the TraceContext.
+	MiddlewareTraceContext = "tracecontext"
+	// MiddlewareRealIP sets the RemoteAddr and ClientIP from the forwarded headers like X-Forwarded-For. See WithTrustedProxies.
+	MiddlewareRealIP = "realip"
+	// MiddlewareRecoverer recovers the panics of the handlers and responds with 500 Internal Server Error.
+	MiddlewareRecoverer = "recoverer"
+)
+
+type namedMiddleware struct {
+	name string
+	mw   func(http.Handler) http.Handler
+}
+
+// newDefaultMiddleware returns the default middlewares. ri is the configuration of MiddlewareRealIP of the Router.
+func newDefaultMiddleware(ri *realIP) []namedMiddleware {
+	return []namedMiddleware{
+		{name: MiddlewareRequestID, mw: requestid.Middleware},
+		{name: MiddlewareLogAttrs, mw: logAttrsMiddleware},
+		{name: MiddlewareTraceContext, mw: traceContextMiddleware},
+		{name: MiddlewareRealIP, mw: ri.middleware},
+		{name: MiddlewareRecoverer, mw: middleware.Recoverer},
+	}
+}
+
+// WithDefaultMiddleware replaces the default middlewares of the Router. The middlewares have no names.
+// Use WithoutDefaultMiddleware, WithMiddlewareBefore and WithMiddlewareAfter to change a part of the defaults.
+func WithDefaultMiddleware[Reg any](middlewares ...func(http.Handler) http.Handler) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.defaultMiddleware = make([]namedMiddleware, 0, len(middlewares))
+		for _, mw := range middlewares {
+			r.defaultMiddleware = append(r.defaultMiddleware, namedMiddleware{mw: mw})
+		}
+		return r
+	}
+}
+
+// WithoutDefaultMiddleware removes the default middlewares of the names like MiddlewareRealIP.
+// It is useful when the same middleware is applied by the other layer, like the trusted proxy.
+//
+//	tanukirpc.NewRouter(reg, tanukirpc.WithoutDefaultMiddleware[*registry](tanukirpc.MiddlewareRealIP))
+func WithoutDefaultMiddleware[Reg any](names ...string) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.defaultMiddleware = slices.DeleteFunc(slices.Clone(r.defaultMiddleware), func(nm namedMiddleware) bool {
+			return nm.name != "" && slices.Contains(names, nm.name)
+		})
+		return r
+	}
+}
+
+// WithMiddlewareBefore inserts the middlewares before the default middleware of the name,
+// for example, to see the request before the RemoteAddr is rewritten by MiddlewareRealIP.
+// It panics if the default middleware of the name is not found, like removed by WithoutDefaultMiddleware.
+func WithMiddlewareBefore[Reg any](name string, middlewares ...func(http.Handler) http.Handler) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.defaultMiddleware = insertMiddleware(r.defaultMiddleware, name, 0, middlewares)
+		return r
+	}
+}
+
+// WithMiddlewareAfter inserts the middlewares after the default middleware of the name,
+// for example, to recover the panics of the middleware that follows MiddlewareRecoverer.
+// It panics if the default middleware of the name is not found, like removed by WithoutDefaultMiddleware.
+func WithMiddlewareAfter[Reg any](name string, middlewares ...func(http.Handler) http.Handler) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.defaultMiddleware = insertMiddleware(r.defaultMiddleware, name, 1, middlewares)
+		return r
+	}
+}
+
+func insertMiddleware(nms []namedMiddleware, name string, offset int, middlewares []func(http.Handler) http.Handler) []namedMiddleware {
+	i := slices.IndexFunc(nms, func(nm namedMiddleware) bool {
+		return nm.name == name
+	})
+	if i < 0 {
+		panic(fmt.Sprintf("tanukirpc: default middleware %q is not found", name))
+	}
+	inserted := make([]namedMiddleware, 0, len(middlewares))
+	for _, mw := range middlewares {
+		inserted = append(inserted, namedMiddleware{mw: mw})
+	}
+	return slices.Insert(slices.Clone(nms), i+offset, inserted...)
+}
+
+func (r *Router[Reg]) defaultMiddlewares() []func(http.Handler) http.Handler {
+	mws := make([]func(http.Handler) http.Handler, 0, len(r.defaultMiddleware))
+	for _, nm := range r.defaultMiddleware {
+		mws = append(mws, nm.mw)
+	}
+	return mws
+}
@@ -0,0 +1,52 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+)
+
+// DeferErrorHook is called with each error of the functions deferred by Context.Defer, with the caller that registered the function.
+// It makes the failures of the cleanups after the response observable, which are not responded to the clients.
+// ctx is the Context[Reg] of the route.
+type DeferErrorHook func(ctx gocontext.Context, timing DeferDoTiming, err *DeferFuncError)
+
+// WithOnDeferError sets the DeferErrorHook of the Router.
+func WithOnDeferError[Reg any](hook DeferErrorHook) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.deferErrorHook = hook
+		return r
+	}
+}
+
+// WithDeferJoinErrors makes the Router run all deferred functions even if some of them fail,
+// instead of stopping at the first error. The errors are joined by errors.Join as DeferFuncError,
+// so each error keeps the caller that registered the function, and the failures do not mask each other.
+func WithDeferJoinErrors[Reg any]() RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.deferJoinErrors = true
+		return r
+	}
+}
+
+// deferDoer is implemented by the Contexts built by the ContextFactory of this package.
+type deferDoer interface {
+	deferDo(timing DeferDoTiming, joinErrors bool, onError func(*DeferFuncError)) error
+}
+
+// deferDo runs the deferred functions of the timing with the options of the Router.
+// The Contexts of the other ContextFactory are run by DeferDo, and the caller of the error passed to the hook is unknown.
+func (r *Router[Reg]) deferDo(ctx Context[Reg], timing DeferDoTiming) error {
+	var onError func(*DeferFuncError)
+	if r.deferErrorHook != nil {
+		onError = func(err *DeferFuncError) {
+			r.deferErrorHook(ctx, timing, err)
+		}
+	}
+	if dd, ok := ctx.(deferDoer); ok {
+		return dd.deferDo(timing, r.deferJoinErrors, onError)
+	}
+	err := ctx.DeferDo(timing)
+	if err != nil && onError != nil {
+		onError(&DeferFuncError{Err: err, Caller: &DeferFuncCallerStack{}})
+	}
+	return err
+}
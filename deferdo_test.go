@@ -0,0 +1,79 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	newRouter := func(hooked *[]*tanukirpc.DeferFuncError, called *[]string, opts ...tanukirpc.RouterOption[struct{}]) *tanukirpc.Router[struct{}] {
+		opts = append(opts, tanukirpc.WithOnDeferError[struct{}](func(ctx context.Context, timing tanukirpc.DeferDoTiming, err *tanukirpc.DeferFuncError) {
+			assert.Equal(t, tanukirpc.DeferDoTimingBeforeResponse, timing)
+			*hooked = append(*hooked, err)
+		}))
+		router := tanukirpc.NewRouter(struct{}{}, opts...)
+		router.Get("/", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			ctx.Defer(func() error {
+				*called = append(*called, "third")
+				return nil
+			}, tanukirpc.DeferDoTimingBeforeResponse)
+			ctx.Defer(func() error {
+				*called = append(*called, "second")
+				return errSecond
+			}, tanukirpc.DeferDoTimingBeforeResponse)
+			ctx.Defer(func() error {
+				*called = append(*called, "first")
+				return errFirst
+			}, tanukirpc.DeferDoTimingBeforeResponse)
+			return "ok", nil
+		}))
+		return router
+	}
+	serve := func(router http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("stop at the first error", func(t *testing.T) {
+		var hooked []*tanukirpc.DeferFuncError
+		var called []string
+		rec := serve(newRouter(&hooked, &called))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"error":{"message":"first"}}`, rec.Body.String())
+		assert.Equal(t, []string{"first"}, called)
+		require.Len(t, hooked, 1)
+		assert.ErrorIs(t, hooked[0], errFirst)
+	})
+
+	t.Run("join errors", func(t *testing.T) {
+		var hooked []*tanukirpc.DeferFuncError
+		var called []string
+		rec := serve(newRouter(&hooked, &called, tanukirpc.WithDeferJoinErrors[struct{}]()))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, []string{"first", "second", "third"}, called)
+		assert.Contains(t, rec.Body.String(), "deferdo_test.go")
+		assert.Contains(t, rec.Body.String(), ": first")
+		assert.Contains(t, rec.Body.String(), ": second")
+
+		require.Len(t, hooked, 2)
+		assert.ErrorIs(t, hooked[0], errFirst)
+		assert.ErrorIs(t, hooked[1], errSecond)
+		for _, err := range hooked {
+			require.True(t, err.Caller.Ok)
+			assert.Equal(t, "deferdo_test.go", filepath.Base(err.Caller.File))
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+)
+
+// DeferResultFunc is the function deferred by DeferWithResult. status is the status of the response,
+// which is StatusClientClosedRequest if the client disconnected, and err is the error of the request or nil if it succeeded.
+type DeferResultFunc func(status int, err error) error
+
+// DeferWithResult registers fn to be called after the response with the result of the request, like Defer with DeferDoTimingAfterResponse.
+// Unlike the functions registered by Defer, fn is called even if the request fails after the Context is built,
+// so it can branch on whether the handler succeeded, for the metrics, the publishing of the outbox and the invalidation of the caches.
+// The functions are called in the reverse order of the registration after the functions registered by Defer.
+//
+//	tanukirpc.DeferWithResult(ctx, func(status int, err error) error {
+//		if err != nil {
+//			return nil
+//		}
+//		return ctx.Registry().outbox.Publish(ctx, event)
+//	})
+//
+// If the Context is not built by the ContextFactory of this package, fn is registered by Defer and called only if the request succeeds.
+func DeferWithResult[Reg any](ctx Context[Reg], fn DeferResultFunc) {
+	pc, file, line, ok := runtime.Caller(1)
+	caller := &DeferFuncCallerStack{PC: pc, File: file, Line: line, Ok: ok}
+	if rd, ok := ctx.(resultDeferrer); ok {
+		rd.deferWithResult(fn, caller)
+		return
+	}
+	ctx.Defer(func() error {
+		return fn(http.StatusOK, nil)
+	})
+}
+
+// resultDeferrer and resultDeferDoer are implemented by the Contexts built by the ContextFactory of this package.
+type resultDeferrer interface {
+	deferWithResult(fn DeferResultFunc, caller *DeferFuncCallerStack)
+}
+
+type resultDeferDoer interface {
+	deferDoWithResult(status int, err error, joinErrors bool, onError func(*DeferFuncError)) error
+}
+
+type deferResult struct {
+	status int
+	err    error
+}
+
+func (c *context[Reg]) deferWithResult(fn DeferResultFunc, caller *DeferFuncCallerStack) {
+	c.resultDefers.push(&deferAction{
+		fn: func() error {
+			return fn(c.result.status, c.result.err)
+		},
+		caller: caller,
+	})
+}
+
+func (c *context[Reg]) deferDoWithResult(status int, err error, joinErrors bool, onError func(*DeferFuncError)) error {
+	c.result = deferResult{status: status, err: err}
+	return c.resultDefers.do(joinErrors, onError)
+}
+
+func (c *batchItemContext[Reg]) deferWithResult(fn DeferResultFunc, caller *DeferFuncCallerStack) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rd, ok := c.Context.(resultDeferrer); ok {
+		rd.deferWithResult(fn, caller)
+		return
+	}
+	c.Context.Defer(func() error {
+		return fn(http.StatusOK, nil)
+	})
+}
+
+// deferDoWithResult calls the functions registered by DeferWithResult with the result of the request.
+// ctx is nil if the request fails before the Context is built.
+func (r *Router[Reg]) deferDoWithResult(ctx Context[Reg], w WrapResponseWriter, req *http.Request, err error) {
+	rd, ok := ctx.(resultDeferDoer)
+	if !ok {
+		return
+	}
+	status := w.Status()
+	switch {
+	case isClientClosed(req, err):
+		status = StatusClientClosedRequest
+	case status == 0 && err != nil:
+		// the response is not written by the ErrorHooker or the handler panics
+		status = http.StatusInternalServerError
+	case status == 0:
+		status = http.StatusOK
+	}
+	var onError func(*DeferFuncError)
+	if r.deferErrorHook != nil {
+		onError = func(derr *DeferFuncError) {
+			r.deferErrorHook(ctx, DeferDoTimingAfterResponse, derr)
+		}
+	}
+	if derr := rd.deferDoWithResult(status, err, r.deferJoinErrors, onError); derr != nil {
+		r.logger.ErrorContext(ctx, "defer do error", slog.Any("error", derr))
+	}
+}
+
+// resultError returns the error of the result of the request, which is the panic of the handler if it panics.
+// The panic is propagated to the Recoverer middleware after the functions registered by DeferWithResult are called.
+func resultError(err error, rec any) error {
+	if rec == nil {
+		return err
+	}
+	return fmt.Errorf("panic: %v", rec)
+}
@@ -0,0 +1,80 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferWithResult(t *testing.T) {
+	type result struct {
+		status int
+		err    error
+	}
+	var results []result
+	var order []string
+	errNotFound := tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("not found"))
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		ID string `urlparam:"id"`
+	}) (string, error) {
+		tanukirpc.DeferWithResult(ctx, func(status int, err error) error {
+			order = append(order, "result")
+			results = append(results, result{status: status, err: err})
+			return nil
+		})
+		ctx.Defer(func() error {
+			order = append(order, "after")
+			return nil
+		})
+		switch req.ID {
+		case "missing":
+			return "", errNotFound
+		case "panic":
+			panic("boom")
+		}
+		return req.ID, nil
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/tasks/1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"after", "result"}, order)
+
+	rec = serve("/tasks/missing")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"after", "result", "result"}, order, "the functions registered by Defer are not called on the errors")
+
+	rec = serve("/tasks/panic")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, result{status: http.StatusOK}, results[0])
+	assert.Equal(t, http.StatusNotFound, results[1].status)
+	assert.ErrorIs(t, results[1].err, errNotFound)
+	assert.Equal(t, http.StatusInternalServerError, results[2].status)
+	assert.ErrorContains(t, results[2].err, "boom")
+}
+
+func TestTestContextDeferWithResult(t *testing.T) {
+	ctx := tanukirpc.NewTestContextBuilder(struct{}{}).Build()
+	var statuses []int
+	tanukirpc.DeferWithResult(ctx, func(status int, err error) error {
+		statuses = append(statuses, status)
+		return err
+	})
+	require.NoError(t, ctx.DeferDoAll())
+	assert.Equal(t, []int{http.StatusOK}, statuses)
+}
@@ -0,0 +1,73 @@
+package tanukirpc
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Deprecation is the deprecation of the route.
+type Deprecation struct {
+	// Sunset is the time when the route will be removed. It is zero if it is not scheduled.
+	Sunset time.Time
+	// Link is the URL of the document about the deprecation like the migration guide.
+	Link string
+}
+
+// Deprecated marks the handler as deprecated. The responses have the Deprecation header,
+// the Sunset header if sunset is not zero and the Link header with rel="deprecation" if link is not empty.
+// The calls of the route are logged with the remote address and the User-Agent to identify the callers,
+// and the access logs have "deprecated" attribute.
+// The route is marked as deprecated in the generated TypeScript client and the OpenAPI document.
+//
+//	r.Get("/v1/tasks", tanukirpc.Deprecated(tanukirpc.NewHandler(listTasksV1), sunset, "https://example.com/docs/migrate-to-v2"))
+func Deprecated[Reg any](h Handler[Reg], sunset time.Time, link string) Handler[Reg] {
+	d := &Deprecation{Sunset: sunset, Link: link}
+	var meta *HandlerMetadata
+	if m := h.Metadata(); m != nil {
+		mc := *m
+		mc.Deprecation = d
+		meta = &mc
+	}
+	return &deprecatedHandler[Reg]{handler: h, deprecation: d, meta: meta}
+}
+
+type deprecatedHandler[Reg any] struct {
+	handler     Handler[Reg]
+	deprecation *Deprecation
+	meta        *HandlerMetadata
+}
+
+func (h *deprecatedHandler[Reg]) Metadata() *HandlerMetadata {
+	return h.meta
+}
+
+func (h *deprecatedHandler[Reg]) isNil() bool {
+	return isNilHandler(h.handler)
+}
+
+func (h *deprecatedHandler[Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	next := h.handler.build(r)
+	var sunset string
+	if !h.deprecation.Sunset.IsZero() {
+		sunset = h.deprecation.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		req = withLogAttrs(req)
+		w.Header().Set("Deprecation", "true")
+		if sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+		if h.deprecation.Link != "" {
+			w.Header().Add("Link", "<"+h.deprecation.Link+`>; rel="deprecation"`)
+		}
+		AddLogAttr(req.Context(), slog.Bool("deprecated", true))
+		r.logger.WarnContext(req.Context(), "deprecated route is called",
+			slog.String("method", req.Method),
+			slog.String("route", routePattern(req)),
+			slog.String("remote", req.RemoteAddr),
+			slog.String("user_agent", req.UserAgent()),
+		)
+		next(w, req)
+	}
+}
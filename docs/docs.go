@@ -0,0 +1,42 @@
+// Package docs provides the handler that serves the API reference generated by gendocs.
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// swaggerHTML is the page of Swagger UI. The assets of Swagger UI are loaded from unpkg.com.
+//
+//go:embed swagger.html
+var swaggerHTML []byte
+
+// NewHandler returns the handler that serves the reference at "/". The reference is the Markdown or the HTML generated by gendocs.
+// If openAPI is not nil, the handler also serves the OpenAPI document at "/openapi.json" and Swagger UI of it at "/swagger".
+// The paths are relative, so mount the handler with http.StripPrefix like the following:
+//
+//	router.Mount("/docs", http.StripPrefix("/docs", docs.NewHandler(reference, openAPI)))
+func NewHandler(reference, openAPI []byte) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", http.DetectContentType(reference))
+		w.Write(reference)
+	})
+	if openAPI != nil {
+		mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(openAPI)
+		})
+		mux.HandleFunc("GET /swagger", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(swaggerHTML)
+		})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// http.StripPrefix leaves the empty path for the request to the mount point itself
+		if req.URL.Path == "" {
+			req.URL.Path = "/"
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,50 @@
+package docs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler(t *testing.T) {
+	reference := []byte("<!DOCTYPE html>\n<html><body><h1>API Reference</h1></body></html>\n")
+	openAPI := []byte(`{"openapi":"3.0.3"}`)
+
+	t.Run("with openapi", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/docs/", http.StripPrefix("/docs", docs.NewHandler(reference, openAPI)))
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, reference, rec.Body.Bytes())
+
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, openAPI, rec.Body.Bytes())
+
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/swagger", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `url: "openapi.json"`)
+	})
+	t.Run("without openapi", func(t *testing.T) {
+		h := docs.NewHandler([]byte("# API Reference\n"), nil)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,159 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// dynamicRoutes is the routes that are registered by Register. They are served by the chi router that is rebuilt
+// and swapped atomically on each Register and Unregister, so the requests in flight keep the router that they started with.
+type dynamicRoutes struct {
+	mu     sync.Mutex
+	root   chi.Routes
+	routes []*dynamicRoute
+	mux    atomic.Pointer[chi.Mux]
+}
+
+type dynamicRoute struct {
+	method  string
+	pattern string
+	handler *routeHandler
+}
+
+func newDynamicRoutes(root chi.Routes) *dynamicRoutes {
+	return &dynamicRoutes{root: root}
+}
+
+func (d *dynamicRoutes) register(route *dynamicRoute) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	routes := slices.DeleteFunc(slices.Clone(d.routes), func(dr *dynamicRoute) bool {
+		return dr.method == route.method && dr.pattern == route.pattern
+	})
+	return d.swap(append(routes, route))
+}
+
+func (d *dynamicRoutes) unregister(method, pattern string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	routes := slices.DeleteFunc(slices.Clone(d.routes), func(dr *dynamicRoute) bool {
+		return dr.method == method && dr.pattern == pattern
+	})
+	if len(routes) == len(d.routes) {
+		return false
+	}
+	// the routes were built without them, so it does not fail
+	_ = d.swap(routes)
+	return true
+}
+
+// swap builds the chi router of the routes and replaces the current one. The middlewares of the root are taken at this time,
+// because chi does not allow to add the middlewares after the routes are registered.
+func (d *dynamicRoutes) swap(routes []*dynamicRoute) (err error) {
+	if len(routes) == 0 {
+		d.routes = nil
+		d.mux.Store(nil)
+		return nil
+	}
+	defer func() {
+		// chi panics on the invalid patterns
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("invalid route: %v", rec)
+		}
+	}()
+	mux := chi.NewRouter()
+	mux.Use(d.root.Middlewares()...)
+	for _, route := range routes {
+		mux.Method(route.method, route.pattern, route.handler)
+	}
+	d.routes = routes
+	d.mux.Store(mux)
+	return nil
+}
+
+func (d *dynamicRoutes) current() *chi.Mux {
+	if d == nil {
+		return nil
+	}
+	return d.mux.Load()
+}
+
+// handler returns the router of the dynamic routes if the request matches one of them and does not match the static routes.
+func (d *dynamicRoutes) handler(req *http.Request) http.Handler {
+	mux := d.current()
+	if mux == nil {
+		return nil
+	}
+	path := req.URL.RawPath
+	if path == "" {
+		path = req.URL.Path
+	}
+	// the prefix of the outer router is already stripped if the Router is mounted into it
+	if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+	}
+	if d.root.Match(chi.NewRouteContext(), req.Method, path) {
+		return nil
+	}
+	if !mux.Match(chi.NewRouteContext(), req.Method, path) {
+		return nil
+	}
+	return mux
+}
+
+// Register registers the handler of the method and the pattern at runtime, like the routes of the plugins and the webhooks
+// that are configured by the administrators. It is safe to call it while the Router is serving, and the handler of
+// the same method and pattern is replaced. The pattern is relative to the Router like Get.
+//
+// The routes that are registered by Get and the others take precedence, so it returns the error for them.
+// The registered routes run through the middlewares of the root Router, not through the ones of With and Route.
+// Validate does not check them, so Register checks the nil handler and the pattern instead.
+func (r *Router[Reg]) Register(method, pattern string, h Handler[Reg]) error {
+	method = strings.ToUpper(method)
+	if !slices.Contains(dynamicRouteMethods, method) {
+		return fmt.Errorf("unsupported method: %s", method)
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("pattern must begin with '/': %s", pattern)
+	}
+	pattern = r.routePrefix + pattern
+	if isNilHandler(h) {
+		return fmt.Errorf("%s %s: handler is nil", method, pattern)
+	}
+	if r.registrations.has(method, pattern) {
+		return fmt.Errorf("%s %s: route is already registered", method, pattern)
+	}
+	route := &dynamicRoute{
+		method:  method,
+		pattern: pattern,
+		handler: &routeHandler{HandlerFunc: h.build(r), meta: h.Metadata()},
+	}
+	if err := r.dynamic.register(route); err != nil {
+		return fmt.Errorf("failed to register %s %s: %w", method, pattern, err)
+	}
+	return nil
+}
+
+// Unregister removes the route that is registered by Register, and reports whether it was registered.
+// The requests in flight are served by the removed handler until they finish.
+func (r *Router[Reg]) Unregister(method, pattern string) bool {
+	return r.dynamic.unregister(strings.ToUpper(method), r.routePrefix+pattern)
+}
+
+var dynamicRouteMethods = []string{
+	http.MethodConnect,
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodTrace,
+}
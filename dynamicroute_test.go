@@ -0,0 +1,135 @@
+package tanukirpc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dynamicWebhookRequest struct {
+	Name string `urlparam:"name"`
+}
+
+type dynamicWebhookResponse struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func dynamicWebhookHandler(version int) tanukirpc.Handler[struct{}] {
+	return tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req dynamicWebhookRequest) (*dynamicWebhookResponse, error) {
+		return &dynamicWebhookResponse{Name: req.Name, Version: version}, nil
+	})
+}
+
+func TestRouterRegister(t *testing.T) {
+	serve := func(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("register and unregister while serving", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Root", "1")
+				next.ServeHTTP(w, req)
+			})
+		})
+		r.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*dynamicWebhookResponse, error) {
+			return &dynamicWebhookResponse{Name: "ping"}, nil
+		}))
+
+		assert.Equal(t, http.StatusNotFound, serve(t, r, http.MethodPost, "/webhooks/github").Code)
+
+		require.NoError(t, r.Register(http.MethodPost, "/webhooks/{name}", dynamicWebhookHandler(1)))
+		rec := serve(t, r, http.MethodPost, "/webhooks/github")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("X-Root"))
+		var resp dynamicWebhookResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, dynamicWebhookResponse{Name: "github", Version: 1}, resp)
+
+		require.NoError(t, r.Register("post", "/webhooks/{name}", dynamicWebhookHandler(2)))
+		rec = serve(t, r, http.MethodPost, "/webhooks/github")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, 2, resp.Version)
+
+		assert.Equal(t, http.StatusOK, serve(t, r, http.MethodGet, "/ping").Code)
+
+		routes, err := r.Routes()
+		require.NoError(t, err)
+		patterns := make([]string, 0, len(routes))
+		for _, route := range routes {
+			patterns = append(patterns, route.Method+" "+route.Pattern)
+		}
+		assert.Equal(t, []string{"GET /ping", "POST /webhooks/{name}"}, patterns)
+
+		assert.True(t, r.Unregister(http.MethodPost, "/webhooks/{name}"))
+		assert.False(t, r.Unregister(http.MethodPost, "/webhooks/{name}"))
+		assert.Equal(t, http.StatusNotFound, serve(t, r, http.MethodPost, "/webhooks/github").Code)
+	})
+	t.Run("pattern is relative to the Router", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		var plugins *tanukirpc.Router[struct{}]
+		r.Route("/plugins", func(r *tanukirpc.Router[struct{}]) {
+			r.Get("/", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*dynamicWebhookResponse, error) {
+				return &dynamicWebhookResponse{}, nil
+			}))
+			plugins = r
+		})
+
+		require.NoError(t, plugins.Register(http.MethodGet, "/{name}", dynamicWebhookHandler(1)))
+		assert.Equal(t, http.StatusOK, serve(t, r, http.MethodGet, "/plugins/hello").Code)
+		assert.True(t, plugins.Unregister(http.MethodGet, "/{name}"))
+		assert.Equal(t, http.StatusNotFound, serve(t, r, http.MethodGet, "/plugins/hello").Code)
+	})
+	t.Run("invalid routes", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/ping", dynamicWebhookHandler(1))
+
+		err := r.Register(http.MethodGet, "/ping", dynamicWebhookHandler(2))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /ping: route is already registered")
+
+		err = r.Register(http.MethodGet, "/nil", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /nil: handler is nil")
+
+		assert.Error(t, r.Register("FETCH", "/fetch", dynamicWebhookHandler(1)))
+		assert.Error(t, r.Register(http.MethodGet, "webhooks", dynamicWebhookHandler(1)))
+		assert.Error(t, r.Register(http.MethodGet, "/webhooks/{name", dynamicWebhookHandler(1)))
+
+		routes, err := r.Routes()
+		require.NoError(t, err)
+		assert.Len(t, routes, 1)
+	})
+	t.Run("concurrent registrations and requests", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, r.Register(http.MethodPost, "/webhooks/{name}", dynamicWebhookHandler(i)))
+				r.Unregister(http.MethodPost, "/webhooks/{name}")
+			}(i)
+			go func() {
+				defer wg.Done()
+				code := serve(t, r, http.MethodPost, "/webhooks/github").Code
+				assert.Contains(t, []int{http.StatusOK, http.StatusNotFound}, code)
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,55 @@
+package tanukirpc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mackee/tanukirpc/internal/requestid"
+)
+
+// EnvelopeMeta is the metadata of the response that is wrapped by the envelope.
+type EnvelopeMeta struct {
+	// RequestID is the ID of the request that is also in the access log.
+	RequestID string `json:"request_id,omitempty"`
+	// DurationMs is the milliseconds from the start of the handling of the request to the encoding of the response.
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Envelope is the envelope of the responses of DefaultEnvelope like {"data": ..., "meta": {"request_id": ..., "duration_ms": ...}}.
+type Envelope struct {
+	Data any          `json:"data"`
+	Meta EnvelopeMeta `json:"meta"`
+}
+
+// EnvelopeFunc returns the envelope that wraps the successful response data.
+type EnvelopeFunc func(req *http.Request, data any, meta EnvelopeMeta) any
+
+// DefaultEnvelope is the EnvelopeFunc that wraps the responses by Envelope.
+func DefaultEnvelope(req *http.Request, data any, meta EnvelopeMeta) any {
+	return &Envelope{Data: data, Meta: meta}
+}
+
+// WithResponseEnvelope wraps all successful responses of the Router in the envelope that fn returns when they are encoded.
+// If fn is nil, DefaultEnvelope is used. The error responses, the raw responses and the streaming responses are not wrapped.
+// Generate the TypeScript client with the -envelope flag of gentypescript to unwrap the responses by the key of the data, like "data" for DefaultEnvelope.
+func WithResponseEnvelope[Reg any](fn EnvelopeFunc) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		if fn == nil {
+			fn = DefaultEnvelope
+		}
+		r.responseEnvelope = fn
+		return r
+	}
+}
+
+// envelope wraps the response by the EnvelopeFunc of the Router if it is set.
+func (r *Router[Reg]) envelope(req *http.Request, res any, start time.Time) any {
+	if r.responseEnvelope == nil {
+		return res
+	}
+	requestID, _ := req.Context().Value(requestid.RequestIDKey).(string)
+	return r.responseEnvelope(req, res, EnvelopeMeta{
+		RequestID:  requestID,
+		DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+	})
+}
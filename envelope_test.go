@@ -0,0 +1,77 @@
+package tanukirpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResponseEnvelope(t *testing.T) {
+	type task struct {
+		Title string `json:"title"`
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithResponseEnvelope[struct{}](nil))
+	router.Get("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		ID int `urlparam:"id"`
+	}) (*task, error) {
+		if req.ID != 1 {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("task not found"))
+		}
+		return &task{Title: "buy milk"}, nil
+	}))
+	router.Get("/tasks.csv", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.RawResponse, error) {
+		return tanukirpc.WithContentType(strings.NewReader("buy milk\n"), "text/csv"), nil
+	}))
+	custom := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithResponseEnvelope[struct{}](func(req *http.Request, data any, meta tanukirpc.EnvelopeMeta) any {
+		return map[string]any{"result": data, "request_id": meta.RequestID}
+	}))
+	custom.Get("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*task, error) {
+		return &task{Title: "buy milk"}, nil
+	}))
+
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Request-ID", "req-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(router, "/tasks/1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var envelope struct {
+		Data task `json:"data"`
+		Meta struct {
+			RequestID  string   `json:"request_id"`
+			DurationMs *float64 `json:"duration_ms"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &envelope))
+	assert.Equal(t, task{Title: "buy milk"}, envelope.Data)
+	assert.Equal(t, "req-1", envelope.Meta.RequestID)
+	require.NotNil(t, envelope.Meta.DurationMs)
+	assert.GreaterOrEqual(t, *envelope.Meta.DurationMs, 0.0)
+
+	rec = get(router, "/tasks/2")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":{"message":"task not found"}}`, rec.Body.String(), "the error responses are not wrapped")
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks.csv", nil)
+	req.Header.Set("Accept", "*/*")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "buy milk\n", rec.Body.String(), "the raw responses are not wrapped")
+
+	rec = get(custom, "/tasks/1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"result":{"title":"buy milk"},"request_id":"req-1"}`, rec.Body.String())
+}
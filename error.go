@@ -1,10 +1,13 @@
 package tanukirpc
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type ErrorWithStatus interface {
@@ -80,6 +83,9 @@ func (e *errorHooker) OnError(w http.ResponseWriter, req *http.Request, logger *
 		http.Redirect(w, req, ewr.Redirect(), ewr.Status())
 		return
 	}
+	if RenderHTMLError(w, req, logger, err) {
+		return
+	}
 	var ews ErrorWithStatus
 	if errors.As(err, &ews) {
 		w.WriteHeader(ews.Status())
@@ -87,5 +93,111 @@ func (e *errorHooker) OnError(w http.ResponseWriter, req *http.Request, logger *
 		w.WriteHeader(http.StatusInternalServerError)
 		logger.ErrorContext(req.Context(), "ocurred internal server error", slog.Any("error", err))
 	}
-	codec.Encode(w, req, ErrorMessage{Error: ErrorBody{Message: err.Error()}})
+	codec.Encode(w, req, ErrorMessage{Error: ErrorBody{Message: LocalizedErrorMessage(req, err)}})
+}
+
+// ErrorStage is the stage of the request processing where the error occurred.
+type ErrorStage int
+
+const (
+	// ErrorStageDecode is the stage of decoding the request.
+	ErrorStageDecode ErrorStage = iota
+	// ErrorStageValidate is the stage of validating the request.
+	ErrorStageValidate
+	// ErrorStageContext is the stage of building the Context by the ContextFactory and the Transformer.
+	ErrorStageContext
+	// ErrorStageHandler is the stage of the handler function.
+	ErrorStageHandler
+	// ErrorStageDeferBeforeResponse is the stage of the deferred functions with DeferDoTimingBeforeResponse.
+	ErrorStageDeferBeforeResponse
+	// ErrorStageEncode is the stage of validating and encoding the response.
+	ErrorStageEncode
+	// ErrorStageMaintenance is the stage of rejecting the request in the maintenance mode.
+	ErrorStageMaintenance
+	// ErrorStageFeatureFlag is the stage of evaluating the feature flags of the route.
+	ErrorStageFeatureFlag
+	// ErrorStageConcurrencyLimit is the stage of rejecting the request over the concurrency limit.
+	ErrorStageConcurrencyLimit
+	// ErrorStageAuthorize is the stage of evaluating the authorization rules of the route.
+	ErrorStageAuthorize
+)
+
+func (e ErrorStage) String() string {
+	switch e {
+	case ErrorStageDecode:
+		return "decode"
+	case ErrorStageValidate:
+		return "validate"
+	case ErrorStageContext:
+		return "context"
+	case ErrorStageHandler:
+		return "handler"
+	case ErrorStageDeferBeforeResponse:
+		return "defer_before_response"
+	case ErrorStageEncode:
+		return "encode"
+	case ErrorStageMaintenance:
+		return "maintenance"
+	case ErrorStageFeatureFlag:
+		return "feature_flag"
+	case ErrorStageConcurrencyLimit:
+		return "concurrency_limit"
+	case ErrorStageAuthorize:
+		return "authorize"
+	}
+	return "unknown"
+}
+
+// ErrorInfo is the information of the request where the error occurred.
+type ErrorInfo struct {
+	// Context is the Context[Reg] of the request. It is nil when the error occurred before the Context is built.
+	// It can be asserted to the Context[Reg] of the route.
+	Context gocontext.Context
+	// RoutePattern is the matched route pattern like a "/api/tasks/{id}".
+	RoutePattern string
+	// Stage is the stage of the request processing where the error occurred.
+	Stage ErrorStage
+}
+
+// ErrorHookerV2 is an ErrorHooker that receives the information of the request where the error occurred.
+// If the ErrorHooker set by WithErrorHooker also implements ErrorHookerV2, OnErrorV2 is called instead of OnError.
+type ErrorHookerV2 interface {
+	OnErrorV2(w http.ResponseWriter, req *http.Request, logger *slog.Logger, codec Codec, info *ErrorInfo, err error)
+}
+
+type errorHookerV2Adapter struct {
+	ErrorHookerV2
+}
+
+func (e *errorHookerV2Adapter) OnError(w http.ResponseWriter, req *http.Request, logger *slog.Logger, codec Codec, err error) {
+	e.OnErrorV2(w, req, logger, codec, &ErrorInfo{RoutePattern: routePattern(req), Stage: ErrorStageHandler}, err)
+}
+
+func routePattern(req *http.Request) string {
+	if rctx := chi.RouteContext(req.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+func (r *Router[Reg]) onError(w http.ResponseWriter, req *http.Request, ctx Context[Reg], stage ErrorStage, err error) {
+	if isClientClosed(req, err) {
+		r.onClientClosed(req, ctx, stage, err)
+		return
+	}
+	req = withErrorLocalizer(req, ctx, r.errorLocalizer)
+	req = withHTMLErrorRenderer(req, r.htmlErrorRenderer)
+	eh2, ok := r.errorHooker.(ErrorHookerV2)
+	if !ok {
+		r.errorHooker.OnError(w, req, r.logger, r.codec, err)
+		return
+	}
+	info := &ErrorInfo{
+		RoutePattern: routePattern(req),
+		Stage:        stage,
+	}
+	if ctx != nil {
+		info.Context = ctx
+	}
+	eh2.OnErrorV2(w, req, r.logger, r.codec, info, err)
 }
@@ -0,0 +1,102 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrFeatureDisabled is the error of the requests to the routes whose feature flags are disabled.
+// The error of the response is FeatureDisabledError, which wraps this error.
+var ErrFeatureDisabled = errors.New("feature is disabled")
+
+// FlagProvider evaluates the feature flags of the routes per request.
+// ctx is the Context[Reg] of the route, so the provider can use the registry and the request, for example, to roll out the feature to the users.
+type FlagProvider interface {
+	IsEnabled(ctx gocontext.Context, name string) (bool, error)
+}
+
+// FlagProviderFunc is an adapter to allow the use of ordinary functions as FlagProvider.
+type FlagProviderFunc func(ctx gocontext.Context, name string) (bool, error)
+
+func (f FlagProviderFunc) IsEnabled(ctx gocontext.Context, name string) (bool, error) {
+	return f(ctx, name)
+}
+
+// FeatureDisabledError is the error of the requests to the routes whose feature flags are disabled.
+// The message is the status text, so the response does not tell that the route exists.
+type FeatureDisabledError struct {
+	// Flag is the name of the disabled feature flag.
+	Flag   string
+	status int
+}
+
+func (e *FeatureDisabledError) Error() string {
+	return strings.ToLower(http.StatusText(e.status))
+}
+
+func (e *FeatureDisabledError) Status() int {
+	return e.status
+}
+
+func (e *FeatureDisabledError) Unwrap() error {
+	return ErrFeatureDisabled
+}
+
+// WithFlagProvider sets the FlagProvider that evaluates the feature flags of the routes that are registered by Router.WithFeatureFlag.
+func WithFlagProvider[Reg any](provider FlagProvider) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.featureFlags.provider = provider
+		return r
+	}
+}
+
+// WithFeatureDisabledStatus sets the status code of the responses of the routes whose feature flags are disabled.
+// The default is 404 Not Found. Use 403 Forbidden to tell the callers that the route exists.
+func WithFeatureDisabledStatus[Reg any](status int) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.featureFlags.disabledStatus = status
+		return r
+	}
+}
+
+// WithFeatureFlag returns the Router that gates the routes registered to it by the feature flags.
+// The flags are evaluated by the FlagProvider set by WithFlagProvider after the Context is built,
+// and the route responds as not found unless all flags are enabled. If no FlagProvider is set, the flags are disabled.
+//
+//	r.WithFeatureFlag("new-search").Get("/search", tanukirpc.NewHandler(search))
+func (r *Router[Reg]) WithFeatureFlag(names ...string) *Router[Reg] {
+	r2 := r.clone()
+	r2.featureFlags.names = append(slices.Clip(r.featureFlags.names), names...)
+	return r2
+}
+
+type featureFlags struct {
+	provider       FlagProvider
+	disabledStatus int
+	names          []string
+}
+
+// check returns the error if any of the feature flags of the route is disabled.
+func (f featureFlags) check(ctx gocontext.Context) error {
+	status := f.disabledStatus
+	if status == 0 {
+		status = http.StatusNotFound
+	}
+	for _, name := range f.names {
+		if f.provider == nil {
+			return &FeatureDisabledError{Flag: name, status: status}
+		}
+		enabled, err := f.provider.IsEnabled(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate feature flag %s: %w", name, err)
+		}
+		if !enabled {
+			return &FeatureDisabledError{Flag: name, status: status}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package tanukirpc
+
+import (
+	"encoding"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMultipartFormContentType = "multipart/form-data"
+	// defaultFormMaxMemory is the default of WithFormMaxMemory as same as http.Request.FormValue.
+	defaultFormMaxMemory = 32 << 20
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	fileHeaderType      = reflect.TypeFor[*multipart.FileHeader]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// formTimeLayouts is the layouts of the time fields of the form. The layouts of the date and the datetime-local inputs of HTML are accepted.
+var formTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+type formCodecConfig struct {
+	maxMemory int64
+}
+
+// FormCodecOption is an option for the FormCodec.
+type FormCodecOption func(*formCodecConfig)
+
+// WithFormMaxMemory sets the maximum bytes of the multipart form that are stored in memory. The rest of the files are stored in the temporary files.
+// The default is 32MB.
+func WithFormMaxMemory(maxMemory int64) FormCodecOption {
+	return func(c *formCodecConfig) {
+		c.maxMemory = maxMemory
+	}
+}
+
+type formCodec struct {
+	cfg *formCodecConfig
+}
+
+// NewFormCodec returns a new FormCodec. This codec supports request decoding only.
+// The content type header of the request is application/x-www-form-urlencoded or multipart/form-data.
+// If you want to use this codec, you need to set the struct field tag like a `form:"name"`. The fields without the tag are bound by the field name.
+//
+// The fields of the nested structs are bound by the dotted names like "task.name", and the elements of the slices of the structs are bound by the indexes like "tasks.0.name".
+// The slices of the values are bound by the repeated names. time.Time is parsed as RFC 3339 or the values of the date and the datetime-local inputs,
+// time.Duration is parsed by time.ParseDuration, and the types that implement encoding.TextUnmarshaler are bound by it.
+// The files of the multipart form are bound to the fields of *multipart.FileHeader and []*multipart.FileHeader.
+// The empty values are skipped except for the string fields, and the bool fields accept "on" of the checkboxes.
+func NewFormCodec(opts ...FormCodecOption) *formCodec {
+	cfg := &formCodecConfig{maxMemory: defaultFormMaxMemory}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &formCodec{cfg: cfg}
+}
+
+func (c *formCodec) Name() string {
+	return "form"
+}
+
+func (c *formCodec) MatchRequestType(t reflect.Type) bool {
+	return !isRawBodyStreamType(t)
+}
+
+func (c *formCodec) Decode(r *http.Request, v any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("content-type"))
+	if err != nil || (mediaType != defaultFormCodecContentType && mediaType != defaultMultipartFormContentType) {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+
+	// the binders generated by genbind read r.PostForm, which is empty for the multipart forms
+	if bind, ok := lookupBinder(c.Name(), v); ok && mediaType == defaultFormCodecContentType {
+		if err := bind(r, v); err != nil {
+			return &ErrCodecDecode{err: err}
+		}
+		return nil
+	}
+
+	var values url.Values
+	var files map[string][]*multipart.FileHeader
+	if mediaType == defaultMultipartFormContentType {
+		if err := r.ParseMultipartForm(c.cfg.maxMemory); err != nil {
+			return &ErrCodecDecode{err: fmt.Errorf("failed to parse multipart form: %w", err)}
+		}
+		values = url.Values(r.MultipartForm.Value)
+		files = r.MultipartForm.File
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return &ErrCodecDecode{err: fmt.Errorf("failed to parse form: %w", err)}
+		}
+		values = r.PostForm
+	}
+
+	vr := reflect.ValueOf(v)
+	if vr.Kind() != reflect.Pointer || vr.IsNil() {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+	vr = vr.Elem()
+	// for the handler that receives the request as a pointer
+	if vr.Kind() == reflect.Pointer {
+		if vr.IsNil() {
+			vr.Set(reflect.New(vr.Type().Elem()))
+		}
+		vr = vr.Elem()
+	}
+	if vr.Kind() != reflect.Struct {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+	d := &formDecoder{values: values, files: files}
+	if err := d.decodeStruct(vr, ""); err != nil {
+		return &ErrCodecDecode{err: err}
+	}
+	return nil
+}
+
+func (c *formCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return ErrResponseNotSupportedAtThisCodec
+}
+
+// FormFieldError is an error that occurs when the value of the form field can not be bound to the field.
+type FormFieldError struct {
+	Field string
+	err   error
+}
+
+// NewFormFieldError returns the FormFieldError of the field. It is used by the binders generated by the genbind command.
+func NewFormFieldError(field string, err error) *FormFieldError {
+	return &FormFieldError{Field: field, err: err}
+}
+
+func (e *FormFieldError) Error() string {
+	return fmt.Sprintf("invalid field %q: %v", e.Field, e.err)
+}
+
+func (e *FormFieldError) Status() int {
+	return http.StatusBadRequest
+}
+
+func (e *FormFieldError) Unwrap() error {
+	return e.err
+}
+
+type formDecoder struct {
+	values url.Values
+	files  map[string][]*multipart.FileHeader
+}
+
+// hasPrefix reports whether the form has the values or the files of the nested fields of the key.
+func (d *formDecoder) hasPrefix(key string) bool {
+	prefix := key + "."
+	for k := range d.values {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	for k := range d.files {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexes returns the sorted indexes of the elements of the slice of the key like "tasks.0.name".
+func (d *formDecoder) indexes(key string) []int {
+	prefix := key + "."
+	seen := map[int]struct{}{}
+	collect := func(k string) {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			return
+		}
+		index, _, _ := strings.Cut(rest, ".")
+		if i, err := strconv.Atoi(index); err == nil && i >= 0 {
+			seen[i] = struct{}{}
+		}
+	}
+	for k := range d.values {
+		collect(k)
+	}
+	for k := range d.files {
+		collect(k)
+	}
+	indexes := make([]int, 0, len(seen))
+	for i := range seen {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+func (d *formDecoder) decodeStruct(vr reflect.Value, prefix string) error {
+	str := vr.Type()
+	for i := 0; i < vr.NumField(); i++ {
+		ft := str.Field(i)
+		field := vr.Field(i)
+		if !ft.IsExported() && !ft.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(ft.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && ft.Anonymous && indirectType(ft.Type).Kind() == reflect.Struct {
+			if field.Kind() == reflect.Pointer {
+				if field.IsNil() {
+					if !field.CanSet() {
+						continue
+					}
+					field.Set(reflect.New(ft.Type.Elem()))
+				}
+				field = field.Elem()
+			}
+			if err := d.decodeStruct(field, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = ft.Name
+		}
+		if err := d.decodeField(field, prefix+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *formDecoder) decodeField(field reflect.Value, key string) error {
+	t := field.Type()
+	switch {
+	case t == fileHeaderType:
+		if fhs := d.files[key]; len(fhs) > 0 {
+			field.Set(reflect.ValueOf(fhs[0]))
+		}
+		return nil
+	case t.Kind() == reflect.Slice && t.Elem() == fileHeaderType:
+		if fhs := d.files[key]; len(fhs) > 0 {
+			field.Set(reflect.ValueOf(slices.Clone(fhs)))
+		}
+		return nil
+	case isFormScalar(t):
+		if vs, ok := d.values[key]; ok && len(vs) > 0 {
+			return d.setScalar(field, key, vs[0])
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if _, ok := d.values[key]; !ok && d.files[key] == nil && !d.hasPrefix(key) {
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(t.Elem()))
+		}
+		return d.decodeField(field.Elem(), key)
+	case reflect.Struct:
+		return d.decodeStruct(field, key+".")
+	case reflect.Slice:
+		if isFormScalar(t.Elem()) {
+			vs := d.values[key]
+			if len(vs) == 0 {
+				vs = d.values[key+"[]"]
+			}
+			if len(vs) == 0 {
+				return nil
+			}
+			s := reflect.MakeSlice(t, 0, len(vs))
+			for i, v := range vs {
+				ev := reflect.New(t.Elem()).Elem()
+				if v == "" && ev.Kind() != reflect.String {
+					continue
+				}
+				if err := d.setScalar(ev, key+"."+strconv.Itoa(i), v); err != nil {
+					return err
+				}
+				s = reflect.Append(s, ev)
+			}
+			field.Set(s)
+			return nil
+		}
+		indexes := d.indexes(key)
+		if len(indexes) == 0 {
+			return nil
+		}
+		s := reflect.MakeSlice(t, len(indexes), len(indexes))
+		for i, index := range indexes {
+			if err := d.decodeField(s.Index(i), key+"."+strconv.Itoa(index)); err != nil {
+				return err
+			}
+		}
+		field.Set(s)
+		return nil
+	}
+	if _, ok := d.values[key]; !ok && d.files[key] == nil && !d.hasPrefix(key) {
+		return nil
+	}
+	return &FormFieldError{Field: key, err: fmt.Errorf("unsupported type: %s", t)}
+}
+
+// isFormScalar reports whether the type is bound from the single value of the form.
+func isFormScalar(t reflect.Type) bool {
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+func (d *formDecoder) setScalar(field reflect.Value, key, value string) error {
+	if value == "" && field.Kind() != reflect.String {
+		return nil
+	}
+	switch {
+	case field.Type() == timeType:
+		for _, layout := range formTimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return &FormFieldError{Field: key, err: fmt.Errorf("failed to parse time %q", value)}
+	case field.Type() == durationType:
+		du, err := time.ParseDuration(value)
+		if err != nil {
+			return &FormFieldError{Field: key, err: err}
+		}
+		field.SetInt(int64(du))
+		return nil
+	case field.Addr().Type().Implements(textUnmarshalerType):
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return &FormFieldError{Field: key, err: err}
+		}
+		return nil
+	case field.Kind() == reflect.Bool && value == "on":
+		field.SetBool(true)
+		return nil
+	}
+	if err := setURLParam(field, key, value); err != nil {
+		return &FormFieldError{Field: key, err: err}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formTaskNewInput struct {
+	Name        string     `json:"name" form:"name" validate:"required"`
+	Description string     `json:"description" form:"description"`
+	Done        bool       `json:"done" form:"done"`
+	DueAt       *time.Time `json:"due_at" form:"due_at"`
+	Tags        []string   `json:"tags" form:"tags"`
+}
+
+type formAddTaskRequest struct {
+	Task     formTaskNewInput   `json:"task" form:"task" validate:"required"`
+	Subtasks []formTaskNewInput `json:"subtasks" form:"subtasks"`
+	Priority int                `json:"priority" form:"priority"`
+}
+
+type formAddTaskResponse struct {
+	Task     formTaskNewInput   `json:"task"`
+	Subtasks []formTaskNewInput `json:"subtasks"`
+	Priority int                `json:"priority"`
+	Attached string             `json:"attached"`
+}
+
+func formRouter() *tanukirpc.Router[struct{}] {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req formAddTaskRequest) (*formAddTaskResponse, error) {
+		return &formAddTaskResponse{Task: req.Task, Subtasks: req.Subtasks, Priority: req.Priority}, nil
+	}))
+	router.Post("/tasks/attachments", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		Task       formTaskNewInput      `form:"task"`
+		Attachment *multipart.FileHeader `form:"attachment"`
+	}) (*formAddTaskResponse, error) {
+		f, err := req.Attachment.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		body, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return &formAddTaskResponse{Task: req.Task, Attached: req.Attachment.Filename + ":" + string(body)}, nil
+	}))
+	return router
+}
+
+func TestFormCodec(t *testing.T) {
+	router := formRouter()
+
+	testCases := []struct {
+		name         string
+		form         url.Values
+		expectedCode int
+		expected     string
+	}{
+		{
+			name: "nested struct",
+			form: url.Values{
+				"task.name":        {"buy milk"},
+				"task.description": {"2 bottles"},
+				"task.done":        {"on"},
+				"task.due_at":      {"2024-08-01T10:30"},
+				"task.tags":        {"home", "shopping"},
+				"priority":         {"3"},
+			},
+			expectedCode: http.StatusOK,
+			expected:     `{"task":{"name":"buy milk","description":"2 bottles","done":true,"due_at":"2024-08-01T10:30:00Z","tags":["home","shopping"]},"subtasks":null,"priority":3,"attached":""}`,
+		},
+		{
+			name: "slice of structs",
+			form: url.Values{
+				"task.name":          {"move"},
+				"subtasks.1.name":    {"unpack"},
+				"subtasks.0.name":    {"pack"},
+				"subtasks.0.due_at":  {"2024-08-01"},
+				"subtasks.1.tags":    {"heavy"},
+				"priority":           {""},
+				"task.unknown_field": {"ignored"},
+			},
+			expectedCode: http.StatusOK,
+			expected:     `{"task":{"name":"move","description":"","done":false,"due_at":null,"tags":null},"subtasks":[{"name":"pack","description":"","done":false,"due_at":"2024-08-01T00:00:00Z","tags":null},{"name":"unpack","description":"","done":false,"due_at":null,"tags":["heavy"]}],"priority":0,"attached":""}`,
+		},
+		{
+			name:         "invalid number",
+			form:         url.Values{"task.name": {"buy milk"}, "priority": {"high"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid time",
+			form:         url.Values{"task.name": {"buy milk"}, "task.due_at": {"tomorrow"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "validation",
+			form:         url.Values{"task.description": {"no name"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, tc.expectedCode, rec.Code, rec.Body.String())
+			if tc.expected != "" {
+				assert.JSONEq(t, tc.expected, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFormCodecMultipart(t *testing.T) {
+	router := formRouter()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("task.name", "buy milk"))
+	require.NoError(t, mw.WriteField("task.tags", "home"))
+	fw, err := mw.CreateFormFile("attachment", "list.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("milk, eggs"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"task":{"name":"buy milk","description":"","done":false,"due_at":null,"tags":["home"]},"subtasks":null,"priority":0,"attached":"list.txt:milk, eggs"}`, rec.Body.String())
+}
+
+type formBoundRequest struct {
+	Name string `form:"name"`
+}
+
+var formBoundCalls int
+
+// bindFormFormBoundRequest is the same as the code generated by genbind.
+func bindFormFormBoundRequest(r *http.Request, v *formBoundRequest) error {
+	formBoundCalls++
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	v.Name = r.PostForm.Get("name")
+	return nil
+}
+
+func init() {
+	tanukirpc.RegisterBinder("form", bindFormFormBoundRequest)
+}
+
+func TestFormCodecMultipartWithBinder(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Post("/names", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req formBoundRequest) ([]byte, error) {
+		return []byte(req.Name), nil
+	}))
+	post := func(contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/names", body)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	formBoundCalls = 0
+	rec := post("application/x-www-form-urlencoded", strings.NewReader(url.Values{"name": {"tanuki"}}.Encode()))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "tanuki", rec.Body.String())
+	assert.Equal(t, 1, formBoundCalls)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("name", "kitsune"))
+	require.NoError(t, mw.Close())
+	rec = post(mw.FormDataContentType(), &body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "kitsune", rec.Body.String())
+	assert.Equal(t, 1, formBoundCalls)
+}
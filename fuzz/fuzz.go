@@ -0,0 +1,189 @@
+// Package fuzz provides the harness that sends the structured random requests to the routes of the Router.
+// The requests are generated from the request types of the handlers, and the responses with 5xx status code are reported as failures.
+// The Router recovers the panics in the handlers as 500 Internal Server Error, so the panics are also reported.
+package fuzz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+)
+
+// Target is the Router that is fuzzed.
+type Target interface {
+	http.Handler
+	Routes() ([]*tanukirpc.RouteInfo, error)
+}
+
+type config struct {
+	filter func(*tanukirpc.RouteInfo) bool
+}
+
+// Option is an option for Fuzz and Run.
+type Option func(*config)
+
+// WithRouteFilter limits the routes that are fuzzed to the ones that the filter returns true.
+func WithRouteFilter(filter func(*tanukirpc.RouteInfo) bool) Option {
+	return func(c *config) {
+		c.filter = filter
+	}
+}
+
+// Fuzz drives the Go native fuzzing with the routes of the target.
+//
+//	func FuzzRouter(f *testing.F) {
+//		fuzz.Fuzz(f, newRouter())
+//	}
+func Fuzz(f *testing.F, target Target, opts ...Option) {
+	f.Helper()
+	routes := fuzzRoutes(f, target, opts...)
+	for i := range routes {
+		f.Add(uint(i), []byte{})
+	}
+	f.Fuzz(func(t *testing.T, index uint, data []byte) {
+		route := routes[index%uint(len(routes))]
+		sum := sha256.Sum256(data)
+		src := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+		do(t, target, route, src)
+	})
+}
+
+// Run sends n random requests to each route of the target. The seed makes the requests reproducible.
+func Run(t testing.TB, target Target, n int, seed uint64, opts ...Option) {
+	t.Helper()
+	routes := fuzzRoutes(t, target, opts...)
+	src := rand.New(rand.NewPCG(seed, seed))
+	for _, route := range routes {
+		for range n {
+			do(t, target, route, src)
+		}
+	}
+}
+
+func fuzzRoutes(t testing.TB, target Target, opts ...Option) []*tanukirpc.RouteInfo {
+	t.Helper()
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	routes, err := target.Routes()
+	if err != nil {
+		t.Fatalf("fuzz: failed to get routes: %v", err)
+	}
+	filtered := make([]*tanukirpc.RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		if route.Metadata == nil {
+			continue
+		}
+		if cfg.filter != nil && !cfg.filter(route) {
+			continue
+		}
+		filtered = append(filtered, route)
+	}
+	if len(filtered) == 0 {
+		t.Fatalf("fuzz: no routes to fuzz")
+	}
+	return filtered
+}
+
+func do(t testing.TB, target Target, route *tanukirpc.RouteInfo, src *rand.Rand) {
+	t.Helper()
+	req, err := NewRequest(route, src)
+	if err != nil {
+		t.Fatalf("fuzz: failed to generate request of %s %s: %v", route.Method, route.Pattern, err)
+	}
+	body := ""
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		req.Body = io.NopCloser(strings.NewReader(body))
+	}
+	rec := httptest.NewRecorder()
+	target.ServeHTTP(rec, req)
+	if rec.Code >= http.StatusInternalServerError {
+		t.Errorf("fuzz: %s %s responded %d: request=%s %s body=%s response=%s",
+			route.Method, route.Pattern, rec.Code, req.Method, req.URL.String(), body, rec.Body.String())
+	}
+}
+
+// NewRequest generates the random request for the route by src.
+// The path parameters and the query string are filled by the urlparam and query struct tags, and the request is sent as the JSON body except for GET and HEAD.
+func NewRequest(route *tanukirpc.RouteInfo, src *rand.Rand) (*http.Request, error) {
+	g := &generator{src: src}
+	rv := g.value(route.Metadata.Request, 0)
+	sv := reflect.Indirect(rv)
+
+	params := taggedValues(sv, "urlparam")
+	path := fillPath(route.Pattern, func(name string) string {
+		if v := params.Get(name); v != "" {
+			return v
+		}
+		return g.nonEmptyString()
+	})
+	if query := taggedValues(sv, "query").Encode(); query != "" {
+		path += "?" + query
+	}
+
+	var body io.Reader
+	req := httptest.NewRequest(route.Method, path, nil)
+	if route.Method != http.MethodGet && route.Method != http.MethodHead {
+		b, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+		req = httptest.NewRequest(route.Method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+func fillPath(pattern string, value func(name string) string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		end := strings.Index(pattern, "}")
+		if start < 0 || end < start {
+			sb.WriteString(pattern)
+			break
+		}
+		name, _, _ := strings.Cut(pattern[start+1:end], ":")
+		sb.WriteString(pattern[:start])
+		sb.WriteString(url.PathEscape(value(name)))
+		pattern = pattern[end+1:]
+	}
+	return strings.ReplaceAll(sb.String(), "/*", "/"+url.PathEscape(value("*")))
+}
+
+func taggedValues(rv reflect.Value, tag string) url.Values {
+	values := url.Values{}
+	if rv.Kind() != reflect.Struct {
+		return values
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fv := reflect.Indirect(rv.Field(i))
+		if !fv.IsValid() {
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+	return values
+}
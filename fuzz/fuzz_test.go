@@ -0,0 +1,67 @@
+package fuzz_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/fuzz"
+	"github.com/stretchr/testify/assert"
+)
+
+type itemRequest struct {
+	ID    int      `urlparam:"id" json:"-"`
+	Limit int      `query:"limit" json:"-"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+}
+
+type itemResponse struct {
+	Message string `json:"message"`
+}
+
+type recordTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newItemRouter() *tanukirpc.Router[struct{}] {
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithAccessLogger[struct{}](nil))
+	router.Get("/items/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req itemRequest) (*itemResponse, error) {
+		return &itemResponse{Message: fmt.Sprintf("%d:%d", req.ID, req.Limit)}, nil
+	}))
+	router.Post("/items/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req itemRequest) (*itemResponse, error) {
+		// panics when the tags are empty
+		return &itemResponse{Message: req.Tags[0]}, nil
+	}))
+	return router
+}
+
+func TestRun(t *testing.T) {
+	router := newItemRouter()
+
+	t.Run("no failures", func(t *testing.T) {
+		fuzz.Run(t, router, 50, 1, fuzz.WithRouteFilter(func(ri *tanukirpc.RouteInfo) bool {
+			return ri.Method == http.MethodGet
+		}))
+	})
+	t.Run("surface panics", func(t *testing.T) {
+		rt := &recordTB{TB: t}
+		fuzz.Run(rt, router, 50, 1, fuzz.WithRouteFilter(func(ri *tanukirpc.RouteInfo) bool {
+			return ri.Method == http.MethodPost
+		}))
+		assert.NotEmpty(t, rt.errors)
+		assert.Contains(t, rt.errors[0], "POST /items/{id} responded 500")
+	})
+}
+
+func FuzzRouter(f *testing.F) {
+	fuzz.Fuzz(f, newItemRouter(), fuzz.WithRouteFilter(func(ri *tanukirpc.RouteInfo) bool {
+		return ri.Method == http.MethodGet
+	}))
+}
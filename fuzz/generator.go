@@ -0,0 +1,123 @@
+package fuzz
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"time"
+)
+
+const maxDepth = 4
+
+var (
+	timeType = reflect.TypeFor[time.Time]()
+
+	interestingStrings = []string{"", " ", "0", "-1", "null", "true", "tanuki", "日本語", "<script>", "'\"", "%00", strings100}
+	interestingInts    = []int64{0, 1, -1, math.MaxInt8, math.MinInt8, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64}
+	strings100         = string(make([]byte, 100))
+)
+
+type generator struct {
+	src *rand.Rand
+}
+
+// value returns the random value of the type. The returned value is addressable.
+func (g *generator) value(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	g.fill(v, depth)
+	return v
+}
+
+func (g *generator) fill(v reflect.Value, depth int) {
+	if !v.CanSet() {
+		return
+	}
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(time.Unix(g.src.Int64N(1<<33), 0).UTC()))
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(g.string())
+	case reflect.Bool:
+		v.SetBool(g.src.IntN(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := g.int()
+		if v.OverflowInt(n) {
+			n = int64(int8(n))
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := uint64(g.int())
+		if v.OverflowUint(n) {
+			n = uint64(uint8(n))
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(g.src.NormFloat64() * 1e6)
+	case reflect.Pointer:
+		if depth >= maxDepth || g.src.IntN(4) == 0 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		g.fill(p.Elem(), depth+1)
+		v.Set(p)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			g.fill(v.Field(i), depth+1)
+		}
+	case reflect.Slice:
+		if depth >= maxDepth {
+			return
+		}
+		n := g.src.IntN(4)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := range n {
+			g.fill(s.Index(i), depth+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := range v.Len() {
+			g.fill(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if depth >= maxDepth {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		for range g.src.IntN(4) {
+			k := g.value(v.Type().Key(), depth+1)
+			e := g.value(v.Type().Elem(), depth+1)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	}
+}
+
+func (g *generator) string() string {
+	if g.src.IntN(2) == 0 {
+		return interestingStrings[g.src.IntN(len(interestingStrings))]
+	}
+	b := make([]rune, g.src.IntN(16))
+	for i := range b {
+		b[i] = rune(0x20 + g.src.IntN(0x3000))
+	}
+	return string(b)
+}
+
+func (g *generator) nonEmptyString() string {
+	if s := g.string(); s != "" {
+		return s
+	}
+	return "tanuki"
+}
+
+func (g *generator) int() int64 {
+	if g.src.IntN(2) == 0 {
+		return interestingInts[g.src.IntN(len(interestingInts))]
+	}
+	return g.src.Int64N(2000) - 1000
+}
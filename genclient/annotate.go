@@ -0,0 +1,155 @@
+package genclient
+
+import (
+	"cmp"
+	"go/constant"
+	"go/types"
+	"slices"
+
+	"github.com/gostaticanalysis/analysisutil"
+	"github.com/mackee/tanukirpc"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+type annotateTypeInfo struct {
+	annotateObj    types.Object
+	nameObj        types.Object
+	tagsObj        types.Object
+	scopesObj      types.Object
+	deprecationObj types.Object
+}
+
+func newAnnotateTypeInfo(pass *analysis.Pass) *annotateTypeInfo {
+	annotateObj := analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "Annotate")
+	if annotateObj == nil {
+		return nil
+	}
+	return &annotateTypeInfo{
+		annotateObj:    annotateObj,
+		nameObj:        analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "WithRouteName"),
+		tagsObj:        analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "WithRouteTags"),
+		scopesObj:      analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "WithRouteScopes"),
+		deprecationObj: analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "WithRouteDeprecation"),
+	}
+}
+
+// isAnnotate reports whether the callee is Annotate.
+func (a *annotateTypeInfo) isAnnotate(callee *ssa.Function) bool {
+	return a != nil && callee != nil && callee.Object() == a.annotateObj
+}
+
+// annotations returns the annotations of the Annotate calls that newHandlerCall followed, and whether they deprecate the route.
+// The options are resolved only if they are the calls of the option functions with the string literals.
+func (a *annotateTypeInfo) annotations(seen map[ssa.Value]struct{}) (*tanukirpc.RouteAnnotations, bool) {
+	if a == nil {
+		return nil, false
+	}
+	calls := make([]*ssa.Call, 0)
+	for v := range seen {
+		if call, ok := v.(*ssa.Call); ok && a.isAnnotate(call.Call.StaticCallee()) {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return nil, false
+	}
+	// the inner Annotate is applied first, so the outer one overrides the name
+	slices.SortFunc(calls, func(x, y *ssa.Call) int {
+		return cmp.Compare(y.Pos(), x.Pos())
+	})
+	annotations := &tanukirpc.RouteAnnotations{}
+	deprecated := false
+	for _, call := range calls {
+		if len(call.Call.Args) < 2 {
+			continue
+		}
+		for _, opt := range variadicValues(call.Call.Args[1]) {
+			optCall, ok := opt.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			callee := optCall.Call.StaticCallee()
+			if callee == nil {
+				continue
+			}
+			switch callee.Object() {
+			case a.nameObj:
+				if len(optCall.Call.Args) == 1 {
+					if name, ok := stringConst(optCall.Call.Args[0]); ok {
+						annotations.Name = name
+					}
+				}
+			case a.tagsObj:
+				if len(optCall.Call.Args) == 1 {
+					annotations.Tags = appendStringConsts(annotations.Tags, variadicValues(optCall.Call.Args[0]))
+				}
+			case a.scopesObj:
+				if len(optCall.Call.Args) == 1 {
+					annotations.Scopes = appendStringConsts(annotations.Scopes, variadicValues(optCall.Call.Args[0]))
+				}
+			case a.deprecationObj:
+				deprecated = true
+			}
+		}
+	}
+	return annotations, deprecated
+}
+
+// variadicValues returns the values of the variadic arguments in the order of the arguments.
+func variadicValues(v ssa.Value) []ssa.Value {
+	s, ok := v.(*ssa.Slice)
+	if !ok {
+		return nil
+	}
+	alloc, ok := s.X.(*ssa.Alloc)
+	if !ok || alloc.Referrers() == nil {
+		return nil
+	}
+	type indexed struct {
+		index int64
+		value ssa.Value
+	}
+	values := make([]indexed, 0)
+	for _, ref := range *alloc.Referrers() {
+		ia, ok := ref.(*ssa.IndexAddr)
+		if !ok || ia.Referrers() == nil {
+			continue
+		}
+		c, ok := ia.Index.(*ssa.Const)
+		if !ok {
+			continue
+		}
+		for _, r := range *ia.Referrers() {
+			if store, ok := r.(*ssa.Store); ok && store.Addr == ia {
+				values = append(values, indexed{index: c.Int64(), value: store.Val})
+			}
+		}
+	}
+	slices.SortFunc(values, func(x, y indexed) int {
+		return cmp.Compare(x.index, y.index)
+	})
+	result := make([]ssa.Value, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.value)
+	}
+	return result
+}
+
+func stringConst(v ssa.Value) (string, bool) {
+	c, ok := v.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(c.Value), true
+}
+
+// appendStringConsts appends the string literals of values to ss except for the duplicates.
+func appendStringConsts(ss []string, values []ssa.Value) []string {
+	for _, v := range values {
+		if s, ok := stringConst(v); ok && !slices.Contains(ss, s) {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,49 @@
+package genclient
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/gostaticanalysis/analysisutil"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+type batchTypeInfo struct {
+	newBatchHandlerObj types.Object
+	requestObj         types.Object
+	responseObj        types.Object
+}
+
+func newBatchTypeInfo(pass *analysis.Pass) *batchTypeInfo {
+	newBatchHandlerObj := analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "NewBatchHandler")
+	if newBatchHandlerObj == nil {
+		return nil
+	}
+	return &batchTypeInfo{
+		newBatchHandlerObj: newBatchHandlerObj,
+		requestObj:         analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "BatchRequest"),
+		responseObj:        analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "BatchResponse"),
+	}
+}
+
+// isNewBatchHandler reports whether the callee is NewBatchHandler.
+func (b *batchTypeInfo) isNewBatchHandler(callee *ssa.Function) bool {
+	return b != nil && callee != nil && callee.Object() == b.newBatchHandlerObj
+}
+
+// wrap returns BatchRequest[req] and *BatchResponse[res] that are the request and the response of the batch handler.
+func (b *batchTypeInfo) wrap(req, res types.Type) (types.Type, types.Type, error) {
+	if b.requestObj == nil || b.responseObj == nil {
+		return nil, nil, fmt.Errorf("BatchRequest or BatchResponse is not found")
+	}
+	breq, err := types.Instantiate(nil, b.requestObj.Type(), []types.Type{req}, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to instantiate BatchRequest: %w", err)
+	}
+	bres, err := types.Instantiate(nil, b.responseObj.Type(), []types.Type{res}, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to instantiate BatchResponse: %w", err)
+	}
+	return breq, types.NewPointer(bres), nil
+}
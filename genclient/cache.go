@@ -0,0 +1,136 @@
+package genclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// cacheVersion is changed when the generated code is changed with the same inputs.
+const cacheVersion = "1"
+
+// analysisCache stores the generated code in the user cache directory.
+// The key is the hash of the Go files in the module, go.mod, go.sum and the flags of the analyzer,
+// so the entry is reused until any of them is changed.
+// The modules that are replaced with the directories outside the module are not hashed.
+type analysisCache struct {
+	dir string
+}
+
+func newAnalysisCache(name string) (*analysisCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the user cache directory: %w", err)
+	}
+	dir = filepath.Join(dir, "tanukirpc", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+	return &analysisCache{dir: dir}, nil
+}
+
+func (c *analysisCache) get(key string) ([]byte, bool) {
+	b, err := os.ReadFile(filepath.Join(c.dir, key))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func (c *analysisCache) put(key string, b []byte) error {
+	tmp, err := os.CreateTemp(c.dir, key+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create the cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close the cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, key)); err != nil {
+		return fmt.Errorf("failed to rename the cache file: %w", err)
+	}
+	return nil
+}
+
+// packageHash returns the hash of the inputs of the analysis in the module that contains dir.
+func packageHash(dir string, patterns []string, flags *flag.FlagSet) (string, error) {
+	root, err := moduleRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%s go=%s patterns=%s\n", cacheVersion, runtime.Version(), strings.Join(patterns, ","))
+	flags.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(h, "flag %s=%s\n", f.Name, f.Value.String())
+	})
+	if p := typeScriptClientMarshalers; p != "" {
+		if err := hashFile(h, p); err != nil {
+			return "", err
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && isIgnoredDir(name) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+		return hashFile(h, path)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash the module: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	fmt.Fprintf(w, "file %s\n", path)
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+var errModuleRootNotFound = errors.New("go.mod is not found")
+
+func moduleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the absolute path: %w", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errModuleRootNotFound
+		}
+		dir = parent
+	}
+}
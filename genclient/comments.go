@@ -0,0 +1,91 @@
+package genclient
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// docComments holds the doc comments of the functions and the struct fields in the analyzed package.
+// They are keyed by the position of the name.
+type docComments struct {
+	funcs  map[token.Pos]string
+	fields map[token.Pos]string
+}
+
+func newDocComments(pass *analysis.Pass) *docComments {
+	d := &docComments{
+		funcs:  make(map[token.Pos]string),
+		fields: make(map[token.Pos]string),
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if text := strings.TrimSpace(n.Doc.Text()); text != "" {
+					d.funcs[n.Name.Pos()] = text
+				}
+			case *ast.InterfaceType:
+				// the methods of the service interfaces are registered by RegisterService
+				for _, m := range n.Methods.List {
+					if _, ok := m.Type.(*ast.FuncType); !ok {
+						continue
+					}
+					if text := strings.TrimSpace(m.Doc.Text()); text != "" {
+						for _, name := range m.Names {
+							d.funcs[name.Pos()] = text
+						}
+					}
+				}
+			case *ast.Field:
+				text := strings.TrimSpace(n.Doc.Text())
+				if text == "" {
+					text = strings.TrimSpace(n.Comment.Text())
+				}
+				if text == "" {
+					return true
+				}
+				for _, name := range n.Names {
+					d.fields[name.Pos()] = text
+				}
+			}
+			return true
+		})
+	}
+	return d
+}
+
+func (d *docComments) funcDoc(fn *types.Func) string {
+	if d == nil || fn == nil {
+		return ""
+	}
+	return d.funcs[fn.Pos()]
+}
+
+func (d *docComments) fieldDoc(field *types.Var) string {
+	if d == nil || field == nil {
+		return ""
+	}
+	return d.fields[field.Pos()]
+}
+
+// jsDoc renders the comment as JSDoc with the indent.
+func jsDoc(prefix, doc string) string {
+	if doc == "" {
+		return ""
+	}
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		return prefix + "/** " + lines[0] + " */"
+	}
+	ret := prefix + "/**\n"
+	for _, line := range lines {
+		ret += strings.TrimRight(prefix+" * "+line, " ") + "\n"
+	}
+	ret += prefix + " */"
+	return ret
+}
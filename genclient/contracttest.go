@@ -0,0 +1,148 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var ContractTestGenerator = &analysis.Analyzer{
+	Name: "gencontracttest",
+	Doc:  "generate tests that assert the request and response shapes of the routes against golden files",
+	Run:  generateContractTest,
+	Requires: []*analysis.Analyzer{
+		Analyzer,
+	},
+	ResultType: reflect.TypeOf((*bytes.Buffer)(nil)),
+}
+
+var contractTestOutPath string
+
+func init() {
+	ContractTestGenerator.Flags.StringVar(&contractTestOutPath, "out", "", "output file path")
+}
+
+func generateContractTest(pass *analysis.Pass) (any, error) {
+	result := pass.ResultOf[Analyzer].(*AnalyzerResult)
+	if len(result.RoutePaths) == 0 {
+		return &bytes.Buffer{}, nil
+	}
+
+	args := &contractTestTemplateArgs{Package: pass.Pkg.Name()}
+	seen := make(map[string]struct{}, len(result.RoutePaths))
+	for _, rp := range result.RoutePaths {
+		contract, err := routeContract(rp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate contract of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		name := contractName(rp.Method(), rp.Path())
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		args.Contracts = append(args.Contracts, &contractTestTemplateContract{
+			Name:     name,
+			Contract: contract,
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	if err := contractTestTemplate.Execute(buf, args); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	if contractTestOutPath != "" {
+		if err := os.WriteFile(contractTestOutPath, src, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+
+	return bytes.NewBuffer(src), nil
+}
+
+// routeContract renders the wire contract of the route in the same notation as the TypeScript client.
+func routeContract(rp RoutePath) (string, error) {
+	gen := &typeScriptClientGenerator{opts: &TypeScriptOptions{DurationType: "number"}}
+	h := rp.Handler()
+	sections := []struct {
+		name string
+		typ  func() (typeScriptClientGeneratorField, error)
+	}{
+		{name: "query", typ: func() (typeScriptClientGeneratorField, error) { return gen.typeInfo(h.Req(), "query") }},
+		{name: "request", typ: func() (typeScriptClientGeneratorField, error) { return gen.typeInfo(h.Req(), "json") }},
+		{name: "response", typ: func() (typeScriptClientGeneratorField, error) { return gen.typeInfo(h.Res(), "json") }},
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", rp.Method(), rp.Path())
+	for _, s := range sections {
+		f, err := s.typ()
+		if err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", s.name, err)
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", s.name, f.RenderResponse(""))
+	}
+	return sb.String(), nil
+}
+
+// contractName converts the method and the path to the name that can be used as the test name and the file name.
+func contractName(method, path string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, method+"_"+strings.Trim(path, "/"))
+	for strings.Contains(name, "__") {
+		name = strings.ReplaceAll(name, "__", "_")
+	}
+	return strings.TrimSuffix(name, "_")
+}
+
+type contractTestTemplateArgs struct {
+	Package   string
+	Contracts []*contractTestTemplateContract
+}
+
+type contractTestTemplateContract struct {
+	Name     string
+	Contract string
+}
+
+var contractTestTemplate = template.Must(template.New("gencontracttest").Parse(`// Code generated by gencontracttest. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mackee/tanukirpc/tanukitest"
+)
+
+func TestContract(t *testing.T) {
+	contracts := []struct {
+		name     string
+		contract string
+	}{
+{{- range .Contracts }}
+		{name: {{ printf "%q" .Name }}, contract: {{ printf "%q" .Contract }}},
+{{- end }}
+	}
+	for _, c := range contracts {
+		t.Run(c.name, func(t *testing.T) {
+			tanukitest.AssertGolden(t, filepath.Join("testdata", "contract", c.name+".golden"), c.contract)
+		})
+	}
+}
+`))
@@ -0,0 +1,267 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// DocsOptions is the options of GenerateMarkdown and GenerateHTML.
+type DocsOptions struct {
+	// Title is the title of the document. The default is "API Reference".
+	Title string
+	// Marshalers and DurationType are the same as TypeScriptOptions.
+	Marshalers   map[string]string
+	DurationType string
+}
+
+// docsReference is the data of the templates of the documents.
+type docsReference struct {
+	Title   string
+	Routes  []*mobileRoute
+	Classes []*mobileClass
+}
+
+func newDocsReference(result *AnalyzerResult, opts *DocsOptions) (*docsReference, error) {
+	if opts == nil {
+		opts = &DocsOptions{}
+	}
+	title := opts.Title
+	if title == "" {
+		title = "API Reference"
+	}
+	m, err := newMobileTypeMapper(result, docsLanguage{}, opts.Marshalers, opts.DurationType)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := m.routes(result)
+	if err != nil {
+		return nil, err
+	}
+	return &docsReference{Title: title, Routes: routes, Classes: m.classes}, nil
+}
+
+// GenerateMarkdown generates the API reference in Markdown of the routes in the result.
+// It describes the method, the path, the tables of the request and response types with the validation rules, and the shape of the errors.
+func GenerateMarkdown(result *AnalyzerResult, opts *DocsOptions) ([]byte, error) {
+	ref, err := newDocsReference(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := markdownDocsTemplate.Execute(buf, ref); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateHTML generates the API reference in HTML. The content is the same as GenerateMarkdown.
+func GenerateHTML(result *AnalyzerResult, opts *DocsOptions) ([]byte, error) {
+	ref, err := newDocsReference(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := htmlDocsTemplate.Execute(buf, ref); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// docsLanguage renders the types in the TypeScript notation.
+type docsLanguage struct{}
+
+func (docsLanguage) literal(t typeScriptClientGeneratorLiteralType) string {
+	return string(t)
+}
+
+func (docsLanguage) enum(t typeScriptClientGeneratorEnumType) string {
+	return strings.Join(t, " | ")
+}
+
+func (docsLanguage) array(elem string) string {
+	if strings.Contains(elem, " ") {
+		return "(" + elem + ")[]"
+	}
+	return elem + "[]"
+}
+
+func (docsLanguage) record(elem string) string {
+	return "Record<string, " + elem + ">"
+}
+
+func (docsLanguage) identifier(name string) string {
+	return name
+}
+
+// docsRules returns the validation rules except required, which is shown in the Required column.
+func docsRules(validate string) string {
+	rules := make([]string, 0)
+	for _, rule := range strings.Split(validate, ",") {
+		if rule == "" || rule == "required" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return strings.Join(rules, ", ")
+}
+
+// docsSummary returns the first line of the doc comment.
+func docsSummary(doc string) string {
+	summary, _, _ := strings.Cut(doc, "\n")
+	return summary
+}
+
+// markdownCell escapes the text in the cell of the table.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// markdownCode renders the text as the code span in the cell of the table.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+var docsFuncs = map[string]any{
+	"rules":   docsRules,
+	"summary": docsSummary,
+	"cell":    markdownCell,
+	"code":    markdownCode,
+}
+
+var markdownDocsTemplate = template.Must(template.New("markdown").Funcs(docsFuncs).Parse(`# {{ .Title }}
+
+## Routes
+
+| Method | Path | Summary |
+| --- | --- | --- |
+{{- range .Routes }}
+| {{ .Method }} | [{{ code .Path }}](#{{ .Name }}) | {{ cell (summary .Doc) }} |
+{{- end }}
+{{ range .Routes }}
+### <a id="{{ .Name }}"></a>{{ .Method }} {{ .Path }}
+{{ if .Doc }}
+{{ .Doc }}
+{{ end }}
+{{- if .PathParams }}
+Path parameters:
+
+| Name | Type | Required | Rules | Description |
+| --- | --- | --- | --- | --- |
+{{- range .PathParams }}
+| {{ code .Name }} | {{ code .Type }} | yes | {{ code (rules .Validate) }} |  |
+{{- end }}
+{{ end }}
+{{- if .QueryFields }}
+Query parameters:
+
+| Name | Type | Required | Rules | Description |
+| --- | --- | --- | --- | --- |
+{{- range .QueryFields }}
+| {{ code .Name }} | {{ code .Type }} | {{ if .Optional }}no{{ else }}yes{{ end }} | {{ code (rules .Validate) }} | {{ cell .Doc }} |
+{{- end }}
+{{ end }}
+- Request body: {{ if .Request }}[{{ code .Request }}](#{{ .Request }}){{ else }}none{{ end }}
+- Response: {{ if .Response }}[{{ code .Response }}](#{{ .Response }}){{ else }}none{{ end }}
+- Errors: [` + "`Error`" + `](#errors)
+{{ end }}
+## Types
+{{ range .Classes }}
+### <a id="{{ .Name }}"></a>{{ .Name }}
+
+| Name | Type | Required | Rules | Description |
+| --- | --- | --- | --- | --- |
+{{- range .Fields }}
+| {{ code .Name }} | {{ code .Type }} | {{ if .Optional }}no{{ else }}yes{{ end }} | {{ code (rules .Validate) }} | {{ cell .Doc }} |
+{{- end }}
+{{ end }}
+## <a id="errors"></a>Errors
+
+The errors are responded with the status code of the error and the following body.
+The validation errors of the request are responded with 400 Bad Request.
+
+` + "```json" + `
+{
+  "error": {
+    "message": "string"
+  }
+}
+` + "```" + `
+`))
+
+var htmlDocsTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(docsFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.method { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+
+<h2>Routes</h2>
+<table>
+<tr><th>Method</th><th>Path</th><th>Summary</th></tr>
+{{- range .Routes }}
+<tr><td class="method">{{ .Method }}</td><td><a href="#{{ .Name }}"><code>{{ .Path }}</code></a></td><td>{{ summary .Doc }}</td></tr>
+{{- end }}
+</table>
+{{ range .Routes }}
+<section id="{{ .Name }}">
+<h3><span class="method">{{ .Method }}</span> <code>{{ .Path }}</code></h3>
+{{- if .Doc }}
+<p>{{ .Doc }}</p>
+{{- end }}
+{{- if .PathParams }}
+<h4>Path parameters</h4>
+{{ template "fields" .PathParams }}
+{{- end }}
+{{- if .QueryFields }}
+<h4>Query parameters</h4>
+{{ template "fields" .QueryFields }}
+{{- end }}
+<ul>
+<li>Request body: {{ if .Request }}<a href="#{{ .Request }}"><code>{{ .Request }}</code></a>{{ else }}none{{ end }}</li>
+<li>Response: {{ if .Response }}<a href="#{{ .Response }}"><code>{{ .Response }}</code></a>{{ else }}none{{ end }}</li>
+<li>Errors: <a href="#errors"><code>Error</code></a></li>
+</ul>
+</section>
+{{ end }}
+<h2>Types</h2>
+{{ range .Classes }}
+<section id="{{ .Name }}">
+<h3>{{ .Name }}</h3>
+{{ template "fields" .Fields }}
+</section>
+{{ end }}
+<section id="errors">
+<h2>Errors</h2>
+<p>The errors are responded with the status code of the error and the following body.
+The validation errors of the request are responded with 400 Bad Request.</p>
+<pre><code>{
+  "error": {
+    "message": "string"
+  }
+}</code></pre>
+</section>
+</body>
+</html>
+{{ define "fields" -}}
+<table>
+<tr><th>Name</th><th>Type</th><th>Required</th><th>Rules</th><th>Description</th></tr>
+{{- range . }}
+<tr><td><code>{{ .Name }}</code></td><td><code>{{ .Type }}</code></td><td>{{ if .Optional }}no{{ else }}yes{{ end }}</td><td>{{ with rules .Validate }}<code>{{ . }}</code>{{ end }}</td><td>{{ .Doc }}</td></tr>
+{{- end }}
+</table>
+{{- end }}`))
@@ -0,0 +1,202 @@
+package genclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode is the mode to load the packages for the analyzers.
+// The dependencies are loaded from the export data, so only the target packages are type-checked from the source.
+const loadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedSyntax |
+	packages.NeedTypes |
+	packages.NeedTypesInfo |
+	packages.NeedTypesSizes |
+	packages.NeedModule
+
+func loadPackages(ctx context.Context, dir string, patterns ...string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    loadMode,
+		Dir:     dir,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages are found: %v", patterns)
+	}
+	var errs []error
+	for _, pkg := range pkgs {
+		for _, perr := range pkg.Errors {
+			errs = append(errs, perr)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load packages: %w", errors.Join(errs...))
+	}
+	return pkgs, nil
+}
+
+// Load analyzes the package in dir and returns the routes without the analysis driver.
+// The problems of the route registrations are returned in AnalyzerResult.Diagnostics.
+func Load(ctx context.Context, dir string) (*AnalyzerResult, error) {
+	pkgs, err := loadPackages(ctx, dir, ".")
+	if err != nil {
+		return nil, err
+	}
+	r := newAnalysisRunner(pkgs[0])
+	v, err := r.run(Analyzer)
+	if err != nil {
+		return nil, err
+	}
+	result := v.(*AnalyzerResult)
+	for _, d := range r.diagnostics {
+		result.Diagnostics = append(result.Diagnostics, Diagnostic{
+			Position: r.pkg.Fset.Position(d.Pos),
+			Message:  d.Message,
+		})
+	}
+	return result, nil
+}
+
+// recheckPackage parses the files of the package again and type-checks it with the loaded dependencies.
+// It returns false when the package imports the package that is not loaded.
+func recheckPackage(pkg *packages.Package) (*packages.Package, bool, error) {
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(pkg.CompiledGoFiles))
+	for _, name := range pkg.CompiledGoFiles {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		files = append(files, f)
+	}
+	imports := make(map[string]*types.Package, len(pkg.Types.Imports()))
+	for _, imp := range pkg.Types.Imports() {
+		imports[imp.Path()] = imp
+	}
+	imports["unsafe"] = types.Unsafe
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to unquote the import path: %w", err)
+			}
+			if _, ok := imports[path]; !ok {
+				return nil, false, nil
+			}
+		}
+	}
+
+	info := &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		FileVersions: make(map[*ast.File]string),
+	}
+	conf := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			return imports[path], nil
+		}),
+		Sizes: pkg.TypesSizes,
+	}
+	tpkg, err := conf.Check(pkg.PkgPath, fset, files, info)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to type-check %s: %w", pkg.PkgPath, err)
+	}
+
+	rechecked := *pkg
+	rechecked.Fset = fset
+	rechecked.Syntax = files
+	rechecked.Types = tpkg
+	rechecked.TypesInfo = info
+	return &rechecked, true, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// analysisRunner runs the analyzer and its requirements on a package without the analysis driver.
+// The analyzers in this package do not use the facts.
+type analysisRunner struct {
+	pkg         *packages.Package
+	results     map[*analysis.Analyzer]any
+	diagnostics []analysis.Diagnostic
+}
+
+func newAnalysisRunner(pkg *packages.Package) *analysisRunner {
+	return &analysisRunner{
+		pkg:     pkg,
+		results: make(map[*analysis.Analyzer]any),
+	}
+}
+
+func (r *analysisRunner) run(a *analysis.Analyzer) (any, error) {
+	if result, ok := r.results[a]; ok {
+		return result, nil
+	}
+	resultOf := make(map[*analysis.Analyzer]any, len(a.Requires))
+	for _, req := range a.Requires {
+		result, err := r.run(req)
+		if err != nil {
+			return nil, err
+		}
+		resultOf[req] = result
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:     a,
+		Fset:         r.pkg.Fset,
+		Files:        r.pkg.Syntax,
+		OtherFiles:   r.pkg.OtherFiles,
+		IgnoredFiles: r.pkg.IgnoredFiles,
+		Pkg:          r.pkg.Types,
+		TypesInfo:    r.pkg.TypesInfo,
+		TypesSizes:   r.pkg.TypesSizes,
+		ResultOf:     resultOf,
+		Report: func(d analysis.Diagnostic) {
+			r.diagnostics = append(r.diagnostics, d)
+		},
+		ReadFile:          os.ReadFile,
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ExportPackageFact: func(analysis.Fact) {},
+		AllPackageFacts:   func() []analysis.PackageFact { return nil },
+		AllObjectFacts:    func() []analysis.ObjectFact { return nil },
+	}
+	result, err := a.Run(pass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", a.Name, err)
+	}
+	r.results[a] = result
+	return result, nil
+}
+
+// printDiagnostics writes the diagnostics in the same format as singlechecker.
+func (r *analysisRunner) printDiagnostics() {
+	for _, d := range r.diagnostics {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", r.pkg.Fset.Position(d.Pos), d.Message)
+	}
+}
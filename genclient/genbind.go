@@ -0,0 +1,302 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var BindGenerator = &analysis.Analyzer{
+	Name: "genbind",
+	Doc:  "generate request binding functions that replace the reflection-based binding",
+	Run:  generateBind,
+	Requires: []*analysis.Analyzer{
+		Analyzer,
+	},
+	ResultType: reflect.TypeOf((*bytes.Buffer)(nil)),
+}
+
+var bindOutPath string
+
+func init() {
+	BindGenerator.Flags.StringVar(&bindOutPath, "out", "", "output file path")
+}
+
+var bindCodecTags = []struct {
+	codec string
+	tag   string
+}{
+	{codec: "urlparam", tag: "urlparam"},
+	{codec: "query", tag: "query"},
+	{codec: "form", tag: "form"},
+}
+
+func generateBind(pass *analysis.Pass) (any, error) {
+	result := pass.ResultOf[Analyzer].(*AnalyzerResult)
+	if len(result.RoutePaths) == 0 {
+		return &bytes.Buffer{}, nil
+	}
+
+	args := &bindTemplateArgs{Package: pass.Pkg.Name()}
+	seen := make(map[*types.TypeName]struct{})
+	for _, rp := range result.RoutePaths {
+		named, ok := bindTargetType(pass.Pkg, rp.Handler().Req())
+		if !ok {
+			continue
+		}
+		if _, ok := seen[named.Obj()]; ok {
+			continue
+		}
+		seen[named.Obj()] = struct{}{}
+
+		st := named.Underlying().(*types.Struct)
+		for _, ct := range bindCodecTags {
+			fields, ok := bindFields(st, ct.codec, ct.tag)
+			if !ok || len(fields) == 0 {
+				continue
+			}
+			args.Binders = append(args.Binders, &bindTemplateBinder{
+				Codec:    ct.codec,
+				TypeName: named.Obj().Name(),
+				Fields:   fields,
+			})
+		}
+	}
+	sort.SliceStable(args.Binders, func(i, j int) bool {
+		return args.Binders[i].FuncName() < args.Binders[j].FuncName()
+	})
+
+	buf := &bytes.Buffer{}
+	if len(args.Binders) == 0 {
+		return buf, nil
+	}
+	if err := bindTemplate.Execute(buf, args); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	if bindOutPath != "" {
+		if err := os.WriteFile(bindOutPath, src, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+
+	return bytes.NewBuffer(src), nil
+}
+
+// bindTargetType returns the named struct type that is declared at the package scope.
+// The types declared in a function cannot be referred from the generated code.
+func bindTargetType(pkg *types.Package, t types.Type) (*types.Named, bool) {
+	if pt, ok := t.(*types.Pointer); ok {
+		t = pt.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.TypeArgs().Len() > 0 {
+		return nil, false
+	}
+	obj := named.Obj()
+	if obj.Pkg() != pkg || obj.Parent() != pkg.Scope() {
+		return nil, false
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, false
+	}
+	return named, true
+}
+
+type bindField struct {
+	Codec string
+	Name  string
+	Param string
+	Kind  types.BasicKind
+	Type  string
+}
+
+// bindFields returns the fields that are bound by the codec. If the struct has a field that is not supported, it returns false.
+// The query and form codecs bind the exported fields without the tag by the field name, so they are bound by the field name as well.
+// The binder is generated only if the struct has a field with the tag.
+func bindFields(st *types.Struct, codec, tag string) ([]*bindField, bool) {
+	fields := make([]*bindField, 0)
+	tagged := false
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		param, hasTag := reflect.StructTag(st.Tag(i)).Lookup(tag)
+		if _, ok := f.Type().Underlying().(*types.Struct); ok {
+			// nested structs are bound by the reflection-based binding
+			return nil, false
+		}
+		param = strings.Split(param, ",")[0]
+		if hasTag && param != "" && param != "-" {
+			tagged = true
+		}
+		if param == "-" {
+			continue
+		}
+		if param == "" {
+			if codec == "urlparam" || !f.Exported() {
+				continue
+			}
+			param = f.Name()
+		}
+		bt, ok := f.Type().Underlying().(*types.Basic)
+		if !ok || bt.Info()&(types.IsString|types.IsInteger|types.IsFloat|types.IsBoolean) == 0 {
+			return nil, false
+		}
+		fields = append(fields, &bindField{
+			Codec: codec,
+			Name:  f.Name(),
+			Param: param,
+			Kind:  bt.Kind(),
+			Type:  types.TypeString(f.Type(), types.RelativeTo(f.Pkg())),
+		})
+	}
+	return fields, tagged
+}
+
+func (b *bindField) Parse() string {
+	switch b.Kind {
+	case types.String:
+		return fmt.Sprintf("v.%s = %s(s)", b.Name, b.Type)
+	case types.Bool:
+		if b.Codec == "form" {
+			// the checked checkbox without the value attribute is sent as "on"
+			return fmt.Sprintf("if s == \"on\" {\n\tv.%s = true\n} else {\n%s\n}", b.Name, b.parse("strconv.ParseBool(s)"))
+		}
+		return b.parse("strconv.ParseBool(s)")
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		return b.parse(fmt.Sprintf("strconv.ParseInt(s, 10, %d)", bitSize(b.Kind)))
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		return b.parse(fmt.Sprintf("strconv.ParseUint(s, 10, %d)", bitSize(b.Kind)))
+	case types.Float32, types.Float64:
+		return b.parse(fmt.Sprintf("strconv.ParseFloat(s, %d)", bitSize(b.Kind)))
+	}
+	return ""
+}
+
+func (b *bindField) parse(expr string) string {
+	ret := fmt.Sprintf(`fmt.Errorf("failed to parse %s: %%w", err)`, b.Param)
+	if b.Codec == "form" {
+		// reported with 400 Bad Request as the reflection-based binding of the form codec
+		ret = fmt.Sprintf(`tanukirpc.NewFormFieldError(%q, err)`, b.Param)
+	}
+	return fmt.Sprintf(`p, err := %s
+if err != nil {
+	return %s
+}
+v.%s = %s(p)`, expr, ret, b.Name, b.Type)
+}
+
+func bitSize(k types.BasicKind) int {
+	switch k {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	}
+	return 64
+}
+
+type bindTemplateArgs struct {
+	Package string
+	Binders []*bindTemplateBinder
+}
+
+func (b *bindTemplateArgs) UseStrconv() bool {
+	for _, binder := range b.Binders {
+		for _, f := range binder.Fields {
+			if f.Kind != types.String {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *bindTemplateArgs) UseChi() bool {
+	for _, binder := range b.Binders {
+		if binder.Codec == "urlparam" {
+			return true
+		}
+	}
+	return false
+}
+
+type bindTemplateBinder struct {
+	Codec    string
+	TypeName string
+	Fields   []*bindField
+}
+
+func (b *bindTemplateBinder) FuncName() string {
+	codec := map[string]string{"urlparam": "URLParam", "query": "Query", "form": "Form"}[b.Codec]
+	return "bind" + codec + strings.ToUpper(b.TypeName[:1]) + b.TypeName[1:]
+}
+
+var bindTemplate = template.Must(template.New("genbind").Parse(`// Code generated by genbind. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"fmt"
+	"net/http"
+{{- if .UseStrconv }}
+	"strconv"
+{{- end }}
+{{ if .UseChi }}
+	"github.com/go-chi/chi/v5"
+{{- end }}
+	"github.com/mackee/tanukirpc"
+)
+
+func init() {
+{{- range .Binders }}
+	tanukirpc.RegisterBinder("{{ .Codec }}", {{ .FuncName }})
+{{- end }}
+}
+{{ range .Binders }}
+func {{ .FuncName }}(r *http.Request, v *{{ .TypeName }}) error {
+{{- if eq .Codec "urlparam" }}
+{{- range .Fields }}
+{{- if eq .Param "*" }}
+	// the catch-all segment of the wildcard route can be empty
+	if s := chi.URLParam(r, "*"); s != "" {
+		{{ .Parse }}
+	}
+{{- else }}
+	if s := chi.URLParam(r, "{{ .Param }}"); s == "" {
+		return fmt.Errorf("url param {{ .Param }} is required at field {{ .Name }}")
+	} else {
+		{{ .Parse }}
+	}
+{{- end }}
+{{- end }}
+{{- else }}
+{{- if eq .Codec "query" }}
+	values := r.URL.Query()
+{{- else }}
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("failed to parse form: %w", err)
+	}
+	values := r.PostForm
+{{- end }}
+{{- range .Fields }}
+	if s := values.Get("{{ .Param }}"); s != "" {
+		{{ .Parse }}
+	}
+{{- end }}
+{{- end }}
+	return nil
+}
+{{ end }}`))
@@ -1,11 +1,15 @@
 package genclient
 
 import (
+	"fmt"
+	"go/token"
 	"go/types"
 	"net/http"
 	"path"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gostaticanalysis/analysisutil"
 	"github.com/mackee/tanukirpc"
@@ -32,6 +36,32 @@ var Analyzer = &analysis.Analyzer{
 
 type AnalyzerResult struct {
 	RoutePaths []RoutePath
+	// DebugEndpointsPrefixes is the prefixes that are passed to tanukirpc.WithDebugEndpoints as string literals.
+	DebugEndpointsPrefixes []string
+	// MountPaths is the paths that are passed to Router.Mount.
+	MountPaths []MountPath
+	// FallbackHandlers is the handlers that are registered by Router.NotFound and Router.MethodNotAllowed.
+	FallbackHandlers []FallbackHandler
+	// Diagnostics is the problems of the route registrations. It is set only by Load,
+	// because the analysis driver reports them by itself.
+	Diagnostics []Diagnostic
+
+	docs *docComments
+}
+
+// Diagnostic is the problem of the route registration that is found by the analyzer.
+type Diagnostic struct {
+	Position token.Position
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position, d.Message)
+}
+
+// FieldDoc returns the doc comment of the struct field declared in the analyzed package.
+func (r *AnalyzerResult) FieldDoc(field *types.Var) string {
+	return r.docs.fieldDoc(field)
 }
 
 var routerMethodNames = map[string]string{
@@ -86,18 +116,71 @@ func run(pass *analysis.Pass) (any, error) {
 			}
 			for _, arg := range routerArgs {
 				is := ap.analyzeRouterValue(pass, arg)
-				for _, rp := range is.listRoute() {
+				routes := is.listRoute()
+				for _, rp := range routes {
 					rps = append(rps, rp)
 				}
+				lintRoutes(pass, routes)
 			}
 		}
 	}
 
+	mps := make([]MountPath, 0, len(ap.mounts))
+	for _, mp := range ap.mounts {
+		mps = append(mps, mp)
+	}
+	fhs := make([]FallbackHandler, 0, len(ap.fallbacks))
+	for _, fh := range ap.fallbacks {
+		fhs = append(fhs, fh)
+	}
+
 	return &AnalyzerResult{
-		RoutePaths: rps,
+		RoutePaths:             rps,
+		DebugEndpointsPrefixes: debugEndpointsPrefixes(pass, ssaresult),
+		MountPaths:             mps,
+		FallbackHandlers:       fhs,
+		docs:                   ap.docs,
 	}, nil
 }
 
+func debugEndpointsPrefixes(pass *analysis.Pass, ssaresult *buildssa.SSA) []string {
+	withDebugEndpointsObj := analysisutil.LookupFromImports(
+		pass.Pkg.Imports(),
+		"github.com/mackee/tanukirpc",
+		"WithDebugEndpoints",
+	)
+	if withDebugEndpointsObj == nil {
+		return nil
+	}
+
+	prefixes := make([]string, 0)
+	for _, f := range ssaresult.SrcFuncs {
+		for _, b := range f.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				callee := call.Call.StaticCallee()
+				if callee == nil || callee.Object() != withDebugEndpointsObj {
+					continue
+				}
+				// the prefix that is not a string literal cannot be proxied by tanukiup
+				c, ok := call.Call.Args[0].(*ssa.Const)
+				if !ok || c.Value == nil {
+					continue
+				}
+				prefix, err := strconv.Unquote(c.Value.ExactString())
+				if err != nil {
+					continue
+				}
+				prefixes = append(prefixes, strings.TrimSuffix(prefix, "/"))
+			}
+		}
+	}
+	return prefixes
+}
+
 func (g *tanukiTypeInfo) analyzeRouterValue(pass *analysis.Pass, v ssa.Value) *instrs {
 	routerInstrs := make([]ssa.Instruction, 0)
 	if call, ok := v.(*ssa.Call); ok {
@@ -123,6 +206,23 @@ type tanukiTypeInfo struct {
 	routerMethods           map[*types.Func]string
 	routeMethod             *types.Func
 	routeWithTransformerObj types.Object
+	registerServiceObj      types.Object
+	newProxyHandlerObj      types.Object
+	deprecatedObj           types.Object
+	authorizeObj            types.Object
+	annotate                *annotateTypeInfo
+	contextObj              types.Object
+	batch                   *batchTypeInfo
+	tenant                  *tenantTypeInfo
+	mountMethod             *types.Func
+	resourceMethod          *types.Func
+	notFoundMethod          *types.Func
+	methodNotAllowedMethod  *types.Func
+	docs                    *docComments
+
+	// mounts and fallbacks are collected while analyzing the routers
+	mounts    []*mountPath
+	fallbacks []*fallbackHandler
 }
 
 func newTanukiTypeInfo(pass *analysis.Pass) *tanukiTypeInfo {
@@ -153,6 +253,19 @@ func newTanukiTypeInfo(pass *analysis.Pass) *tanukiTypeInfo {
 		routerMethods:           routerMethods,
 		routeMethod:             routeMethod,
 		routeWithTransformerObj: routeWithTransformerObj,
+		registerServiceObj:      analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "RegisterService"),
+		newProxyHandlerObj:      analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "NewProxyHandler"),
+		deprecatedObj:           analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "Deprecated"),
+		authorizeObj:            analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "Authorize"),
+		annotate:                newAnnotateTypeInfo(pass),
+		contextObj:              analysisutil.LookupFromImports(pass.Pkg.Imports(), "github.com/mackee/tanukirpc", "Context"),
+		batch:                   newBatchTypeInfo(pass),
+		tenant:                  newTenantTypeInfo(pass),
+		mountMethod:             analysisutil.MethodOf(routerObj.Type(), "Mount"),
+		resourceMethod:          analysisutil.MethodOf(routerObj.Type(), "Resource"),
+		notFoundMethod:          analysisutil.MethodOf(routerObj.Type(), "NotFound"),
+		methodNotAllowedMethod:  analysisutil.MethodOf(routerObj.Type(), "MethodNotAllowed"),
+		docs:                    newDocComments(pass),
 	}
 }
 
@@ -206,8 +319,31 @@ func (i *instrs) analyze(pass *analysis.Pass) {
 				i.children = append(i.children, rnp)
 				continue
 			}
-			if rp := i.tryPathMethod(pass, instr); rp != nil {
-				i.children = append(i.children, rp)
+			if np, ok := i.tryMount(pass, instr); ok {
+				if np != nil {
+					i.children = append(i.children, np)
+				}
+				continue
+			}
+			if i.tryFallback(pass, instr) {
+				continue
+			}
+			if rps, ok := i.tryRegisterService(pass, instr); ok {
+				for _, rp := range rps {
+					i.children = append(i.children, rp)
+				}
+				continue
+			}
+			if rps, ok := i.tryResource(pass, instr); ok {
+				for _, rp := range rps {
+					i.children = append(i.children, rp)
+				}
+				continue
+			}
+			if rps := i.tryPathMethod(pass, instr); rps != nil {
+				for _, rp := range rps {
+					i.children = append(i.children, rp)
+				}
 				continue
 			}
 			if callee := instr.Call.StaticCallee(); callee != nil {
@@ -247,6 +383,8 @@ type routeNestedPath struct {
 	parent   analyzedPath
 	path     string
 	children *instrs
+	// tenant is the source of the tenant ID of the transformer of RouteWithTransformer
+	tenant *TenantSource
 }
 
 func (i *instrs) tryRoute(pass *analysis.Pass, instr ssa.Instruction) *routeNestedPath {
@@ -367,6 +505,7 @@ func (i *instrs) tryRouteWithTransformer(pass *analysis.Pass, instr ssa.Instruct
 		parent:   i,
 		path:     c.Value.ExactString(),
 		children: children,
+		tenant:   i.agg.tenant.source(args[1]),
 	}
 	children.parent = np
 	children.analyze(pass)
@@ -388,12 +527,20 @@ type routePath struct {
 	path    string
 	method  string
 	handler *handlerType
+	// pos is the position of the registration
+	pos token.Pos
+	// resource is set if the route is registered by Router.Resource
+	resource *resourceAction
 }
 
 type RoutePath interface {
 	Path() string
 	Method() string
 	Handler() HandlerType
+	// Tenant returns the source of the tenant ID if the route is scoped by tenant.NewTransformer, otherwise nil.
+	Tenant() *TenantSource
+	// Resource returns the action of the resource if the route is registered by Router.Resource, otherwise nil.
+	Resource() *ResourceAction
 }
 
 func (r *routePath) Path() string {
@@ -408,16 +555,39 @@ func (r *routePath) Handler() HandlerType {
 	return r.handler
 }
 
+func (r *routePath) Tenant() *TenantSource {
+	return tenantOf(r.parent)
+}
+
+func (r *routePath) Resource() *ResourceAction {
+	if r.resource == nil {
+		return nil
+	}
+	return &ResourceAction{
+		Path:   r.parent.joinPath(r.resource.path),
+		Action: r.resource.action,
+	}
+}
+
 type handlerType struct {
-	req types.Type
-	res types.Type
-	reg types.Type
+	req         types.Type
+	res         types.Type
+	reg         types.Type
+	doc         string
+	deprecated  bool
+	annotations *tanukirpc.RouteAnnotations
 }
 
 type HandlerType interface {
 	Req() types.Type
 	Res() types.Type
 	Reg() types.Type
+	// Doc returns the doc comment of the handler function.
+	Doc() string
+	// Deprecated reports whether the handler is wrapped by Deprecated or annotated by WithRouteDeprecation.
+	Deprecated() bool
+	// Annotations returns the annotations of the options of Annotate that are the string literals, or nil if the handler is not annotated.
+	Annotations() *tanukirpc.RouteAnnotations
 }
 
 func (h *handlerType) Req() types.Type {
@@ -432,7 +602,19 @@ func (h *handlerType) Reg() types.Type {
 	return h.reg
 }
 
-func (i *instrs) tryPathMethod(pass *analysis.Pass, instr ssa.Instruction) *routePath {
+func (h *handlerType) Doc() string {
+	return h.doc
+}
+
+func (h *handlerType) Deprecated() bool {
+	return h.deprecated
+}
+
+func (h *handlerType) Annotations() *tanukirpc.RouteAnnotations {
+	return h.annotations
+}
+
+func (i *instrs) tryPathMethod(pass *analysis.Pass, instr ssa.Instruction) []*routePath {
 	call, ok := instr.(*ssa.Call)
 	if !ok {
 		return nil
@@ -457,46 +639,121 @@ func (i *instrs) tryPathMethod(pass *analysis.Pass, instr ssa.Instruction) *rout
 		return nil
 	}
 	pathArg := args[1]
+	handlerArg := args[2]
 	c, ok := pathArg.(*ssa.Const)
 	if !ok {
-		pass.Reportf(pathArg.Pos(), "invalid path argument. must be string literal.")
-		return nil
+		// table-driven registration such as router.Get(r.path, r.handler) in the range loop
+		entries := routeTable(pathArg, handlerArg)
+		if len(entries) == 0 {
+			pass.Reportf(call.Pos(), "unresolvable route registration: the path must be a string literal or a field of a route table literal. the route is not included in the generated code.")
+			return nil
+		}
+		rps := make([]*routePath, 0, len(entries))
+		for _, entry := range entries {
+			if rp := i.newRoutePath(pass, httpMethod, entry.path, entry.handler, call.Pos()); rp != nil {
+				rps = append(rps, rp)
+			}
+		}
+		return rps
 	}
 	if c.Value == nil {
 		pass.Reportf(pathArg.Pos(), "invalid path argument. must be string literal.")
 		return nil
 	}
-	pathStr := c.Value.ExactString()
+	if rp := i.newRoutePath(pass, httpMethod, c, handlerArg, call.Pos()); rp != nil {
+		return []*routePath{rp}
+	}
+	return nil
+}
 
-	handlerArg := args[2]
-	ht := i.handlerType(pass, handlerArg)
-	if ht == nil {
-		return nil
+// tryRegisterService returns the routes of the methods of the service that is registered by RegisterService.
+func (i *instrs) tryRegisterService(pass *analysis.Pass, call *ssa.Call) ([]*routePath, bool) {
+	if i.agg.registerServiceObj == nil {
+		return nil, false
+	}
+	callee := call.Call.StaticCallee()
+	if callee == nil || callee.Object() != i.agg.registerServiceObj {
+		return nil, false
+	}
+	svcType := call.Call.Signature().Params().At(1).Type()
+	named := svcType
+	if pt, ok := named.(*types.Pointer); ok {
+		named = pt.Elem()
 	}
+	nt, ok := named.(*types.Named)
+	if !ok {
+		pass.Reportf(call.Pos(), "invalid service argument. the service type must be named.")
+		return nil, true
+	}
+	name := nt.Obj().Name()
 
-	return &routePath{
-		parent:  i,
-		path:    pathStr,
-		method:  httpMethod,
-		handler: ht,
+	mset := types.NewMethodSet(svcType)
+	rps := make([]*routePath, 0, mset.Len())
+	for j := 0; j < mset.Len(); j++ {
+		fn, ok := mset.At(j).Obj().(*types.Func)
+		if !ok || !fn.Exported() {
+			continue
+		}
+		ht := i.agg.serviceMethodType(mset.At(j).Type().(*types.Signature))
+		if ht == nil {
+			continue
+		}
+		ht.doc = i.agg.docs.funcDoc(fn)
+		rps = append(rps, &routePath{
+			parent:  i,
+			path:    strconv.Quote("/" + name + "/" + fn.Name()),
+			method:  http.MethodPost,
+			handler: ht,
+			pos:     call.Pos(),
+		})
 	}
+	return rps, true
 }
 
-func (i *instrs) handlerType(pass *analysis.Pass, v ssa.Value) *handlerType {
-	call, ok := v.(*ssa.Call)
-	if !ok {
+// serviceMethodType returns the handler type of the method that has the signature func(Context[Reg], Req) (Res, error).
+// It returns nil if the method is not the one.
+func (g *tanukiTypeInfo) serviceMethodType(sig *types.Signature) *handlerType {
+	if sig.Params().Len() != 2 || sig.Results().Len() != 2 {
 		return nil
 	}
-	callee := call.Call.StaticCallee()
-	if callee == nil {
+	if !types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type()) {
 		return nil
 	}
-	fn, ok := callee.Object().(*types.Func)
-	if !ok {
+	ctxType, ok := sig.Params().At(0).Type().(*types.Named)
+	if !ok || g.contextObj == nil || ctxType.Origin().Obj() != g.contextObj {
 		return nil
 	}
-	if fn != i.agg.newHandlerObj {
-		pass.Reportf(call.Pos(), "invalid handler argument. must be NewHandler function call.")
+	return &handlerType{
+		req: sig.Params().At(1).Type(),
+		res: sig.Results().At(0).Type(),
+		reg: ctxType.TypeArgs().At(0),
+	}
+}
+
+func (i *instrs) newRoutePath(pass *analysis.Pass, httpMethod string, path *ssa.Const, handler ssa.Value, pos token.Pos) *routePath {
+	ht := i.handlerType(pass, handler, pos)
+	if ht == nil {
+		return nil
+	}
+
+	return &routePath{
+		parent:  i,
+		path:    path.Value.ExactString(),
+		method:  httpMethod,
+		handler: ht,
+		pos:     pos,
+	}
+}
+
+func (i *instrs) handlerType(pass *analysis.Pass, v ssa.Value, pos token.Pos) *handlerType {
+	seen := make(map[ssa.Value]struct{})
+	call := i.agg.newHandlerCall(v, seen)
+	if call == nil {
+		if i.agg.isProxyHandler(v) {
+			// the proxied routes have no request and response types, so they are not included in the generated code
+			return nil
+		}
+		pass.Reportf(pos, "invalid handler argument. must be NewHandler function call.")
 		return nil
 	}
 
@@ -511,11 +768,184 @@ func (i *instrs) handlerType(pass *analysis.Pass, v ssa.Value) *handlerType {
 	req := tps.At(0)
 	res := tps.At(1)
 	reg := tps.At(2)
+	if i.agg.batch.isNewBatchHandler(call.Call.StaticCallee()) {
+		var err error
+		if req, res, err = i.agg.batch.wrap(req, res); err != nil {
+			pass.Reportf(call.Pos(), "invalid batch handler: %s", err)
+			return nil
+		}
+	}
+	annotations, deprecated := i.agg.annotate.annotations(seen)
 	return &handlerType{
-		req: req,
-		res: res,
-		reg: reg,
+		req:         req,
+		res:         res,
+		reg:         reg,
+		doc:         i.agg.docs.funcDoc(handlerFunc(call.Call.Args[0])),
+		deprecated:  deprecated || i.agg.throughDeprecated(seen),
+		annotations: annotations,
+	}
+}
+
+// isProxyHandler reports whether the handler is created by NewProxyHandler.
+func (g *tanukiTypeInfo) isProxyHandler(v ssa.Value) bool {
+	if g.newProxyHandlerObj == nil {
+		return false
+	}
+	if mi, ok := v.(*ssa.MakeInterface); ok {
+		v = mi.X
+	}
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return false
+	}
+	callee := call.Call.StaticCallee()
+	return callee != nil && callee.Object() == g.newProxyHandlerObj
+}
+
+// throughDeprecated reports whether the values that newHandlerCall followed have the call of Deprecated.
+func (g *tanukiTypeInfo) throughDeprecated(seen map[ssa.Value]struct{}) bool {
+	if g.deprecatedObj == nil {
+		return false
+	}
+	for v := range seen {
+		if call, ok := v.(*ssa.Call); ok {
+			if callee := call.Call.StaticCallee(); callee != nil && callee.Object() == g.deprecatedObj {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newHandlerCall follows the def-use chain of the handler to the NewHandler call.
+// The handler can be stored in a variable, a captured variable or a package variable,
+// and can be returned from a constructor function.
+func (g *tanukiTypeInfo) newHandlerCall(v ssa.Value, seen map[ssa.Value]struct{}) *ssa.Call {
+	if _, ok := seen[v]; ok {
+		return nil
+	}
+	seen[v] = struct{}{}
+
+	switch v := v.(type) {
+	case *ssa.Call:
+		callee := v.Call.StaticCallee()
+		if callee == nil {
+			return nil
+		}
+		if callee.Object() == g.newHandlerObj || g.batch.isNewBatchHandler(callee) {
+			return v
+		}
+		if g.deprecatedObj != nil && callee.Object() == g.deprecatedObj {
+			return g.newHandlerCall(v.Call.Args[0], seen)
+		}
+		if g.authorizeObj != nil && callee.Object() == g.authorizeObj {
+			return g.newHandlerCall(v.Call.Args[0], seen)
+		}
+		if g.annotate.isAnnotate(callee) {
+			return g.newHandlerCall(v.Call.Args[0], seen)
+		}
+		// constructor function that returns the handler
+		for _, ret := range analysisutil.Returns(callee) {
+			if len(ret.Results) == 0 {
+				continue
+			}
+			if call := g.newHandlerCall(ret.Results[0], seen); call != nil {
+				return call
+			}
+		}
+	case *ssa.ChangeType:
+		return g.newHandlerCall(v.X, seen)
+	case *ssa.MakeInterface:
+		return g.newHandlerCall(v.X, seen)
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if call := g.newHandlerCall(edge, seen); call != nil {
+				return call
+			}
+		}
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return nil
+		}
+		return g.storedNewHandlerCall(v.X, seen)
+	case *ssa.FreeVar:
+		if binding := freeVarBinding(v); binding != nil {
+			return g.newHandlerCall(binding, seen)
+		}
+	}
+	return nil
+}
+
+// storedNewHandlerCall returns the NewHandler call that is stored to the address.
+func (g *tanukiTypeInfo) storedNewHandlerCall(addr ssa.Value, seen map[ssa.Value]struct{}) *ssa.Call {
+	var instrs []ssa.Instruction
+	switch addr := addr.(type) {
+	case *ssa.Global:
+		// the referrers of the package variables are not tracked
+		for _, fn := range addr.Pkg.Members {
+			f, ok := fn.(*ssa.Function)
+			if !ok {
+				continue
+			}
+			for _, b := range f.Blocks {
+				instrs = append(instrs, b.Instrs...)
+			}
+		}
+	case *ssa.FreeVar:
+		if binding := freeVarBinding(addr); binding != nil {
+			return g.storedNewHandlerCall(binding, seen)
+		}
+	default:
+		if referrers := addr.Referrers(); referrers != nil {
+			instrs = *referrers
+		}
+	}
+	for _, instr := range instrs {
+		store, ok := instr.(*ssa.Store)
+		if !ok || store.Addr != addr {
+			continue
+		}
+		if call := g.newHandlerCall(store.Val, seen); call != nil {
+			return call
+		}
+	}
+	return nil
+}
+
+// freeVarBinding returns the value that is captured by the closure as the free variable.
+func freeVarBinding(fv *ssa.FreeVar) ssa.Value {
+	fn := fv.Parent()
+	idx := slices.Index(fn.FreeVars, fv)
+	if idx < 0 || fn.Parent() == nil {
+		return nil
+	}
+	for _, b := range fn.Parent().Blocks {
+		for _, instr := range b.Instrs {
+			mc, ok := instr.(*ssa.MakeClosure)
+			if !ok || mc.Fn != fn {
+				continue
+			}
+			return mc.Bindings[idx]
+		}
+	}
+	return nil
+}
+
+// handlerFunc returns the function that is passed to NewHandler.
+func handlerFunc(v ssa.Value) *types.Func {
+	if ct, ok := v.(*ssa.ChangeType); ok {
+		v = ct.X
+	}
+	// method value such as s.getTask
+	if mc, ok := v.(*ssa.MakeClosure); ok {
+		v = mc.Fn
+	}
+	f, ok := v.(*ssa.Function)
+	if !ok {
+		return nil
 	}
+	fn, _ := f.Object().(*types.Func)
+	return fn
 }
 
 func (r *routePath) joinPath(p string) string {
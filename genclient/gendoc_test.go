@@ -1,13 +1,741 @@
 package genclient_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/mackee/tanukirpc"
 	"github.com/mackee/tanukirpc/genclient"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
 func TestGenerateTypeScriptClient(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	buf, ok := results[0].Result.(*bytes.Buffer)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", results[0].Result)
+	}
+	generated := buf.String()
+	for _, expect := range []string{
+		`| { ok: false; status: number; error: errorResponse["error"] };`,
+		`export const newResultClient = (baseURL = "", myFetch: myFetcher = fetch): resultClient => {`,
+		`export const newThrowingClient = (baseURL = "", myFetch: myFetcher = fetch): throwingClient => {`,
+		`status: "todo" | "doing" | "done";`,
+		`priority?: 0 | 1;`,
+		`note?: string;`,
+		`statuses: ("todo" | "doing" | "done")[];`,
+		`labels?: Record<string, string>;`,
+		`extra?: unknown;`,
+		`timeout?: number;`,
+		`tags?: string[];`,
+		`limits: Record<string, number[]>;`,
+		`matrix: ("todo" | "doing" | "done")[][];`,
+		`updated_at?: string;`,
+		"export interface TaskRequest {\n  title: string;\n",
+		"  /** getTask returns the task. */\n  \"GET /tasks/{id}\": {",
+		"  /** deleteTask deletes the task. */\n  \"DELETE /tasks/{id}\": {",
+		"  \"GET /projects/{project_id}/tasks\": {\n    Query: ListTasksQuery;\n",
+		"  \"GET /health/ready\": {\n    Query: undefined;\n    Request: undefined;\n    Response: HealthResponse;\n  };",
+		"  /** deleteTask deletes the task. */\n  \"POST /tasks/{id}/unarchive\": {",
+		"  /** Labels is attached to the settings. */\n  labels?: Record<string, string>;",
+		"  /** in nanoseconds */\n  timeout?: number;",
+		"  /**\n   * taskHandler creates a task.\n   * The statuses of the response contain the status of the request.\n   */\n  \"POST /tasks\": {",
+		"  \"GET /ping/nested\": {\n    Query: undefined;\n    Request: undefined;\n    Response: PingCounterResponse;\n  };",
+		"    \"/files/*\": (args: {\"*\": string}) => `/files/${args[\"*\"]}`,",
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestAnalyzeDebugEndpoints(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.Analyzer, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	result, ok := results[0].Result.(*genclient.AnalyzerResult)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", results[0].Result)
+	}
+	if !slices.Equal(result.DebugEndpointsPrefixes, []string{"/debug"}) {
+		t.Errorf("unexpected debug endpoints prefixes: %v", result.DebugEndpointsPrefixes)
+	}
+}
+
+func TestAnalyzeMountsAndFallbacks(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.Analyzer, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	result := results[0].Result.(*genclient.AnalyzerResult)
+
+	mounts := make([]string, 0, len(result.MountPaths))
+	for _, mp := range result.MountPaths {
+		mounts = append(mounts, fmt.Sprintf("%s router=%t", mp.Path(), mp.IsRouter()))
+	}
+	if expect := []string{"/admin router=true", "/static router=false"}; !slices.Equal(mounts, expect) {
+		t.Errorf("unexpected mount paths: %v", mounts)
+	}
+	if !slices.ContainsFunc(result.RoutePaths, func(rp genclient.RoutePath) bool {
+		return rp.Method() == "GET" && rp.Path() == "/admin/stats"
+	}) {
+		t.Errorf("the route of the mounted router is not found")
+	}
+	if len(result.FallbackHandlers) != 1 {
+		t.Fatalf("unexpected number of fallback handlers: %d", len(result.FallbackHandlers))
+	}
+	fh := result.FallbackHandlers[0]
+	if fh.Kind() != genclient.FallbackKindNotFound || fh.Path() != "/" || fh.Handler() == nil {
+		t.Errorf("unexpected fallback handler: kind=%s path=%s", fh.Kind(), fh.Path())
+	}
+}
+
+func TestAnalyzeUnresolvedRoutes(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, genclient.Analyzer, "./unresolvedtest")
+}
+
+func TestAnalyzeRegisterService(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.Analyzer, "./servicetest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	result := results[0].Result.(*genclient.AnalyzerResult)
+	if len(result.RoutePaths) != 7 {
+		t.Fatalf("unexpected number of routes: %d", len(result.RoutePaths))
+	}
+	rp := result.RoutePaths[0]
+	if rp.Method() != "POST" || rp.Path() != "/rpc/TaskService/AddTask" {
+		t.Errorf("unexpected route: %s %s", rp.Method(), rp.Path())
+	}
+	if rp.Handler().Deprecated() {
+		t.Errorf("unexpected deprecated route: %s %s", rp.Method(), rp.Path())
+	}
+	if req := rp.Handler().Req().String(); req != "*github.com/mackee/tanukirpc/testdata/servicetest.AddTaskRequest" {
+		t.Errorf("unexpected request type: %s", req)
+	}
+	if doc := rp.Handler().Doc(); doc != "AddTask adds a task." {
+		t.Errorf("unexpected doc: %q", doc)
+	}
+
+	deprecated := result.RoutePaths[1]
+	if deprecated.Path() != "/v1/tasks" || !deprecated.Handler().Deprecated() {
+		t.Errorf("the route is not deprecated: %s %s", deprecated.Method(), deprecated.Path())
+	}
+	if rp.Tenant() != nil {
+		t.Errorf("unexpected tenant of the route: %s %s", rp.Method(), rp.Path())
+	}
+	scoped := result.RoutePaths[2]
+	if tenant := scoped.Tenant(); scoped.Path() != "/tenant/tasks" || tenant == nil || tenant.Kind != genclient.TenantSourceKindHeader || tenant.Name != "X-Tenant-ID" {
+		t.Errorf("unexpected tenant of the route: %s %s %+v", scoped.Method(), scoped.Path(), tenant)
+	}
+	if rp.Resource() != nil {
+		t.Errorf("unexpected resource of the route: %s %s", rp.Method(), rp.Path())
+	}
+	for idx, expect := range []struct {
+		method string
+		path   string
+		action string
+	}{
+		{"GET", "/projects", "Index"},
+		{"GET", "/projects/{project_id}", "Show"},
+	} {
+		rp := result.RoutePaths[4+idx]
+		res := rp.Resource()
+		if rp.Method() != expect.method || rp.Path() != expect.path || res == nil || res.Path != "/projects" || res.Action != expect.action || res.Name() != "projects" {
+			t.Errorf("unexpected resource route: %s %s %+v", rp.Method(), rp.Path(), res)
+		}
+	}
+	if rp.Handler().Annotations() != nil {
+		t.Errorf("unexpected annotations of the route: %s %s", rp.Method(), rp.Path())
+	}
+	annotated := result.RoutePaths[6]
+	expectAnnotations := &tanukirpc.RouteAnnotations{Name: "listTasks", Tags: []string{"tasks", "v2"}, Scopes: []string{"tasks:read"}}
+	if annotated.Path() != "/v2/tasks" || !reflect.DeepEqual(annotated.Handler().Annotations(), expectAnnotations) || !annotated.Handler().Deprecated() {
+		t.Errorf("unexpected annotations of the route: %s %s %+v", annotated.Method(), annotated.Path(), annotated.Handler().Annotations())
+	}
+	ts, err := genclient.GenerateTypeScript(result, &genclient.TypeScriptOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(ts), "export const apiOperations = {\n  \"tasks\": {\n    \"listTasks\": \"GET /v2/tasks\",\n  },\n  \"v2\": {\n    \"listTasks\": \"GET /v2/tasks\",\n  },\n} as const;") {
+		t.Errorf("the TypeScript client does not group the annotated routes:\n%s", ts)
+	}
+	if !strings.Contains(string(ts), "  /** @deprecated */\n  \"GET /v1/tasks\"") {
+		t.Errorf("the TypeScript client does not mark the route as deprecated:\n%s", ts)
+	}
+	for _, expect := range []string{
+		"requests: AddTaskRequest[];",
+		"results: BatchResult[];",
+		"error?: BatchError",
+	} {
+		if !strings.Contains(string(ts), expect) {
+			t.Errorf("the TypeScript client does not have the batch types %q:\n%s", expect, ts)
+		}
+	}
+	goc, err := genclient.GenerateGoClient(result, &genclient.GoClientOptions{Package: "serviceclient"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(goc), "func (c *Client) PostTasksBatch(ctx context.Context, req *PostTasksBatchRequest) (*PostTasksBatchResponse, error) {") {
+		t.Errorf("the Go client does not have the batch method:\n%s", goc)
+	}
+	oas, err := genclient.GenerateOpenAPI(result, &genclient.OpenAPIOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(oas), `"name": "X-Tenant-ID"`) {
+		t.Errorf("the OpenAPI document does not have the tenant header:\n%s", oas)
+	}
+	if !strings.Contains(string(oas), `"deprecated": true`) {
+		t.Errorf("the OpenAPI document does not mark the route as deprecated:\n%s", oas)
+	}
+	if !strings.Contains(string(oas), "\"tags\": [\n          \"projects\"\n        ]") {
+		t.Errorf("the OpenAPI document does not tag the resource routes:\n%s", oas)
+	}
+	for _, expect := range []string{
+		`"operationId": "listTasks"`,
+		"\"tags\": [\n          \"tasks\",\n          \"v2\"\n        ]",
+		"\"x-required-scopes\": [\n          \"tasks:read\"\n        ]",
+	} {
+		if !strings.Contains(string(oas), expect) {
+			t.Errorf("the OpenAPI document does not have the annotations %q:\n%s", expect, oas)
+		}
+	}
+}
+
+func TestAnalyzeLint(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, genclient.Analyzer, "./linttest")
+}
+
+func TestAnalyzeLintBodyTags(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, genclient.Analyzer, "./bodytagtest")
+}
+
+func TestGenerateTypeScriptClientCached(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	out := filepath.Join(t.TempDir(), "client.ts")
+	if err := genclient.TypeScriptClientGenerator.Flags.Set("out", out); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("out", "")
+	})
+
+	testdata := analysistest.TestData()
+	ctx := context.Background()
+	if err := genclient.GenerateTypeScriptClientCached(ctx, testdata, []string{"./gendoctest"}); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(testdata, "gendoctest", "client.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, expected) {
+		t.Errorf("generated code is not equal to client.ts:\n%s", generated)
+	}
+
+	// the second run is served from the cache
+	if err := os.Remove(out); err != nil {
+		t.Fatal(err)
+	}
+	if err := genclient.GenerateTypeScriptClientCached(ctx, testdata, []string{"./gendoctest"}); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cached, generated) {
+		t.Errorf("cached code is not equal to the generated code:\n%s", cached)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", result.Diagnostics)
+	}
+	generated, err := genclient.GenerateTypeScript(result, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(testdata, "gendoctest", "client.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, expected) {
+		t.Errorf("generated code is not equal to client.ts:\n%s", generated)
+	}
+
+	result, err = genclient.Load(context.Background(), filepath.Join(testdata, "unresolvedtest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Diagnostics) == 0 {
+		t.Error("diagnostics of the unresolved routes are not returned")
+	}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := genclient.GenerateOpenAPI(result, &genclient.OpenAPIOptions{Title: "gendoctest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Info  map[string]string                    `json:"info"`
+		Paths map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(generated, &doc); err != nil {
+		t.Fatalf("generated document is not JSON: %v\n%s", err, generated)
+	}
+	if doc.Info["title"] != "gendoctest" {
+		t.Errorf("unexpected title: %v", doc.Info)
+	}
+	for path, method := range map[string]string{
+		"/echo":                        "post",
+		"/nested/{epoch}":              "get",
+		"/tasks/{id}":                  "delete",
+		"/admin/stats":                 "get",
+		"/settings":                    "put",
+		"/projects/{project_id}/tasks": "get",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("%s %s is not found in the paths", method, path)
+		}
+	}
+	for _, expect := range []string{
+		`"operationId": "getNestedByEpoch"`,
+		`"pattern": "^[0-9]+$"`,
+		`"enum": [
+                        "todo",
+                        "doing",
+                        "done"
+                      ]`,
+		`"maxLength": 100`,
+		`"description": "Labels is attached to the settings."`,
+		`"$ref": "#/components/schemas/Error"`,
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated document does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateGoClient(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := genclient.GenerateGoClient(result, &genclient.GoClientOptions{Package: "gendocclient"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"package gendocclient",
+		"type GetNestedByEpochRequest struct {\n\tEpoch int64 `urlparam:\"epoch\" json:\"-\"`\n}",
+		"type GetProjectsByProjectIdTasksRequest struct {\n\tStatus    string `query:\"status\" json:\"-\"`\n\tProjectId string `urlparam:\"project_id\" json:\"-\"`\n}",
+		"\tUpdatedAt *time.Time       `json:\"updated_at\"`",
+		"func (c *Client) PostTasks(ctx context.Context, req *PostTasksRequest) (*PostTasksResponse, error) {",
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client.go", generated, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("gendocclient", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %v\n%s", err, generated)
+	}
+}
+
+func TestGenerateKotlin(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := genclient.GenerateKotlin(result, &genclient.KotlinOptions{Package: "com.example.api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"package com.example.api",
+		"data class TaskRequest(\n    @SerialName(\"title\") val title: String,",
+		"    @SerialName(\"updated_at\") val updatedAt: String? = null,",
+		"    @SerialName(\"limits\") val limits: Map<String, List<Double>>,",
+		"    suspend fun getTasksById(id: String): TaskRequest {",
+		"    suspend fun deleteTasksById(id: String): Unit {",
+		"client.request(baseUrl + \"/projects/${projectId.encodeURLPathPart()}/tasks\") {",
+		"            parameter(\"status\", query.status)",
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateGraphQL(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := genclient.GenerateGraphQLSchema(result, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"scalar JSON",
+		"input TaskRequestInput {\n  title: String!",
+		"type TaskRequest {\n  title: String!",
+		"  updated_at: String\n",
+		"type Query {",
+		"  getTasksById(id: String!): TaskRequest!",
+		"type Mutation {",
+		"  deleteTasksById(id: String!): Boolean!",
+		"  getProjectsByProjectIdTasks(project_id: String!, query: ListTasksQueryInput): TaskResponse!",
+	} {
+		if !strings.Contains(string(schema), expect) {
+			t.Errorf("generated schema does not contain %q:\n%s", expect, schema)
+		}
+	}
+
+	generated, err := genclient.GenerateGraphQLResolvers(result, &genclient.GraphQLOptions{Package: "gendocgraphql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"package gendocgraphql",
+		`"Query.getTasksById": func(ctx context.Context, args map[string]any) (any, error) {`,
+		`return serve(ctx, h, "GET", "/tasks/"+pathArg(args, "id"), args, false)`,
+		`return serve(ctx, h, "DELETE", "/tasks/"+pathArg(args, "id"), args, true)`,
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "resolvers.go", generated, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("gendocgraphql", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %v\n%s", err, generated)
+	}
+}
+
+func TestGenerateSwift(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := genclient.GenerateSwift(result, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"public struct TaskRequest: Codable {\n    public var title: String",
+		"    public var updatedAt: String?\n",
+		"        case updatedAt = \"updated_at\"",
+		"    public var limits: [String: [Double]]",
+		"    public func getTasksById(id: String) async throws -> TaskRequest {",
+		"    public func deleteTasksById(id: String) async throws {",
+		"path: \"/projects/\\(escape(projectId))/tasks\"",
+		"queryItems.append(URLQueryItem(name: \"status\", value: \"\\(query.status)\"))",
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	testdata := analysistest.TestData()
+	result, err := genclient.Load(context.Background(), filepath.Join(testdata, "gendoctest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := genclient.GenerateMarkdown(result, &genclient.DocsOptions{Title: "Task API"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"# Task API\n",
+		"| POST | [`/tasks`](#postTasks) | taskHandler creates a task. |",
+		"### <a id=\"getNestedByEpoch\"></a>GET /nested/{epoch}",
+		"| `epoch` | `string` | yes | `pattern=^[0-9]+$` |  |",
+		"| `status` | `\"todo\" \\| \"doing\" \\| \"done\"` | yes |  |  |",
+		"- Request body: [`TaskRequest`](#TaskRequest)",
+		"| `title` | `string` | yes | `min=1, max=100` |  |",
+		"| `labels` | `Record<string, string>` | yes |  | Labels is attached to the settings. |",
+		"| `updated_at` | `string` | no |  |  |",
+		"## <a id=\"errors\"></a>Errors",
+	} {
+		if !strings.Contains(string(generated), expect) {
+			t.Errorf("generated document does not contain %q:\n%s", expect, generated)
+		}
+	}
+
+	html, err := genclient.GenerateHTML(result, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"<title>API Reference</title>",
+		"<section id=\"TaskRequest\">",
+		"<tr><td><code>title</code></td><td><code>string</code></td><td>yes</td><td><code>min=1, max=100</code></td><td></td></tr>",
+		"<li>Response: <a href=\"#TaskResponse\"><code>TaskResponse</code></a></li>",
+	} {
+		if !strings.Contains(string(html), expect) {
+			t.Errorf("generated document does not contain %q:\n%s", expect, html)
+		}
+	}
+}
+
+func TestGenerateBind(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.BindGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	buf, ok := results[0].Result.(*bytes.Buffer)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", results[0].Result)
+	}
+	generated := buf.String()
+	for _, expect := range []string{
+		`tanukirpc.RegisterBinder("urlparam", bindURLParamEpochRequest)`,
+		`func bindURLParamEpochRequest(r *http.Request, v *epochRequest) error {`,
+		`strconv.ParseInt(s, 10, 64)`,
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+// TestGenerateBindInternal generates the binders of internal/bindtest, whose tests compare them with the reflection-based binding.
+func TestGenerateBindInternal(t *testing.T) {
+	dir, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(dir, "internal", "bindtest", "bind_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the package is loaded with the variant of the tests as well
+	compared := 0
+	for _, result := range analysistest.Run(t, dir, genclient.BindGenerator, "./internal/bindtest") {
+		if result.Pass.Pkg.Name() != "bindtest" {
+			continue
+		}
+		compared++
+		generated := result.Result.(*bytes.Buffer).Bytes()
+		if !bytes.Equal(generated, expected) {
+			t.Errorf("generated code is not equal to bind_gen.go. run go generate ./internal/bindtest:\n%s", generated)
+		}
+	}
+	if compared == 0 {
+		t.Fatal("package bindtest is not analyzed")
+	}
+}
+
+func TestGenerateContractTest(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.ContractTestGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	buf, ok := results[0].Result.(*bytes.Buffer)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", results[0].Result)
+	}
+	generated := buf.String()
+	for _, expect := range []string{
+		`func TestContract(t *testing.T) {`,
+		`{name: "POST_echo", contract: "POST /echo\nquery: undefined\nrequest: {\n  message: string;\n}\nresponse: {\n  message?: string;\n}\n"},`,
+		`{name: "GET_nested_epoch_0-9", contract: "GET /nested/{epoch:[0-9]+}\n`,
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClientFactory(t *testing.T) {
+	if err := genclient.TypeScriptClientGenerator.Flags.Set("client-factory", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("client-factory", "false")
+	})
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	generated := results[0].Result.(*bytes.Buffer).String()
+	for _, expect := range []string{
+		`export type clientOptions = {`,
+		`export const createClient = (options: clientOptions = {}): client => newClient(options.baseURL, fetcherWithOptions(options));`,
+		`export const createThrowingClient = (options: clientOptions = {}): throwingClient =>`,
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClientEnvelope(t *testing.T) {
+	if err := genclient.TypeScriptClientGenerator.Flags.Set("envelope", "data"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("envelope", "")
+	})
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	generated := results[0].Result.(*bytes.Buffer).String()
+	for _, expect := range []string{
+		`const unwrapEnvelope = <T,>(body: unknown): T => (body as { "data": T })["data"];`,
+		`const data = unwrapEnvelope<apiSchemaCollection[PM]["Response"]>(await response.json());`,
+		`return unwrapEnvelope<apiSchemaCollection[PM]["Response"]>(await response.json());`,
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClientKeyCase(t *testing.T) {
+	if err := genclient.TypeScriptClientGenerator.Flags.Set("key-case", "camel"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("key-case", "")
+	})
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	generated := results[0].Result.(*bytes.Buffer).String()
+	if !strings.Contains(generated, `updatedAt?: string;`) {
+		t.Errorf("generated code does not contain the camelCase key:\n%s", generated)
+	}
+	if strings.Contains(generated, `updated_at`) {
+		t.Errorf("generated code contains the snake_case key:\n%s", generated)
+	}
+}
+
+func TestGenerateTypeScriptClientZod(t *testing.T) {
+	if err := genclient.TypeScriptClientGenerator.Flags.Set("emit-zod", "true"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("emit-zod", "false")
+	})
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	generated := results[0].Result.(*bytes.Buffer).String()
+	for _, expect := range []string{
+		`import { z } from "zod";`,
+		`status: z.union([z.literal("todo"), z.literal("doing"), z.literal("done")]),`,
+		`title: z.string().min(1).max(100),`,
+		`limits: z.record(z.string(), z.array(z.number())),`,
+		`extra: z.unknown().optional(),`,
+		"  \"POST /echo\": {\n    Query: z.undefined(),\n    Request: z.object({\n      message: z.string(),\n    }),\n    Response: z.object({\n      message: z.string().nullish(),\n    }),\n  },",
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClientMarshalerWhitelist(t *testing.T) {
+	whitelist := filepath.Join(t.TempDir(), "whitelist.txt")
+	content := "# custom marshalers\ntime.Time number\n"
+	if err := os.WriteFile(whitelist, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"marshaler-whitelist": whitelist, "duration": "string"} {
+		if err := genclient.TypeScriptClientGenerator.Flags.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		genclient.TypeScriptClientGenerator.Flags.Set("marshaler-whitelist", "")
+		genclient.TypeScriptClientGenerator.Flags.Set("duration", "number")
+	})
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, genclient.TypeScriptClientGenerator, "./gendoctest")
+	if len(results) != 1 {
+		t.Fatalf("unexpected number of results: %d", len(results))
+	}
+	generated := results[0].Result.(*bytes.Buffer).String()
+	for _, expect := range []string{
+		`timeout?: string;`,
+		`updated_at?: number;`,
+	} {
+		if !strings.Contains(generated, expect) {
+			t.Errorf("generated code does not contain %q:\n%s", expect, generated)
+		}
+	}
 }
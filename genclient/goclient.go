@@ -0,0 +1,420 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// GoClientOptions is the options of GenerateGoClient.
+type GoClientOptions struct {
+	// Package is the package name of the generated code. The default is "client".
+	Package string
+}
+
+// GenerateGoClient generates the Go client of the routes in the result.
+// The request and response types are declared again in the generated package,
+// because the types of the server are often declared in the functions or the main package.
+// The named types of the standard library like time.Time are referenced as they are. opts may be nil.
+func GenerateGoClient(result *AnalyzerResult, opts *GoClientOptions) ([]byte, error) {
+	pkg := "client"
+	if opts != nil && opts.Package != "" {
+		pkg = opts.Package
+	}
+	args := &goClientTemplateArgs{
+		Package: pkg,
+		imports: map[string]struct{}{},
+	}
+	seen := make(map[string]struct{})
+	for _, rp := range result.RoutePaths {
+		path, params := openAPIPath(rp.Path())
+		name := openAPIOperationID(rp.Method(), path)
+		name = strings.ToUpper(name[:1]) + name[1:]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		h := rp.Handler()
+		req, err := args.structBody(h.Req(), params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate request type of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		res, err := args.structBody(h.Res(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate response type of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		args.Routes = append(args.Routes, &goClientTemplateRoute{
+			Name:     name,
+			Method:   rp.Method(),
+			Path:     rp.Path(),
+			Doc:      h.Doc(),
+			Request:  req,
+			Response: res,
+		})
+	}
+	for path := range args.imports {
+		if _, ok := goClientBaseImports[path]; !ok {
+			args.Imports = append(args.Imports, path)
+		}
+	}
+	sort.Strings(args.Imports)
+
+	buf := &bytes.Buffer{}
+	if err := goClientTemplate.Execute(buf, args); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+type goClientTemplateArgs struct {
+	Package string
+	Imports []string
+	Routes  []*goClientTemplateRoute
+	imports map[string]struct{}
+}
+
+type goClientTemplateRoute struct {
+	Name     string
+	Method   string
+	Path     string
+	Doc      string
+	Request  string
+	Response string
+}
+
+func (r *goClientTemplateRoute) Comment() string {
+	comment := fmt.Sprintf("// %s calls %s %s.", r.Name, r.Method, r.Path)
+	if r.Doc == "" {
+		return comment
+	}
+	return comment + "\n//\n// " + strings.Join(strings.Split(r.Doc, "\n"), "\n// ")
+}
+
+// structBody returns the struct type of the top-level request or response.
+// The path params that are not bound by the urlparam tags are added as the string fields.
+func (a *goClientTemplateArgs) structBody(t types.Type, params []map[string]any) (string, error) {
+	if pt, ok := t.(*types.Pointer); ok {
+		t = pt.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return a.typeExpr(t)
+	}
+	fields, err := a.fields(st)
+	if err != nil {
+		return "", err
+	}
+	bound := make(map[string]struct{})
+	for i := 0; i < st.NumFields(); i++ {
+		name, _, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("urlparam"), ",")
+		bound[name] = struct{}{}
+	}
+	for _, p := range params {
+		name := p["name"].(string)
+		if _, ok := bound[name]; ok {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s string `urlparam:%q json:\"-\"`", goClientFieldName(name), name))
+	}
+	if len(fields) == 0 {
+		return "struct{}", nil
+	}
+	return "struct {\n" + strings.Join(fields, "\n") + "\n}", nil
+}
+
+// goClientFieldName converts the param name like project_id to the exported field name like ProjectId.
+func goClientFieldName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+func (a *goClientTemplateArgs) fields(st *types.Struct) ([]string, error) {
+	fields := make([]string, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		if f.Embedded() && tag.Get("json") == "" {
+			// the fields of the embedded struct are promoted in JSON
+			ft := f.Type()
+			if pt, ok := ft.(*types.Pointer); ok {
+				ft = pt.Elem()
+			}
+			if est, ok := ft.Underlying().(*types.Struct); ok {
+				efs, err := a.fields(est)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, efs...)
+				continue
+			}
+		}
+		if !f.Exported() {
+			continue
+		}
+		typ, err := a.typeExpr(f.Type())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert field %s: %w", f.Name(), err)
+		}
+		tagValue := string(tag)
+		if _, ok := tag.Lookup("json"); !ok {
+			// the fields without the json tag are not the body
+			tagValue = strings.TrimSpace(tagValue + ` json:"-"`)
+		}
+		fields = append(fields, fmt.Sprintf("%s %s %s", f.Name(), typ, "`"+tagValue+"`"))
+	}
+	return fields, nil
+}
+
+// typeExpr returns the Go type expression in the generated package.
+func (a *goClientTemplateArgs) typeExpr(t types.Type) (string, error) {
+	if at, ok := t.(*types.Alias); ok {
+		if expr, ok := a.stdlibName(at.Obj()); ok {
+			return expr, nil
+		}
+		t = types.Unalias(at)
+	}
+	switch tt := t.(type) {
+	case *types.Named:
+		if expr, ok := a.stdlibName(tt.Obj()); ok {
+			return expr, nil
+		}
+		// the types of the server are replaced with the underlying types
+		return a.typeExpr(tt.Underlying())
+	case *types.Basic:
+		return tt.Name(), nil
+	case *types.Pointer:
+		elem, err := a.typeExpr(tt.Elem())
+		return "*" + elem, err
+	case *types.Slice:
+		elem, err := a.typeExpr(tt.Elem())
+		return "[]" + elem, err
+	case *types.Array:
+		elem, err := a.typeExpr(tt.Elem())
+		return "[" + strconv.FormatInt(tt.Len(), 10) + "]" + elem, err
+	case *types.Map:
+		key, err := a.typeExpr(tt.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := a.typeExpr(tt.Elem())
+		return "map[" + key + "]" + elem, err
+	case *types.Struct:
+		fields, err := a.fields(tt)
+		if err != nil {
+			return "", err
+		}
+		if len(fields) == 0 {
+			return "struct{}", nil
+		}
+		return "struct {\n" + strings.Join(fields, "\n") + "\n}", nil
+	case *types.Interface:
+		return "any", nil
+	}
+	return "", fmt.Errorf("unsupported type: %s", t.String())
+}
+
+// stdlibName returns the qualified name of the type in the standard library and records the import.
+func (a *goClientTemplateArgs) stdlibName(obj *types.TypeName) (string, bool) {
+	pkg := obj.Pkg()
+	if pkg == nil {
+		// the predeclared types like error
+		return obj.Name(), true
+	}
+	first, _, _ := strings.Cut(pkg.Path(), "/")
+	if strings.Contains(first, ".") || pkg.Path() == "main" {
+		return "", false
+	}
+	// encoding/json/jsontext is available only with GOEXPERIMENT=jsonv2
+	if strings.HasPrefix(pkg.Path(), "encoding/json/") {
+		return "", false
+	}
+	a.imports[pkg.Path()] = struct{}{}
+	return pkg.Name() + "." + obj.Name(), true
+}
+
+// goClientBaseImports is the packages that are imported by the template.
+var goClientBaseImports = map[string]struct{}{
+	"bytes":         {},
+	"context":       {},
+	"encoding/json": {},
+	"fmt":           {},
+	"io":            {},
+	"net/http":      {},
+	"net/url":       {},
+	"reflect":       {},
+	"strings":       {},
+}
+
+var goClientTemplate = template.Must(template.New("goclient").Parse(`// Code generated by tanukigen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+{{- range .Imports }}
+	{{ printf "%q" . }}
+{{- end }}
+)
+
+// Client calls the API.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns the client that sends the requests to baseURL with http.DefaultClient.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Error is the error response of the API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+{{ range .Routes }}
+type {{ .Name }}Request {{ .Request }}
+
+type {{ .Name }}Response {{ .Response }}
+
+{{ .Comment }}
+func (c *Client) {{ .Name }}(ctx context.Context, req *{{ .Name }}Request) (*{{ .Name }}Response, error) {
+	var res {{ .Name }}Response
+	if err := c.do(ctx, {{ printf "%q" .Method }}, {{ printf "%q" .Path }}, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+{{ end }}
+func (c *Client) do(ctx context.Context, method, pattern string, req, res any) error {
+	rv := reflect.Indirect(reflect.ValueOf(req))
+	path, err := fillPathParams(pattern, rv)
+	if err != nil {
+		return err
+	}
+	if query := taggedValues(rv, "query").Encode(); query != "" {
+		path += "?" + query
+	}
+
+	var body io.Reader
+	if method != http.MethodGet && method != http.MethodHead {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to encode the request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create the request: %w", err)
+	}
+	hreq.Header.Set("Accept", "application/json")
+	if body != nil {
+		hreq.Header.Set("Content-Type", "application/json")
+	}
+	hres, err := c.HTTPClient.Do(hreq)
+	if err != nil {
+		return fmt.Errorf("failed to send the request: %w", err)
+	}
+	defer hres.Body.Close()
+
+	if hres.StatusCode < 200 || hres.StatusCode >= 300 {
+		var em struct {
+			Error struct {
+				Message string ` + "`json:\"message\"`" + `
+			} ` + "`json:\"error\"`" + `
+		}
+		_ = json.NewDecoder(hres.Body).Decode(&em)
+		return &Error{StatusCode: hres.StatusCode, Message: em.Error.Message}
+	}
+	if err := json.NewDecoder(hres.Body).Decode(res); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
+
+func fillPathParams(pattern string, rv reflect.Value) (string, error) {
+	params := taggedValues(rv, "urlparam")
+	var sb strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			// the catch-all segment keeps the slashes of the remaining path
+			if rest, ok := strings.CutSuffix(pattern, "*"); ok && params.Has("*") {
+				sb.WriteString(rest)
+				sb.WriteString(escapeWildcard(params.Get("*")))
+				break
+			}
+			sb.WriteString(pattern)
+			break
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid pattern %q", pattern)
+		}
+		end += start
+		name, _, _ := strings.Cut(pattern[start+1:end], ":")
+		if !params.Has(name) {
+			return "", fmt.Errorf("url param %q is not found in the request", name)
+		}
+		sb.WriteString(pattern[:start])
+		sb.WriteString(url.PathEscape(params.Get(name)))
+		pattern = pattern[end+1:]
+	}
+	return sb.String(), nil
+}
+
+func escapeWildcard(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+func taggedValues(rv reflect.Value, tag string) url.Values {
+	values := url.Values{}
+	if rv.Kind() != reflect.Struct {
+		return values
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fv := reflect.Indirect(rv.Field(i))
+		if !fv.IsValid() || (tag != "urlparam" && fv.IsZero()) {
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+	return values
+}
+`))
@@ -0,0 +1,454 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// GraphQLOptions is the options of GenerateGraphQLSchema and GenerateGraphQLResolvers.
+type GraphQLOptions struct {
+	// Package is the package of the generated resolvers. The default is "graphql".
+	Package string
+	// Marshalers and DurationType are the same as TypeScriptOptions.
+	Marshalers   map[string]string
+	DurationType string
+}
+
+// GenerateGraphQLSchema generates the GraphQL schema of the routes in the result. It is experimental.
+// The GET routes are the fields of Query, and the others are the fields of Mutation. The fields take the path params,
+// the query string as the query argument and the request body as the input argument.
+// The fields of the types are named after the names in JSON, so the responses of the handlers are resolved as they are.
+func GenerateGraphQLSchema(result *AnalyzerResult, opts *GraphQLOptions) ([]byte, error) {
+	s, err := newGraphQLSchema(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := graphQLSchemaTemplate.Execute(buf, s); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateGraphQLResolvers generates the Go resolvers of the fields in the schema generated by GenerateGraphQLSchema.
+// It is experimental. The resolvers call the handlers in process with the arguments of the fields, so they are used
+// with the GraphQL libraries that take the resolvers of map[string]any like github.com/graphql-go/graphql.
+func GenerateGraphQLResolvers(result *AnalyzerResult, opts *GraphQLOptions) ([]byte, error) {
+	s, err := newGraphQLSchema(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	pkg := "graphql"
+	if opts != nil && opts.Package != "" {
+		pkg = opts.Package
+	}
+	buf := &bytes.Buffer{}
+	if err := graphQLResolversTemplate.Execute(buf, map[string]any{
+		"Package":   pkg,
+		"Queries":   s.Queries,
+		"Mutations": s.Mutations,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+type graphQLType struct {
+	Name   string
+	Fields []*mobileField
+}
+
+// graphQLOperation is the field of Query or Mutation that calls the route.
+type graphQLOperation struct {
+	*mobileRoute
+	// Void reports whether the route has no response. It is resolved as true.
+	Void bool
+}
+
+type graphQLSchema struct {
+	Scalars   []string
+	Inputs    []*graphQLType
+	Types     []*graphQLType
+	Queries   []*graphQLOperation
+	Mutations []*graphQLOperation
+}
+
+// graphQLIdentPattern matches the names of the types in the type references like "[Task!]".
+var graphQLIdentPattern = regexp.MustCompile(`[_A-Za-z][_0-9A-Za-z]*`)
+
+func newGraphQLSchema(result *AnalyzerResult, opts *GraphQLOptions) (*graphQLSchema, error) {
+	if opts == nil {
+		opts = &GraphQLOptions{}
+	}
+	m, err := newMobileTypeMapper(result, graphQLLanguage{}, opts.Marshalers, opts.DurationType)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := m.routes(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// the classes are declared both as the input types and the object types when they are used by both
+	inputs := make(map[string]struct{})
+	outputs := make(map[string]struct{})
+	var reach func(typ string, seen map[string]struct{})
+	reach = func(typ string, seen map[string]struct{}) {
+		for _, name := range graphQLIdentPattern.FindAllString(typ, -1) {
+			class := m.classOf(name)
+			if class == nil {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			for _, f := range class.Fields {
+				reach(f.Type, seen)
+			}
+		}
+	}
+	inputType := func(typ string) string {
+		return graphQLIdentPattern.ReplaceAllStringFunc(typ, func(name string) string {
+			if m.classOf(name) != nil {
+				return name + "Input"
+			}
+			return name
+		})
+	}
+
+	s := &graphQLSchema{}
+	used := make(map[string]struct{})
+	for _, route := range routes {
+		reach(route.Query, inputs)
+		reach(route.Request, inputs)
+		reach(route.Response, outputs)
+		r := *route
+		r.PathParams = make([]*mobileField, 0, len(route.PathParams))
+		for _, p := range route.PathParams {
+			pp := *p
+			pp.Prop = graphQLName(p.Name)
+			r.PathParams = append(r.PathParams, &pp)
+		}
+		if r.Query != "" {
+			r.Query = inputType(r.Query)
+		}
+		if r.Request != "" {
+			r.Request = inputType(r.Request)
+		}
+		op := &graphQLOperation{mobileRoute: &r, Void: r.Response == ""}
+		if op.Void {
+			r.Response = "Boolean"
+		}
+		used[r.Response] = struct{}{}
+		if r.Method == "GET" {
+			s.Queries = append(s.Queries, op)
+		} else {
+			s.Mutations = append(s.Mutations, op)
+		}
+	}
+
+	for _, class := range m.classes {
+		if _, ok := inputs[class.Name]; ok {
+			t := &graphQLType{Name: class.Name + "Input"}
+			for _, f := range class.Fields {
+				ff := *f
+				ff.Type = inputType(f.Type)
+				t.Fields = append(t.Fields, &ff)
+				used[ff.Type] = struct{}{}
+			}
+			s.Inputs = append(s.Inputs, t)
+		}
+		if _, ok := outputs[class.Name]; ok {
+			s.Types = append(s.Types, &graphQLType{Name: class.Name, Fields: class.Fields})
+			for _, f := range class.Fields {
+				used[f.Type] = struct{}{}
+			}
+		}
+	}
+	for typ := range used {
+		if containsIdent(typ, graphQLJSONScalar) {
+			s.Scalars = []string{graphQLJSONScalar}
+			break
+		}
+	}
+	return s, nil
+}
+
+func containsIdent(typ, ident string) bool {
+	for _, name := range graphQLIdentPattern.FindAllString(typ, -1) {
+		if name == ident {
+			return true
+		}
+	}
+	return false
+}
+
+// graphQLJSONScalar is the custom scalar of the values that GraphQL has no type for, like the records.
+const graphQLJSONScalar = "JSON"
+
+type graphQLLanguage struct{}
+
+func (graphQLLanguage) literal(t typeScriptClientGeneratorLiteralType) string {
+	switch t {
+	case "string":
+		return "String"
+	case "number":
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	}
+	return graphQLJSONScalar
+}
+
+func (graphQLLanguage) enum(t typeScriptClientGeneratorEnumType) string {
+	if len(t) > 0 && strings.HasPrefix(t[0], `"`) {
+		return "String"
+	}
+	return "Int"
+}
+
+func (graphQLLanguage) array(elem string) string {
+	return "[" + elem + "!]"
+}
+
+func (graphQLLanguage) record(elem string) string {
+	return graphQLJSONScalar
+}
+
+func (graphQLLanguage) identifier(name string) string {
+	return name
+}
+
+// graphQLName replaces the characters that are not allowed in the names of GraphQL like "-" with "_".
+func graphQLName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || '0' <= b[0] && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+// graphQLArgs returns the arguments of the field of the route like "(id: String!, input: TaskInput!)".
+func graphQLArgs(r *graphQLOperation) string {
+	args := make([]string, 0, len(r.PathParams)+2)
+	for _, p := range r.PathParams {
+		args = append(args, p.Prop+": "+p.Type+"!")
+	}
+	if r.Query != "" {
+		args = append(args, "query: "+r.Query)
+	}
+	if r.Request != "" {
+		args = append(args, "input: "+r.Request+"!")
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+// graphQLGoPath returns the Go expression of the path like "/tasks/" + pathArg(args, "id").
+func graphQLGoPath(r *graphQLOperation) string {
+	path := strconv.Quote(r.Path)
+	for _, p := range r.PathParams {
+		path = strings.Replace(path, "{"+p.Name+"}", `" + pathArg(args, `+strconv.Quote(p.Prop)+`) + "`, 1)
+	}
+	return strings.TrimSuffix(path, ` + ""`)
+}
+
+var graphQLSchemaTemplate = template.Must(template.New("graphql").Funcs(template.FuncMap{
+	"doc": func(indent, doc string) string {
+		return mobileDoc(indent, `"""`, "", `"""`, strings.ReplaceAll(doc, `"""`, `\"""`))
+	},
+	"args": graphQLArgs,
+	"name": graphQLName,
+}).Parse(`# Code generated by tanukigen. DO NOT EDIT.
+{{ range .Scalars }}
+scalar {{ . }}
+{{ end }}
+{{- range .Inputs }}
+input {{ .Name }} {
+{{- range .Fields }}
+{{ doc "  " .Doc }}  {{ name .Name }}: {{ .Type }}{{ if not .Optional }}!{{ end }}
+{{- end }}
+}
+{{ end }}
+{{- range .Types }}
+type {{ .Name }} {
+{{- range .Fields }}
+{{ doc "  " .Doc }}  {{ name .Name }}: {{ .Type }}{{ if not .Optional }}!{{ end }}
+{{- end }}
+}
+{{ end }}
+type Query {
+{{- range .Queries }}
+{{ doc "  " .Doc }}  {{ .Name }}{{ args . }}: {{ .Response }}!
+{{- else }}
+  """
+  Query has no fields because no GET routes are registered.
+  """
+  _empty: Boolean
+{{- end }}
+}
+{{- if .Mutations }}
+
+type Mutation {
+{{- range .Mutations }}
+{{ doc "  " .Doc }}  {{ .Name }}{{ args . }}: {{ .Response }}!
+{{- end }}
+}
+{{- end }}
+`))
+
+var graphQLResolversTemplate = template.Must(template.New("graphqlresolvers").Funcs(template.FuncMap{
+	"path":  graphQLGoPath,
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by tanukigen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Resolver resolves the field of Query or Mutation. The arguments and the result are the values decoded from JSON.
+type Resolver func(ctx context.Context, args map[string]any) (any, error)
+
+// Error is the error response of the handler.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type headerContextKey struct{}
+
+// ContextWithHeader returns the context that has the header of the request to the handler, like the Authorization header of the GraphQL request.
+func ContextWithHeader(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, headerContextKey{}, header)
+}
+
+// Resolvers returns the resolvers of the fields that call h in process. The keys are like "Query.getTasks" and "Mutation.postTasks".
+func Resolvers(h http.Handler) map[string]Resolver {
+	return map[string]Resolver{
+{{- range .Queries }}
+		{{ quote (print "Query." .Name) }}: func(ctx context.Context, args map[string]any) (any, error) {
+			return serve(ctx, h, {{ quote .Method }}, {{ path . }}, args, {{ .Void }})
+		},
+{{- end }}
+{{- range .Mutations }}
+		{{ quote (print "Mutation." .Name) }}: func(ctx context.Context, args map[string]any) (any, error) {
+			return serve(ctx, h, {{ quote .Method }}, {{ path . }}, args, {{ .Void }})
+		},
+{{- end }}
+	}
+}
+
+func pathArg(args map[string]any, name string) string {
+	return url.PathEscape(fmt.Sprint(args[name]))
+}
+
+func serve(ctx context.Context, h http.Handler, method, path string, args map[string]any, void bool) (any, error) {
+	if query, ok := args["query"].(map[string]any); ok && len(query) > 0 {
+		values := url.Values{}
+		for k, v := range query {
+			switch v := v.(type) {
+			case nil:
+			case []any:
+				for _, e := range v {
+					values.Add(k, fmt.Sprint(e))
+				}
+			default:
+				values.Set(k, fmt.Sprint(v))
+			}
+		}
+		path += "?" + values.Encode()
+	}
+	var body []byte
+	if input, ok := args["input"]; ok {
+		b, err := json.Marshal(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input: %w", err)
+		}
+		body = b
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if header, ok := ctx.Value(headerContextKey{}).(http.Header); ok {
+		req.Header = header.Clone()
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+	h.ServeHTTP(w, req)
+	if w.status >= http.StatusBadRequest {
+		var res struct {
+			Error struct {
+				Message string ` + "`json:\"message\"`" + `
+			} ` + "`json:\"error\"`" + `
+		}
+		message := http.StatusText(w.status)
+		if err := json.Unmarshal(w.body.Bytes(), &res); err == nil && res.Error.Message != "" {
+			message = res.Error.Message
+		}
+		return nil, &Error{Status: w.status, Message: message}
+	}
+	if void {
+		return true, nil
+	}
+	var v any
+	if err := json.Unmarshal(w.body.Bytes(), &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return v, nil
+}
+
+type responseRecorder struct {
+	header http.Header
+	status int
+	wrote  bool
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.body.Write(p)
+}
+`))
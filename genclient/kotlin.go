@@ -0,0 +1,180 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// KotlinOptions is the options of GenerateKotlin.
+type KotlinOptions struct {
+	// Package is the package of the generated code. The default is "tanukirpc.client".
+	Package string
+	// Marshalers and DurationType are the same as TypeScriptOptions.
+	Marshalers   map[string]string
+	DurationType string
+}
+
+// GenerateKotlin generates the Kotlin client of the routes in the result.
+// The client uses Ktor and kotlinx.serialization.
+func GenerateKotlin(result *AnalyzerResult, opts *KotlinOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &KotlinOptions{}
+	}
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "tanukirpc.client"
+	}
+	m, err := newMobileTypeMapper(result, kotlinLanguage{}, opts.Marshalers, opts.DurationType)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := m.routes(result)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := kotlinTemplate.Execute(buf, map[string]any{
+		"Package": pkg,
+		"Classes": m.classes,
+		"Routes":  routes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type kotlinLanguage struct{}
+
+func (kotlinLanguage) literal(t typeScriptClientGeneratorLiteralType) string {
+	switch t {
+	case "string":
+		return "String"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	}
+	return "JsonElement"
+}
+
+func (kotlinLanguage) enum(t typeScriptClientGeneratorEnumType) string {
+	if len(t) > 0 && strings.HasPrefix(t[0], `"`) {
+		return "String"
+	}
+	return "Long"
+}
+
+func (kotlinLanguage) array(elem string) string {
+	return "List<" + elem + ">"
+}
+
+func (kotlinLanguage) record(elem string) string {
+	return "Map<String, " + elem + ">"
+}
+
+var kotlinKeywords = map[string]struct{}{
+	"as": {}, "break": {}, "class": {}, "continue": {}, "do": {}, "else": {}, "false": {}, "for": {}, "fun": {},
+	"if": {}, "in": {}, "interface": {}, "is": {}, "null": {}, "object": {}, "package": {}, "return": {}, "super": {},
+	"this": {}, "throw": {}, "true": {}, "try": {}, "typealias": {}, "typeof": {}, "val": {}, "var": {}, "when": {}, "while": {},
+}
+
+func (kotlinLanguage) identifier(name string) string {
+	if _, ok := kotlinKeywords[name]; ok {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// kotlinPath returns the string template of the path like "/tasks/${id.encodeURLPathPart()}".
+func kotlinPath(r *mobileRoute) string {
+	path := strings.ReplaceAll(strconv.Quote(r.Path), "$", `\$`)
+	for _, p := range r.PathParams {
+		path = strings.Replace(path, "{"+p.Name+"}", "${"+p.Prop+".encodeURLPathPart()}", 1)
+	}
+	return path
+}
+
+func kotlinMethod(method string) string {
+	return "HttpMethod." + strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+}
+
+var kotlinTemplate = template.Must(template.New("kotlin").Funcs(template.FuncMap{
+	"doc": func(indent, doc string) string {
+		return mobileDoc(indent, "/**", " * ", " */", strings.ReplaceAll(doc, "*/", "*\\/"))
+	},
+	"path":   kotlinPath,
+	"params": mobileParams,
+	"method": kotlinMethod,
+	"quote":  strconv.Quote,
+}).Parse(`// Code generated by tanukigen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import io.ktor.client.HttpClient
+import io.ktor.client.call.body
+import io.ktor.client.request.accept
+import io.ktor.client.request.parameter
+import io.ktor.client.request.request
+import io.ktor.client.request.setBody
+import io.ktor.http.ContentType
+import io.ktor.http.HttpMethod
+import io.ktor.http.contentType
+import io.ktor.http.encodeURLPathPart
+import io.ktor.http.isSuccess
+import kotlinx.serialization.SerialName
+import kotlinx.serialization.Serializable
+import kotlinx.serialization.json.JsonElement
+
+@Serializable
+data class ErrorBody(
+    @SerialName("message") val message: String,
+)
+
+@Serializable
+data class ErrorResponse(
+    @SerialName("error") val error: ErrorBody,
+)
+
+/** ApiException is thrown on the non-2xx responses. */
+class ApiException(val status: Int, message: String) : Exception(message)
+{{ range .Classes }}
+@Serializable
+data class {{ .Name }}(
+{{- range .Fields }}
+{{ doc "    " .Doc }}    @SerialName({{ quote .Name }}) val {{ .Prop }}: {{ .Type }}{{ if .Optional }}? = null{{ end }},
+{{- end }}
+)
+{{ end }}
+/** ApiClient calls the API with the HttpClient that installs ContentNegotiation with kotlinx.serialization. */
+class ApiClient(private val client: HttpClient, private val baseUrl: String) {
+{{- range .Routes }}
+{{ doc "    " .Doc }}    suspend fun {{ .Name }}({{ params . }}): {{ if .Response }}{{ .Response }}{{ else }}Unit{{ end }} {
+        val response = client.request(baseUrl + {{ path . }}) {
+            method = {{ method .Method }}
+            accept(ContentType.Application.Json)
+{{- range .QueryFields }}
+{{- if .Optional }}
+            query.{{ .Prop }}?.let { parameter({{ quote .Name }}, it) }
+{{- else }}
+            parameter({{ quote .Name }}, query.{{ .Prop }})
+{{- end }}
+{{- end }}
+{{- if .Request }}
+            contentType(ContentType.Application.Json)
+            setBody(body)
+{{- end }}
+        }
+        if (!response.status.isSuccess()) {
+            val message = runCatching { response.body<ErrorResponse>().error.message }.getOrDefault(response.status.description)
+            throw ApiException(response.status.value, message)
+        }
+{{- if .Response }}
+        return response.body()
+{{- end }}
+    }
+{{ end -}}
+}
+`))
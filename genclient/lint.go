@@ -0,0 +1,164 @@
+package genclient
+
+import (
+	"go/types"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// lintRoutes reports the registrations that panic or are silently shadowed at runtime.
+func lintRoutes(pass *analysis.Pass, routes []*routePath) {
+	registered := make(map[string]*routePath, len(routes))
+	for _, rp := range routes {
+		p := rp.Path()
+		params, ok := lintPattern(pass, rp, p)
+		if !ok {
+			continue
+		}
+
+		key := rp.Method() + " " + normalizePattern(p)
+		if prev, ok := registered[key]; ok {
+			if prev.Path() == p {
+				pass.Reportf(rp.pos, "duplicate route: %s %s is already registered", rp.Method(), p)
+			} else {
+				pass.Reportf(rp.pos, "conflicting route: %s %s overlaps %s %s", rp.Method(), p, prev.Method(), prev.Path())
+			}
+		} else {
+			registered[key] = rp
+		}
+
+		req := rp.Handler().Req()
+		for _, name := range tagNames(req, "urlparam") {
+			if _, ok := params[name]; !ok {
+				pass.Reportf(rp.pos, "urlparam %q is not in the route pattern %s", name, p)
+			}
+		}
+		if tenant := rp.Tenant(); tenant != nil && tenant.Kind == TenantSourceKindPath {
+			if _, ok := params[tenant.Name]; !ok {
+				pass.Reportf(rp.pos, "tenant path param %q is not in the route pattern %s", tenant.Name, p)
+			}
+		}
+		lintBodyTags(pass, rp, req)
+	}
+}
+
+// lintBodyTags reports the request body tags that are not bound by the method of the route.
+func lintBodyTags(pass *analysis.Pass, rp *routePath, req types.Type) {
+	switch rp.Method() {
+	case http.MethodGet, http.MethodHead:
+		if names := tagNames(req, "json"); len(names) > 0 {
+			pass.Reportf(rp.pos, "json tags of the request are declared on %s %s, but the request body of %s is not sent by the most clients", rp.Method(), rp.Path(), rp.Method())
+		}
+	}
+	switch rp.Method() {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		// http.Request.ParseForm reads the body only for POST, PUT and PATCH
+		if names := tagNames(req, "form"); len(names) > 0 {
+			pass.Reportf(rp.pos, "form tags of the request are declared on %s %s, but the form body is parsed only for POST, PUT and PATCH", rp.Method(), rp.Path())
+		}
+	}
+}
+
+// lintPattern reports the patterns that chi panics on, and returns the names of the params.
+func lintPattern(pass *analysis.Pass, rp *routePath, pattern string) (map[string]struct{}, bool) {
+	params := make(map[string]struct{})
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		names, static := patternParams(seg)
+		if strings.Contains(static, "*") && (i != len(segments)-1 || !strings.HasSuffix(static, "*")) {
+			pass.Reportf(rp.pos, "invalid route pattern %s: wildcard '*' must be the last value in a route", pattern)
+			return nil, false
+		}
+		if strings.HasSuffix(static, "*") {
+			params["*"] = struct{}{}
+		}
+		for _, name := range names {
+			if _, ok := params[name]; ok {
+				pass.Reportf(rp.pos, "invalid route pattern %s: duplicate param key %q", pattern, name)
+				return nil, false
+			}
+			params[name] = struct{}{}
+		}
+	}
+	return params, true
+}
+
+// patternParams returns the names of the params in the segment like {id} or {id:[0-9]+},
+// and the static part of the segment.
+func patternParams(seg string) ([]string, string) {
+	names := make([]string, 0)
+	var static strings.Builder
+	for {
+		start := strings.Index(seg, "{")
+		if start < 0 {
+			static.WriteString(seg)
+			return names, static.String()
+		}
+		end := strings.Index(seg[start:], "}")
+		if end < 0 {
+			static.WriteString(seg)
+			return names, static.String()
+		}
+		static.WriteString(seg[:start])
+		param := seg[start+1 : start+end]
+		name, _, _ := strings.Cut(param, ":")
+		names = append(names, name)
+		seg = seg[start+end+1:]
+	}
+}
+
+// normalizePattern removes the names of the params because chi routes
+// the patterns that differ only in the param names to the same node.
+func normalizePattern(pattern string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			sb.WriteString(pattern)
+			return sb.String()
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			sb.WriteString(pattern)
+			return sb.String()
+		}
+		sb.WriteString(pattern[:start])
+		param := pattern[start+1 : start+end]
+		if _, rexp, ok := strings.Cut(param, ":"); ok {
+			sb.WriteString("{:" + rexp + "}")
+		} else {
+			sb.WriteString("{}")
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
+
+// tagNames returns the names of the tag of the request struct fields.
+func tagNames(req types.Type, key string) []string {
+	if pt, ok := req.(*types.Pointer); ok {
+		req = pt.Elem()
+	}
+	st, ok := req.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get(key)
+		if tag == "" && f.Embedded() {
+			names = append(names, tagNames(f.Type(), key)...)
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,275 @@
+package genclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mobileLanguage renders the types of the TypeScript client in the language of the mobile client.
+type mobileLanguage interface {
+	literal(t typeScriptClientGeneratorLiteralType) string
+	enum(t typeScriptClientGeneratorEnumType) string
+	array(elem string) string
+	record(elem string) string
+	// identifier escapes the keyword of the language.
+	identifier(name string) string
+}
+
+// mobileClass is the class or struct that is declared from the object of the TypeScript client.
+type mobileClass struct {
+	Name   string
+	Fields []*mobileField
+}
+
+type mobileField struct {
+	// Name is the name in JSON, the query string or the path.
+	Name string
+	// Prop is the property name in the language.
+	Prop     string
+	Type     string
+	Optional bool
+	Doc      string
+	// Validate is the validate struct tag, or the pattern of the path param. It is rendered only in the documents.
+	Validate string
+}
+
+type mobileRoute struct {
+	Name       string
+	Method     string
+	Path       string
+	Doc        string
+	PathParams []*mobileField
+	Query      string
+	// QueryFields is the fields of the query class.
+	QueryFields []*mobileField
+	Request     string
+	Response    string
+}
+
+// mobileTypeMapper converts the fields of the TypeScript client to the types of the Kotlin and Swift clients,
+// so all clients are generated from the same type mapping.
+type mobileTypeMapper struct {
+	lang    mobileLanguage
+	gen     *typeScriptClientGenerator
+	classes []*mobileClass
+	refs    map[*typeScriptClientGeneratorNamedRef]*mobileClass
+	names   map[string]struct{}
+}
+
+func newMobileTypeMapper(result *AnalyzerResult, lang mobileLanguage, marshalers map[string]string, durationType string) (*mobileTypeMapper, error) {
+	switch durationType {
+	case "":
+		durationType = "number"
+	case "number", "string":
+	default:
+		return nil, fmt.Errorf("invalid duration type: %s", durationType)
+	}
+	return &mobileTypeMapper{
+		lang: lang,
+		gen: &typeScriptClientGenerator{
+			opts:       &TypeScriptOptions{Marshalers: marshalers, DurationType: durationType},
+			fieldDoc:   result.FieldDoc,
+			namedTypes: newTypeScriptClientGeneratorNamedTypes(),
+		},
+		refs:  make(map[*typeScriptClientGeneratorNamedRef]*mobileClass),
+		names: make(map[string]struct{}),
+	}, nil
+}
+
+func (m *mobileTypeMapper) routes(result *AnalyzerResult) ([]*mobileRoute, error) {
+	routes := make([]*mobileRoute, 0, len(result.RoutePaths))
+	seen := make(map[string]struct{})
+	for _, rp := range result.RoutePaths {
+		path, params := openAPIPath(rp.Path())
+		name := openAPIOperationID(rp.Method(), path)
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		pascal := strings.ToUpper(name[:1]) + name[1:]
+
+		h := rp.Handler()
+		route := &mobileRoute{
+			Name:   name,
+			Method: rp.Method(),
+			Path:   path,
+			Doc:    h.Doc(),
+		}
+		for _, p := range params {
+			pname := p["name"].(string)
+			field := &mobileField{
+				Name: pname,
+				Prop: m.lang.identifier(mobilePropName(pname)),
+				Type: m.lang.literal("string"),
+			}
+			if pattern, ok := p["schema"].(openAPISchema)["pattern"].(string); ok {
+				field.Validate = "pattern=" + pattern
+			}
+			route.PathParams = append(route.PathParams, field)
+		}
+
+		query, err := m.gen.typeInfo(h.Req(), "query")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate query type of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		route.Query = m.typeOf(query, pascal+"Query")
+		if class := m.classOf(route.Query); class != nil {
+			route.QueryFields = class.Fields
+		}
+		request, err := m.gen.typeInfo(h.Req(), "json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate request type of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		route.Request = m.typeOf(request, pascal+"Request")
+		response, err := m.gen.typeInfo(h.Res(), "json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate response type of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		route.Response = m.typeOf(response, pascal+"Response")
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (m *mobileTypeMapper) classOf(name string) *mobileClass {
+	for _, c := range m.classes {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// typeOf returns the type of the field. The objects are declared as the classes named by hint.
+// It returns the empty string for the void field.
+func (m *mobileTypeMapper) typeOf(field typeScriptClientGeneratorField, hint string) string {
+	switch f := field.(type) {
+	case *typeScriptClientGeneratorVoidField:
+		return ""
+	case *typeScriptClientGeneratorNamedRef:
+		if class, ok := m.refs[f]; ok {
+			return class.Name
+		}
+		class := m.declare(f.baseName)
+		m.refs[f] = class
+		m.fillClass(class, f.object)
+		return class.Name
+	case *typeScriptClientGeneratorObjectField:
+		if len(f.fields) == 0 {
+			return ""
+		}
+		class := m.declare(hint)
+		m.fillClass(class, f)
+		return class.Name
+	case *typeScriptClientGeneratorGenericField:
+		typ := m.typeOf(f.typedef, hint)
+		if f.isSlice {
+			typ = m.lang.array(typ)
+		}
+		return typ
+	case *typeScriptClientGeneratorArrayType:
+		return m.lang.array(m.typeOf(f.elem, hint+"Item"))
+	case *typeScriptClientGeneratorRecordType:
+		return m.lang.record(m.typeOf(f.elem, hint+"Value"))
+	case typeScriptClientGeneratorEnumType:
+		return m.lang.enum(f)
+	case typeScriptClientGeneratorLiteralType:
+		return m.lang.literal(f)
+	}
+	return m.lang.literal("unknown")
+}
+
+// declare adds the class with the unique name.
+func (m *mobileTypeMapper) declare(baseName string) *mobileClass {
+	name := baseName
+	for i := 2; ; i++ {
+		if _, ok := m.names[name]; !ok {
+			break
+		}
+		name = baseName + strconv.Itoa(i)
+	}
+	m.names[name] = struct{}{}
+	class := &mobileClass{Name: name}
+	m.classes = append(m.classes, class)
+	return class
+}
+
+func (m *mobileTypeMapper) fillClass(class *mobileClass, object *typeScriptClientGeneratorObjectField) {
+	for _, child := range object.fields {
+		gf, ok := child.(*typeScriptClientGeneratorGenericField)
+		if !ok {
+			continue
+		}
+		prop := mobilePropName(gf.name)
+		typ := m.typeOf(gf, class.Name+strings.ToUpper(prop[:1])+prop[1:])
+		if typ == "" {
+			typ = m.lang.literal("unknown")
+		}
+		class.Fields = append(class.Fields, &mobileField{
+			Name:     gf.name,
+			Prop:     m.lang.identifier(prop),
+			Type:     typ,
+			Optional: gf.isOption && !gf.isRequired,
+			Doc:      gf.doc,
+			Validate: gf.validate,
+		})
+	}
+}
+
+// mobilePropName converts the name in JSON like updated_at to the property name like updatedAt.
+func mobilePropName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	if len(parts) == 0 {
+		return "value"
+	}
+	var sb strings.Builder
+	for i, part := range parts {
+		if i == 0 {
+			sb.WriteString(strings.ToLower(part[:1]) + part[1:])
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	prop := sb.String()
+	if '0' <= prop[0] && prop[0] <= '9' {
+		prop = "_" + prop
+	}
+	return prop
+}
+
+// mobileParams returns the parameters of the function of the route.
+func mobileParams(r *mobileRoute) string {
+	params := make([]string, 0, len(r.PathParams)+2)
+	for _, p := range r.PathParams {
+		params = append(params, p.Prop+": "+p.Type)
+	}
+	if r.Query != "" {
+		params = append(params, "query: "+r.Query)
+	}
+	if r.Request != "" {
+		params = append(params, "body: "+r.Request)
+	}
+	return strings.Join(params, ", ")
+}
+
+// mobileDoc renders the doc comment with the prefix of each line like "/// " or " * ".
+func mobileDoc(indent, open, prefix, closing, doc string) string {
+	if doc == "" {
+		return ""
+	}
+	lines := strings.Split(doc, "\n")
+	var sb strings.Builder
+	if open != "" {
+		sb.WriteString(indent + open + "\n")
+	}
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(indent+prefix+line, " ") + "\n")
+	}
+	if closing != "" {
+		sb.WriteString(indent + closing + "\n")
+	}
+	return sb.String()
+}
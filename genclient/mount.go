@@ -0,0 +1,153 @@
+package genclient
+
+import (
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// MountPath is the path that is passed to Router.Mount.
+type MountPath interface {
+	Path() string
+	// IsRouter reports whether the mounted handler is a tanukirpc.Router.
+	// The routes of the mounted router are included in AnalyzerResult.RoutePaths.
+	IsRouter() bool
+}
+
+// FallbackKind is the kind of the fallback handler.
+type FallbackKind string
+
+const (
+	FallbackKindNotFound         FallbackKind = "NotFound"
+	FallbackKindMethodNotAllowed FallbackKind = "MethodNotAllowed"
+)
+
+// FallbackHandler is the handler that is registered by Router.NotFound or Router.MethodNotAllowed.
+type FallbackHandler interface {
+	Kind() FallbackKind
+	// Path is the prefix of the router that the handler is registered to.
+	Path() string
+	// Handler returns nil when the NewHandler call of the handler is not resolved.
+	Handler() HandlerType
+}
+
+type mountPath struct {
+	parent analyzedPath
+	path   string
+	router bool
+}
+
+func (m *mountPath) Path() string {
+	unquoted, _ := strconv.Unquote(m.path)
+	return m.parent.joinPath(unquoted)
+}
+
+func (m *mountPath) IsRouter() bool {
+	return m.router
+}
+
+type fallbackHandler struct {
+	parent  analyzedPath
+	kind    FallbackKind
+	handler *handlerType
+}
+
+func (f *fallbackHandler) Kind() FallbackKind {
+	return f.kind
+}
+
+func (f *fallbackHandler) Path() string {
+	return f.parent.joinPath("/")
+}
+
+func (f *fallbackHandler) Handler() HandlerType {
+	if f.handler == nil {
+		return nil
+	}
+	return f.handler
+}
+
+func (i *instrs) routerMethodCall(instr ssa.Instruction, method *types.Func) (*ssa.Call, bool) {
+	call, ok := instr.(*ssa.Call)
+	if !ok || method == nil {
+		return nil, false
+	}
+	callee := call.Call.StaticCallee()
+	if callee == nil {
+		return nil, false
+	}
+	named, ok := callee.Object().(*types.Func)
+	if !ok || named.Origin() != method {
+		return nil, false
+	}
+	return call, true
+}
+
+// tryMount records the mount point. When the mounted handler is a tanukirpc.Router,
+// its routes are analyzed with the prefix.
+func (i *instrs) tryMount(pass *analysis.Pass, instr ssa.Instruction) (analyzedPath, bool) {
+	call, ok := i.routerMethodCall(instr, i.agg.mountMethod)
+	if !ok {
+		return nil, false
+	}
+	args := call.Call.Args
+	if len(args) != 3 {
+		pass.Reportf(call.Pos(), "invalid number of arguments")
+		return nil, true
+	}
+	c, ok := args[1].(*ssa.Const)
+	if !ok || c.Value == nil {
+		pass.Reportf(call.Pos(), "unresolvable mount: the path must be a string literal. the mounted handler is not included in the generated code.")
+		return nil, true
+	}
+
+	handler := args[2]
+	if mi, ok := handler.(*ssa.MakeInterface); ok {
+		handler = mi.X
+	}
+	mp := &mountPath{
+		parent: i,
+		path:   c.Value.ExactString(),
+		router: i.agg.isRouterType(handler.Type()),
+	}
+	i.agg.mounts = append(i.agg.mounts, mp)
+	if !mp.router {
+		return nil, true
+	}
+
+	children := i.agg.analyzeRouterValue(pass, handler)
+	np := &routeNestedPath{
+		parent:   i,
+		path:     mp.path,
+		children: children,
+	}
+	children.parent = np
+	return np, true
+}
+
+func (i *instrs) tryFallback(pass *analysis.Pass, instr ssa.Instruction) bool {
+	kinds := map[FallbackKind]*types.Func{
+		FallbackKindNotFound:         i.agg.notFoundMethod,
+		FallbackKindMethodNotAllowed: i.agg.methodNotAllowedMethod,
+	}
+	for kind, method := range kinds {
+		call, ok := i.routerMethodCall(instr, method)
+		if !ok {
+			continue
+		}
+		args := call.Call.Args
+		if len(args) != 2 {
+			pass.Reportf(call.Pos(), "invalid number of arguments")
+			return true
+		}
+		i.agg.fallbacks = append(i.agg.fallbacks, &fallbackHandler{
+			parent:  i,
+			kind:    kind,
+			handler: i.handlerType(pass, args[1], call.Pos()),
+		})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+package genclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIOptions is the options of GenerateOpenAPI.
+type OpenAPIOptions struct {
+	// Title is the title of the API. The default is "tanukirpc".
+	Title string
+	// Version is the version of the API. The default is "0.0.0".
+	Version string
+	// Marshalers maps the full names of the types implementing json.Marshaler to their TypeScript types
+	// like TypeScriptOptions.Marshalers. string, number and boolean are converted to the schema types.
+	Marshalers map[string]string
+	// DurationType is the type of time.Duration: "number" or "string". The default is "number".
+	DurationType string
+}
+
+// openAPISchema is the schema object of OpenAPI.
+// The keys are sorted by encoding/json, so the output is stable.
+type openAPISchema map[string]any
+
+// GenerateOpenAPI generates the OpenAPI 3.0 document in JSON of the routes in the result.
+// The schemas are derived in the same way as the TypeScript client. opts may be nil.
+func GenerateOpenAPI(result *AnalyzerResult, opts *OpenAPIOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &OpenAPIOptions{}
+	}
+	tsOpts := &TypeScriptOptions{
+		Marshalers:   opts.Marshalers,
+		DurationType: opts.DurationType,
+	}
+	switch tsOpts.DurationType {
+	case "":
+		tsOpts.DurationType = "number"
+	case "number", "string":
+	default:
+		return nil, fmt.Errorf("invalid duration type: %s", opts.DurationType)
+	}
+	title := opts.Title
+	if title == "" {
+		title = "tanukirpc"
+	}
+	version := opts.Version
+	if version == "" {
+		version = "0.0.0"
+	}
+
+	gen := &typeScriptClientGenerator{opts: tsOpts, fieldDoc: result.FieldDoc}
+	paths := make(map[string]map[string]any)
+	for _, rp := range result.RoutePaths {
+		path, params := openAPIPath(rp.Path())
+		op, err := openAPIOperation(gen, rp, path, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate operation of route %s %s: %w", rp.Method(), rp.Path(), err)
+		}
+		if _, ok := paths[path]; !ok {
+			paths[path] = make(map[string]any)
+		}
+		paths[path][strings.ToLower(rp.Method())] = op
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": map[string]any{
+				// tanukirpc.ErrorMessage
+				"Error": openAPISchema{
+					"type":     "object",
+					"required": []string{"error"},
+					"properties": map[string]any{
+						"error": openAPISchema{
+							"type":     "object",
+							"required": []string{"message"},
+							"properties": map[string]any{
+								"message": openAPISchema{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// openAPIPath converts the chi pattern to the OpenAPI path template.
+// The regexp of the param is returned as the pattern of the param schema.
+func openAPIPath(pattern string) (string, []map[string]any) {
+	params := make([]map[string]any, 0)
+	var sb strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			sb.WriteString(pattern)
+			return sb.String(), params
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			sb.WriteString(pattern)
+			return sb.String(), params
+		}
+		sb.WriteString(pattern[:start])
+		name, regexp, _ := strings.Cut(pattern[start+1:start+end], ":")
+		schema := openAPISchema{"type": "string"}
+		if regexp != "" {
+			schema["pattern"] = "^" + regexp + "$"
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+		sb.WriteString("{" + name + "}")
+		pattern = pattern[start+end+1:]
+	}
+}
+
+func openAPIOperation(gen *typeScriptClientGenerator, rp RoutePath, path string, params []map[string]any) (map[string]any, error) {
+	h := rp.Handler()
+	op := map[string]any{
+		"operationId": openAPIOperationID(rp.Method(), path),
+	}
+	annotations := h.Annotations()
+	if annotations != nil && annotations.Name != "" {
+		op["operationId"] = annotations.Name
+	}
+	if doc := h.Doc(); doc != "" {
+		summary, _, _ := strings.Cut(doc, "\n")
+		op["summary"] = summary
+		op["description"] = doc
+	}
+	if h.Deprecated() {
+		op["deprecated"] = true
+	}
+	tags := make([]string, 0)
+	if res := rp.Resource(); res != nil {
+		if name := res.Name(); name != "" {
+			tags = append(tags, name)
+		}
+	}
+	if annotations != nil {
+		for _, tag := range annotations.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if len(annotations.Scopes) > 0 {
+			op["x-required-scopes"] = annotations.Scopes
+		}
+	}
+	if len(tags) > 0 {
+		op["tags"] = tags
+	}
+
+	if tenant := rp.Tenant(); tenant != nil && tenant.Kind == TenantSourceKindHeader {
+		params = append(params, map[string]any{
+			"name":        tenant.Name,
+			"in":          "header",
+			"required":    true,
+			"description": "The ID of the tenant.",
+			"schema":      openAPISchema{"type": "string"},
+		})
+	}
+
+	query, err := gen.typeInfo(h.Req(), "query")
+	if err != nil {
+		return nil, err
+	}
+	if object, ok := query.(*typeScriptClientGeneratorObjectField); ok {
+		for _, f := range object.fields {
+			gf, ok := f.(*typeScriptClientGeneratorGenericField)
+			if !ok {
+				continue
+			}
+			param := map[string]any{
+				"name":     gf.name,
+				"in":       "query",
+				"required": gf.isRequired,
+				"schema":   gf.openAPISchema(),
+			}
+			if gf.doc != "" {
+				param["description"] = gf.doc
+			}
+			params = append(params, param)
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	request, err := gen.typeInfo(h.Req(), "json")
+	if err != nil {
+		return nil, err
+	}
+	if schema := openAPISchemaOf(request); schema != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schema},
+			},
+		}
+	}
+
+	response, err := gen.typeInfo(h.Res(), "json")
+	if err != nil {
+		return nil, err
+	}
+	ok := map[string]any{"description": "OK"}
+	if schema := openAPISchemaOf(response); schema != nil {
+		ok["content"] = map[string]any{
+			"application/json": map[string]any{"schema": schema},
+		}
+	}
+	op["responses"] = map[string]any{
+		"200": ok,
+		"default": map[string]any{
+			"description": "Error",
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": openAPISchema{"$ref": "#/components/schemas/Error"},
+				},
+			},
+		},
+	}
+	return op, nil
+}
+
+// openAPIOperationID returns the unique ID of the operation like getTasksById for GET /tasks/{id}.
+func openAPIOperationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, seg := range strings.FieldsFunc(path, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '{')
+	}) {
+		if strings.HasPrefix(seg, "{") {
+			sb.WriteString("By")
+			seg = strings.TrimPrefix(seg, "{")
+		}
+		if seg == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	return sb.String()
+}
+
+// openAPISchemaOf converts the field of the TypeScript client to the schema.
+// It returns nil for the void field.
+func openAPISchemaOf(field typeScriptClientGeneratorField) openAPISchema {
+	switch f := field.(type) {
+	case *typeScriptClientGeneratorVoidField:
+		return nil
+	case *typeScriptClientGeneratorObjectField:
+		properties := make(map[string]any, len(f.fields))
+		required := make([]string, 0, len(f.fields))
+		for _, child := range f.fields {
+			gf, ok := child.(*typeScriptClientGeneratorGenericField)
+			if !ok {
+				continue
+			}
+			properties[gf.name] = gf.openAPISchema()
+			if gf.isRequired {
+				required = append(required, gf.name)
+			}
+		}
+		schema := openAPISchema{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case *typeScriptClientGeneratorNamedRef:
+		return openAPISchemaOf(f.object)
+	case *typeScriptClientGeneratorGenericField:
+		return f.openAPISchema()
+	case *typeScriptClientGeneratorArrayType:
+		return openAPISchema{"type": "array", "items": openAPISchemaOf(f.elem)}
+	case *typeScriptClientGeneratorRecordType:
+		return openAPISchema{"type": "object", "additionalProperties": openAPISchemaOf(f.elem)}
+	case typeScriptClientGeneratorEnumType:
+		values := make([]any, 0, len(f))
+		schemaType := "string"
+		for _, v := range f {
+			if s, err := strconv.Unquote(v); err == nil {
+				values = append(values, s)
+				continue
+			}
+			schemaType = "integer"
+			values = append(values, json.Number(v))
+		}
+		return openAPISchema{"type": schemaType, "enum": values}
+	case typeScriptClientGeneratorLiteralType:
+		switch f {
+		case "string", "number", "boolean":
+			return openAPISchema{"type": string(f)}
+		}
+		// unknown and the type that is specified by the tstype struct tag
+		return openAPISchema{}
+	}
+	return openAPISchema{}
+}
+
+// openAPISchema returns the schema of the field with the validation rules.
+func (t *typeScriptClientGeneratorGenericField) openAPISchema() openAPISchema {
+	schema := openAPISchemaOf(t.typedef)
+	if schema == nil {
+		schema = openAPISchema{}
+	}
+	isNumber := t.typedef == typeScriptClientGeneratorLiteralType("number")
+	isString := t.typedef == typeScriptClientGeneratorLiteralType("string")
+	target := schema
+	if t.isSlice {
+		schema = openAPISchema{"type": "array", "items": schema}
+		target = schema
+	}
+	for _, rule := range strings.Split(t.validate, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		n, err := strconv.ParseFloat(param, 64)
+		if param != "" && err != nil {
+			continue
+		}
+		switch {
+		case name == "dive":
+			// the rules after dive are applied to the elements
+			if !t.isSlice {
+				return t.withDoc(schema)
+			}
+			target = schema["items"].(openAPISchema)
+			continue
+		case target["type"] == "array":
+			switch name {
+			case "min":
+				target["minItems"] = n
+			case "max":
+				target["maxItems"] = n
+			case "len":
+				target["minItems"], target["maxItems"] = n, n
+			}
+		case isNumber:
+			switch name {
+			case "min", "gte":
+				target["minimum"] = n
+			case "max", "lte":
+				target["maximum"] = n
+			case "gt":
+				target["minimum"], target["exclusiveMinimum"] = n, true
+			case "lt":
+				target["maximum"], target["exclusiveMaximum"] = n, true
+			}
+		case isString:
+			switch name {
+			case "min":
+				target["minLength"] = n
+			case "max":
+				target["maxLength"] = n
+			case "len":
+				target["minLength"], target["maxLength"] = n, n
+			case "email", "uuid":
+				target["format"] = name
+			case "url":
+				target["format"] = "uri"
+			}
+		}
+	}
+	return t.withDoc(schema)
+}
+
+func (t *typeScriptClientGeneratorGenericField) withDoc(schema openAPISchema) openAPISchema {
+	if t.doc != "" {
+		schema["description"] = t.doc
+	}
+	if t.isOption && !t.isRequired {
+		schema["nullable"] = true
+	}
+	return schema
+}
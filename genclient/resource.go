@@ -0,0 +1,146 @@
+package genclient
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// ResourceAction is the action of the resource of the route that is registered by Router.Resource.
+type ResourceAction struct {
+	// Path is the path of the collection of the resource, like "/tasks".
+	Path string
+	// Action is the name of the field of tanukirpc.Resource, like "Index" or "Show".
+	Action string
+}
+
+// Name returns the name of the resource that is the last segment of the path without the URL parameters, like "tasks".
+func (a *ResourceAction) Name() string {
+	segments := strings.Split(strings.Trim(a.Path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] != "" && !strings.HasPrefix(segments[i], "{") {
+			return segments[i]
+		}
+	}
+	return ""
+}
+
+type resourceAction struct {
+	// path is the unquoted path of the collection that is relative to the parent
+	path   string
+	action string
+}
+
+// resourceRoutes is the routes of the fields of tanukirpc.Resource in the order of the registration.
+var resourceRoutes = []struct {
+	field  string
+	method string
+	member bool
+}{
+	{"Index", http.MethodGet, false},
+	{"Create", http.MethodPost, false},
+	{"Show", http.MethodGet, true},
+	{"Update", http.MethodPut, true},
+	{"Destroy", http.MethodDelete, true},
+}
+
+// tryResource returns the routes of the resource that is registered by Router.Resource.
+// The resource must be a struct literal of tanukirpc.Resource in the function.
+func (i *instrs) tryResource(pass *analysis.Pass, instr ssa.Instruction) ([]*routePath, bool) {
+	call, ok := instr.(*ssa.Call)
+	if !ok || i.agg.resourceMethod == nil {
+		return nil, false
+	}
+	callee := call.Call.StaticCallee()
+	if callee == nil {
+		return nil, false
+	}
+	named, ok := callee.Object().(*types.Func)
+	if !ok || named.Origin() != i.agg.resourceMethod {
+		return nil, false
+	}
+	args := call.Call.Args
+	if len(args) != 3 {
+		pass.Reportf(call.Pos(), "invalid number of arguments")
+		return nil, true
+	}
+	c, ok := args[1].(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		pass.Reportf(call.Pos(), "invalid path argument. must be string literal.")
+		return nil, true
+	}
+	fields := resourceFields(args[2])
+	if fields == nil {
+		pass.Reportf(call.Pos(), "unresolvable resource registration: the resource must be a struct literal. the routes are not included in the generated code.")
+		return nil, true
+	}
+
+	collection := constant.StringVal(c.Value)
+	idParam := "id"
+	if v, ok := fields["IDParam"].(*ssa.Const); ok && v.Value != nil && constant.StringVal(v.Value) != "" {
+		idParam = constant.StringVal(v.Value)
+	}
+	member := strings.TrimSuffix(collection, "/") + "/{" + idParam + "}"
+
+	rps := make([]*routePath, 0, len(resourceRoutes))
+	for _, route := range resourceRoutes {
+		handler, ok := fields[route.field]
+		if !ok {
+			continue
+		}
+		if c, ok := handler.(*ssa.Const); ok && c.IsNil() {
+			continue
+		}
+		ht := i.handlerType(pass, handler, call.Pos())
+		if ht == nil {
+			continue
+		}
+		p := collection
+		if route.member {
+			p = member
+		}
+		rps = append(rps, &routePath{
+			parent:   i,
+			path:     strconv.Quote(p),
+			method:   route.method,
+			handler:  ht,
+			pos:      call.Pos(),
+			resource: &resourceAction{path: collection, action: route.field},
+		})
+	}
+	return rps, true
+}
+
+// resourceFields returns the values that are stored to the fields of the struct literal by the names of the fields.
+func resourceFields(v ssa.Value) map[string]ssa.Value {
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return nil
+	}
+	alloc, ok := load.X.(*ssa.Alloc)
+	if !ok {
+		return nil
+	}
+	st, ok := alloc.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]ssa.Value)
+	for _, fa := range referrersOf[*ssa.FieldAddr](alloc) {
+		if fa.X != alloc {
+			continue
+		}
+		for _, store := range referrersOf[*ssa.Store](fa) {
+			if store.Addr == fa {
+				fields[st.Field(fa.Field).Name()] = store.Val
+			}
+		}
+	}
+	return fields
+}
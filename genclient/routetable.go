@@ -0,0 +1,204 @@
+package genclient
+
+import (
+	"go/constant"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// routeTableEntry is the route that is defined as an element of the route table literal.
+type routeTableEntry struct {
+	path    *ssa.Const
+	handler ssa.Value
+}
+
+// routeTableField is the field of the element of the route table that is read in the range loop.
+type routeTableField struct {
+	elem  *ssa.IndexAddr
+	field int
+	// deref is true when the elements are pointers like []*route
+	deref bool
+}
+
+// routeTable evaluates the table-driven registration like the following:
+//
+//	for _, r := range routes {
+//		router.Get(r.path, r.handler)
+//	}
+//
+// routes must be a slice literal in the function or a package variable initialized by a slice literal.
+// The elements that the path is not a string literal are skipped.
+func routeTable(pathArg, handlerArg ssa.Value) []routeTableEntry {
+	pathField, ok := tableFieldOf(pathArg)
+	if !ok {
+		return nil
+	}
+	handlerField, ok := tableFieldOf(handlerArg)
+	if !ok || handlerField.elem.X != pathField.elem.X || handlerField.deref != pathField.deref {
+		return nil
+	}
+	array := tableArray(pathField.elem.X)
+	if array == nil {
+		return nil
+	}
+
+	paths := tableFieldValues(array, pathField)
+	handlers := tableFieldValues(array, handlerField)
+	indexes := make([]int64, 0, len(paths))
+	for idx := range paths {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	entries := make([]routeTableEntry, 0, len(indexes))
+	for _, idx := range indexes {
+		path, ok := paths[idx].(*ssa.Const)
+		if !ok || path.Value == nil || path.Value.Kind() != constant.String {
+			continue
+		}
+		handler, ok := handlers[idx]
+		if !ok {
+			continue
+		}
+		entries = append(entries, routeTableEntry{
+			path:    path,
+			handler: handler,
+		})
+	}
+	return entries
+}
+
+// tableFieldOf matches the field read of the element of the route table in the range loop.
+// The loop variable is either the copy of the element or the pointer to the struct literal.
+func tableFieldOf(v ssa.Value) (*routeTableField, bool) {
+	switch v := v.(type) {
+	case *ssa.Field:
+		// (*s[i]).f
+		if elem, ok := loadedElem(v.X); ok {
+			return &routeTableField{elem: elem, field: v.Field}, true
+		}
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return nil, false
+		}
+		fa, ok := v.X.(*ssa.FieldAddr)
+		if !ok {
+			return nil, false
+		}
+		switch x := fa.X.(type) {
+		case *ssa.IndexAddr:
+			// s[i].f
+			return &routeTableField{elem: x, field: fa.Field}, true
+		case *ssa.Alloc:
+			// r := s[i]; r.f
+			for _, store := range referrersOf[*ssa.Store](x) {
+				if elem, ok := loadedElem(store.Val); ok && store.Addr == x {
+					return &routeTableField{elem: elem, field: fa.Field}, true
+				}
+			}
+		default:
+			// r := s[i] of []*T; r.f
+			if elem, ok := loadedElem(x); ok {
+				return &routeTableField{elem: elem, field: fa.Field, deref: true}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// loadedElem matches the load of the element: *s[i]
+func loadedElem(v ssa.Value) (*ssa.IndexAddr, bool) {
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return nil, false
+	}
+	elem, ok := load.X.(*ssa.IndexAddr)
+	return elem, ok
+}
+
+// tableArray returns the backing array of the slice literal.
+func tableArray(v ssa.Value) *ssa.Alloc {
+	switch v := v.(type) {
+	case *ssa.Slice:
+		alloc, _ := v.X.(*ssa.Alloc)
+		return alloc
+	case *ssa.UnOp:
+		global, ok := v.X.(*ssa.Global)
+		if !ok || v.Op != token.MUL {
+			return nil
+		}
+		// the package variable is initialized in the init function
+		init := global.Pkg.Func("init")
+		if init == nil {
+			return nil
+		}
+		for _, b := range init.Blocks {
+			for _, instr := range b.Instrs {
+				store, ok := instr.(*ssa.Store)
+				if !ok || store.Addr != global {
+					continue
+				}
+				return tableArray(store.Val)
+			}
+		}
+	}
+	return nil
+}
+
+// tableFieldValues returns the values that are stored to the field of the elements by the index.
+func tableFieldValues(array *ssa.Alloc, f *routeTableField) map[int64]ssa.Value {
+	values := make(map[int64]ssa.Value)
+	for _, ia := range referrersOf[*ssa.IndexAddr](array) {
+		if ia.X != array {
+			continue
+		}
+		c, ok := ia.Index.(*ssa.Const)
+		if !ok {
+			continue
+		}
+		idx, ok := constant.Int64Val(c.Value)
+		if !ok {
+			continue
+		}
+		structAddr := ssa.Value(ia)
+		for _, store := range referrersOf[*ssa.Store](ia) {
+			if store.Addr != ia {
+				continue
+			}
+			if f.deref {
+				// the element is the pointer to the struct literal
+				structAddr = store.Val
+			} else if load, ok := store.Val.(*ssa.UnOp); ok && load.Op == token.MUL {
+				// the struct literal is built in the temporary variable and copied
+				structAddr = load.X
+			}
+		}
+		for _, fa := range referrersOf[*ssa.FieldAddr](structAddr) {
+			if fa.X != structAddr || fa.Field != f.field {
+				continue
+			}
+			for _, store := range referrersOf[*ssa.Store](fa) {
+				if store.Addr == fa {
+					values[idx] = store.Val
+				}
+			}
+		}
+	}
+	return values
+}
+
+func referrersOf[T ssa.Instruction](v ssa.Value) []T {
+	referrers := v.Referrers()
+	if referrers == nil {
+		return nil
+	}
+	ret := make([]T, 0, len(*referrers))
+	for _, instr := range *referrers {
+		if t, ok := instr.(T); ok {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
@@ -3,6 +3,7 @@ package genclient
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
 	"golang.org/x/tools/go/analysis"
@@ -19,11 +20,24 @@ var ShowPathAnalyzer = &analysis.Analyzer{
 
 func runShowPaths(pass *analysis.Pass) (any, error) {
 	result := pass.ResultOf[Analyzer].(*AnalyzerResult)
-	rps := result.RoutePaths
-	if len(rps) == 0 {
+	if len(result.RoutePaths) == 0 && len(result.DebugEndpointsPrefixes) == 0 && len(result.MountPaths) == 0 {
 		return nil, nil
 	}
 
+	b, err := GenerateRoutesJSON(result)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stdout.Write(b); err != nil {
+		return nil, fmt.Errorf("failed to write json: %w", err)
+	}
+
+	return nil, nil
+}
+
+// GenerateRoutesJSON returns the routes, the mounts and the fallback handlers in the same JSON as the showpaths command.
+func GenerateRoutesJSON(result *AnalyzerResult) ([]byte, error) {
+	rps := result.RoutePaths
 	rpps := make([]showPathPath, 0, len(rps))
 	for _, rp := range rps {
 		rpps = append(rpps, showPathPath{
@@ -31,21 +45,58 @@ func runShowPaths(pass *analysis.Pass) (any, error) {
 			Path:   rp.Path(),
 		})
 	}
+	// pprof handlers accept GET and POST (symbol lookup)
+	for _, prefix := range result.DebugEndpointsPrefixes {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			rpps = append(rpps, showPathPath{
+				Method: method,
+				Path:   prefix + "/*",
+			})
+		}
+	}
+	mounts := make([]showPathMount, 0, len(result.MountPaths))
+	for _, mp := range result.MountPaths {
+		mounts = append(mounts, showPathMount{
+			Path:   mp.Path(),
+			Router: mp.IsRouter(),
+		})
+	}
+	fallbacks := make([]showPathFallback, 0, len(result.FallbackHandlers))
+	for _, fh := range result.FallbackHandlers {
+		fallbacks = append(fallbacks, showPathFallback{
+			Kind: string(fh.Kind()),
+			Path: fh.Path(),
+		})
+	}
 	jsonRet := showPathResult{
-		Paths: rpps,
+		Paths:     rpps,
+		Mounts:    mounts,
+		Fallbacks: fallbacks,
 	}
-	if err := json.NewEncoder(os.Stdout).Encode(jsonRet); err != nil {
+	b, err := json.Marshal(jsonRet)
+	if err != nil {
 		return nil, fmt.Errorf("failed to encode json: %w", err)
 	}
-
-	return nil, nil
+	return append(b, '\n'), nil
 }
 
 type showPathResult struct {
-	Paths []showPathPath `json:"paths"`
+	Paths     []showPathPath     `json:"paths"`
+	Mounts    []showPathMount    `json:"mounts"`
+	Fallbacks []showPathFallback `json:"fallbacks"`
 }
 
 type showPathPath struct {
 	Method string `json:"method"`
 	Path   string `json:"path"`
 }
+
+type showPathMount struct {
+	Path   string `json:"path"`
+	Router bool   `json:"router"`
+}
+
+type showPathFallback struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
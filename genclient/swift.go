@@ -0,0 +1,230 @@
+package genclient
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// SwiftOptions is the options of GenerateSwift.
+type SwiftOptions struct {
+	// Marshalers and DurationType are the same as TypeScriptOptions.
+	Marshalers   map[string]string
+	DurationType string
+}
+
+// GenerateSwift generates the Swift client of the routes in the result.
+// The client uses URLSession and Codable.
+func GenerateSwift(result *AnalyzerResult, opts *SwiftOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &SwiftOptions{}
+	}
+	m, err := newMobileTypeMapper(result, swiftLanguage{}, opts.Marshalers, opts.DurationType)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := m.routes(result)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := swiftTemplate.Execute(buf, map[string]any{
+		"Classes": m.classes,
+		"Routes":  routes,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type swiftLanguage struct{}
+
+func (swiftLanguage) literal(t typeScriptClientGeneratorLiteralType) string {
+	switch t {
+	case "string":
+		return "String"
+	case "number":
+		return "Double"
+	case "boolean":
+		return "Bool"
+	}
+	return "JSONValue"
+}
+
+func (swiftLanguage) enum(t typeScriptClientGeneratorEnumType) string {
+	if len(t) > 0 && strings.HasPrefix(t[0], `"`) {
+		return "String"
+	}
+	return "Int"
+}
+
+func (swiftLanguage) array(elem string) string {
+	return "[" + elem + "]"
+}
+
+func (swiftLanguage) record(elem string) string {
+	return "[String: " + elem + "]"
+}
+
+var swiftKeywords = map[string]struct{}{
+	"as": {}, "break": {}, "case": {}, "class": {}, "continue": {}, "default": {}, "defer": {}, "do": {}, "else": {},
+	"enum": {}, "extension": {}, "false": {}, "for": {}, "func": {}, "if": {}, "import": {}, "in": {}, "init": {},
+	"is": {}, "let": {}, "nil": {}, "protocol": {}, "repeat": {}, "return": {}, "self": {}, "static": {}, "struct": {},
+	"subscript": {}, "super": {}, "switch": {}, "throw": {}, "true": {}, "try": {}, "var": {}, "where": {}, "while": {},
+}
+
+func (swiftLanguage) identifier(name string) string {
+	if _, ok := swiftKeywords[name]; ok {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// swiftPath returns the string literal of the path like "/tasks/\(escape(id))".
+func swiftPath(r *mobileRoute) string {
+	path := strconv.Quote(r.Path)
+	for _, p := range r.PathParams {
+		path = strings.Replace(path, "{"+p.Name+"}", `\(escape(`+p.Prop+`))`, 1)
+	}
+	return path
+}
+
+var swiftTemplate = template.Must(template.New("swift").Funcs(template.FuncMap{
+	"doc": func(indent, doc string) string {
+		return mobileDoc(indent, "", "/// ", "", doc)
+	},
+	"path":   swiftPath,
+	"params": mobileParams,
+	"quote":  strconv.Quote,
+}).Parse(`// Code generated by tanukigen. DO NOT EDIT.
+
+import Foundation
+
+/// JSONValue is the value of any JSON.
+public enum JSONValue: Codable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let v = try? container.decode(Bool.self) {
+            self = .bool(v)
+        } else if let v = try? container.decode(Double.self) {
+            self = .number(v)
+        } else if let v = try? container.decode(String.self) {
+            self = .string(v)
+        } else if let v = try? container.decode([JSONValue].self) {
+            self = .array(v)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null: try container.encodeNil()
+        case .bool(let v): try container.encode(v)
+        case .number(let v): try container.encode(v)
+        case .string(let v): try container.encode(v)
+        case .array(let v): try container.encode(v)
+        case .object(let v): try container.encode(v)
+        }
+    }
+}
+
+/// ApiError is thrown on the non-2xx responses.
+public struct ApiError: Error {
+    public let status: Int
+    public let message: String
+}
+
+private struct ErrorResponse: Decodable {
+    struct Body: Decodable {
+        let message: String
+    }
+    let error: Body
+}
+{{ range .Classes }}
+public struct {{ .Name }}: Codable {
+{{- range .Fields }}
+{{ doc "    " .Doc }}    public var {{ .Prop }}: {{ .Type }}{{ if .Optional }}?{{ end }}
+{{- end }}
+
+    enum CodingKeys: String, CodingKey {
+{{- range .Fields }}
+        case {{ .Prop }} = {{ quote .Name }}
+{{- end }}
+    }
+
+    public init({{ range $i, $f := .Fields }}{{ if $i }}, {{ end }}{{ $f.Prop }}: {{ $f.Type }}{{ if $f.Optional }}? = nil{{ end }}{{ end }}) {
+{{- range .Fields }}
+        self.{{ .Prop }} = {{ .Prop }}
+{{- end }}
+    }
+}
+{{ end }}
+/// ApiClient calls the API with URLSession.
+public final class ApiClient {
+    private let baseURL: String
+    private let session: URLSession
+
+    public init(baseURL: String, session: URLSession = .shared) {
+        self.baseURL = baseURL.hasSuffix("/") ? String(baseURL.dropLast()) : baseURL
+        self.session = session
+    }
+{{ range .Routes }}
+{{ doc "    " .Doc }}    public func {{ .Name }}({{ params . }}) async throws{{ if .Response }} -> {{ .Response }}{{ end }} {
+        {{ if .QueryFields }}var{{ else }}let{{ end }} queryItems: [URLQueryItem] = []
+{{- range .QueryFields }}
+{{- if .Optional }}
+        if let v = query.{{ .Prop }} { queryItems.append(URLQueryItem(name: {{ quote .Name }}, value: "\(v)")) }
+{{- else }}
+        queryItems.append(URLQueryItem(name: {{ quote .Name }}, value: "\(query.{{ .Prop }})"))
+{{- end }}
+{{- end }}
+        {{ if .Response }}let data{{ else }}_{{ end }} = try await send(method: {{ quote .Method }}, path: {{ path . }}, queryItems: queryItems, body: {{ if .Request }}try JSONEncoder().encode(body){{ else }}nil{{ end }})
+{{- if .Response }}
+        return try JSONDecoder().decode({{ .Response }}.self, from: data)
+{{- end }}
+    }
+{{ end }}
+    private func escape(_ value: String) -> String {
+        value.addingPercentEncoding(withAllowedCharacters: .urlPathAllowed.subtracting(CharacterSet(charactersIn: "/"))) ?? value
+    }
+
+    private func send(method: String, path: String, queryItems: [URLQueryItem], body: Data?) async throws -> Data {
+        guard var components = URLComponents(string: baseURL + path) else {
+            throw URLError(.badURL)
+        }
+        if !queryItems.isEmpty {
+            components.queryItems = queryItems
+        }
+        guard let url = components.url else {
+            throw URLError(.badURL)
+        }
+        var request = URLRequest(url: url)
+        request.httpMethod = method
+        request.setValue("application/json", forHTTPHeaderField: "Accept")
+        if let body = body {
+            request.httpBody = body
+            request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+        }
+        let (data, response) = try await session.data(for: request)
+        let status = (response as? HTTPURLResponse)?.statusCode ?? 0
+        if !(200..<300).contains(status) {
+            let message = (try? JSONDecoder().decode(ErrorResponse.self, from: data))?.error.message ?? HTTPURLResponse.localizedString(forStatusCode: status)
+            throw ApiError(status: status, message: message)
+        }
+        return data
+    }
+}
+`))
@@ -0,0 +1,112 @@
+package genclient
+
+import (
+	"go/constant"
+	"go/types"
+
+	"github.com/gostaticanalysis/analysisutil"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+const tenantPkgPath = "github.com/mackee/tanukirpc/tenant"
+
+// TenantSourceKind is the kind of the Resolver of tenant.NewTransformer.
+type TenantSourceKind string
+
+const (
+	TenantSourceKindHeader    TenantSourceKind = "header"
+	TenantSourceKindSubdomain TenantSourceKind = "subdomain"
+	TenantSourceKindPath      TenantSourceKind = "path"
+)
+
+// TenantSource is the source of the tenant ID of the routes that are scoped by tenant.NewTransformer.
+type TenantSource struct {
+	Kind TenantSourceKind
+	// Name is the name of the header, the base domain or the name of the path param.
+	Name string
+}
+
+type tenantTypeInfo struct {
+	newTransformerObj types.Object
+	resolvers         map[types.Object]TenantSourceKind
+}
+
+func newTenantTypeInfo(pass *analysis.Pass) *tenantTypeInfo {
+	newTransformerObj := analysisutil.LookupFromImports(pass.Pkg.Imports(), tenantPkgPath, "NewTransformer")
+	if newTransformerObj == nil {
+		return nil
+	}
+	resolvers := make(map[types.Object]TenantSourceKind)
+	for name, kind := range map[string]TenantSourceKind{
+		"FromHeader":    TenantSourceKindHeader,
+		"FromSubdomain": TenantSourceKindSubdomain,
+		"FromPathParam": TenantSourceKindPath,
+	} {
+		if obj := analysisutil.LookupFromImports(pass.Pkg.Imports(), tenantPkgPath, name); obj != nil {
+			resolvers[obj] = kind
+		}
+	}
+	return &tenantTypeInfo{
+		newTransformerObj: newTransformerObj,
+		resolvers:         resolvers,
+	}
+}
+
+// source returns the source of the tenant ID if v is the transformer that is created by tenant.NewTransformer with the resolver of the tenant package.
+func (t *tenantTypeInfo) source(v ssa.Value) *TenantSource {
+	if t == nil {
+		return nil
+	}
+	if mi, ok := v.(*ssa.MakeInterface); ok {
+		v = mi.X
+	}
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return nil
+	}
+	callee := call.Call.StaticCallee()
+	if callee == nil {
+		return nil
+	}
+	fn, ok := callee.Object().(*types.Func)
+	if !ok || fn.Origin() != t.newTransformerObj || len(call.Call.Args) == 0 {
+		return nil
+	}
+
+	resolverCall, ok := call.Call.Args[0].(*ssa.Call)
+	if !ok {
+		return nil
+	}
+	resolver := resolverCall.Call.StaticCallee()
+	if resolver == nil {
+		return nil
+	}
+	kind, ok := t.resolvers[resolver.Object()]
+	if !ok || len(resolverCall.Call.Args) != 1 {
+		return nil
+	}
+	c, ok := resolverCall.Call.Args[0].(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.String {
+		return nil
+	}
+	return &TenantSource{Kind: kind, Name: constant.StringVal(c.Value)}
+}
+
+// tenantOf returns the source of the tenant ID of the nearest RouteWithTransformer of tenant.NewTransformer.
+func tenantOf(p analyzedPath) *TenantSource {
+	for p != nil {
+		switch np := p.(type) {
+		case *instrs:
+			p = np.parent
+		case *routeNestedPath:
+			if np.tenant != nil {
+				return np.tenant
+			}
+			p = np.parent
+		default:
+			return nil
+		}
+	}
+	return nil
+}
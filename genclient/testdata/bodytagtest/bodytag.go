@@ -0,0 +1,40 @@
+package bodytagtest
+
+import (
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/genclient"
+)
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type uploadRequest struct {
+	Name string `form:"name"`
+}
+
+type echoResponse struct {
+	Message string `json:"message"`
+}
+
+func echo(ctx tanukirpc.Context[struct{}], req echoRequest) (*echoResponse, error) {
+	return &echoResponse{Message: req.Message}, nil
+}
+
+func upload(ctx tanukirpc.Context[struct{}], req uploadRequest) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+func register(router *tanukirpc.Router[struct{}]) {
+	router.Get( // want `json tags of the request are declared on GET /echo`
+		"/echo",
+		tanukirpc.NewHandler(echo),
+	)
+	router.Head("/echo", tanukirpc.NewHandler(echo)) // want `json tags of the request are declared on HEAD /echo`
+	router.Post("/echo", tanukirpc.NewHandler(echo))
+	router.Delete("/upload", tanukirpc.NewHandler(upload)) // want `form tags of the request are declared on DELETE /upload`
+	router.Put("/upload", tanukirpc.NewHandler(upload))
+	router.Patch("/upload", tanukirpc.NewHandler(upload))
+
+	genclient.AnalyzeTarget(router)
+}
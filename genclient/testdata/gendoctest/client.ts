@@ -1,108 +1,395 @@
 // This file was automatically @generated by gentypescript
 
+export interface PingResponse {
+  message: string;
+}
+
+export interface PingCounterResponse {
+  count: number;
+}
+
+export interface EchoRequest {
+  message: string;
+}
+
+export interface EchoResponse {
+  message?: string;
+}
+
+export interface NowResponse {
+  now: string;
+}
+
+export interface EpochResponse {
+  datetime: string;
+}
+
+export interface TaskRequest {
+  title: string;
+  status: "todo" | "doing" | "done";
+  priority?: 0 | 1;
+  note?: string;
+}
+
+export interface TaskResponse {
+  statuses: ("todo" | "doing" | "done")[];
+}
+
+export interface SettingsRequest {
+  /** Labels is attached to the settings. */
+  labels?: Record<string, string>;
+  extra?: unknown;
+  /** in nanoseconds */
+  timeout?: number;
+  tags?: string[];
+}
+
+export interface SettingsResponse {
+  limits: Record<string, number[]>;
+  matrix: ("todo" | "doing" | "done")[][];
+  updated_at?: string;
+}
+
+export interface FileResponse {
+  path: string;
+}
+
+export interface TaskRequest2 {
+  title: string;
+  status: "todo" | "doing" | "done";
+  priority: 0 | 1;
+  note: string;
+}
+
+export interface ListTasksQuery {
+  status?: "todo" | "doing" | "done";
+}
+
+export interface HealthResponse {
+  ok: boolean;
+}
+
+export interface StatsResponse {
+  tasks: number;
+}
+
 type apiSchemaCollection = {
   "POST /ping": {
     Query: undefined;
     Request: undefined;
-    Response: {
-      message: string
-    };
+    Response: PingResponse;
   };
   "GET /ping": {
     Query: undefined;
     Request: undefined;
-    Response: {
-      count: number
-    };
+    Response: PingCounterResponse;
   };
   "GET /ping/nested": {
     Query: undefined;
     Request: undefined;
-    Response: {
-      count: number
-    };
+    Response: PingCounterResponse;
   };
-  "GET /echo": {
+  "POST /echo": {
     Query: undefined;
-    Request: {
-      message: string
-    };
-    Response: {
-      message?: string
-    };
+    Request: EchoRequest;
+    Response: EchoResponse;
   };
   "GET /nested/now": {
     Query: undefined;
     Request: undefined;
-    Response: {
-      now: string
-    };
+    Response: NowResponse;
   };
   "GET /nested/{epoch:[0-9]+}": {
     Query: undefined;
     Request: undefined;
-    Response: {
-      datetime: string
-    };
+    Response: EpochResponse;
+  };
+  /**
+   * taskHandler creates a task.
+   * The statuses of the response contain the status of the request.
+   */
+  "POST /tasks": {
+    Query: undefined;
+    Request: TaskRequest;
+    Response: TaskResponse;
+  };
+  "PUT /settings": {
+    Query: undefined;
+    Request: SettingsRequest;
+    Response: SettingsResponse;
+  };
+  "GET /files/*": {
+    Query: undefined;
+    Request: undefined;
+    Response: FileResponse;
+  };
+  /** getTask returns the task. */
+  "GET /tasks/{id}": {
+    Query: undefined;
+    Request: undefined;
+    Response: TaskRequest2;
+  };
+  /** deleteTask deletes the task. */
+  "DELETE /tasks/{id}": {
+    Query: undefined;
+    Request: undefined;
+    Response: undefined;
+  };
+  "GET /projects/{project_id}/tasks": {
+    Query: ListTasksQuery;
+    Request: undefined;
+    Response: TaskResponse;
+  };
+  "GET /health/live": {
+    Query: undefined;
+    Request: undefined;
+    Response: HealthResponse;
   };
+  "GET /health/ready": {
+    Query: undefined;
+    Request: undefined;
+    Response: HealthResponse;
+  };
+  /** deleteTask deletes the task. */
+  "POST /tasks/{id}/archive": {
+    Query: undefined;
+    Request: undefined;
+    Response: undefined;
+  };
+  /** deleteTask deletes the task. */
+  "POST /tasks/{id}/unarchive": {
+    Query: undefined;
+    Request: undefined;
+    Response: undefined;
+  };
+  "GET /admin/stats": {
+    Query: undefined;
+    Request: undefined;
+    Response: StatsResponse;
+  };
+};
+
+export type errorResponse = { error: { message: string } };
+
+export const isErrorResponse = (response: unknown): response is errorResponse => {
+  return !!((response as { error: unknown })?.error)
+};
+
+export type apiResult<T> =
+  | { ok: true; status: number; data: T }
+  | { ok: false; status: number; error: errorResponse["error"] };
+
+export class ApiError extends Error {
+  readonly status: number;
+  readonly error: errorResponse["error"];
+
+  constructor(status: number, error: errorResponse["error"]) {
+    super(error.message);
+    this.name = "ApiError";
+    this.status = status;
+    this.error = error;
+  }
 }
 
 type method = keyof apiSchemaCollection extends `${infer M} ${string}` ? M : never;
-type methodPathsByMethod<M extends method> = Extract<keyof apiSchemaCollection, `${M} ${string}`>
-type pathByMethod<MP extends string> = MP extends `${method} ${infer P}` ? P : never
-type pathsByMethod<M extends method> = pathByMethod<methodPathsByMethod<M>>
+type methodPathsByMethod<M extends method> = Extract<keyof apiSchemaCollection, `${M} ${string}`>;
+type pathByMethod<MP extends string> = MP extends `${method} ${infer P}` ? P : never;
+type pathsByMethod<M extends method> = pathByMethod<methodPathsByMethod<M>>;
 
 const hasApiRequest = <PM extends keyof apiSchemaCollection>(args: unknown): args is { data: apiSchemaCollection[PM]["Request"] } => {
   return !!(args as { data: unknown })?.data
-}
+};
 
 const hasApiQuery = <PM extends keyof apiSchemaCollection>(args: unknown): args is { query: apiSchemaCollection[PM]["Query"] } => {
   return !!(args as { query: unknown })?.query
-}
+};
 const apiPathBuilder = {
     "/nested/{epoch:[0-9]+}": (args: {epoch: string}) => `/nested/${args.epoch}`,
-} as const
+    "/files/*": (args: {"*": string}) => `/files/${args["*"]}`,
+    "/tasks/{id}": (args: {id: string}) => `/tasks/${args.id}`,
+    "/projects/{project_id}/tasks": (args: {project_id: string}) => `/projects/${args.project_id}/tasks`,
+    "/tasks/{id}/archive": (args: {id: string}) => `/tasks/${args.id}/archive`,
+    "/tasks/{id}/unarchive": (args: {id: string}) => `/tasks/${args.id}/unarchive`,
+} as const;
+
+const hasApiPathBuilder = (path: string): path is keyof typeof apiPathBuilder => path in apiPathBuilder;
+type apiPathBuilderArgs<PM extends keyof apiSchemaCollection> =
+	PM extends `${method} ${infer P}`
+		? P extends keyof typeof apiPathBuilder
+			? apiPathBuilderArgsByPath<P>
+			: never
+		: never;
+type apiPathBuilderArgsByPath<K extends keyof typeof apiPathBuilder> =
+	Parameters<(typeof apiPathBuilder)[K]>[0];
 
-const hasApiPathBuilder = (path: string): path is keyof typeof apiPathBuilder => path in apiPathBuilder
-type apiPathBuilderArgs<K extends keyof apiSchemaCollection> = K extends `${method} ${infer P}` ? (P extends keyof typeof apiPathBuilder ? Parameters<typeof apiPathBuilder[P]>[0] : never) : never
+const pathBuilderByPath = <P extends keyof typeof apiPathBuilder>(
+	path: P,
+): ((args: apiPathBuilderArgsByPath<P>) => string) => {
+	const builder: unknown = apiPathBuilder[path];
+	return builder as (args: apiPathBuilderArgsByPath<P>) => string;
+};
 
 const hasApiPathArgs = <PM extends keyof apiSchemaCollection>(args: unknown): args is { pathArgs: apiPathBuilderArgs<PM> } => {
   return !!(args as { pathArgs: unknown })?.pathArgs
-}
+};
 
 type pathCallArgs<PM extends keyof apiSchemaCollection> =
-  (apiSchemaCollection[PM]["Request"] extends undefined ? {} : { data: apiSchemaCollection[PM]["Request"] }) &
-  (apiPathBuilderArgs<PM> extends never ? {} : { pathArgs: apiPathBuilderArgs<PM> }) &
-  (apiSchemaCollection[PM]["Query"] extends undefined ? {} : { query: apiSchemaCollection[PM]["Query"]})
+  apiSchemaCollection[PM]["Request"] extends undefined
+    ? // if Request is undefined
+      apiSchemaCollection[PM]["Query"] extends undefined
+      ? // if Query is undefined
+        apiPathBuilderArgs<PM> extends never
+        ? Record<string, never>
+        : { pathArgs: apiPathBuilderArgs<PM> }
+      : // if Query is defined
+        apiPathBuilderArgs<PM> extends never
+        ? { query: apiSchemaCollection[PM]["Query"] }
+        : {
+            query: apiSchemaCollection[PM]["Query"];
+            pathArgs: apiPathBuilderArgs<PM>;
+          }
+    : // if Request is defined
+      apiSchemaCollection[PM]["Query"] extends undefined
+      ? // if Query is undefined
+        apiPathBuilderArgs<PM> extends never
+        ? { data: apiSchemaCollection[PM]["Request"] }
+        : {
+            data: apiSchemaCollection[PM]["Request"];
+            pathArgs: apiPathBuilderArgs<PM>;
+          }
+      : // if Query is defined
+        apiPathBuilderArgs<PM> extends never
+        ? {
+            data: apiSchemaCollection[PM]["Request"];
+            query: apiSchemaCollection[PM]["Query"];
+          }
+        : {
+            data: apiSchemaCollection[PM]["Request"];
+            query: apiSchemaCollection[PM]["Query"];
+            pathArgs: apiPathBuilderArgs<PM>;
+          };
 
 type client = {
+  post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiSchemaCollection[`POST ${P}`]["Response"] | errorResponse>
+  get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiSchemaCollection[`GET ${P}`]["Response"] | errorResponse>
+  put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiSchemaCollection[`PUT ${P}`]["Response"] | errorResponse>
+  delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiSchemaCollection[`DELETE ${P}`]["Response"] | errorResponse>
+};
+
+type resultClient = {
+  post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiResult<apiSchemaCollection[`POST ${P}`]["Response"]>>
+  get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiResult<apiSchemaCollection[`GET ${P}`]["Response"]>>
+  put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiResult<apiSchemaCollection[`PUT ${P}`]["Response"]>>
+  delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiResult<apiSchemaCollection[`DELETE ${P}`]["Response"]>>
+};
+
+type throwingClient = {
   post: <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => Promise<apiSchemaCollection[`POST ${P}`]["Response"]>
   get: <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => Promise<apiSchemaCollection[`GET ${P}`]["Response"]>
-}
+  put: <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => Promise<apiSchemaCollection[`PUT ${P}`]["Response"]>
+  delete: <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => Promise<apiSchemaCollection[`DELETE ${P}`]["Response"]>
+};
+
+type myFetcher = (input: string, init: { method: string; headers: Record<string, string>; body: string | undefined; }) => Promise<Response>;
+
+const newRequester = (baseURL: string, myFetch: myFetcher) => async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>): Promise<Response> => {
+  const builtPath = hasApiPathBuilder(path) && hasApiPathArgs(args) ? pathBuilderByPath(path)(args.pathArgs) : path;
+  const query = hasApiQuery(args) ? `?${new URLSearchParams(args.query).toString()}` : "";
+  const body = hasApiRequest(args) ? JSON.stringify(args.data) : undefined;
+  return await myFetch(baseURL + builtPath + query, {
+    method,
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body,
+  });
+};
+
+const newResultFetcher = (baseURL: string, myFetch: myFetcher) => {
+  const request = newRequester(baseURL, myFetch);
+  return async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>): Promise<apiResult<apiSchemaCollection[PM]["Response"]>> => {
+    const response = await request(method, path, args);
+    if (!response.ok) {
+      let error: unknown = undefined;
+      try {
+        error = await response.json();
+      } catch (e) {
+        // the error response is not JSON
+      }
+      return {
+        ok: false,
+        status: response.status,
+        error: isErrorResponse(error) ? error.error : { message: response.statusText },
+      };
+    }
+    const data = (await response.json()) as apiSchemaCollection[PM]["Response"];
+    return { ok: true, status: response.status, data };
+  };
+};
 
-export const newClient = (baseURL: string = ""): client => {
+export const newClient = (baseURL = "", myFetch: myFetcher = fetch): client => {
+  const request = newRequester(baseURL, myFetch);
   const fetchByPath = async <PM extends keyof apiSchemaCollection>(method: method, path: string, args: pathCallArgs<PM>) => {
-    const builtPath = hasApiPathBuilder(path) && hasApiPathArgs(args) ? apiPathBuilder[path](args.pathArgs) : path
-    const query = hasApiQuery(args) ? "?" + (new URLSearchParams(args.query).toString()) : ""
-    const body = hasApiRequest(args) ? JSON.stringify(args.data) : undefined
-    const response = await fetch(baseURL + builtPath + query, {
-      method,
-      headers: {
-        "Content-Type": "application/json",
-      },
-      body,
-    })
+    const response = await request(method, path, args);
 
     if (!response.ok) {
-      throw new Error(response.statusText)
+      try {
+        const error = await response.json();
+        return error;
+      } catch (e) {
+        throw new Error(response.statusText);
+      }
     }
-    return response.json() as Promise<apiSchemaCollection[PM]["Response"]>
+    return response.json() as Promise<apiSchemaCollection[PM]["Response"]>;
   }
-  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath("POST", path, args)
-  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath("GET", path, args)
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath("POST", path, args);
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath("GET", path, args);
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await fetchByPath("PUT", path, args);
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await fetchByPath("DELETE", path, args);
 
   return {
     post,
     get,
-  }
-}
+    put,
+    delete: _delete,
+  };
+};
+
+// newResultClient returns the client that resolves to the result union instead of throwing on the non-2xx responses.
+export const newResultClient = (baseURL = "", myFetch: myFetcher = fetch): resultClient => {
+  const fetchByPath = newResultFetcher(baseURL, myFetch);
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await fetchByPath<`POST ${P}`>("POST", path, args);
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await fetchByPath<`GET ${P}`>("GET", path, args);
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await fetchByPath<`PUT ${P}`>("PUT", path, args);
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await fetchByPath<`DELETE ${P}`>("DELETE", path, args);
+
+  return {
+    post,
+    get,
+    put,
+    delete: _delete,
+  };
+};
+
+// newThrowingClient returns the client that resolves to the response data and throws ApiError on the non-2xx responses.
+export const newThrowingClient = (baseURL = "", myFetch: myFetcher = fetch): throwingClient => {
+  const fetchByPath = newResultFetcher(baseURL, myFetch);
+  const unwrap = async <T,>(result: Promise<apiResult<T>>): Promise<T> => {
+    const r = await result;
+    if (!r.ok) {
+      throw new ApiError(r.status, r.error);
+    }
+    return r.data;
+  };
+  const post = async <P extends pathsByMethod<"POST">>(path: P, args: pathCallArgs<`POST ${P}`>) => await unwrap(fetchByPath<`POST ${P}`>("POST", path, args));
+  const get = async <P extends pathsByMethod<"GET">>(path: P, args: pathCallArgs<`GET ${P}`>) => await unwrap(fetchByPath<`GET ${P}`>("GET", path, args));
+  const put = async <P extends pathsByMethod<"PUT">>(path: P, args: pathCallArgs<`PUT ${P}`>) => await unwrap(fetchByPath<`PUT ${P}`>("PUT", path, args));
+  const _delete = async <P extends pathsByMethod<"DELETE">>(path: P, args: pathCallArgs<`DELETE ${P}`>) => await unwrap(fetchByPath<`DELETE ${P}`>("DELETE", path, args));
+
+  return {
+    post,
+    get,
+    put,
+    delete: _delete,
+  };
+};
@@ -1,6 +1,10 @@
 package gendoctest
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/mackee/tanukirpc"
@@ -21,7 +25,7 @@ func testGendoc() {
 	type echoResponse struct {
 		Message *string `json:"message"`
 	}
-	router.Get(
+	router.Post(
 		echoPath,
 		tanukirpc.NewHandler(
 			func(ctx tanukirpc.Context[struct{}], req *echoRequest) (*echoResponse, error) {
@@ -40,8 +44,39 @@ func testGendoc() {
 		))
 		r.Get("/{epoch:[0-9]+}", tanukirpc.NewHandler(epochHandler))
 	})
+	router.Post("/tasks", tanukirpc.NewHandler(taskHandler))
+	router.Put("/settings", tanukirpc.NewHandler(settingsHandler))
+	router.Get("/files/*", tanukirpc.NewHandler(fileHandler))
+
+	ts := &taskServer{}
+	router.Get("/tasks/{id}", tanukirpc.NewHandler(ts.getTask))
+	router.Delete("/tasks/{id}", deleteTaskHandler)
+	listTasks := newListTasksHandler()
+	router.Route("/projects/{project_id}", func(r *tanukirpc.Router[struct{}]) {
+		r.Get("/tasks", listTasks)
+	})
+	for _, r := range []struct {
+		path    string
+		handler tanukirpc.Handler[struct{}]
+	}{
+		{path: "/health/live", handler: tanukirpc.NewHandler(healthHandler)},
+		{path: "/health/ready", handler: tanukirpc.NewHandler(healthHandler)},
+	} {
+		router.Get(r.path, r.handler)
+	}
+	for _, r := range archiveRoutes {
+		router.Post(r.path, r.handler)
+	}
+
+	admin := tanukirpc.NewRouter(struct{}{})
+	admin.Get("/stats", tanukirpc.NewHandler(statsHandler))
+	router.Mount("/admin", admin)
+	router.Mount("/static", http.FileServer(http.Dir("./static")))
+	router.NotFound(tanukirpc.NewHandler(notFoundHandler))
 
 	genclient.AnalyzeTarget(router)
+
+	_ = router.ListenAndServe(context.Background(), ":8080", tanukirpc.WithDebugEndpoints("/debug/"))
 }
 
 type exampleTestRouterRegistry struct {
@@ -92,3 +127,122 @@ type epochResponse struct {
 func epochHandler(ctx tanukirpc.Context[struct{}], req *epochRequest) (*epochResponse, error) {
 	return &epochResponse{Datetime: time.Unix(req.Epoch, 0).String()}, nil
 }
+
+type fileRequest struct {
+	Path string `urlparam:"*"`
+}
+type fileResponse struct {
+	Path string `json:"path"`
+}
+
+func fileHandler(ctx tanukirpc.Context[struct{}], req *fileRequest) (*fileResponse, error) {
+	return &fileResponse{Path: req.Path}, nil
+}
+
+type taskStatus string
+
+const (
+	taskStatusTodo  taskStatus = "todo"
+	taskStatusDoing taskStatus = "doing"
+	taskStatusDone  taskStatus = "done"
+)
+
+type taskPriority int
+
+const (
+	taskPriorityLow taskPriority = iota
+	taskPriorityHigh
+)
+
+type taskRequest struct {
+	Title    string       `json:"title" validate:"required,min=1,max=100"`
+	Status   taskStatus   `json:"status" validate:"required"`
+	Priority taskPriority `json:"priority"`
+	Note     taskStatus   `json:"note" tsenum:"false"`
+}
+
+type taskResponse struct {
+	Statuses []taskStatus `json:"statuses"`
+}
+
+// taskHandler creates a task.
+// The statuses of the response contain the status of the request.
+func taskHandler(ctx tanukirpc.Context[struct{}], req *taskRequest) (*taskResponse, error) {
+	return &taskResponse{Statuses: []taskStatus{taskStatusTodo, taskStatusDoing, taskStatusDone, req.Status}}, nil
+}
+
+type settingsRequest struct {
+	// Labels is attached to the settings.
+	Labels  map[string]string `json:"labels"`
+	Extra   json.RawMessage   `json:"extra"`
+	Timeout time.Duration     `json:"timeout"` // in nanoseconds
+	Tags    *[]string         `json:"tags"`
+}
+
+type settingsResponse struct {
+	Limits    map[string][]int `json:"limits"`
+	Matrix    [][]taskStatus   `json:"matrix"`
+	UpdatedAt *time.Time       `json:"updated_at"`
+}
+
+func settingsHandler(ctx tanukirpc.Context[struct{}], req *settingsRequest) (*settingsResponse, error) {
+	return &settingsResponse{Limits: map[string][]int{"tags": {len(*req.Tags)}}}, nil
+}
+
+type taskServer struct{}
+
+type getTaskRequest struct {
+	ID string `urlparam:"id"`
+}
+
+// getTask returns the task.
+func (s *taskServer) getTask(ctx tanukirpc.Context[struct{}], req getTaskRequest) (*taskRequest, error) {
+	return &taskRequest{Title: req.ID}, nil
+}
+
+var deleteTaskHandler = tanukirpc.NewHandler(deleteTask)
+
+// deleteTask deletes the task.
+func deleteTask(ctx tanukirpc.Context[struct{}], req getTaskRequest) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+type listTasksQuery struct {
+	Status taskStatus `query:"status"`
+}
+
+func newListTasksHandler() tanukirpc.Handler[struct{}] {
+	return tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req listTasksQuery) (*taskResponse, error) {
+		return &taskResponse{Statuses: []taskStatus{req.Status}}, nil
+	})
+}
+
+type healthResponse struct {
+	OK bool `json:"ok"`
+}
+
+func healthHandler(ctx tanukirpc.Context[struct{}], _ struct{}) (*healthResponse, error) {
+	return &healthResponse{OK: true}, nil
+}
+
+type archiveRoute struct {
+	path    string
+	handler tanukirpc.Handler[struct{}]
+}
+
+var archiveRoutes = []*archiveRoute{
+	{path: "/tasks/{id}/archive", handler: tanukirpc.NewHandler(deleteTask)},
+	{path: "/tasks/{id}/unarchive", handler: tanukirpc.NewHandler(deleteTask)},
+}
+
+type statsResponse struct {
+	Tasks int `json:"tasks"`
+}
+
+func statsHandler(ctx tanukirpc.Context[struct{}], _ struct{}) (*statsResponse, error) {
+	return &statsResponse{Tasks: 0}, nil
+}
+
+func notFoundHandler(ctx tanukirpc.Context[struct{}], _ struct{}) (*struct{}, error) {
+	return nil, tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("not found"))
+}
@@ -0,0 +1,52 @@
+package linttest
+
+import (
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/genclient"
+	"github.com/mackee/tanukirpc/tenant"
+)
+
+type userRequest struct {
+	ID string `urlparam:"id"`
+}
+
+type commentRequest struct {
+	UserID    string `urlparam:"user_id"`
+	CommentID string `urlparam:"comment_id"`
+}
+
+type searchRequest struct {
+	Keyword string `json:"keyword"`
+}
+
+func search(ctx tanukirpc.Context[struct{}], req searchRequest) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+func getUser(ctx tanukirpc.Context[struct{}], req userRequest) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+func getComment(ctx tanukirpc.Context[struct{}], req commentRequest) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+func tenantRegistry(ctx tanukirpc.Context[struct{}], id string) (struct{}, error) {
+	return struct{}{}, nil
+}
+
+func register(router *tanukirpc.Router[struct{}]) {
+	router.Get("/users/{id}", tanukirpc.NewHandler(getUser))
+	router.Get("/users/{id}", tanukirpc.NewHandler(getUser))   // want `duplicate route: GET /users/\{id\} is already registered`
+	router.Get("/users/{name}", tanukirpc.NewHandler(getUser)) // want `conflicting route: GET /users/\{name\} overlaps GET /users/\{id\}` `urlparam "id" is not in the route pattern /users/\{name\}`
+	router.Post("/users/{id}", tanukirpc.NewHandler(getUser))
+	router.Get("/users/{id}/comments/{comment_id}", tanukirpc.NewHandler(getComment)) // want `urlparam "user_id" is not in the route pattern`
+	router.Get("/users/{id}/posts/{id}", tanukirpc.NewHandler(getUser))               // want `duplicate param key "id"`
+	router.Get("/files/*/raw", tanukirpc.NewHandler(getUser))                         // want `wildcard '\*' must be the last value`
+	router.Get("/files/{path:.*}", tanukirpc.NewHandler(getUser))                     // want `urlparam "id" is not in the route pattern`
+	tanukirpc.RouteWithTransformer(router, tenant.NewTransformer(tenant.FromPathParam("tenant"), tenantRegistry), "/orgs/{org}", func(r *tanukirpc.Router[struct{}]) {
+		r.Post("/search", tanukirpc.NewHandler(search)) // want `tenant path param "tenant" is not in the route pattern /orgs/\{org\}/search`
+	})
+
+	genclient.AnalyzeTarget(router)
+}
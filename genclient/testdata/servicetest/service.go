@@ -0,0 +1,96 @@
+package servicetest
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/genclient"
+	"github.com/mackee/tanukirpc/tenant"
+)
+
+type AddTaskRequest struct {
+	Title string `json:"title"`
+}
+
+type AddTaskResponse struct {
+	ID int `json:"id"`
+}
+
+type ListTasksRequest struct{}
+
+type ListTasksResponse struct {
+	IDs []int `json:"ids"`
+}
+
+type ShowProjectRequest struct {
+	ID int `urlparam:"project_id"`
+}
+
+type TaskService interface {
+	// AddTask adds a task.
+	AddTask(ctx tanukirpc.Context[struct{}], req *AddTaskRequest) (*AddTaskResponse, error)
+	Close() error
+}
+
+type taskService struct{}
+
+func (s *taskService) AddTask(ctx tanukirpc.Context[struct{}], req *AddTaskRequest) (*AddTaskResponse, error) {
+	return &AddTaskResponse{ID: 1}, nil
+}
+
+func (s *taskService) Close() error {
+	return nil
+}
+
+func setup() {
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Route("/rpc", func(r *tanukirpc.Router[struct{}]) {
+		if err := tanukirpc.RegisterService[TaskService](r, &taskService{}); err != nil {
+			panic(err)
+		}
+	})
+	r.Get("/v1/tasks", tanukirpc.Deprecated(
+		tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req ListTasksRequest) (*ListTasksResponse, error) {
+			return &ListTasksResponse{}, nil
+		}),
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		"https://example.com/migrate",
+	))
+	tr := tenant.NewTransformer(tenant.FromHeader("X-Tenant-ID"), func(ctx tanukirpc.Context[struct{}], id string) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	tanukirpc.RouteWithTransformer(r, tr, "/tenant", func(r *tanukirpc.Router[struct{}]) {
+		r.Post("/tasks", tanukirpc.Authorize(
+			tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *AddTaskRequest) (*AddTaskResponse, error) {
+				return &AddTaskResponse{ID: 1}, nil
+			}),
+			tanukirpc.Policy[struct{}]("tasks:write"),
+		))
+	})
+	r.Get("/legacy/*", tanukirpc.NewProxyHandler(func(ctx tanukirpc.Context[struct{}]) (*url.URL, error) {
+		return url.Parse("http://legacy.example.com")
+	}))
+	r.Post("/tasks/batch", tanukirpc.NewBatchHandler(func(ctx tanukirpc.Context[struct{}], req AddTaskRequest) (*AddTaskResponse, error) {
+		return &AddTaskResponse{ID: 1}, nil
+	}))
+	r.Resource("/projects", tanukirpc.Resource[struct{}]{
+		Index: tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req ListTasksRequest) (*ListTasksResponse, error) {
+			return &ListTasksResponse{}, nil
+		}),
+		Show: tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *ShowProjectRequest) (*AddTaskResponse, error) {
+			return &AddTaskResponse{ID: 1}, nil
+		}),
+		IDParam: "project_id",
+	})
+	r.Get("/v2/tasks", tanukirpc.Annotate(
+		tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req ListTasksRequest) (*ListTasksResponse, error) {
+			return &ListTasksResponse{}, nil
+		}),
+		tanukirpc.WithRouteName("listTasks"),
+		tanukirpc.WithRouteTags("tasks", "v2"),
+		tanukirpc.WithRouteScopes("tasks:read"),
+		tanukirpc.WithRouteDeprecation(time.Time{}, ""),
+	))
+	genclient.AnalyzeTarget(r)
+}
@@ -0,0 +1,17 @@
+package unresolvedtest
+
+import (
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/genclient"
+)
+
+func hello(ctx tanukirpc.Context[struct{}], _ struct{}) (*struct{}, error) {
+	return &struct{}{}, nil
+}
+
+func register(router *tanukirpc.Router[struct{}], path string, h tanukirpc.Handler[struct{}]) {
+	router.Get(path, tanukirpc.NewHandler(hello)) // want "unresolvable route registration"
+	router.Get("/hello", h)                       // want "invalid handler argument"
+
+	genclient.AnalyzeTarget(router)
+}
@@ -4,18 +4,24 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"go/constant"
 	"go/types"
-	"io"
 	"os"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/mackee/tanukirpc/internal/keycase"
 	"golang.org/x/tools/go/analysis"
 )
 
-var jsonStringMarshalerWhitelist = map[string]struct{}{
-	"time.Time": {},
+// jsonStringMarshalerWhitelist is the TypeScript types of the types that implement json.Marshaler.
+// It can be extended by the -marshaler-whitelist flag.
+var jsonStringMarshalerWhitelist = map[string]string{
+	"time.Time": "string",
 }
 
 //go:embed typescriptclient.tmpl
@@ -31,10 +37,24 @@ var TypeScriptClientGenerator = &analysis.Analyzer{
 	ResultType: reflect.TypeOf((*bytes.Buffer)(nil)),
 }
 
-var typeScriptClientOutPath string
+var (
+	typeScriptClientOutPath       string
+	typeScriptClientEmitFactories bool
+	typeScriptClientEmitZod       bool
+	typeScriptClientMarshalers    string
+	typeScriptClientDurationType  string
+	typeScriptClientEnvelopeKey   string
+	typeScriptClientKeyCase       string
+)
 
 func init() {
 	TypeScriptClientGenerator.Flags.StringVar(&typeScriptClientOutPath, "out", "", "output file path")
+	TypeScriptClientGenerator.Flags.BoolVar(&typeScriptClientEmitZod, "emit-zod", false, "emit the zod schemas of the requests and the responses")
+	TypeScriptClientGenerator.Flags.BoolVar(&typeScriptClientEmitFactories, "client-factory", false, "emit the client factories that accept baseURL, fetch, default headers and interceptors")
+	TypeScriptClientGenerator.Flags.StringVar(&typeScriptClientMarshalers, "marshaler-whitelist", "", "file that lists the types implementing json.Marshaler and their TypeScript types")
+	TypeScriptClientGenerator.Flags.StringVar(&typeScriptClientDurationType, "duration", "number", "TypeScript type of time.Duration: number or string")
+	TypeScriptClientGenerator.Flags.StringVar(&typeScriptClientEnvelopeKey, "envelope", "", "key of the data in the response envelope of tanukirpc.WithResponseEnvelope like data. The responses are unwrapped by it")
+	TypeScriptClientGenerator.Flags.StringVar(&typeScriptClientKeyCase, "key-case", "", "case of the JSON keys of tanukirpc.WithJSONKeyCase: camel or snake. The json tags are used as they are if it is empty")
 }
 
 func generateTypeScriptClient(pass *analysis.Pass) (any, error) {
@@ -43,42 +63,112 @@ func generateTypeScriptClient(pass *analysis.Pass) (any, error) {
 		return &bytes.Buffer{}, nil
 	}
 
-	gen, err := newTypeScriptClientGenerator()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create TypeScript client generator: %w", err)
-	}
-	if err := gen.generate(result.RoutePaths); err != nil {
-		return nil, fmt.Errorf("failed to generate TypeScript client code: %w", err)
+	opts := &TypeScriptOptions{
+		EmitZod:       typeScriptClientEmitZod,
+		ClientFactory: typeScriptClientEmitFactories,
+		DurationType:  typeScriptClientDurationType,
+		EnvelopeKey:   typeScriptClientEnvelopeKey,
+		KeyCase:       typeScriptClientKeyCase,
 	}
-	if typeScriptClientOutPath != "" {
-		f, err := os.Create(typeScriptClientOutPath)
+	if typeScriptClientMarshalers != "" {
+		marshalers, err := LoadMarshalerWhitelist(typeScriptClientMarshalers)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create output file: %w", err)
+			return nil, err
 		}
-		if _, err := io.Copy(f, gen.rw); err != nil {
+		opts.Marshalers = marshalers
+	}
+	b, err := GenerateTypeScript(result, opts)
+	if err != nil {
+		return nil, err
+	}
+	if typeScriptClientOutPath != "" {
+		if err := os.WriteFile(typeScriptClientOutPath, b, 0o644); err != nil {
 			return nil, fmt.Errorf("failed to write output file: %w", err)
 		}
 	}
 
-	return gen.rw, nil
+	return bytes.NewBuffer(b), nil
+}
+
+// TypeScriptOptions is the options of GenerateTypeScript.
+// The fields correspond to the flags of TypeScriptClientGenerator.
+type TypeScriptOptions struct {
+	// EmitZod emits the zod schemas of the requests and the responses.
+	EmitZod bool
+	// ClientFactory emits the client factories that accept baseURL, fetch, default headers and interceptors.
+	ClientFactory bool
+	// Marshalers maps the full names of the types implementing json.Marshaler to their TypeScript types.
+	Marshalers map[string]string
+	// DurationType is the TypeScript type of time.Duration: "number" or "string". The default is "number".
+	DurationType string
+	// EnvelopeKey is the key of the data in the response envelope of tanukirpc.WithResponseEnvelope like "data".
+	// The successful responses are unwrapped by it. The responses are not unwrapped if it is empty.
+	EnvelopeKey string
+	// KeyCase is the case of the JSON keys of tanukirpc.WithJSONKeyCase: "camel" or "snake".
+	// The names of the json tags are converted to it. They are used as they are if it is empty.
+	KeyCase string
+}
+
+// GenerateTypeScript generates the TypeScript client code of the routes in the result.
+// opts may be nil.
+func GenerateTypeScript(result *AnalyzerResult, opts *TypeScriptOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &TypeScriptOptions{}
+	}
+	switch opts.DurationType {
+	case "":
+		o := *opts
+		o.DurationType = "number"
+		opts = &o
+	case "number", "string":
+	default:
+		return nil, fmt.Errorf("invalid duration type: %s", opts.DurationType)
+	}
+	switch opts.KeyCase {
+	case "", "camel", "snake":
+	default:
+		return nil, fmt.Errorf("invalid key case: %s", opts.KeyCase)
+	}
+
+	gen, err := newTypeScriptClientGenerator(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TypeScript client generator: %w", err)
+	}
+	gen.fieldDoc = result.FieldDoc
+	if err := gen.generate(result.RoutePaths); err != nil {
+		return nil, fmt.Errorf("failed to generate TypeScript client code: %w", err)
+	}
+	return gen.rw.Bytes(), nil
 }
 
 type typeScriptClientGenerator struct {
-	rw   *bytes.Buffer
-	tmpl *template.Template
+	rw       *bytes.Buffer
+	tmpl     *template.Template
+	opts     *TypeScriptOptions
+	fieldDoc func(*types.Var) string
+	// namedTypes is nil when the named types are inlined
+	namedTypes *typeScriptClientGeneratorNamedTypes
 }
 
-func newTypeScriptClientGenerator() (*typeScriptClientGenerator, error) {
-	tmpl, err := template.ParseFS(typeScriptClientTemplate, "typescriptclient.tmpl")
+func newTypeScriptClientGenerator(opts *TypeScriptOptions) (*typeScriptClientGenerator, error) {
+	gen := &typeScriptClientGenerator{
+		rw:         &bytes.Buffer{},
+		opts:       opts,
+		namedTypes: newTypeScriptClientGeneratorNamedTypes(),
+	}
+	funcs := template.FuncMap{
+		"emitClientFactory": func() bool { return opts.ClientFactory },
+		"emitZod":           func() bool { return opts.EmitZod },
+		"envelopeKey":       func() string { return opts.EnvelopeKey },
+		"namedTypes":        func() []*typeScriptClientGeneratorNamedTypeDecl { return gen.namedTypes.decls },
+	}
+	tmpl, err := template.New("typescriptclient.tmpl").Funcs(funcs).ParseFS(typeScriptClientTemplate, "typescriptclient.tmpl")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	gen.tmpl = tmpl
 
-	buf := &bytes.Buffer{}
-	return &typeScriptClientGenerator{
-		rw:   buf,
-		tmpl: tmpl,
-	}, nil
+	return gen, nil
 }
 
 func (t *typeScriptClientGenerator) generate(routes []RoutePath) error {
@@ -86,8 +176,14 @@ func (t *typeScriptClientGenerator) generate(routes []RoutePath) error {
 	for _, r := range routes {
 		h := r.Handler()
 		mp := &typeScriptClientGeneratorTemplateArgsMethodPath{
-			Method: r.Method(),
-			Path:   r.Path(),
+			Method:     r.Method(),
+			Path:       r.Path(),
+			Doc:        h.Doc(),
+			Deprecated: h.Deprecated(),
+		}
+		if annotations := h.Annotations(); annotations != nil {
+			mp.Name = annotations.Name
+			mp.Tags = annotations.Tags
 		}
 
 		// query of request
@@ -113,6 +209,12 @@ func (t *typeScriptClientGenerator) generate(routes []RoutePath) error {
 
 		templateArgs = append(templateArgs, mp)
 	}
+	// resolve the names of the interfaces to declare them before apiSchemaCollection
+	for _, mp := range templateArgs {
+		mp.Query.RenderRequest("")
+		mp.Request.RenderRequest("")
+		mp.Response.RenderResponse("")
+	}
 	if err := t.tmpl.Execute(t.rw, templateArgs); err != nil {
 		return fmt.Errorf("failed to execute template: %w", err)
 	}
@@ -122,6 +224,8 @@ func (t *typeScriptClientGenerator) generate(routes []RoutePath) error {
 type typeScriptClientGeneratorField interface {
 	RenderRequest(prefix string) string
 	RenderResponse(prefix string) string
+	RenderZodRequest(prefix string) string
+	RenderZodResponse(prefix string) string
 }
 
 type typeScriptClientGeneratorObjectField struct {
@@ -146,12 +250,32 @@ func (t *typeScriptClientGeneratorObjectField) RenderResponse(prefix string) str
 	return ret
 }
 
+func (t *typeScriptClientGeneratorObjectField) RenderZodRequest(prefix string) string {
+	ret := "z.object({\n"
+	for _, field := range t.fields {
+		ret += field.RenderZodRequest(prefix+"  ") + "\n"
+	}
+	ret += prefix + "})"
+	return ret
+}
+
+func (t *typeScriptClientGeneratorObjectField) RenderZodResponse(prefix string) string {
+	ret := "z.object({\n"
+	for _, field := range t.fields {
+		ret += field.RenderZodResponse(prefix+"  ") + "\n"
+	}
+	ret += prefix + "})"
+	return ret
+}
+
 type typeScriptClientGeneratorGenericField struct {
 	name       string
 	typedef    typeScriptClientGeneratorField
 	isSlice    bool
 	isRequired bool
 	isOption   bool
+	validate   string
+	doc        string
 }
 
 func (t *typeScriptClientGeneratorGenericField) sliceSuffix() string {
@@ -175,12 +299,83 @@ func (t *typeScriptClientGeneratorGenericField) isRequiredOpResponse() string {
 	return ""
 }
 
+func (t *typeScriptClientGeneratorGenericField) docComment(prefix string) string {
+	if t.doc == "" {
+		return ""
+	}
+	return jsDoc(prefix, t.doc) + "\n"
+}
+
 func (t *typeScriptClientGeneratorGenericField) RenderRequest(prefix string) string {
-	return fmt.Sprintf("%s%s%s: %s%s;", prefix, t.name, t.isRequiredOpRequest(), t.typedef.RenderRequest(prefix), t.sliceSuffix())
+	return t.docComment(prefix) + fmt.Sprintf("%s%s%s: %s%s;", prefix, t.name, t.isRequiredOpRequest(), t.elemType(t.typedef.RenderRequest(prefix)), t.sliceSuffix())
 }
 
 func (t *typeScriptClientGeneratorGenericField) RenderResponse(prefix string) string {
-	return fmt.Sprintf("%s%s%s: %s%s;", prefix, t.name, t.isRequiredOpResponse(), t.typedef.RenderResponse(prefix), t.sliceSuffix())
+	return t.docComment(prefix) + fmt.Sprintf("%s%s%s: %s%s;", prefix, t.name, t.isRequiredOpResponse(), t.elemType(t.typedef.RenderResponse(prefix)), t.sliceSuffix())
+}
+
+// elemType wraps the union type with parentheses to be the element of the array.
+func (t *typeScriptClientGeneratorGenericField) elemType(typedef string) string {
+	if !t.isSlice {
+		return typedef
+	}
+	return arrayElemType(t.typedef, typedef)
+}
+
+func arrayElemType(elem typeScriptClientGeneratorField, typedef string) string {
+	if _, ok := elem.(typeScriptClientGeneratorEnumType); ok && strings.Contains(typedef, " | ") {
+		return "(" + typedef + ")"
+	}
+	return typedef
+}
+
+func (t *typeScriptClientGeneratorGenericField) RenderZodRequest(prefix string) string {
+	schema := t.zodSchema(t.typedef.RenderZodRequest(prefix))
+	if !t.isRequired {
+		schema += ".optional()"
+	}
+	return fmt.Sprintf("%s%s: %s,", prefix, t.name, schema)
+}
+
+func (t *typeScriptClientGeneratorGenericField) RenderZodResponse(prefix string) string {
+	schema := t.zodSchema(t.typedef.RenderZodResponse(prefix))
+	if !t.isRequired && t.isOption {
+		// the nil pointer is encoded as null without omitempty
+		schema += ".nullish()"
+	}
+	return fmt.Sprintf("%s%s: %s,", prefix, t.name, schema)
+}
+
+// zodSchema applies the validate rules to the schema of the field.
+// The rules that are not able to be expressed in zod are ignored.
+func (t *typeScriptClientGeneratorGenericField) zodSchema(schema string) string {
+	isNumber := t.typedef == typeScriptClientGeneratorLiteralType("number") && !t.isSlice
+	if t.isSlice {
+		schema = "z.array(" + schema + ")"
+	}
+	for _, rule := range strings.Split(t.validate, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max", "len":
+			method := map[string]string{"min": "min", "max": "max", "len": "length"}[name]
+			if isNumber && name == "len" {
+				continue
+			}
+			schema += fmt.Sprintf(".%s(%s)", method, param)
+		case "gt", "gte", "lt", "lte":
+			if isNumber {
+				schema += fmt.Sprintf(".%s(%s)", name, param)
+			}
+		case "email", "url", "uuid":
+			if t.typedef == typeScriptClientGeneratorLiteralType("string") && !t.isSlice {
+				schema += fmt.Sprintf(".%s()", name)
+			}
+		case "dive":
+			// the rules after dive are applied to the elements
+			return schema
+		}
+	}
+	return schema
 }
 
 type typeScriptClientGeneratorLiteralType string
@@ -193,6 +388,232 @@ func (t typeScriptClientGeneratorLiteralType) RenderResponse(prefix string) stri
 	return string(t)
 }
 
+func (t typeScriptClientGeneratorLiteralType) RenderZodRequest(prefix string) string {
+	switch t {
+	case "string", "number", "boolean", "unknown":
+		return fmt.Sprintf("z.%s()", string(t))
+	}
+	// the type that is specified by the tstype struct tag
+	return fmt.Sprintf("z.custom<%s>()", string(t))
+}
+
+func (t typeScriptClientGeneratorLiteralType) RenderZodResponse(prefix string) string {
+	return t.RenderZodRequest(prefix)
+}
+
+// typeScriptClientGeneratorEnumType is the union of the literal types that is generated from the constants of the named type.
+type typeScriptClientGeneratorEnumType []string
+
+func (t typeScriptClientGeneratorEnumType) RenderRequest(prefix string) string {
+	return strings.Join(t, " | ")
+}
+
+func (t typeScriptClientGeneratorEnumType) RenderResponse(prefix string) string {
+	return t.RenderRequest(prefix)
+}
+
+func (t typeScriptClientGeneratorEnumType) RenderZodRequest(prefix string) string {
+	if len(t) == 1 {
+		return fmt.Sprintf("z.literal(%s)", t[0])
+	}
+	literals := make([]string, 0, len(t))
+	for _, v := range t {
+		literals = append(literals, fmt.Sprintf("z.literal(%s)", v))
+	}
+	return fmt.Sprintf("z.union([%s])", strings.Join(literals, ", "))
+}
+
+func (t typeScriptClientGeneratorEnumType) RenderZodResponse(prefix string) string {
+	return t.RenderZodRequest(prefix)
+}
+
+// typeScriptClientGeneratorArrayType is the array type of the nested slices.
+type typeScriptClientGeneratorArrayType struct {
+	elem typeScriptClientGeneratorField
+}
+
+func (t *typeScriptClientGeneratorArrayType) RenderRequest(prefix string) string {
+	return arrayElemType(t.elem, t.elem.RenderRequest(prefix)) + "[]"
+}
+
+func (t *typeScriptClientGeneratorArrayType) RenderResponse(prefix string) string {
+	return arrayElemType(t.elem, t.elem.RenderResponse(prefix)) + "[]"
+}
+
+func (t *typeScriptClientGeneratorArrayType) RenderZodRequest(prefix string) string {
+	return "z.array(" + t.elem.RenderZodRequest(prefix) + ")"
+}
+
+func (t *typeScriptClientGeneratorArrayType) RenderZodResponse(prefix string) string {
+	return "z.array(" + t.elem.RenderZodResponse(prefix) + ")"
+}
+
+// typeScriptClientGeneratorRecordType is the record type of the map.
+type typeScriptClientGeneratorRecordType struct {
+	elem typeScriptClientGeneratorField
+}
+
+func (t *typeScriptClientGeneratorRecordType) RenderRequest(prefix string) string {
+	return "Record<string, " + t.elem.RenderRequest(prefix) + ">"
+}
+
+func (t *typeScriptClientGeneratorRecordType) RenderResponse(prefix string) string {
+	return "Record<string, " + t.elem.RenderResponse(prefix) + ">"
+}
+
+func (t *typeScriptClientGeneratorRecordType) RenderZodRequest(prefix string) string {
+	return "z.record(z.string(), " + t.elem.RenderZodRequest(prefix) + ")"
+}
+
+func (t *typeScriptClientGeneratorRecordType) RenderZodResponse(prefix string) string {
+	return "z.record(z.string(), " + t.elem.RenderZodResponse(prefix) + ")"
+}
+
+// enumValues returns the values of the constants that are declared with the named type in the same package.
+// The values are ordered by the declaration.
+func enumValues(named *types.Named) typeScriptClientGeneratorEnumType {
+	pkg := named.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+	bt, ok := named.Underlying().(*types.Basic)
+	if !ok || bt.Info()&(types.IsString|types.IsInteger) == 0 {
+		return nil
+	}
+	consts := make([]*types.Const, 0)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !types.Identical(c.Type(), named) {
+			continue
+		}
+		consts = append(consts, c)
+	}
+	sort.SliceStable(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+
+	values := make(typeScriptClientGeneratorEnumType, 0, len(consts))
+	seen := make(map[string]struct{}, len(consts))
+	for _, c := range consts {
+		v := c.Val().ExactString()
+		if c.Val().Kind() == constant.String {
+			v = strconv.Quote(constant.StringVal(c.Val()))
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	return values
+}
+
+// typeScriptClientGeneratorNamedTypes collects the interfaces that are generated from the named struct types.
+// The same shape is declared once, and the different shapes with the same name are numbered.
+type typeScriptClientGeneratorNamedTypes struct {
+	refs  map[typeScriptClientGeneratorNamedTypeKey]*typeScriptClientGeneratorNamedRef
+	decls []*typeScriptClientGeneratorNamedTypeDecl
+}
+
+type typeScriptClientGeneratorNamedTypeKey struct {
+	named     *types.Named
+	tagFilter string
+}
+
+type typeScriptClientGeneratorNamedTypeDecl struct {
+	Name string
+	Body string
+}
+
+// typeScriptClientGeneratorReservedNames is the names that are declared by the template.
+var typeScriptClientGeneratorReservedNames = map[string]struct{}{
+	"ApiError": {},
+}
+
+func newTypeScriptClientGeneratorNamedTypes() *typeScriptClientGeneratorNamedTypes {
+	return &typeScriptClientGeneratorNamedTypes{
+		refs: make(map[typeScriptClientGeneratorNamedTypeKey]*typeScriptClientGeneratorNamedRef),
+	}
+}
+
+// namedRef returns the reference to the interface of the named type if the field is an object.
+func (t *typeScriptClientGenerator) namedRef(named *types.Named, tagFilter string, field typeScriptClientGeneratorField) typeScriptClientGeneratorField {
+	object, ok := field.(*typeScriptClientGeneratorObjectField)
+	if !ok || t.namedTypes == nil || len(object.fields) == 0 {
+		return field
+	}
+	key := typeScriptClientGeneratorNamedTypeKey{named: named, tagFilter: tagFilter}
+	if ref, ok := t.namedTypes.refs[key]; ok {
+		return ref
+	}
+	name := named.Obj().Name()
+	name = strings.ToUpper(name[:1]) + name[1:]
+	if tagFilter == "query" && !strings.HasSuffix(name, "Query") {
+		name += "Query"
+	}
+	ref := &typeScriptClientGeneratorNamedRef{
+		namedTypes: t.namedTypes,
+		baseName:   name,
+		object:     object,
+	}
+	t.namedTypes.refs[key] = ref
+	return ref
+}
+
+// declare returns the name of the interface that has the body.
+func (t *typeScriptClientGeneratorNamedTypes) declare(baseName, body string) string {
+	for i := 1; ; i++ {
+		name := baseName
+		if i > 1 {
+			name += strconv.Itoa(i)
+		}
+		if _, ok := typeScriptClientGeneratorReservedNames[name]; ok {
+			continue
+		}
+		idx := slices.IndexFunc(t.decls, func(d *typeScriptClientGeneratorNamedTypeDecl) bool { return d.Name == name })
+		if idx < 0 {
+			t.decls = append(t.decls, &typeScriptClientGeneratorNamedTypeDecl{Name: name, Body: body})
+			return name
+		}
+		if t.decls[idx].Body == body {
+			return name
+		}
+	}
+}
+
+// typeScriptClientGeneratorNamedRef is the reference to the interface of the named struct type.
+// The request and the response can be different interfaces because the optional fields are rendered differently.
+type typeScriptClientGeneratorNamedRef struct {
+	namedTypes   *typeScriptClientGeneratorNamedTypes
+	baseName     string
+	object       *typeScriptClientGeneratorObjectField
+	requestName  string
+	responseName string
+}
+
+func (t *typeScriptClientGeneratorNamedRef) RenderRequest(prefix string) string {
+	if t.requestName == "" {
+		t.requestName = t.namedTypes.declare(t.baseName, t.object.RenderRequest(""))
+	}
+	return t.requestName
+}
+
+func (t *typeScriptClientGeneratorNamedRef) RenderResponse(prefix string) string {
+	if t.responseName == "" {
+		t.responseName = t.namedTypes.declare(t.baseName, t.object.RenderResponse(""))
+	}
+	return t.responseName
+}
+
+func (t *typeScriptClientGeneratorNamedRef) RenderZodRequest(prefix string) string {
+	return t.object.RenderZodRequest(prefix)
+}
+
+func (t *typeScriptClientGeneratorNamedRef) RenderZodResponse(prefix string) string {
+	return t.object.RenderZodResponse(prefix)
+}
+
 type typeScriptClientGeneratorVoidField struct{}
 
 func (t *typeScriptClientGeneratorVoidField) RenderRequest(prefix string) string {
@@ -203,6 +624,14 @@ func (t *typeScriptClientGeneratorVoidField) RenderResponse(prefix string) strin
 	return "undefined"
 }
 
+func (t *typeScriptClientGeneratorVoidField) RenderZodRequest(prefix string) string {
+	return "z.undefined()"
+}
+
+func (t *typeScriptClientGeneratorVoidField) RenderZodResponse(prefix string) string {
+	return "z.unknown()"
+}
+
 func (t *typeScriptClientGenerator) typeInfo(tt types.Type, tagFilter string) (typeScriptClientGeneratorField, error) {
 	if tp, ok := tt.(*types.Pointer); ok {
 		tt = tp.Elem()
@@ -215,8 +644,11 @@ func (t *typeScriptClientGenerator) typeInfo(tt types.Type, tagFilter string) (t
 		}
 		ts = tt
 	case *types.Named:
-		tu := tt.Underlying()
-		return t.typeInfo(tu, tagFilter)
+		field, err := t.typeInfo(tt.Underlying(), tagFilter)
+		if err != nil {
+			return nil, err
+		}
+		return t.namedRef(tt, tagFilter, field), nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", tt.String())
 	}
@@ -248,6 +680,14 @@ func (t *typeScriptClientGenerator) toFields(tt *types.Struct, filterTag string)
 			continue
 		}
 		fieldName := tagFieldName
+		if filterTag == "json" {
+			switch t.opts.KeyCase {
+			case "camel":
+				fieldName = keycase.Camel(fieldName)
+			case "snake":
+				fieldName = keycase.Snake(fieldName)
+			}
+		}
 
 		var required bool
 		validateTag := tag.Get("validate")
@@ -273,82 +713,165 @@ func (t *typeScriptClientGenerator) toFields(tt *types.Struct, filterTag string)
 				isSlice:    false,
 				isRequired: required,
 				isOption:   option,
+				validate:   validateTag,
+				doc:        t.docOf(f),
 			})
 			continue
 		}
 
 		ft := f.Type()
-
-		if nt, ok := ft.(*types.Named); ok {
-			if _, ok := jsonStringMarshalerWhitelist[nt.String()]; ok {
-				fields = append(fields, &typeScriptClientGeneratorGenericField{
-					name:       fieldName,
-					typedef:    typeScriptClientGeneratorLiteralType("string"),
-					isSlice:    false,
-					isRequired: required,
-					isOption:   option,
-				})
-				continue
-			}
-			ft = nt.Underlying()
-		}
 		if pt, ok := ft.(*types.Pointer); ok {
 			option = true
 			ft = pt.Elem()
 		}
-		if nt, ok := ft.(*types.Named); ok {
-			ft = nt.Underlying()
+		if st, ok := ft.Underlying().(*types.Struct); ok && f.Embedded() {
+			cfs, err := t.toFields(st, filterTag)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert fields: %w", err)
+			}
+			fields = append(fields, cfs...)
+			continue
 		}
 
+		typedef, err := t.typeOf(ft, filterTag, tag)
+		if err != nil {
+			return nil, err
+		}
 		isSlice := false
-		if st, ok := ft.(*types.Slice); ok {
-			ft = st.Elem()
+		if at, ok := typedef.(*typeScriptClientGeneratorArrayType); ok {
 			isSlice = true
+			typedef = at.elem
 		}
-		if pt, ok := ft.(*types.Pointer); ok {
-			ft = pt.Elem()
+		fields = append(fields, &typeScriptClientGeneratorGenericField{
+			name:       fieldName,
+			typedef:    typedef,
+			isSlice:    isSlice,
+			isRequired: required,
+			isOption:   option,
+			validate:   validateTag,
+			doc:        t.docOf(f),
+		})
+	}
+	return fields, nil
+}
+
+func (t *typeScriptClientGenerator) docOf(f *types.Var) string {
+	if t.fieldDoc == nil {
+		return ""
+	}
+	return t.fieldDoc(f)
+}
+
+// typeOf converts the type of the field to the TypeScript type.
+// The pointers are dereferenced because nil is encoded as null.
+func (t *typeScriptClientGenerator) typeOf(ft types.Type, filterTag string, tag reflect.StructTag) (typeScriptClientGeneratorField, error) {
+	if at, ok := ft.(*types.Alias); ok {
+		// json.RawMessage is the alias of jsontext.Value with encoding/json/v2
+		if typedef, ok := t.wellKnownType(at.String()); ok {
+			return typedef, nil
 		}
-		if nt, ok := ft.(*types.Named); ok {
-			ft = nt.Underlying()
+		ft = types.Unalias(at)
+	}
+	switch tt := ft.(type) {
+	case *types.Pointer:
+		return t.typeOf(tt.Elem(), filterTag, tag)
+	case *types.Named:
+		if typedef, ok := t.wellKnownType(tt.String()); ok {
+			return typedef, nil
 		}
-
-		if st, ok := ft.(*types.Struct); ok {
-			cfs, err := t.toFields(st, filterTag)
-			if err != nil {
-				return nil, fmt.Errorf("failed to convert fields: %w", err)
-			}
-			if f.Embedded() {
-				fields = append(fields, cfs...)
-				continue
+		if tag.Get("tsenum") != "false" {
+			if enum := enumValues(tt); len(enum) > 0 {
+				return enum, nil
 			}
-			fields = append(fields, &typeScriptClientGeneratorGenericField{
-				name:       fieldName,
-				typedef:    &typeScriptClientGeneratorObjectField{fields: cfs},
-				isSlice:    isSlice,
-				isRequired: required,
-				isOption:   option,
-			})
-			continue
 		}
-
-		if bt, ok := ft.(*types.Basic); ok {
-			typename, err := t.typeNameByBasicLit(bt)
-			if err != nil {
-				return nil, fmt.Errorf("failed to convert basic type: %w", err)
-			}
-			fields = append(fields, &typeScriptClientGeneratorGenericField{
-				name:       fieldName,
-				typedef:    typeScriptClientGeneratorLiteralType(typename),
-				isSlice:    isSlice,
-				isRequired: required,
-				isOption:   option,
-			})
-		} else {
-			return nil, fmt.Errorf("unsupported field type: %s type=%T", ft.String(), ft)
+		field, err := t.typeOf(tt.Underlying(), filterTag, tag)
+		if err != nil {
+			return nil, err
+		}
+		return t.namedRef(tt, filterTag, field), nil
+	case *types.Basic:
+		typename, err := t.typeNameByBasicLit(tt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert basic type: %w", err)
+		}
+		return typeScriptClientGeneratorLiteralType(typename), nil
+	case *types.Struct:
+		cfs, err := t.toFields(tt, filterTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert fields: %w", err)
+		}
+		return &typeScriptClientGeneratorObjectField{fields: cfs}, nil
+	case *types.Slice:
+		// []byte is encoded as the base64 string
+		if bt, ok := tt.Elem().Underlying().(*types.Basic); ok && bt.Kind() == types.Byte {
+			return typeScriptClientGeneratorLiteralType("string"), nil
+		}
+		elem, err := t.typeOf(tt.Elem(), filterTag, tag)
+		if err != nil {
+			return nil, err
+		}
+		return &typeScriptClientGeneratorArrayType{elem: elem}, nil
+	case *types.Array:
+		elem, err := t.typeOf(tt.Elem(), filterTag, tag)
+		if err != nil {
+			return nil, err
+		}
+		return &typeScriptClientGeneratorArrayType{elem: elem}, nil
+	case *types.Map:
+		// the keys of the map are encoded as the string in JSON
+		elem, err := t.typeOf(tt.Elem(), filterTag, tag)
+		if err != nil {
+			return nil, err
 		}
+		return &typeScriptClientGeneratorRecordType{elem: elem}, nil
+	case *types.Interface:
+		return typeScriptClientGeneratorLiteralType("unknown"), nil
+	}
+	return nil, fmt.Errorf("unsupported field type: %s type=%T", ft.String(), ft)
+}
 
+// wellKnownType returns the TypeScript type of the named type that is not encoded by its underlying type.
+func (t *typeScriptClientGenerator) wellKnownType(name string) (typeScriptClientGeneratorField, bool) {
+	if jsType, ok := t.opts.Marshalers[name]; ok {
+		return typeScriptClientGeneratorLiteralType(jsType), true
 	}
-	return fields, nil
+	if jsType, ok := jsonStringMarshalerWhitelist[name]; ok {
+		return typeScriptClientGeneratorLiteralType(jsType), true
+	}
+	switch name {
+	case "encoding/json.RawMessage", "encoding/json/jsontext.Value":
+		return typeScriptClientGeneratorLiteralType("unknown"), true
+	case "time.Duration":
+		return typeScriptClientGeneratorLiteralType(t.opts.DurationType), true
+	}
+	return nil, false
+}
+
+// LoadMarshalerWhitelist reads the file of the -marshaler-whitelist flag that lists the types implementing json.Marshaler.
+// Each line is the full name of the type and the optional TypeScript type, like "github.com/foo/bar.Money number".
+// The TypeScript type defaults to string. The lines starting with # are ignored.
+func LoadMarshalerWhitelist(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read marshaler whitelist: %w", err)
+	}
+	marshalers := make(map[string]string)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, jsType, _ := strings.Cut(line, " ")
+		jsType = strings.TrimSpace(jsType)
+		if jsType == "" {
+			jsType = "string"
+		}
+		if !strings.Contains(name, ".") {
+			return nil, fmt.Errorf("invalid type name at line %d of %s: %s", i+1, path, name)
+		}
+		marshalers[name] = jsType
+	}
+	return marshalers, nil
 }
 
 func (t *typeScriptClientGenerator) typeNameByBasicLit(tt *types.Basic) (string, error) {
@@ -423,12 +946,58 @@ func (t typeScriptClientGeneratorTemplateArgs) Methods() []typeScriptClientGener
 	return methods
 }
 
+// typeScriptClientGeneratorTemplateArgsOperations is the operations of the routes of the tag.
+type typeScriptClientGeneratorTemplateArgsOperations struct {
+	Tag        string
+	Operations []*typeScriptClientGeneratorTemplateArgsMethodPath
+}
+
+// Operations returns the routes that are annotated with the tags grouped by the tags in the order of the first appearance.
+func (t typeScriptClientGeneratorTemplateArgs) Operations() []*typeScriptClientGeneratorTemplateArgsOperations {
+	groups := make([]*typeScriptClientGeneratorTemplateArgsOperations, 0)
+	for _, mp := range t {
+		for _, tag := range mp.Tags {
+			idx := slices.IndexFunc(groups, func(g *typeScriptClientGeneratorTemplateArgsOperations) bool {
+				return g.Tag == tag
+			})
+			if idx < 0 {
+				groups = append(groups, &typeScriptClientGeneratorTemplateArgsOperations{Tag: tag})
+				idx = len(groups) - 1
+			}
+			groups[idx].Operations = append(groups[idx].Operations, mp)
+		}
+	}
+	return groups
+}
+
 type typeScriptClientGeneratorTemplateArgsMethodPath struct {
-	Method   string
-	Path     string
-	Query    typeScriptClientGeneratorField
-	Request  typeScriptClientGeneratorField
-	Response typeScriptClientGeneratorField
+	Method     string
+	Path       string
+	Doc        string
+	Deprecated bool
+	Query      typeScriptClientGeneratorField
+	Request    typeScriptClientGeneratorField
+	Response   typeScriptClientGeneratorField
+	// Name and Tags are the annotations of Annotate
+	Name string
+	Tags []string
+}
+
+func (t *typeScriptClientGeneratorTemplateArgsMethodPath) JSDoc(prefix string) string {
+	doc := t.Doc
+	if t.Deprecated {
+		doc = strings.TrimLeft(doc+"\n@deprecated", "\n")
+	}
+	return jsDoc(prefix, doc)
+}
+
+// OperationName returns the name of the operation of the route, or the name that is derived from the method and the path like the OpenAPI document.
+func (t *typeScriptClientGeneratorTemplateArgsMethodPath) OperationName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	path, _ := openAPIPath(t.Path)
+	return openAPIOperationID(t.Method, path)
 }
 
 func (t *typeScriptClientGeneratorTemplateArgsMethodPath) MethodPath() string {
@@ -439,6 +1008,11 @@ func (t *typeScriptClientGeneratorTemplateArgsMethodPath) Builder() string {
 	pathFragments := strings.Split(string(t.Path), "/")
 	args := make([]string, 0, len(pathFragments))
 	for _, fragment := range pathFragments {
+		// the catch-all segment of chi is bound by the urlparam:"*" tag
+		if fragment == "*" {
+			args = append(args, fragment)
+			continue
+		}
 		if !strings.HasPrefix(fragment, "{") || !strings.HasSuffix(fragment, "}") {
 			continue
 		}
@@ -462,17 +1036,25 @@ func (t *typeScriptClientGeneratorTemplateArgsMethodPath) Builder() string {
 		if i > 0 {
 			argType += ", "
 		}
+		if arg == "*" {
+			argType += `"*": string`
+			continue
+		}
 		argType += fmt.Sprintf("%s: string", arg)
 	}
 	builder := ""
 	for _, fragment := range pathFragments[1:] {
 		builder += "/"
-		if !strings.HasPrefix(fragment, "{") || !strings.HasSuffix(fragment, "}") {
+		if fragment != "*" && (!strings.HasPrefix(fragment, "{") || !strings.HasSuffix(fragment, "}")) {
 			builder += fragment
 			continue
 		}
 		var argName string
 		argName, args = args[0], args[1:]
+		if argName == "*" {
+			builder += `${args["*"]}`
+			continue
+		}
 		builder += fmt.Sprintf("${args.%s}", argName)
 	}
 
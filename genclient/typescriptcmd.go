@@ -0,0 +1,230 @@
+package genclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/tools/go/packages"
+)
+
+// watchDebounce is the duration to wait for the successive file events before regenerating.
+const watchDebounce = 100 * time.Millisecond
+
+var errOutPathRequired = errors.New("-out is required")
+
+// GenerateTypeScriptClientCached generates the TypeScript client to the path of the -out flag
+// like TypeScriptClientGenerator, but reuses the generated code in the user cache directory
+// when the module is not changed since the last generation.
+func GenerateTypeScriptClientCached(ctx context.Context, dir string, patterns []string) error {
+	if typeScriptClientOutPath == "" {
+		return errOutPathRequired
+	}
+	key, err := packageHash(dir, patterns, &TypeScriptClientGenerator.Flags)
+	if err != nil {
+		return err
+	}
+	cache, err := newAnalysisCache("gentypescript")
+	if err != nil {
+		return err
+	}
+	if b, ok := cache.get(key); ok {
+		if err := os.WriteFile(typeScriptClientOutPath, b, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	}
+
+	pkgs, err := loadPackages(ctx, dir, patterns...)
+	if err != nil {
+		return err
+	}
+	if err := runTypeScriptClientGenerator(pkgs); err != nil {
+		return err
+	}
+	b, err := os.ReadFile(typeScriptClientOutPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// no routes are found
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	if err := cache.put(key, b); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WatchTypeScriptClient generates the TypeScript client to the path of the -out flag
+// and regenerates it every time the Go files in the module are changed until ctx is canceled.
+// The loaded packages are reused, so only the changed target packages are type-checked again
+// unless the other packages, go.mod or the imports are changed.
+func WatchTypeScriptClient(ctx context.Context, dir string, patterns []string) error {
+	if typeScriptClientOutPath == "" {
+		return errOutPathRequired
+	}
+	root, err := moduleRoot(dir)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	digests := make(map[string][sha256.Size]byte)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && isIgnoredDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if err := watcher.Add(path); err != nil {
+				return fmt.Errorf("failed to add directory to watcher: %w", err)
+			}
+			return nil
+		}
+		if isWatchedFile(path) {
+			digests[path], _ = fileDigest(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch the module: %w", err)
+	}
+
+	pkgs, err := loadPackages(ctx, dir, patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else if err := runTypeScriptClientGenerator(pkgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	changed := make(map[string]struct{})
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() && !isIgnoredDir(stat.Name()) {
+					if err := watcher.Add(event.Name); err != nil {
+						return fmt.Errorf("failed to add directory to watcher: %w", err)
+					}
+					continue
+				}
+			}
+			if !isWatchedFile(event.Name) {
+				continue
+			}
+			digest, err := fileDigest(event.Name)
+			if prev, ok := digests[event.Name]; ok && err == nil && prev == digest {
+				continue
+			}
+			if err != nil {
+				delete(digests, event.Name)
+			} else {
+				digests[event.Name] = digest
+			}
+			changed[event.Name] = struct{}{}
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, "watcher error:", err)
+		case <-timer.C:
+			reloaded, err := reloadPackages(ctx, dir, patterns, pkgs, changed)
+			clear(changed)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			pkgs = reloaded
+			if err := runTypeScriptClientGenerator(pkgs); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "generated %s\n", typeScriptClientOutPath)
+		}
+	}
+}
+
+// reloadPackages type-checks only the target packages that contain the changed files.
+// The packages are loaded again when the other files are changed.
+func reloadPackages(ctx context.Context, dir string, patterns []string, pkgs []*packages.Package, changed map[string]struct{}) ([]*packages.Package, error) {
+	files := make(map[string]int)
+	for i, pkg := range pkgs {
+		for _, name := range pkg.CompiledGoFiles {
+			files[name] = i
+		}
+	}
+	targets := make(map[int]struct{})
+	for name := range changed {
+		i, ok := files[name]
+		if !ok {
+			return loadPackages(ctx, dir, patterns...)
+		}
+		targets[i] = struct{}{}
+	}
+
+	reloaded := slices.Clone(pkgs)
+	for i := range targets {
+		pkg, ok, err := recheckPackage(pkgs[i])
+		if err != nil || !ok {
+			// the errors are reported by go/packages in the same format as the initial load
+			return loadPackages(ctx, dir, patterns...)
+		}
+		reloaded[i] = pkg
+	}
+	return reloaded, nil
+}
+
+func runTypeScriptClientGenerator(pkgs []*packages.Package) error {
+	for _, pkg := range pkgs {
+		r := newAnalysisRunner(pkg)
+		_, err := r.run(TypeScriptClientGenerator)
+		r.printDiagnostics()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isIgnoredDir reports whether the directory is ignored by the go command.
+func isIgnoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "node_modules"
+}
+
+func isWatchedFile(path string) bool {
+	name := filepath.Base(path)
+	if name == "go.mod" || name == "go.sum" {
+		return true
+	}
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+func fileDigest(path string) ([sha256.Size]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
@@ -1,8 +1,10 @@
 package tanukirpc
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -10,77 +12,201 @@ import (
 
 type Handler[Reg any] interface {
 	build(r *Router[Reg]) http.HandlerFunc
+	Metadata() *HandlerMetadata
 }
 
 func NewHandler[Req any, Res any, Reg any](h HandlerFunc[Req, Res, Reg]) Handler[Reg] {
-	return &handler[Req, Res, Reg]{h: h}
+	return &handler[Req, Res, Reg]{
+		h:    h,
+		meta: newHandlerMetadata(reflect.TypeFor[Req](), reflect.TypeFor[Res]()),
+	}
 }
 
 type HandlerFunc[Req any, Res any, Reg any] func(Context[Reg], Req) (Res, error)
 
+// HandlerMetadata is the information of the handler that is computed once at the creation of the handler.
+// This is used to reduce the work per request, and it is also useful for tooling.
+type HandlerMetadata struct {
+	// Request is the type of the request.
+	Request reflect.Type
+	// Response is the type of the response.
+	Response reflect.Type
+	// Validatable reports whether the request implements the Validatable interface.
+	Validatable bool
+	// HasValidateTag reports whether the request has the validate struct tags.
+	HasValidateTag bool
+	// Deprecation is the deprecation of the handler that is wrapped by Deprecated. It is nil if the handler is not deprecated.
+	Deprecation *Deprecation
+	// Annotations is the annotations of the handler that is wrapped by Annotate. It is nil if the handler is not annotated.
+	Annotations *RouteAnnotations
+}
+
+func newHandlerMetadata(req, res reflect.Type) *HandlerMetadata {
+	return &HandlerMetadata{
+		Request:        req,
+		Response:       res,
+		Validatable:    req.Implements(validatableType),
+		HasValidateTag: hasValidateTagType(req),
+	}
+}
+
 type handler[Req any, Res any, T any] struct {
-	h HandlerFunc[Req, Res, T]
+	h    HandlerFunc[Req, Res, T]
+	meta *HandlerMetadata
+}
+
+func (h *handler[Req, Res, Reg]) Metadata() *HandlerMetadata {
+	return h.meta
+}
+
+func (h *handler[Req, Res, Reg]) isNil() bool {
+	return h.h == nil
 }
 
 func (h *handler[Req, Res, Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	return buildHandlerFunc(r, h.meta, handlerCall[Reg]{
+		newRequest: func() any { return new(Req) },
+		request:    func(p any) any { return *p.(*Req) },
+		call: func(ctx Context[Reg], p any) (any, error) {
+			return h.h(ctx, *p.(*Req))
+		},
+	})
+}
+
+// handlerCall is the typed part of the handler that is called by the pipeline of buildHandlerFunc.
+type handlerCall[Reg any] struct {
+	// newRequest returns the pointer to the zero value of the request to decode into.
+	newRequest func() any
+	// request returns the request that the pointer returned by newRequest points to.
+	request func(p any) any
+	// call calls the handler function with the request that the pointer points to.
+	call func(ctx Context[Reg], p any) (any, error)
+}
+
+func validateRequest(meta *HandlerMetadata, req any) error {
+	if meta.Validatable {
+		return req.(Validatable).Validate()
+	}
+	if !meta.HasValidateTag {
+		return nil
+	}
+	if meta.Request.Kind() == reflect.Pointer && reflect.ValueOf(req).IsNil() {
+		return nil
+	}
+	return newStructValidator(req).Validate()
+}
+
+func buildHandlerFunc[Reg any](r *Router[Reg], meta *HandlerMetadata, hc handlerCall[Reg]) http.HandlerFunc {
+	decoder := r.withCodecMiddlewares(codecForRequestType(r.codec, meta.Request))
+	redacted := newRedactedInputs(meta.Request)
 	return func(w http.ResponseWriter, req *http.Request) {
+		req = redacted.withRedactedURI(withLogAttrs(req))
 		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
 		t1 := time.Now()
 		var t2 time.Time
 		var lerr error
+		var aborted bool
+		var ctx Context[Reg]
 		defer func() {
+			rec := recover()
 			if t2.IsZero() {
 				t2 = time.Now()
 			}
+			r.deferDoWithResult(ctx, ww, req, resultError(lerr, rec))
 			if err := r.accessLoggerLog(req.Context(), ww, req, lerr, t1, t2); err != nil {
 				r.logger.ErrorContext(req.Context(), "access log error", slog.Any("error", err))
 			}
+			if rec != nil {
+				panic(rec)
+			}
+			if aborted {
+				// the status has been sent, so abort the connection not to end the truncated response normally
+				panic(http.ErrAbortHandler)
+			}
 		}()
 
-		var reqBody Req
-		if err := r.codec.Decode(req, &reqBody); err != nil {
-			r.errorHooker.OnError(ww, req, r.logger, r.codec, err)
+		recordRequest := func(reflect.Type) {}
+		if r.auditBodyLimit > 0 {
+			req, recordRequest = withAuditBody(req, r.auditBodyLimit)
+		}
+		var payloads *accessLogPayloadRecord
+		req, payloads = r.accessLogPayloads.withRecord(req)
+
+		// the disabled and unauthorized requests are rejected before the request is decoded,
+		// so they are not told that the route exists or which fields are invalid by the decode and validation errors
+		var err error
+		ctx, err = r.contextFactory.Build(ww, req)
+		if err != nil {
+			r.onError(ww, req, nil, ErrorStageContext, err)
 			lerr = err
 			return
 		}
-		if vreq, ok := canValidate(reqBody); ok {
-			if err := vreq.Validate(); err != nil {
-				ve := &ValidateError{err: err}
-				r.errorHooker.OnError(ww, req, r.logger, r.codec, ve)
-				lerr = err
-				return
-			}
+		if err := r.featureFlags.check(ctx); err != nil {
+			r.onError(ww, req, ctx, ErrorStageFeatureFlag, err)
+			lerr = err
+			return
+		}
+		if err := r.authorize(ctx); err != nil {
+			r.onError(ww, req, ctx, ErrorStageAuthorize, err)
+			lerr = err
+			return
 		}
 
-		ctx, err := r.contextFactory.Build(ww, req)
+		reqBody := hc.newRequest()
+		err = decoder.Decode(req, reqBody)
+		recordRequest(meta.Request)
 		if err != nil {
-			r.errorHooker.OnError(ww, req, r.logger, r.codec, err)
+			err = redacted.decodeError(req, err)
+			r.onError(ww, req, ctx, ErrorStageDecode, err)
+			lerr = err
+			return
+		}
+		payloads.recordRequest(hc.request(reqBody))
+		if err := validateRequest(meta, hc.request(reqBody)); err != nil {
+			ve := &ValidateError{err: err}
+			r.onError(ww, req, ctx, ErrorStageValidate, ve)
 			lerr = err
 			return
 		}
 
-		res, err := h.h(ctx, reqBody)
+		res, err := hc.call(ctx, reqBody)
 		if err != nil {
-			r.errorHooker.OnError(ww, req, r.logger, r.codec, err)
+			r.onError(ww, req, ctx, ErrorStageHandler, err)
 			lerr = err
 			return
 		}
+		payloads.recordResponse(res)
 
-		if err := ctx.DeferDo(DeferDoTimingBeforeResponse); err != nil {
-			r.errorHooker.OnError(ww, req, r.logger, r.codec, err)
+		if err := r.deferDo(ctx, DeferDoTimingBeforeResponse); err != nil {
+			r.onError(ww, req, ctx, ErrorStageDeferBeforeResponse, err)
 			lerr = err
 			return
 		}
+		if r.validateResponse {
+			if vres, ok := canValidate(res); ok {
+				if err := vres.Validate(); err != nil {
+					re := &ResponseValidateError{err: err}
+					r.onError(ww, req, ctx, ErrorStageEncode, re)
+					lerr = re
+					return
+				}
+			}
+		}
 		if ww.Status() == 0 {
-			if err := r.codec.Encode(ww, req, res); err != nil {
-				r.errorHooker.OnError(ww, req, r.logger, r.codec, err)
+			if err := r.encodeResponse(ww, req, res, t1); err != nil {
 				lerr = err
+				if errors.Is(err, ErrStreamAborted) {
+					r.logger.ErrorContext(ctx, "streaming response is aborted", slog.Any("error", err))
+					aborted = true
+					return
+				}
+				r.onError(ww, req, ctx, ErrorStageEncode, err)
 				return
 			}
 		}
 		t2 = time.Now()
 
-		if err := ctx.DeferDo(DeferDoTimingAfterResponse); err != nil {
+		if err := r.deferDo(ctx, DeferDoTimingAfterResponse); err != nil {
 			r.logger.ErrorContext(ctx, "defer do error", slog.Any("error", err))
 			lerr = err
 			return
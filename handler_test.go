@@ -0,0 +1,171 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchmarkItem struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+type benchmarkResponse struct {
+	Items []benchmarkItem `json:"items"`
+}
+
+func newBenchmarkJSONResponseRouter(n int) http.Handler {
+	res := &benchmarkResponse{Items: make([]benchmarkItem, n)}
+	for i := range res.Items {
+		res.Items[i] = benchmarkItem{ID: i, Name: "tanuki", Tags: []string{"a", "b"}, Score: 4.2}
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req struct{}) (*benchmarkResponse, error) {
+		return res, nil
+	}
+	router := tanukirpc.NewRouter(
+		struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](nil),
+		tanukirpc.WithDefaultMiddleware[struct{}](),
+	)
+	router.Get("/items", tanukirpc.NewHandler(h))
+	return router
+}
+
+func benchmarkJSONResponse(b *testing.B, n int) {
+	router := newBenchmarkJSONResponseRouter(n)
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("accept", "application/json")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status: %d", w.Code)
+		}
+	}
+}
+
+func BenchmarkJSONResponseSmall(b *testing.B) {
+	benchmarkJSONResponse(b, 1)
+}
+
+func BenchmarkJSONResponseLarge(b *testing.B) {
+	benchmarkJSONResponse(b, 1000)
+}
+
+type benchmarkValidateRequest struct {
+	ID    int    `urlparam:"id" validate:"required"`
+	Query string `query:"q" validate:"max=10"`
+}
+
+func BenchmarkValidateRequest(b *testing.B) {
+	h := func(ctx tanukirpc.Context[struct{}], req benchmarkValidateRequest) (struct{}, error) {
+		return struct{}{}, nil
+	}
+	router := tanukirpc.NewRouter(
+		struct{}{},
+		tanukirpc.WithAccessLogger[struct{}](nil),
+		tanukirpc.WithDefaultMiddleware[struct{}](),
+	)
+	router.Get("/items/{id}", tanukirpc.NewHandler(h))
+	req := httptest.NewRequest(http.MethodGet, "/items/42?q=tanuki", nil)
+	req.Header.Set("accept", "application/json")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status: %d", w.Code)
+		}
+	}
+}
+
+func TestHandlerMetadata(t *testing.T) {
+	h := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *benchmarkValidateRequest) (*benchmarkResponse, error) {
+		return nil, nil
+	})
+	meta := h.Metadata()
+	assert.Equal(t, reflect.TypeFor[*benchmarkValidateRequest](), meta.Request)
+	assert.Equal(t, reflect.TypeFor[*benchmarkResponse](), meta.Response)
+	assert.True(t, meta.HasValidateTag)
+	assert.False(t, meta.Validatable)
+}
+
+func TestDeprecated(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](tanukirpc.NewLogger(slog.New(slog.NewJSONHandler(logBuf, nil)), nil)))
+	sunset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	router.Get("/v1/tasks", tanukirpc.Deprecated(tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}), sunset, "https://example.com/migrate"))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/tasks", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "old-client/1.0")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 02 Jan 2030 03:04:05 GMT", rec.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, rec.Header().Get("Link"))
+	assert.Contains(t, logBuf.String(), `"msg":"deprecated route is called"`)
+	assert.Contains(t, logBuf.String(), `"user_agent":"old-client/1.0"`)
+	assert.Contains(t, logBuf.String(), `"deprecated":true`)
+
+	routes, err := router.Routes()
+	assert.NoError(t, err)
+	assert.Len(t, routes, 1)
+	assert.Equal(t, &tanukirpc.Deprecation{Sunset: sunset, Link: "https://example.com/migrate"}, routes[0].Metadata.Deprecation)
+}
+
+func TestAnnotate(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	listTasks := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	router.Get("/tasks", tanukirpc.Annotate(listTasks,
+		tanukirpc.WithRouteName("listTasks"),
+		tanukirpc.WithRouteTags("tasks"),
+		tanukirpc.WithRouteScopes("tasks:read"),
+	))
+	sunset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	router.Get("/v1/tasks", tanukirpc.Annotate(
+		tanukirpc.Annotate(listTasks, tanukirpc.WithRouteName("listTasksV1"), tanukirpc.WithRouteTags("tasks")),
+		tanukirpc.WithRouteName("listLegacyTasks"),
+		tanukirpc.WithRouteTags("tasks", "legacy"),
+		tanukirpc.WithRouteDeprecation(sunset, ""),
+	))
+	router.Get("/plain", listTasks)
+
+	routes, err := router.Routes()
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Nil(t, routes[0].Metadata.Annotations)
+	assert.Equal(t, &tanukirpc.RouteAnnotations{Name: "listTasks", Tags: []string{"tasks"}, Scopes: []string{"tasks:read"}}, routes[1].Metadata.Annotations)
+	assert.Nil(t, routes[1].Metadata.Deprecation)
+	assert.Equal(t, &tanukirpc.RouteAnnotations{Name: "listLegacyTasks", Tags: []string{"tasks", "legacy"}}, routes[2].Metadata.Annotations)
+	assert.Equal(t, &tanukirpc.Deprecation{Sunset: sunset}, routes[2].Metadata.Deprecation)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/tasks", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+}
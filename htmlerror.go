@@ -0,0 +1,199 @@
+package tanukirpc
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTMLError is the error that is rendered by the HTMLErrorRenderer.
+type HTMLError struct {
+	// Status is the status code of the response, from ErrorWithStatus or 500 Internal Server Error.
+	Status int
+	// Message is the message of the error that is localized by the ErrorLocalizer of the Router.
+	Message string
+	// Err is the error that occurred.
+	Err error
+}
+
+// HTMLErrorRenderer renders the error response of the requests that accept text/html rather than JSON,
+// like the form posts from the server-rendered pages. If it returns the error, the error response is written by the codec instead,
+// so it should not write the response before it is sure to succeed.
+type HTMLErrorRenderer interface {
+	RenderError(w http.ResponseWriter, req *http.Request, e *HTMLError) error
+}
+
+// HTMLErrorRendererFunc is the function that implements HTMLErrorRenderer.
+type HTMLErrorRendererFunc func(w http.ResponseWriter, req *http.Request, e *HTMLError) error
+
+func (f HTMLErrorRendererFunc) RenderError(w http.ResponseWriter, req *http.Request, e *HTMLError) error {
+	return f(w, req, e)
+}
+
+// WithHTMLErrorRenderer renders the errors by renderer for the requests that prefer text/html to JSON in the Accept header.
+// The other requests, like the ones of the generated clients, have the error responses of the codec as usual.
+// RouteHTMLErrorRenderer overrides it per route.
+//
+//	tanukirpc.NewRouter(reg, tanukirpc.WithHTMLErrorRenderer[*registry](tanukirpc.HTMLErrorTemplate(errorPage)))
+func WithHTMLErrorRenderer[Reg any](renderer HTMLErrorRenderer) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.htmlErrorRenderer = renderer
+		return r
+	}
+}
+
+type htmlErrorRendererKey struct{}
+
+type htmlErrorRendererConfig struct {
+	renderer HTMLErrorRenderer
+}
+
+// RouteHTMLErrorRenderer returns a middleware that overrides the HTMLErrorRenderer of the Router for the routes.
+// Use this with Router.With, and pass nil to respond with the codec even to the requests that accept text/html.
+//
+//	router.With(tanukirpc.RouteHTMLErrorRenderer(tanukirpc.RedirectBackWithFlash("/signup"))).Post("/signup", handler)
+func RouteHTMLErrorRenderer(renderer HTMLErrorRenderer) func(http.Handler) http.Handler {
+	cfg := &htmlErrorRendererConfig{renderer: renderer}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := gocontext.WithValue(req.Context(), htmlErrorRendererKey{}, cfg)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// withHTMLErrorRenderer attaches the HTMLErrorRenderer of the Router to the request unless the route overrides it.
+func withHTMLErrorRenderer(req *http.Request, renderer HTMLErrorRenderer) *http.Request {
+	if renderer == nil {
+		return req
+	}
+	if _, ok := req.Context().Value(htmlErrorRendererKey{}).(*htmlErrorRendererConfig); ok {
+		return req
+	}
+	return req.WithContext(gocontext.WithValue(req.Context(), htmlErrorRendererKey{}, &htmlErrorRendererConfig{renderer: renderer}))
+}
+
+// RenderHTMLError renders err by the HTMLErrorRenderer of the Router or the route if the request prefers text/html to JSON.
+// It returns false if the error is not rendered, so the ErrorHooker writes the error response by the codec.
+// The default ErrorHooker calls it, so use this in the custom ErrorHooker to render the HTML errors as well.
+func RenderHTMLError(w http.ResponseWriter, req *http.Request, logger *slog.Logger, err error) bool {
+	cfg, ok := req.Context().Value(htmlErrorRendererKey{}).(*htmlErrorRendererConfig)
+	if !ok || cfg.renderer == nil || !prefersHTML(req) {
+		return false
+	}
+	e := &HTMLError{Status: http.StatusInternalServerError, Message: LocalizedErrorMessage(req, err), Err: err}
+	var ews ErrorWithStatus
+	if errors.As(err, &ews) {
+		e.Status = ews.Status()
+	} else {
+		logger.ErrorContext(req.Context(), "ocurred internal server error", slog.Any("error", err))
+	}
+	if rerr := cfg.renderer.RenderError(w, req, e); rerr != nil {
+		logger.ErrorContext(req.Context(), "failed to render HTML error", slog.Any("error", rerr))
+		return false
+	}
+	return true
+}
+
+// prefersHTML reports whether the Accept header of the request prefers text/html to JSON.
+// The wildcards like */* are not counted, so the requests of the API clients without the Accept header are responded with JSON.
+func prefersHTML(req *http.Request) bool {
+	for _, mediaType := range parseQualityValues(req.Header.Get("Accept"), true) {
+		switch {
+		case mediaType == "text/html", mediaType == "application/xhtml+xml":
+			return true
+		case mediaType == defaultJSONCodecContentType, strings.HasSuffix(mediaType, "+json"):
+			return false
+		}
+	}
+	return false
+}
+
+// HTMLErrorTemplate returns the HTMLErrorRenderer that executes tmpl with the HTMLError as the data.
+// The response is written after the template succeeds, so the error of the template falls back to the codec.
+func HTMLErrorTemplate(tmpl *template.Template) HTMLErrorRenderer {
+	return HTMLErrorRendererFunc(func(w http.ResponseWriter, req *http.Request, e *HTMLError) error {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, e); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(e.Status)
+		if _, err := buf.WriteTo(w); err != nil {
+			return fmt.Errorf("failed to write body: %w", err)
+		}
+		return nil
+	})
+}
+
+const (
+	flashCookieName = "tanukirpc_flash"
+	// maxFlashMessageBytes keeps the cookie under the limit of the browsers.
+	maxFlashMessageBytes = 1024
+)
+
+// RedirectBackWithFlash returns the HTMLErrorRenderer that redirects back to the page of the Referer header with 303 See Other,
+// and passes the message of the error to the page by the flash cookie. The page reads it by FlashMessage.
+// It redirects to fallback if the Referer is absent or not the same host as the request, so it is not an open redirect.
+func RedirectBackWithFlash(fallback string) HTMLErrorRenderer {
+	if fallback == "" {
+		fallback = "/"
+	}
+	return HTMLErrorRendererFunc(func(w http.ResponseWriter, req *http.Request, e *HTMLError) error {
+		message := e.Message
+		if len(message) > maxFlashMessageBytes {
+			message = message[:maxFlashMessageBytes]
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     flashCookieName,
+			Value:    base64.RawURLEncoding.EncodeToString([]byte(message)),
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   req.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, req, refererPath(req, fallback), http.StatusSeeOther)
+		return nil
+	})
+}
+
+// refererPath returns the path and the query of the Referer header if it is the same host as the request, or fallback.
+func refererPath(req *http.Request, fallback string) string {
+	ref := req.Referer()
+	if ref == "" {
+		return fallback
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host != req.Host || (u.Scheme != "http" && u.Scheme != "https") {
+		return fallback
+	}
+	return u.RequestURI()
+}
+
+// FlashMessage returns the message that is passed by RedirectBackWithFlash, and clears it so it is shown only once.
+func FlashMessage(w http.ResponseWriter, req *http.Request) (string, bool) {
+	c, err := req.Cookie(flashCookieName)
+	if err != nil {
+		return "", false
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	b, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
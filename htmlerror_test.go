@@ -0,0 +1,109 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHTMLErrorRenderer(t *testing.T) {
+	page := template.Must(template.New("error").Parse(`<p class="error">{{.Status}}: {{.Message}}</p>`))
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithHTMLErrorRenderer[struct{}](tanukirpc.HTMLErrorTemplate(page)))
+	failing := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, tanukirpc.WrapErrorWithStatus(http.StatusConflict, errors.New("name <taken>"))
+	})
+	router.Post("/signup", failing)
+	router.With(tanukirpc.RouteHTMLErrorRenderer(tanukirpc.RedirectBackWithFlash("/signup"))).Post("/signup/flash", failing)
+	router.With(tanukirpc.RouteHTMLErrorRenderer(nil)).Post("/signup/json", failing)
+	router.Post("/broken", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, errors.New("broken")
+	}))
+
+	serve := func(path, accept, referer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Accept", accept)
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	t.Run("html", func(t *testing.T) {
+		rec := serve("/signup", browser, "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `<p class="error">409: name &lt;taken&gt;</p>`, rec.Body.String())
+	})
+	t.Run("internal server error", func(t *testing.T) {
+		rec := serve("/broken", browser, "")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, `<p class="error">500: broken</p>`, rec.Body.String())
+	})
+	t.Run("json", func(t *testing.T) {
+		for _, accept := range []string{"application/json", "*/*"} {
+			rec := serve("/signup", accept, "")
+			assert.Equal(t, http.StatusConflict, rec.Code, accept)
+			assert.JSONEq(t, `{"error":{"message":"name <taken>"}}`, rec.Body.String(), accept)
+		}
+	})
+	t.Run("json preferred", func(t *testing.T) {
+		rec := serve("/signup", "application/json, text/html;q=0.5", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.NotContains(t, rec.Header().Get("Content-Type"), "text/html")
+	})
+	t.Run("route disables html", func(t *testing.T) {
+		rec := serve("/signup/json", browser, "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.NotContains(t, rec.Header().Get("Content-Type"), "text/html")
+	})
+	t.Run("redirect back with flash", func(t *testing.T) {
+		rec := serve("/signup/flash", browser, "http://example.com/signup?plan=pro")
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/signup?plan=pro", rec.Header().Get("Location"))
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		req := httptest.NewRequest(http.MethodGet, "/signup", nil)
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		message, ok := tanukirpc.FlashMessage(w, req)
+		assert.True(t, ok)
+		assert.Equal(t, "name <taken>", message)
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "Max-Age=0")
+
+		_, ok = tanukirpc.FlashMessage(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/signup", nil))
+		assert.False(t, ok)
+	})
+	t.Run("redirect to fallback for other hosts", func(t *testing.T) {
+		for _, referer := range []string{"", "https://evil.example.com/signup", "//evil.example.com/signup"} {
+			rec := serve("/signup/flash", browser, referer)
+			assert.Equal(t, http.StatusSeeOther, rec.Code, referer)
+			assert.Equal(t, "/signup", rec.Header().Get("Location"), referer)
+		}
+	})
+}
+
+func TestHTMLErrorTemplateFallback(t *testing.T) {
+	page := template.Must(template.New("error").Parse(`{{.Missing}}`))
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithHTMLErrorRenderer[struct{}](tanukirpc.HTMLErrorTemplate(page)))
+	router.Get("/", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, tanukirpc.WrapErrorWithStatus(http.StatusBadRequest, errors.New("bad"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotContains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,125 @@
+// Code generated by genbind. DO NOT EDIT.
+
+package bindtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mackee/tanukirpc"
+)
+
+func init() {
+	tanukirpc.RegisterBinder("form", bindFormSignupRequest)
+	tanukirpc.RegisterBinder("query", bindQueryItemRequest)
+	tanukirpc.RegisterBinder("query", bindQuerySearchRequest)
+	tanukirpc.RegisterBinder("urlparam", bindURLParamFileRequest)
+	tanukirpc.RegisterBinder("urlparam", bindURLParamItemRequest)
+}
+
+func bindFormSignupRequest(r *http.Request, v *signupRequest) error {
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("failed to parse form: %w", err)
+	}
+	values := r.PostForm
+	if s := values.Get("name"); s != "" {
+		v.Name = string(s)
+	}
+	if s := values.Get("agree"); s != "" {
+		if s == "on" {
+			v.Agree = true
+		} else {
+			p, err := strconv.ParseBool(s)
+			if err != nil {
+				return tanukirpc.NewFormFieldError("agree", err)
+			}
+			v.Agree = bool(p)
+		}
+	}
+	if s := values.Get("Age"); s != "" {
+		p, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return tanukirpc.NewFormFieldError("Age", err)
+		}
+		v.Age = uint8(p)
+	}
+	return nil
+}
+
+func bindQueryItemRequest(r *http.Request, v *itemRequest) error {
+	values := r.URL.Query()
+	if s := values.Get("ID"); s != "" {
+		p, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse ID: %w", err)
+		}
+		v.ID = int(p)
+	}
+	if s := values.Get("verbose"); s != "" {
+		p, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse verbose: %w", err)
+		}
+		v.Verbose = bool(p)
+	}
+	return nil
+}
+
+func bindQuerySearchRequest(r *http.Request, v *searchRequest) error {
+	values := r.URL.Query()
+	if s := values.Get("q"); s != "" {
+		v.Query = string(s)
+	}
+	if s := values.Get("limit"); s != "" {
+		p, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse limit: %w", err)
+		}
+		v.Limit = int(p)
+	}
+	if s := values.Get("score"); s != "" {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse score: %w", err)
+		}
+		v.Score = float64(p)
+	}
+	if s := values.Get("Page"); s != "" {
+		p, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse Page: %w", err)
+		}
+		v.Page = int(p)
+	}
+	if s := values.Get("Desc"); s != "" {
+		p, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse Desc: %w", err)
+		}
+		v.Desc = bool(p)
+	}
+	return nil
+}
+
+func bindURLParamFileRequest(r *http.Request, v *fileRequest) error {
+	// the catch-all segment of the wildcard route can be empty
+	if s := chi.URLParam(r, "*"); s != "" {
+		v.Path = string(s)
+	}
+	return nil
+}
+
+func bindURLParamItemRequest(r *http.Request, v *itemRequest) error {
+	if s := chi.URLParam(r, "id"); s == "" {
+		return fmt.Errorf("url param id is required at field ID")
+	} else {
+		p, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse id: %w", err)
+		}
+		v.ID = int(p)
+	}
+	return nil
+}
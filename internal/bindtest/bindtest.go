@@ -0,0 +1,74 @@
+// Package bindtest has the routes whose binders are generated by genbind into bind_gen.go.
+// The tests compare the generated binders with the reflection-based binding of the codecs on the same structs.
+package bindtest
+
+import (
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/genclient"
+)
+
+//go:generate go run github.com/mackee/tanukirpc/cmd/genbind -out ./bind_gen.go ./
+
+type fileRequest struct {
+	Path string `urlparam:"*"`
+}
+
+type itemRequest struct {
+	ID      int  `urlparam:"id"`
+	Verbose bool `query:"verbose"`
+}
+
+type searchRequest struct {
+	Query  string  `query:"q"`
+	Limit  int     `query:"limit"`
+	Score  float64 `query:"score"`
+	Page   int
+	Desc   bool
+	Cursor string `query:"-"`
+	secret string
+}
+
+type signupRequest struct {
+	Name  string `form:"name"`
+	Agree bool   `form:"agree"`
+	Age   uint8
+	Plan  string `form:"-"`
+}
+
+// listRequest has the field that is not supported by the generated binders, so it is bound by the reflection.
+type listRequest struct {
+	Kind string `query:"kind"`
+	Tags []string
+}
+
+func getFile(ctx tanukirpc.Context[struct{}], req fileRequest) (*fileRequest, error) {
+	return &req, nil
+}
+
+func getItem(ctx tanukirpc.Context[struct{}], req itemRequest) (*itemRequest, error) {
+	return &req, nil
+}
+
+func search(ctx tanukirpc.Context[struct{}], req searchRequest) (*searchRequest, error) {
+	return &req, nil
+}
+
+func signup(ctx tanukirpc.Context[struct{}], req signupRequest) (*signupRequest, error) {
+	return &req, nil
+}
+
+func list(ctx tanukirpc.Context[struct{}], req listRequest) (*listRequest, error) {
+	return &req, nil
+}
+
+// NewRouter returns the router of the routes whose requests have the generated binders.
+func NewRouter() *tanukirpc.Router[struct{}] {
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Get("/files/*", tanukirpc.NewHandler(getFile))
+	r.Get("/items/{id}", tanukirpc.NewHandler(getItem))
+	r.Get("/search", tanukirpc.NewHandler(search))
+	r.Post("/signup", tanukirpc.NewHandler(signup))
+	r.Get("/list", tanukirpc.NewHandler(list))
+	genclient.AnalyzeTarget(r)
+	return r
+}
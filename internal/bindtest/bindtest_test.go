@@ -0,0 +1,95 @@
+package bindtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// the types that have the same fields without the generated binders are bound by the reflection
+type (
+	fileRequestReflect   fileRequest
+	itemRequestReflect   itemRequest
+	searchRequestReflect searchRequest
+	signupRequestReflect signupRequest
+	listRequestReflect   listRequest
+)
+
+type bindCase struct {
+	target string
+	form   string
+}
+
+func serve[Req any](pattern string, bc bindCase) *httptest.ResponseRecorder {
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithAccessLogger[struct{}](nil))
+	h := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req Req) (*Req, error) {
+		return &req, nil
+	})
+	var req *http.Request
+	if bc.form != "" {
+		router.Post(pattern, h)
+		req = httptest.NewRequest(http.MethodPost, bc.target, strings.NewReader(bc.form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		router.Get(pattern, h)
+		req = httptest.NewRequest(http.MethodGet, bc.target, nil)
+	}
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func compareBinders[Generated, Reflect any](t *testing.T, pattern string, cases []bindCase) {
+	t.Helper()
+	for _, bc := range cases {
+		t.Run(bc.target+" "+bc.form, func(t *testing.T) {
+			generated := serve[Generated](pattern, bc)
+			reflected := serve[Reflect](pattern, bc)
+			assert.Equal(t, reflected.Code, generated.Code, "generated: %s reflection: %s", generated.Body, reflected.Body)
+			if reflected.Code == http.StatusOK {
+				assert.JSONEq(t, reflected.Body.String(), generated.Body.String())
+			}
+		})
+	}
+}
+
+func TestGeneratedBinders(t *testing.T) {
+	t.Run("urlparam wildcard", func(t *testing.T) {
+		compareBinders[fileRequest, fileRequestReflect](t, "/files/*", []bindCase{
+			{target: "/files/docs/readme.md"},
+			{target: "/files/"},
+		})
+	})
+	t.Run("urlparam and query", func(t *testing.T) {
+		compareBinders[itemRequest, itemRequestReflect](t, "/items/{id}", []bindCase{
+			{target: "/items/42"},
+			{target: "/items/42?verbose=true"},
+			{target: "/items/42?verbose=maybe"},
+			{target: "/items/forty-two"},
+		})
+	})
+	t.Run("query with untagged fields", func(t *testing.T) {
+		compareBinders[searchRequest, searchRequestReflect](t, "/search", []bindCase{
+			{target: "/search?q=tanuki&limit=10&score=1.5&Page=2&Desc=true&Cursor=next&secret=s"},
+			{target: "/search"},
+			{target: "/search?Page=two"},
+		})
+	})
+	t.Run("form with untagged fields", func(t *testing.T) {
+		compareBinders[signupRequest, signupRequestReflect](t, "/signup", []bindCase{
+			{target: "/signup", form: "name=tanuki&agree=on&Age=20&Plan=pro"},
+			{target: "/signup", form: "name=tanuki&agree=false"},
+			{target: "/signup", form: "Age=twenty"},
+		})
+	})
+	t.Run("unsupported field", func(t *testing.T) {
+		compareBinders[listRequest, listRequestReflect](t, "/list", []bindCase{
+			{target: "/list?kind=animal"},
+		})
+	})
+}
@@ -0,0 +1,50 @@
+// Package keycase converts the keys of JSON between snake_case and camelCase.
+package keycase
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Camel converts the snake_case key like "due_at" to the camelCase key like "dueAt".
+// The keys without the underscores are returned as they are.
+func Camel(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+	var b strings.Builder
+	b.Grow(len(key))
+	upper := false
+	for i, r := range key {
+		switch {
+		case r == '_' && i > 0:
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Snake converts the camelCase key like "dueAt" to the snake_case key like "due_at".
+// The successive upper cases are treated as the word of the acronym, so "userID" is converted to "user_id".
+func Snake(key string) string {
+	rs := []rune(key)
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(unicode.IsUpper(rs[i-1]) && i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler returns the http.Handler of the route that is registered to the Router with the method and the pattern.
+// The returned handler includes the middlewares of the route, so it can be mounted into the other routers like http.ServeMux
+// one by one during the incremental adoption.
+//
+// The URL parameters are taken by matching the path of the request to the pattern. If the path does not match,
+// like the prefix is stripped by the other router, they are taken from http.Request.PathValue.
+// It returns nil if the route is not found.
+func (r *Router[Reg]) Handler(method, pattern string) http.Handler {
+	var found http.Handler
+	walkFn := func(m string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if m == method && route == pattern {
+			found = chi.Chain(middlewares...).Handler(handler)
+		}
+		return nil
+	}
+	if err := chi.Walk(r.cr, walkFn); err != nil || found == nil {
+		return nil
+	}
+	names := patternParamNames(pattern)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rctx := chi.NewRouteContext()
+		if !r.cr.Match(rctx, method, req.URL.Path) || rctx.RoutePattern() != pattern {
+			rctx = pathValueRouteContext(req, pattern, names)
+		}
+		ctx := gocontext.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		found.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// HandlerFuncAdapter converts the Handler to http.HandlerFunc without the Router, to mount it into the other routers.
+// The handler has its own codec and error pipeline configured by opts like the Router with reg, and the default middlewares are applied.
+// The URL parameters of the `urlparam` struct tags are taken from http.Request.PathValue, so the patterns of http.ServeMux
+// need to use the same names.
+func HandlerFuncAdapter[Reg any](reg Reg, h Handler[Reg], opts ...RouterOption[Reg]) http.HandlerFunc {
+	r := NewRouter(reg, opts...)
+	var names []string
+	if meta := h.Metadata(); meta != nil {
+		names = structTagNames(meta.Request, "urlparam")
+	}
+	hh := chi.Chain(r.defaultMiddlewares()...).HandlerFunc(h.build(r))
+	return func(w http.ResponseWriter, req *http.Request) {
+		if chi.RouteContext(req.Context()) == nil {
+			ctx := gocontext.WithValue(req.Context(), chi.RouteCtxKey, pathValueRouteContext(req, "", names))
+			req = req.WithContext(ctx)
+		}
+		hh.ServeHTTP(w, req)
+	}
+}
+
+// pathValueRouteContext returns the route context that has the URL parameters of names from http.Request.PathValue.
+func pathValueRouteContext(req *http.Request, pattern string, names []string) *chi.Context {
+	rctx := chi.NewRouteContext()
+	if pattern != "" {
+		rctx.RoutePatterns = []string{pattern}
+	}
+	for _, name := range names {
+		if v := req.PathValue(name); v != "" {
+			rctx.URLParams.Add(name, v)
+		}
+	}
+	return rctx
+}
+
+// patternParamNames returns the names of the URL parameters in the chi pattern like "/users/{id}" or "/users/{id:[0-9]+}".
+func patternParamNames(pattern string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return names
+		}
+		name, _, _ := strings.Cut(pattern[start+1:start+end], ":")
+		names = append(names, name)
+		pattern = pattern[start+end+1:]
+	}
+}
+
+// structTagNames returns the values of the struct tag in t and its nested structs.
+func structTagNames(t reflect.Type, tag string) []string {
+	return structTagNamesVisited(t, tag, nil, map[reflect.Type]struct{}{})
+}
+
+func structTagNamesVisited(t reflect.Type, tag string, names []string, visited map[reflect.Type]struct{}) []string {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	if _, ok := visited[t]; ok {
+		return names
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if v := ft.Tag.Get(tag); v != "" {
+			names = append(names, v)
+			continue
+		}
+		names = structTagNamesVisited(ft.Type, tag, names, visited)
+	}
+	return names
+}
@@ -0,0 +1,91 @@
+package tanukirpc_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type interopRequest struct {
+	ID   int    `urlparam:"id"`
+	Name string `query:"name"`
+}
+
+type interopResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func interopHandler() tanukirpc.Handler[struct{}] {
+	return tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req interopRequest) (*interopResponse, error) {
+		return &interopResponse{ID: req.ID, Name: req.Name}, nil
+	})
+}
+
+func getBody(t *testing.T, server *httptest.Server, path string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestRouterHandler(t *testing.T) {
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Route("/api", func(r *tanukirpc.Router[struct{}]) {
+		r.With(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("X-Middleware", "applied")
+				next.ServeHTTP(w, req)
+			})
+		}).Get("/users/{id}", interopHandler())
+	})
+
+	assert.Nil(t, r.Handler(http.MethodPost, "/api/users/{id}"))
+	h := r.Handler(http.MethodGet, "/api/users/{id}")
+	require.NotNil(t, h)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/users/{id}", h)
+	// the prefix is stripped, so the URL parameters are taken from PathValue
+	mux.Handle("GET /v2/users/{id}", http.StripPrefix("/v2", h))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, path := range []string{"/api/users/1?name=tanuki", "/v2/users/1?name=tanuki"} {
+		t.Run(path, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "application/json")
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "applied", resp.Header.Get("X-Middleware"))
+			assert.JSONEq(t, `{"id":1,"name":"tanuki"}`, string(b))
+		})
+	}
+}
+
+func TestHandlerFuncAdapter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", tanukirpc.HandlerFuncAdapter(struct{}{}, interopHandler()))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	status, body := getBody(t, server, "/users/2?name=pon")
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":2,"name":"pon"}`, body)
+}
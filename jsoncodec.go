@@ -0,0 +1,319 @@
+package tanukirpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type jsonCodecConfig struct {
+	disallowUnknownFields bool
+	useNumber             bool
+	indentPrefix          string
+	indent                string
+	escapeHTML            bool
+	nilSliceAsEmpty       bool
+	encoderFunc           EncoderFunc
+	decoderFunc           DecoderFunc
+	engine                JSONEngine
+}
+
+// JSONCodecOption is an option for the JSONCodec.
+type JSONCodecOption func(*jsonCodecConfig)
+
+// WithJSONDisallowUnknownFields makes the JSONCodec to reject the request that contains unknown fields.
+// The request is responded with 400 Bad Request and the name of the unknown field.
+func WithJSONDisallowUnknownFields() JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// WithJSONUseNumber makes the JSONCodec to decode a number into a json.Number instead of a float64 when the destination is an interface{}.
+// Also, the request that has a mismatched type of field is responded with 400 Bad Request and the name of the field.
+func WithJSONUseNumber() JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.useNumber = true
+	}
+}
+
+// WithJSONIndent makes the JSONCodec to indent the response like a json.MarshalIndent.
+func WithJSONIndent(prefix, indent string) JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.indentPrefix = prefix
+		c.indent = indent
+	}
+}
+
+// WithJSONEscapeHTML specifies whether problematic HTML characters should be escaped inside JSON quoted strings.
+// The default is true as same as encoding/json.
+func WithJSONEscapeHTML(on bool) JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.escapeHTML = on
+	}
+}
+
+// WithJSONNilSliceAsEmpty makes the JSONCodec to encode nil slices as [] instead of null.
+func WithJSONNilSliceAsEmpty() JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.nilSliceAsEmpty = true
+	}
+}
+
+// WithJSONEncoderFunc replaces the encoder of the JSONCodec.
+// This is useful to use an alternative JSON library like a github.com/goccy/go-json,
+// or to customize the encoding that encoding/json does not support (e.g. custom time format).
+// When this option is specified, WithJSONIndent and WithJSONEscapeHTML are ignored.
+func WithJSONEncoderFunc(fn EncoderFunc) JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.encoderFunc = fn
+	}
+}
+
+// WithJSONDecoderFunc replaces the decoder of the JSONCodec.
+// When this option is specified, WithJSONDisallowUnknownFields and WithJSONUseNumber are ignored.
+func WithJSONDecoderFunc(fn DecoderFunc) JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		c.decoderFunc = fn
+	}
+}
+
+// JSONEngine is the JSON library of the JSONCodec. The engines of github.com/goccy/go-json and github.com/segmentio/encoding/json
+// are provided by the jsonengine module, so tanukirpc does not depend on them. The other libraries that are compatible with encoding/json
+// can be adapted like:
+//
+//	var sonicEngine = tanukirpc.JSONEngine{
+//		NewEncoder: func(w io.Writer) tanukirpc.Encoder { return sonic.ConfigStd.NewEncoder(w) },
+//		NewDecoder: func(r io.Reader) tanukirpc.Decoder { return sonic.ConfigStd.NewDecoder(r) },
+//	}
+//
+// WithJSONDisallowUnknownFields, WithJSONUseNumber, WithJSONIndent and WithJSONEscapeHTML are applied
+// if the encoders and the decoders have the methods of them like encoding/json.
+type JSONEngine struct {
+	NewEncoder EncoderFunc
+	NewDecoder DecoderFunc
+}
+
+// StdJSONEngine is the JSONEngine of encoding/json. It is the default of the JSONCodec.
+var StdJSONEngine = JSONEngine{
+	NewEncoder: func(w io.Writer) Encoder { return json.NewEncoder(w) },
+	NewDecoder: func(r io.Reader) Decoder { return json.NewDecoder(r) },
+}
+
+// WithJSONEngine replaces the JSON library of the JSONCodec. Unlike WithJSONEncoderFunc and WithJSONDecoderFunc,
+// the other options are applied to the encoders and the decoders of the engine.
+func WithJSONEngine(engine JSONEngine) JSONCodecOption {
+	return func(c *jsonCodecConfig) {
+		if engine.NewEncoder == nil || engine.NewDecoder == nil {
+			panic("tanukirpc: JSONEngine requires NewEncoder and NewDecoder")
+		}
+		c.engine = engine
+	}
+}
+
+func (c *jsonCodecConfig) decoder(r io.Reader) Decoder {
+	if c.decoderFunc != nil {
+		return c.decoderFunc(r)
+	}
+	dec := c.engine.NewDecoder(r)
+	if c.disallowUnknownFields {
+		if d, ok := dec.(interface{ DisallowUnknownFields() }); ok {
+			d.DisallowUnknownFields()
+		}
+	}
+	if c.useNumber {
+		if d, ok := dec.(interface{ UseNumber() }); ok {
+			d.UseNumber()
+		}
+	}
+	if c.disallowUnknownFields || c.useNumber {
+		return &strictJSONDecoder{dec: dec}
+	}
+	return dec
+}
+
+func (c *jsonCodecConfig) encoder(w io.Writer) Encoder {
+	var enc Encoder
+	if c.encoderFunc != nil {
+		enc = c.encoderFunc(w)
+	} else {
+		enc = c.engine.NewEncoder(w)
+		if e, ok := enc.(interface{ SetIndent(prefix, indent string) }); ok {
+			e.SetIndent(c.indentPrefix, c.indent)
+		}
+		if e, ok := enc.(interface{ SetEscapeHTML(on bool) }); ok {
+			e.SetEscapeHTML(c.escapeHTML)
+		}
+	}
+	if c.nilSliceAsEmpty {
+		return &nilSliceAsEmptyEncoder{enc: enc}
+	}
+	return enc
+}
+
+const jsonUnknownFieldErrorPrefix = "json: unknown field "
+
+// JSONFieldError is an error that occurs when the request contains an unknown field or a field of mismatched type.
+// This error is returned by the JSONCodec with WithJSONDisallowUnknownFields or WithJSONUseNumber option.
+type JSONFieldError struct {
+	Field string
+	err   error
+}
+
+func (e *JSONFieldError) Error() string {
+	return fmt.Sprintf("invalid field %q: %v", e.Field, e.err)
+}
+
+func (e *JSONFieldError) Status() int {
+	return http.StatusBadRequest
+}
+
+func (e *JSONFieldError) Unwrap() error {
+	return e.err
+}
+
+type strictJSONDecoder struct {
+	dec Decoder
+}
+
+func (s *strictJSONDecoder) Decode(v any) error {
+	err := s.dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		return &JSONFieldError{Field: ute.Field, err: err}
+	}
+	if msg := err.Error(); strings.HasPrefix(msg, jsonUnknownFieldErrorPrefix) {
+		field, uerr := strconv.Unquote(strings.TrimPrefix(msg, jsonUnknownFieldErrorPrefix))
+		if uerr != nil {
+			field = strings.TrimPrefix(msg, jsonUnknownFieldErrorPrefix)
+		}
+		return &JSONFieldError{Field: field, err: err}
+	}
+	return err
+}
+
+type nilSliceAsEmptyEncoder struct {
+	enc Encoder
+}
+
+func (n *nilSliceAsEmptyEncoder) Encode(v any) error {
+	if v == nil {
+		return n.enc.Encode(v)
+	}
+	return n.enc.Encode(nilSliceAsEmpty(reflect.ValueOf(v)).Interface())
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// nilSliceAsEmpty returns a copy of v that nil slices are replaced with empty slices.
+// If v has the cycle of the pointers, v is returned unchanged and the encoder reports the cycle.
+func nilSliceAsEmpty(v reflect.Value) reflect.Value {
+	nv, ok := copyNilSliceAsEmpty(v, map[visitedRef]struct{}{})
+	if !ok {
+		return v
+	}
+	return nv
+}
+
+// visitedRef is the pointer of the value that is being copied by copyNilSliceAsEmpty, to detect the cycle.
+type visitedRef struct {
+	ptr uintptr
+	t   reflect.Type
+}
+
+// copyNilSliceAsEmpty copies v with the pointers, the slices and the maps on the path in visiting.
+// It returns false if the pointer on the path is found again.
+func copyNilSliceAsEmpty(v reflect.Value, visiting map[visitedRef]struct{}) (reflect.Value, bool) {
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) {
+		return v, true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if !v.IsNil() {
+			ref := visitedRef{ptr: v.Pointer(), t: t}
+			if _, ok := visiting[ref]; ok {
+				return v, false
+			}
+			visiting[ref] = struct{}{}
+			defer delete(visiting, ref)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, true
+		}
+		e, ok := copyNilSliceAsEmpty(v.Elem(), visiting)
+		if !ok {
+			return v, false
+		}
+		nv := reflect.New(t.Elem())
+		nv.Elem().Set(e)
+		return nv, true
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, true
+		}
+		e, ok := copyNilSliceAsEmpty(v.Elem(), visiting)
+		if !ok {
+			return v, false
+		}
+		nv := reflect.New(t).Elem()
+		nv.Set(e)
+		return nv, true
+	case reflect.Struct:
+		nv := reflect.New(t).Elem()
+		nv.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			f, ok := copyNilSliceAsEmpty(v.Field(i), visiting)
+			if !ok {
+				return v, false
+			}
+			nv.Field(i).Set(f)
+		}
+		return nv, true
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.MakeSlice(t, 0, 0), true
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v, true
+		}
+		nv := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, ok := copyNilSliceAsEmpty(v.Index(i), visiting)
+			if !ok {
+				return v, false
+			}
+			nv.Index(i).Set(e)
+		}
+		return nv, true
+	case reflect.Map:
+		if v.IsNil() {
+			return v, true
+		}
+		nv := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e, ok := copyNilSliceAsEmpty(iter.Value(), visiting)
+			if !ok {
+				return v, false
+			}
+			nv.SetMapIndex(iter.Key(), e)
+		}
+		return nv, true
+	}
+	return v, true
+}
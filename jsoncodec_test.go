@@ -0,0 +1,99 @@
+package tanukirpc_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingJSONEncoder struct {
+	*json.Encoder
+}
+
+type countingJSONDecoder struct {
+	*json.Decoder
+}
+
+func TestWithJSONEngine(t *testing.T) {
+	var encoders, decoders atomic.Int64
+	engine := tanukirpc.JSONEngine{
+		NewEncoder: func(w io.Writer) tanukirpc.Encoder {
+			encoders.Add(1)
+			return &countingJSONEncoder{Encoder: json.NewEncoder(w)}
+		},
+		NewDecoder: func(r io.Reader) tanukirpc.Decoder {
+			decoders.Add(1)
+			return &countingJSONDecoder{Decoder: json.NewDecoder(r)}
+		},
+	}
+	type echoRequest struct {
+		Message string `json:"message"`
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithCodec[struct{}](tanukirpc.CodecList{
+		tanukirpc.NewJSONCodec(
+			tanukirpc.WithJSONEngine(engine),
+			tanukirpc.WithJSONIndent("", "  "),
+			tanukirpc.WithJSONDisallowUnknownFields(),
+		),
+	}))
+	router.Post("/echo", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req echoRequest) (*echoRequest, error) {
+		return &req, nil
+	}))
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(`{"message":"hello"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "{\n  \"message\": \"hello\"\n}\n", rec.Body.String(), "the options are applied to the encoder of the engine")
+	assert.Equal(t, int64(1), encoders.Load())
+	assert.Equal(t, int64(1), decoders.Load())
+
+	rec = post(`{"message":"hello","unknown":1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "the options are applied to the decoder of the engine")
+
+	assert.Panics(t, func() {
+		tanukirpc.NewJSONCodec(tanukirpc.WithJSONEngine(tanukirpc.JSONEngine{}))
+	})
+}
+
+func TestWithJSONNilSliceAsEmptyCycle(t *testing.T) {
+	type node struct {
+		Name     string   `json:"name"`
+		Tags     []string `json:"tags"`
+		Next     *node    `json:"next,omitempty"`
+		Children []*node  `json:"children,omitempty"`
+	}
+	codec := tanukirpc.NewJSONCodec(tanukirpc.WithJSONNilSliceAsEmpty())
+	encode := func(v any) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		return rec, codec.Encode(rec, req, v)
+	}
+
+	shared := &node{Name: "shared"}
+	rec, err := encode(&node{Name: "root", Children: []*node{shared, shared}})
+	require.NoError(t, err, "the shared pointers are not the cycle")
+	assert.JSONEq(t, `{"name":"root","tags":[],"children":[{"name":"shared","tags":[]},{"name":"shared","tags":[]}]}`, rec.Body.String())
+
+	loop := &node{Name: "loop"}
+	loop.Next = loop
+	assert.NotPanics(t, func() {
+		_, err = encode(loop)
+	})
+	assert.Error(t, err, "the cycle is reported by the encoder")
+}
@@ -0,0 +1,47 @@
+// Package jsonengine provides the JSONEngines of github.com/goccy/go-json and github.com/segmentio/encoding/json
+// for tanukirpc.WithJSONEngine. It is the separate module, so the applications that use encoding/json
+// do not depend on these libraries.
+//
+//	codec := tanukirpc.NewJSONCodec(tanukirpc.WithJSONEngine(jsonengine.GoJSON))
+//
+// The options of the JSONCodec like WithJSONDisallowUnknownFields and WithJSONIndent are applied to the engines,
+// and the type errors of the requests are reported as tanukirpc.JSONFieldError like encoding/json.
+// BenchmarkJSONEngine compares the engines with encoding/json.
+package jsonengine
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/mackee/tanukirpc"
+	segmentiojson "github.com/segmentio/encoding/json"
+)
+
+// GoJSON is the JSONEngine of github.com/goccy/go-json.
+var GoJSON = tanukirpc.JSONEngine{
+	NewEncoder: func(w io.Writer) tanukirpc.Encoder { return gojson.NewEncoder(w) },
+	NewDecoder: func(r io.Reader) tanukirpc.Decoder { return &goJSONDecoder{Decoder: gojson.NewDecoder(r)} },
+}
+
+// Segmentio is the JSONEngine of github.com/segmentio/encoding/json.
+var Segmentio = tanukirpc.JSONEngine{
+	NewEncoder: func(w io.Writer) tanukirpc.Encoder { return segmentiojson.NewEncoder(w) },
+	NewDecoder: func(r io.Reader) tanukirpc.Decoder { return segmentiojson.NewDecoder(r) },
+}
+
+// goJSONDecoder converts the type errors of go-json to the ones of encoding/json,
+// which the JSONCodec reports as JSONFieldError.
+type goJSONDecoder struct {
+	*gojson.Decoder
+}
+
+func (d *goJSONDecoder) Decode(v any) error {
+	err := d.Decoder.Decode(v)
+	var ute *gojson.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		return &json.UnmarshalTypeError{Value: ute.Value, Type: ute.Type, Offset: ute.Offset, Struct: ute.Struct, Field: ute.Field}
+	}
+	return err
+}
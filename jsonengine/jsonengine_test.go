@@ -0,0 +1,169 @@
+package jsonengine_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/jsonengine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var engines = []struct {
+	name   string
+	engine tanukirpc.JSONEngine
+}{
+	{name: "std", engine: tanukirpc.StdJSONEngine},
+	{name: "gojson", engine: jsonengine.GoJSON},
+	{name: "segmentio", engine: jsonengine.Segmentio},
+}
+
+type noteRequest struct {
+	Title    string            `json:"title"`
+	Priority int               `json:"priority"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type noteResponse struct {
+	Title    string            `json:"title"`
+	HTML     string            `json:"html"`
+	Priority int               `json:"priority"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func newRouter(engine tanukirpc.JSONEngine) *tanukirpc.Router[struct{}] {
+	codec := tanukirpc.NewJSONCodec(
+		tanukirpc.WithJSONEngine(engine),
+		tanukirpc.WithJSONDisallowUnknownFields(),
+		tanukirpc.WithJSONIndent("", "  "),
+	)
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithCodec[struct{}](codec),
+		tanukirpc.WithAccessLogger[struct{}](nil),
+	)
+	router.Post("/notes", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req noteRequest) (*noteResponse, error) {
+		return &noteResponse{Title: req.Title, HTML: "<b>" + req.Title + "</b>", Priority: req.Priority, Labels: req.Labels}, nil
+	}))
+	return router
+}
+
+// TestEngines tests that the engines respond the same as encoding/json with the options of the JSONCodec.
+func TestEngines(t *testing.T) {
+	bodies := []string{
+		`{"title":"buy milk","priority":3,"labels":{"area":"kitchen","team":"core"}}`,
+		`{"title":"buy milk","unknown":true}`,
+		`{"title":"buy milk","priority":"high"}`,
+		`{"title":`,
+	}
+	serve := func(engine tanukirpc.JSONEngine, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		newRouter(engine).ServeHTTP(rec, req)
+		return rec
+	}
+	for _, body := range bodies {
+		expected := serve(tanukirpc.StdJSONEngine, body)
+		for _, e := range engines[1:] {
+			t.Run(e.name+" "+body, func(t *testing.T) {
+				rec := serve(e.engine, body)
+				require.Equal(t, expected.Code, rec.Code, rec.Body.String())
+				if expected.Code == http.StatusOK {
+					assert.Equal(t, expected.Body.String(), rec.Body.String(), "the indent and the escape of HTML are applied")
+				}
+			})
+		}
+	}
+
+	rec := serve(jsonengine.GoJSON, `{"title":"buy milk","priority":"high"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `invalid field \"priority\"`)
+}
+
+type benchmarkTaskRequest struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Priority    int               `json:"priority"`
+	DueAt       time.Time         `json:"due_at"`
+	Tags        []string          `json:"tags"`
+	Labels      map[string]string `json:"labels"`
+}
+
+type benchmarkTaskResponse struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
+	Assignees []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"assignees"`
+}
+
+type benchmarkJSONShape struct {
+	name string
+	new  func() any
+	body any
+}
+
+// benchmarkJSONShapes returns the representative requests and responses: the tiny message, the request of the task and the list of 1000 tasks.
+func benchmarkJSONShapes() []benchmarkJSONShape {
+	task := &benchmarkTaskRequest{
+		Title:       "buy milk",
+		Description: strings.Repeat("tanuki ", 20),
+		Priority:    3,
+		DueAt:       time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{"home", "shopping"},
+		Labels:      map[string]string{"team": "core", "area": "kitchen"},
+	}
+	tasks := make([]benchmarkTaskResponse, 1000)
+	for i := range tasks {
+		tasks[i] = benchmarkTaskResponse{ID: int64(i), Title: "buy milk", CreatedAt: task.DueAt}
+		tasks[i].Assignees = append(tasks[i].Assignees, struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		}{ID: 1, Name: "tanuki"})
+	}
+	return []benchmarkJSONShape{
+		{name: "small", new: func() any { return &struct{ Message string }{} }, body: &struct{ Message string }{Message: "hello"}},
+		{name: "task", new: func() any { return &benchmarkTaskRequest{} }, body: task},
+		{name: "list1k", new: func() any { return &[]benchmarkTaskResponse{} }, body: &tasks},
+	}
+}
+
+// BenchmarkJSONEngine compares the engines with encoding/json. The results are in testdata/benchmark.txt.
+func BenchmarkJSONEngine(b *testing.B) {
+	for _, shape := range benchmarkJSONShapes() {
+		data, err := json.Marshal(shape.body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, e := range engines {
+			b.Run("encode/"+shape.name+"/"+e.name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(data)))
+				for i := 0; i < b.N; i++ {
+					if err := e.engine.NewEncoder(io.Discard).Encode(shape.body); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("decode/"+shape.name+"/"+e.name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(data)))
+				for i := 0; i < b.N; i++ {
+					if err := e.engine.NewDecoder(strings.NewReader(string(data))).Decode(shape.new()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package tanukirpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/mackee/tanukirpc/internal/keycase"
+)
+
+// JSONKeyCase is the case of the keys of the JSON bodies on the wire.
+type JSONKeyCase string
+
+const (
+	// JSONKeyCaseCamel is the camelCase keys like "dueAt" for the structs that are tagged in snake_case like `json:"due_at"`.
+	JSONKeyCaseCamel JSONKeyCase = "camel"
+	// JSONKeyCaseSnake is the snake_case keys like "due_at" for the structs that are tagged in camelCase like `json:"dueAt"`.
+	JSONKeyCaseSnake JSONKeyCase = "snake"
+)
+
+// WithJSONKeyCase converts the keys of the JSON requests from the case of the clients to the case of the json tags before they are decoded,
+// and the keys of the JSON responses to the case of the clients after they are encoded.
+// Only the keys of the struct fields with the json tags are converted, following the types of the requests and the responses,
+// so the keys of the maps and the values of json.RawMessage are kept as they are. The streaming responses and the error responses are not converted.
+// Generate the TypeScript client with the -key-case flag of gentypescript to match the types to the case.
+func WithJSONKeyCase[Reg any](c JSONKeyCase) RouterOption[Reg] {
+	var mw *jsonKeyCaseMiddleware
+	switch c {
+	case JSONKeyCaseCamel:
+		mw = &jsonKeyCaseMiddleware{decode: keycase.Snake, encode: keycase.Camel}
+	case JSONKeyCaseSnake:
+		mw = &jsonKeyCaseMiddleware{decode: keycase.Camel, encode: keycase.Snake}
+	default:
+		panic(fmt.Sprintf("tanukirpc: unknown JSON key case %q", c))
+	}
+	return WithCodecMiddleware[Reg](mw)
+}
+
+type jsonKeyCaseMiddleware struct {
+	decode func(string) string
+	encode func(string) string
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == defaultJSONCodecContentType
+}
+
+func (m *jsonKeyCaseMiddleware) Decode(r *http.Request, v any, next DecodeFunc) error {
+	if !isJSONContentType(r.Header.Get("content-type")) {
+		return next(r, v)
+	}
+	t := reflect.TypeOf(v)
+	return TransformRequestBody(func(r *http.Request, body []byte) ([]byte, error) {
+		return convertJSONKeys(body, jsonKeyNode{t: t}, m.decode, true)
+	}).Decode(r, v, next)
+}
+
+func (m *jsonKeyCaseMiddleware) Encode(w http.ResponseWriter, r *http.Request, v any, next EncodeFunc) error {
+	if isRawResponse(v) || isSeqResponse(v) {
+		return next(w, r, v)
+	}
+	cw := &captureResponseWriter{ResponseWriter: w}
+	if err := next(cw, r, v); err != nil {
+		return err
+	}
+	body := cw.buf.Bytes()
+	if len(body) > 0 && isJSONContentType(w.Header().Get("content-type")) {
+		var err error
+		if body, err = convertJSONKeys(body, jsonKeyNode{t: reflect.TypeOf(v), v: reflect.ValueOf(v)}, m.encode, false); err != nil {
+			return &ErrCodecEncode{err: err}
+		}
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write body: %w", err)
+	}
+	return nil
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// jsonKeyNode is the Go type of the JSON value. v is the value of the response, which resolves the interfaces like the data of Envelope.
+// It is invalid for the requests that are not decoded yet.
+type jsonKeyNode struct {
+	t reflect.Type
+	v reflect.Value
+}
+
+// resolve dereferences the pointers and the interfaces of the node. The types that marshal themselves are opaque, so t is nil for them.
+func (n jsonKeyNode) resolve() jsonKeyNode {
+	for n.t != nil {
+		if n.t.Implements(jsonMarshalerType) || n.t.Implements(jsonUnmarshalerType) || reflect.PointerTo(n.t).Implements(jsonUnmarshalerType) {
+			return jsonKeyNode{}
+		}
+		switch n.t.Kind() {
+		case reflect.Pointer:
+			n.t = n.t.Elem()
+			if n.v.IsValid() {
+				n.v = n.v.Elem()
+			}
+		case reflect.Interface:
+			if !n.v.IsValid() || n.v.IsNil() {
+				return jsonKeyNode{}
+			}
+			n.v = n.v.Elem()
+			n.t = n.v.Type()
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// elem returns the node of the n-th element of the array or the value of the key of the map.
+func (n jsonKeyNode) elem(index int, key string) jsonKeyNode {
+	if n.t == nil {
+		return jsonKeyNode{}
+	}
+	switch n.t.Kind() {
+	case reflect.Slice, reflect.Array:
+		e := jsonKeyNode{t: n.t.Elem()}
+		if n.v.IsValid() && index < n.v.Len() {
+			e.v = n.v.Index(index)
+		}
+		return e
+	case reflect.Map:
+		e := jsonKeyNode{t: n.t.Elem()}
+		if n.v.IsValid() && n.t.Key().Kind() == reflect.String {
+			e.v = n.v.MapIndex(reflect.ValueOf(key).Convert(n.t.Key()))
+		}
+		return e
+	}
+	return jsonKeyNode{}
+}
+
+// jsonKeyField is the field of the struct in the JSON object. tagged is true if the name is from the json tag.
+type jsonKeyField struct {
+	index  []int
+	tagged bool
+}
+
+// jsonKeyFields is the cache of the fields of the struct types by the keys of the JSON objects.
+var jsonKeyFields sync.Map
+
+func structJSONKeyFields(t reflect.Type) map[string]jsonKeyField {
+	if cached, ok := jsonKeyFields.Load(t); ok {
+		return cached.(map[string]jsonKeyField)
+	}
+	fields := map[string]jsonKeyField{}
+	collectJSONKeyFields(t, nil, fields, map[reflect.Type]struct{}{})
+	cached, _ := jsonKeyFields.LoadOrStore(t, fields)
+	return cached.(map[string]jsonKeyField)
+}
+
+func collectJSONKeyFields(t reflect.Type, index []int, fields map[string]jsonKeyField, visited map[reflect.Type]struct{}) {
+	if _, ok := visited[t]; ok {
+		return
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		if sf.Anonymous && name == "" {
+			// the fields of the embedded struct are promoted to the object
+			if et := indirectType(sf.Type); et.Kind() == reflect.Struct {
+				collectJSONKeyFields(et, fieldIndex, fields, visited)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = jsonKeyField{index: fieldIndex, tagged: tagged}
+		}
+	}
+}
+
+// lookupJSONKeyField returns the field of the key. It matches case-insensitively like encoding/json if exact is false.
+func lookupJSONKeyField(fields map[string]jsonKeyField, key string, exact bool) (jsonKeyField, bool) {
+	if f, ok := fields[key]; ok || exact {
+		return f, ok
+	}
+	for name, f := range fields {
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return jsonKeyField{}, false
+}
+
+// field returns the key that is converted by conv and the node of the value of the key in the struct.
+// The keys of the requests are converted if they match the tagged fields after the conversion,
+// and the keys of the responses are converted if they are the names of the tagged fields.
+func (n jsonKeyNode) field(key string, conv func(string) string, decode bool) (string, jsonKeyNode) {
+	if n.t == nil {
+		return key, jsonKeyNode{}
+	}
+	if n.t.Kind() != reflect.Struct {
+		return key, n.elem(0, key)
+	}
+	fields := structJSONKeyFields(n.t)
+	converted := key
+	f, ok := lookupJSONKeyField(fields, key, !decode)
+	if decode {
+		if cf, cok := lookupJSONKeyField(fields, conv(key), false); cok && cf.tagged {
+			f, ok, converted = cf, true, conv(key)
+		}
+	} else if ok && f.tagged {
+		converted = conv(key)
+	}
+	if !ok {
+		return key, jsonKeyNode{}
+	}
+	fn := jsonKeyNode{t: n.t.FieldByIndex(f.index).Type}
+	if n.v.IsValid() {
+		if fv, err := n.v.FieldByIndexErr(f.index); err == nil {
+			fn.v = fv
+		}
+	}
+	return converted, fn
+}
+
+// convertJSONKeys converts the keys of the objects in the JSON by conv, walking the JSON with the Go type of root.
+// The order of the keys and the numbers are kept as they are.
+func convertJSONKeys(data []byte, root jsonKeyNode, conv func(string) string, decode bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	type container struct {
+		object bool
+		n      int
+		node   jsonKeyNode
+		value  jsonKeyNode
+	}
+	var stack []*container
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert keys of JSON: %w", err)
+		}
+		var top *container
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			buf.WriteRune(rune(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		isKey := false
+		node := root
+		if top != nil {
+			switch {
+			case top.object && top.n%2 == 1:
+				buf.WriteByte(':')
+			case top.n > 0:
+				buf.WriteByte(',')
+			}
+			isKey = top.object && top.n%2 == 0
+			if top.object {
+				node = top.value
+			} else {
+				node = top.node.elem(top.n, "")
+			}
+			top.n++
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			buf.WriteRune(rune(t))
+			stack = append(stack, &container{object: t == '{', node: node.resolve()})
+		case string:
+			if isKey {
+				t, top.value = top.node.field(t, conv, decode)
+			}
+			b, err := json.Marshal(t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert keys of JSON: %w", err)
+			}
+			buf.Write(b)
+		case json.Number:
+			buf.WriteString(t.String())
+		case bool:
+			if t {
+				buf.WriteString("true")
+			} else {
+				buf.WriteString("false")
+			}
+		case nil:
+			buf.WriteString("null")
+		}
+	}
+	if bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
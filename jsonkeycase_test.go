@@ -0,0 +1,106 @@
+package tanukirpc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithJSONKeyCase(t *testing.T) {
+	type subtask struct {
+		TaskID int64 `json:"task_id"`
+		IsDone bool  `json:"is_done"`
+	}
+	type task struct {
+		TaskID   int64             `json:"task_id"`
+		DueAt    string            `json:"due_at"`
+		Subtasks []subtask         `json:"sub_tasks"`
+		Labels   map[string]string `json:"labels"`
+		Note     *string           `json:"note"`
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithJSONKeyCase[struct{}](tanukirpc.JSONKeyCaseCamel))
+	router.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req task) (*task, error) {
+		return &req, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(
+		`{"taskID":9007199254740993,"dueAt":"2024-08-01","subTasks":[{"taskId":2,"isDone":true}],"labels":{"team_name":"core"},"note":null}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t,
+		`{"taskId":9007199254740993,"dueAt":"2024-08-01","subTasks":[{"taskId":2,"isDone":true}],"labels":{"team_name":"core"},"note":null}`+"\n",
+		rec.Body.String(),
+	)
+
+	snake := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithJSONKeyCase[struct{}](tanukirpc.JSONKeyCaseSnake))
+	snake.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		DueAt string `json:"dueAt"`
+	}) (*struct {
+		DueAt string `json:"dueAt"`
+	}, error) {
+		return &req, nil
+	}))
+	req = httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"due_at":"2024-08-01"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	snake.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"due_at":"2024-08-01"}`, rec.Body.String())
+
+	assert.Panics(t, func() {
+		tanukirpc.WithJSONKeyCase[struct{}]("kebab")
+	})
+}
+
+func TestWithJSONKeyCaseKeepsNonStructKeys(t *testing.T) {
+	type payload struct {
+		EventType string          `json:"event_type"`
+		RawBody   json.RawMessage `json:"raw_body"`
+		Extra     map[string]any  `json:"extra"`
+		Untagged  string
+	}
+	type embedded struct {
+		CreatedBy string `json:"created_by"`
+	}
+	type webhook struct {
+		embedded
+		Payloads []*payload `json:"payloads"`
+	}
+	router := tanukirpc.NewRouter(struct{}{},
+		tanukirpc.WithJSONKeyCase[struct{}](tanukirpc.JSONKeyCaseCamel),
+		tanukirpc.WithResponseEnvelope[struct{}](nil),
+	)
+	router.Post("/webhooks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req webhook) (*webhook, error) {
+		return &req, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(
+		`{"createdBy":"tanuki","payloads":[{"eventType":"push","rawBody":{"head_commit":{"user_id":1}},"extra":{"retry_count":2,"nested_map":{"a_b":1}},"Untagged":"x"}]}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.JSONEq(t,
+		`{"createdBy":"tanuki","payloads":[{"eventType":"push","rawBody":{"head_commit":{"user_id":1}},"extra":{"retry_count":2,"nested_map":{"a_b":1}},"Untagged":"x"}]}`,
+		string(got.Data),
+	)
+	assert.Contains(t, rec.Body.String(), `"durationMs"`, "the keys of the envelope are converted")
+}
@@ -0,0 +1,96 @@
+// Package kv provides the caches for the registries of tanukirpc.
+//
+// Cache is the interface of the caches, and there are the implementations of the scopes:
+//
+//   - NewLRU is the in-memory LRU cache of the process. Create it once with the registry.
+//   - NewRedis is the cache on Redis that is shared by the processes.
+//   - NewRequestScoped is the in-memory cache of the request. Create it in the ContextFactory or the Transformer for each request.
+//
+// The handlers read the values through the cache by GetOrLoad, and write them after the response by WriteBehind:
+//
+//	type registry struct {
+//		tasks kv.Cache[int, *Task]
+//	}
+//
+//	reg := &registry{tasks: kv.NewLRU[int, *Task](1000)}
+//
+//	func getTask(ctx tanukirpc.Context[*registry], req *getTaskRequest) (*Task, error) {
+//		db := ctx.Registry().db
+//		return kv.GetOrLoad(ctx, ctx.Registry().tasks, req.ID, time.Minute, func(ctx context.Context) (*Task, error) {
+//			return db.GetTask(ctx, req.ID)
+//		})
+//	}
+package kv
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+)
+
+// Cache is the cache of the values by the keys. The ttl of 0 keeps the value until it is evicted or deleted.
+type Cache[K comparable, V any] interface {
+	// Get returns the value of the key. ok is false if the key is not cached.
+	Get(ctx context.Context, key K) (value V, ok bool, err error)
+	Set(ctx context.Context, key K, value V, ttl time.Duration) error
+	Delete(ctx context.Context, key K) error
+}
+
+// GetOrLoad returns the cached value of the key, or loads it by load and caches it with ttl.
+// The error of the cache to get the value is ignored and the value is loaded, so the cache does not fail the request.
+func GetOrLoad[K comparable, V any](ctx context.Context, c Cache[K, V], key K, ttl time.Duration, load func(ctx context.Context) (V, error)) (V, error) {
+	if v, ok, err := c.Get(ctx, key); err == nil && ok {
+		return v, nil
+	}
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+	if err := c.Set(ctx, key, v, ttl); err != nil {
+		slog.WarnContext(ctx, "failed to set the cache", slog.Any("key", key), slog.Any("error", err))
+	}
+	return v, nil
+}
+
+// WriteBehind sets the value to the cache after the response by Context.Defer, so the handler does not wait for the write of the cache.
+// The error of the write is handled as the error of the deferred function.
+func WriteBehind[Reg any, K comparable, V any](ctx tanukirpc.Context[Reg], c Cache[K, V], key K, value V, ttl time.Duration) {
+	ctx.Defer(func() error {
+		return c.Set(context.WithoutCancel(ctx), key, value, ttl)
+	}, tanukirpc.DeferDoTimingAfterResponse)
+}
+
+type requestScoped[K comparable, V any] struct {
+	mu     sync.Mutex
+	values map[K]V
+}
+
+// NewRequestScoped returns the in-memory Cache of the request, which memoizes the values while handling the request.
+// The values are never expired, and ttl is ignored.
+func NewRequestScoped[K comparable, V any]() Cache[K, V] {
+	return &requestScoped[K, V]{values: make(map[K]V)}
+}
+
+func (c *requestScoped[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	return v, ok, nil
+}
+
+func (c *requestScoped[K, V]) Set(ctx context.Context, key K, value V, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *requestScoped[K, V]) Delete(ctx context.Context, key K) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
@@ -0,0 +1,146 @@
+package kv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.values[key]
+	return v, ok, nil
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = value
+	return nil
+}
+
+func (r *fakeRedis) Del(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, key)
+	return nil
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c := kv.NewLRU[string, int](2)
+	require.NoError(t, c.Set(ctx, "a", 1, 0))
+	require.NoError(t, c.Set(ctx, "b", 2, 0))
+	_, _, _ = c.Get(ctx, "a")
+	require.NoError(t, c.Set(ctx, "c", 3, 0))
+
+	_, ok, err := c.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, ok, "the least recently used value is evicted")
+	v, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, c.Len())
+
+	require.NoError(t, c.Set(ctx, "d", 4, 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok, "the value is expired")
+
+	require.NoError(t, c.Delete(ctx, "a"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestRedis(t *testing.T) {
+	type task struct {
+		Title string `json:"title"`
+	}
+	ctx := context.Background()
+	client := &fakeRedis{values: map[string][]byte{}}
+	c := kv.NewRedis[int, *task](client, kv.WithRedisPrefix("tasks:"))
+
+	require.NoError(t, c.Set(ctx, 1, &task{Title: "buy milk"}, time.Minute))
+	assert.Equal(t, `{"title":"buy milk"}`, string(client.values["tasks:1"]))
+	v, ok, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, &task{Title: "buy milk"}, v)
+
+	require.NoError(t, c.Delete(ctx, 1))
+	_, ok, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestGetOrLoadAndWriteBehind(t *testing.T) {
+	type registry struct {
+		tasks kv.Cache[string, string]
+		loads int
+	}
+	reg := &registry{tasks: kv.NewLRU[string, string](10)}
+	router := tanukirpc.NewRouter(reg)
+	router.Get("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[*registry], req struct {
+		ID string `urlparam:"id"`
+	}) (string, error) {
+		return kv.GetOrLoad(ctx, ctx.Registry().tasks, req.ID, time.Minute, func(context.Context) (string, error) {
+			ctx.Registry().loads++
+			return "task " + req.ID, nil
+		})
+	}))
+	router.Put("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[*registry], req struct {
+		ID    string `urlparam:"id"`
+		Title string `json:"title"`
+	}) (string, error) {
+		kv.WriteBehind(ctx, ctx.Registry().tasks, req.ID, req.Title, time.Minute)
+		return req.Title, nil
+	}))
+	serve := func(method, path, body string) string {
+		var req *http.Request
+		if body == "" {
+			req = httptest.NewRequest(method, path, nil)
+		} else {
+			req = httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec.Body.String()
+	}
+
+	assert.JSONEq(t, `"task 1"`, serve(http.MethodGet, "/tasks/1", ""))
+	assert.JSONEq(t, `"task 1"`, serve(http.MethodGet, "/tasks/1", ""))
+	assert.Equal(t, 1, reg.loads)
+
+	assert.JSONEq(t, `"renamed"`, serve(http.MethodPut, "/tasks/1", `{"title":"renamed"}`))
+	assert.JSONEq(t, `"renamed"`, serve(http.MethodGet, "/tasks/1", ""))
+	assert.Equal(t, 1, reg.loads)
+}
+
+func TestRequestScoped(t *testing.T) {
+	ctx := context.Background()
+	c := kv.NewRequestScoped[string, int]()
+	require.NoError(t, c.Set(ctx, "a", 1, time.Nanosecond))
+	v, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
@@ -0,0 +1,114 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RedisClient is the interface of the Redis client for NewRedis. kv does not import the Redis client,
+// so adapt the client like go-redis to it:
+//
+//	type redisClient struct{ *redis.Client }
+//
+//	func (c redisClient) Get(ctx context.Context, key string) ([]byte, bool, error) {
+//		b, err := c.Client.Get(ctx, key).Bytes()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, false, nil
+//		}
+//		return b, err == nil, err
+//	}
+//
+//	func (c redisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//		return c.Client.Set(ctx, key, value, ttl).Err()
+//	}
+//
+//	func (c redisClient) Del(ctx context.Context, key string) error {
+//		return c.Client.Del(ctx, key).Err()
+//	}
+type RedisClient interface {
+	// Get returns the value of the key. ok is false if the key does not exist.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set sets the value of the key with ttl. The ttl of 0 keeps the value.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+type redisOptions struct {
+	prefix    string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+// RedisOption is the option of NewRedis.
+type RedisOption func(*redisOptions)
+
+// WithRedisPrefix sets the prefix of the keys of Redis, like "tasks:". The keys are formatted by fmt.Sprint after the prefix.
+func WithRedisPrefix(prefix string) RedisOption {
+	return func(o *redisOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithRedisMarshaler sets the functions to encode and decode the values. The default is encoding/json.
+func WithRedisMarshaler(marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) RedisOption {
+	return func(o *redisOptions) {
+		o.marshal = marshal
+		o.unmarshal = unmarshal
+	}
+}
+
+type redisCache[K comparable, V any] struct {
+	client RedisClient
+	opts   *redisOptions
+}
+
+// NewRedis returns the Cache on Redis that is shared by the processes.
+func NewRedis[K comparable, V any](client RedisClient, opts ...RedisOption) Cache[K, V] {
+	o := &redisOptions{
+		marshal:   json.Marshal,
+		unmarshal: json.Unmarshal,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &redisCache[K, V]{client: client, opts: o}
+}
+
+func (c *redisCache[K, V]) key(key K) string {
+	return c.opts.prefix + fmt.Sprint(key)
+}
+
+func (c *redisCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	var v V
+	data, ok, err := c.client.Get(ctx, c.key(key))
+	if err != nil {
+		return v, false, fmt.Errorf("failed to get %s from redis: %w", c.key(key), err)
+	}
+	if !ok {
+		return v, false, nil
+	}
+	if err := c.opts.unmarshal(data, &v); err != nil {
+		return v, false, fmt.Errorf("failed to decode %s: %w", c.key(key), err)
+	}
+	return v, true, nil
+}
+
+func (c *redisCache[K, V]) Set(ctx context.Context, key K, value V, ttl time.Duration) error {
+	data, err := c.opts.marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", c.key(key), err)
+	}
+	if err := c.client.Set(ctx, c.key(key), data, ttl); err != nil {
+		return fmt.Errorf("failed to set %s to redis: %w", c.key(key), err)
+	}
+	return nil
+}
+
+func (c *redisCache[K, V]) Delete(ctx context.Context, key K) error {
+	if err := c.client.Del(ctx, c.key(key)); err != nil {
+		return fmt.Errorf("failed to delete %s from redis: %w", c.key(key), err)
+	}
+	return nil
+}
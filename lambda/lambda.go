@@ -0,0 +1,230 @@
+// Package lambda runs the Router on AWS Lambda. It translates the events of API Gateway HTTP API and Lambda Function URLs
+// (the payload format version 2.0) to http.Request, and the responses back to the events.
+//
+// Start runs the function with the Lambda runtime API, so no other framework is needed:
+//
+//	func main() {
+//		r := tanukirpc.NewRouter(reg)
+//		r.Get("/hello", tanukirpc.NewHandler(hello))
+//		if err := lambda.Start(r); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// Handler also implements the lambda.Handler interface of github.com/aws/aws-lambda-go.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Event is the event of API Gateway HTTP API and Lambda Function URLs in the payload format version 2.0.
+type Event struct {
+	Version               string            `json:"version"`
+	RouteKey              string            `json:"routeKey"`
+	RawPath               string            `json:"rawPath"`
+	RawQueryString        string            `json:"rawQueryString"`
+	Cookies               []string          `json:"cookies,omitempty"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters,omitempty"`
+	PathParameters        map[string]string `json:"pathParameters,omitempty"`
+	StageVariables        map[string]string `json:"stageVariables,omitempty"`
+	RequestContext        RequestContext    `json:"requestContext"`
+	Body                  string            `json:"body,omitempty"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded"`
+}
+
+// RequestContext is the context of the request in Event.
+type RequestContext struct {
+	AccountID    string             `json:"accountId"`
+	APIID        string             `json:"apiId"`
+	DomainName   string             `json:"domainName"`
+	DomainPrefix string             `json:"domainPrefix"`
+	HTTP         RequestContextHTTP `json:"http"`
+	RequestID    string             `json:"requestId"`
+	RouteKey     string             `json:"routeKey"`
+	Stage        string             `json:"stage"`
+	Time         string             `json:"time"`
+	TimeEpoch    int64              `json:"timeEpoch"`
+	// Authorizer is the result of the authorizer like the claims of JWT. It is kept as it is.
+	Authorizer json.RawMessage `json:"authorizer,omitempty"`
+}
+
+// RequestContextHTTP is the HTTP information of the request in RequestContext.
+type RequestContextHTTP struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Protocol  string `json:"protocol"`
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// Response is the response of API Gateway HTTP API and Lambda Function URLs in the payload format version 2.0.
+type Response struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Cookies         []string          `json:"cookies,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+type eventContextKey struct{}
+
+// EventFromContext returns the event of the request. It is available in the handlers served by Handler.
+func EventFromContext(ctx context.Context) (*Event, bool) {
+	event, ok := ctx.Value(eventContextKey{}).(*Event)
+	return event, ok
+}
+
+// Handler serves the events with the http.Handler like *tanukirpc.Router.
+type Handler struct {
+	handler http.Handler
+}
+
+// NewHandler returns the Handler that serves the events with handler.
+func NewHandler(handler http.Handler) *Handler {
+	return &Handler{handler: handler}
+}
+
+// Invoke serves the event in payload, and returns the response.
+func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	res, err := h.Serve(ctx, &event)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return b, nil
+}
+
+// Serve serves the event, and returns the response.
+func (h *Handler) Serve(ctx context.Context, event *Event) (*Response, error) {
+	req, err := NewRequest(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	w := newResponseWriter()
+	h.handler.ServeHTTP(w, req)
+	return w.response(), nil
+}
+
+// NewRequest translates the event to http.Request. The event is available by EventFromContext.
+func NewRequest(ctx context.Context, event *Event) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode body: %w", err)
+		}
+		body = b
+	}
+	method := event.RequestContext.HTTP.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := event.RawPath
+	if path == "" {
+		path = event.RequestContext.HTTP.Path
+	}
+	u := &url.URL{Path: path, RawQuery: event.RawQueryString}
+	if raw, err := url.PathUnescape(path); err == nil && raw != path {
+		u.Path, u.RawPath = raw, path
+	}
+
+	ctx = context.WithValue(ctx, eventContextKey{}, event)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// the values of the same header are joined with commas in the event
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	req.Host = req.Header.Get("Host")
+	if req.Host == "" {
+		req.Host = event.RequestContext.DomainName
+	}
+	req.URL.Host = req.Host
+	req.RequestURI = u.RequestURI()
+	req.RemoteAddr = event.RequestContext.HTTP.SourceIP
+	if proto := event.RequestContext.HTTP.Protocol; proto != "" {
+		if major, minor, ok := http.ParseHTTPVersion(proto); ok {
+			req.Proto, req.ProtoMajor, req.ProtoMinor = proto, major, minor
+		}
+	}
+	return req, nil
+}
+
+// responseWriter buffers the response to translate it to Response.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: http.Header{}}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// Flush is no-op. The response is sent when the handler returns.
+func (w *responseWriter) Flush() {}
+
+func (w *responseWriter) response() *Response {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &Response{StatusCode: status, Headers: make(map[string]string, len(w.header))}
+	for k, vs := range w.header {
+		if k == "Set-Cookie" {
+			res.Cookies = append(res.Cookies, vs...)
+			continue
+		}
+		res.Headers[k] = strings.Join(vs, ",")
+	}
+	if _, ok := w.header["Content-Type"]; !ok && w.body.Len() > 0 {
+		res.Headers["Content-Type"] = http.DetectContentType(w.body.Bytes())
+	}
+	// the compressed or binary bodies are encoded in base64
+	if w.header.Get("Content-Encoding") != "" || !utf8.Valid(w.body.Bytes()) {
+		res.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		res.IsBase64Encoded = true
+	} else {
+		res.Body = w.body.String()
+	}
+	return res
+}
@@ -0,0 +1,188 @@
+package lambda_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/lambda"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoRequest struct {
+	ID   int    `urlparam:"id"`
+	Name string `json:"name"`
+}
+
+type echoResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	Cookie    string `json:"cookie"`
+	Host      string `json:"host"`
+	RequestID string `json:"request_id"`
+}
+
+func newRouter() *tanukirpc.Router[struct{}] {
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Post("/users/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req echoRequest) (*echoResponse, error) {
+		var session string
+		if cookie, err := ctx.Request().Cookie("session"); err == nil {
+			session = cookie.Value
+		}
+		var requestID string
+		if event, ok := lambda.EventFromContext(ctx); ok {
+			requestID = event.RequestContext.RequestID
+		}
+		http.SetCookie(ctx.Response(), &http.Cookie{Name: "seen", Value: "1"})
+		return &echoResponse{
+			ID:        req.ID,
+			Name:      req.Name,
+			Query:     ctx.Request().URL.Query().Get("q"),
+			Cookie:    session,
+			Host:      ctx.Request().Host,
+			RequestID: requestID,
+		}, nil
+	}))
+	r.Get("/binary", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.RawResponse, error) {
+		return &tanukirpc.RawResponse{ContentType: "image/png", Body: bytes.NewReader([]byte{0x89, 0x50, 0x4e, 0x47, 0xff})}, nil
+	}))
+	return r
+}
+
+func TestHandlerInvoke(t *testing.T) {
+	h := lambda.NewHandler(newRouter())
+
+	t.Run("json", func(t *testing.T) {
+		event := lambda.Event{
+			Version:        "2.0",
+			RawPath:        "/users/42",
+			RawQueryString: "q=tanuki",
+			Cookies:        []string{"session=abc"},
+			Headers:        map[string]string{"content-type": "application/json", "accept": "application/json", "host": "example.com"},
+			RequestContext: lambda.RequestContext{
+				RequestID: "req-1",
+				HTTP:      lambda.RequestContextHTTP{Method: http.MethodPost, Path: "/users/42", Protocol: "HTTP/1.1", SourceIP: "192.0.2.1"},
+			},
+			Body:            base64.StdEncoding.EncodeToString([]byte(`{"name":"pon"}`)),
+			IsBase64Encoded: true,
+		}
+		payload, err := json.Marshal(event)
+		require.NoError(t, err)
+		out, err := h.Invoke(context.Background(), payload)
+		require.NoError(t, err)
+
+		var res lambda.Response
+		require.NoError(t, json.Unmarshal(out, &res))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.False(t, res.IsBase64Encoded)
+		assert.Equal(t, "application/json", res.Headers["Content-Type"])
+		assert.Equal(t, []string{"seen=1"}, res.Cookies)
+		var body echoResponse
+		require.NoError(t, json.Unmarshal([]byte(res.Body), &body))
+		assert.Equal(t, echoResponse{ID: 42, Name: "pon", Query: "tanuki", Cookie: "abc", Host: "example.com", RequestID: "req-1"}, body)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		res, err := h.Serve(context.Background(), &lambda.Event{
+			RawPath:        "/binary",
+			RequestContext: lambda.RequestContext{HTTP: lambda.RequestContextHTTP{Method: http.MethodGet}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.True(t, res.IsBase64Encoded)
+		body, err := base64.StdEncoding.DecodeString(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x89, 0x50, 0x4e, 0x47, 0xff}, body)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		res, err := h.Serve(context.Background(), &lambda.Event{
+			RawPath:        "/unknown",
+			RequestContext: lambda.RequestContext{HTTP: lambda.RequestContextHTTP{Method: http.MethodGet}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestStartWithContext(t *testing.T) {
+	event, err := json.Marshal(lambda.Event{
+		RawPath:        "/users/1",
+		Headers:        map[string]string{"content-type": "application/json", "accept": "*/*"},
+		RequestContext: lambda.RequestContext{RequestID: "req-2", HTTP: lambda.RequestContextHTTP{Method: http.MethodPost}},
+		Body:           `{"name":"pon"}`,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu       sync.Mutex
+		served   bool
+		response []byte
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/2018-06-01/runtime/invocation/next":
+			mu.Lock()
+			first := !served
+			served = true
+			mu.Unlock()
+			if !first {
+				// the runtime waits for the next event until the function is stopped
+				<-req.Context().Done()
+				return
+			}
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", "abc")
+			w.Header().Set("Lambda-Runtime-Deadline-Ms", "9999999999999")
+			w.Write(event)
+		case "/2018-06-01/runtime/invocation/abc/response":
+			b, _ := io.ReadAll(req.Body)
+			mu.Lock()
+			response = b
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+			cancel()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", api.Listener.Addr().String())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lambda.StartWithContext(ctx, newRouter())
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var res lambda.Response
+	require.NoError(t, json.Unmarshal(response, &res))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{"id":1,"name":"pon","query":"","cookie":"","host":"","request_id":"req-2"}`, res.Body)
+}
+
+func TestStartWithoutRuntimeAPI(t *testing.T) {
+	// t.Setenv restores the variable after the test
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	require.NoError(t, os.Unsetenv("AWS_LAMBDA_RUNTIME_API"))
+	assert.ErrorIs(t, lambda.Start(newRouter()), lambda.ErrNotInLambda)
+}
@@ -0,0 +1,138 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	runtimeAPIEnv        = "AWS_LAMBDA_RUNTIME_API"
+	runtimeAPIVersion    = "2018-06-01"
+	traceIDEnv           = "_X_AMZN_TRACE_ID"
+	headerRequestID      = "Lambda-Runtime-Aws-Request-Id"
+	headerDeadline       = "Lambda-Runtime-Deadline-Ms"
+	headerTraceID        = "Lambda-Runtime-Trace-Id"
+	headerFunctionError  = "Lambda-Runtime-Function-Error-Type"
+	functionErrorType    = "Unhandled"
+	runtimeRequestPrefix = "/" + runtimeAPIVersion + "/runtime/invocation/"
+)
+
+// ErrNotInLambda is returned by Start when the process is not run by the Lambda runtime.
+var ErrNotInLambda = errors.New("AWS_LAMBDA_RUNTIME_API is not set")
+
+// Start serves the events of the Lambda runtime API with handler until an error occurs.
+func Start(handler http.Handler) error {
+	return StartWithContext(context.Background(), handler)
+}
+
+// StartWithContext serves the events of the Lambda runtime API with handler until ctx is canceled or an error occurs.
+// The context of each event has the deadline of the invocation.
+func StartWithContext(ctx context.Context, handler http.Handler) error {
+	api, ok := os.LookupEnv(runtimeAPIEnv)
+	if !ok {
+		return ErrNotInLambda
+	}
+	r := &runtime{
+		baseURL: "http://" + api + runtimeRequestPrefix,
+		client:  &http.Client{},
+		handler: NewHandler(handler),
+	}
+	for {
+		if err := r.next(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+type runtime struct {
+	baseURL string
+	client  *http.Client
+	handler *Handler
+}
+
+// next waits for the next event, and serves it.
+func (r *runtime) next(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"next", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get next invocation: %w", err)
+	}
+	payload, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read next invocation: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get next invocation: status=%d body=%s", res.StatusCode, payload)
+	}
+
+	requestID := res.Header.Get(headerRequestID)
+	invokeCtx := ctx
+	if ms, err := strconv.ParseInt(res.Header.Get(headerDeadline), 10, 64); err == nil {
+		var cancel context.CancelFunc
+		invokeCtx, cancel = context.WithDeadline(ctx, time.UnixMilli(ms))
+		defer cancel()
+	}
+	// the tracers like X-Ray read the trace id from the environment variable
+	if traceID := res.Header.Get(headerTraceID); traceID != "" {
+		os.Setenv(traceIDEnv, traceID)
+	} else {
+		os.Unsetenv(traceIDEnv)
+	}
+
+	out, err := r.handler.Invoke(invokeCtx, payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to invoke", slog.String("request_id", requestID), slog.Any("error", err))
+		return r.postError(ctx, requestID, err)
+	}
+	return r.post(ctx, requestID+"/response", out, nil)
+}
+
+type invocationError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+func (r *runtime) postError(ctx context.Context, requestID string, ierr error) error {
+	b, err := json.Marshal(invocationError{ErrorMessage: ierr.Error(), ErrorType: fmt.Sprintf("%T", ierr)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal error: %w", err)
+	}
+	return r.post(ctx, requestID+"/error", b, http.Header{headerFunctionError: []string{functionErrorType}})
+}
+
+func (r *runtime) post(ctx context.Context, path string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s: %w", path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("failed to post %s: status=%d body=%s", path, res.StatusCode, b)
+	}
+	return nil
+}
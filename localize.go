@@ -0,0 +1,148 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrorLocalizer returns the message of the error in the language of the caller. If it returns the empty string, err.Error() is used.
+// ctx is the Context[Reg] of the request if it is built, so the registry can be used to look up the messages.
+// AcceptLanguages returns the languages of the caller from ctx.
+type ErrorLocalizer func(ctx gocontext.Context, err error) string
+
+// WithErrorLocalizer sets the ErrorLocalizer that localizes the message of the ErrorMessage body.
+// The errors in the logs are not localized.
+func WithErrorLocalizer[Reg any](localizer ErrorLocalizer) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.errorLocalizer = localizer
+		return r
+	}
+}
+
+type errorLocalizerKey struct{}
+
+type localizerContext struct {
+	localizer ErrorLocalizer
+	ctx       gocontext.Context
+}
+
+// requestContext is the context.Context that has the request like Context[Reg], for the errors before the Context is built.
+type requestContext struct {
+	gocontext.Context
+	req *http.Request
+}
+
+func (r *requestContext) Request() *http.Request {
+	return r.req
+}
+
+// withErrorLocalizer attaches the localizer to the request for the ErrorHooker.
+func withErrorLocalizer(req *http.Request, ctx gocontext.Context, localizer ErrorLocalizer) *http.Request {
+	if localizer == nil {
+		return req
+	}
+	if ctx == nil {
+		ctx = &requestContext{Context: req.Context(), req: req}
+	}
+	return req.WithContext(gocontext.WithValue(req.Context(), errorLocalizerKey{}, &localizerContext{localizer: localizer, ctx: ctx}))
+}
+
+// LocalizedErrorMessage returns the message of the error that is localized by the ErrorLocalizer of the Router.
+// It returns err.Error() if WithErrorLocalizer is not set. Use this in the custom ErrorHooker to render the ErrorMessage.
+func LocalizedErrorMessage(req *http.Request, err error) string {
+	if lc, ok := req.Context().Value(errorLocalizerKey{}).(*localizerContext); ok {
+		if message := lc.localizer(lc.ctx, err); message != "" {
+			return message
+		}
+	}
+	return err.Error()
+}
+
+// AcceptLanguages returns the languages of the Accept-Language header of the request of ctx in the order of the preference.
+// ctx is the Context[Reg] or the context that is passed to the ErrorLocalizer. It returns nil if ctx has no request.
+func AcceptLanguages(ctx gocontext.Context) []string {
+	rc, ok := ctx.(interface{ Request() *http.Request })
+	if !ok {
+		return nil
+	}
+	return ParseAcceptLanguage(rc.Request().Header.Get("Accept-Language"))
+}
+
+// ParseAcceptLanguage parses the value of the Accept-Language header like "ja-JP,ja;q=0.9,en;q=0.8",
+// and returns the languages in the order of the quality values. The languages with q=0 and the wildcard are excluded.
+func ParseAcceptLanguage(header string) []string {
+	return parseQualityValues(header, false)
+}
+
+// parseQualityValues returns the values of the header with the quality values like "gzip;q=0.8, br" in the order of them.
+// The values with q=0 and the wildcard are excluded. The values are lowercased if lower is true.
+func parseQualityValues(header string, lower bool) []string {
+	type value struct {
+		v string
+		q float64
+	}
+	var values []value
+	for _, part := range strings.Split(header, ",") {
+		v, params, _ := strings.Cut(part, ";")
+		v = strings.TrimSpace(v)
+		if v == "" || v == "*" {
+			continue
+		}
+		if lower {
+			v = strings.ToLower(v)
+		}
+		q := 1.0
+		if pv, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			pq, err := strconv.ParseFloat(pv, 64)
+			if err != nil {
+				continue
+			}
+			q = pq
+		}
+		if q <= 0 {
+			continue
+		}
+		values = append(values, value{v: v, q: q})
+	}
+	slices.SortStableFunc(values, func(a, b value) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	vs := make([]string, len(values))
+	for i, v := range values {
+		vs[i] = v.v
+	}
+	return vs
+}
+
+// MatchLanguage returns the supported language that matches the preferred languages best.
+// The language matches the exact tag case-insensitively, or the primary language like "ja" for "ja-JP" and vice versa.
+// It returns the first supported language if nothing matches.
+func MatchLanguage(preferred []string, supported ...string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+	for _, p := range preferred {
+		for _, s := range supported {
+			if strings.EqualFold(p, s) {
+				return s
+			}
+		}
+		base, _, _ := strings.Cut(p, "-")
+		for _, s := range supported {
+			sbase, _, _ := strings.Cut(s, "-")
+			if strings.EqualFold(base, sbase) {
+				return s
+			}
+		}
+	}
+	return supported[0]
+}
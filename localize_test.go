@@ -0,0 +1,80 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"ja-JP", "ja", "en"}, tanukirpc.ParseAcceptLanguage("en;q=0.8, ja-JP, ja;q=0.9, fr;q=0, *;q=0.5"))
+	assert.Empty(t, tanukirpc.ParseAcceptLanguage(""))
+}
+
+func TestMatchLanguage(t *testing.T) {
+	assert.Equal(t, "ja", tanukirpc.MatchLanguage([]string{"ja-JP", "en"}, "en", "ja"))
+	assert.Equal(t, "en-US", tanukirpc.MatchLanguage([]string{"en"}, "ja", "en-US"))
+	assert.Equal(t, "en", tanukirpc.MatchLanguage([]string{"fr"}, "en", "ja"))
+}
+
+var errTaskNotFound = errors.New("task not found")
+
+type localizeRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+func TestWithErrorLocalizer(t *testing.T) {
+	messages := map[string]map[string]string{
+		"ja": {"not_found": "タスクが見つかりません", "required": "%s は必須です"},
+	}
+	localizer := func(ctx context.Context, err error) string {
+		lang := tanukirpc.MatchLanguage(tanukirpc.AcceptLanguages(ctx), "en", "ja")
+		msgs, ok := messages[lang]
+		if !ok {
+			return ""
+		}
+		var verrs validator.ValidationErrors
+		switch {
+		case errors.As(err, &verrs):
+			return strings.ReplaceAll(msgs[verrs[0].Tag()], "%s", verrs[0].Field())
+		case errors.Is(err, errTaskNotFound):
+			return msgs["not_found"]
+		}
+		return ""
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithErrorLocalizer[struct{}](localizer))
+	router.Post("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *localizeRequest) (struct{}, error) {
+		return struct{}{}, tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errTaskNotFound)
+	}))
+
+	testCases := []struct {
+		name     string
+		lang     string
+		body     string
+		status   int
+		expected string
+	}{
+		{name: "handler error", lang: "ja-JP,en;q=0.5", body: `{"title":"buy milk"}`, status: http.StatusNotFound, expected: `{"error":{"message":"タスクが見つかりません"}}`},
+		{name: "validation error", lang: "ja", body: `{}`, status: http.StatusBadRequest, expected: `{"error":{"message":"Title は必須です"}}`},
+		{name: "not localized", lang: "en", body: `{"title":"buy milk"}`, status: http.StatusNotFound, expected: `{"error":{"message":"task not found"}}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Accept-Language", tc.lang)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
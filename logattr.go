@@ -0,0 +1,52 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+type logAttrsKey struct{}
+
+type logAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// AddLogAttr attaches the attributes to the request.
+// The attributes appear in the access log and in the log lines of the logger of the Router that are written with the context of the request.
+// Middlewares, transformers and handlers can use it to build the canonical log line without threading the logger manually.
+// It does nothing if the context is not derived from the request that is handled by the Router.
+func AddLogAttr(ctx gocontext.Context, attrs ...slog.Attr) {
+	la, ok := ctx.Value(logAttrsKey{}).(*logAttrs)
+	if !ok {
+		return
+	}
+	la.mu.Lock()
+	defer la.mu.Unlock()
+	la.attrs = append(la.attrs, attrs...)
+}
+
+func logAttrsFromContext(ctx gocontext.Context) []slog.Attr {
+	la, ok := ctx.Value(logAttrsKey{}).(*logAttrs)
+	if !ok {
+		return nil
+	}
+	la.mu.Lock()
+	defer la.mu.Unlock()
+	return append([]slog.Attr(nil), la.attrs...)
+}
+
+func withLogAttrs(req *http.Request) *http.Request {
+	if _, ok := req.Context().Value(logAttrsKey{}).(*logAttrs); ok {
+		return req
+	}
+	return req.WithContext(gocontext.WithValue(req.Context(), logAttrsKey{}, &logAttrs{}))
+}
+
+func logAttrsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, withLogAttrs(req))
+	})
+}
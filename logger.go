@@ -21,6 +21,7 @@ func (l *loggerHandler) Handle(ctx gocontext.Context, record slog.Record) error
 			record.AddAttrs(slog.Any(key.String(), v))
 		}
 	}
+	record.AddAttrs(logAttrsFromContext(ctx)...)
 	return l.Handler.Handle(ctx, record)
 }
 
@@ -28,6 +29,7 @@ func (l *loggerHandler) Handle(ctx gocontext.Context, record slog.Record) error
 // This logger output with the informwation with request ID.
 // If the given logger is nil, it returns use the default logger.
 // keys is the whitelist of keys that use read from context.Context.
+// The attributes that are added by AddLogAttr are also written.
 func NewLogger(logger *slog.Logger, keys []fmt.Stringer) *slog.Logger {
 	if logger == nil {
 		logger = slog.Default()
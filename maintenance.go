@@ -0,0 +1,94 @@
+package tanukirpc
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ErrMaintenance is the error of the requests that are rejected in the maintenance mode. It is reported with 503 Service Unavailable.
+var ErrMaintenance = errors.New("service is under maintenance")
+
+const defaultMaintenanceRetryAfter = 60 * time.Second
+
+type maintenance struct {
+	config     atomic.Pointer[maintenanceConfig]
+	retryAfter time.Duration
+}
+
+type maintenanceConfig struct {
+	allowlist []string
+}
+
+func newMaintenance() *maintenance {
+	return &maintenance{retryAfter: defaultMaintenanceRetryAfter}
+}
+
+// SetMaintenance switches the maintenance mode of the Router and its sub routers. It is safe to call at runtime from the other goroutines.
+// In the maintenance mode, the requests are rejected with 503 Service Unavailable and the Retry-After header by the ErrorHooker,
+// except for the paths in the allowlist like the health checks. The path that ends with "/*" allows the paths under the prefix.
+//
+//	r.SetMaintenance(true, "/healthz", "/admin/*")
+func (r *Router[Reg]) SetMaintenance(enabled bool, allowlist ...string) {
+	if !enabled {
+		r.maintenance.config.Store(nil)
+		return
+	}
+	r.maintenance.config.Store(&maintenanceConfig{allowlist: slices.Clone(allowlist)})
+}
+
+// InMaintenance reports whether the Router is in the maintenance mode.
+func (r *Router[Reg]) InMaintenance() bool {
+	return r.maintenance.config.Load() != nil
+}
+
+// WithMaintenanceRetryAfter sets the value of the Retry-After header of the responses in the maintenance mode. The default is 60 seconds.
+func WithMaintenanceRetryAfter[Reg any](d time.Duration) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.maintenance.retryAfter = d
+		return r
+	}
+}
+
+func (c *maintenanceConfig) allowed(path string) bool {
+	for _, p := range c.allowlist {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router[Reg]) maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cfg := r.maintenance.config.Load()
+		if cfg == nil || cfg.allowed(req.URL.Path) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		t1 := time.Now()
+		if r.maintenance.retryAfter > 0 {
+			ww.Header().Set("Retry-After", strconv.Itoa(int(r.maintenance.retryAfter.Round(time.Second)/time.Second)))
+		}
+		err := WrapErrorWithStatus(http.StatusServiceUnavailable, ErrMaintenance)
+		r.onError(ww, req, nil, ErrorStageMaintenance, err)
+		if err := r.accessLoggerLog(req.Context(), ww, req, err, t1, time.Now()); err != nil {
+			r.logger.ErrorContext(req.Context(), "access log error", slog.Any("error", err))
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package tanukirpc
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultMirrorTimeout     = 10 * time.Second
+	defaultMirrorMaxBody     = 1 << 20
+	defaultMirrorMaxInFlight = 100
+)
+
+// MirroredHeader is the header of the mirrored requests, so the secondary service can tell them from the production requests.
+const MirroredHeader = "X-Mirrored"
+
+type mirror struct {
+	target    *url.URL
+	rate      float64
+	transport http.RoundTripper
+	timeout   time.Duration
+	maxBody   int64
+	inFlight  chan struct{}
+}
+
+// MirrorOption is the option of Router.Mirror.
+type MirrorOption func(*mirror)
+
+// WithMirrorSampleRate sets the rate of the requests that are mirrored from 0 to 1. The default is 1, which mirrors all requests.
+func WithMirrorSampleRate(rate float64) MirrorOption {
+	return func(m *mirror) {
+		m.rate = rate
+	}
+}
+
+// WithMirrorTransport sets the http.RoundTripper to send the mirrored requests. The default is http.DefaultTransport.
+func WithMirrorTransport(transport http.RoundTripper) MirrorOption {
+	return func(m *mirror) {
+		m.transport = transport
+	}
+}
+
+// WithMirrorTimeout sets the timeout of the mirrored requests. The default is 10 seconds.
+func WithMirrorTimeout(d time.Duration) MirrorOption {
+	return func(m *mirror) {
+		m.timeout = d
+	}
+}
+
+// WithMirrorMaxBody sets the limit of the request body to mirror. The requests with the larger body are not mirrored. The default is 1 MiB.
+func WithMirrorMaxBody(n int64) MirrorOption {
+	return func(m *mirror) {
+		m.maxBody = n
+	}
+}
+
+// WithMirrorMaxInFlight sets the limit of the mirrored requests in flight. The requests over the limit are not mirrored,
+// so the slow secondary service does not pile up the goroutines of the Router. The default is 100.
+func WithMirrorMaxInFlight(n int) MirrorOption {
+	return func(m *mirror) {
+		m.inFlight = make(chan struct{}, n)
+	}
+}
+
+// Mirror returns the Router that replays the copies of the requests to the routes registered to it to target asynchronously,
+// for validating the rewritten service against the production traffic. The path and the query of the request are appended to the path of target,
+// and the mirrored requests have the MirroredHeader. The responses and the errors of target are discarded, and never affect the responses of the routes.
+// It panics if target is not the absolute URL.
+//
+//	m := r.Mirror("http://tasks-v2.internal", tanukirpc.WithMirrorSampleRate(0.1))
+//	m.Get("/tasks", tanukirpc.NewHandler(listTasks))
+func (r *Router[Reg]) Mirror(target string, opts ...MirrorOption) *Router[Reg] {
+	u, err := url.Parse(target)
+	if err != nil || !u.IsAbs() {
+		panic(fmt.Sprintf("tanukirpc: invalid mirror target %q", target))
+	}
+	m := &mirror{
+		target:    u,
+		rate:      1,
+		transport: http.DefaultTransport,
+		timeout:   defaultMirrorTimeout,
+		maxBody:   defaultMirrorMaxBody,
+		inFlight:  make(chan struct{}, defaultMirrorMaxInFlight),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if m.rate >= 1 || rand.Float64() < m.rate {
+				req = r.mirrorRequest(m, req)
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
+
+// mirrorRequest sends the copy of req to the target of m, and returns req whose body can be read again.
+func (r *Router[Reg]) mirrorRequest(m *mirror, req *http.Request) *http.Request {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := io.ReadAll(io.LimitReader(req.Body, m.maxBody+1))
+		// the handler reads the rest of the body, and sees the same error if any
+		req.Body = &teeReadCloser{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+		if err != nil || int64(len(buf)) > m.maxBody {
+			return req
+		}
+		body = buf
+	}
+
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		return req
+	}
+
+	u := m.target.JoinPath(req.URL.Path)
+	u.RawQuery = req.URL.RawQuery
+	ctx, cancel := gocontext.WithTimeout(gocontext.WithoutCancel(req.Context()), m.timeout)
+	mreq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		<-m.inFlight
+		r.logger.WarnContext(req.Context(), "failed to create mirrored request", slog.Any("error", err))
+		return req
+	}
+	mreq.Header = req.Header.Clone()
+	mreq.Header.Set(MirroredHeader, "true")
+
+	go func() {
+		defer func() {
+			cancel()
+			<-m.inFlight
+		}()
+		res, err := m.transport.RoundTrip(mreq)
+		if err != nil {
+			r.logger.DebugContext(ctx, "failed to mirror request", slog.String("url", u.String()), slog.Any("error", err))
+			return
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, res.Body)
+	}()
+	return req
+}
@@ -0,0 +1,69 @@
+package tanukirpc_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterMirror(t *testing.T) {
+	type mirrored struct {
+		method, uri, body, header string
+	}
+	received := make(chan mirrored, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		received <- mirrored{method: req.Method, uri: req.URL.RequestURI(), body: string(body), header: req.Header.Get(tanukirpc.MirroredHeader)}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+
+	type echoRequest struct {
+		Name string `json:"name"`
+	}
+	echo := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *echoRequest) (string, error) {
+		return req.Name, nil
+	})
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Mirror(shadow.URL+"/v2").Post("/echo", echo)
+	router.Mirror(shadow.URL, tanukirpc.WithMirrorSampleRate(0)).Post("/unsampled", echo)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"tanuki"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/echo?lang=ja")
+	require.Equal(t, http.StatusOK, rec.Code, "the handler reads the body after mirroring")
+	assert.JSONEq(t, `"tanuki"`, rec.Body.String())
+	select {
+	case m := <-received:
+		assert.Equal(t, mirrored{method: http.MethodPost, uri: "/v2/echo?lang=ja", body: `{"name":"tanuki"}`, header: "true"}, m)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request is not mirrored")
+	}
+
+	rec = serve("/unsampled")
+	require.Equal(t, http.StatusOK, rec.Code)
+	select {
+	case m := <-received:
+		t.Fatalf("the request is mirrored: %+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRouterMirrorInvalidTarget(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	assert.Panics(t, func() { router.Mirror("/relative") })
+}
@@ -0,0 +1,220 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// NegotiateLanguage is the value of the negotiate tag to bind the languages of the Accept-Language header.
+	NegotiateLanguage = "language"
+	// NegotiateEncoding is the value of the negotiate tag to bind the content codings of the Accept-Encoding header.
+	NegotiateEncoding = "encoding"
+	// NegotiateUserAgent is the value of the negotiate tag to bind the User-Agent header.
+	NegotiateUserAgent = "useragent"
+)
+
+var userAgentType = reflect.TypeFor[UserAgent]()
+
+type negotiateCodec struct{}
+
+// NewNegotiateCodec returns a new NegotiateCodec. This codec supports request decoding only.
+// It binds the content negotiation headers to the fields with the negotiate tag, so the handlers do not parse them by themselves:
+//
+//	type listTasksRequest struct {
+//		Languages []string `json:"-" negotiate:"language"`
+//		Encodings []string `json:"-" negotiate:"encoding"`
+//		UserAgent tanukirpc.UserAgent `json:"-" negotiate:"useragent"`
+//	}
+//
+// The language and encoding fields of []string have the values in the order of the quality values, and the string fields have the most preferred one.
+// The values with q=0 and the wildcard are excluded, and the encodings are lowercased.
+// The useragent field is UserAgent, *UserAgent or the string of the raw header. The fields are left as they are when the headers are absent.
+func NewNegotiateCodec() *negotiateCodec {
+	return &negotiateCodec{}
+}
+
+func (c *negotiateCodec) Name() string {
+	return "negotiate"
+}
+
+func (c *negotiateCodec) Decode(r *http.Request, v any) error {
+	vr := reflect.ValueOf(v)
+	for vr.Kind() == reflect.Pointer {
+		if vr.IsNil() {
+			if !vr.CanSet() {
+				return ErrRequestNotSupportedAtThisCodec
+			}
+			vr.Set(reflect.New(vr.Type().Elem()))
+		}
+		vr = vr.Elem()
+	}
+	if vr.Kind() != reflect.Struct {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+	if err := decodeNegotiations(r, vr); err != nil {
+		return err
+	}
+	return ErrRequestContinueDecode
+}
+
+func decodeNegotiations(r *http.Request, vr reflect.Value) error {
+	str := vr.Type()
+	for i := 0; i < vr.NumField(); i++ {
+		ft := str.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+		field := vr.Field(i)
+		switch tag := ft.Tag.Get("negotiate"); tag {
+		case NegotiateLanguage, NegotiateEncoding:
+			var values []string
+			if tag == NegotiateLanguage {
+				values = ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+			} else {
+				values = ParseAcceptEncoding(r.Header.Get("Accept-Encoding"))
+			}
+			if len(values) == 0 {
+				continue
+			}
+			if err := setNegotiatedValues(field, values); err != nil {
+				return fmt.Errorf("failed to bind %s at field %s: %w", tag, ft.Name, err)
+			}
+		case NegotiateUserAgent:
+			ua := r.Header.Get("User-Agent")
+			if ua == "" {
+				continue
+			}
+			switch {
+			case field.Kind() == reflect.String:
+				field.SetString(ua)
+			case field.Type() == userAgentType:
+				field.Set(reflect.ValueOf(ParseUserAgent(ua)))
+			case field.Kind() == reflect.Pointer && field.Type().Elem() == userAgentType:
+				parsed := ParseUserAgent(ua)
+				field.Set(reflect.ValueOf(&parsed))
+			default:
+				return fmt.Errorf("unsupported type at field %s: %s", ft.Name, field.Type())
+			}
+		case "":
+			if indirectType(ft.Type).Kind() == reflect.Struct && indirectType(ft.Type) != userAgentType && hasStructTag(ft.Type, "negotiate") {
+				if field.Kind() == reflect.Pointer {
+					if field.IsNil() {
+						field.Set(reflect.New(field.Type().Elem()))
+					}
+					field = field.Elem()
+				}
+				if err := decodeNegotiations(r, field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func setNegotiatedValues(field reflect.Value, values []string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(values[0])
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		s := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, v := range values {
+			s.Index(i).SetString(v)
+		}
+		field.Set(s)
+	default:
+		return fmt.Errorf("unsupported type: %s", field.Type())
+	}
+	return nil
+}
+
+func (c *negotiateCodec) MatchRequestType(t reflect.Type) bool {
+	return hasStructTag(t, "negotiate")
+}
+
+func (c *negotiateCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return ErrResponseNotSupportedAtThisCodec
+}
+
+// ParseAcceptEncoding parses the value of the Accept-Encoding header like "gzip, br;q=0.9, identity;q=0",
+// and returns the lowercased content codings in the order of the quality values. The codings with q=0 and the wildcard are excluded.
+func ParseAcceptEncoding(header string) []string {
+	return parseQualityValues(header, true)
+}
+
+// UserAgent is the summary of the User-Agent header for the logs and the feature switches by the clients.
+// It is parsed by the well-known tokens, so use Raw for the precise detection.
+type UserAgent struct {
+	// Raw is the value of the User-Agent header.
+	Raw string `json:"raw"`
+	// Name is the name of the browser like "Chrome", or the product of the first token like "curl" for the other clients.
+	Name string `json:"name"`
+	// Version is the version of Name.
+	Version string `json:"version"`
+	// OS is the operating system like "Windows", "macOS", "iOS", "Android" and "Linux". It is empty if it is unknown.
+	OS string `json:"os"`
+	// Mobile reports whether the client is the mobile device.
+	Mobile bool `json:"mobile"`
+	// Bot reports whether the client is the crawler.
+	Bot bool `json:"bot"`
+}
+
+// userAgentBrowsers is the tokens of the browsers in the order of the detection,
+// because the User-Agent of the browsers has the tokens of the other browsers for the compatibility.
+var userAgentBrowsers = []struct {
+	token string
+	name  string
+}{
+	{token: "Edg/", name: "Edge"},
+	{token: "OPR/", name: "Opera"},
+	{token: "CriOS/", name: "Chrome"},
+	{token: "FxiOS/", name: "Firefox"},
+	{token: "Firefox/", name: "Firefox"},
+	{token: "Chrome/", name: "Chrome"},
+	{token: "Version/", name: "Safari"},
+}
+
+// userAgentOSes is the tokens of the operating systems in the order of the detection.
+var userAgentOSes = []struct {
+	token string
+	name  string
+}{
+	{token: "Windows", name: "Windows"},
+	{token: "iPhone", name: "iOS"},
+	{token: "iPad", name: "iOS"},
+	{token: "Android", name: "Android"},
+	{token: "Mac OS X", name: "macOS"},
+	{token: "CrOS", name: "ChromeOS"},
+	{token: "Linux", name: "Linux"},
+}
+
+// ParseUserAgent parses the value of the User-Agent header like
+// "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36".
+func ParseUserAgent(header string) UserAgent {
+	ua := UserAgent{Raw: header}
+	if header == "" {
+		return ua
+	}
+	lower := strings.ToLower(header)
+	ua.Bot = strings.Contains(lower, "bot") || strings.Contains(lower, "crawler") || strings.Contains(lower, "spider")
+	ua.Mobile = strings.Contains(header, "Mobile") || strings.Contains(header, "iPhone") || strings.Contains(header, "Android")
+	for _, os := range userAgentOSes {
+		if strings.Contains(header, os.token) {
+			ua.OS = os.name
+			break
+		}
+	}
+	for _, b := range userAgentBrowsers {
+		if _, after, ok := strings.Cut(header, b.token); ok {
+			ua.Name = b.name
+			ua.Version, _, _ = strings.Cut(after, " ")
+			return ua
+		}
+	}
+	product, _, _ := strings.Cut(header, " ")
+	ua.Name, ua.Version, _ = strings.Cut(product, "/")
+	return ua
+}
@@ -0,0 +1,105 @@
+package tanukirpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	type clientInfo struct {
+		UserAgent *tanukirpc.UserAgent `json:"-" negotiate:"useragent"`
+	}
+	type negotiateRequest struct {
+		Languages []string `json:"-" negotiate:"language"`
+		Language  string   `json:"-" negotiate:"language"`
+		Encodings []string `json:"-" negotiate:"encoding"`
+		Client    clientInfo
+	}
+	type negotiateResponse struct {
+		Languages []string             `json:"languages"`
+		Language  string               `json:"language"`
+		Encodings []string             `json:"encodings"`
+		UserAgent *tanukirpc.UserAgent `json:"user_agent"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/negotiate", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req negotiateRequest) (*negotiateResponse, error) {
+		return &negotiateResponse{
+			Languages: req.Languages,
+			Language:  req.Language,
+			Encodings: req.Encodings,
+			UserAgent: req.Client.UserAgent,
+		}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/negotiate", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "en;q=0.8, ja-JP, ja;q=0.9")
+	req.Header.Set("Accept-Encoding", "gzip;q=0.5, BR, identity;q=0")
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{
+		"languages":["ja-JP","ja","en"],
+		"language":"ja-JP",
+		"encodings":["br","gzip"],
+		"user_agent":{"raw":"curl/8.4.0","name":"curl","version":"8.4.0","os":"","mobile":false,"bot":false}
+	}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/negotiate", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"languages":null,"language":"","encodings":null,"user_agent":null}`, rec.Body.String())
+}
+
+func TestParseUserAgent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		expected tanukirpc.UserAgent
+	}{
+		{
+			name:     "chrome",
+			header:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: tanukirpc.UserAgent{Name: "Chrome", Version: "120.0.0.0", OS: "Windows"},
+		},
+		{
+			name:     "edge",
+			header:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.61",
+			expected: tanukirpc.UserAgent{Name: "Edge", Version: "120.0.2210.61", OS: "Windows"},
+		},
+		{
+			name:     "mobile safari",
+			header:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			expected: tanukirpc.UserAgent{Name: "Safari", Version: "17.1", OS: "iOS", Mobile: true},
+		},
+		{
+			name:     "firefox",
+			header:   "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			expected: tanukirpc.UserAgent{Name: "Firefox", Version: "120.0", OS: "Linux"},
+		},
+		{
+			name:     "bot",
+			header:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected: tanukirpc.UserAgent{Name: "Mozilla", Version: "5.0", Bot: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expected.Raw = tc.header
+			assert.Equal(t, tc.expected, tanukirpc.ParseUserAgent(tc.header))
+		})
+	}
+}
+
+func TestParseAcceptEncoding(t *testing.T) {
+	assert.Equal(t, []string{"br", "gzip"}, tanukirpc.ParseAcceptEncoding("gzip;q=0.8, br, *;q=0.1, deflate;q=0"))
+	assert.Empty(t, tanukirpc.ParseAcceptEncoding(""))
+}
@@ -0,0 +1,121 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// RouterPlugin is an extension of the Router that is distributed as a package, like the metrics, the tracing,
+// the authentication and the caching. Attach sets it up to the Router.
+//
+// The plugin can implement RequestPlugin to handle each request and ShutdownPlugin to release the resources
+// when the server is shutting down.
+type RouterPlugin[Reg any] interface {
+	// Name is the name of the plugin. It must be unique in the Router.
+	Name() string
+	// Setup is called once by Attach to register the routes and the options of the plugin to the Router.
+	Setup(r *Router[Reg]) error
+}
+
+// RequestPlugin is implemented by the RouterPlugin that handles each request by the middleware.
+type RequestPlugin interface {
+	Middleware(next http.Handler) http.Handler
+}
+
+// ShutdownPlugin is implemented by the RouterPlugin that releases the resources like the exporters of the metrics
+// when the server is shutting down.
+type ShutdownPlugin interface {
+	Shutdown(ctx gocontext.Context) error
+}
+
+// routerPlugins records the plugins that are attached to the Router and the Routers derived from it.
+type routerPlugins struct {
+	mu        sync.Mutex
+	names     []string
+	shutdowns []namedShutdownPlugin
+}
+
+type namedShutdownPlugin struct {
+	name   string
+	plugin ShutdownPlugin
+}
+
+func newRouterPlugins() *routerPlugins {
+	return &routerPlugins{}
+}
+
+func (rp *routerPlugins) add(names []string) error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for i, name := range names {
+		if slices.Contains(rp.names, name) || slices.Contains(names[:i], name) {
+			return fmt.Errorf("plugin %s is already attached", name)
+		}
+	}
+	rp.names = append(rp.names, names...)
+	return nil
+}
+
+func (rp *routerPlugins) addShutdown(name string, p ShutdownPlugin) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.shutdowns = append(rp.shutdowns, namedShutdownPlugin{name: name, plugin: p})
+}
+
+func (rp *routerPlugins) shutdown(ctx gocontext.Context) error {
+	rp.mu.Lock()
+	shutdowns := rp.shutdowns
+	rp.shutdowns = nil
+	rp.mu.Unlock()
+
+	var errs []error
+	for i := len(shutdowns) - 1; i >= 0; i-- {
+		s := shutdowns[i]
+		if err := s.plugin.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown plugin %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Attach attaches the plugins to the Router in the order of the arguments.
+//
+// The middlewares of the RequestPlugins are added first like Use, so the first plugin handles the requests first,
+// and Attach must be called before the routes are registered. Then Setup of the plugins is called in the order,
+// so Setup can register the routes. ShutdownPlugins are shut down in the reverse order by ListenAndServe or ShutdownPlugins.
+func (r *Router[Reg]) Attach(plugins ...RouterPlugin[Reg]) error {
+	names := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		if p == nil {
+			return errors.New("failed to attach plugin: plugin is nil")
+		}
+		names = append(names, p.Name())
+	}
+	if err := r.plugins.add(names); err != nil {
+		return fmt.Errorf("failed to attach plugin: %w", err)
+	}
+	for _, p := range plugins {
+		if rp, ok := p.(RequestPlugin); ok {
+			r.Use(rp.Middleware)
+		}
+	}
+	for _, p := range plugins {
+		if err := p.Setup(r); err != nil {
+			return fmt.Errorf("failed to setup plugin %s: %w", p.Name(), err)
+		}
+		if sp, ok := p.(ShutdownPlugin); ok {
+			r.plugins.addShutdown(p.Name(), sp)
+		}
+	}
+	return nil
+}
+
+// ShutdownPlugins shuts down the ShutdownPlugins that are attached to the Router in the reverse order of Attach.
+// ListenAndServe calls it after the server is shut down, so call it when the Router is served in another way.
+func (r *Router[Reg]) ShutdownPlugins(ctx gocontext.Context) error {
+	return r.plugins.shutdown(ctx)
+}
@@ -0,0 +1,180 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pluginEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *pluginEvents) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *pluginEvents) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.events...)
+}
+
+type testPlugin struct {
+	name     string
+	events   *pluginEvents
+	setupErr error
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) Setup(r *tanukirpc.Router[struct{}]) error {
+	p.events.add("setup " + p.name)
+	return p.setupErr
+}
+
+type testRequestPlugin struct {
+	testPlugin
+}
+
+func (p *testRequestPlugin) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p.events.add("request " + p.name)
+		next.ServeHTTP(w, req)
+	})
+}
+
+type testShutdownPlugin struct {
+	testPlugin
+	shutdownErr error
+}
+
+func (p *testShutdownPlugin) Shutdown(ctx context.Context) error {
+	p.events.add("shutdown " + p.name)
+	return p.shutdownErr
+}
+
+type testRoutePlugin struct {
+	testPlugin
+}
+
+func (p *testRoutePlugin) Setup(r *tanukirpc.Router[struct{}]) error {
+	p.events.add("setup " + p.name)
+	r.Get("/metrics", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	}))
+	return nil
+}
+
+func TestRouterAttach(t *testing.T) {
+	t.Run("ordering", func(t *testing.T) {
+		events := &pluginEvents{}
+		r := tanukirpc.NewRouter(struct{}{})
+		err := r.Attach(
+			&testRoutePlugin{testPlugin{name: "metrics", events: events}},
+			&testRequestPlugin{testPlugin{name: "tracing", events: events}},
+			&testShutdownPlugin{testPlugin: testPlugin{name: "exporter", events: events}},
+			&testRequestPlugin{testPlugin{name: "auth", events: events}},
+		)
+		require.NoError(t, err)
+		require.NoError(t, r.Attach(&testShutdownPlugin{testPlugin: testPlugin{name: "cache", events: events}}))
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		require.NoError(t, r.ShutdownPlugins(context.Background()))
+		require.NoError(t, r.ShutdownPlugins(context.Background()))
+
+		assert.Equal(t, []string{
+			"setup metrics",
+			"setup tracing",
+			"setup exporter",
+			"setup auth",
+			"setup cache",
+			"request tracing",
+			"request auth",
+			"shutdown cache",
+			"shutdown exporter",
+		}, events.list())
+	})
+	t.Run("errors", func(t *testing.T) {
+		events := &pluginEvents{}
+		r := tanukirpc.NewRouter(struct{}{})
+		require.NoError(t, r.Attach(&testPlugin{name: "metrics", events: events}))
+
+		err := r.Attach(&testPlugin{name: "metrics", events: events})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "plugin metrics is already attached")
+
+		err = r.Attach(&testPlugin{name: "auth", events: events}, &testPlugin{name: "auth", events: events})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "plugin auth is already attached")
+
+		assert.Error(t, r.Attach(nil))
+
+		err = r.Attach(&testPlugin{name: "broken", events: events, setupErr: errors.New("no config")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to setup plugin broken: no config")
+
+		assert.Equal(t, []string{"setup metrics", "setup broken"}, events.list())
+	})
+	t.Run("shutdown errors", func(t *testing.T) {
+		events := &pluginEvents{}
+		r := tanukirpc.NewRouter(struct{}{})
+		require.NoError(t, r.Attach(
+			&testShutdownPlugin{testPlugin: testPlugin{name: "exporter", events: events}, shutdownErr: errors.New("flush failed")},
+			&testShutdownPlugin{testPlugin: testPlugin{name: "cache", events: events}},
+		))
+
+		err := r.ShutdownPlugins(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to shutdown plugin exporter: flush failed")
+		assert.Equal(t, []string{"setup exporter", "setup cache", "shutdown cache", "shutdown exporter"}, events.list())
+	})
+}
+
+func TestListenAndServeShutdownPlugins(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	events := &pluginEvents{}
+	r := tanukirpc.NewRouter(struct{}{})
+	require.NoError(t, r.Attach(&testShutdownPlugin{testPlugin: testPlugin{name: "exporter", events: events}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- r.ListenAndServe(ctx, addr, tanukirpc.WithDisableTanukiupProxy())
+	}()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"setup exporter", "shutdown exporter"}, events.list())
+}
@@ -0,0 +1,257 @@
+package tanukirpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPreconditionFailed is the error of the update that does not match the precondition of the request,
+// like the If-Match header that is not the current version of the resource. It is reported with 412 Precondition Failed.
+//
+//	if req.IfMatch != nil && *req.IfMatch != task.Version {
+//		return nil, tanukirpc.ErrPreconditionFailed
+//	}
+var ErrPreconditionFailed = WrapErrorWithStatus(http.StatusPreconditionFailed, errors.New("precondition failed"))
+
+const (
+	// PreconditionIfMatch is the value of the precondition tag to bind the entity tag of the If-Match header.
+	PreconditionIfMatch = "if-match"
+	// PreconditionIfUnmodifiedSince is the value of the precondition tag to bind the time of the If-Unmodified-Since header.
+	PreconditionIfUnmodifiedSince = "if-unmodified-since"
+	// PreconditionETag is the value of the precondition tag of the response field that is sent as the ETag header, like the version.
+	PreconditionETag = "etag"
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+type preconditionCodec struct{}
+
+// NewPreconditionCodec returns a new PreconditionCodec. This codec supports request decoding only.
+// It binds the conditional request headers to the fields with the precondition tag, for the optimistic concurrency of the updates:
+//
+//	type changeTaskRequest struct {
+//		IfMatch *int `json:"-" precondition:"if-match"`
+//		IfUnmodifiedSince time.Time `json:"-" precondition:"if-unmodified-since"`
+//	}
+//
+// The if-match field is the string, the number or the pointer to them, and it is bound from the first entity tag without the quotes.
+// The field of []string has all entity tags. The if-unmodified-since field is time.Time or *time.Time.
+// The fields are left as they are when the headers are absent or If-Match is the wildcard "*", which has no version constraint,
+// and the entity tag that is not the number fails the number field with ErrPreconditionFailed. The field of []string has "*" for the wildcard.
+func NewPreconditionCodec() *preconditionCodec {
+	return &preconditionCodec{}
+}
+
+func (c *preconditionCodec) Name() string {
+	return "precondition"
+}
+
+func (c *preconditionCodec) Decode(r *http.Request, v any) error {
+	vr := reflect.ValueOf(v)
+	for vr.Kind() == reflect.Pointer {
+		if vr.IsNil() {
+			if !vr.CanSet() {
+				return ErrRequestNotSupportedAtThisCodec
+			}
+			vr.Set(reflect.New(vr.Type().Elem()))
+		}
+		vr = vr.Elem()
+	}
+	if vr.Kind() != reflect.Struct {
+		return ErrRequestNotSupportedAtThisCodec
+	}
+	if err := decodePreconditions(r, vr); err != nil {
+		return err
+	}
+	return ErrRequestContinueDecode
+}
+
+func decodePreconditions(r *http.Request, vr reflect.Value) error {
+	str := vr.Type()
+	for i := 0; i < vr.NumField(); i++ {
+		ft := str.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+		field := vr.Field(i)
+		switch ft.Tag.Get("precondition") {
+		case PreconditionIfMatch:
+			tags := parseEntityTags(r.Header.Get("If-Match"))
+			if len(tags) == 0 {
+				continue
+			}
+			// the wildcard matches any version, so the version field is left as it is for no constraint
+			if len(tags) == 1 && tags[0] == "*" && !(field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String) {
+				continue
+			}
+			if err := setEntityTags(field, tags); err != nil {
+				return fmt.Errorf("failed to bind If-Match at field %s: %w", ft.Name, err)
+			}
+		case PreconditionIfUnmodifiedSince:
+			v := r.Header.Get("If-Unmodified-Since")
+			if v == "" {
+				continue
+			}
+			t, err := http.ParseTime(v)
+			if err != nil {
+				return WrapErrorWithStatus(http.StatusBadRequest, fmt.Errorf("failed to parse If-Unmodified-Since at field %s: %w", ft.Name, err))
+			}
+			if field.Kind() == reflect.Pointer {
+				field.Set(reflect.New(field.Type().Elem()))
+				field = field.Elem()
+			}
+			if field.Type() != timeType {
+				return fmt.Errorf("unsupported type at field %s: %s", ft.Name, field.Type())
+			}
+			field.Set(reflect.ValueOf(t))
+		case "":
+			if indirectType(ft.Type).Kind() == reflect.Struct && ft.Type != timeType && hasStructTag(ft.Type, "precondition") {
+				if field.Kind() == reflect.Pointer {
+					if field.IsNil() {
+						field.Set(reflect.New(field.Type().Elem()))
+					}
+					field = field.Elem()
+				}
+				if err := decodePreconditions(r, field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseEntityTags returns the opaque tags of the entity tags like `"v1", W/"v2"` without the quotes.
+// The weak tags keep the W/ prefix, so they never match the version. The wildcard is "*".
+func parseEntityTags(v string) []string {
+	var tags []string
+	for _, tag := range strings.Split(v, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		weak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) >= 2 && tag[0] == '"' && tag[len(tag)-1] == '"' {
+			tag = tag[1 : len(tag)-1]
+		}
+		if weak {
+			tag = "W/" + tag
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func setEntityTags(field reflect.Value, tags []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String {
+		s := reflect.MakeSlice(field.Type(), len(tags), len(tags))
+		for i, tag := range tags {
+			s.Index(i).SetString(tag)
+		}
+		field.Set(s)
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	tag := tags[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(tag)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pi, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: entity tag %q is not the version", ErrPreconditionFailed, tag)
+		}
+		field.SetInt(pi)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pu, err := strconv.ParseUint(tag, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: entity tag %q is not the version", ErrPreconditionFailed, tag)
+		}
+		field.SetUint(pu)
+	default:
+		return fmt.Errorf("unsupported type: %s", field.Type())
+	}
+	return nil
+}
+
+func (c *preconditionCodec) MatchRequestType(t reflect.Type) bool {
+	return hasStructTag(t, "precondition")
+}
+
+func (c *preconditionCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return ErrResponseNotSupportedAtThisCodec
+}
+
+// etagFields is the cache of the index paths of the fields with `precondition:"etag"` by the response types.
+var etagFields sync.Map
+
+// responseETag returns the ETag header of the response from the field with `precondition:"etag"` unless it is zero.
+// The field is searched in the nested structs, like the version of the resource in the response.
+func responseETag(res any) (string, bool) {
+	if res == nil {
+		return "", false
+	}
+	t := reflect.TypeOf(res)
+	cached, ok := etagFields.Load(t)
+	if !ok {
+		cached, _ = etagFields.LoadOrStore(t, findETagField(t, map[reflect.Type]struct{}{}))
+	}
+	index := cached.([]int)
+	if index == nil {
+		return "", false
+	}
+	v := reflect.ValueOf(res)
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return "", false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.IsZero() {
+		return "", false
+	}
+	return `"` + fmt.Sprint(v.Interface()) + `"`, true
+}
+
+func findETagField(t reflect.Type, visited map[reflect.Type]struct{}) []int {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if _, ok := visited[t]; ok {
+		return nil
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+		if ft.Tag.Get("precondition") == PreconditionETag {
+			return []int{i}
+		}
+		if index := findETagField(ft.Type, visited); index != nil {
+			return append([]int{i}, index...)
+		}
+	}
+	return nil
+}
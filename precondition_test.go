@@ -0,0 +1,117 @@
+package tanukirpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecondition(t *testing.T) {
+	type task struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Version int    `json:"version" precondition:"etag"`
+	}
+	type taskResponse struct {
+		Task *task `json:"task"`
+	}
+	type changeTaskRequest struct {
+		ID                int       `urlparam:"id"`
+		IfMatch           *int      `json:"-" precondition:"if-match"`
+		IfUnmodifiedSince time.Time `json:"-" precondition:"if-unmodified-since"`
+		Name              string    `json:"name"`
+	}
+	modified := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	current := &task{ID: 1, Name: "task", Version: 3}
+
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Put("/tasks/{id}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req changeTaskRequest) (*taskResponse, error) {
+		if req.IfMatch != nil && *req.IfMatch != current.Version {
+			return nil, tanukirpc.ErrPreconditionFailed
+		}
+		if !req.IfUnmodifiedSince.IsZero() && modified.After(req.IfUnmodifiedSince) {
+			return nil, tanukirpc.ErrPreconditionFailed
+		}
+		return &taskResponse{Task: &task{ID: req.ID, Name: req.Name, Version: current.Version + 1}}, nil
+	}))
+
+	testCases := []struct {
+		name         string
+		header       map[string]string
+		expectedCode int
+		expectedETag string
+	}{
+		{name: "no precondition", expectedCode: http.StatusOK, expectedETag: `"4"`},
+		{name: "if-match", header: map[string]string{"If-Match": `"3"`}, expectedCode: http.StatusOK, expectedETag: `"4"`},
+		{name: "if-match stale", header: map[string]string{"If-Match": `"2"`}, expectedCode: http.StatusPreconditionFailed},
+		{name: "if-match not version", header: map[string]string{"If-Match": `"abc"`}, expectedCode: http.StatusPreconditionFailed},
+		{name: "if-match wildcard", header: map[string]string{"If-Match": "*"}, expectedCode: http.StatusOK, expectedETag: `"4"`},
+		{name: "if-unmodified-since", header: map[string]string{"If-Unmodified-Since": modified.Format(http.TimeFormat)}, expectedCode: http.StatusOK, expectedETag: `"4"`},
+		{name: "if-unmodified-since stale", header: map[string]string{"If-Unmodified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, expectedCode: http.StatusPreconditionFailed},
+		{name: "invalid if-unmodified-since", header: map[string]string{"If-Unmodified-Since": "yesterday"}, expectedCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/tasks/1", strings.NewReader(`{"name":"renamed"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, tc.expectedCode, rec.Code, rec.Body.String())
+			assert.Equal(t, tc.expectedETag, rec.Header().Get("ETag"))
+			if tc.expectedCode == http.StatusOK {
+				assert.JSONEq(t, `{"task":{"id":1,"name":"renamed","version":4}}`, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPreconditionWildcardTags(t *testing.T) {
+	type deleteTaskRequest struct {
+		IfMatch []string `json:"-" precondition:"if-match"`
+		Version string   `json:"-" precondition:"if-match"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	type deleteTaskResponse struct {
+		IfMatch []string `json:"if_match"`
+		Version string   `json:"version"`
+	}
+	router.Delete("/tasks/1", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req deleteTaskRequest) (*deleteTaskResponse, error) {
+		return &deleteTaskResponse{IfMatch: req.IfMatch, Version: req.Version}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("If-Match", "*")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"if_match":["*"],"version":""}`, rec.Body.String())
+}
+
+func TestResponseETagAfterEncode(t *testing.T) {
+	type brokenResponse struct {
+		Version int       `json:"version" precondition:"etag"`
+		Updates chan bool `json:"updates"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/broken", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*brokenResponse, error) {
+		return &brokenResponse{Version: 1, Updates: make(chan bool)}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/broken", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
@@ -0,0 +1,167 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ProxyTargetFunc returns the URL of the upstream of the request. The path of the request is appended to the path of the URL.
+type ProxyTargetFunc[Reg any] func(ctx Context[Reg]) (*url.URL, error)
+
+// ProxyOption is the option of NewProxyHandler.
+type ProxyOption[Reg any] func(*proxyHandler[Reg])
+
+// WithProxyRewrite adds the hook that rewrites the outbound request, like setting the headers for the upstream.
+// The hooks are called after the URL, the X-Forwarded headers and the traceparent header of the TraceContext are set.
+func WithProxyRewrite[Reg any](fn func(ctx Context[Reg], pr *httputil.ProxyRequest)) ProxyOption[Reg] {
+	return func(h *proxyHandler[Reg]) {
+		h.rewrites = append(h.rewrites, fn)
+	}
+}
+
+// WithProxyModifyResponse adds the hook that modifies the response of the upstream, like removing the internal headers.
+func WithProxyModifyResponse[Reg any](fn func(ctx Context[Reg], res *http.Response) error) ProxyOption[Reg] {
+	return func(h *proxyHandler[Reg]) {
+		h.modifyResponses = append(h.modifyResponses, fn)
+	}
+}
+
+// WithProxyTransport sets the http.RoundTripper to send the requests to the upstream. The default is http.DefaultTransport.
+func WithProxyTransport[Reg any](transport http.RoundTripper) ProxyOption[Reg] {
+	return func(h *proxyHandler[Reg]) {
+		h.transport = transport
+	}
+}
+
+// NewProxyHandler returns the Handler that proxies the requests to the upstream returned by target.
+// The Context is built like the other handlers, so the transformers of RouteWithTransformer, for example the authentication,
+// are shared with the native handlers, and the requests are written to the access log.
+// The errors of target are handled by the ErrorHooker, and the errors of the upstream are reported as 502 Bad Gateway.
+//
+//	r.Get("/legacy/*", tanukirpc.NewProxyHandler(func(ctx tanukirpc.Context[*registry]) (*url.URL, error) {
+//		return ctx.Registry().legacyURL, nil
+//	}))
+//
+// The Metadata of the Handler is nil, because the proxied routes have no request and response types.
+func NewProxyHandler[Reg any](target ProxyTargetFunc[Reg], opts ...ProxyOption[Reg]) Handler[Reg] {
+	h := &proxyHandler[Reg]{target: target}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type proxyHandler[Reg any] struct {
+	target          ProxyTargetFunc[Reg]
+	rewrites        []func(ctx Context[Reg], pr *httputil.ProxyRequest)
+	modifyResponses []func(ctx Context[Reg], res *http.Response) error
+	transport       http.RoundTripper
+}
+
+func (h *proxyHandler[Reg]) Metadata() *HandlerMetadata {
+	return nil
+}
+
+type proxyStateKey struct{}
+
+// proxyState is the state of the request that is passed to the hooks of httputil.ReverseProxy.
+type proxyState[Reg any] struct {
+	ctx    Context[Reg]
+	target *url.URL
+	err    error
+}
+
+func (h *proxyHandler[Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	state := func(req *http.Request) *proxyState[Reg] {
+		return req.Context().Value(proxyStateKey{}).(*proxyState[Reg])
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			s := state(pr.In)
+			pr.SetURL(s.target)
+			pr.SetXForwarded()
+			if tc, ok := TraceContextFromContext(s.ctx); ok {
+				tc.Inject(pr.Out.Header)
+			}
+			for _, fn := range h.rewrites {
+				fn(s.ctx, pr)
+			}
+		},
+		Transport: h.transport,
+		ModifyResponse: func(res *http.Response) error {
+			s := state(res.Request)
+			for _, fn := range h.modifyResponses {
+				if err := fn(s.ctx, res); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			s := state(req)
+			s.err = err
+			r.onError(w, req, s.ctx, ErrorStageHandler, WrapErrorWithStatus(http.StatusBadGateway, err))
+		},
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		req = withLogAttrs(req)
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		t1 := time.Now()
+		var lerr error
+		var ctx Context[Reg]
+		defer func() {
+			rec := recover()
+			r.deferDoWithResult(ctx, ww, req, resultError(lerr, rec))
+			if err := r.accessLoggerLog(req.Context(), ww, req, lerr, t1, time.Now()); err != nil {
+				r.logger.ErrorContext(req.Context(), "access log error", slog.Any("error", err))
+			}
+			if rec != nil {
+				panic(rec)
+			}
+		}()
+
+		ctx, err := r.contextFactory.Build(ww, req)
+		if err != nil {
+			r.onError(ww, req, nil, ErrorStageContext, err)
+			lerr = err
+			return
+		}
+		if err := r.featureFlags.check(ctx); err != nil {
+			r.onError(ww, req, ctx, ErrorStageFeatureFlag, err)
+			lerr = err
+			return
+		}
+		if err := r.authorize(ctx); err != nil {
+			r.onError(ww, req, ctx, ErrorStageAuthorize, err)
+			lerr = err
+			return
+		}
+		target, err := h.target(ctx)
+		if err != nil {
+			r.onError(ww, req, ctx, ErrorStageHandler, err)
+			lerr = err
+			return
+		}
+		if err := r.deferDo(ctx, DeferDoTimingBeforeResponse); err != nil {
+			r.onError(ww, req, ctx, ErrorStageDeferBeforeResponse, err)
+			lerr = err
+			return
+		}
+
+		s := &proxyState[Reg]{ctx: ctx, target: target}
+		proxy.ServeHTTP(ww, req.WithContext(gocontext.WithValue(req.Context(), proxyStateKey{}, s)))
+		lerr = s.err
+
+		if err := r.deferDo(ctx, DeferDoTimingAfterResponse); err != nil {
+			r.logger.ErrorContext(ctx, "defer do error", slog.Any("error", err))
+			lerr = err
+		}
+	}
+}
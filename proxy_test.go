@@ -0,0 +1,97 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type proxyUser struct {
+	name string
+}
+
+func TestNewProxyHandler(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Internal", "secret")
+		fmt.Fprintf(w, "%s %s user=%s forwarded=%s", req.Method, req.URL.Path, req.Header.Get("X-User"), req.Header.Get("X-Forwarded-Host"))
+	}))
+	t.Cleanup(backend.Close)
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL, err := url.Parse(dead.URL)
+	require.NoError(t, err)
+	dead.Close()
+
+	auth := tanukirpc.NewTransformer(func(ctx tanukirpc.Context[struct{}]) (*proxyUser, error) {
+		name := ctx.Request().Header.Get("Authorization")
+		if name == "" {
+			return nil, tanukirpc.WrapErrorWithStatus(http.StatusUnauthorized, errors.New("unauthorized"))
+		}
+		return &proxyUser{name: name}, nil
+	})
+	r := tanukirpc.NewRouter(struct{}{})
+	tanukirpc.RouteWithTransformer(r, auth, "/", func(r *tanukirpc.Router[*proxyUser]) {
+		r.Get("/legacy/*", tanukirpc.NewProxyHandler(
+			func(ctx tanukirpc.Context[*proxyUser]) (*url.URL, error) {
+				return backendURL, nil
+			},
+			tanukirpc.WithProxyRewrite(func(ctx tanukirpc.Context[*proxyUser], pr *httputil.ProxyRequest) {
+				pr.Out.Header.Set("X-User", ctx.Registry().name)
+			}),
+			tanukirpc.WithProxyModifyResponse(func(ctx tanukirpc.Context[*proxyUser], res *http.Response) error {
+				res.Header.Del("X-Internal")
+				return nil
+			}),
+		))
+		r.Get("/dead", tanukirpc.NewProxyHandler(func(ctx tanukirpc.Context[*proxyUser]) (*url.URL, error) {
+			return deadURL, nil
+		}))
+	})
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	do := func(t *testing.T, path, authorization string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	t.Run("proxied", func(t *testing.T) {
+		resp, body := do(t, "/legacy/users", "tanuki")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "GET /legacy/users user=tanuki forwarded="+server.Listener.Addr().String(), body)
+		assert.Empty(t, resp.Header.Get("X-Internal"))
+	})
+
+	t.Run("transformer error", func(t *testing.T) {
+		resp, body := do(t, "/legacy/users", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.JSONEq(t, `{"error":{"message":"unauthorized"}}`, body)
+	})
+
+	t.Run("bad gateway", func(t *testing.T) {
+		resp, _ := do(t, "/dead", "tanuki")
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+}
@@ -0,0 +1,139 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// ErrRawBodyTooLarge is returned when the request body exceeds the limit of the RawBodyCodec.
+// The request is responded with 413 Request Entity Too Large.
+var ErrRawBodyTooLarge = errors.New("request body too large")
+
+// RawBodyCodecOption is an option for the RawBodyCodec.
+type RawBodyCodecOption func(*RawBodyCodec)
+
+// WithRawBodyMaxBytes limits the size of the request body that is read by the RawBodyCodec.
+func WithRawBodyMaxBytes(n int64) RawBodyCodecOption {
+	return func(r *RawBodyCodec) {
+		r.maxBytes = n
+	}
+}
+
+// WithRawBodyContentTypes restricts the content types of the request that is read by the RawBodyCodec.
+// The request with the other content type is responded with 415 Unsupported Media Type.
+func WithRawBodyContentTypes(contentTypes ...string) RawBodyCodecOption {
+	return func(r *RawBodyCodec) {
+		r.contentTypes = contentTypes
+	}
+}
+
+type rawBodyConfigCtxKey struct{}
+
+type rawBodyConfig struct {
+	maxBytes     int64
+	contentTypes []string
+}
+
+// RawBodyLimit returns a middleware that overrides the limits of the RawBodyCodec for the routes.
+// Use this with Router.With to set the limits per route.
+//
+//	router.With(tanukirpc.RawBodyLimit(100<<20, "image/png")).Post("/upload", handler)
+func RawBodyLimit(maxBytes int64, contentTypes ...string) func(http.Handler) http.Handler {
+	cfg := &rawBodyConfig{maxBytes: maxBytes, contentTypes: contentTypes}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := gocontext.WithValue(req.Context(), rawBodyConfigCtxKey{}, cfg)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+func (r *RawBodyCodec) config(req *http.Request) *rawBodyConfig {
+	if cfg, ok := req.Context().Value(rawBodyConfigCtxKey{}).(*rawBodyConfig); ok {
+		return cfg
+	}
+	return &rawBodyConfig{maxBytes: r.maxBytes, contentTypes: r.contentTypes}
+}
+
+func (c *rawBodyConfig) checkContentType(req *http.Request) error {
+	if len(c.contentTypes) == 0 {
+		return nil
+	}
+	ct := req.Header.Get("content-type")
+	mt, _, err := mime.ParseMediaType(ct)
+	if err == nil && slices.Contains(c.contentTypes, mt) {
+		return nil
+	}
+	return WrapErrorWithStatus(
+		http.StatusUnsupportedMediaType,
+		fmt.Errorf("unsupported content type: %q", ct),
+	)
+}
+
+func (c *rawBodyConfig) readAll(body io.Reader) ([]byte, error) {
+	if c.maxBytes <= 0 {
+		bs, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+		return bs, nil
+	}
+	bs, err := io.ReadAll(io.LimitReader(body, c.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if int64(len(bs)) > c.maxBytes {
+		return nil, WrapErrorWithStatus(http.StatusRequestEntityTooLarge, ErrRawBodyTooLarge)
+	}
+	return bs, nil
+}
+
+func (c *rawBodyConfig) stream(body io.ReadCloser) io.ReadCloser {
+	if c.maxBytes <= 0 {
+		return body
+	}
+	return &limitedBody{ReadCloser: http.MaxBytesReader(nil, body, c.maxBytes)}
+}
+
+// limitedBody converts the error of http.MaxBytesReader into the error with 413 status.
+// The handler can return the error as is.
+type limitedBody struct {
+	io.ReadCloser
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return n, WrapErrorWithStatus(http.StatusRequestEntityTooLarge, ErrRawBodyTooLarge)
+	}
+	return n, err
+}
+
+var readCloserType = reflect.TypeFor[io.ReadCloser]()
+
+// isRawBodyStreamType reports whether the request type receives the body as a stream.
+// The codecs that read the body (e.g. JSONCodec) skip this type, so the body is passed to the handler unread.
+// Note that the nopCodec discards the body, but it is not reached because the RawBodyCodec decodes this type.
+func isRawBodyStreamType(t reflect.Type) bool {
+	if t.AssignableTo(readCloserType) {
+		return true
+	}
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if _, ok := ft.Tag.Lookup("rawbody"); ok && ft.Type.AssignableTo(readCloserType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package tanukirpc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const sniffLen = 512
+
+// RawResponse is a response that writes the body as is with the content type.
+// If ContentType is empty, it is detected by http.DetectContentType.
+// If ContentLength is negative, the Content-Length header is set only when the length is known from the body.
+type RawResponse struct {
+	Body          io.Reader
+	ContentType   string
+	ContentLength int64
+}
+
+// WithContentType wraps the raw response body with the content type.
+// The body must be []byte, string or io.Reader.
+//
+//	func image(ctx tanukirpc.Context[*Registry], req imageRequest) (*tanukirpc.RawResponse, error) {
+//		return tanukirpc.WithContentType(pngBytes, "image/png"), nil
+//	}
+func WithContentType(body any, contentType string) *RawResponse {
+	switch body := body.(type) {
+	case []byte:
+		return &RawResponse{Body: bytes.NewReader(body), ContentType: contentType, ContentLength: int64(len(body))}
+	case string:
+		return &RawResponse{Body: bytes.NewReader([]byte(body)), ContentType: contentType, ContentLength: int64(len(body))}
+	case io.Reader:
+		return &RawResponse{Body: body, ContentType: contentType, ContentLength: -1}
+	}
+	panic(fmt.Sprintf("tanukirpc: unsupported raw response body type %T", body))
+}
+
+// FileResponse is the response of the content like the file that supports the range requests.
+// The Range and If-Range headers are honored with 206 Partial Content and the Content-Range header by http.ServeContent,
+// for the resumable downloads and the seeking of the media. The RawResponse and the io.Reader that are io.ReadSeeker are responded as well.
+//
+//	func download(ctx tanukirpc.Context[*Registry], req downloadRequest) (*tanukirpc.FileResponse, error) {
+//		f, err := os.Open(filepath.Join(ctx.Registry().dir, req.Name))
+//		if err != nil {
+//			return nil, err
+//		}
+//		return tanukirpc.NewFileResponse(f)
+//	}
+type FileResponse struct {
+	// Content is closed after the response if it is io.Closer.
+	Content io.ReadSeeker
+	// Name is the name of the file. The content type is detected by the extension of it unless ContentType is set.
+	Name string
+	// ModTime is the time for the Last-Modified, If-Modified-Since and If-Range headers. The zero value is ignored.
+	ModTime time.Time
+	// ContentType is the content type of the content. If it is empty, it is detected by Name or the content.
+	ContentType string
+	// Attachment makes the browsers download the content as the file of Name by the Content-Disposition header.
+	Attachment bool
+}
+
+// NewFileResponse returns the FileResponse of the file with the name and the modification time of it. The file is closed after the response.
+func NewFileResponse(f *os.File) (*FileResponse, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if fi.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%s is a directory", f.Name())
+	}
+	return &FileResponse{Content: f, Name: fi.Name(), ModTime: fi.ModTime()}, nil
+}
+
+func (f *FileResponse) write(w http.ResponseWriter, req *http.Request) error {
+	if f.Content == nil {
+		return fmt.Errorf("file response content is nil")
+	}
+	if closer, ok := f.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if f.ContentType != "" {
+		w.Header().Set("content-type", f.ContentType)
+	}
+	if f.Attachment {
+		w.Header().Set("content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Name}))
+	}
+	http.ServeContent(w, req, f.Name, f.ModTime, f.Content)
+	return nil
+}
+
+func isRawResponse(v any) bool {
+	switch v.(type) {
+	case *RawResponse, *FileResponse, io.Reader:
+		return true
+	}
+	return false
+}
+
+type lener interface {
+	Len() int
+}
+
+func (r *RawResponse) length() int64 {
+	if r.ContentLength >= 0 {
+		return r.ContentLength
+	}
+	switch body := r.Body.(type) {
+	case lener:
+		return int64(body.Len())
+	case *os.File:
+		if fi, err := body.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	}
+	return -1
+}
+
+func (r *RawResponse) write(w http.ResponseWriter, req *http.Request) error {
+	if r.Body == nil {
+		return fmt.Errorf("raw response body is nil")
+	}
+	if rs, ok := r.Body.(io.ReadSeeker); ok {
+		return (&FileResponse{Content: rs, ContentType: r.ContentType}).write(w, req)
+	}
+	if closer, ok := r.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if l := r.length(); l >= 0 {
+		w.Header().Set("content-length", strconv.FormatInt(l, 10))
+	}
+	body := r.Body
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = w.Header().Get("content-type")
+	}
+	if contentType == "" {
+		br := bufio.NewReaderSize(r.Body, sniffLen)
+		peeked, _ := br.Peek(sniffLen)
+		contentType = http.DetectContentType(peeked)
+		body = br
+	}
+	w.Header().Set("content-type", contentType)
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("failed to write body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package tanukirpc_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileResponseRange(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "animals.txt")
+	require.NoError(t, os.WriteFile(path, []byte("tanuki,kitsune,anaguma"), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/animals.txt", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.FileResponse, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		res, err := tanukirpc.NewFileResponse(f)
+		if err != nil {
+			return nil, err
+		}
+		res.Attachment = true
+		return res, nil
+	}))
+	router.Get("/animals.csv", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (io.Reader, error) {
+		return strings.NewReader("tanuki,kitsune,anaguma"), nil
+	}))
+
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "*/*")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/animals.txt", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=animals.txt`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, modTime.Format(http.TimeFormat), rec.Header().Get("Last-Modified"))
+	assert.Equal(t, "tanuki,kitsune,anaguma", rec.Body.String())
+
+	rec = get("/animals.txt", map[string]string{"Range": "bytes=7-13"})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "bytes 7-13/22", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "kitsune", rec.Body.String())
+
+	rec = get("/animals.txt", map[string]string{"Range": "bytes=7-13", "If-Range": modTime.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusPartialContent, rec.Code, "the range is honored when the file is not modified")
+	assert.Equal(t, "kitsune", rec.Body.String())
+
+	rec = get("/animals.txt", map[string]string{"Range": "bytes=7-13", "If-Range": modTime.Add(-time.Hour).Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusOK, rec.Code, "the whole file is responded when the file is modified")
+	assert.Equal(t, "tanuki,kitsune,anaguma", rec.Body.String())
+
+	rec = get("/animals.txt", map[string]string{"Range": "bytes=100-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+	assert.Equal(t, "bytes */22", rec.Header().Get("Content-Range"))
+
+	rec = get("/animals.csv", map[string]string{"Range": "bytes=15-"})
+	assert.Equal(t, http.StatusPartialContent, rec.Code, "the io.ReadSeeker is responded with the range")
+	assert.Equal(t, "bytes 15-21/22", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "anaguma", rec.Body.String())
+}
+
+type rawBlob []byte
+
+func TestRawBodyCodecEncode(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/blob", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (rawBlob, error) {
+		return rawBlob("tanuki"), nil
+	}))
+	router.Get("/nil", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.RawResponse, error) {
+		return nil, nil
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/octet-stream")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("named byte slice", func(t *testing.T) {
+		rec := get("/blob")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "tanuki", rec.Body.String())
+	})
+	t.Run("nil raw response", func(t *testing.T) {
+		rec := get("/nil")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("Content-Length"))
+		assert.Empty(t, rec.Body.String())
+	})
+}
@@ -0,0 +1,142 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// realIP resolves the IP address of the client from the forwarded headers.
+type realIP struct {
+	// restricted is true if the trusted proxies are configured by WithTrustedProxies.
+	// Otherwise, the forwarded headers of all peers are trusted like middleware.RealIP of chi.
+	restricted bool
+	trusted    []netip.Prefix
+}
+
+// WithTrustedProxies honors the forwarded headers like X-Forwarded-For only when the peer is in the CIDRs, like "10.0.0.0/8".
+// The client IP is the rightmost address of X-Forwarded-For that is not a trusted proxy, so the addresses added by the clients are ignored.
+// Without this option, the forwarded headers of all peers are trusted, and they can be spoofed if the Router is exposed directly.
+// Calling it without the CIDRs ignores the forwarded headers. It panics if a CIDR is invalid.
+//
+//	tanukirpc.NewRouter(reg, tanukirpc.WithTrustedProxies[*registry]("10.0.0.0/8", "fd00::/8"))
+func WithTrustedProxies[Reg any](cidrs ...string) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.realIP.restricted = true
+		for _, cidr := range cidrs {
+			prefix, err := parsePrefix(cidr)
+			if err != nil {
+				panic(fmt.Sprintf("tanukirpc: invalid trusted proxy %q: %v", cidr, err))
+			}
+			r.realIP.trusted = append(r.realIP.trusted, prefix)
+		}
+		return r
+	}
+}
+
+// parsePrefix parses the CIDR or the single address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+type clientIPKey struct{}
+
+// ClientIP returns the IP address of the client of the request that is resolved by the default middleware MiddlewareRealIP.
+// It is the key of the access logs and the rate limiting per client. ctx is the Context[Reg] or the context of the request.
+// If MiddlewareRealIP is removed, it returns the host of the RemoteAddr of the request of Context[Reg], or the empty string.
+func ClientIP(ctx gocontext.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(netip.Addr); ok {
+		return ip.String()
+	}
+	if rc, ok := ctx.(interface{ Request() *http.Request }); ok {
+		return remoteHost(rc.Request().RemoteAddr)
+	}
+	return ""
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// middleware sets the RemoteAddr of the request to the client IP like middleware.RealIP of chi, and attaches it to the context.
+func (ri *realIP) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		peer, _ := netip.ParseAddr(remoteHost(req.RemoteAddr))
+		ip, forwarded := ri.clientIP(req, peer.Unmap())
+		if forwarded {
+			req.RemoteAddr = ip.String()
+		}
+		if ip.IsValid() {
+			req = req.WithContext(gocontext.WithValue(req.Context(), clientIPKey{}, ip))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// clientIP returns the client IP, and whether it is taken from the forwarded headers.
+func (ri *realIP) clientIP(req *http.Request, peer netip.Addr) (netip.Addr, bool) {
+	if !ri.restricted {
+		for _, v := range []string{
+			req.Header.Get("True-Client-IP"),
+			req.Header.Get("X-Real-IP"),
+			strings.Split(req.Header.Get("X-Forwarded-For"), ",")[0],
+		} {
+			if ip, err := netip.ParseAddr(strings.TrimSpace(v)); err == nil {
+				return ip.Unmap(), true
+			}
+		}
+		return peer, false
+	}
+
+	if !peer.IsValid() || !ri.isTrusted(peer) {
+		return peer, false
+	}
+	var hops []string
+	for _, v := range req.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = ip.Unmap()
+		if !ri.isTrusted(client) {
+			break
+		}
+	}
+	if len(hops) == 0 {
+		if ip, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+			client = ip.Unmap()
+		}
+	}
+	return client, client != peer
+}
+
+func (ri *realIP) isTrusted(ip netip.Addr) bool {
+	for _, prefix := range ri.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package tanukirpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []tanukirpc.RouterOption[struct{}]
+		remote   string
+		header   map[string]string
+		expected string
+	}{
+		{
+			name:     "trust all by default",
+			remote:   "198.51.100.1:1234",
+			header:   map[string]string{"X-Forwarded-For": "203.0.113.1, 10.0.0.1"},
+			expected: "203.0.113.1",
+		},
+		{
+			name:     "untrusted peer",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithTrustedProxies[struct{}]("10.0.0.0/8")},
+			remote:   "198.51.100.1:1234",
+			header:   map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "spoofed by the client",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithTrustedProxies[struct{}]("10.0.0.0/8")},
+			remote:   "10.0.0.2:1234",
+			header:   map[string]string{"X-Forwarded-For": "192.0.2.99, 203.0.113.1, 10.0.0.1"},
+			expected: "203.0.113.1",
+		},
+		{
+			name:     "all hops are trusted",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithTrustedProxies[struct{}]("10.0.0.0/8")},
+			remote:   "10.0.0.2:1234",
+			header:   map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.1"},
+			expected: "10.0.0.3",
+		},
+		{
+			name:     "X-Real-IP",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithTrustedProxies[struct{}]("10.0.0.1")},
+			remote:   "10.0.0.1:1234",
+			header:   map[string]string{"X-Real-IP": "203.0.113.1"},
+			expected: "203.0.113.1",
+		},
+		{
+			name:     "no trusted proxies",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithTrustedProxies[struct{}]()},
+			remote:   "10.0.0.1:1234",
+			header:   map[string]string{"X-Real-IP": "203.0.113.1"},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "without the middleware",
+			opts:     []tanukirpc.RouterOption[struct{}]{tanukirpc.WithoutDefaultMiddleware[struct{}](tanukirpc.MiddlewareRealIP)},
+			remote:   "198.51.100.1:1234",
+			header:   map[string]string{"X-Real-IP": "203.0.113.1"},
+			expected: "198.51.100.1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := tanukirpc.NewRouter(struct{}{}, tc.opts...)
+			router.Get("/ip", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+				return tanukirpc.ClientIP(ctx), nil
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.Header.Set("Accept", "application/json")
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `"`+tc.expected+`"`, rec.Body.String())
+		})
+	}
+
+	assert.PanicsWithValue(t, `tanukirpc: invalid trusted proxy "10.0.0.0/33": netip.ParsePrefix("10.0.0.0/33"): prefix length out of range`, func() {
+		tanukirpc.NewRouter(struct{}{}, tanukirpc.WithTrustedProxies[struct{}]("10.0.0.0/33"))
+	})
+}
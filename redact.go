@@ -0,0 +1,365 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RedactedValue is the value that replaces the values of the redacted fields in the logs and the error messages.
+const RedactedValue = "[REDACTED]"
+
+// Redact returns the copy of v for the logs and the debug endpoints like expvar, where the values of the redacted fields are replaced with RedactedValue.
+// The fields are redacted by the `sensitive:"true"`, `log:"redact"` or `log:"-"` struct tags. The copy has the shape of v encoded as JSON.
+//
+//	logger.InfoContext(ctx, "login", slog.Any("request", tanukirpc.Redact(req)))
+//
+// The same struct tags are honored by the access logs, the audit bodies of WithAuditBody and the decode errors of the request.
+func Redact(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return RedactedValue
+	}
+	var jv any
+	if err := json.Unmarshal(b, &jv); err != nil {
+		return RedactedValue
+	}
+	if t := reflect.TypeOf(v); t != nil {
+		jv = redactJSONValue(jv, t)
+	}
+	return jv
+}
+
+// isRedactedField reports whether the field is redacted in the logs and the error messages.
+func isRedactedField(sf reflect.StructField) bool {
+	switch sf.Tag.Get("log") {
+	case "-", "redact":
+		return true
+	}
+	return sf.Tag.Get("sensitive") == "true"
+}
+
+// redactBody replaces the values of the redacted fields of t in the JSON body with RedactedValue.
+func redactBody(body []byte, t reflect.Type, truncated bool) []byte {
+	if len(body) == 0 || t == nil || !hasRedactedField(t) {
+		return body
+	}
+	if truncated {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	b, err := json.Marshal(redactJSONValue(v, t))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func hasRedactedField(t reflect.Type) bool {
+	return hasRedactedFieldVisited(t, map[reflect.Type]struct{}{})
+}
+
+func hasRedactedFieldVisited(t reflect.Type, visited map[reflect.Type]struct{}) bool {
+	t = indirectType(t)
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasRedactedFieldVisited(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if isRedactedField(sf) || hasRedactedFieldVisited(sf.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactJSONValue walks the value decoded from JSON along with the Go type that it is encoded from.
+func redactJSONValue(v any, t reflect.Type) any {
+	t = indirectType(t)
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, e := range v {
+				v[k] = redactJSONValue(e, t.Elem())
+			}
+		case reflect.Struct:
+			redactJSONObject(v, t)
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, e := range v {
+				v[i] = redactJSONValue(e, t.Elem())
+			}
+		}
+	}
+	return v
+}
+
+func redactJSONObject(v map[string]any, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			// the fields of the embedded struct are promoted to the object
+			if et := indirectType(sf.Type); et.Kind() == reflect.Struct {
+				redactJSONObject(v, et)
+				continue
+			}
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		key, ok := jsonObjectKey(v, name)
+		if !ok {
+			continue
+		}
+		if isRedactedField(sf) {
+			v[key] = RedactedValue
+			continue
+		}
+		v[key] = redactJSONValue(v[key], sf.Type)
+	}
+}
+
+// jsonObjectKey returns the key of the object for the field name. It matches case-insensitively like encoding/json.
+func jsonObjectKey(v map[string]any, name string) (string, bool) {
+	if _, ok := v[name]; ok {
+		return name, true
+	}
+	for k := range v {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// redactedInputs is the names of the redacted fields of the request type for each source of the values.
+type redactedInputs struct {
+	urlParams []string
+	queries   []string
+	forms     []string
+	jsonNames map[string]struct{}
+}
+
+// newRedactedInputs returns the redactedInputs of the request type t. It returns nil if t has no redacted fields.
+func newRedactedInputs(t reflect.Type) *redactedInputs {
+	if !hasRedactedField(t) {
+		return nil
+	}
+	ri := &redactedInputs{jsonNames: map[string]struct{}{}}
+	ri.collect(t, map[reflect.Type]struct{}{})
+	return ri
+}
+
+func (ri *redactedInputs) collect(t reflect.Type, visited map[reflect.Type]struct{}) {
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		ri.collect(t.Elem(), visited)
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+	if _, ok := visited[t]; ok {
+		return
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !isRedactedField(sf) {
+			ri.collect(sf.Type, visited)
+			continue
+		}
+		if v := sf.Tag.Get("urlparam"); v != "" {
+			ri.urlParams = append(ri.urlParams, v)
+		}
+		if v, _, _ := strings.Cut(sf.Tag.Get("query"), ","); v != "" {
+			ri.queries = append(ri.queries, v)
+		}
+		if v, _, _ := strings.Cut(sf.Tag.Get("form"), ","); v != "" {
+			ri.forms = append(ri.forms, v)
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" {
+			name = sf.Name
+		}
+		ri.jsonNames[strings.ToLower(name)] = struct{}{}
+	}
+}
+
+// decodeError returns the error that hides the values of the redacted fields of the request in err.
+// Only the segments of the values in the known shapes of the errors are replaced, like the quoted value of strconv.ParseInt
+// and the number of json.UnmarshalTypeError, so the short values do not mangle the other text of the message.
+func (ri *redactedInputs) decodeError(req *http.Request, err error) error {
+	if ri == nil {
+		return err
+	}
+	var values []string
+	for _, name := range ri.urlParams {
+		values = append(values, chi.URLParam(req, name))
+	}
+	query := req.URL.Query()
+	for _, name := range ri.queries {
+		values = append(values, query[name]...)
+	}
+	for _, name := range ri.forms {
+		values = append(values, req.PostForm[name]...)
+	}
+	var segments []string
+	for _, v := range values {
+		if v != "" {
+			segments = append(segments, strconv.Quote(v), strconv.Quote(RedactedValue))
+		}
+	}
+	// the error of encoding/json has the number value like "cannot unmarshal number -5 into"
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) && strings.HasPrefix(ute.Value, "number ") {
+		field := ute.Field[strings.LastIndexByte(ute.Field, '.')+1:]
+		if _, ok := ri.jsonNames[strings.ToLower(field)]; ok {
+			segments = append(segments, "unmarshal "+ute.Value+" into", "unmarshal number "+RedactedValue+" into")
+		}
+	}
+	if len(segments) == 0 {
+		return err
+	}
+	return &redactedError{err: err, replacer: strings.NewReplacer(segments...)}
+}
+
+// redactedError is the error whose message does not have the values of the redacted fields.
+// The wrapped error still has them, so do not write it to the logs.
+type redactedError struct {
+	err      error
+	replacer *strings.Replacer
+}
+
+func (e *redactedError) Error() string {
+	return e.replacer.Replace(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+type redactedURIKey struct{}
+
+// redactedURI is the names of the redacted query parameters and the path where the redacted URL parameters are replaced.
+type redactedURI struct {
+	queries []string
+	path    string
+}
+
+// withRedactedURI attaches the redacted query parameters and the redacted path of the request for the access logs.
+func (ri *redactedInputs) withRedactedURI(req *http.Request) *http.Request {
+	if ri == nil || (len(ri.queries) == 0 && len(ri.urlParams) == 0) {
+		return req
+	}
+	ru := &redactedURI{queries: ri.queries}
+	if len(ri.urlParams) > 0 {
+		ru.path = redactedPath(req, ri.urlParams)
+	}
+	return req.WithContext(gocontext.WithValue(req.Context(), redactedURIKey{}, ru))
+}
+
+// redactedPath returns the path of the request where the values of the redacted URL parameters are replaced with RedactedValue.
+// The path is rebuilt from the route pattern. If the rebuilt path does not match the request, the route pattern is returned not to log the values.
+func redactedPath(req *http.Request, names []string) string {
+	pattern := routePattern(req)
+	var actual, redacted strings.Builder
+	param := func(name string) {
+		v := chi.URLParam(req, name)
+		actual.WriteString(v)
+		if slices.Contains(names, name) {
+			redacted.WriteString(RedactedValue)
+		} else {
+			redacted.WriteString(v)
+		}
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '{':
+			// the parameter like {id} or {id:[0-9]{3}} that has the braces in the regexp
+			depth, end := 0, -1
+			for j := i; j < len(pattern) && end < 0; j++ {
+				switch pattern[j] {
+				case '{':
+					depth++
+				case '}':
+					if depth--; depth == 0 {
+						end = j
+					}
+				}
+			}
+			if end < 0 {
+				return pattern
+			}
+			name, _, _ := strings.Cut(pattern[i+1:end], ":")
+			param(name)
+			i = end
+		case '*':
+			param("*")
+		default:
+			actual.WriteByte(c)
+			redacted.WriteByte(c)
+		}
+	}
+	if actual.String() != req.URL.Path {
+		return pattern
+	}
+	return redacted.String()
+}
+
+// redactedRequestURI returns the request URI where the values of the redacted query parameters and URL parameters are replaced with RedactedValue.
+func redactedRequestURI(ctx gocontext.Context, uri string) string {
+	ru, ok := ctx.Value(redactedURIKey{}).(*redactedURI)
+	if !ok {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	redacted := ru.path != ""
+	if redacted {
+		u.Path, u.RawPath = ru.path, ""
+	}
+	query := u.Query()
+	queryRedacted := false
+	for _, name := range ru.queries {
+		if vs, ok := query[name]; ok {
+			for i := range vs {
+				vs[i] = RedactedValue
+			}
+			queryRedacted = true
+		}
+	}
+	if !redacted && !queryRedacted {
+		return uri
+	}
+	if queryRedacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
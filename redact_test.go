@@ -0,0 +1,107 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type redactCredential struct {
+	Kind  string `json:"kind"`
+	Token string `json:"token" sensitive:"true"`
+}
+
+type redactRequest struct {
+	Name        string             `json:"name"`
+	Password    string             `json:"password" log:"redact"`
+	Credentials []redactCredential `json:"credentials"`
+}
+
+func TestRedact(t *testing.T) {
+	v := tanukirpc.Redact(&redactRequest{
+		Name:        "tanuki",
+		Password:    "p@ssw0rd",
+		Credentials: []redactCredential{{Kind: "api", Token: "secret-token"}},
+	})
+	assert.Equal(t, map[string]any{
+		"name":     "tanuki",
+		"password": tanukirpc.RedactedValue,
+		"credentials": []any{
+			map[string]any{"kind": "api", "token": tanukirpc.RedactedValue},
+		},
+	}, v)
+}
+
+type redactQueryRequest struct {
+	PIN   int    `query:"pin" sensitive:"true"`
+	Token string `query:"token" log:"-"`
+	Page  int    `query:"page"`
+}
+
+func TestRedactRequestErrorsAndAccessLog(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	combined := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logBuf, nil))
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](logger))
+	router.Get("/secrets", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req redactQueryRequest) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	combinedRouter := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithAccessLogger[struct{}](tanukirpc.NewCombinedAccessLogger(combined)))
+	combinedRouter.Get("/secrets", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req redactQueryRequest) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	for _, r := range []*tanukirpc.Router[struct{}]{router, combinedRouter} {
+		req := httptest.NewRequest(http.MethodGet, "/secrets?pin=12a4&token=secret-token&page=2", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "12a4")
+	}
+	logs := logBuf.String() + combined.String()
+	assert.NotContains(t, logs, "12a4")
+	assert.NotContains(t, logs, "secret-token")
+	assert.Contains(t, logs, "page=2")
+	assert.Contains(t, combined.String(), "token=%5BREDACTED%5D")
+}
+
+type redactPathRequest struct {
+	Token string `urlparam:"token" sensitive:"true"`
+	ID    int    `urlparam:"id"`
+	PIN   int    `query:"pin" sensitive:"true"`
+}
+
+func TestRedactURLParamsAndShortValues(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	combined := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logBuf, nil))
+	h := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req redactPathRequest) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](logger))
+	router.Get("/invites/{token}/items/{id:[0-9]{1,3}}", h)
+	combinedRouter := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithAccessLogger[struct{}](tanukirpc.NewCombinedAccessLogger(combined)))
+	combinedRouter.Get("/invites/{token}/items/{id:[0-9]{1,3}}", h)
+
+	for _, r := range []*tanukirpc.Router[struct{}]{router, combinedRouter} {
+		req := httptest.NewRequest(http.MethodGet, "/invites/invite-secret/items/42?pin=a&page=2", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `failed to decode query`, "the short value does not mangle the message")
+		assert.Contains(t, rec.Body.String(), `parsing \"[REDACTED]\"`)
+	}
+	logs := logBuf.String() + combined.String()
+	assert.NotContains(t, logs, "invite-secret")
+	assert.Contains(t, logBuf.String(), `"path":"/invites/%5BREDACTED%5D/items/42?page=2&pin=%5BREDACTED%5D"`)
+	assert.Contains(t, combined.String(), `"GET /invites/%5BREDACTED%5D/items/42?page=2&pin=%5BREDACTED%5D HTTP/1.1"`)
+}
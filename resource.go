@@ -0,0 +1,65 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultResourceIDParam = "id"
+
+// Resource is the set of the handlers of the conventional REST routes of the resource that is registered by Router.Resource.
+// The routes of the nil handlers are not registered.
+type Resource[Reg any] struct {
+	// Index lists the resources. It is routed to GET pattern.
+	Index Handler[Reg]
+	// Create creates the resource. It is routed to POST pattern.
+	Create Handler[Reg]
+	// Show gets the resource. It is routed to GET pattern/{id}.
+	Show Handler[Reg]
+	// Update updates the resource. It is routed to PUT pattern/{id}.
+	Update Handler[Reg]
+	// Destroy deletes the resource. It is routed to DELETE pattern/{id}.
+	Destroy Handler[Reg]
+	// IDParam is the name of the URL parameter of the ID of the resource. The default is "id".
+	IDParam string
+}
+
+// Resource registers the handlers of res as the conventional REST routes of the resource at pattern:
+//
+//	r.Resource("/tasks", tanukirpc.Resource[*registry]{
+//		Index:   tanukirpc.NewHandler(listTasks),  // GET /tasks
+//		Create:  tanukirpc.NewHandler(createTask), // POST /tasks
+//		Show:    tanukirpc.NewHandler(getTask),    // GET /tasks/{id}
+//		Update:  tanukirpc.NewHandler(updateTask), // PUT /tasks/{id}
+//		Destroy: tanukirpc.NewHandler(deleteTask), // DELETE /tasks/{id}
+//	})
+//
+// The ID is bound to the field with `urlparam:"id"` of the requests of Show, Update and Destroy.
+// genclient recognizes the routes as the actions of the resource. It panics if res has no handlers.
+func (r *Router[Reg]) Resource(pattern string, res Resource[Reg]) {
+	if res.Index == nil && res.Create == nil && res.Show == nil && res.Update == nil && res.Destroy == nil {
+		panic(fmt.Sprintf("tanukirpc: resource %s has no handlers", pattern))
+	}
+	idParam := res.IDParam
+	if idParam == "" {
+		idParam = defaultResourceIDParam
+	}
+	member := strings.TrimSuffix(pattern, "/") + "/{" + idParam + "}"
+
+	for _, route := range []struct {
+		method  string
+		pattern string
+		handler Handler[Reg]
+	}{
+		{http.MethodGet, pattern, res.Index},
+		{http.MethodPost, pattern, res.Create},
+		{http.MethodGet, member, res.Show},
+		{http.MethodPut, member, res.Update},
+		{http.MethodDelete, member, res.Destroy},
+	} {
+		if route.handler != nil {
+			r.handle(route.method, route.pattern, route.handler)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tanukirpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ResponseBodyHook is called with the encoded response body before it is written to the client.
+// The hook can modify the headers and the body, for example, to set ETag, to compress the body or to store it in a cache.
+// If the hook calls w.WriteHeader, the status code is used for the response.
+type ResponseBodyHook interface {
+	OnResponseBody(w http.ResponseWriter, req *http.Request, body *bytes.Buffer) error
+}
+
+// ResponseBodyHookFunc is an adapter to allow the use of ordinary functions as ResponseBodyHook.
+type ResponseBodyHookFunc func(w http.ResponseWriter, req *http.Request, body *bytes.Buffer) error
+
+func (f ResponseBodyHookFunc) OnResponseBody(w http.ResponseWriter, req *http.Request, body *bytes.Buffer) error {
+	return f(w, req, body)
+}
+
+const maxPooledResponseBufferSize = 64 * 1024
+
+var responseBufferPool = &sync.Pool{
+	New: func() any {
+		return &bytes.Buffer{}
+	},
+}
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	buf    *bytes.Buffer
+	status int
+}
+
+func newBufferedResponseWriter(w http.ResponseWriter) *bufferedResponseWriter {
+	buf := responseBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return &bufferedResponseWriter{ResponseWriter: w, buf: buf}
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+func (b *bufferedResponseWriter) flush() error {
+	status := b.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status != http.StatusNoContent && status != http.StatusNotModified {
+		b.Header().Set("Content-Length", strconv.Itoa(b.buf.Len()))
+	}
+	b.ResponseWriter.WriteHeader(status)
+	if b.buf.Len() == 0 {
+		return nil
+	}
+	if _, err := b.ResponseWriter.Write(b.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write response body: %w", err)
+	}
+	return nil
+}
+
+func (b *bufferedResponseWriter) release() {
+	if b.buf.Cap() > maxPooledResponseBufferSize {
+		return
+	}
+	responseBufferPool.Put(b.buf)
+}
+
+func (r *Router[Reg]) encodeResponse(w http.ResponseWriter, req *http.Request, res any, start time.Time) error {
+	codec := r.withCodecMiddlewares(r.codec)
+	// raw responses and sequences are written directly without buffering
+	if isRawResponse(res) {
+		return codec.Encode(w, req, res)
+	}
+	if isSeqResponse(res) {
+		err := codec.Encode(w, req, res)
+		var se *seqError
+		if errors.As(err, &se) {
+			return se.err
+		}
+		return err
+	}
+
+	bw := newBufferedResponseWriter(w)
+	defer bw.release()
+	if err := codec.Encode(bw, req, r.envelope(req, res, start)); err != nil {
+		return err
+	}
+	// the ETag is set after the encoding, so the error response of the failed encoding does not have it
+	if etag, ok := responseETag(res); ok && w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", etag)
+	}
+	for _, hook := range r.responseBodyHooks {
+		if err := hook.OnResponseBody(bw, req, bw.buf); err != nil {
+			return err
+		}
+	}
+	recordAuditResponse(req, res, bw.buf.Bytes())
+	return bw.flush()
+}
@@ -0,0 +1,106 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBackoffBase = 10 * time.Millisecond
+	defaultRetryBackoffMax  = time.Second
+)
+
+type retryConfig struct {
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+	retryable   func(err error) bool
+}
+
+// RetryOption is the option of WithRetry.
+type RetryOption func(*retryConfig)
+
+// WithRetryMaxAttempts sets the number of the calls of the handler including the first one. The default is 3.
+func WithRetryMaxAttempts(n int) RetryOption {
+	return func(c *retryConfig) {
+		c.maxAttempts = n
+	}
+}
+
+// WithRetryBackoff sets the function that returns the duration to wait before the retry of the attempt, which starts from 1.
+// The default doubles the duration from 10 milliseconds up to 1 second with the full jitter.
+func WithRetryBackoff(fn func(attempt int) time.Duration) RetryOption {
+	return func(c *retryConfig) {
+		c.backoff = fn
+	}
+}
+
+// WithRetryIf sets the function that reports whether the error of the handler is retried,
+// like the serialization failures and the deadlocks of the database transactions.
+// By default, the internal errors are retried, that is, the errors except ErrorWithStatus and the cancellation of the context.
+func WithRetryIf(fn func(err error) bool) RetryOption {
+	return func(c *retryConfig) {
+		c.retryable = fn
+	}
+}
+
+func defaultRetryable(err error) bool {
+	var ews ErrorWithStatus
+	if errors.As(err, &ews) {
+		return false
+	}
+	return !errors.Is(err, gocontext.Canceled) && !errors.Is(err, gocontext.DeadlineExceeded)
+}
+
+// WithRetry wraps the handler function to call it again with the backoff when it fails with the retryable error,
+// so the retry policy is kept out of the business logic. The error is handled by the ErrorHooker only after the last attempt fails.
+// The handler must be idempotent, because the side effects of the failed attempts are not rolled back by WithRetry.
+// The access logs have the "retries" attribute of the number of the retries.
+//
+//	r.Post("/tasks", tanukirpc.NewHandler(tanukirpc.WithRetry(addTask, tanukirpc.WithRetryIf(isSerializationFailure))))
+func WithRetry[Req any, Res any, Reg any](h HandlerFunc[Req, Res, Reg], opts ...RetryOption) HandlerFunc[Req, Res, Reg] {
+	cfg := &retryConfig{
+		maxAttempts: defaultRetryMaxAttempts,
+		backoff:     exponentialBackoff(defaultRetryBackoffBase, defaultRetryBackoffMax),
+		retryable:   defaultRetryable,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(ctx Context[Reg], req Req) (Res, error) {
+		for attempt := 1; ; attempt++ {
+			res, err := h(ctx, req)
+			if err == nil || attempt >= cfg.maxAttempts || !cfg.retryable(err) {
+				if attempt > 1 {
+					AddLogAttr(ctx, slog.Int("retries", attempt-1))
+				}
+				return res, err
+			}
+			t := time.NewTimer(cfg.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				AddLogAttr(ctx, slog.Int("retries", attempt-1))
+				return res, err
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// exponentialBackoff returns the backoff that doubles the duration from base up to maxDelay with the full jitter.
+func exponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := maxDelay
+		if attempt < 32 {
+			d = min(base<<(attempt-1), maxDelay)
+		}
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d) + 1
+	}
+}
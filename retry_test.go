@@ -0,0 +1,55 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	errSerialization := errors.New("could not serialize access")
+	noBackoff := tanukirpc.WithRetryBackoff(func(int) time.Duration { return 0 })
+	testCases := []struct {
+		name          string
+		errs          []error
+		opts          []tanukirpc.RetryOption
+		expectedCalls int
+		expectedCode  int
+	}{
+		{name: "success after retries", errs: []error{errSerialization, errSerialization}, expectedCalls: 3, expectedCode: http.StatusOK},
+		{name: "max attempts", errs: []error{errSerialization, errSerialization, errSerialization, errSerialization}, expectedCalls: 3, expectedCode: http.StatusInternalServerError},
+		{name: "error with status is not retried", errs: []error{tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("not found"))}, expectedCalls: 1, expectedCode: http.StatusNotFound},
+		{
+			name:          "retry if",
+			errs:          []error{errSerialization, errors.New("other")},
+			opts:          []tanukirpc.RetryOption{tanukirpc.WithRetryIf(func(err error) bool { return errors.Is(err, errSerialization) })},
+			expectedCalls: 2,
+			expectedCode:  http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			h := func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+				calls++
+				if calls <= len(tc.errs) {
+					return "", tc.errs[calls-1]
+				}
+				return "ok", nil
+			}
+			router := tanukirpc.NewRouter(struct{}{})
+			router.Get("/", tanukirpc.NewHandler(tanukirpc.WithRetry(h, append([]tanukirpc.RetryOption{noBackoff}, tc.opts...)...)))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
@@ -4,33 +4,50 @@ import (
 	gocontext "context"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/mackee/tanukirpc/internal/requestid"
 )
 
-var defaultMiddleware = []func(http.Handler) http.Handler{
-	requestid.Middleware,
-	middleware.RealIP,
-	middleware.Recoverer,
-}
-
 type Router[Reg any] struct {
-	cr                chi.Router
-	codec             Codec
-	contextFactory    ContextFactory[Reg]
-	logger            *slog.Logger
-	errorHooker       ErrorHooker
-	accessLogger      AccessLogger
-	defaultMiddleware []func(http.Handler) http.Handler
+	cr                 chi.Router
+	codec              Codec
+	contextFactory     ContextFactory[Reg]
+	logger             *slog.Logger
+	errorHooker        ErrorHooker
+	accessLogger       AccessLogger
+	accessLogFilters   []AccessLogFilter
+	defaultMiddleware  []namedMiddleware
+	validateResponse   bool
+	responseBodyHooks  []ResponseBodyHook
+	auditBodyLimit     int
+	accessLogPayloads  *accessLogPayloads
+	errorLocalizer     ErrorLocalizer
+	htmlErrorRenderer  HTMLErrorRenderer
+	maintenance        *maintenance
+	featureFlags       featureFlags
+	realIP             *realIP
+	maxRequestTimeout  time.Duration
+	concurrencyLimiter *concurrencyLimiter
+	policyEngine       PolicyEngine
+	authorizationRules []AuthorizationRule[Reg]
+	clientClosedHook   ClientClosedHook
+	deferErrorHook     DeferErrorHook
+	deferJoinErrors    bool
+	codecMiddlewares   []CodecMiddleware
+	responseEnvelope   EnvelopeFunc
+	registrations      *routeRegistrations
+	routePrefix        string
+	dynamic            *dynamicRoutes
+	plugins            *routerPlugins
 }
 
 // NewRouter creates a new Router.
 //
 // The registry is used to create a context.
 func NewRouter[Reg any](reg Reg, opts ...RouterOption[Reg]) *Router[Reg] {
+	ri := &realIP{}
 	router := &Router[Reg]{
 		cr:                chi.NewRouter(),
 		codec:             DefaultCodecList,
@@ -38,10 +55,20 @@ func NewRouter[Reg any](reg Reg, opts ...RouterOption[Reg]) *Router[Reg] {
 		errorHooker:       &errorHooker{},
 		logger:            NewLogger(slog.Default(), defaultLoggerKeys),
 		accessLogger:      &accessLogger{},
-		defaultMiddleware: defaultMiddleware,
+		defaultMiddleware: newDefaultMiddleware(ri),
+		maintenance:       newMaintenance(),
+		realIP:            ri,
+		registrations:     newRouteRegistrations(),
+		plugins:           newRouterPlugins(),
 	}
 	router.apply(opts...)
-	router.Use(router.defaultMiddleware...)
+	router.dynamic = newDynamicRoutes(router.cr)
+	router.Use(router.defaultMiddlewares()...)
+	router.Use(router.maintenanceMiddleware)
+	router.Use(router.deadlineMiddleware)
+	router.Use(func(next http.Handler) http.Handler {
+		return router.concurrencyLimitMiddleware(router.concurrencyLimiter, next)
+	})
 
 	return router
 }
@@ -59,12 +86,32 @@ func (r *Router[Reg]) Use(middlewares ...func(http.Handler) http.Handler) {
 
 func (r *Router[Reg]) clone() *Router[Reg] {
 	return &Router[Reg]{
-		cr:             r.cr,
-		codec:          r.codec,
-		contextFactory: r.contextFactory,
-		errorHooker:    r.errorHooker,
-		logger:         r.logger,
-		accessLogger:   r.accessLogger,
+		cr:                 r.cr,
+		codec:              r.codec,
+		contextFactory:     r.contextFactory,
+		errorHooker:        r.errorHooker,
+		logger:             r.logger,
+		accessLogger:       r.accessLogger,
+		accessLogFilters:   r.accessLogFilters,
+		validateResponse:   r.validateResponse,
+		responseBodyHooks:  r.responseBodyHooks,
+		auditBodyLimit:     r.auditBodyLimit,
+		accessLogPayloads:  r.accessLogPayloads,
+		errorLocalizer:     r.errorLocalizer,
+		htmlErrorRenderer:  r.htmlErrorRenderer,
+		maintenance:        r.maintenance,
+		featureFlags:       r.featureFlags,
+		policyEngine:       r.policyEngine,
+		authorizationRules: r.authorizationRules,
+		clientClosedHook:   r.clientClosedHook,
+		deferErrorHook:     r.deferErrorHook,
+		deferJoinErrors:    r.deferJoinErrors,
+		codecMiddlewares:   r.codecMiddlewares,
+		responseEnvelope:   r.responseEnvelope,
+		registrations:      r.registrations,
+		routePrefix:        r.routePrefix,
+		dynamic:            r.dynamic,
+		plugins:            r.plugins,
 	}
 }
 
@@ -79,49 +126,58 @@ func (r *Router[Reg]) With(middlewares ...func(http.Handler) http.Handler) *Rout
 }
 
 func (r *Router[Reg]) Route(pattern string, fn func(r *Router[Reg])) *Router[Reg] {
-	return r.cloneWithChiRouter(r.cr.Route(pattern, func(cr chi.Router) {
-		fn(r.cloneWithChiRouter(cr))
+	prefix := strings.TrimSuffix(r.routePrefix+pattern, "/")
+	sub := r.cloneWithChiRouter(r.cr.Route(pattern, func(cr chi.Router) {
+		r2 := r.cloneWithChiRouter(cr)
+		r2.routePrefix = prefix
+		fn(r2)
 	}))
+	sub.routePrefix = prefix
+	return sub
 }
 
+// Mount mounts the http.Handler at pattern. The mounted Router is validated by Validate of this Router as well.
 func (r *Router[Reg]) Mount(pattern string, h http.Handler) {
+	if v, ok := h.(routeValidator); ok {
+		r.registrations.mount(r.routePrefix+pattern, v)
+	}
 	r.cr.Mount(pattern, h)
 }
 
 func (r *Router[Reg]) Connect(pattern string, h Handler[Reg]) {
-	r.cr.Connect(pattern, h.build(r))
+	r.handle(http.MethodConnect, pattern, h)
 }
 
 func (r *Router[Reg]) Delete(pattern string, h Handler[Reg]) {
-	r.cr.Delete(pattern, h.build(r))
+	r.handle(http.MethodDelete, pattern, h)
 }
 
 func (r *Router[Reg]) Get(pattern string, h Handler[Reg]) {
-	r.cr.Get(pattern, h.build(r))
+	r.handle(http.MethodGet, pattern, h)
 }
 
 func (r *Router[Reg]) Head(pattern string, h Handler[Reg]) {
-	r.cr.Head(pattern, h.build(r))
+	r.handle(http.MethodHead, pattern, h)
 }
 
 func (r *Router[Reg]) Options(pattern string, h Handler[Reg]) {
-	r.cr.Options(pattern, h.build(r))
+	r.handle(http.MethodOptions, pattern, h)
 }
 
 func (r *Router[Reg]) Patch(pattern string, h Handler[Reg]) {
-	r.cr.Patch(pattern, h.build(r))
+	r.handle(http.MethodPatch, pattern, h)
 }
 
 func (r *Router[Reg]) Post(pattern string, h Handler[Reg]) {
-	r.cr.Post(pattern, h.build(r))
+	r.handle(http.MethodPost, pattern, h)
 }
 
 func (r *Router[Reg]) Put(pattern string, h Handler[Reg]) {
-	r.cr.Put(pattern, h.build(r))
+	r.handle(http.MethodPut, pattern, h)
 }
 
 func (r *Router[Reg]) Trace(pattern string, h Handler[Reg]) {
-	r.cr.Trace(pattern, h.build(r))
+	r.handle(http.MethodTrace, pattern, h)
 }
 
 func (r *Router[Reg]) NotFound(h Handler[Reg]) {
@@ -133,6 +189,10 @@ func (r *Router[Reg]) MethodNotAllowed(h Handler[Reg]) {
 }
 
 func (r *Router[Reg]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h := r.dynamic.handler(req); h != nil {
+		h.ServeHTTP(w, req)
+		return
+	}
 	r.cr.ServeHTTP(w, req)
 }
 
@@ -140,12 +200,31 @@ func RouteWithTransformer[Reg1 any, Reg2 any](r *Router[Reg1], tr Transformer[Re
 	return r.Route(pattern, func(r *Router[Reg1]) {
 		cf := compositionContextHooker(r.contextFactory, tr)
 		r2 := &Router[Reg2]{
-			cr:             r.cr,
-			codec:          r.codec,
-			contextFactory: cf,
-			errorHooker:    r.errorHooker,
-			logger:         r.logger,
-			accessLogger:   r.accessLogger,
+			cr:                r.cr,
+			codec:             r.codec,
+			contextFactory:    cf,
+			errorHooker:       r.errorHooker,
+			logger:            r.logger,
+			accessLogger:      r.accessLogger,
+			accessLogFilters:  r.accessLogFilters,
+			validateResponse:  r.validateResponse,
+			responseBodyHooks: r.responseBodyHooks,
+			auditBodyLimit:    r.auditBodyLimit,
+			accessLogPayloads: r.accessLogPayloads,
+			errorLocalizer:    r.errorLocalizer,
+			htmlErrorRenderer: r.htmlErrorRenderer,
+			maintenance:       r.maintenance,
+			featureFlags:      r.featureFlags,
+			policyEngine:      r.policyEngine,
+			clientClosedHook:  r.clientClosedHook,
+			deferErrorHook:    r.deferErrorHook,
+			deferJoinErrors:   r.deferJoinErrors,
+			codecMiddlewares:  r.codecMiddlewares,
+			responseEnvelope:  r.responseEnvelope,
+			registrations:     r.registrations,
+			routePrefix:       r.routePrefix,
+			dynamic:           r.dynamic,
+			plugins:           r.plugins,
 		}
 		fn(r2)
 	})
@@ -155,6 +234,12 @@ func (r *Router[Reg]) accessLoggerLog(ctx gocontext.Context, w WrapResponseWrite
 	if r.accessLogger == nil {
 		return nil
 	}
+	if isClientClosed(req, err) {
+		w = &clientClosedResponseWriter{WrapResponseWriter: w}
+	}
+	if !r.shouldAccessLog(req, w.Status()) {
+		return nil
+	}
 	return r.accessLogger.Log(ctx, r.logger, w, req, err, t1, t2)
 }
 
@@ -188,6 +273,14 @@ func WithErrorHooker[Reg any](eh ErrorHooker) RouterOption[Reg] {
 	}
 }
 
+// WithErrorHookerV2 sets the ErrorHookerV2 that receives the Context, the matched route pattern and the stage of the error.
+func WithErrorHookerV2[Reg any](eh ErrorHookerV2) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.errorHooker = &errorHookerV2Adapter{ErrorHookerV2: eh}
+		return r
+	}
+}
+
 func WithLogger[Reg any](logger *slog.Logger) RouterOption[Reg] {
 	return func(r *Router[Reg]) *Router[Reg] {
 		r.logger = logger
@@ -202,9 +295,41 @@ func WithAccessLogger[Reg any](al AccessLogger) RouterOption[Reg] {
 	}
 }
 
-func WithDefaultMiddleware[Reg any](middlewares ...func(http.Handler) http.Handler) RouterOption[Reg] {
+// WithAccessLogFilter adds filters to decide whether the access log is written.
+// The access log is written only when all filters return true.
+// AccessLogSampling and AccessLogExcludePaths are the helpers to keep the access logging affordable in high-traffic services.
+func WithAccessLogFilter[Reg any](filters ...AccessLogFilter) RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.accessLogFilters = append(r.accessLogFilters, filters...)
+		return r
+	}
+}
+
+// WithResponseValidation enables the validation of the response by the validate struct tags or the Validatable interface before encoding.
+// This is useful to detect the handlers that return a response that does not satisfy the contract in development and testing.
+// The invalid response is reported as an internal server error.
+func WithResponseValidation[Reg any]() RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.validateResponse = true
+		return r
+	}
+}
+
+// WithStrictJSON replaces the JSONCodec in the codec of the Router with the strict one.
+// The strict JSONCodec rejects unknown fields and mismatched types with 400 Bad Request.
+func WithStrictJSON[Reg any]() RouterOption[Reg] {
+	return func(r *Router[Reg]) *Router[Reg] {
+		r.codec = replaceCodec(r.codec, NewJSONCodec(WithJSONDisallowUnknownFields(), WithJSONUseNumber()))
+		return r
+	}
+}
+
+// WithResponseBodyHook adds hooks that are called with the encoded response body before it is written.
+// The response body is buffered, so the Content-Length header is set to the response.
+// The response that is io.Reader is not buffered and the hooks are not called.
+func WithResponseBodyHook[Reg any](hooks ...ResponseBodyHook) RouterOption[Reg] {
 	return func(r *Router[Reg]) *Router[Reg] {
-		r.defaultMiddleware = middlewares
+		r.responseBodyHooks = append(r.responseBodyHooks, hooks...)
 		return r
 	}
 }
@@ -1,14 +1,21 @@
 package tanukirpc_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/mackee/tanukirpc"
@@ -101,6 +108,78 @@ func TestRouter(t *testing.T) {
 			request: errorRedirectHandlerRequest(t),
 			expect:  errorRedirectHandlerExpect,
 		},
+		{
+			name:    "response validation handler",
+			router:  responseValidationHandler(),
+			request: responseValidationHandlerRequest(t),
+			expect:  responseValidationHandlerExpect,
+		},
+		{
+			name:    "strict json handler with unknown field",
+			router:  strictJSONHandler(),
+			request: strictJSONHandlerRequest(t, `{"name":"tanuki","age":3}`),
+			expect:  strictJSONHandlerExpectBadRequest("age"),
+		},
+		{
+			name:    "strict json handler with mismatched type",
+			router:  strictJSONHandler(),
+			request: strictJSONHandlerRequest(t, `{"name":3}`),
+			expect:  strictJSONHandlerExpectBadRequest("name"),
+		},
+		{
+			name:    "json codec options handler",
+			router:  jsonCodecOptionsHandler(),
+			request: jsonCodecOptionsHandlerRequest(t),
+			expect:  jsonCodecOptionsHandlerExpect,
+		},
+		{
+			name:    "response body hook handler",
+			router:  responseBodyHookHandler(),
+			request: newHelloHandlerRequest(t),
+			expect:  responseBodyHookHandlerExpect,
+		},
+		{
+			name:    "raw body limit handler within limit",
+			router:  rawBodyLimitHandler(),
+			request: rawBodyLimitHandlerRequest(t, "text/plain; charset=utf-8", "world"),
+			expect:  rawBodyCodecHandlerExpect,
+		},
+		{
+			name:    "raw body limit handler exceeds limit",
+			router:  rawBodyLimitHandler(),
+			request: rawBodyLimitHandlerRequest(t, "text/plain", "tanuki and kitsune"),
+			expect:  rawBodyLimitHandlerExpectStatus(http.StatusRequestEntityTooLarge),
+		},
+		{
+			name:    "raw body limit handler with unsupported content type",
+			router:  rawBodyLimitHandler(),
+			request: rawBodyLimitHandlerRequest(t, "image/png", "world"),
+			expect:  rawBodyLimitHandlerExpectStatus(http.StatusUnsupportedMediaType),
+		},
+		{
+			name:    "raw response with content type handler",
+			router:  rawResponseContentTypeHandler(),
+			request: rawResponseContentTypeHandlerRequest(t),
+			expect:  rawResponseContentTypeHandlerExpect,
+		},
+		{
+			name:    "error hooker v2 handler at handler stage",
+			router:  errorHookerV2Handler(),
+			request: errorHookerV2HandlerRequest(t, "/tasks/42"),
+			expect:  errorHookerV2HandlerExpect("/tasks/{id}", "handler", "true"),
+		},
+		{
+			name:    "error hooker v2 handler at decode stage",
+			router:  errorHookerV2Handler(),
+			request: errorHookerV2HandlerRequest(t, "/tasks/tanuki"),
+			expect:  errorHookerV2HandlerExpect("/tasks/{id}", "decode", "true"),
+		},
+		{
+			name:    "error hooker v2 handler at validate stage",
+			router:  errorHookerV2Handler(),
+			request: errorHookerV2HandlerRequest(t, "/tasks/0"),
+			expect:  errorHookerV2HandlerExpect("/tasks/{id}", "validate", "true"),
+		},
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -345,3 +424,513 @@ func errorRedirectHandlerExpect(t *testing.T, resp *http.Response, err error) {
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "https://example.com", resp.Header.Get("Location"))
 }
+
+func responseValidationHandler() http.Handler {
+	type validatedResponse struct {
+		Name string `json:"name" validate:"required"`
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req struct{}) (*validatedResponse, error) {
+		return &validatedResponse{}, nil
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithResponseValidation[struct{}]())
+	router.Get("/invalid", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func responseValidationHandlerRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "/invalid", nil)
+	require.NoError(t, err)
+	req.Header.Set("accept", "application/json")
+	return req
+}
+
+func responseValidationHandlerExpect(t *testing.T, resp *http.Response, err error) {
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	var em tanukirpc.ErrorMessage
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&em))
+	assert.Contains(t, em.Error.Message, "invalid response")
+}
+
+func strictJSONHandler() http.Handler {
+	type strictRequest struct {
+		Name string `json:"name"`
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req strictRequest) (struct{}, error) {
+		return struct{}{}, nil
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithStrictJSON[struct{}]())
+	router.Post("/strict", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func strictJSONHandlerRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "/strict", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("accept", "application/json")
+	return req
+}
+
+func strictJSONHandlerExpectBadRequest(field string) func(t *testing.T, resp *http.Response, err error) {
+	return func(t *testing.T, resp *http.Response, err error) {
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var em tanukirpc.ErrorMessage
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&em))
+		assert.Contains(t, em.Error.Message, `"`+field+`"`)
+	}
+}
+
+func jsonCodecOptionsHandler() http.Handler {
+	type listResponse struct {
+		Items []string `json:"items"`
+		Note  string   `json:"note"`
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req struct{}) (*listResponse, error) {
+		return &listResponse{Note: "<tanuki>"}, nil
+	}
+	codec := tanukirpc.NewJSONCodec(
+		tanukirpc.WithJSONNilSliceAsEmpty(),
+		tanukirpc.WithJSONEscapeHTML(false),
+	)
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithCodec[struct{}](tanukirpc.CodecList{codec}))
+	router.Get("/list", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func jsonCodecOptionsHandlerRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "/list", nil)
+	require.NoError(t, err)
+	req.Header.Set("accept", "application/json")
+	return req
+}
+
+func jsonCodecOptionsHandlerExpect(t *testing.T, resp *http.Response, err error) {
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[],"note":"<tanuki>"}`, string(body))
+	assert.Contains(t, string(body), "<tanuki>")
+}
+
+func responseBodyHookHandler() http.Handler {
+	type helloRequest struct {
+		Name string `urlparam:"name"`
+	}
+	type helloResponse struct {
+		Message string `json:"message"`
+	}
+	helloHandler := func(ctx tanukirpc.Context[struct{}], req helloRequest) (*helloResponse, error) {
+		return &helloResponse{Message: "Hello, " + req.Name}, nil
+	}
+	etagHook := tanukirpc.ResponseBodyHookFunc(func(w http.ResponseWriter, req *http.Request, body *bytes.Buffer) error {
+		sum := sha256.Sum256(body.Bytes())
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+		return nil
+	})
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithResponseBodyHook[struct{}](etagHook))
+	router.Get("/hello/{name}", tanukirpc.NewHandler(helloHandler))
+
+	return router
+}
+
+func responseBodyHookHandlerExpect(t *testing.T, resp *http.Response, err error) {
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	sum := sha256.Sum256(body)
+	assert.Equal(t, `"`+hex.EncodeToString(sum[:8])+`"`, resp.Header.Get("ETag"))
+	assert.Equal(t, int64(len(body)), resp.ContentLength)
+}
+
+func rawBodyLimitHandler() http.Handler {
+	h := func(ctx tanukirpc.Context[struct{}], req io.ReadCloser) ([]byte, error) {
+		body, err := io.ReadAll(req)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte("hello, "), body...), nil
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.With(tanukirpc.RawBodyLimit(10, "text/plain")).Post("/raw", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func rawBodyLimitHandlerRequest(t *testing.T, contentType string, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "/raw", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("content-type", contentType)
+	req.ContentLength = -1
+	return req
+}
+
+func rawBodyLimitHandlerExpectStatus(status int) func(t *testing.T, resp *http.Response, err error) {
+	return func(t *testing.T, resp *http.Response, err error) {
+		require.NoError(t, err)
+
+		assert.Equal(t, status, resp.StatusCode)
+	}
+}
+
+func rawResponseContentTypeHandler() http.Handler {
+	h := func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.RawResponse, error) {
+		return tanukirpc.WithContentType(strings.NewReader("tanuki,kitsune\n"), "text/csv"), nil
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/animals.csv", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func rawResponseContentTypeHandlerRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "/animals.csv", nil)
+	require.NoError(t, err)
+	req.Header.Set("accept", "*/*")
+	return req
+}
+
+func rawResponseContentTypeHandlerExpect(t *testing.T, resp *http.Response, err error) {
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(t, int64(len("tanuki,kitsune\n")), resp.ContentLength)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "tanuki,kitsune\n", string(body))
+}
+
+type testErrorHookerV2 struct{}
+
+func (e *testErrorHookerV2) OnErrorV2(w http.ResponseWriter, req *http.Request, logger *slog.Logger, codec tanukirpc.Codec, info *tanukirpc.ErrorInfo, err error) {
+	_, hasContext := info.Context.(tanukirpc.Context[struct{}])
+	w.Header().Set("X-Route-Pattern", info.RoutePattern)
+	w.Header().Set("X-Error-Stage", info.Stage.String())
+	w.Header().Set("X-Has-Context", strconv.FormatBool(hasContext))
+	w.WriteHeader(http.StatusTeapot)
+}
+
+func errorHookerV2Handler() http.Handler {
+	type taskRequest struct {
+		ID int `urlparam:"id" validate:"min=1"`
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req taskRequest) (struct{}, error) {
+		return struct{}{}, errors.New("task not found")
+	}
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithErrorHookerV2[struct{}](&testErrorHookerV2{}))
+	router.Get("/tasks/{id}", tanukirpc.NewHandler(h))
+
+	return router
+}
+
+func errorHookerV2HandlerRequest(t *testing.T, path string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func errorHookerV2HandlerExpect(pattern, stage, hasContext string) func(t *testing.T, resp *http.Response, err error) {
+	return func(t *testing.T, resp *http.Response, err error) {
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+		assert.Equal(t, pattern, resp.Header.Get("X-Route-Pattern"))
+		assert.Equal(t, stage, resp.Header.Get("X-Error-Stage"))
+		assert.Equal(t, hasContext, resp.Header.Get("X-Has-Context"))
+	}
+}
+
+func TestRouterSetMaintenance(t *testing.T) {
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithMaintenanceRetryAfter[struct{}](2*time.Minute))
+	ok := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+	router.Get("/healthz", ok)
+	router.Route("/api", func(r *tanukirpc.Router[struct{}]) {
+		r.Get("/tasks", ok)
+	})
+	router.Route("/admin", func(r *tanukirpc.Router[struct{}]) {
+		r.Get("/stats", ok)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.False(t, router.InMaintenance())
+	assert.Equal(t, http.StatusOK, get("/api/tasks").Code)
+
+	router.SetMaintenance(true, "/healthz", "/admin/*")
+	assert.True(t, router.InMaintenance())
+	rec := get("/api/tasks")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "120", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":{"message":"service is under maintenance"}}`, rec.Body.String())
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+	assert.Equal(t, http.StatusOK, get("/admin/stats").Code)
+
+	router.SetMaintenance(false)
+	assert.Equal(t, http.StatusOK, get("/api/tasks").Code)
+}
+
+func TestRouterWithFeatureFlag(t *testing.T) {
+	flags := map[string]bool{"search": true}
+	provider := tanukirpc.FlagProviderFunc(func(ctx context.Context, name string) (bool, error) {
+		if name == "beta" {
+			// the beta features are enabled for the testers
+			return ctx.(tanukirpc.Context[struct{}]).Request().Header.Get("X-Tester") == "1", nil
+		}
+		return flags[name], nil
+	})
+	ok := tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+
+	get := func(router http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithFlagProvider[struct{}](provider))
+	router.Get("/tasks", ok)
+	router.WithFeatureFlag("search").Get("/search", ok)
+	router.WithFeatureFlag("search", "beta").Get("/search/beta", ok)
+	router.WithFeatureFlag("export").Route("/export", func(r *tanukirpc.Router[struct{}]) {
+		r.Get("/csv", ok)
+	})
+
+	assert.Equal(t, http.StatusOK, get(router, "/tasks", nil).Code)
+	assert.Equal(t, http.StatusOK, get(router, "/search", nil).Code)
+	assert.Equal(t, http.StatusOK, get(router, "/search/beta", map[string]string{"X-Tester": "1"}).Code)
+	rec := get(router, "/search/beta", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":{"message":"not found"}}`, rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, get(router, "/export/csv", nil).Code)
+	flags["export"] = true
+	assert.Equal(t, http.StatusOK, get(router, "/export/csv", nil).Code)
+
+	forbidden := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithFeatureDisabledStatus[struct{}](http.StatusForbidden))
+	forbidden.WithFeatureFlag("search").Get("/search", ok)
+	assert.Equal(t, http.StatusForbidden, get(forbidden, "/search", nil).Code)
+
+	t.Run("disabled before decode", func(t *testing.T) {
+		router.WithFeatureFlag("import").Post("/import", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+			Name string `json:"name" validate:"required"`
+		}) (struct{}, error) {
+			return struct{}{}, nil
+		}))
+		for _, body := range []string{`{"name":`, `{}`} {
+			req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusNotFound, rec.Code, body)
+			assert.JSONEq(t, `{"error":{"message":"not found"}}`, rec.Body.String(), body)
+		}
+	})
+}
+
+func TestDefaultMiddlewareOrdering(t *testing.T) {
+	type remoteResponse struct {
+		Remote string   `json:"remote"`
+		Seen   []string `json:"seen"`
+	}
+	mark := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("X-Seen", name+":"+req.RemoteAddr)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	do := func(t *testing.T, opts ...tanukirpc.RouterOption[struct{}]) (*remoteResponse, []string) {
+		t.Helper()
+		router := tanukirpc.NewRouter(struct{}{}, opts...)
+		router.Get("/remote", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*remoteResponse, error) {
+			return &remoteResponse{Remote: ctx.Request().RemoteAddr}, nil
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/remote", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Real-IP", "203.0.113.1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res remoteResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return &res, rec.Header().Values("X-Seen")
+	}
+
+	t.Run("default", func(t *testing.T) {
+		res, _ := do(t)
+		assert.Equal(t, "203.0.113.1", res.Remote)
+	})
+	t.Run("without", func(t *testing.T) {
+		res, _ := do(t, tanukirpc.WithoutDefaultMiddleware[struct{}](tanukirpc.MiddlewareRealIP))
+		assert.Equal(t, "192.0.2.1:1234", res.Remote)
+	})
+	t.Run("before and after", func(t *testing.T) {
+		res, seen := do(t,
+			tanukirpc.WithMiddlewareBefore[struct{}](tanukirpc.MiddlewareRealIP, mark("before")),
+			tanukirpc.WithMiddlewareAfter[struct{}](tanukirpc.MiddlewareRealIP, mark("after")),
+		)
+		assert.Equal(t, "203.0.113.1", res.Remote)
+		assert.Equal(t, []string{"before:192.0.2.1:1234", "after:203.0.113.1"}, seen)
+	})
+	t.Run("not found", func(t *testing.T) {
+		assert.PanicsWithValue(t, `tanukirpc: default middleware "realip" is not found`, func() {
+			tanukirpc.NewRouter(struct{}{},
+				tanukirpc.WithoutDefaultMiddleware[struct{}](tanukirpc.MiddlewareRealIP),
+				tanukirpc.WithMiddlewareAfter[struct{}](tanukirpc.MiddlewareRealIP, mark("after")),
+			)
+		})
+	})
+}
+
+type urlParamBase struct {
+	OrgID string `urlparam:"org_id"`
+}
+
+type URLParamPage struct {
+	Page *int `urlparam:"page"`
+}
+
+func TestURLParamNestedAndPointer(t *testing.T) {
+	type project struct {
+		ID int `urlparam:"id"`
+	}
+	type request struct {
+		urlParamBase
+		*URLParamPage
+		Project project
+		Tag     *string `urlparam:"tag"`
+		Name    string  `json:"name"`
+	}
+	type response struct {
+		OrgID     string  `json:"org_id"`
+		ProjectID int     `json:"project_id"`
+		Page      *int    `json:"page"`
+		Tag       *string `json:"tag"`
+	}
+	h := func(ctx tanukirpc.Context[struct{}], req *request) (*response, error) {
+		return &response{OrgID: req.OrgID, ProjectID: req.Project.ID, Page: req.Page, Tag: req.Tag}, nil
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/orgs/{org_id}/projects/{id}", tanukirpc.NewHandler(h))
+	router.Get("/orgs/{org_id}/projects/{id}/pages/{page}/tags/{tag}", tanukirpc.NewHandler(h))
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/orgs/acme/projects/42", expected: `{"org_id":"acme","project_id":42,"page":null,"tag":null}`},
+		{path: "/orgs/acme/projects/42/pages/3/tags/go", expected: `{"org_id":"acme","project_id":42,"page":3,"tag":"go"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestURLParamWildcard(t *testing.T) {
+	type fileRequest struct {
+		Path string `urlparam:"*"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/files/*", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req fileRequest) (string, error) {
+		return req.Path, nil
+	}))
+
+	for path, expected := range map[string]string{
+		"/files/docs/readme.md": `"docs/readme.md"`,
+		"/files/":               `""`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.JSONEq(t, expected, rec.Body.String(), path)
+	}
+}
+
+func TestRouterResource(t *testing.T) {
+	type taskRequest struct {
+		ID *string `urlparam:"task_id"`
+	}
+	handler := func(action string) tanukirpc.Handler[struct{}] {
+		return tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req taskRequest) (string, error) {
+			if req.ID == nil {
+				return action + ":", nil
+			}
+			return action + ":" + *req.ID, nil
+		})
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Resource("/tasks", tanukirpc.Resource[struct{}]{
+		Index:   handler("index"),
+		Create:  handler("create"),
+		Show:    handler("show"),
+		Destroy: handler("destroy"),
+		IDParam: "task_id",
+	})
+
+	for _, tc := range []struct {
+		method   string
+		path     string
+		status   int
+		expected string
+	}{
+		{http.MethodGet, "/tasks", http.StatusOK, `"index:"`},
+		{http.MethodPost, "/tasks", http.StatusOK, `"create:"`},
+		{http.MethodGet, "/tasks/1", http.StatusOK, `"show:1"`},
+		{http.MethodDelete, "/tasks/1", http.StatusOK, `"destroy:1"`},
+		{http.MethodPut, "/tasks/1", http.StatusMethodNotAllowed, ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, tc.status, rec.Code, "%s %s: %s", tc.method, tc.path, rec.Body.String())
+		if tc.expected != "" {
+			assert.JSONEq(t, tc.expected, rec.Body.String(), "%s %s", tc.method, tc.path)
+		}
+	}
+
+	assert.Panics(t, func() {
+		router.Resource("/empty", tanukirpc.Resource[struct{}]{})
+	})
+}
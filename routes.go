@@ -0,0 +1,80 @@
+package tanukirpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteInfo is the information of the route that is registered to the Router.
+type RouteInfo struct {
+	// Method is the HTTP method of the route.
+	Method string
+	// Pattern is the full pattern of the route.
+	Pattern string
+	// Metadata is the metadata of the handler. It is nil for the http.Handler that is mounted by Mount and the handler created by NewProxyHandler.
+	Metadata *HandlerMetadata
+	// Middlewares are the middlewares that are applied to the route.
+	Middlewares []func(http.Handler) http.Handler
+}
+
+// routeHandler is the http.Handler built from the Handler that keeps the metadata for the introspection.
+type routeHandler struct {
+	http.HandlerFunc
+	meta *HandlerMetadata
+}
+
+// handle registers the handler and records the route for Validate. The nil handlers are not registered, and Validate reports them.
+func (r *Router[Reg]) handle(method string, pattern string, h Handler[Reg]) {
+	rr := &registeredRoute{method: method, pattern: r.routePrefix + pattern, codec: r.codec}
+	if isNilHandler(h) {
+		rr.err = errors.New("handler is nil")
+		r.registrations.add(rr)
+		return
+	}
+	rr.meta = h.Metadata()
+	r.registrations.add(rr)
+	r.cr.Method(method, pattern, &routeHandler{HandlerFunc: h.build(r), meta: rr.meta})
+}
+
+// Routes returns the routes that are registered to the Router. The root Router includes the routes that are registered by Register.
+// The patterns of the routes are relative to the Router, so use it with the root Router to get the full patterns.
+func (r *Router[Reg]) Routes() ([]*RouteInfo, error) {
+	routes := make([]*RouteInfo, 0)
+	walkFn := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		ri := &RouteInfo{
+			Method:      method,
+			Pattern:     route,
+			Middlewares: middlewares,
+		}
+		if rh, ok := handler.(*routeHandler); ok {
+			ri.Metadata = rh.meta
+		}
+		routes = append(routes, ri)
+		return nil
+	}
+	if err := chi.Walk(r.cr, walkFn); err != nil {
+		return nil, fmt.Errorf("failed to walk routes: %w", err)
+	}
+	if mux := r.dynamic.current(); mux != nil && r.cr == r.dynamic.root {
+		if err := chi.Walk(mux, walkFn); err != nil {
+			return nil, fmt.Errorf("failed to walk dynamic routes: %w", err)
+		}
+	}
+	slices.SortStableFunc(routes, func(a, b *RouteInfo) int {
+		if c := strings.Compare(a.Pattern, b.Pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return routes, nil
+}
+
+// Codec returns the codec of the Router.
+func (r *Router[Reg]) Codec() Codec {
+	return r.codec
+}
@@ -0,0 +1,109 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ScheduledFunc is a function that is called periodically by the Scheduler.
+type ScheduledFunc[Reg any] func(ctx gocontext.Context, reg Reg) error
+
+type scheduledTask[Reg any] struct {
+	interval time.Duration
+	fn       ScheduledFunc[Reg]
+}
+
+// Scheduler runs periodic tasks with the same registry as the Router.
+// It is started and stopped together with the server by using the WithScheduler option of ListenAndServe.
+type Scheduler[Reg any] struct {
+	registry Reg
+	logger   *slog.Logger
+	tasks    []*scheduledTask[Reg]
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler[Reg any](reg Reg) *Scheduler[Reg] {
+	return &Scheduler[Reg]{
+		registry: reg,
+	}
+}
+
+// Every registers fn to be called at every interval.
+// The interval is a string that can be parsed by time.ParseDuration like a "5m".
+func (s *Scheduler[Reg]) Every(interval string, fn ScheduledFunc[Reg]) *Scheduler[Reg] {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		panic(fmt.Sprintf("tanukirpc: invalid scheduler interval %q: %v", interval, err))
+	}
+	if d <= 0 {
+		panic(fmt.Sprintf("tanukirpc: scheduler interval must be positive: %q", interval))
+	}
+	s.tasks = append(s.tasks, &scheduledTask[Reg]{interval: d, fn: fn})
+	return s
+}
+
+// Run runs the registered tasks until the context is canceled.
+// It waits for the running tasks to finish before returning.
+func (s *Scheduler[Reg]) Run(ctx gocontext.Context) error {
+	logger := s.logger
+	if logger == nil {
+		logger = NewLogger(slog.Default(), defaultLoggerKeys)
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runTask(ctx, logger, task)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *Scheduler[Reg]) runTask(ctx gocontext.Context, logger *slog.Logger, task *scheduledTask[Reg]) {
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.doTask(ctx, task); err != nil {
+				logger.ErrorContext(ctx, "scheduled task error", slog.String("interval", task.interval.String()), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func (s *Scheduler[Reg]) doTask(ctx gocontext.Context, task *scheduledTask[Reg]) (err error) {
+	defer func() {
+		if rv := recover(); rv != nil {
+			err = fmt.Errorf("panic in scheduled task: %v", rv)
+		}
+	}()
+	return task.fn(ctx, s.registry)
+}
+
+func (s *Scheduler[Reg]) runWithLogger(ctx gocontext.Context, logger *slog.Logger) error {
+	if s.logger == nil {
+		s.logger = logger
+	}
+	return s.Run(ctx)
+}
+
+type backgroundRunner interface {
+	runWithLogger(ctx gocontext.Context, logger *slog.Logger) error
+}
+
+// WithScheduler runs the scheduler while the server is running.
+// The scheduler uses the logger of the Router, and it is stopped when the server is shutting down.
+func WithScheduler[Reg any](s *Scheduler[Reg]) ListenAndServeOption {
+	return func(o *listenAndServeConfig) {
+		o.backgroundRunners = append(o.backgroundRunners, s)
+	}
+}
@@ -0,0 +1,35 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	type registry struct {
+		count atomic.Int32
+	}
+	reg := &registry{}
+	s := tanukirpc.NewScheduler(reg).Every("10ms", func(ctx context.Context, reg *registry) error {
+		reg.count.Add(1)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, s.Run(ctx))
+	assert.GreaterOrEqual(t, reg.count.Load(), int32(3))
+}
+
+func TestSchedulerInvalidInterval(t *testing.T) {
+	assert.Panics(t, func() {
+		tanukirpc.NewScheduler(struct{}{}).Every("five minutes", func(ctx context.Context, _ struct{}) error {
+			return nil
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package tanukirpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// ErrStreamAborted is the error of the streaming response of the sequence that fails after the first item is written.
+// The status has been sent, so the connection is aborted to tell the client that the response is truncated.
+var ErrStreamAborted = errors.New("streaming response is aborted")
+
+// seqFlushInterval is the interval to flush the items of the streaming response to the client.
+const seqFlushInterval = 100 * time.Millisecond
+
+var boolType = reflect.TypeFor[bool]()
+
+// seqKind returns the number of the values of the sequence: 1 for iter.Seq[T], 2 for iter.Seq2[T, error], and 0 if t is not the sequence.
+// The sequences are detected by the shape of the func types, so they do not need to be iter.Seq.
+func seqKind(t reflect.Type) int {
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return 0
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0) != boolType {
+		return 0
+	}
+	switch {
+	case yield.NumIn() == 1:
+		return 1
+	case yield.NumIn() == 2 && yield.In(1) == errorType:
+		return 2
+	}
+	return 0
+}
+
+func isSeqResponse(v any) bool {
+	return v != nil && seqKind(reflect.TypeOf(v)) != 0
+}
+
+// encodeSeq writes the items of the sequence as the JSON array like [item,item,...] while iterating it.
+// The items are written one by one, so the iteration waits for the client to receive them when the connection is congested,
+// and stops when the client disconnects. The error of iter.Seq2 before the first item is returned as it is,
+// and the error after it is returned with ErrStreamAborted.
+func (c *codec) encodeSeq(w http.ResponseWriter, v any) error {
+	seq := reflect.ValueOf(v)
+	if seq.IsNil() {
+		w.Header().Set("content-type", c.responseContentType)
+		_, err := w.Write([]byte("[]\n"))
+		return err
+	}
+	enc := c.encoderFunc(w)
+	rc := http.NewResponseController(w)
+	started := false
+	lastFlush := time.Now()
+	var serr error
+	write := func(item reflect.Value) bool {
+		if !started {
+			w.Header().Set("content-type", c.responseContentType)
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte("[")); err != nil {
+				serr = err
+				return false
+			}
+			started = true
+		} else if _, err := w.Write([]byte(",")); err != nil {
+			serr = err
+			return false
+		}
+		if err := enc.Encode(item.Interface()); err != nil {
+			serr = &ErrCodecEncode{err: err}
+			return false
+		}
+		if time.Since(lastFlush) >= seqFlushInterval {
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				serr = err
+				return false
+			}
+			lastFlush = time.Now()
+		}
+		return true
+	}
+
+	var yield reflect.Value
+	if seqKind(seq.Type()) == 1 {
+		yield = reflect.MakeFunc(seq.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.ValueOf(write(args[0]))}
+		})
+	} else {
+		yield = reflect.MakeFunc(seq.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			if err, _ := args[1].Interface().(error); err != nil {
+				serr = err
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			return []reflect.Value{reflect.ValueOf(write(args[0]))}
+		})
+	}
+	seq.Call([]reflect.Value{yield})
+
+	if serr != nil {
+		if !started {
+			return &seqError{err: serr}
+		}
+		return fmt.Errorf("%w: %w", ErrStreamAborted, serr)
+	}
+	if !started {
+		w.Header().Set("content-type", c.responseContentType)
+		_, err := w.Write([]byte("[]\n"))
+		return err
+	}
+	if _, err := w.Write([]byte("]\n")); err != nil {
+		return fmt.Errorf("%w: %w", ErrStreamAborted, err)
+	}
+	return nil
+}
+
+// seqError is the error of the sequence before the first item is written. It is responded as the error of the handler.
+type seqError struct {
+	err error
+}
+
+func (e *seqError) Error() string {
+	return e.err.Error()
+}
+
+func (e *seqError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,82 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeqResponse(t *testing.T) {
+	type task struct {
+		ID int `json:"id"`
+	}
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/tasks", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (func(yield func(*task) bool), error) {
+		return func(yield func(*task) bool) {
+			for i := 1; i <= 3; i++ {
+				if !yield(&task{ID: i}) {
+					return
+				}
+			}
+		}, nil
+	}))
+	router.Get("/empty", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (func(yield func(*task) bool), error) {
+		return func(yield func(*task) bool) {}, nil
+	}))
+	router.Get("/failed/{at}", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct {
+		At int `urlparam:"at"`
+	}) (func(yield func(*task, error) bool), error) {
+		return func(yield func(*task, error) bool) {
+			for i := 1; i <= 3; i++ {
+				if i == req.At {
+					yield(nil, tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("cursor is closed")))
+					return
+				}
+				if !yield(&task{ID: i}, nil) {
+					return
+				}
+			}
+		}, nil
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(path string) (*http.Response, string, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/json")
+		res, err := server.Client().Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		return res, string(body), err
+	}
+
+	res, body, err := get("/tasks")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":1},{"id":2},{"id":3}]`, body)
+
+	res, body, err = get("/empty")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `[]`, body)
+
+	res, body, err = get("/failed/1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "the error before the first item is responded with the status")
+	assert.JSONEq(t, `{"error":{"message":"cursor is closed"}}`, body)
+
+	_, body, err = get("/failed/3")
+	assert.Error(t, err, "the connection is aborted after the first item")
+	assert.NotContains(t, body, "cursor is closed")
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -15,6 +16,11 @@ type listenAndServeConfig struct {
 	disableTanukiupProxy bool
 	shutdownTimeout      time.Duration
 	noSetDefaultLogger   bool
+	backgroundRunners    []backgroundRunner
+	debugEndpoints       *debugEndpoints
+	serverConfigurers    []func(*http.Server)
+	tlsCertFile          string
+	tlsKeyFile           string
 }
 
 type ListenAndServeOption func(*listenAndServeConfig)
@@ -37,19 +43,87 @@ func WithNoSetDefaultLogger() ListenAndServeOption {
 	}
 }
 
+// WithReadTimeout sets http.Server.ReadTimeout.
+func WithReadTimeout(d time.Duration) ListenAndServeOption {
+	return WithServerConfigurer(func(s *http.Server) {
+		s.ReadTimeout = d
+	})
+}
+
+// WithReadHeaderTimeout sets http.Server.ReadHeaderTimeout.
+func WithReadHeaderTimeout(d time.Duration) ListenAndServeOption {
+	return WithServerConfigurer(func(s *http.Server) {
+		s.ReadHeaderTimeout = d
+	})
+}
+
+// WithWriteTimeout sets http.Server.WriteTimeout.
+func WithWriteTimeout(d time.Duration) ListenAndServeOption {
+	return WithServerConfigurer(func(s *http.Server) {
+		s.WriteTimeout = d
+	})
+}
+
+// WithIdleTimeout sets http.Server.IdleTimeout.
+func WithIdleTimeout(d time.Duration) ListenAndServeOption {
+	return WithServerConfigurer(func(s *http.Server) {
+		s.IdleTimeout = d
+	})
+}
+
+// WithMaxHeaderBytes sets http.Server.MaxHeaderBytes.
+func WithMaxHeaderBytes(n int) ListenAndServeOption {
+	return WithServerConfigurer(func(s *http.Server) {
+		s.MaxHeaderBytes = n
+	})
+}
+
+// WithServerConfigurer configures the http.Server before it starts, for example to set TLSConfig or ErrorLog.
+// The configurers are called in the order of the options, after Addr and Handler are set.
+func WithServerConfigurer(configure func(*http.Server)) ListenAndServeOption {
+	return func(o *listenAndServeConfig) {
+		o.serverConfigurers = append(o.serverConfigurers, configure)
+	}
+}
+
+// WithTLS serves HTTPS with the certificate and the key files. The TLSConfig of the server set by WithServerConfigurer is used as well.
+// The socket passed by tanukiup is served without TLS, because tanukiup terminates TLS.
+func WithTLS(certFile, keyFile string) ListenAndServeOption {
+	return func(o *listenAndServeConfig) {
+		o.tlsCertFile = certFile
+		o.tlsKeyFile = keyFile
+	}
+}
+
 // ListenAndServe starts the server.
+// The server listens on the socket passed by tanukiup, the sockets passed by the socket activation of systemd (LISTEN_FDS),
+// or addr in this order.
 // If the context is canceled, the server will be shutdown.
+// The routes are checked by Validate before the server starts, and it returns the error of Validate if the routes are invalid.
+// The plugins attached by Attach are shut down after the server is shut down.
 func (r *Router[Reg]) ListenAndServe(ctx gocontext.Context, addr string, opts ...ListenAndServeOption) error {
+	if err := r.Validate(); err != nil {
+		return fmt.Errorf("invalid routes: %w", err)
+	}
 	cfg := &listenAndServeConfig{}
 	for _, o := range opts {
 		o(cfg)
 	}
 
+	var handler http.Handler = r
+	if cfg.debugEndpoints != nil {
+		handler = cfg.debugEndpoints.wrap(handler)
+	}
 	server := &http.Server{
 		Addr:    addr,
-		Handler: r,
+		Handler: handler,
 	}
+	for _, configure := range cfg.serverConfigurers {
+		configure(server)
+	}
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
 		rctx, cancel := gocontext.WithTimeout(gocontext.Background(), 5*time.Second)
 		defer cancel()
@@ -58,37 +132,106 @@ func (r *Router[Reg]) ListenAndServe(ctx gocontext.Context, addr string, opts ..
 		if err := server.Shutdown(rctx); err != nil {
 			slog.ErrorContext(ctx, "failed to shutdown server", slog.Any("error", err))
 		}
+		if err := r.ShutdownPlugins(rctx); err != nil {
+			slog.ErrorContext(ctx, "failed to shutdown plugins", slog.Any("error", err))
+		}
 	}()
+	// the plugins are shut down after the requests in flight finish
+	defer func() {
+		if ctx.Err() != nil {
+			<-shutdownDone
+		}
+	}()
+
+	var runnersWg sync.WaitGroup
+	defer runnersWg.Wait()
+	runnerCtx, stopRunners := gocontext.WithCancel(ctx)
+	defer stopRunners()
+	for _, runner := range cfg.backgroundRunners {
+		runnersWg.Add(1)
+		go func() {
+			defer runnersWg.Done()
+			if err := runner.runWithLogger(runnerCtx, r.logger); err != nil {
+				r.logger.ErrorContext(runnerCtx, "background runner error", slog.Any("error", err))
+			}
+		}()
+	}
 	var uds net.Listener
 	if !cfg.disableTanukiupProxy {
-		_uds, err := r.tanukiupUnixListener()
+		_uds, err := r.tanukiupListener()
 		if err != nil && !errors.Is(err, errTanukiupUDSNotFound) {
-			return fmt.Errorf("failed to listen tanukiup unix domain socket: %w", err)
+			return fmt.Errorf("failed to listen for tanukiup: %w", err)
 		}
 		uds = _uds
 	}
-
-	if uds == nil {
-		slog.InfoContext(ctx, "Server is starting...", slog.String("addr", addr))
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("failed to listen and serve: %w", err)
-		}
-	} else {
-		slog.InfoContext(ctx, "Server is starting with unix domain socket...")
+	if uds != nil {
+		slog.InfoContext(ctx, "Server is starting with tanukiup...", slog.String("addr", uds.Addr().String()))
 		if err := server.Serve(uds); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			return fmt.Errorf("failed to serve: %w", err)
 		}
+		return nil
+	}
+
+	listeners, err := socketActivationListeners()
+	if err != nil {
+		return fmt.Errorf("failed to get the sockets of the socket activation: %w", err)
+	}
+	serve := server.Serve
+	listenAndServe := server.ListenAndServe
+	if cfg.tlsCertFile != "" || cfg.tlsKeyFile != "" {
+		serve = func(l net.Listener) error {
+			return server.ServeTLS(l, cfg.tlsCertFile, cfg.tlsKeyFile)
+		}
+		listenAndServe = func() error {
+			return server.ListenAndServeTLS(cfg.tlsCertFile, cfg.tlsKeyFile)
+		}
+	}
+	if len(listeners) > 0 {
+		return serveListeners(ctx, server, listeners, serve)
+	}
+
+	slog.InfoContext(ctx, "Server is starting...", slog.String("addr", addr))
+	if err := listenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to listen and serve: %w", err)
 	}
 	return nil
 }
 
+// serveListeners serves on all the listeners by serve. If one of them fails, the server is closed.
+func serveListeners(ctx gocontext.Context, server *http.Server, listeners []net.Listener, serve func(net.Listener) error) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		slog.InfoContext(ctx, "Server is starting with socket activation...", slog.String("addr", l.Addr().String()))
+		go func() {
+			errs <- serve(l)
+		}()
+	}
+	var serveErr error
+	for range listeners {
+		if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) && serveErr == nil {
+			serveErr = fmt.Errorf("failed to serve: %w", err)
+			server.Close()
+		}
+	}
+	return serveErr
+}
+
 var errTanukiupUDSNotFound = errors.New("tanukiup unix domain socket not found")
 
 const (
 	tanukiupUDSPathEnv = "TANUKIUP_UDS_PATH"
+	// tanukiupAddrEnv is the loopback address that is passed by tanukiup instead of the unix domain socket, for example on Windows.
+	tanukiupAddrEnv = "TANUKIUP_ADDR"
 )
 
-func (r *Router[Reg]) tanukiupUnixListener() (net.Listener, error) {
+func (r *Router[Reg]) tanukiupListener() (net.Listener, error) {
+	if addr, ok := os.LookupEnv(tanukiupAddrEnv); ok {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen tanukiup address: %w", err)
+		}
+		return l, nil
+	}
 	p, ok := os.LookupEnv(tanukiupUDSPathEnv)
 	if !ok {
 		return nil, errTanukiupUDSNotFound
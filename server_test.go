@@ -0,0 +1,204 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAndServeWithDebugEndpoints(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	router := tanukirpc.NewRouter(struct{}{})
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer tanuki" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- router.ListenAndServe(ctx, addr,
+			tanukirpc.WithDisableTanukiupProxy(),
+			tanukirpc.WithDebugEndpoints("/_debug", auth),
+		)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	get := func(path string, authorized bool) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+path, nil)
+		require.NoError(t, err)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer tanuki")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Eventually(t, func() bool {
+		return get("/_debug/vars", true) == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, get("/_debug/pprof/", true))
+	assert.Equal(t, http.StatusUnauthorized, get("/_debug/pprof/", false))
+	assert.Equal(t, http.StatusNotFound, get("/pprof/", true))
+}
+
+func TestListenAndServeWithTanukiupAddr(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	t.Setenv("TANUKIUP_ADDR", addr)
+
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		return "pong", nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		// the address of tanukiup is used instead of addr
+		done <- router.ListenAndServe(ctx, "127.0.0.1:1")
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestListenAndServeWithServerConfigurer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+		return "pong", nil
+	}))
+
+	configured := make(chan *http.Server, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- router.ListenAndServe(ctx, addr,
+			tanukirpc.WithDisableTanukiupProxy(),
+			tanukirpc.WithReadTimeout(3*time.Second),
+			tanukirpc.WithWriteTimeout(4*time.Second),
+			tanukirpc.WithIdleTimeout(5*time.Second),
+			tanukirpc.WithMaxHeaderBytes(1<<10),
+			tanukirpc.WithServerConfigurer(func(s *http.Server) {
+				configured <- s
+			}),
+		)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	server := <-configured
+	assert.Equal(t, addr, server.Addr)
+	assert.Equal(t, 3*time.Second, server.ReadTimeout)
+	assert.Equal(t, 4*time.Second, server.WriteTimeout)
+	assert.Equal(t, 5*time.Second, server.IdleTimeout)
+	assert.Equal(t, 1<<10, server.MaxHeaderBytes)
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the request headers over MaxHeaderBytes are rejected
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/ping", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Large", strings.Repeat("a", 64<<10))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
+func TestListenAndServeWithSocketActivation(t *testing.T) {
+	if os.Getenv("TANUKIRPC_TEST_SOCKET_ACTIVATION") != "" {
+		router := tanukirpc.NewRouter(struct{}{})
+		router.Get("/ping", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (string, error) {
+			return "pong", nil
+		}))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		// addr is not used with the socket activation
+		if err := router.ListenAndServe(ctx, "127.0.0.1:1"); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("socket activation is not supported on windows")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	// LISTEN_PID is the pid of the process after exec like systemd
+	cmd := exec.Command("sh", "-c", `LISTEN_PID=$$ exec "$0" -test.run=^TestListenAndServeWithSocketActivation$`, os.Args[0])
+	cmd.Env = append(os.Environ(), "TANUKIRPC_TEST_SOCKET_ACTIVATION=1", "LISTEN_FDS=1", "LISTEN_FDNAMES=http")
+	cmd.ExtraFiles = []*os.File{f}
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+}
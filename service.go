@@ -0,0 +1,81 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// RegisterService registers the methods of svc as the POST routes named after the service and the methods,
+// like "/TaskService/AddTask". The methods must have the signature of HandlerFunc, func(Context[Reg], Req) (Res, error),
+// and the other methods are ignored.
+//
+// The name of the service is the name of Svc, so pass the interface type explicitly to name the routes after the interface:
+//
+//	tanukirpc.RegisterService[TaskService](r, &taskService{})
+//
+// The routes are handled like the handlers created by NewHandler, so the codecs, the validation and genclient work as well.
+func RegisterService[Svc any, Reg any](r *Router[Reg], svc Svc) error {
+	st := reflect.TypeFor[Svc]()
+	name := indirectType(st).Name()
+	if name == "" {
+		return fmt.Errorf("failed to register service: the service type %s has no name", st)
+	}
+	sv := reflect.ValueOf(&svc).Elem()
+	if sv.Kind() == reflect.Interface && sv.IsNil() {
+		return fmt.Errorf("failed to register service: %s is nil", name)
+	}
+	registered := 0
+	for i := 0; i < sv.NumMethod(); i++ {
+		m := st.Method(i)
+		if !m.IsExported() {
+			continue
+		}
+		fn := sv.Method(i)
+		if !isServiceMethod[Reg](fn.Type()) {
+			continue
+		}
+		r.handle(http.MethodPost, "/"+name+"/"+m.Name, newServiceHandler[Reg](fn))
+		registered++
+	}
+	if registered == 0 {
+		return fmt.Errorf("failed to register service: %s has no methods of func(Context, Req) (Res, error)", name)
+	}
+	return nil
+}
+
+var errorType = reflect.TypeFor[error]()
+
+func isServiceMethod[Reg any](t reflect.Type) bool {
+	return t.NumIn() == 2 && t.In(0) == reflect.TypeFor[Context[Reg]]() &&
+		t.NumOut() == 2 && t.Out(1) == errorType
+}
+
+// serviceHandler is the Handler that calls the method of the service by reflection.
+type serviceHandler[Reg any] struct {
+	fn   reflect.Value
+	meta *HandlerMetadata
+}
+
+func newServiceHandler[Reg any](fn reflect.Value) *serviceHandler[Reg] {
+	return &serviceHandler[Reg]{
+		fn:   fn,
+		meta: newHandlerMetadata(fn.Type().In(1), fn.Type().Out(0)),
+	}
+}
+
+func (h *serviceHandler[Reg]) Metadata() *HandlerMetadata {
+	return h.meta
+}
+
+func (h *serviceHandler[Reg]) build(r *Router[Reg]) http.HandlerFunc {
+	return buildHandlerFunc(r, h.meta, handlerCall[Reg]{
+		newRequest: func() any { return reflect.New(h.meta.Request).Interface() },
+		request:    func(p any) any { return reflect.ValueOf(p).Elem().Interface() },
+		call: func(ctx Context[Reg], p any) (any, error) {
+			out := h.fn.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem(), reflect.ValueOf(p).Elem()})
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type addTaskRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type addTaskResponse struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type TaskService interface {
+	AddTask(ctx tanukirpc.Context[struct{}], req *addTaskRequest) (*addTaskResponse, error)
+	FailTask(ctx tanukirpc.Context[struct{}], req struct{}) (*struct{}, error)
+}
+
+type taskService struct {
+	nextID int
+}
+
+func (s *taskService) AddTask(ctx tanukirpc.Context[struct{}], req *addTaskRequest) (*addTaskResponse, error) {
+	s.nextID++
+	return &addTaskResponse{ID: s.nextID, Title: req.Title}, nil
+}
+
+func (s *taskService) FailTask(ctx tanukirpc.Context[struct{}], req struct{}) (*struct{}, error) {
+	return nil, tanukirpc.WrapErrorWithStatus(http.StatusConflict, errors.New("conflict"))
+}
+
+// Helper is not a service method, so it is not registered.
+func (s *taskService) Helper() string {
+	return "helper"
+}
+
+func TestRegisterService(t *testing.T) {
+	r := tanukirpc.NewRouter(struct{}{})
+	require.NoError(t, tanukirpc.RegisterService[TaskService](r, &taskService{}))
+
+	routes, err := r.Routes()
+	require.NoError(t, err)
+	patterns := make([]string, 0, len(routes))
+	for _, route := range routes {
+		patterns = append(patterns, route.Method+" "+route.Pattern)
+	}
+	assert.ElementsMatch(t, []string{"POST /TaskService/AddTask", "POST /TaskService/FailTask"}, patterns)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	post := func(path, body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	status, body := post("/TaskService/AddTask", `{"title":"buy milk"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":1,"title":"buy milk"}`, body)
+
+	status, _ = post("/TaskService/AddTask", `{}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/TaskService/FailTask", `{}`)
+	assert.Equal(t, http.StatusConflict, status)
+}
+
+func TestRegisterServiceError(t *testing.T) {
+	r := tanukirpc.NewRouter(struct{}{})
+	var svc TaskService
+	assert.Error(t, tanukirpc.RegisterService(r, svc))
+	assert.Error(t, tanukirpc.RegisterService(r, struct{ Name string }{}))
+	assert.Error(t, tanukirpc.RegisterService(r, &struct{}{}))
+}
@@ -0,0 +1,130 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// singleflights is the expvar of the stats of the Singleflights by the names.
+var singleflights = expvar.NewMap("tanukirpc.singleflights")
+
+// Singleflight coalesces the concurrent calls of the same key into one execution per process,
+// like the hot GET endpoints that read the same row of the database. Create it once with the registry, and share it between the requests:
+//
+//	type registry struct {
+//		tasks *tanukirpc.Singleflight[int, *Task]
+//	}
+//
+//	reg := &registry{tasks: tanukirpc.NewSingleflight[int, *Task]("tasks")}
+//
+//	func getTask(ctx tanukirpc.Context[*registry], req *getTaskRequest) (*Task, error) {
+//		db := ctx.Registry().db
+//		return ctx.Registry().tasks.Do(ctx, req.ID, func(ctx context.Context) (*Task, error) {
+//			return db.GetTask(ctx, req.ID)
+//		})
+//	}
+//
+// The value is shared by the coalesced callers, so it must not be modified by them.
+// The stats are published as the expvar "tanukirpc.singleflights", so they are served by the debug endpoint of WithDebugEndpoints.
+type Singleflight[K comparable, V any] struct {
+	name      string
+	mu        sync.Mutex
+	calls     map[K]*singleflightCall[V]
+	total     atomic.Int64
+	coalesced atomic.Int64
+}
+
+type singleflightCall[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// NewSingleflight returns the Singleflight of the name, and publishes the stats of it to the expvar "tanukirpc.singleflights".
+func NewSingleflight[K comparable, V any](name string) *Singleflight[K, V] {
+	s := &Singleflight[K, V]{
+		name:  name,
+		calls: make(map[K]*singleflightCall[V]),
+	}
+	singleflights.Set(name, expvar.Func(func() any {
+		return s.Stats()
+	}))
+	return s
+}
+
+// SingleflightStats is the stats of the Singleflight that is published to the expvar.
+type SingleflightStats struct {
+	// Calls is the number of the calls of Do.
+	Calls int64 `json:"calls"`
+	// Coalesced is the number of the calls of Do that shared the execution of the other call.
+	Coalesced int64 `json:"coalesced"`
+	// CoalesceRate is the ratio of Coalesced to Calls.
+	CoalesceRate float64 `json:"coalesce_rate"`
+}
+
+// Name returns the name of the Singleflight.
+func (s *Singleflight[K, V]) Name() string {
+	return s.name
+}
+
+// Stats returns the stats of the Singleflight.
+func (s *Singleflight[K, V]) Stats() SingleflightStats {
+	stats := SingleflightStats{
+		Calls:     s.total.Load(),
+		Coalesced: s.coalesced.Load(),
+	}
+	if stats.Calls > 0 {
+		stats.CoalesceRate = float64(stats.Coalesced) / float64(stats.Calls)
+	}
+	return stats
+}
+
+// Do calls fn and returns the result, unless the call of the same key is in flight, in which case it waits for the call and returns the same result.
+// fn is called with the context without the cancellation of ctx, so the cancellation of the caller does not fail the other callers.
+// The caller whose ctx is done returns the error of ctx without waiting. The panic of fn is returned as the error to the callers.
+func (s *Singleflight[K, V]) Do(ctx gocontext.Context, key K, fn func(ctx gocontext.Context) (V, error)) (V, error) {
+	s.total.Add(1)
+	s.mu.Lock()
+	c, ok := s.calls[key]
+	if ok {
+		s.coalesced.Add(1)
+	} else {
+		c = &singleflightCall[V]{done: make(chan struct{})}
+		s.calls[key] = c
+		go s.call(gocontext.WithoutCancel(ctx), key, c, fn)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (s *Singleflight[K, V]) call(ctx gocontext.Context, key K, c *singleflightCall[V], fn func(ctx gocontext.Context) (V, error)) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			c.err = fmt.Errorf("panic in singleflight %s: %v", s.name, rec)
+		}
+		s.mu.Lock()
+		if s.calls[key] == c {
+			delete(s.calls, key)
+		}
+		s.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn(ctx)
+}
+
+// Forget makes the next call of the key execute fn instead of waiting for the call in flight, like after the row is updated.
+func (s *Singleflight[K, V]) Forget(key K) {
+	s.mu.Lock()
+	delete(s.calls, key)
+	s.mu.Unlock()
+}
@@ -0,0 +1,90 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleflight(t *testing.T) {
+	t.Run("coalesce", func(t *testing.T) {
+		sf := tanukirpc.NewSingleflight[int, string]("test_coalesce")
+		release := make(chan struct{})
+		var executions int
+		fn := func(ctx context.Context) (string, error) {
+			executions++
+			<-release
+			return "task", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, 3)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := sf.Do(context.Background(), 1, fn)
+				assert.NoError(t, err)
+				results[i] = v
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			return sf.Stats().Calls == 3
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, []string{"task", "task", "task"}, results)
+		assert.Equal(t, 1, executions)
+		assert.Equal(t, tanukirpc.SingleflightStats{Calls: 3, Coalesced: 2, CoalesceRate: 2.0 / 3.0}, sf.Stats())
+
+		// the key is not in flight after the call
+		v, err := sf.Do(context.Background(), 1, func(ctx context.Context) (string, error) {
+			return "updated", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "updated", v)
+	})
+
+	t.Run("cancel the caller", func(t *testing.T) {
+		sf := tanukirpc.NewSingleflight[int, string]("test_cancel")
+		release := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := sf.Do(ctx, 1, func(ctx context.Context) (string, error) {
+			<-release
+			return "task", ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// the other caller shares the execution that is not canceled
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			v, err := sf.Do(context.Background(), 1, func(ctx context.Context) (string, error) {
+				return "", errors.New("not coalesced")
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "task", v)
+		}()
+		assert.Eventually(t, func() bool {
+			return sf.Stats().Coalesced == 1
+		}, time.Second, time.Millisecond)
+		close(release)
+		<-done
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		sf := tanukirpc.NewSingleflight[string, int]("test_panic")
+		_, err := sf.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+			panic("boom")
+		})
+		assert.ErrorContains(t, err, "boom")
+	})
+}
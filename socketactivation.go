@@ -0,0 +1,55 @@
+package tanukirpc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	listenPIDEnv     = "LISTEN_PID"
+	listenFDsEnv     = "LISTEN_FDS"
+	listenFDNamesEnv = "LISTEN_FDNAMES"
+	// listenFDsStart is SD_LISTEN_FDS_START of sd_listen_fds(3).
+	listenFDsStart = 3
+)
+
+// socketActivationListeners returns the listeners passed by the socket activation of systemd.
+// It returns nil if the process is not activated by the socket. The environment variables are unset
+// like sd_listen_fds(3), so the child processes don't take the sockets.
+func socketActivationListeners() ([]net.Listener, error) {
+	pid, ok := os.LookupEnv(listenPIDEnv)
+	if !ok || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv(listenFDsEnv))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv(listenFDNamesEnv), ":")
+	os.Unsetenv(listenPIDEnv)
+	os.Unsetenv(listenFDsEnv)
+	os.Unsetenv(listenFDNamesEnv)
+
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFDsStart+i), name)
+		l, err := net.FileListener(f)
+		// the listener has the duplicated file descriptor
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to listen the socket of %s: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
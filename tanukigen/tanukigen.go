@@ -0,0 +1,216 @@
+package tanukigen
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mackee/tanukirpc/genclient"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigPath is the path of the configuration file that is used when it is not specified.
+const DefaultConfigPath = "tanukigen.yaml"
+
+// Kind is the kind of the generated artifact.
+type Kind string
+
+const (
+	KindTypeScript Kind = "typescript"
+	KindOpenAPI    Kind = "openapi"
+	KindGoClient   Kind = "goclient"
+	KindRoutes     Kind = "routes"
+	KindKotlin     Kind = "kotlin"
+	KindSwift      Kind = "swift"
+	KindMarkdown   Kind = "markdown"
+	KindHTML       Kind = "html"
+	// KindGraphQL and KindGraphQLResolvers are experimental.
+	KindGraphQL          Kind = "graphql"
+	KindGraphQLResolvers Kind = "graphql_resolvers"
+)
+
+// Config is the configuration of tanukigen.yaml like the following:
+//
+//	dir: ./server
+//	outputs:
+//	  - kind: typescript
+//	    out: ./frontend/src/client.ts
+//	    emit_zod: true
+//	  - kind: openapi
+//	    out: ./openapi.json
+//	    title: Task API
+//	  - kind: goclient
+//	    out: ./client/client.go
+//	    package: client
+//	  - kind: routes
+//	    out: ./routes.json
+//	  - kind: kotlin
+//	    out: ./android/ApiClient.kt
+//	    package: com.example.api
+//	  - kind: swift
+//	    out: ./ios/ApiClient.swift
+//	  - kind: html
+//	    out: ./docs/index.html
+//	    title: Task API
+//	  - kind: graphql
+//	    out: ./graphql/schema.graphql
+//	  - kind: graphql_resolvers
+//	    out: ./graphql/resolvers.go
+//	    package: graphql
+//
+// The relative paths are resolved from the directory of the configuration file.
+type Config struct {
+	// Dir is the directory of the package that calls genclient.AnalyzeTarget. The default is the directory of the configuration file.
+	Dir     string   `yaml:"dir"`
+	Outputs []Output `yaml:"outputs"`
+}
+
+// Output is the artifact that is generated from the analysis result.
+type Output struct {
+	Kind Kind   `yaml:"kind"`
+	Out  string `yaml:"out"`
+
+	// EmitZod, ClientFactory, MarshalerWhitelist and Duration are the options of the typescript kind.
+	// They correspond to the flags of gentypescript. MarshalerWhitelist and Duration are also used by the other kinds except goclient and routes.
+	EmitZod            bool   `yaml:"emit_zod"`
+	ClientFactory      bool   `yaml:"client_factory"`
+	MarshalerWhitelist string `yaml:"marshaler_whitelist"`
+	Duration           string `yaml:"duration"`
+
+	// Title is the option of the openapi, markdown and html kinds. Version is the option of the openapi kind.
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+
+	// Package is the package name of the goclient, kotlin and graphql_resolvers kinds.
+	Package string `yaml:"package"`
+}
+
+// LoadConfig reads the configuration file and resolves the relative paths.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w filepath=%s", err, path)
+	}
+	if len(cfg.Outputs) == 0 {
+		return nil, fmt.Errorf("no outputs are configured: filepath=%s", path)
+	}
+
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	cfg.Dir = resolve(cfg.Dir)
+	if cfg.Dir == "" {
+		cfg.Dir = base
+	}
+	for i := range cfg.Outputs {
+		o := &cfg.Outputs[i]
+		if o.Out == "" {
+			return nil, fmt.Errorf("out is required: outputs[%d] of %s", i, path)
+		}
+		o.Out = resolve(o.Out)
+		o.MarshalerWhitelist = resolve(o.MarshalerWhitelist)
+	}
+	return &cfg, nil
+}
+
+// Run analyzes the package once and writes all the outputs.
+func Run(ctx context.Context, cfg *Config) error {
+	result, err := genclient.Load(ctx, cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", cfg.Dir, err)
+	}
+	for _, d := range result.Diagnostics {
+		slog.WarnContext(ctx, d.String())
+	}
+
+	for _, o := range cfg.Outputs {
+		b, err := generate(result, &o)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", o.Kind, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(o.Out), 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := os.WriteFile(o.Out, b, 0o644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		slog.InfoContext(ctx, "generated", slog.String("kind", string(o.Kind)), slog.String("out", o.Out))
+	}
+	return nil
+}
+
+func generate(result *genclient.AnalyzerResult, o *Output) ([]byte, error) {
+	var marshalers map[string]string
+	if o.MarshalerWhitelist != "" {
+		m, err := genclient.LoadMarshalerWhitelist(o.MarshalerWhitelist)
+		if err != nil {
+			return nil, err
+		}
+		marshalers = m
+	}
+
+	switch o.Kind {
+	case KindTypeScript:
+		return genclient.GenerateTypeScript(result, &genclient.TypeScriptOptions{
+			EmitZod:       o.EmitZod,
+			ClientFactory: o.ClientFactory,
+			Marshalers:    marshalers,
+			DurationType:  o.Duration,
+		})
+	case KindOpenAPI:
+		return genclient.GenerateOpenAPI(result, &genclient.OpenAPIOptions{
+			Title:        o.Title,
+			Version:      o.Version,
+			Marshalers:   marshalers,
+			DurationType: o.Duration,
+		})
+	case KindGoClient:
+		return genclient.GenerateGoClient(result, &genclient.GoClientOptions{
+			Package: o.Package,
+		})
+	case KindRoutes:
+		return genclient.GenerateRoutesJSON(result)
+	case KindKotlin:
+		return genclient.GenerateKotlin(result, &genclient.KotlinOptions{
+			Package:      o.Package,
+			Marshalers:   marshalers,
+			DurationType: o.Duration,
+		})
+	case KindSwift:
+		return genclient.GenerateSwift(result, &genclient.SwiftOptions{
+			Marshalers:   marshalers,
+			DurationType: o.Duration,
+		})
+	case KindGraphQL, KindGraphQLResolvers:
+		opts := &genclient.GraphQLOptions{
+			Package:      o.Package,
+			Marshalers:   marshalers,
+			DurationType: o.Duration,
+		}
+		if o.Kind == KindGraphQLResolvers {
+			return genclient.GenerateGraphQLResolvers(result, opts)
+		}
+		return genclient.GenerateGraphQLSchema(result, opts)
+	case KindMarkdown, KindHTML:
+		opts := &genclient.DocsOptions{
+			Title:        o.Title,
+			Marshalers:   marshalers,
+			DurationType: o.Duration,
+		}
+		if o.Kind == KindHTML {
+			return genclient.GenerateHTML(result, opts)
+		}
+		return genclient.GenerateMarkdown(result, opts)
+	}
+	return nil, fmt.Errorf("unknown kind: %q", o.Kind)
+}
@@ -0,0 +1,34 @@
+package tanukitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable to update the golden files instead of comparing.
+const UpdateGoldenEnv = "TANUKIRPC_UPDATE_GOLDEN"
+
+// AssertGolden compares actual with the content of the golden file.
+// If the environment variable TANUKIRPC_UPDATE_GOLDEN is set, it writes actual to the golden file instead.
+func AssertGolden(t testing.TB, path string, actual string) {
+	t.Helper()
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("tanukitest: failed to create the directory of the golden file: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatalf("tanukitest: failed to write the golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("tanukitest: failed to read the golden file: %v. run the test with %s=1 to create it", err, UpdateGoldenEnv)
+	}
+	if string(expected) != actual {
+		t.Errorf("tanukitest: mismatched with the golden file %s. run the test with %s=1 to update it\n--- expected\n%s\n--- actual\n%s", path, UpdateGoldenEnv, expected, actual)
+	}
+}
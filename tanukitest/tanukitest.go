@@ -0,0 +1,184 @@
+// Package tanukitest provides the utilities for testing the handlers of tanukirpc.
+package tanukitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+)
+
+type callConfig struct {
+	header   http.Header
+	cookies  []*http.Cookie
+	formBody bool
+}
+
+// Option is an option for Call.
+type Option func(*callConfig)
+
+// WithHeader sets the header to the request.
+func WithHeader(key, value string) Option {
+	return func(c *callConfig) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithCookie adds the cookie to the request.
+func WithCookie(cookie *http.Cookie) Option {
+	return func(c *callConfig) {
+		c.cookies = append(c.cookies, cookie)
+	}
+}
+
+// WithFormBody encodes the request body as application/x-www-form-urlencoded by the form struct tags instead of JSON.
+func WithFormBody() Option {
+	return func(c *callConfig) {
+		c.formBody = true
+	}
+}
+
+// ResponseError is returned by Call when the handler responds with the status code that is not 2xx.
+type ResponseError struct {
+	StatusCode int
+	Header     http.Header
+	Message    tanukirpc.ErrorMessage
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message.Error.Message)
+}
+
+// Status returns the status code of the response. It implements tanukirpc.ErrorWithStatus.
+func (e *ResponseError) Status() int {
+	return e.StatusCode
+}
+
+// Call calls the handler with req and decodes the response to Res.
+// route is the method and the pattern of the route like a "POST /api/tasks/{id}".
+// The path parameters in the pattern are filled by the fields with the urlparam struct tags,
+// the fields with the query struct tags are sent as the query string, and the request is sent as the JSON body except for GET and HEAD.
+// If the handler responds with the status code that is not 2xx, it returns *ResponseError that has the decoded tanukirpc.ErrorMessage.
+func Call[Req any, Res any](t testing.TB, h http.Handler, route string, req Req, opts ...Option) (Res, error) {
+	t.Helper()
+
+	cfg := &callConfig{header: http.Header{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		t.Fatalf("tanukitest: invalid route %q. it must be like a \"GET /path\"", route)
+	}
+	rv := reflect.Indirect(reflect.ValueOf(req))
+
+	path, err := fillPathParams(pattern, rv)
+	if err != nil {
+		t.Fatalf("tanukitest: %v", err)
+	}
+	if query := taggedValues(rv, "query").Encode(); query != "" {
+		path += "?" + query
+	}
+
+	var body io.Reader
+	contentType := ""
+	if method != http.MethodGet && method != http.MethodHead {
+		if cfg.formBody {
+			body = strings.NewReader(taggedValues(rv, "form").Encode())
+			contentType = "application/x-www-form-urlencoded"
+		} else {
+			b, err := json.Marshal(req)
+			if err != nil {
+				t.Fatalf("tanukitest: failed to encode the request: %v", err)
+			}
+			body = bytes.NewReader(b)
+			contentType = "application/json"
+		}
+	}
+
+	hreq := httptest.NewRequest(method, path, body)
+	hreq.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		hreq.Header.Set("Content-Type", contentType)
+	}
+	for key, values := range cfg.header {
+		hreq.Header[key] = values
+	}
+	for _, cookie := range cfg.cookies {
+		hreq.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, hreq)
+
+	var res Res
+	if rec.Code < 200 || rec.Code >= 300 {
+		re := &ResponseError{StatusCode: rec.Code, Header: rec.Header()}
+		if rec.Body.Len() > 0 {
+			if err := json.Unmarshal(rec.Body.Bytes(), &re.Message); err != nil {
+				t.Fatalf("tanukitest: failed to decode the error response: %v, body=%s", err, rec.Body.String())
+			}
+		}
+		return res, re
+	}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("tanukitest: failed to decode the response: %v, body=%s", err, rec.Body.String())
+		}
+	}
+	return res, nil
+}
+
+func fillPathParams(pattern string, rv reflect.Value) (string, error) {
+	params := taggedValues(rv, "urlparam")
+	var sb strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			sb.WriteString(pattern)
+			break
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid pattern %q", pattern)
+		}
+		end += start
+		name, _, _ := strings.Cut(pattern[start+1:end], ":")
+		if !params.Has(name) {
+			return "", fmt.Errorf("url param %q is not found in the request", name)
+		}
+		sb.WriteString(pattern[:start])
+		sb.WriteString(url.PathEscape(params.Get(name)))
+		pattern = pattern[end+1:]
+	}
+	return sb.String(), nil
+}
+
+func taggedValues(rv reflect.Value, tag string) url.Values {
+	values := url.Values{}
+	if rv.Kind() != reflect.Struct {
+		return values
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fv := reflect.Indirect(rv.Field(i))
+		if !fv.IsValid() || (tag != "urlparam" && fv.IsZero()) {
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+	return values
+}
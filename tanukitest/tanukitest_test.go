@@ -0,0 +1,73 @@
+package tanukitest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/tanukitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taskRequest struct {
+	ProjectID int    `urlparam:"project_id" json:"-"`
+	Notify    bool   `query:"notify" json:"-"`
+	Title     string `json:"title" validate:"required"`
+}
+
+type taskResponse struct {
+	ProjectID int    `json:"project_id"`
+	Title     string `json:"title"`
+	Notify    bool   `json:"notify"`
+	User      string `json:"user"`
+}
+
+func newTaskRouter() *tanukirpc.Router[struct{}] {
+	router := tanukirpc.NewRouter(struct{}{})
+	router.Post("/projects/{project_id:[0-9]+}/tasks", tanukirpc.NewHandler(
+		func(ctx tanukirpc.Context[struct{}], req taskRequest) (*taskResponse, error) {
+			if req.Title == "forbidden" {
+				return nil, tanukirpc.WrapErrorWithStatus(http.StatusForbidden, errors.New("forbidden title"))
+			}
+			user := ctx.Request().Header.Get("X-User")
+			if c, err := ctx.Request().Cookie("session"); err == nil {
+				user += ":" + c.Value
+			}
+			return &taskResponse{ProjectID: req.ProjectID, Title: req.Title, Notify: req.Notify, User: user}, nil
+		},
+	))
+	return router
+}
+
+func TestCall(t *testing.T) {
+	router := newTaskRouter()
+
+	t.Run("success", func(t *testing.T) {
+		res, err := tanukitest.Call[taskRequest, *taskResponse](t, router, "POST /projects/{project_id:[0-9]+}/tasks",
+			taskRequest{ProjectID: 42, Notify: true, Title: "write tests"},
+			tanukitest.WithHeader("X-User", "tanuki"),
+			tanukitest.WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, &taskResponse{ProjectID: 42, Title: "write tests", Notify: true, User: "tanuki:abc"}, res)
+	})
+	t.Run("error response", func(t *testing.T) {
+		_, err := tanukitest.Call[taskRequest, *taskResponse](t, router, "POST /projects/{project_id}/tasks",
+			taskRequest{ProjectID: 42, Title: "forbidden"},
+		)
+		var re *tanukitest.ResponseError
+		require.ErrorAs(t, err, &re)
+		assert.Equal(t, http.StatusForbidden, re.StatusCode)
+		assert.Equal(t, "forbidden title", re.Message.Error.Message)
+	})
+	t.Run("validation error", func(t *testing.T) {
+		_, err := tanukitest.Call[taskRequest, *taskResponse](t, router, "POST /projects/{project_id}/tasks",
+			taskRequest{ProjectID: 42},
+		)
+		var re *tanukitest.ResponseError
+		require.ErrorAs(t, err, &re)
+		assert.Equal(t, http.StatusBadRequest, re.StatusCode)
+	})
+}
@@ -0,0 +1,296 @@
+package tanukiup
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the configuration file that is read from the base directory.
+const ConfigFileName = "tanukiup.yaml"
+
+// Config is the configuration of tanukiup.yaml like the following:
+//
+//	exts: [".go", ".sql"]
+//	dirs: ["./..."]
+//	build: go build -o {outpath} ./cmd/server
+//	exec: "{outpath} -debug"
+//	addr: localhost:8080
+//	debounce: 200ms
+//	watcher: poll
+//	poll_interval: 1s
+//	handoff: tcp
+//	catchall_target: http://localhost:5173
+//	error_overlay: true
+//	tls: true
+//	log_requests: true
+//	har_file: ./tanukiup.har
+//	env:
+//	  DATABASE_URL: postgres://localhost/dev
+//	generators:
+//	  - command: go run github.com/mackee/tanukirpc/cmd/tanukigen
+//	    dir: ./
+//	allowed_generators:
+//	  - command: github.com/sqlc-dev/sqlc/cmd/sqlc
+//	    dirs: ["./db"]
+//	    order: -1
+//	  - command: templ
+//	remote:
+//	  wrapper: docker compose exec -T app
+//	  dir: /app
+//	  listen: 0.0.0.0:8081
+//	  addr: localhost:8081
+//	test:
+//	  command: go test -short
+//	  changed_only: true
+//
+// The keys correspond to the flags of tanukiup, and the flags override them.
+// handler_dir, temp_dir, tls_cert, tls_key, har_file, the dir of the generators and the dirs of the allowed generators are resolved from the directory of the configuration file.
+type Config struct {
+	Exts               []string                 `yaml:"exts"`
+	Dirs               []string                 `yaml:"dirs"`
+	IgnoreDirs         []string                 `yaml:"ignore_dirs"`
+	Build              string                   `yaml:"build"`
+	Exec               string                   `yaml:"exec"`
+	Addr               string                   `yaml:"addr"`
+	TempDir            string                   `yaml:"temp_dir"`
+	HandlerDir         string                   `yaml:"handler_dir"`
+	CatchAllTarget     string                   `yaml:"catchall_target"`
+	ErrorOverlay       bool                     `yaml:"error_overlay"`
+	TLS                bool                     `yaml:"tls"`
+	TLSCert            string                   `yaml:"tls_cert"`
+	TLSKey             string                   `yaml:"tls_key"`
+	LogRequests        bool                     `yaml:"log_requests"`
+	HARFile            string                   `yaml:"har_file"`
+	StreamDrainTimeout string                   `yaml:"stream_drain_timeout"`
+	LogLevel           string                   `yaml:"log_level"`
+	Debounce           string                   `yaml:"debounce"`
+	Watcher            WatcherKind              `yaml:"watcher"`
+	PollInterval       string                   `yaml:"poll_interval"`
+	Handoff            Handoff                  `yaml:"handoff"`
+	Env                map[string]string        `yaml:"env"`
+	Generators         []GeneratorConfig        `yaml:"generators"`
+	AllowedGenerators  []AllowedGeneratorConfig `yaml:"allowed_generators"`
+	Test               *TestConfig              `yaml:"test"`
+	Remote             *RemoteConfig            `yaml:"remote"`
+}
+
+// RemoteConfig is the configuration to build and run the application in a container or a remote host. See Remote.
+type RemoteConfig struct {
+	Wrapper string `yaml:"wrapper"`
+	Quote   bool   `yaml:"quote"`
+	Sync    string `yaml:"sync"`
+	Dir     string `yaml:"dir"`
+	TempDir string `yaml:"temp_dir"`
+	Listen  string `yaml:"listen"`
+	Addr    string `yaml:"addr"`
+}
+
+// AllowedGeneratorConfig is the go:generate command that is run before each build. See AllowedGenerator.
+type AllowedGeneratorConfig struct {
+	Command string   `yaml:"command"`
+	Dirs    []string `yaml:"dirs"`
+	Order   int      `yaml:"order"`
+}
+
+// TestConfig is the configuration of the tests that run before each build.
+type TestConfig struct {
+	// Command is the test command. The default is "go test".
+	Command string `yaml:"command"`
+	// ChangedOnly runs the tests of only the packages that contain the modified files.
+	ChangedOnly bool `yaml:"changed_only"`
+}
+
+// GeneratorConfig is the command that runs before each build in addition to the detected go:generate directives.
+type GeneratorConfig struct {
+	Command string `yaml:"command"`
+	// Dir is the working directory of the command. The default is the directory of the configuration file.
+	Dir string `yaml:"dir"`
+}
+
+// LoadConfig reads the configuration file. The error wraps fs.ErrNotExist if the file does not exist.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w filepath=%s", err, path)
+	}
+
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+	cfg.TempDir = resolve(cfg.TempDir)
+	cfg.HandlerDir = resolve(cfg.HandlerDir)
+	cfg.TLSCert = resolve(cfg.TLSCert)
+	cfg.TLSKey = resolve(cfg.TLSKey)
+	cfg.HARFile = resolve(cfg.HARFile)
+	for i := range cfg.Generators {
+		g := &cfg.Generators[i]
+		if strings.TrimSpace(g.Command) == "" {
+			return nil, fmt.Errorf("command is required: generators[%d] of %s", i, path)
+		}
+		g.Dir = resolve(g.Dir)
+		if g.Dir == "" {
+			g.Dir = base
+		}
+	}
+	for i := range cfg.AllowedGenerators {
+		g := &cfg.AllowedGenerators[i]
+		if g.Command == "" {
+			return nil, fmt.Errorf("command is required: allowed_generators[%d] of %s", i, path)
+		}
+		for j, dir := range g.Dirs {
+			g.Dirs[j] = resolve(dir)
+		}
+	}
+	return &cfg, nil
+}
+
+// Options converts the configuration to the options of Run.
+func (c *Config) Options() (Options, error) {
+	opts := Options{}
+	if len(c.Exts) > 0 {
+		opts = append(opts, WithFileExts(c.Exts))
+	}
+	if len(c.Dirs) > 0 {
+		opts = append(opts, WithDirs(c.Dirs))
+	}
+	if len(c.IgnoreDirs) > 0 {
+		opts = append(opts, WithIgnoreDirs(c.IgnoreDirs))
+	}
+	if c.Build != "" {
+		opts = append(opts, WithBuildCommand(strings.Fields(c.Build)))
+	}
+	if c.Exec != "" {
+		opts = append(opts, WithExecCommand(strings.Fields(c.Exec)))
+	}
+	if c.Addr != "" {
+		opts = append(opts, WithAddr(c.Addr))
+	}
+	if c.TempDir != "" {
+		opts = append(opts, WithTempDir(c.TempDir))
+	}
+	if c.HandlerDir != "" {
+		opts = append(opts, WithHandlerDir(c.HandlerDir))
+	}
+	if c.CatchAllTarget != "" {
+		opts = append(opts, WithCatchAllTarget(c.CatchAllTarget))
+	}
+	if c.ErrorOverlay {
+		opts = append(opts, WithErrorOverlay(true))
+	}
+	if c.TLSCert != "" || c.TLSKey != "" {
+		opts = append(opts, WithTLSCertificate(c.TLSCert, c.TLSKey))
+	} else if c.TLS {
+		opts = append(opts, WithTLS(true))
+	}
+	if c.LogRequests {
+		opts = append(opts, WithRequestLog(true))
+	}
+	if c.HARFile != "" {
+		opts = append(opts, WithHARFile(c.HARFile))
+	}
+	if c.StreamDrainTimeout != "" {
+		d, err := time.ParseDuration(c.StreamDrainTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stream_drain_timeout: %w", err)
+		}
+		opts = append(opts, WithStreamDrainTimeout(d))
+	}
+	if c.LogLevel != "" {
+		lv, err := ParseLogLevel(c.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithLogLevel(lv))
+	}
+	if c.Debounce != "" {
+		d, err := time.ParseDuration(c.Debounce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse debounce: %w", err)
+		}
+		opts = append(opts, WithDebounce(d))
+	}
+	if c.Watcher != "" {
+		opts = append(opts, WithWatcher(c.Watcher))
+	}
+	if c.PollInterval != "" {
+		d, err := time.ParseDuration(c.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse poll_interval: %w", err)
+		}
+		opts = append(opts, WithPollInterval(d))
+	}
+	if c.Handoff != "" {
+		opts = append(opts, WithHandoff(c.Handoff))
+	}
+	if len(c.Env) > 0 {
+		env := make([]string, 0, len(c.Env))
+		for k, v := range c.Env {
+			env = append(env, k+"="+v)
+		}
+		slices.Sort(env)
+		opts = append(opts, WithEnv(env))
+	}
+	if len(c.Generators) > 0 {
+		generators := make([]Generator, 0, len(c.Generators))
+		for _, g := range c.Generators {
+			generators = append(generators, Generator{Command: strings.Fields(g.Command), Dir: g.Dir})
+		}
+		opts = append(opts, WithGenerators(generators))
+	}
+	if len(c.AllowedGenerators) > 0 {
+		allowed := make([]AllowedGenerator, 0, len(c.AllowedGenerators))
+		for _, g := range c.AllowedGenerators {
+			allowed = append(allowed, AllowedGenerator{Command: g.Command, Dirs: g.Dirs, Order: g.Order})
+		}
+		opts = append(opts, WithAllowedGenerators(allowed))
+	}
+	if c.Remote != nil {
+		opts = append(opts, WithRemote(Remote{
+			Wrapper: strings.Fields(c.Remote.Wrapper),
+			Quote:   c.Remote.Quote,
+			Sync:    strings.Fields(c.Remote.Sync),
+			Dir:     c.Remote.Dir,
+			TempDir: c.Remote.TempDir,
+			Listen:  c.Remote.Listen,
+			Addr:    c.Remote.Addr,
+		}))
+	}
+	if c.Test != nil {
+		command := strings.Fields(c.Test.Command)
+		if len(command) == 0 {
+			command = defaultTestCommand
+		}
+		opts = append(opts, WithTestCommand(command), WithTestChangedOnly(c.Test.ChangedOnly))
+	}
+	return opts, nil
+}
+
+// ParseLogLevel parses the log level: debug, info, warn or error.
+func ParseLogLevel(s string) (slog.Level, error) {
+	levelMap := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	lv, ok := levelMap[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level: %s", s)
+	}
+	return lv, nil
+}
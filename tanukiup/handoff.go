@@ -0,0 +1,118 @@
+package tanukiup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Handoff is how the application listens for the proxy of tanukiup.
+type Handoff string
+
+const (
+	// HandoffUnix passes the path of the unix domain socket by TANUKIUP_UDS_PATH.
+	HandoffUnix Handoff = "unix"
+	// HandoffTCP passes the loopback address of a free port by TANUKIUP_ADDR.
+	HandoffTCP Handoff = "tcp"
+
+	defaultTanukiupUDSPathEnv = "TANUKIUP_UDS_PATH"
+	defaultTanukiupAddrEnv    = "TANUKIUP_ADDR"
+	tcpHandoffPollInterval    = 100 * time.Millisecond
+)
+
+func defaultHandoff() Handoff {
+	// the unix domain sockets are not supported by all the versions and the file systems of Windows
+	if runtime.GOOS == "windows" {
+		return HandoffTCP
+	}
+	return HandoffUnix
+}
+
+// handoffAddress returns the address that the application listens on, and the environment variable to pass it.
+func handoffAddress(handoff Handoff, fname string, tempDir string) (string, string, error) {
+	if handoff == HandoffTCP {
+		address, err := freeLoopbackAddress()
+		if err != nil {
+			return "", "", err
+		}
+		return address, fmt.Sprintf("%s=%s", defaultTanukiupAddrEnv, address), nil
+	}
+	up := udsPath(fname, tempDir)
+	return up, fmt.Sprintf("%s=%s", defaultTanukiupUDSPathEnv, up), nil
+}
+
+func freeLoopbackAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to find free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
+
+func udsPath(fname string, tempDir string) string {
+	bd := filepath.Base(fname)
+	return filepath.Join(tempDir, bd+".sock")
+}
+
+// waitListening returns the channel that is closed when the application listens on the address.
+func waitListening(ctx context.Context, handoff Handoff, address string) (<-chan struct{}, error) {
+	ready := make(chan struct{})
+	if handoff == HandoffTCP {
+		go func() {
+			ticker := time.NewTicker(tcpHandoffPollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				conn, err := net.DialTimeout("tcp", address, tcpHandoffPollInterval)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				close(ready)
+				return
+			}
+		}()
+		return ready, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+	dir := filepath.Dir(address)
+	slog.InfoContext(ctx, "watching directory", slog.String("directory", dir))
+	if err := watcher.Add(dir + "/"); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to add directory to watcher: %w", err)
+	}
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					slog.InfoContext(ctx, "watcher is closed")
+					return
+				}
+				if event.Name == address {
+					close(ready)
+					return
+				}
+			}
+		}
+	}()
+	return ready, nil
+}
@@ -0,0 +1,333 @@
+package tanukiup
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxHARBodySize is the size of the request and response bodies that are recorded in the HAR file.
+	maxHARBodySize = 1 << 20
+	// maxHAREntries is the number of the entries in the HAR file. The oldest entries are dropped.
+	maxHAREntries     = 1000
+	harFlushInterval  = time.Second
+	harCreatorName    = "tanukiup"
+	harVersion        = "1.2"
+	harUnknownSize    = -1
+	harBase64Encoding = "base64"
+)
+
+// recordingResponseWriter records the status, the size and the head of the body of the response.
+// The http.Flusher and http.Hijacker of the underlying writer are available via http.ResponseController.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+	// body is nil if the body is not recorded.
+	body *bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body != nil && w.body.Len() < maxHARBodySize {
+		w.body.Write(p[:min(len(p), maxHARBodySize-w.body.Len())])
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// limitedTeeReader copies the head of the request body to buf while the upstream reads it.
+type limitedTeeReader struct {
+	io.ReadCloser
+	buf  *bytes.Buffer
+	size int64
+}
+
+func (r *limitedTeeReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.buf.Len() < maxHARBodySize {
+		r.buf.Write(p[:min(n, maxHARBodySize-r.buf.Len())])
+	}
+	r.size += int64(n)
+	return n, err
+}
+
+// serveRecorded serves the request with next, and logs it or records it in the HAR file.
+func (p *devProxy) serveRecorded(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	start := time.Now()
+	rw := &recordingResponseWriter{ResponseWriter: w}
+	var reqBody *limitedTeeReader
+	// the bodies of the streams are not recorded
+	if p.har != nil && !isStreamRequest(req) {
+		rw.body = &bytes.Buffer{}
+		if req.Body != nil && req.Body != http.NoBody {
+			reqBody = &limitedTeeReader{ReadCloser: req.Body, buf: &bytes.Buffer{}}
+			req.Body = reqBody
+		}
+	}
+	next.ServeHTTP(rw, req)
+	duration := time.Since(start)
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if p.logRequests {
+		slog.InfoContext(req.Context(), "proxy request",
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.RequestURI()),
+			slog.Int("status", status),
+			slog.Int64("size", rw.size),
+			slog.Duration("duration", duration),
+		)
+	}
+	if p.har != nil {
+		p.har.add(newHAREntry(req, reqBody, rw, status, start, duration))
+	}
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHAREntry(req *http.Request, reqBody *limitedTeeReader, rw *recordingResponseWriter, status int, start time.Time, duration time.Duration) *harEntry {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	ms := float64(duration.Microseconds()) / 1000
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         scheme + "://" + req.Host + req.URL.RequestURI(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: harHeaders(req.URL.Query()),
+			HeadersSize: harUnknownSize,
+		},
+		Response: harResponse{
+			Status:      status,
+			StatusText:  http.StatusText(status),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies((&http.Response{Header: rw.Header()}).Cookies()),
+			Headers:     harHeaders(rw.Header()),
+			Content: harContent{
+				Size:     rw.size,
+				MimeType: rw.Header().Get("Content-Type"),
+			},
+			RedirectURL: rw.Header().Get("Location"),
+			HeadersSize: harUnknownSize,
+			BodySize:    rw.size,
+		},
+		Timings: harTimings{Wait: ms},
+	}
+	if reqBody != nil {
+		entry.Request.BodySize = reqBody.size
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     reqBody.buf.String(),
+		}
+	}
+	if rw.body != nil && rw.body.Len() > 0 {
+		if utf8.Valid(rw.body.Bytes()) {
+			entry.Response.Content.Text = rw.body.String()
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(rw.body.Bytes())
+			entry.Response.Content.Encoding = harBase64Encoding
+		}
+	}
+	return entry
+}
+
+func harHeaders(h map[string][]string) []harNameValue {
+	nvs := make([]harNameValue, 0, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			nvs = append(nvs, harNameValue{Name: name, Value: v})
+		}
+	}
+	return nvs
+}
+
+func harCookies(cookies []*http.Cookie) []harNameValue {
+	nvs := make([]harNameValue, 0, len(cookies))
+	for _, c := range cookies {
+		nvs = append(nvs, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	return nvs
+}
+
+// harRecorder keeps the entries and writes them to the HAR file periodically.
+type harRecorder struct {
+	path string
+
+	mu      sync.Mutex
+	entries []*harEntry
+	dirty   bool
+}
+
+func newHARRecorder(path string) *harRecorder {
+	return &harRecorder{path: path, entries: []*harEntry{}}
+}
+
+func (r *harRecorder) add(entry *harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) >= maxHAREntries {
+		r.entries = r.entries[len(r.entries)-maxHAREntries+1:]
+	}
+	r.entries = append(r.entries, entry)
+	r.dirty = true
+}
+
+// run writes the HAR file when the entries are added, and at last when ctx is done.
+func (r *harRecorder) run(ctx context.Context) {
+	ticker := time.NewTicker(harFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := r.flush(); err != nil {
+				slog.Error("failed to write HAR file", slog.Any("error", err))
+			}
+			return
+		case <-ticker.C:
+			if err := r.flush(); err != nil {
+				slog.ErrorContext(ctx, "failed to write HAR file", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func (r *harRecorder) flush() error {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	b, err := json.MarshalIndent(harFile{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreator{Name: harCreatorName},
+			Entries: r.entries,
+		},
+	}, "", "  ")
+	r.dirty = false
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+
+	// the file is replaced atomically, so the viewers don't read the partial file
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".tanukiup-*.har")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to rename HAR file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package tanukiup
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mackee/tanukirpc/genclient"
+)
+
+// devProxy is the proxy server of the proxy mode. It keeps listening across the restarts of the application,
+// and the router is replaced when the application is started or the build fails.
+type devProxy struct {
+	addr         string
+	errorOverlay bool
+	catchAll     http.Handler
+	// tlsConfig is not nil if the proxy serves over HTTPS.
+	tlsConfig   *tls.Config
+	logRequests bool
+	// har is not nil if the requests are recorded to the HAR file.
+	har *harRecorder
+
+	mu     sync.RWMutex
+	router http.Handler
+	// paths is the routes of the last application that is started.
+	paths *appPaths
+	// failure and failureOutput are the message and the output of the last failed build or tests.
+	// They are empty after the application is started.
+	failure       string
+	failureOutput string
+}
+
+func newDevProxy(args *optionArgs) (*devProxy, error) {
+	p := &devProxy{
+		addr:         args.addr,
+		errorOverlay: args.errorOverlay,
+		paths:        &appPaths{},
+		logRequests:  args.logRequests,
+	}
+	if args.harFile != "" {
+		p.har = newHARRecorder(args.harFile)
+	}
+	if args.tls {
+		host, _, err := net.SplitHostPort(args.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse addr: %w", err)
+		}
+		cert, err := devCertificate(args.tlsCertFile, args.tlsKeyFile, host)
+		if err != nil {
+			return nil, err
+		}
+		p.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	if args.catchAllTarget != "" {
+		u, err := url.Parse(args.catchAllTarget)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %w", err)
+		}
+		catchAll := httputil.NewSingleHostReverseProxy(u)
+		if p.errorOverlay {
+			director := catchAll.Director
+			catchAll.Director = func(req *http.Request) {
+				director(req)
+				// the overlay is injected into the uncompressed body
+				req.Header.Del("Accept-Encoding")
+			}
+			catchAll.ModifyResponse = p.injectErrorOverlay
+		}
+		catchAll.Director = withForwardedProto(catchAll.Director)
+		p.catchAll = catchAll
+	}
+	p.router = p.newRouter(http.HandlerFunc(p.serveBuildError))
+	return p, nil
+}
+
+func (p *devProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p.mu.RLock()
+	router := p.router
+	p.mu.RUnlock()
+	if p.logRequests || p.har != nil {
+		p.serveRecorded(w, req, router)
+		return
+	}
+	router.ServeHTTP(w, req)
+}
+
+// serve listens on the address until ctx is done.
+func (p *devProxy) serve(ctx context.Context) {
+	server := &http.Server{
+		Addr:      p.addr,
+		Handler:   p,
+		TLSConfig: p.tlsConfig,
+	}
+	if p.har != nil {
+		go p.har.run(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(sctx); err != nil {
+			slog.ErrorContext(ctx, "failed to shutdown server", slog.Any("error", err))
+		}
+	}()
+	slog.Info("staring proxy server", slog.String("addr", server.Addr), slog.Bool("tls", p.tlsConfig != nil))
+	var err error
+	if p.tlsConfig != nil {
+		// the certificate is in TLSConfig
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.ErrorContext(ctx, "failed to listen and serve", slog.Any("error", err))
+	}
+}
+
+// withForwardedProto sets X-Forwarded-Proto, so the upstreams can tell the requests over HTTPS like for the secure cookies.
+func withForwardedProto(director func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+		director(req)
+		if req.Header.Get("X-Forwarded-Proto") == "" {
+			req.Header.Set("X-Forwarded-Proto", proto)
+		}
+	}
+}
+
+// newRouter returns the router that forwards the routes of the application to app and the others to the catch-all target.
+func (p *devProxy) newRouter(app http.Handler) http.Handler {
+	router := chi.NewRouter()
+	for _, rp := range p.paths.Paths {
+		router.Method(rp.Method, rp.Path, app)
+	}
+	// the routes of the mounted tanukirpc routers are already in the paths
+	for _, mp := range p.paths.Mounts {
+		if !mp.Router {
+			router.Mount(mp.Path, app)
+		}
+	}
+	for _, fp := range p.paths.Fallbacks {
+		switch {
+		case fp.Kind == genclient.FallbackKindMethodNotAllowed && fp.Path == "/":
+			router.MethodNotAllowed(app.ServeHTTP)
+		case fp.Kind == genclient.FallbackKindNotFound && fp.Path == "/" && p.catchAll == nil:
+			router.NotFound(app.ServeHTTP)
+		}
+	}
+
+	switch {
+	case p.catchAll != nil:
+		router.NotFound(p.catchAll.ServeHTTP)
+	case len(p.paths.Paths) == 0 && len(p.paths.Mounts) == 0:
+		// no application has been started yet
+		router.NotFound(app.ServeHTTP)
+	}
+	return router
+}
+
+// buildFailed replaces the routes of the application with the page of the build error.
+func (p *devProxy) buildFailed(message, output string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failure, p.failureOutput = message, output
+	p.router = p.newRouter(http.HandlerFunc(p.serveBuildError))
+}
+
+// started replaces the router with the routes of the started application.
+func (p *devProxy) started(paths *appPaths, app http.Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	logRouteChanges(p.paths, paths)
+	p.failure, p.failureOutput = "", ""
+	p.paths = paths
+	p.router = p.newRouter(app)
+}
+
+func (p *devProxy) currentPaths() *appPaths {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.paths
+}
+
+// logRouteChanges logs the routes that are added or removed by the restart.
+func logRouteChanges(prev, next *appPaths) {
+	// the first application
+	if len(prev.Paths) == 0 && len(prev.Mounts) == 0 {
+		return
+	}
+	prevRoutes, nextRoutes := prev.routes(), next.routes()
+	for route := range nextRoutes {
+		if _, ok := prevRoutes[route]; !ok {
+			slog.Info("route is added", slog.String("route", route))
+		}
+	}
+	for route := range prevRoutes {
+		if _, ok := nextRoutes[route]; !ok {
+			slog.Info("route is removed", slog.String("route", route))
+		}
+	}
+}
+
+func (p *devProxy) lastFailure() (string, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.failure, p.failureOutput
+}
+
+// serveBuildError responds 503 with the output of the build in JSON, or in HTML for the browsers.
+func (p *devProxy) serveBuildError(w http.ResponseWriter, req *http.Request) {
+	message, output := p.lastFailure()
+	if message == "" {
+		message = "the application is not running"
+	}
+	w.Header().Set("Retry-After", "1")
+	if strings.Contains(req.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := buildErrorPageTemplate.Execute(w, map[string]string{"Message": message, "Output": output}); err != nil {
+			slog.ErrorContext(req.Context(), "failed to render build error", slog.Any("error", err))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	body := buildErrorMessage{Error: buildErrorBody{Message: message, BuildOutput: output}}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.ErrorContext(req.Context(), "failed to encode build error", slog.Any("error", err))
+	}
+}
+
+// buildErrorMessage has the same shape as tanukirpc.ErrorMessage with the output of the build.
+type buildErrorMessage struct {
+	Error buildErrorBody `json:"error"`
+}
+
+type buildErrorBody struct {
+	Message     string `json:"message"`
+	BuildOutput string `json:"build_output,omitempty"`
+}
+
+// injectErrorOverlay injects the overlay of the build error into the HTML of the catch-all target.
+func (p *devProxy) injectErrorOverlay(res *http.Response) error {
+	message, output := p.lastFailure()
+	if message == "" || res.Header.Get("Content-Encoding") != "" ||
+		!strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	res.Body.Close()
+
+	overlay := &bytes.Buffer{}
+	if err := buildErrorOverlayTemplate.Execute(overlay, map[string]string{"Message": message, "Output": output}); err != nil {
+		return fmt.Errorf("failed to render build error overlay: %w", err)
+	}
+	if i := bytes.LastIndex(b, []byte("</body>")); i >= 0 {
+		b = append(b[:i:i], append(overlay.Bytes(), b[i:]...)...)
+	} else {
+		b = append(b, overlay.Bytes()...)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	res.ContentLength = int64(len(b))
+	res.Header.Set("Content-Length", strconv.Itoa(len(b)))
+	return nil
+}
+
+// appUpstream forwards the requests to the application that listens on the unix domain socket or the loopback address.
+type appUpstream struct {
+	proxy *httputil.ReverseProxy
+	// streamsCtx is done when the streams to the application should be closed.
+	streamsCtx context.Context
+	streams    atomic.Int64
+}
+
+func newAppUpstream(addr string, handoff Handoff, address string, streamsCtx context.Context) (*appUpstream, error) {
+	u, err := url.Parse("http://" + addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial(string(handoff), address)
+		},
+	}
+	// flush the streaming responses like Server-Sent Events immediately
+	proxy.FlushInterval = -1
+	proxy.Director = withForwardedProto(proxy.Director)
+	return &appUpstream{proxy: proxy, streamsCtx: streamsCtx}, nil
+}
+
+func (u *appUpstream) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !isStreamRequest(req) {
+		u.proxy.ServeHTTP(w, req)
+		return
+	}
+	u.streams.Add(1)
+	defer u.streams.Add(-1)
+	// httputil.ReverseProxy closes the upgraded connection when the context of the request is done
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	stop := context.AfterFunc(u.streamsCtx, cancel)
+	defer stop()
+	u.proxy.ServeHTTP(w, req.WithContext(ctx))
+}
+
+// drain waits for the in-flight streams until timeout.
+func (u *appUpstream) drain(ctx context.Context, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for n := u.streams.Load(); n > 0; n = u.streams.Load() {
+		if time.Now().After(deadline) {
+			slog.WarnContext(ctx, "closing the in-flight streams", slog.Int64("streams", n))
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isStreamRequest reports whether the request is the WebSocket upgrade or the Server-Sent Events.
+func isStreamRequest(req *http.Request) bool {
+	return req.Header.Get("Upgrade") != "" || strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+}
+
+// waitAndStart retrieves the routes of the application while it is starting, and replaces the router after the application listens on the address.
+// If the routes can't be retrieved, the routes of the previous application are used.
+// The streams to the application are closed when streamsCtx is done.
+func (p *devProxy) waitAndStart(ctx context.Context, handlerDir string, handoff Handoff, address string, streamsCtx context.Context) *appUpstream {
+	upstream, err := newAppUpstream(p.addr, handoff, address, streamsCtx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create upstream", slog.Any("error", err))
+		return nil
+	}
+	ready, err := waitListening(ctx, handoff, address)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to wait for the application", slog.Any("error", err))
+		return nil
+	}
+
+	pathsChan := make(chan *appPaths, 1)
+	go func() {
+		paths, err := retrievePaths(ctx, handlerDir)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to retrieve paths, using the previous routes", slog.Any("error", err))
+			paths = p.currentPaths()
+		}
+		pathsChan <- paths
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ready:
+		}
+		select {
+		case <-ctx.Done():
+		case paths := <-pathsChan:
+			p.started(paths, upstream)
+			slog.InfoContext(ctx, "proxying to the application", slog.String("addr", p.addr))
+		}
+	}()
+	return upstream
+}
+
+var buildErrorPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>tanukiup: {{ .Message }}</title>
+</head>
+<body style="font-family: sans-serif; margin: 2em;">
+<h1>{{ .Message }}</h1>
+{{ if .Output }}<pre style="background: #222; color: #f88; padding: 1em; overflow: auto;">{{ .Output }}</pre>{{ end }}
+<p>This page is served by tanukiup. Reload after fixing the error.</p>
+</body>
+</html>
+`))
+
+var buildErrorOverlayTemplate = template.Must(template.New("overlay").Parse(`<div id="tanukiup-error-overlay" style="position: fixed; inset: 0; z-index: 2147483647; background: rgba(0, 0, 0, 0.85); color: #fff; font-family: sans-serif; padding: 2em; overflow: auto;">
+<button type="button" onclick="this.parentNode.remove()" style="float: right;">close</button>
+<h2 style="color: #f88;">tanukiup: {{ .Message }}</h2>
+<pre style="white-space: pre-wrap;">{{ .Output }}</pre>
+</div>
+`))
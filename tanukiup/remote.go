@@ -0,0 +1,121 @@
+package tanukiup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const (
+	defaultRemoteTempDir = "/tmp"
+	// remoteScript runs the command in the directory of $0, and writes its pid to $1 if it is not empty.
+	// The command keeps the pid because of exec, so it can be stopped by the pid file.
+	remoteScript = `cd "$0" || exit 1; pidfile=$1; shift; if [ -n "$pidfile" ]; then echo $$ > "$pidfile"; fi; exec env "$@"`
+	// remoteStopScript stops the command by the pid file of $0, and removes the pid file and the binary of $1.
+	remoteStopScript = `if [ -f "$0" ]; then kill $(cat "$0") 2>/dev/null; fi; rm -f "$0" "$1"`
+)
+
+// Remote is the configuration to build and run the application in a container or a remote host.
+// tanukiup watches the local files, syncs them by Sync, and runs the build, test and exec commands through Wrapper.
+// The remote needs sh, and the proxy connects to the application by TCP.
+type Remote struct {
+	// Wrapper is prepended to the build, test and exec commands, like ["docker", "compose", "exec", "-T", "app"].
+	Wrapper []string
+	// Quote joins the command into a quoted argument of Wrapper. It is needed for ssh, which runs the command with the shell of the remote.
+	Quote bool
+	// Sync is run locally in the base directory before each build, like ["rsync", "-a", "--delete", "./", "dev:/app/"].
+	// It is not needed for the bind mounts of the containers.
+	Sync []string
+	// Dir is the directory of the source in the remote. The default is the working directory of Wrapper.
+	Dir string
+	// TempDir is the directory of the remote where {outpath} is built. The default is /tmp.
+	TempDir string
+	// Listen is the address that the application listens on in the remote, which is passed by TANUKIUP_ADDR like "0.0.0.0:8081".
+	Listen string
+	// Addr is the address that the proxy connects to, like the published port of the container "localhost:8081".
+	// The default is Listen.
+	Addr string
+}
+
+// WithRemote builds and runs the application in a container or a remote host. See Remote.
+func WithRemote(remote Remote) Option {
+	return func(args *optionArgs) {
+		args.remote = &remote
+	}
+}
+
+func (r *Remote) tempDir() string {
+	if r.TempDir == "" {
+		return defaultRemoteTempDir
+	}
+	return r.TempDir
+}
+
+func (r *Remote) addr() string {
+	if r.Addr == "" {
+		return r.Listen
+	}
+	return r.Addr
+}
+
+// command returns the command that runs command with env in the remote. pidFile is empty if the command is not stopped by stop.
+func (r *Remote) command(command []string, env []string, pidFile string) []string {
+	dir := r.Dir
+	if dir == "" {
+		dir = "."
+	}
+	remote := append([]string{"sh", "-c", remoteScript, dir, pidFile}, env...)
+	remote = append(remote, command...)
+	if r.Quote {
+		remote = []string{shellQuote(remote)}
+	}
+	return append(append([]string{}, r.Wrapper...), remote...)
+}
+
+// stop stops the application that is started with pidFile, and removes the binary at outpath.
+func (r *Remote) stop(ctx context.Context, baseDir, pidFile, outpath string) {
+	remote := []string{"sh", "-c", remoteStopScript, pidFile, outpath}
+	if r.Quote {
+		remote = []string{shellQuote(remote)}
+	}
+	command := append(append([]string{}, r.Wrapper...), remote...)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = baseDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		slog.ErrorContext(ctx, "failed to stop the application in the remote", slog.Any("error", err))
+	}
+}
+
+func (r *Remote) sync(ctx context.Context, baseDir string) error {
+	if len(r.Sync) == 0 {
+		return nil
+	}
+	slog.InfoContext(ctx, "syncing files", slog.Any("command", r.Sync))
+	cmd := exec.CommandContext(ctx, r.Sync[0], r.Sync[1:]...)
+	cmd.Dir = baseDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to sync files: %w", err)
+	}
+	return nil
+}
+
+func (r *Remote) outpath(fname string) string {
+	return path.Join(r.tempDir(), fname)
+}
+
+// shellQuote joins the arguments quoted for sh.
+func shellQuote(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
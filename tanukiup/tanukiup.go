@@ -4,57 +4,75 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"math/rand/v2"
-	"net"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/go-chi/chi/v5"
+	"github.com/mackee/tanukirpc/genclient"
 )
 
 const (
 	defaultLogLevel             = slog.LevelInfo
+	defaultDebounce             = 200 * time.Millisecond
 	generateDetectTargetFileExt = ".go"
 	buildOutPathPlaceholder     = "{outpath}"
 )
 
 var (
-	defaultFileExts     = []string{".go"}
-	defaultDirs         = []string{"./"}
-	defaultIgnoreDirs   = []string{".git"}
-	defaultBuildCommand = []string{"go", "build", "-o", "{outpath}", "./"}
-	defaultExecCommand  = []string{"{outpath}"}
-	whitelistGenerate   = map[string]struct{}{
-		"github.com/mackee/tanukirpc/cmd/gentypescript": {},
+	defaultFileExts          = []string{".go"}
+	defaultDirs              = []string{"./"}
+	defaultIgnoreDirs        = []string{".git"}
+	defaultBuildCommand      = []string{"go", "build", "-o", "{outpath}", "./"}
+	defaultExecCommand       = []string{"{outpath}"}
+	defaultTestCommand       = []string{"go", "test"}
+	defaultAllowedGenerators = []AllowedGenerator{
+		{Command: "github.com/mackee/tanukirpc/cmd/gentypescript"},
 	}
 )
 
 type optionArgs struct {
-	fileExts       []string
-	dirs           []string
-	ignoreDirs     []string
-	buildCommand   []string
-	execCommand    []string
-	addr           string
-	tempDir        string
-	baseDir        string
-	handlerDir     string
-	catchAllTarget string
-	logLevel       slog.Level
+	fileExts          []string
+	dirs              []string
+	ignoreDirs        []string
+	buildCommand      []string
+	execCommand       []string
+	addr              string
+	tempDir           string
+	baseDir           string
+	handlerDir        string
+	catchAllTarget    string
+	logLevel          slog.Level
+	env               []string
+	generators        []Generator
+	debounce          time.Duration
+	errorOverlay      bool
+	allowedGenerators []AllowedGenerator
+	testCommand       []string
+	testChangedOnly   bool
+	tls               bool
+	tlsCertFile       string
+	tlsKeyFile        string
+	logRequests       bool
+	harFile           string
+	watcher           WatcherKind
+	pollInterval      time.Duration
+	handoff           Handoff
+	remote            *Remote
+	// streamDrainTimeout is the time to wait for the in-flight streams before stopping the application.
+	streamDrainTimeout time.Duration
 }
 
 func newDefaultOptionArgs() *optionArgs {
@@ -70,6 +88,10 @@ func newDefaultOptionArgs() *optionArgs {
 		baseDir:      baseDir,
 		handlerDir:   baseDir,
 		logLevel:     defaultLogLevel,
+		debounce:     defaultDebounce,
+		watcher:      WatcherAuto,
+		pollInterval: defaultPollInterval,
+		handoff:      defaultHandoff(),
 	}
 }
 
@@ -149,6 +171,137 @@ func WithHandlerDir(handlerDir string) Option {
 	}
 }
 
+// WithEnv sets the environment variables like "KEY=VALUE" of the exec command in addition to the environment of tanukiup.
+func WithEnv(env []string) Option {
+	return func(args *optionArgs) {
+		args.env = env
+	}
+}
+
+// WithDebounce sets the period to wait for the following file events before restarting. The default is 200ms.
+// The events in the period are coalesced, and the build in progress is canceled when a newer change arrives.
+func WithDebounce(debounce time.Duration) Option {
+	return func(args *optionArgs) {
+		args.debounce = debounce
+	}
+}
+
+// WithErrorOverlay enables the overlay of the build error that is injected into the HTML of the catch-all target.
+func WithErrorOverlay(enabled bool) Option {
+	return func(args *optionArgs) {
+		args.errorOverlay = enabled
+	}
+}
+
+// WithStreamDrainTimeout sets the time to wait for the in-flight WebSocket and Server-Sent Events streams
+// before stopping the application on restart. The new requests are forwarded to the previous application until the new one starts.
+// By default, the streams are closed immediately on restart.
+func WithStreamDrainTimeout(timeout time.Duration) Option {
+	return func(args *optionArgs) {
+		args.streamDrainTimeout = timeout
+	}
+}
+
+// WithTLS serves the proxy over HTTPS with the certificate issued by the local CA of tanukiup. See DevCertDir.
+func WithTLS(enabled bool) Option {
+	return func(args *optionArgs) {
+		args.tls = enabled
+	}
+}
+
+// WithTLSCertificate serves the proxy over HTTPS with the certificate in certFile and keyFile instead of the one issued by tanukiup.
+func WithTLSCertificate(certFile, keyFile string) Option {
+	return func(args *optionArgs) {
+		args.tls = true
+		args.tlsCertFile = certFile
+		args.tlsKeyFile = keyFile
+	}
+}
+
+// WithWatcher sets the kind of the file watcher. The default is WatcherAuto.
+func WithWatcher(kind WatcherKind) Option {
+	return func(args *optionArgs) {
+		args.watcher = kind
+	}
+}
+
+// WithPollInterval sets the interval of the polling watcher. The default is 500ms.
+func WithPollInterval(interval time.Duration) Option {
+	return func(args *optionArgs) {
+		args.pollInterval = interval
+	}
+}
+
+// WithHandoff sets how the proxy connects to the application. The default is HandoffTCP on Windows and HandoffUnix on the others.
+func WithHandoff(handoff Handoff) Option {
+	return func(args *optionArgs) {
+		args.handoff = handoff
+	}
+}
+
+// WithRequestLog logs the method, the path, the status and the duration of the requests through the proxy.
+func WithRequestLog(enabled bool) Option {
+	return func(args *optionArgs) {
+		args.logRequests = enabled
+	}
+}
+
+// WithHARFile records the requests and the responses through the proxy to the HAR file at path.
+// The file keeps the last 1000 entries, and the bodies are truncated at 1 MiB.
+func WithHARFile(path string) Option {
+	return func(args *optionArgs) {
+		args.harFile = path
+	}
+}
+
+// AllowedGenerator is the go:generate command that tanukiup runs before each build when it is detected.
+type AllowedGenerator struct {
+	// Command is the package of "go run" like "github.com/sqlc-dev/sqlc/cmd/sqlc", or the command like "sqlc".
+	// The version suffix of "go run pkg@version" is ignored.
+	Command string
+	// Dirs limits the directories of the go:generate directives. They are relative to the base directory and include the subdirectories.
+	// If it is empty, the directives in all the watched directories are run.
+	Dirs []string
+	// Order is the order to run. The generators run in ascending order, and the generators of the same order run in the detected order.
+	Order int
+}
+
+// WithAllowedGenerators adds the go:generate commands that are run before each build. gentypescript is always allowed.
+func WithAllowedGenerators(generators []AllowedGenerator) Option {
+	return func(args *optionArgs) {
+		args.allowedGenerators = append(args.allowedGenerators, generators...)
+	}
+}
+
+// WithTestCommand sets the command like []string{"go", "test"} that runs the tests before each build.
+// The packages are appended to the command. If the tests fail, the server is not restarted,
+// and the output is shown by the proxy like the build error.
+func WithTestCommand(command []string) Option {
+	return func(args *optionArgs) {
+		args.testCommand = command
+	}
+}
+
+// WithTestChangedOnly runs the tests of only the packages that contain the modified files instead of ./...
+func WithTestChangedOnly(changedOnly bool) Option {
+	return func(args *optionArgs) {
+		args.testChangedOnly = changedOnly
+	}
+}
+
+// Generator is the command that runs before each build.
+type Generator struct {
+	Command []string
+	Dir     string
+}
+
+// WithGenerators sets the generators that run before each build in addition to the detected go:generate directives.
+func WithGenerators(generators []Generator) Option {
+	return func(args *optionArgs) {
+		args.generators = generators
+	}
+}
+
 func Run(ctx context.Context, options ...Option) error {
 	args := newDefaultOptionArgs()
 	Options(options).apply(args)
@@ -158,23 +311,74 @@ func Run(ctx context.Context, options ...Option) error {
 	}))
 	slog.SetDefault(logger)
 
-	watcher, err := fsnotify.NewWatcher()
+	for _, g := range args.generators {
+		enableGenerator(ctx, &generatorInfo{command: g.Command, dir: g.Dir})
+	}
+	allowed := append(slices.Clone(defaultAllowedGenerators), args.allowedGenerators...)
+	for i := range allowed {
+		dirs := make([]string, 0, len(allowed[i].Dirs))
+		for _, dir := range allowed[i].Dirs {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(args.baseDir, dir)
+			}
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return fmt.Errorf("failed to resolve directory of generator: %w", err)
+			}
+			dirs = append(dirs, abs)
+		}
+		allowed[i].Dirs = dirs
+	}
+
+	// the proxy keeps serving while the application is rebuilt, and responds the build error if the build fails
+	var proxy *devProxy
+	if args.tls && args.addr == "" {
+		return errors.New("addr is required to serve over HTTPS")
+	}
+	if args.addr != "" {
+		p, err := newDevProxy(args)
+		if err != nil {
+			return fmt.Errorf("failed to create proxy server: %w", err)
+		}
+		proxy = p
+		go proxy.serve(ctx)
+	}
+
+	switch args.handoff {
+	case HandoffUnix, HandoffTCP:
+	default:
+		return fmt.Errorf("unknown handoff: %s", args.handoff)
+	}
+	if args.remote != nil {
+		if len(args.remote.Wrapper) == 0 {
+			return errors.New("wrapper of the remote is required")
+		}
+		if args.addr != "" && args.remote.Listen == "" {
+			return errors.New("listen address of the remote is required to proxy the application")
+		}
+	}
+	watcher, err := newFileWatcher(ctx, args.watcher, args.pollInterval)
 	if err != nil {
-		return fmt.Errorf("failed to create watcher: %w", err)
+		return err
 	}
 	defer watcher.Close()
 
 	errChan := make(chan error)
 	defer close(errChan)
-	restartChan := make(chan struct{})
+	// restartChan receives the modified files
+	restartChan := make(chan []string)
 	defer close(restartChan)
 	go func() {
 		skipStart := false
+		var done chan struct{}
+		var modified []string
 		for {
 			cmdCtx, cancel := context.WithCancel(ctx)
 			if !skipStart {
-				go func() {
-					if err := runGenerator(ctx); err != nil {
+				done = make(chan struct{})
+				go func(done chan struct{}, modified []string) {
+					defer close(done)
+					if err := runGenerator(cmdCtx); err != nil {
 						var exitError *exec.ExitError
 						if !errors.Is(err, context.Canceled) &&
 							!errors.As(err, &exitError) &&
@@ -184,7 +388,7 @@ func Run(ctx context.Context, options ...Option) error {
 						}
 						return
 					}
-					if err := startCmd(cmdCtx, args); err != nil {
+					if err := startCmd(cmdCtx, args, proxy, modified); err != nil {
 						var exitError *exec.ExitError
 						if !errors.Is(err, context.Canceled) &&
 							!errors.As(err, &exitError) &&
@@ -193,14 +397,22 @@ func Run(ctx context.Context, options ...Option) error {
 							errChan <- err
 						}
 					}
-				}()
+				}(done, modified)
 			}
 			select {
 			case <-ctx.Done():
 				cancel()
 				return
-			case <-restartChan:
+			case modified = <-restartChan:
+				// cancel the build or the server in progress and wait for it, so the cycles do not overlap
 				cancel()
+				for done != nil {
+					select {
+					case <-done:
+						done = nil
+					case <-errChan:
+					}
+				}
 				skipStart = false
 			case <-errChan:
 				cancel()
@@ -215,9 +427,12 @@ func Run(ctx context.Context, options ...Option) error {
 	}
 
 	go func() {
+		// the events in the debounce period are coalesced into a restart
+		var debounce <-chan time.Time
+		modified := make(map[string]struct{})
 		for {
 			select {
-			case event, ok := <-watcher.Events:
+			case event, ok := <-watcher.Events():
 				if !ok {
 					slog.InfoContext(ctx, "watcher is closed")
 					return
@@ -227,10 +442,20 @@ func Run(ctx context.Context, options ...Option) error {
 					if _, ok := extMap[filepath.Ext(event.Name)]; !ok {
 						continue
 					}
-					slog.InfoContext(ctx, "modified file", slog.String("filename", event.Name))
-					restartChan <- struct{}{}
+					modified[event.Name] = struct{}{}
+					debounce = time.After(args.debounce)
+				}
+			case <-debounce:
+				debounce = nil
+				filenames := make([]string, 0, len(modified))
+				for name := range modified {
+					filenames = append(filenames, name)
 				}
-			case err, ok := <-watcher.Errors:
+				slices.Sort(filenames)
+				clear(modified)
+				slog.InfoContext(ctx, "modified files", slog.Any("filenames", filenames))
+				restartChan <- filenames
+			case err, ok := <-watcher.Errors():
 				if !ok {
 					slog.InfoContext(ctx, "watcher is closed")
 					return
@@ -256,12 +481,12 @@ func Run(ctx context.Context, options ...Option) error {
 			if !stat.IsDir() {
 				return fmt.Errorf("not a directory: %s", noRecursive)
 			}
-			if err := filepath.WalkDir(noRecursive, walkDirFunc(ctx, ignoreDirsMap, watcher)); err != nil {
+			if err := filepath.WalkDir(noRecursive, walkDirFunc(ctx, ignoreDirsMap, watcher, allowed)); err != nil {
 				return fmt.Errorf("failed to walk directory: %w", err)
 			}
 			continue
 		}
-		if err := walkDirFunc(ctx, ignoreDirsMap, watcher)(dir, nil, nil); err != nil {
+		if err := walkDirFunc(ctx, ignoreDirsMap, watcher, allowed)(dir, nil, nil); err != nil {
 			return fmt.Errorf("failed to walk directory: %w", err)
 		}
 	}
@@ -274,7 +499,7 @@ type isDirer interface {
 	IsDir() bool
 }
 
-func walkDirFunc(ctx context.Context, ignoreDirsMap map[string]struct{}, watcher *fsnotify.Watcher) fs.WalkDirFunc {
+func walkDirFunc(ctx context.Context, ignoreDirsMap map[string]struct{}, watcher fileWatcher, allowed []AllowedGenerator) fs.WalkDirFunc {
 	return func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("failed to walk directory: %w", err)
@@ -291,7 +516,7 @@ func walkDirFunc(ctx context.Context, ignoreDirsMap map[string]struct{}, watcher
 				continue
 			}
 			if filepath.Ext(f.Name()) == generateDetectTargetFileExt {
-				if err := searchGenerate(ctx, filepath.Join(p, f.Name())); err != nil {
+				if err := searchGenerate(ctx, filepath.Join(p, f.Name()), allowed); err != nil {
 					return fmt.Errorf("failed to search generate: %w", err)
 				}
 			}
@@ -309,13 +534,23 @@ func walkDirFunc(ctx context.Context, ignoreDirsMap map[string]struct{}, watcher
 	}
 }
 
-const (
-	defaultTanukiupUDSPathEnv = "TANUKIUP_UDS_PATH"
-)
+func startCmd(ctx context.Context, args *optionArgs, proxy *devProxy, modified []string) error {
+	if args.remote != nil {
+		if err := args.remote.sync(ctx, args.baseDir); err != nil {
+			return err
+		}
+	}
+	if len(args.testCommand) > 0 {
+		if err := runTests(ctx, args, proxy, modified); err != nil {
+			return err
+		}
+	}
 
-func startCmd(ctx context.Context, args *optionArgs) error {
 	fname := strconv.FormatUint(rand.Uint64(), 10)
 	outpath := filepath.Join(args.tempDir, fname)
+	if args.remote != nil {
+		outpath = args.remote.outpath(fname)
+	}
 	buildCommand := make([]string, 0, len(args.buildCommand))
 	for _, bc := range args.buildCommand {
 		if bc == buildOutPathPlaceholder {
@@ -324,15 +559,24 @@ func startCmd(ctx context.Context, args *optionArgs) error {
 			buildCommand = append(buildCommand, bc)
 		}
 	}
+	if args.remote != nil {
+		buildCommand = args.remote.command(buildCommand, nil, "")
+	}
 	slog.InfoContext(ctx, "building command", slog.Any("command", buildCommand))
 	bcmd := exec.CommandContext(ctx, buildCommand[0], buildCommand[1:]...)
 	bcmd.Dir = args.baseDir
 	bcmd.Stdout = os.Stdout
-	bcmd.Stderr = os.Stderr
+	output := &bytes.Buffer{}
+	bcmd.Stderr = io.MultiWriter(os.Stderr, output)
 	if err := bcmd.Run(); err != nil {
+		if proxy != nil && ctx.Err() == nil {
+			proxy.buildFailed("failed to build the application", output.String())
+		}
 		return fmt.Errorf("failed to build command: %w", err)
 	}
-	defer os.Remove(outpath)
+	if args.remote == nil {
+		defer os.Remove(outpath)
+	}
 
 	execCommand := make([]string, 0, len(args.execCommand))
 	for _, ec := range args.execCommand {
@@ -343,15 +587,60 @@ func startCmd(ctx context.Context, args *optionArgs) error {
 		}
 	}
 
+	env := slices.Clone(args.env)
+	handoff, address := args.handoff, ""
+	if args.addr != "" {
+		var handoffEnv string
+		if args.remote != nil {
+			handoff, address = HandoffTCP, args.remote.addr()
+			handoffEnv = fmt.Sprintf("%s=%s", defaultTanukiupAddrEnv, args.remote.Listen)
+		} else {
+			var err error
+			address, handoffEnv, err = handoffAddress(args.handoff, fname, args.tempDir)
+			if err != nil {
+				return err
+			}
+		}
+		env = append(env, handoffEnv)
+	}
+	pidFile := outpath + ".pid"
+	if args.remote != nil {
+		execCommand = args.remote.command(execCommand, env, pidFile)
+	}
+
 	slog.InfoContext(ctx, "executing command", slog.Any("command", execCommand))
 	ecmd := exec.CommandContext(ctx, execCommand[0], execCommand[1:]...)
 	ecmd.Dir = args.baseDir
 	ecmd.Stdout = os.Stdout
 	ecmd.Stderr = os.Stderr
+	stop := func() error {
+		// the application in the remote is stopped by the pid file, because killing the wrapper may not stop it
+		if args.remote != nil {
+			args.remote.stop(context.WithoutCancel(ctx), args.baseDir, pidFile, outpath)
+		}
+		return ecmd.Process.Kill()
+	}
+	ecmd.Cancel = stop
+	if len(env) > 0 && args.remote == nil {
+		ecmd.Env = append(os.Environ(), env...)
+	}
 	if args.addr != "" {
-		up := udsPath(fname, args.tempDir)
-		ecmd.Env = append(ecmd.Env, fmt.Sprintf("%s=%s", defaultTanukiupUDSPathEnv, up))
-		waitAndListenProxyServer(ctx, args.addr, args.handlerDir, up, args.catchAllTarget)
+		streamsCtx := ctx
+		if args.streamDrainTimeout > 0 {
+			// the application is stopped after the in-flight streams finish
+			var closeStreams context.CancelFunc
+			streamsCtx, closeStreams = context.WithCancel(context.WithoutCancel(ctx))
+			defer closeStreams()
+			if upstream := proxy.waitAndStart(ctx, args.handlerDir, handoff, address, streamsCtx); upstream != nil {
+				ecmd.Cancel = func() error {
+					upstream.drain(streamsCtx, args.streamDrainTimeout)
+					closeStreams()
+					return stop()
+				}
+			}
+		} else {
+			proxy.waitAndStart(ctx, args.handlerDir, handoff, address, streamsCtx)
+		}
 	}
 
 	if err := ecmd.Run(); err != nil {
@@ -361,9 +650,70 @@ func startCmd(ctx context.Context, args *optionArgs) error {
 	return nil
 }
 
+func runTests(ctx context.Context, args *optionArgs, proxy *devProxy, modified []string) error {
+	command := append(slices.Clone(args.testCommand), testPackages(args.baseDir, args.testChangedOnly, modified)...)
+	if args.remote != nil {
+		command = args.remote.command(command, nil, "")
+	}
+	slog.InfoContext(ctx, "running tests", slog.Any("command", command))
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = args.baseDir
+	output := &bytes.Buffer{}
+	cmd.Stdout = io.MultiWriter(os.Stdout, output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, output)
+	if err := cmd.Run(); err != nil {
+		if proxy != nil && ctx.Err() == nil {
+			proxy.buildFailed("tests failed", output.String())
+		}
+		return fmt.Errorf("failed to run tests: %w", err)
+	}
+	return nil
+}
+
+// testPackages returns the packages to test. They are the directories of the modified Go files relative to baseDir
+// if changedOnly is true and the files are given, otherwise ./...
+func testPackages(baseDir string, changedOnly bool, modified []string) []string {
+	all := []string{"./..."}
+	if !changedOnly {
+		return all
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return all
+	}
+	pkgs := make([]string, 0, len(modified))
+	for _, name := range modified {
+		if filepath.Ext(name) != generateDetectTargetFileExt {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(name))
+		if err != nil {
+			return all
+		}
+		rel, err := filepath.Rel(base, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return all
+		}
+		pkg := "./"
+		if rel != "." {
+			pkg += filepath.ToSlash(rel)
+		}
+		if !slices.Contains(pkgs, pkg) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(pkgs) == 0 {
+		return all
+	}
+	return pkgs
+}
+
 type generatorInfo struct {
 	command []string
 	dir     string
+	order   int
+	// seq is the order of the detection.
+	seq int
 }
 
 func (g *generatorInfo) run(ctx context.Context) error {
@@ -389,8 +739,18 @@ var (
 
 func runGenerator(ctx context.Context) error {
 	enabledGeneratorMutex.RLock()
-	defer enabledGeneratorMutex.RUnlock()
+	generators := make([]*generatorInfo, 0, len(enabledGenerator))
 	for _, generator := range enabledGenerator {
+		generators = append(generators, generator)
+	}
+	enabledGeneratorMutex.RUnlock()
+	slices.SortFunc(generators, func(a, b *generatorInfo) int {
+		if a.order != b.order {
+			return a.order - b.order
+		}
+		return a.seq - b.seq
+	})
+	for _, generator := range generators {
 		if err := generator.run(ctx); err != nil {
 			return fmt.Errorf("failed to search generate: %w", err)
 		}
@@ -401,16 +761,24 @@ func runGenerator(ctx context.Context) error {
 func enableGenerator(ctx context.Context, generator *generatorInfo) {
 	enabledGeneratorMutex.Lock()
 	defer enabledGeneratorMutex.Unlock()
+	if _, ok := enabledGenerator[generator.String()]; ok {
+		return
+	}
+	generator.seq = len(enabledGenerator)
 	enabledGenerator[generator.String()] = generator
 	slog.InfoContext(ctx, "detect generator", slog.String("generator", generator.String()))
 }
 
-func searchGenerate(ctx context.Context, filename string) error {
+func searchGenerate(ctx context.Context, filename string, allowed []AllowedGenerator) error {
 	f, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		select {
@@ -419,148 +787,108 @@ func searchGenerate(ctx context.Context, filename string) error {
 		default:
 		}
 		line := scanner.Text()
-		if strings.HasPrefix(line, "//go:generate go run ") {
-			fields := strings.Fields(line)
-			if len(fields) < 4 {
-				continue
-			}
-			if _, ok := whitelistGenerate[fields[3]]; !ok {
-				continue
-			}
-			enableGenerator(ctx, &generatorInfo{
-				command: fields[1:],
-				dir:     filepath.Dir(filename),
-			})
+		if !strings.HasPrefix(line, "//go:generate ") {
+			continue
 		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		generator, ok := matchGenerator(fields[1:], dir, allowed)
+		if !ok {
+			continue
+		}
+		enableGenerator(ctx, &generatorInfo{
+			command: fields[1:],
+			dir:     filepath.Dir(filename),
+			order:   generator.Order,
+		})
 	}
 
 	return nil
 }
 
-type routePath struct {
-	Method string `json:"method"`
-	Path   string `json:"path"`
-}
-
-var routePathsCommand = []string{"go", "run", "github.com/mackee/tanukirpc/cmd/showpaths"}
-
-func retrievePaths(ctx context.Context, handlerDir string) ([]routePath, error) {
-	buf := &bytes.Buffer{}
-	ecmd := exec.CommandContext(ctx, routePathsCommand[0], append(routePathsCommand[1:], handlerDir)...)
-	ecmd.Stdout = buf
-
-	if err := ecmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to run showpaths: %w", err)
-	}
-	type paths struct {
-		Paths []routePath `json:"paths"`
+// matchGenerator returns the allowed generator of the command of go:generate in dir.
+func matchGenerator(command []string, dir string, allowed []AllowedGenerator) (AllowedGenerator, bool) {
+	name := command[0]
+	if len(command) >= 3 && command[0] == "go" && command[1] == "run" {
+		name, _, _ = strings.Cut(command[2], "@")
 	}
-	var ps paths
-	if err := json.NewDecoder(buf).Decode(&ps); err != nil {
-		return nil, fmt.Errorf("failed to decode json: %w", err)
+	for _, g := range allowed {
+		if g.Command != name {
+			continue
+		}
+		if len(g.Dirs) == 0 {
+			return g, true
+		}
+		for _, d := range g.Dirs {
+			if rel, err := filepath.Rel(d, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				return g, true
+			}
+		}
 	}
+	return AllowedGenerator{}, false
+}
 
-	return ps.Paths, nil
+type routePath struct {
+	Method string
+	Path   string
 }
 
-func udsPath(fname string, tempDir string) string {
-	bd := filepath.Base(fname)
-	return filepath.Join(tempDir, bd+".sock")
+type mountPath struct {
+	Path   string
+	Router bool
 }
 
-func proxyServer(addr string, routePaths []routePath, udsPath string, catchAllTarget string) (*http.Server, error) {
-	transport := &http.Transport{
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return net.Dial("unix", udsPath)
-		},
-	}
-	u, err := url.Parse("http://" + addr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
-	}
-	appProxy := httputil.NewSingleHostReverseProxy(u)
-	appProxy.Transport = transport
+type fallbackPath struct {
+	Kind genclient.FallbackKind
+	Path string
+}
 
-	router := chi.NewRouter()
-	for _, rp := range routePaths {
-		router.Method(rp.Method, rp.Path, appProxy)
-	}
+// appPaths is the routes of the application that are analyzed by genclient.
+type appPaths struct {
+	Paths     []routePath
+	Mounts    []mountPath
+	Fallbacks []fallbackPath
+}
 
-	if catchAllTarget != "" {
-		u2, err := url.Parse(catchAllTarget)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse url: %w", err)
-		}
-		catchAll := httputil.NewSingleHostReverseProxy(u2)
-		router.NotFound(catchAll.ServeHTTP)
+// routes returns the set of the routes like "GET /users/{id}" and the mount points like "MOUNT /static".
+func (ps *appPaths) routes() map[string]struct{} {
+	routes := make(map[string]struct{}, len(ps.Paths)+len(ps.Mounts))
+	for _, rp := range ps.Paths {
+		routes[rp.Method+" "+rp.Path] = struct{}{}
 	}
-
-	server := &http.Server{
-		Addr:    addr,
-		Handler: router,
+	for _, mp := range ps.Mounts {
+		routes["MOUNT "+mp.Path] = struct{}{}
 	}
-
-	return server, nil
+	return routes
 }
 
-func tryLaunchProxyServer(ctx context.Context, server *http.Server, udsPath string) {
-	watcher, err := fsnotify.NewWatcher()
+func retrievePaths(ctx context.Context, handlerDir string) (*appPaths, error) {
+	result, err := genclient.Load(ctx, handlerDir)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create watcher", slog.Any("error", err))
-		return
+		return nil, fmt.Errorf("failed to analyze routes: %w", err)
 	}
-
-	dir := filepath.Dir(udsPath)
-	slog.InfoContext(ctx, "watching directory", slog.String("directory", dir))
-	if err := watcher.Add(dir + "/"); err != nil {
-		defer watcher.Close()
-		slog.ErrorContext(ctx, "failed to add directory to watcher", slog.Any("error", err))
-		return
+	for _, d := range result.Diagnostics {
+		slog.WarnContext(ctx, "route analysis", slog.String("diagnostic", d.String()))
 	}
-	go func() {
-		defer watcher.Close()
-	OUTER:
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case event, ok := <-watcher.Events:
-				if !ok {
-					slog.InfoContext(ctx, "watcher is closed")
-					return
-				}
-				if event.Name == udsPath {
-					break OUTER
-				}
-			}
-		}
-		watcher.Close()
 
-		go func() {
-			<-ctx.Done()
-			sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if err := server.Shutdown(sctx); err != nil {
-				slog.ErrorContext(ctx, "failed to shutdown server", slog.Any("error", err))
-			}
-		}()
-		slog.Info("staring proxy server", slog.String("addr", server.Addr))
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.ErrorContext(ctx, "failed to listen and serve", slog.Any("error", err))
+	var ps appPaths
+	for _, rp := range result.RoutePaths {
+		ps.Paths = append(ps.Paths, routePath{Method: rp.Method(), Path: rp.Path()})
+	}
+	// pprof handlers accept GET and POST (symbol lookup)
+	for _, prefix := range result.DebugEndpointsPrefixes {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			ps.Paths = append(ps.Paths, routePath{Method: method, Path: prefix + "/*"})
 		}
-	}()
-}
-
-func waitAndListenProxyServer(ctx context.Context, addr string, handlerDir string, up string, catchAllTarget string) {
-	rps, err := retrievePaths(ctx, handlerDir)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to retrieve paths", slog.Any("error", err))
-		return
 	}
-	server, err := proxyServer(addr, rps, up, catchAllTarget)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to create proxy server", slog.Any("error", err))
-		return
+	for _, mp := range result.MountPaths {
+		ps.Mounts = append(ps.Mounts, mountPath{Path: mp.Path(), Router: mp.IsRouter()})
+	}
+	for _, fh := range result.FallbackHandlers {
+		ps.Fallbacks = append(ps.Fallbacks, fallbackPath{Kind: fh.Kind(), Path: fh.Path()})
 	}
-	tryLaunchProxyServer(ctx, server, up)
+
+	return &ps, nil
 }
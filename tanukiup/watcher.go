@@ -0,0 +1,202 @@
+package tanukiup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatcherKind is the kind of the file watcher.
+type WatcherKind string
+
+const (
+	// WatcherAuto uses fsnotify, and falls back to polling if fsnotify is not available on the platform.
+	WatcherAuto WatcherKind = "auto"
+	// WatcherFSNotify uses the file system notifications like inotify.
+	WatcherFSNotify WatcherKind = "fsnotify"
+	// WatcherPoll polls the modification times of the files. It works on the network file systems
+	// and the bind mounts of the containers that don't propagate the notifications.
+	WatcherPoll WatcherKind = "poll"
+
+	defaultPollInterval = 500 * time.Millisecond
+)
+
+// fileWatcher watches the files in the added directories. The subdirectories are not watched.
+type fileWatcher interface {
+	Add(dir string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+}
+
+func newFileWatcher(ctx context.Context, kind WatcherKind, pollInterval time.Duration) (fileWatcher, error) {
+	switch kind {
+	case WatcherPoll:
+		return newPollingWatcher(pollInterval), nil
+	case WatcherFSNotify, WatcherAuto, "":
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			return &fsnotifyWatcher{watcher: watcher}, nil
+		}
+		if kind == WatcherFSNotify {
+			return nil, fmt.Errorf("failed to create watcher: %w", err)
+		}
+		slog.WarnContext(ctx, "fsnotify is not available, falling back to polling", slog.Any("error", err))
+		return newPollingWatcher(pollInterval), nil
+	default:
+		return nil, fmt.Errorf("unknown watcher: %s", kind)
+	}
+}
+
+type fsnotifyWatcher struct {
+	watcher *fsnotify.Watcher
+}
+
+func (w *fsnotifyWatcher) Add(dir string) error {
+	return w.watcher.Add(dir)
+}
+
+func (w *fsnotifyWatcher) Events() <-chan fsnotify.Event {
+	return w.watcher.Events
+}
+
+func (w *fsnotifyWatcher) Errors() <-chan error {
+	return w.watcher.Errors
+}
+
+func (w *fsnotifyWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollingWatcher compares the modification times and the sizes of the files in the directories at every interval.
+type pollingWatcher struct {
+	interval time.Duration
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+	start    sync.Once
+	close    sync.Once
+
+	mu    sync.Mutex
+	files map[string]map[string]fileState
+}
+
+func newPollingWatcher(interval time.Duration) *pollingWatcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &pollingWatcher{
+		interval: interval,
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+		files:    map[string]map[string]fileState{},
+	}
+}
+
+func (w *pollingWatcher) Add(dir string) error {
+	files, err := scanDir(dir)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.files[filepath.Clean(dir)] = files
+	w.mu.Unlock()
+	w.start.Do(func() { go w.run() })
+	return nil
+}
+
+func (w *pollingWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+func (w *pollingWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *pollingWatcher) Close() error {
+	w.close.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *pollingWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	defer close(w.events)
+	defer close(w.errors)
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		for _, event := range w.poll() {
+			select {
+			case <-w.done:
+				return
+			case w.events <- event:
+			}
+		}
+	}
+}
+
+// poll returns the events of the changes since the last poll.
+func (w *pollingWatcher) poll() []fsnotify.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []fsnotify.Event
+	for dir, prev := range w.files {
+		files, err := scanDir(dir)
+		if err != nil {
+			// the directory is removed
+			files = map[string]fileState{}
+		}
+		for name, state := range files {
+			before, ok := prev[name]
+			switch {
+			case !ok:
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Create})
+			case before != state:
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Write})
+			}
+		}
+		for name := range prev {
+			if _, ok := files[name]; !ok {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			}
+		}
+		w.files[dir] = files
+	}
+	return events
+}
+
+func scanDir(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	files := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// the file is removed after reading the directory
+			continue
+		}
+		files[filepath.Join(dir, entry.Name())] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return files, nil
+}
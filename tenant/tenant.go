@@ -0,0 +1,162 @@
+// Package tenant resolves the tenant of the request and scopes the routes to it.
+//
+// NewTransformer is the Transformer for RouteWithTransformer that resolves the tenant ID by the Resolver,
+// like the subdomain, the header or the path param, and derives the registry of the tenant from it:
+//
+//	tr := tenant.NewTransformer(tenant.FromPathParam("tenant"), func(ctx tanukirpc.Context[*registry], id string) (*tenantRegistry, error) {
+//		return ctx.Registry().forTenant(id)
+//	}, tenant.WithRateLimit(100, time.Second))
+//	tanukirpc.RouteWithTransformer(r, tr, "/tenants/{tenant}", func(r *tanukirpc.Router[*tenantRegistry]) {
+//		r.Get("/tasks", tanukirpc.NewHandler(listTasks))
+//	})
+//
+// The tenant ID is added to the log attributes of the request as "tenant".
+// The analyzer of genclient recognizes the Resolver of NewTransformer, so the header of FromHeader is documented
+// as the required parameter of the routes, and the path param of FromPathParam is checked to be in the route pattern.
+package tenant
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mackee/tanukirpc"
+)
+
+var (
+	// ErrMissingTenant is returned when the Resolver does not find the tenant of the request. It is reported with 400 Bad Request.
+	ErrMissingTenant = errors.New("missing tenant")
+	// ErrRateLimited is returned when the tenant exceeds the rate limit. It is reported with 429 Too Many Requests.
+	ErrRateLimited = errors.New("tenant rate limit exceeded")
+)
+
+// Resolver returns the tenant ID of the request. It returns the empty string if the request has no tenant.
+type Resolver func(req *http.Request) (string, error)
+
+// FromHeader returns the Resolver that reads the tenant ID from the header, like "X-Tenant-ID".
+func FromHeader(name string) Resolver {
+	return func(req *http.Request) (string, error) {
+		return req.Header.Get(name), nil
+	}
+}
+
+// FromSubdomain returns the Resolver that reads the tenant ID from the subdomain of baseDomain,
+// like "acme" of "acme.example.com". The hosts that are not the direct subdomains of baseDomain have no tenant.
+func FromSubdomain(baseDomain string) Resolver {
+	suffix := "." + strings.TrimPrefix(baseDomain, ".")
+	return func(req *http.Request) (string, error) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		sub, ok := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !ok || strings.Contains(sub, ".") {
+			return "", nil
+		}
+		return sub, nil
+	}
+}
+
+// FromPathParam returns the Resolver that reads the tenant ID from the path param of the route pattern, like "{tenant}" of "/tenants/{tenant}".
+func FromPathParam(name string) Resolver {
+	return func(req *http.Request) (string, error) {
+		return chi.URLParam(req, name), nil
+	}
+}
+
+type options struct {
+	limit int
+	per   time.Duration
+}
+
+// Option is the option of NewTransformer.
+type Option func(*options)
+
+// WithRateLimit limits the requests of each tenant to limit per the duration, like 100 per second.
+// The requests over the limit are rejected with 429 Too Many Requests and the Retry-After header before the registry is derived.
+// The limit is counted in the memory of the process.
+func WithRateLimit(limit int, per time.Duration) Option {
+	return func(o *options) {
+		o.limit = limit
+		o.per = per
+	}
+}
+
+// NewTransformer returns the Transformer that resolves the tenant ID by resolve and derives the registry of the tenant by derive.
+// The request that has no tenant is rejected by ErrMissingTenant. The errors of derive are handled by the ErrorHooker as is,
+// so derive can return the error wrapped by WrapErrorWithStatus with 404 Not Found for the unknown tenants.
+func NewTransformer[Reg1 any, Reg2 any](resolve Resolver, derive func(ctx tanukirpc.Context[Reg1], tenantID string) (Reg2, error), opts ...Option) tanukirpc.Transformer[Reg1, Reg2] {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var limiter *limiter
+	if o.limit > 0 && o.per > 0 {
+		limiter = newLimiter(o.limit, o.per)
+	}
+
+	return tanukirpc.NewTransformer(func(ctx tanukirpc.Context[Reg1]) (Reg2, error) {
+		var zeroReg2 Reg2
+		id, err := resolve(ctx.Request())
+		if err != nil {
+			return zeroReg2, err
+		}
+		if id == "" {
+			return zeroReg2, tanukirpc.WrapErrorWithStatus(http.StatusBadRequest, ErrMissingTenant)
+		}
+		tanukirpc.AddLogAttr(ctx, slog.String("tenant", id))
+
+		if limiter != nil {
+			if wait, ok := limiter.allow(id, time.Now()); !ok {
+				ctx.Response().Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+				return zeroReg2, tanukirpc.WrapErrorWithStatus(http.StatusTooManyRequests, ErrRateLimited)
+			}
+		}
+		return derive(ctx, id)
+	})
+}
+
+// limiter is the token bucket per tenant.
+type limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	interval time.Duration
+	buckets  map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(limit int, per time.Duration) *limiter {
+	return &limiter{
+		capacity: float64(limit),
+		interval: per / time.Duration(limit),
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// allow takes the token of the tenant. It returns the duration until the next token if the bucket is empty.
+func (l *limiter) allow(id string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[id] = b
+	}
+	b.tokens = min(l.capacity, b.tokens+float64(now.Sub(b.last))/float64(l.interval))
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(l.interval)), false
+	}
+	b.tokens--
+	return 0, true
+}
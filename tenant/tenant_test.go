@@ -0,0 +1,145 @@
+package tenant_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantRegistry struct {
+	id string
+}
+
+type tenantResponse struct {
+	Tenant string `json:"tenant"`
+}
+
+func tenantHandler(ctx tanukirpc.Context[*tenantRegistry], req struct{}) (*tenantResponse, error) {
+	return &tenantResponse{Tenant: ctx.Registry().id}, nil
+}
+
+func derive(ctx tanukirpc.Context[struct{}], id string) (*tenantRegistry, error) {
+	if id == "unknown" {
+		return nil, tanukirpc.WrapErrorWithStatus(http.StatusNotFound, errors.New("tenant not found"))
+	}
+	return &tenantRegistry{id: id}, nil
+}
+
+func TestNewTransformer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		resolver tenant.Resolver
+		pattern  string
+		path     string
+		setup    func(req *http.Request)
+		status   int
+		expected string
+	}{
+		{
+			name:     "header",
+			resolver: tenant.FromHeader("X-Tenant-ID"),
+			pattern:  "/",
+			path:     "/tasks",
+			setup:    func(req *http.Request) { req.Header.Set("X-Tenant-ID", "acme") },
+			status:   http.StatusOK,
+			expected: `{"tenant":"acme"}`,
+		},
+		{
+			name:     "subdomain",
+			resolver: tenant.FromSubdomain("example.com"),
+			pattern:  "/",
+			path:     "/tasks",
+			setup:    func(req *http.Request) { req.Host = "acme.example.com:8080" },
+			status:   http.StatusOK,
+			expected: `{"tenant":"acme"}`,
+		},
+		{
+			name:     "nested subdomain",
+			resolver: tenant.FromSubdomain("example.com"),
+			pattern:  "/",
+			path:     "/tasks",
+			setup:    func(req *http.Request) { req.Host = "www.acme.example.com" },
+			status:   http.StatusBadRequest,
+			expected: `{"error":{"message":"missing tenant"}}`,
+		},
+		{
+			name:     "path param",
+			resolver: tenant.FromPathParam("tenant"),
+			pattern:  "/tenants/{tenant}",
+			path:     "/tenants/acme/tasks",
+			status:   http.StatusOK,
+			expected: `{"tenant":"acme"}`,
+		},
+		{
+			name:     "missing tenant",
+			resolver: tenant.FromHeader("X-Tenant-ID"),
+			pattern:  "/",
+			path:     "/tasks",
+			status:   http.StatusBadRequest,
+			expected: `{"error":{"message":"missing tenant"}}`,
+		},
+		{
+			name:     "unknown tenant",
+			resolver: tenant.FromPathParam("tenant"),
+			pattern:  "/tenants/{tenant}",
+			path:     "/tenants/unknown/tasks",
+			status:   http.StatusNotFound,
+			expected: `{"error":{"message":"tenant not found"}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := tanukirpc.NewRouter(struct{}{})
+			tanukirpc.RouteWithTransformer(router, tenant.NewTransformer(tc.resolver, derive), tc.pattern, func(r *tanukirpc.Router[*tenantRegistry]) {
+				r.Get("/tasks", tanukirpc.NewHandler(tenantHandler))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept", "application/json")
+			if tc.setup != nil {
+				tc.setup(req)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+			assert.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := tanukirpc.NewLogger(slog.New(slog.NewJSONHandler(buf, nil)), nil)
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](logger))
+	tr := tenant.NewTransformer(tenant.FromHeader("X-Tenant-ID"), derive, tenant.WithRateLimit(1, time.Hour))
+	tanukirpc.RouteWithTransformer(router, tr, "/", func(r *tanukirpc.Router[*tenantRegistry]) {
+		r.Get("/tasks", tanukirpc.NewHandler(tenantHandler))
+	})
+
+	do := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Tenant-ID", id)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, do("acme").Code)
+	limited := do("acme")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "3600", limited.Header().Get("Retry-After"))
+	// the limit is counted per tenant
+	assert.Equal(t, http.StatusOK, do("globex").Code)
+
+	assert.Contains(t, buf.String(), `"tenant":"acme"`)
+	assert.Contains(t, buf.String(), `"tenant":"globex"`)
+}
@@ -0,0 +1,162 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestContext is the Context for unit testing of the handlers and the transformers without the Router.
+type TestContext[Reg any] struct {
+	context[Reg]
+}
+
+// NewTestContext creates a new TestContext with the registry, the request and the response writer.
+func NewTestContext[Reg any](reg Reg, req *http.Request, w http.ResponseWriter) *TestContext[Reg] {
+	return &TestContext[Reg]{
+		context: context[Reg]{
+			Context:  req.Context(),
+			req:      req,
+			res:      w,
+			registry: reg,
+		},
+	}
+}
+
+// Deferred returns the callers of the functions that are registered by Defer with the timing and are not done yet.
+// The callers are ordered by the registration.
+func (c *TestContext[Reg]) Deferred(timing DeferDoTiming) []*DeferFuncCallerStack {
+	ds, ok := c.deferStackMap[timing]
+	if !ok {
+		return nil
+	}
+	callers := make([]*DeferFuncCallerStack, 0, len(*ds))
+	for _, da := range *ds {
+		callers = append(callers, da.caller)
+	}
+	return callers
+}
+
+// DeferDoAll runs the deferred functions in the same order as the Router, DeferDoTimingBeforeResponse and then DeferDoTimingAfterResponse.
+// The functions registered by DeferWithResult are called last with the result of the successful request.
+func (c *TestContext[Reg]) DeferDoAll() error {
+	if err := c.DeferDo(DeferDoTimingBeforeResponse); err != nil {
+		return err
+	}
+	if err := c.DeferDo(DeferDoTimingAfterResponse); err != nil {
+		return err
+	}
+	return c.DeferDoWithResult(http.StatusOK, nil)
+}
+
+// DeferDoWithResult calls the functions registered by DeferWithResult with the status and the error of the request.
+func (c *TestContext[Reg]) DeferDoWithResult(status int, err error) error {
+	return c.deferDoWithResult(status, err, false, nil)
+}
+
+// Recorder returns the response writer as *httptest.ResponseRecorder.
+// It returns nil if the TestContext is created with the other response writer.
+func (c *TestContext[Reg]) Recorder() *httptest.ResponseRecorder {
+	rec, _ := c.res.(*httptest.ResponseRecorder)
+	return rec
+}
+
+// TestContextBuilder builds the TestContext with the request that has the URL parameters, the query and the body.
+type TestContextBuilder[Reg any] struct {
+	reg         Reg
+	method      string
+	path        string
+	header      http.Header
+	query       url.Values
+	urlParams   *chi.Context
+	body        io.Reader
+	w           http.ResponseWriter
+	baseContext gocontext.Context
+}
+
+// NewTestContextBuilder returns a new TestContextBuilder. By default, the request is GET / and the response writer is *httptest.ResponseRecorder.
+func NewTestContextBuilder[Reg any](reg Reg) *TestContextBuilder[Reg] {
+	return &TestContextBuilder[Reg]{
+		reg:       reg,
+		method:    http.MethodGet,
+		path:      "/",
+		header:    http.Header{},
+		query:     url.Values{},
+		urlParams: chi.NewRouteContext(),
+	}
+}
+
+// Request sets the method and the path of the request.
+func (b *TestContextBuilder[Reg]) Request(method, path string) *TestContextBuilder[Reg] {
+	b.method = method
+	b.path = path
+	return b
+}
+
+// URLParam adds the URL parameter that is read by URLParam and the urlparam struct tags.
+func (b *TestContextBuilder[Reg]) URLParam(key, value string) *TestContextBuilder[Reg] {
+	b.urlParams.URLParams.Add(key, value)
+	return b
+}
+
+// Query adds the query parameter.
+func (b *TestContextBuilder[Reg]) Query(key, value string) *TestContextBuilder[Reg] {
+	b.query.Add(key, value)
+	return b
+}
+
+// Header adds the request header.
+func (b *TestContextBuilder[Reg]) Header(key, value string) *TestContextBuilder[Reg] {
+	b.header.Add(key, value)
+	return b
+}
+
+// Body sets the request body with the content type.
+func (b *TestContextBuilder[Reg]) Body(contentType string, body io.Reader) *TestContextBuilder[Reg] {
+	b.header.Set("Content-Type", contentType)
+	b.body = body
+	return b
+}
+
+// ResponseWriter sets the response writer instead of *httptest.ResponseRecorder.
+func (b *TestContextBuilder[Reg]) ResponseWriter(w http.ResponseWriter) *TestContextBuilder[Reg] {
+	b.w = w
+	return b
+}
+
+// Context sets the base context of the request such as the context with the deadline.
+func (b *TestContextBuilder[Reg]) Context(ctx gocontext.Context) *TestContextBuilder[Reg] {
+	b.baseContext = ctx
+	return b
+}
+
+// Build builds the TestContext.
+func (b *TestContextBuilder[Reg]) Build() *TestContext[Reg] {
+	target := b.path
+	if len(b.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + b.query.Encode()
+	}
+	req := httptest.NewRequest(b.method, target, b.body)
+	if b.baseContext != nil {
+		req = req.WithContext(b.baseContext)
+	}
+	for key, values := range b.header {
+		req.Header[key] = values
+	}
+	req = req.WithContext(gocontext.WithValue(req.Context(), chi.RouteCtxKey, b.urlParams))
+
+	w := b.w
+	if w == nil {
+		w = httptest.NewRecorder()
+	}
+	return NewTestContext(b.reg, req, w)
+}
@@ -0,0 +1,52 @@
+package tanukirpc_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestContext(t *testing.T) {
+	type registry struct {
+		closed []string
+	}
+	handler := func(ctx tanukirpc.Context[*registry], req struct{}) (string, error) {
+		ctx.Defer(func() error {
+			ctx.Registry().closed = append(ctx.Registry().closed, "after")
+			return nil
+		})
+		ctx.Defer(func() error {
+			ctx.Registry().closed = append(ctx.Registry().closed, "before")
+			return nil
+		}, tanukirpc.DeferDoTimingBeforeResponse)
+		name := tanukirpc.URLParam(ctx, "name")
+		if name == "" {
+			return "", errors.New("name is required")
+		}
+		return name + ":" + ctx.Request().URL.Query().Get("lang") + ":" + ctx.Request().Header.Get("Content-Type"), nil
+	}
+
+	reg := &registry{}
+	ctx := tanukirpc.NewTestContextBuilder(reg).
+		Request(http.MethodPost, "/hello").
+		URLParam("name", "tanuki").
+		Query("lang", "ja").
+		Body("application/json", strings.NewReader(`{}`)).
+		Build()
+
+	res, err := handler(ctx, struct{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "tanuki:ja:application/json", res)
+	assert.Len(t, ctx.Deferred(tanukirpc.DeferDoTimingBeforeResponse), 1)
+	assert.Len(t, ctx.Deferred(tanukirpc.DeferDoTimingAfterResponse), 1)
+	assert.NotNil(t, ctx.Recorder())
+
+	require.NoError(t, ctx.DeferDoAll())
+	assert.Equal(t, []string{"before", "after"}, reg.closed)
+	assert.Empty(t, ctx.Deferred(tanukirpc.DeferDoTimingAfterResponse))
+}
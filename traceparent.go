@@ -0,0 +1,127 @@
+package tanukirpc
+
+import (
+	gocontext "context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// TraceContext is the W3C Trace Context of the request. It is parsed from the traceparent and tracestate headers by the default middleware,
+// or started by the Router if the request does not have them, so the logs can be correlated across the services without OpenTelemetry.
+type TraceContext struct {
+	// TraceID is the ID of the whole trace as 32 lowercase hex characters.
+	TraceID string
+	// SpanID is the ID of the span of the request in this service as 16 lowercase hex characters.
+	SpanID string
+	// ParentID is the span ID of the caller. It is empty if the trace is started by this service.
+	ParentID string
+	// Flags is the trace-flags. The least significant bit is the sampled flag.
+	Flags byte
+	// State is the value of the tracestate header that is propagated as is.
+	State string
+}
+
+// Sampled reports whether the caller records the trace.
+func (t *TraceContext) Sampled() bool {
+	return t.Flags&0x01 != 0
+}
+
+// Traceparent returns the value of the traceparent header for the outbound requests, whose parent is the span of this request.
+func (t *TraceContext) Traceparent() string {
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// Inject sets the traceparent and tracestate headers of the outbound request to propagate the trace to the upstream.
+func (t *TraceContext) Inject(h http.Header) {
+	h.Set(traceparentHeader, t.Traceparent())
+	if t.State != "" {
+		h.Set(tracestateHeader, t.State)
+	} else {
+		h.Del(tracestateHeader)
+	}
+}
+
+type traceContextKey struct{}
+
+// TraceContextFromContext returns the TraceContext of the request.
+func TraceContextFromContext(ctx gocontext.Context) (*TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(*TraceContext)
+	return tc, ok
+}
+
+// traceContextMiddleware continues the trace of the traceparent header with the new span, or starts the new trace.
+// The trace ID and the span ID are added to the log attributes, so they appear in the access log.
+func traceContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tc, err := parseTraceparent(req.Header.Get(traceparentHeader))
+		if err != nil {
+			tc = &TraceContext{TraceID: newTraceID()}
+		} else {
+			tc.State = strings.TrimSpace(strings.Join(req.Header.Values(tracestateHeader), ","))
+		}
+		tc.SpanID = newSpanID()
+
+		ctx := gocontext.WithValue(req.Context(), traceContextKey{}, tc)
+		AddLogAttr(ctx, slog.String("trace_id", tc.TraceID), slog.String("span_id", tc.SpanID))
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// parseTraceparent parses the traceparent header like "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+// The headers of the future versions are parsed by the fields of the version 00.
+func parseTraceparent(v string) (*TraceContext, error) {
+	v = strings.TrimSpace(v)
+	if len(v) < 55 || (len(v) > 55 && (v[:2] == "00" || v[55] != '-')) {
+		return nil, errInvalidTraceparent
+	}
+	version, traceID, parentID, flags := v[0:2], v[3:35], v[36:52], v[53:55]
+	if v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return nil, errInvalidTraceparent
+	}
+	if !isLowerHex(version) || version == "ff" || !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) {
+		return nil, errInvalidTraceparent
+	}
+	if traceID == strings.Repeat("0", 32) || parentID == strings.Repeat("0", 16) {
+		return nil, errInvalidTraceparent
+	}
+	f, _ := hex.DecodeString(flags)
+	return &TraceContext{TraceID: traceID, ParentID: parentID, Flags: f[0]}, nil
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newTraceID() string {
+	var b [16]byte
+	for b == [16]byte{} {
+		binary.BigEndian.PutUint64(b[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(b[8:], rand.Uint64())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func newSpanID() string {
+	var b [8]byte
+	for b == [8]byte{} {
+		binary.BigEndian.PutUint64(b[:], rand.Uint64())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,86 @@
+package tanukirpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type traceResponse struct {
+	TraceID     string `json:"trace_id"`
+	ParentID    string `json:"parent_id"`
+	Traceparent string `json:"traceparent"`
+	State       string `json:"state"`
+}
+
+func TestTraceContext(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := tanukirpc.NewLogger(slog.New(slog.NewJSONHandler(buf, nil)), nil)
+	router := tanukirpc.NewRouter(struct{}{}, tanukirpc.WithLogger[struct{}](logger))
+	router.Get("/trace", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*traceResponse, error) {
+		tc, ok := tanukirpc.TraceContextFromContext(ctx)
+		if !ok {
+			return &traceResponse{}, nil
+		}
+		return &traceResponse{TraceID: tc.TraceID, ParentID: tc.ParentID, Traceparent: tc.Traceparent(), State: tc.State}, nil
+	}))
+
+	do := func(traceparent, tracestate string) *traceResponse {
+		req := httptest.NewRequest(http.MethodGet, "/trace", nil)
+		req.Header.Set("Accept", "application/json")
+		if traceparent != "" {
+			req.Header.Set("traceparent", traceparent)
+		}
+		if tracestate != "" {
+			req.Header.Set("tracestate", tracestate)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res traceResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return &res
+	}
+
+	t.Run("continue the trace", func(t *testing.T) {
+		buf.Reset()
+		res := do("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=abc")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", res.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", res.ParentID)
+		assert.Equal(t, "vendor=abc", res.State)
+		assert.Regexp(t, `^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$`, res.Traceparent)
+		assert.NotContains(t, res.Traceparent, "00f067aa0ba902b7")
+		assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+		assert.Contains(t, buf.String(), `"span_id":"`+res.Traceparent[36:52]+`"`)
+	})
+	for name, traceparent := range map[string]string{
+		"no traceparent":   "",
+		"invalid":          "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"zero trace ID":    "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"uppercase":        "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"invalid version":  "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"extra of version": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		t.Run(name, func(t *testing.T) {
+			res := do(traceparent, "vendor=abc")
+			assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), res.TraceID)
+			assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", res.TraceID)
+			assert.Empty(t, res.ParentID)
+			assert.Empty(t, res.State)
+			assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-00$`, res.Traceparent)
+		})
+	}
+	t.Run("future version", func(t *testing.T) {
+		res := do("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", res.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", res.ParentID)
+	})
+}
@@ -0,0 +1,271 @@
+package tanukirpc
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// ResponseTypeValidator is implemented by the Codec that can determine by the response type whether it fails to encode the response.
+// Router.Validate reports the error of the codec of the route.
+type ResponseTypeValidator interface {
+	ValidateResponseType(t reflect.Type) error
+}
+
+func (c CodecList) ValidateResponseType(t reflect.Type) error {
+	var errs []error
+	for _, codec := range c {
+		if v, ok := codec.(ResponseTypeValidator); ok {
+			if err := v.ValidateResponseType(t); err != nil {
+				errs = append(errs, fmt.Errorf("codec %s: %w", codec.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *codec) ValidateResponseType(t reflect.Type) error {
+	if c.encoderFunc == nil || t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Interface || isRawResponse(reflect.Zero(t).Interface()) {
+		return nil
+	}
+	switch seqKind(t) {
+	case 1, 2:
+		return validateJSONType(t.In(0).In(0), map[reflect.Type]struct{}{})
+	}
+	return validateJSONType(t, map[reflect.Type]struct{}{})
+}
+
+// validateJSONType returns the error if encoding/json fails to encode the values of t.
+func validateJSONType(t reflect.Type, visited map[reflect.Type]struct{}) error {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Errorf("unsupported type: %s", t)
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return validateJSONType(t.Elem(), visited)
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return fmt.Errorf("unsupported map key type: %s", t.Key())
+			}
+		}
+		return validateJSONType(t.Elem(), visited)
+	case reflect.Struct:
+		if _, ok := visited[t]; ok {
+			return nil
+		}
+		visited[t] = struct{}{}
+		for i := 0; i < t.NumField(); i++ {
+			ft := t.Field(i)
+			if !ft.IsExported() && (!ft.Anonymous || indirectType(ft.Type).Kind() != reflect.Struct) {
+				continue
+			}
+			if ft.Tag.Get("json") == "-" {
+				continue
+			}
+			if err := validateJSONType(ft.Type, visited); err != nil {
+				return fmt.Errorf("field %s of %s: %w", ft.Name, t, err)
+			}
+		}
+	}
+	return nil
+}
+
+// nilHandler is implemented by the Handlers that can report whether they have no function to call.
+type nilHandler interface {
+	isNil() bool
+}
+
+func isNilHandler[Reg any](h Handler[Reg]) bool {
+	if h == nil {
+		return true
+	}
+	n, ok := h.(nilHandler)
+	return ok && n.isNil()
+}
+
+// routeValidator is implemented by Router to validate the routes of the Routers that are mounted by Mount.
+type routeValidator interface {
+	validateRoutes(prefix string) []error
+}
+
+// routeRegistrations records the routes that are registered through the Router and the Routers derived from it, for Validate.
+type routeRegistrations struct {
+	mu     sync.Mutex
+	routes []*registeredRoute
+	seen   map[string]struct{}
+	mounts []*mountedRouter
+}
+
+type registeredRoute struct {
+	method  string
+	pattern string
+	meta    *HandlerMetadata
+	codec   Codec
+	err     error
+}
+
+type mountedRouter struct {
+	pattern string
+	router  routeValidator
+}
+
+func newRouteRegistrations() *routeRegistrations {
+	return &routeRegistrations{seen: map[string]struct{}{}}
+}
+
+func (rs *routeRegistrations) add(rr *registeredRoute) {
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	// the nil handlers are not registered, so they do not overwrite the others
+	if rr.err == nil {
+		key := rr.method + " " + rr.pattern
+		if _, ok := rs.seen[key]; ok {
+			rr.err = errors.New("handler is registered more than once, and the previous one is overwritten")
+		}
+		rs.seen[key] = struct{}{}
+	}
+	rs.routes = append(rs.routes, rr)
+}
+
+func (rs *routeRegistrations) has(method, pattern string) bool {
+	if rs == nil {
+		return false
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	_, ok := rs.seen[method+" "+pattern]
+	return ok
+}
+
+func (rs *routeRegistrations) mount(pattern string, router routeValidator) {
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.mounts = append(rs.mounts, &mountedRouter{pattern: pattern, router: router})
+}
+
+func (rs *routeRegistrations) validate(prefix string) []error {
+	if rs == nil {
+		return nil
+	}
+	rs.mu.Lock()
+	routes := rs.routes
+	mounts := rs.mounts
+	rs.mu.Unlock()
+
+	var errs []error
+	for _, rr := range routes {
+		pattern := prefix + rr.pattern
+		if rr.err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", rr.method, pattern, rr.err))
+			continue
+		}
+		if rr.meta == nil {
+			continue
+		}
+		params := routeParams(pattern)
+		for _, name := range requiredURLParams(rr.meta.Request, nil, map[reflect.Type]struct{}{}) {
+			if _, ok := params[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s %s: urlparam %q of the request %s is not in the pattern", rr.method, pattern, name, rr.meta.Request))
+			}
+		}
+		if v, ok := rr.codec.(ResponseTypeValidator); ok {
+			if err := v.ValidateResponseType(rr.meta.Response); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: failed to encode the response %s: %w", rr.method, pattern, rr.meta.Response, err))
+			}
+		}
+	}
+	for _, m := range mounts {
+		errs = append(errs, m.router.validateRoutes(prefix+strings.TrimSuffix(m.pattern, "/"))...)
+	}
+	return errs
+}
+
+// routeParams returns the names of the URL parameters of the pattern like /tasks/{id}, /tasks/{id:[0-9]+} and /files/*.
+func routeParams(pattern string) map[string]struct{} {
+	params := map[string]struct{}{}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			params["*"] = struct{}{}
+		case '{':
+			end, depth := i+1, 1
+			for ; end < len(pattern); end++ {
+				if pattern[end] == '{' {
+					depth++
+				} else if pattern[end] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			name, _, _ := strings.Cut(pattern[i+1:end], ":")
+			params[name] = struct{}{}
+			i = end
+		}
+	}
+	return params
+}
+
+// requiredURLParams returns the names of the urlparam tags of the request type and the nested structs.
+// The pointer fields are optional, so they are excluded.
+func requiredURLParams(t reflect.Type, names []string, visited map[reflect.Type]struct{}) []string {
+	if t == nil {
+		return names
+	}
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	if _, ok := visited[t]; ok {
+		return names
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		param, _, _ := strings.Cut(ft.Tag.Get("urlparam"), ",")
+		switch {
+		case param == "":
+			if ft.IsExported() || ft.Anonymous {
+				names = requiredURLParams(ft.Type, names, visited)
+			}
+		case param == "-" || !ft.IsExported() || ft.Type.Kind() == reflect.Pointer:
+		default:
+			names = append(names, param)
+		}
+	}
+	return names
+}
+
+// Validate checks the routes that are registered through the Router, the Routers derived from it by With, Route and RouteWithTransformer,
+// and the Routers that are mounted by Mount. It reports the routes that are registered more than once, the nil handlers,
+// the urlparam tags of the requests that are not in the patterns, and the responses that the codecs can not encode,
+// so the mistakes are found at the startup rather than at the first request. ListenAndServe calls it before it starts.
+func (r *Router[Reg]) Validate() error {
+	return errors.Join(r.validateRoutes("")...)
+}
+
+func (r *Router[Reg]) validateRoutes(prefix string) []error {
+	return r.registrations.validate(prefix)
+}
@@ -0,0 +1,141 @@
+package tanukirpc_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateTaskRequest struct {
+	ID string `urlparam:"id"`
+}
+
+type validateTaskResponse struct {
+	ID string `json:"id"`
+}
+
+type validateProjectTaskRequest struct {
+	ProjectID string  `urlparam:"projectID"`
+	TaskID    string  `urlparam:"taskID"`
+	Cursor    *string `urlparam:"cursor"`
+}
+
+type validateStreamResponse struct {
+	Updates chan string `json:"updates"`
+}
+
+func validateGetTask(ctx tanukirpc.Context[struct{}], req validateTaskRequest) (*validateTaskResponse, error) {
+	return &validateTaskResponse{ID: req.ID}, nil
+}
+
+func validateGetProjectTask(ctx tanukirpc.Context[struct{}], req validateProjectTaskRequest) (*validateTaskResponse, error) {
+	return &validateTaskResponse{ID: req.TaskID}, nil
+}
+
+func validateCreateProject(ctx tanukirpc.Context[struct{}], req struct{}) (*validateTaskResponse, error) {
+	return &validateTaskResponse{}, nil
+}
+
+func TestRouterValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/tasks/{id}", tanukirpc.NewHandler(validateGetTask))
+		r.Route("/projects/{projectID}", func(r *tanukirpc.Router[struct{}]) {
+			r.Get("/tasks/{taskID:[0-9]+}", tanukirpc.NewHandler(validateGetProjectTask))
+		})
+		sub := tanukirpc.NewRouter(struct{}{})
+		sub.Get("/tasks/{taskID}", tanukirpc.NewHandler(validateGetProjectTask))
+		r.Mount("/mounted/{projectID}", sub)
+		r.Get("/files/*", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*tanukirpc.RawResponse, error) {
+			return nil, nil
+		}))
+
+		assert.NoError(t, r.Validate())
+	})
+	t.Run("duplicate routes", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/tasks/{id}", tanukirpc.NewHandler(validateGetTask))
+		r.With(func(next http.Handler) http.Handler { return next }).Get("/tasks/{id}", tanukirpc.NewHandler(validateGetTask))
+		r.Route("/projects", func(r *tanukirpc.Router[struct{}]) {
+			r.Post("/", tanukirpc.NewHandler(validateCreateProject))
+		})
+		r.Post("/projects", tanukirpc.NewHandler(validateCreateProject))
+
+		err := r.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /tasks/{id}: handler is registered more than once")
+		assert.NotContains(t, err.Error(), "POST /projects")
+	})
+	t.Run("nil handlers", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/nil", nil)
+		var h tanukirpc.HandlerFunc[struct{}, *validateTaskResponse, struct{}]
+		r.Get("/nil-func", tanukirpc.NewHandler(h))
+		r.Get("/authorized-nil", tanukirpc.Authorize[struct{}](nil))
+
+		err := r.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /nil: handler is nil")
+		assert.Contains(t, err.Error(), "GET /nil-func: handler is nil")
+		assert.Contains(t, err.Error(), "GET /authorized-nil: handler is nil")
+	})
+	t.Run("urlparam is not in the pattern", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/tasks/{taskID}", tanukirpc.NewHandler(validateGetTask))
+		r.Route("/projects", func(r *tanukirpc.Router[struct{}]) {
+			r.Get("/{id}/tasks/{taskID}", tanukirpc.NewHandler(validateGetProjectTask))
+		})
+
+		err := r.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `GET /tasks/{taskID}: urlparam "id" of the request tanukirpc_test.validateTaskRequest is not in the pattern`)
+		assert.Contains(t, err.Error(), `GET /projects/{id}/tasks/{taskID}: urlparam "projectID"`)
+		assert.NotContains(t, err.Error(), `"cursor"`)
+	})
+	t.Run("urlparam of the mounted router", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		sub := tanukirpc.NewRouter(struct{}{})
+		sub.Get("/tasks/{taskID}", tanukirpc.NewHandler(validateGetProjectTask))
+		r.Mount("/mounted", sub)
+
+		err := r.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `GET /mounted/tasks/{taskID}: urlparam "projectID"`)
+	})
+	t.Run("response can not be encoded", func(t *testing.T) {
+		r := tanukirpc.NewRouter(struct{}{})
+		r.Get("/stream", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*validateStreamResponse, error) {
+			return nil, nil
+		}))
+		r.Get("/complex", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (map[string]complex128, error) {
+			return nil, nil
+		}))
+		r.Get("/ignored", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req struct{}) (*struct {
+			Updates chan string `json:"-"`
+			Expires time.Time   `json:"expires"`
+		}, error) {
+			return nil, nil
+		}))
+
+		err := r.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /stream: failed to encode the response *tanukirpc_test.validateStreamResponse: codec json: field Updates of tanukirpc_test.validateStreamResponse: unsupported type: chan string")
+		assert.Contains(t, err.Error(), "GET /complex: failed to encode the response map[string]complex128: codec json: unsupported type: complex128")
+		assert.NotContains(t, err.Error(), "/ignored")
+	})
+}
+
+func TestListenAndServeValidatesRoutes(t *testing.T) {
+	r := tanukirpc.NewRouter(struct{}{})
+	r.Get("/tasks/{taskID}", tanukirpc.NewHandler(validateGetTask))
+
+	err := r.ListenAndServe(context.Background(), "127.0.0.1:0", tanukirpc.WithDisableTanukiupProxy())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid routes:")
+	assert.Contains(t, err.Error(), `urlparam "id"`)
+}
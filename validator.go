@@ -1,6 +1,7 @@
 package tanukirpc
 
 import (
+	"fmt"
 	"net/http"
 	"reflect"
 	"sync"
@@ -42,35 +43,62 @@ func (v *ValidateError) Unwrap() error {
 	return v.err
 }
 
+// ResponseValidateError is an error that occurs when the response is invalid.
+// This error is reported only when the WithResponseValidation option is enabled.
+type ResponseValidateError struct {
+	err error
+}
+
+func (v *ResponseValidateError) Error() string {
+	return fmt.Sprintf("invalid response: %v", v.err)
+}
+
+func (v *ResponseValidateError) Unwrap() error {
+	return v.err
+}
+
+var hasValidateTagCache sync.Map
+
 func hasValidateTag(req any) bool {
 	v := reflect.ValueOf(req)
-	t := v.Type()
 	if v.Kind() == reflect.Pointer && v.IsNil() {
 		return false
 	}
-	if v.Kind() == reflect.Pointer {
-		v = v.Elem()
+	t := v.Type()
+	if cached, ok := hasValidateTagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	has := hasValidateTagType(t)
+	hasValidateTagCache.Store(t, has)
+	return has
+}
+
+var validatableType = reflect.TypeFor[Validatable]()
+
+// hasValidateTagType reports whether the type has the validate struct tags.
+func hasValidateTagType(t reflect.Type) bool {
+	return hasValidateTagTypeVisited(t, map[reflect.Type]struct{}{})
+}
+
+func hasValidateTagTypeVisited(t reflect.Type, visited map[reflect.Type]struct{}) bool {
+	if t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
 	if t.Kind() != reflect.Struct {
 		return false
 	}
-	for i := 0; i < v.NumField(); i++ {
-		fv := v.Field(i)
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+	for i := 0; i < t.NumField(); i++ {
 		ft := t.Field(i)
-		ftt := ft.Type
-		if ftt.Kind() == reflect.Pointer {
-			ftt = ftt.Elem()
-		}
-		if ftt.Kind() == reflect.Struct && ft.IsExported() {
-			if hasValidateTag(fv.Interface()) {
-				return true
-			}
-		}
-
 		if _, ok := ft.Tag.Lookup("validate"); ok {
 			return true
 		}
+		if ft.IsExported() && hasValidateTagTypeVisited(ft.Type, visited) {
+			return true
+		}
 	}
 	return false
 }
@@ -91,5 +119,6 @@ func newStructValidator(req any) *structValidator {
 }
 
 func (s *structValidator) Validate() error {
+	defer defaultValidator.Put(s.val)
 	return s.val.Struct(s.req)
 }
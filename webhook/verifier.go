@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMACSHA256 returns the Verifier that compares the hex HMAC-SHA256 of the body with the value of the header after the prefix,
+// like "sha256=" of GitHub. The signed timestamp is not verified.
+func HMACSHA256(secret []byte, header, prefix string) Verifier {
+	return VerifierFunc(func(h http.Header, body []byte) (*Signature, error) {
+		value := h.Get(header)
+		if value == "" {
+			return nil, ErrMissingSignature
+		}
+		sig, ok := strings.CutPrefix(value, prefix)
+		if !ok || !equalHMAC(secret, body, sig) {
+			return nil, ErrInvalidSignature
+		}
+		return &Signature{ID: sig}, nil
+	})
+}
+
+// GitHub returns the Verifier of the X-Hub-Signature-256 header of GitHub.
+// The ID of the Signature is the X-GitHub-Delivery header.
+func GitHub(secret []byte) Verifier {
+	v := HMACSHA256(secret, "X-Hub-Signature-256", "sha256=")
+	return VerifierFunc(func(h http.Header, body []byte) (*Signature, error) {
+		sig, err := v.Verify(h, body)
+		if err != nil {
+			return nil, err
+		}
+		if id := h.Get("X-GitHub-Delivery"); id != "" {
+			sig.ID = id
+		}
+		return sig, nil
+	})
+}
+
+// Stripe returns the Verifier of the Stripe-Signature header like "t=1492774577,v1=5257a869...".
+// The payload is signed with the timestamp t, and one of the v1 signatures must match it.
+func Stripe(secret []byte) Verifier {
+	return VerifierFunc(func(h http.Header, body []byte) (*Signature, error) {
+		value := h.Get("Stripe-Signature")
+		if value == "" {
+			return nil, ErrMissingSignature
+		}
+		var (
+			timestamp  string
+			signatures []string
+		)
+		for _, pair := range strings.Split(value, ",") {
+			k, v, _ := strings.Cut(pair, "=")
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				signatures = append(signatures, v)
+			}
+		}
+		ts, err := parseUnix(timestamp)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		payload := append([]byte(timestamp+"."), body...)
+		for _, sig := range signatures {
+			if equalHMAC(secret, payload, sig) {
+				return &Signature{Timestamp: ts, ID: sig}, nil
+			}
+		}
+		return nil, ErrInvalidSignature
+	})
+}
+
+// Slack returns the Verifier of the X-Slack-Signature and X-Slack-Request-Timestamp headers of Slack.
+func Slack(secret []byte) Verifier {
+	return VerifierFunc(func(h http.Header, body []byte) (*Signature, error) {
+		value, timestamp := h.Get("X-Slack-Signature"), h.Get("X-Slack-Request-Timestamp")
+		if value == "" || timestamp == "" {
+			return nil, ErrMissingSignature
+		}
+		ts, err := parseUnix(timestamp)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		sig, ok := strings.CutPrefix(value, "v0=")
+		payload := append([]byte("v0:"+timestamp+":"), body...)
+		if !ok || !equalHMAC(secret, payload, sig) {
+			return nil, ErrInvalidSignature
+		}
+		return &Signature{Timestamp: ts, ID: sig}, nil
+	})
+}
+
+// Ed25519 returns the Verifier of the hex Ed25519 signature in signatureHeader over the timestamp in timestampHeader
+// followed by the body, like the X-Signature-Ed25519 and X-Signature-Timestamp headers of Discord.
+// The timestamp is the unix time in seconds.
+func Ed25519(publicKey ed25519.PublicKey, signatureHeader, timestampHeader string) Verifier {
+	return VerifierFunc(func(h http.Header, body []byte) (*Signature, error) {
+		value, timestamp := h.Get(signatureHeader), h.Get(timestampHeader)
+		if value == "" || timestamp == "" {
+			return nil, ErrMissingSignature
+		}
+		ts, err := parseUnix(timestamp)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		sig, err := hex.DecodeString(value)
+		if err != nil || !ed25519.Verify(publicKey, append([]byte(timestamp), body...), sig) {
+			return nil, ErrInvalidSignature
+		}
+		return &Signature{Timestamp: ts, ID: value}, nil
+	})
+}
+
+func equalHMAC(secret, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func parseUnix(s string) (time.Time, error) {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -0,0 +1,200 @@
+// Package webhook verifies the signatures of the webhook requests before they are handled by the Router.
+//
+// Verify is the middleware that reads the raw body, verifies it by the Verifier and gives the body back to the handler,
+// so the handler receives the typed request struct bound by the codecs as usual:
+//
+//	r.With(webhook.Verify(webhook.GitHub(secret))).Post("/webhooks/github", tanukirpc.NewHandler(onPush))
+//
+// The request that fails the verification is responded with 401 Unauthorized, and the handler is not called.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+)
+
+const (
+	defaultTolerance = 5 * time.Minute
+	defaultMaxBytes  = 1 << 20
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature does not match the body.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrMissingSignature is returned when the request has no signature.
+	ErrMissingSignature = errors.New("missing signature")
+	// ErrTimestampOutOfTolerance is returned when the signed timestamp is too old or too new.
+	ErrTimestampOutOfTolerance = errors.New("timestamp is out of tolerance")
+	// ErrReplayed is returned when the request has been already handled.
+	ErrReplayed = errors.New("replayed request")
+)
+
+// Signature is the verified signature of the request.
+type Signature struct {
+	// Timestamp is the time when the payload is signed. It is zero if the scheme does not sign the timestamp.
+	Timestamp time.Time
+	// ID identifies the request in the replay cache, like the delivery id or the signature itself.
+	ID string
+}
+
+// Verifier verifies the signature of the body with the header of the request.
+type Verifier interface {
+	Verify(header http.Header, body []byte) (*Signature, error)
+}
+
+// VerifierFunc is the function that implements Verifier.
+type VerifierFunc func(header http.Header, body []byte) (*Signature, error)
+
+func (f VerifierFunc) Verify(header http.Header, body []byte) (*Signature, error) {
+	return f(header, body)
+}
+
+// ReplayCache remembers the handled requests to reject the replayed ones.
+type ReplayCache interface {
+	// Add adds the id that expires at expiresAt. It returns false if the id has been already added and not expired.
+	Add(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+type options struct {
+	tolerance   time.Duration
+	maxBytes    int64
+	replayCache ReplayCache
+}
+
+// Option is the option of Verify.
+type Option func(*options)
+
+// WithTolerance sets the tolerance of the signed timestamp. The default is 5 minutes.
+// It is also the duration to remember the requests in the replay cache.
+func WithTolerance(d time.Duration) Option {
+	return func(o *options) {
+		o.tolerance = d
+	}
+}
+
+// WithMaxBytes sets the limit of the size of the body. The default is 1 MiB.
+func WithMaxBytes(n int64) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// WithReplayCache rejects the requests that have the same Signature.ID as the handled ones.
+// The requests are remembered until the tolerance has passed since the signed timestamp, or since they are received
+// if the scheme does not sign the timestamp.
+func WithReplayCache(cache ReplayCache) Option {
+	return func(o *options) {
+		o.replayCache = cache
+	}
+}
+
+type signatureContextKey struct{}
+
+// SignatureFromContext returns the verified signature of the request.
+func SignatureFromContext(ctx context.Context) (*Signature, bool) {
+	sig, ok := ctx.Value(signatureContextKey{}).(*Signature)
+	return sig, ok
+}
+
+// Verify returns the middleware that verifies the requests by v.
+func Verify(v Verifier, opts ...Option) func(http.Handler) http.Handler {
+	o := &options{
+		tolerance: defaultTolerance,
+		maxBytes:  defaultMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(req.Body, o.maxBytes+1))
+			if err != nil {
+				writeError(w, req, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+				return
+			}
+			if int64(len(body)) > o.maxBytes {
+				writeError(w, req, http.StatusRequestEntityTooLarge, fmt.Errorf("body is too large: limit=%d", o.maxBytes))
+				return
+			}
+			sig, err := o.verify(req, body, v)
+			if err != nil {
+				writeError(w, req, http.StatusUnauthorized, err)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+			ctx := context.WithValue(req.Context(), signatureContextKey{}, sig)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+func (o *options) verify(req *http.Request, body []byte, v Verifier) (*Signature, error) {
+	sig, err := v.Verify(req.Header, body)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(o.tolerance)
+	if !sig.Timestamp.IsZero() {
+		if d := now.Sub(sig.Timestamp); d > o.tolerance || d < -o.tolerance {
+			return nil, ErrTimestampOutOfTolerance
+		}
+		expiresAt = sig.Timestamp.Add(o.tolerance)
+	}
+	if o.replayCache != nil {
+		ok, err := o.replayCache.Add(req.Context(), sig.ID, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add to replay cache: %w", err)
+		}
+		if !ok {
+			return nil, ErrReplayed
+		}
+	}
+	return sig, nil
+}
+
+// writeError writes the error in the same JSON as the error responses of the Router.
+func writeError(w http.ResponseWriter, req *http.Request, status int, err error) {
+	slog.WarnContext(req.Context(), "webhook verification failed", slog.String("path", req.URL.Path), slog.Any("error", err))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(tanukirpc.ErrorMessage{Error: tanukirpc.ErrorBody{Message: err.Error()}})
+}
+
+// MemoryReplayCache is the ReplayCache in memory. It is not shared between the processes.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewMemoryReplayCache returns the ReplayCache in memory.
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{entries: make(map[string]time.Time)}
+}
+
+func (c *MemoryReplayCache) Add(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, exp := range c.entries {
+		if !now.Before(exp) {
+			delete(c.entries, k)
+		}
+	}
+	if _, ok := c.entries[id]; ok {
+		return false, nil
+	}
+	c.entries[id] = expiresAt
+	return true, nil
+}
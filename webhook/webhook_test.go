@@ -0,0 +1,152 @@
+package webhook_test
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mackee/tanukirpc"
+	"github.com/mackee/tanukirpc/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("tanuki")
+
+type pushEvent struct {
+	Ref string `json:"ref" validate:"required"`
+}
+
+type pushResponse struct {
+	Ref string `json:"ref"`
+	ID  string `json:"id"`
+}
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newServer(t *testing.T, v webhook.Verifier, opts ...webhook.Option) *httptest.Server {
+	t.Helper()
+	r := tanukirpc.NewRouter(struct{}{})
+	r.With(webhook.Verify(v, opts...)).Post("/webhook", tanukirpc.NewHandler(func(ctx tanukirpc.Context[struct{}], req *pushEvent) (*pushResponse, error) {
+		var id string
+		if sig, ok := webhook.SignatureFromContext(ctx); ok {
+			id = sig.ID
+		}
+		return &pushResponse{Ref: req.Ref, ID: id}, nil
+	}))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func post(t *testing.T, server *httptest.Server, body string, header map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/webhook", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestGitHub(t *testing.T) {
+	server := newServer(t, webhook.GitHub(secret), webhook.WithReplayCache(webhook.NewMemoryReplayCache()))
+	body := `{"ref":"refs/heads/main"}`
+	header := map[string]string{"X-Hub-Signature-256": "sha256=" + sign(body), "X-GitHub-Delivery": "delivery-1"}
+
+	status, res := post(t, server, body, header)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"ref":"refs/heads/main","id":"delivery-1"}`, res)
+
+	status, res = post(t, server, body, header)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.JSONEq(t, `{"error":{"message":"replayed request"}}`, res)
+
+	status, _ = post(t, server, `{"ref":"refs/heads/evil"}`, map[string]string{"X-Hub-Signature-256": "sha256=" + sign(body)})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = post(t, server, body, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// the body is given back to the codecs, so the validation works after the verification
+	status, _ = post(t, server, `{}`, map[string]string{"X-Hub-Signature-256": "sha256=" + sign(`{}`)})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestTimestampedVerifiers(t *testing.T) {
+	body := `{"ref":"refs/heads/main"}`
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		verifier webhook.Verifier
+		header   func(ts string) map[string]string
+	}{
+		{
+			name:     "stripe",
+			verifier: webhook.Stripe(secret),
+			header: func(ts string) map[string]string {
+				return map[string]string{"Stripe-Signature": "t=" + ts + ",v1=" + sign("dummy") + ",v1=" + sign(ts+"."+body)}
+			},
+		},
+		{
+			name:     "slack",
+			verifier: webhook.Slack(secret),
+			header: func(ts string) map[string]string {
+				return map[string]string{"X-Slack-Signature": "v0=" + sign("v0:"+ts+":"+body), "X-Slack-Request-Timestamp": ts}
+			},
+		},
+		{
+			name:     "ed25519",
+			verifier: webhook.Ed25519(pub, "X-Signature-Ed25519", "X-Signature-Timestamp"),
+			header: func(ts string) map[string]string {
+				sig := ed25519.Sign(priv, []byte(ts+body))
+				return map[string]string{"X-Signature-Ed25519": hex.EncodeToString(sig), "X-Signature-Timestamp": ts}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newServer(t, tc.verifier)
+			now := strconv.FormatInt(time.Now().Unix(), 10)
+			status, _ := post(t, server, body, tc.header(now))
+			assert.Equal(t, http.StatusOK, status)
+
+			old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+			status, res := post(t, server, body, tc.header(old))
+			assert.Equal(t, http.StatusUnauthorized, status)
+			assert.JSONEq(t, `{"error":{"message":"timestamp is out of tolerance"}}`, res)
+
+			status, _ = post(t, server, `{"ref":"refs/heads/evil"}`, tc.header(now))
+			assert.Equal(t, http.StatusUnauthorized, status)
+		})
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	server := newServer(t, webhook.GitHub(secret), webhook.WithMaxBytes(8))
+	body := `{"ref":"refs/heads/main"}`
+	status, _ := post(t, server, body, map[string]string{"X-Hub-Signature-256": "sha256=" + sign(body)})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+}